@@ -60,6 +60,42 @@ func WithRootMiddleware(m func(rw http.ResponseWriter, r *http.Request, next htt
 	}
 }
 
+// selfServiceRoutes are the public routes which tolerate a trailing slash or a different case
+// if `serve.public.route_normalization` is enabled.
+var selfServiceRoutes = []string{
+	login.RouteInitBrowserFlow,
+	login.RouteInitAPIFlow,
+	login.RouteGetFlow,
+	login.RouteSubmitFlow,
+
+	logout.RouteBrowser,
+
+	registration.RouteInitBrowserFlow,
+	registration.RouteInitAPIFlow,
+	registration.RouteGetFlow,
+	registration.RouteSubmitFlow,
+
+	settings.RouteInitBrowserFlow,
+	settings.RouteInitAPIFlow,
+	settings.RouteGetFlow,
+	settings.RouteSubmitFlow,
+
+	verification.RouteInitAPIFlow,
+	verification.RouteInitBrowserFlow,
+	verification.RouteGetFlow,
+	verification.RouteSubmitFlow,
+
+	recovery.RouteInitAPIFlow,
+	recovery.RouteInitBrowserFlow,
+	recovery.RouteGetFlow,
+	recovery.RouteSubmitFlow,
+
+	oidc.RouteCallback,
+
+	session.RouteWhoami,
+	errorx.RouteGet,
+}
+
 func ServePublic(r driver.Registry, wg *sync.WaitGroup, cmd *cobra.Command, args []string, opts ...Option) {
 	defer wg.Done()
 	modifiers := newOptions(opts)
@@ -76,7 +112,12 @@ func ServePublic(r driver.Registry, wg *sync.WaitGroup, cmd *cobra.Command, args
 	router := x.NewRouterPublic()
 	csrf := x.NewCSRFHandler(router, r)
 
+	n.UseFunc(x.HSTS(r, "public"))
+	n.UseFunc(x.MaxRequestBodySize(r))
 	n.UseFunc(x.CleanPath) // Prevent double slashes from breaking CSRF.
+	n.UseFunc(x.NormalizeRoutes(r, selfServiceRoutes))
+	n.UseFunc(x.TenantResolver(r))
+	n.UseFunc(x.MaintenanceMode(ctx, r, "public"))
 	r.WithCSRFHandler(csrf)
 	n.UseHandler(r.CSRFHandler())
 
@@ -101,7 +142,7 @@ func ServePublic(r driver.Registry, wg *sync.WaitGroup, cmd *cobra.Command, args
 	})
 
 	l.Printf("Starting the public httpd on: %s", server.Addr)
-	if err := graceful.Graceful(server.ListenAndServe, server.Shutdown); err != nil {
+	if err := graceful.Graceful(listenAndServe(server, c, "public"), server.Shutdown); err != nil {
 		l.Fatalln("Failed to gracefully shutdown public httpd")
 	}
 	l.Println("Public httpd was shutdown gracefully")
@@ -122,6 +163,10 @@ func ServeAdmin(r driver.Registry, wg *sync.WaitGroup, cmd *cobra.Command, args
 	router := x.NewRouterAdmin()
 	r.RegisterAdminRoutes(ctx, router)
 	n.Use(reqlog.NewMiddlewareFromLogger(l, "admin#"+c.SelfPublicURL(nil).String()))
+	n.UseFunc(x.HSTS(r, "admin"))
+	n.UseFunc(x.AdminIPFilter(r))
+	n.UseFunc(x.TenantResolver(r))
+	n.UseFunc(x.MaintenanceMode(ctx, r, "admin"))
 	n.Use(sqa(cmd, r))
 	n.Use(r.PrometheusManager())
 
@@ -136,12 +181,25 @@ func ServeAdmin(r driver.Registry, wg *sync.WaitGroup, cmd *cobra.Command, args
 	})
 
 	l.Printf("Starting the admin httpd on: %s", server.Addr)
-	if err := graceful.Graceful(server.ListenAndServe, server.Shutdown); err != nil {
+	if err := graceful.Graceful(listenAndServe(server, c, "admin"), server.Shutdown); err != nil {
 		l.Fatalln("Failed to gracefully shutdown admin httpd")
 	}
 	l.Println("Admin httpd was shutdown gracefully")
 }
 
+// listenAndServe serves over HTTPS if `serve.<iface>.tls` has a certificate and a key.
+func listenAndServe(server *http.Server, c *config.Config, iface string) func() error {
+	certPath, keyPath, ok := c.TLS(iface)
+	if !ok {
+		return server.ListenAndServe
+	}
+
+	server.TLSConfig.MinVersion = c.TLSMinVersion(iface)
+	return func() error {
+		return server.ListenAndServeTLS(certPath, keyPath)
+	}
+}
+
 func sqa(cmd *cobra.Command, d driver.Registry) *metricsx.Service {
 	// Creates only ones
 	// instance
@@ -210,13 +268,19 @@ func sqa(cmd *cobra.Command, d driver.Registry) *metricsx.Service {
 func bgTasks(d driver.Registry, wg *sync.WaitGroup, cmd *cobra.Command, args []string) {
 	defer wg.Done()
 
+	// The courier is watched in the foreground so that ServeAll waits for in-flight messages to be
+	// delivered before exiting.
 	if d.Config(cmd.Context()).IsBackgroundCourierEnabled() {
-		go courier.Watch(cmd.Context(), d)
+		courier.Watch(cmd.Context(), d)
 	}
 }
 
 func ServeAll(d driver.Registry, opts ...Option) func(cmd *cobra.Command, args []string) {
 	return func(cmd *cobra.Command, args []string) {
+		if err := d.IdentityTraitsSchemas(cmd.Context()).Compile(); err != nil {
+			d.Logger().WithError(err).Fatal("Unable to load the identity schemas.")
+		}
+
 		var wg sync.WaitGroup
 		wg.Add(3)
 		go ServePublic(d, &wg, cmd, args, opts...)
@@ -0,0 +1,118 @@
+package identities
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/google/go-jsonnet"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/ory/x/cmdx"
+)
+
+const (
+	FlagInputFormat = "input-format"
+	FlagMapping     = "mapping"
+	FlagBatchSize   = "batch-size"
+
+	inputFormatJSON = "json"
+	inputFormatCSV  = "csv"
+)
+
+// readCSVIdentities reads identities from CSV files (or STD_IN) and maps every row to an identity
+// using the Jsonnet mapping. The first line of every file is expected to hold the column names.
+//
+// The mapping receives the row as `std.extVar('row')`, an object of column name to cell value,
+// and must return the identity payload (e.g. `{schema_id: "default", traits: {email: std.extVar('row').email}}`).
+//
+// Rows which can not be mapped are returned as failed instead of aborting the whole file.
+func readCSVIdentities(cmd *cobra.Command, args []string, mappingFile string) (map[string]string, map[string]error, error) {
+	mapping, err := ioutil.ReadFile(mappingFile)
+	if err != nil {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "%s: Could not open mapping file: %s\n", mappingFile, err)
+		return nil, nil, cmdx.FailSilently(cmd)
+	}
+
+	rawIdentities := make(map[string]string)
+	failed := make(map[string]error)
+	mapFile := func(name string, r io.Reader) error {
+		cr := csv.NewReader(r)
+		cr.TrimLeadingSpace = true
+
+		header, err := cr.Read()
+		if err != nil {
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "%s: Could not read CSV header: %s\n", name, err)
+			return cmdx.FailSilently(cmd)
+		}
+
+		// Line 1 is the header.
+		for line := 2; ; line++ {
+			src := fmt.Sprintf("%s[%d]", name, line)
+			record, err := cr.Read()
+			if errors.Is(err, io.EOF) {
+				return nil
+			} else if err != nil {
+				failed[src] = errors.Wrap(err, "could not parse row")
+				if _, ok := err.(*csv.ParseError); ok {
+					continue
+				}
+				return nil
+			}
+
+			row := make(map[string]string, len(header))
+			for k, column := range header {
+				if k < len(record) {
+					row[column] = record[k]
+				}
+			}
+
+			i, err := mapCSVRow(mappingFile, string(mapping), row)
+			if err != nil {
+				failed[src] = err
+				continue
+			}
+			rawIdentities[src] = i
+		}
+	}
+
+	if len(args) == 0 {
+		if err := mapFile("STD_IN", cmd.InOrStdin()); err != nil {
+			return nil, nil, err
+		}
+		return rawIdentities, failed, nil
+	}
+
+	for _, fn := range args {
+		f, err := os.Open(fn)
+		if err != nil {
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "%s: Could not open CSV file: %s\n", fn, err)
+			return nil, nil, cmdx.FailSilently(cmd)
+		}
+		err = mapFile(fn, f)
+		_ = f.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return rawIdentities, failed, nil
+}
+
+func mapCSVRow(mappingFile, mapping string, row map[string]string) (string, error) {
+	input, err := json.Marshal(row)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	vm := jsonnet.MakeVM()
+	vm.ExtCode("row", string(input))
+	evaluated, err := vm.EvaluateSnippet(mappingFile, mapping)
+	if err != nil {
+		return "", errors.Wrap(err, "could not evaluate mapping")
+	}
+	return evaluated, nil
+}
@@ -5,10 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 
 	"github.com/ory/kratos-client-go"
 
 	"github.com/ory/x/cmdx"
+	"github.com/ory/x/flagx"
+	"github.com/pkg/errors"
 
 	"github.com/spf13/cobra"
 
@@ -30,11 +34,37 @@ EOF
 
 $ kratos identities import file.json
 # Alternatively:
-$ cat file.json | kratos identities import`,
+$ cat file.json | kratos identities import
+
+$ cat > ./users.csv <<EOF
+email,name
+foo@example.com,Foo
+EOF
+
+$ cat > ./mapping.jsonnet <<EOF
+local row = std.extVar('row');
+{
+  schema_id: "default",
+  traits: {
+    email: row.email,
+    name: row.name,
+  },
+}
+EOF
+
+$ kratos identities import --input-format csv --mapping mapping.jsonnet users.csv`,
 	Long: `Import identities from files or STD_IN.
 
 Files can contain only a single or an array of identities. The validity of files can be tested beforehand using "... identities validate".
 
+Using "--input-format csv", identities are read from CSV files instead. The first line of every CSV file must contain the
+column names. Every row is mapped to an identity using the Jsonnet file given by "--mapping", which receives the row
+as "std.extVar('row')". Rows which can not be mapped, are invalid or fail to import are reported without aborting the import.
+The flag is called "--input-format" because "--format" already sets the output format of all identities commands.
+
+Identities are created using the batch endpoint of the admin API, "--batch-size" identities per request. The batch
+size must not exceed "identity.batch.max_size" of the server.
+
 WARNING: Importing credentials is not yet supported.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		c := cliclient.NewClient(cmd)
@@ -42,16 +72,60 @@ WARNING: Importing credentials is not yet supported.`,
 		imported := make([]kratos.Identity, 0, len(args))
 		failed := make(map[string]error)
 
-		is, err := readIdentities(cmd, args)
+		batchSize := flagx.MustGetInt(cmd, FlagBatchSize)
+		if batchSize < 1 {
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Flag --%s must be at least 1.\n", FlagBatchSize)
+			return cmdx.FailSilently(cmd)
+		}
+
+		getSchema := func(ctx context.Context, id string) (map[string]interface{}, *http.Response, error) {
+			return c.PublicApi.GetSchema(ctx, id).Execute()
+		}
+
+		var is map[string]string
+		var err error
+		format := flagx.MustGetString(cmd, FlagInputFormat)
+		switch format {
+		case inputFormatJSON:
+			is, err = readIdentities(cmd, args)
+		case inputFormatCSV:
+			mapping := flagx.MustGetString(cmd, FlagMapping)
+			if mapping == "" {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Flag --%s is required when importing from CSV.\n", FlagMapping)
+				return cmdx.FailSilently(cmd)
+			}
+			is, failed, err = readCSVIdentities(cmd, args, mapping)
+		default:
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Unknown input format \"%s\", expected one of: %s, %s\n", format, inputFormatJSON, inputFormatCSV)
+			return cmdx.FailSilently(cmd)
+		}
 		if err != nil {
 			return err
 		}
 
-		for src, i := range is {
-			err = validateIdentity(cmd, src, i, func(ctx context.Context, id string) (map[string]interface{}, *http.Response, error) {
-				return c.PublicApi.GetSchema(ctx, id).Execute()
-			})
-			if err != nil {
+		srcs := make([]string, 0, len(is))
+		for src := range is {
+			srcs = append(srcs, src)
+		}
+		sort.Strings(srcs)
+
+		batch := make([]kratos.CreateIdentity, 0, len(srcs))
+		batchSrcs := make([]string, 0, len(srcs))
+		for _, src := range srcs {
+			i := is[src]
+			if format == inputFormatCSV {
+				// Report invalid rows but keep importing the rest of the file.
+				var validationErrs []error
+				if err := findValidationErrors(cmd, src, i, getSchema, func(err error) {
+					validationErrs = append(validationErrs, err)
+				}); err != nil {
+					return err
+				}
+				if len(validationErrs) > 0 {
+					failed[src] = errors.Errorf("identity is not valid: %s", strings.Join(validationMessages(validationErrs), "; "))
+					continue
+				}
+			} else if err := validateIdentity(cmd, src, i, getSchema); err != nil {
 				return err
 			}
 
@@ -61,19 +135,31 @@ WARNING: Importing credentials is not yet supported.`,
 				_, _ = fmt.Fprintln(cmd.ErrOrStderr(), "STD_IN: Could not parse identity")
 				return cmdx.FailSilently(cmd)
 			}
+			batch = append(batch, params)
+			batchSrcs = append(batchSrcs, src)
+		}
+
+		for start := 0; start < len(batch); start += batchSize {
+			end := start + batchSize
+			if end > len(batch) {
+				end = len(batch)
+			}
 
-			// TODO merge
-			//
-			//resp, err := c.Admin.CreateIdentity(&admin.CreateIdentityParams{
-			//	Body:       &params,
-			//	Context:    cmd.Context(),
-			//	HTTPClient: cliclient.NewHTTPClient(cmd),
-			//})
-			ident, _, err := c.AdminApi.CreateIdentity(cmd.Context()).CreateIdentity(params).Execute()
+			res, _, err := c.AdminApi.CreateIdentities(cmd.Context()).CreateIdentity(batch[start:end]).Execute()
 			if err != nil {
-				failed[src] = err
-			} else {
-				imported = append(imported, *ident)
+				for _, src := range batchSrcs[start:end] {
+					failed[src] = err
+				}
+				continue
+			}
+
+			for k, result := range res.Identities {
+				src, params := batchSrcs[start+k], batch[start+k]
+				if result.Status != "created" || result.IdentityId == nil {
+					failed[src] = batchError(result)
+					continue
+				}
+				imported = append(imported, kratos.Identity{Id: *result.IdentityId, SchemaId: params.SchemaId, Traits: params.Traits})
 			}
 		}
 		if len(imported) == 1 {
@@ -90,3 +176,22 @@ WARNING: Importing credentials is not yet supported.`,
 		return nil
 	},
 }
+
+func init() {
+	ImportCmd.Flags().String(FlagInputFormat, inputFormatJSON, "The format of the input files, one of: json, csv")
+	ImportCmd.Flags().String(FlagMapping, "", "Path to a Jsonnet file mapping CSV rows to identities, required if --input-format is csv")
+	ImportCmd.Flags().Int(FlagBatchSize, 1000, "The number of identities to create per request, must not exceed identity.batch.max_size of the server")
+}
+
+// batchError returns the error of an identity which the batch endpoint did not create.
+func batchError(result kratos.CreateIdentitiesResult) error {
+	if result.Error == nil {
+		return errors.Errorf("identity was not created: %s", result.Status)
+	}
+
+	message := result.Error.GetMessage()
+	if reason := result.Error.GetReason(); reason != "" {
+		message += ": " + reason
+	}
+	return errors.New(message)
+}
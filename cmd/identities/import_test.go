@@ -126,4 +126,80 @@ func TestImportCmd(t *testing.T) {
 		assert.Contains(t, stdErr, "STD_IN[0]: not valid")
 		assert.Len(t, stdOut, 0)
 	})
+
+	t.Run("case=imports identities from CSV and reports failing rows", func(t *testing.T) {
+		mapping, err := ioutil.TempFile("", "*.jsonnet")
+		require.NoError(t, err)
+		_, err = mapping.WriteString(`local row = std.extVar('row');
+{
+  schema_id: "default",
+  traits: if row.key == "invalid" then { unknown: row.key } else { testKey: row.key },
+}`)
+		require.NoError(t, err)
+		require.NoError(t, mapping.Close())
+
+		require.NoError(t, ImportCmd.Flags().Set(FlagInputFormat, "csv"))
+		require.NoError(t, ImportCmd.Flags().Set(FlagMapping, mapping.Name()))
+		t.Cleanup(func() {
+			require.NoError(t, ImportCmd.Flags().Set(FlagInputFormat, "json"))
+			require.NoError(t, ImportCmd.Flags().Set(FlagMapping, ""))
+		})
+
+		stdOut, stdErr, err := exec(ImportCmd, bytes.NewBufferString("key\nfoo\ninvalid\nbar\n"))
+		assert.True(t, errors.Is(err, cmdx.ErrNoPrintButFail))
+		assert.Contains(t, stdErr, `STD_IN[3]: identity is not valid: traits: additionalProperties "unknown" not allowed`)
+		assert.NotContains(t, stdErr, "STD_IN[2]:")
+		assert.NotContains(t, stdErr, "STD_IN[4]:")
+
+		var keys []string
+		for _, i := range gjson.Parse(stdOut).Array() {
+			id, err := uuid.FromString(i.Get("id").String())
+			require.NoError(t, err)
+			actual, err := reg.Persister().GetIdentity(context.Background(), id)
+			require.NoError(t, err)
+			keys = append(keys, gjson.GetBytes(actual.Traits, "testKey").String())
+		}
+		assert.ElementsMatch(t, []string{"foo", "bar"}, keys)
+	})
+
+	t.Run("case=imports identities in batches", func(t *testing.T) {
+		require.NoError(t, ImportCmd.Flags().Set(FlagBatchSize, "2"))
+		t.Cleanup(func() {
+			require.NoError(t, ImportCmd.Flags().Set(FlagBatchSize, "1000"))
+		})
+
+		i := make([]kratos.CreateIdentity, 5)
+		for k := range i {
+			i[k] = kratos.CreateIdentity{
+				SchemaId: config.DefaultIdentityTraitsSchemaID,
+				Traits:   map[string]interface{}{},
+			}
+		}
+		ij, err := json.Marshal(i)
+		require.NoError(t, err)
+
+		stdOut, stdErr, err := exec(ImportCmd, bytes.NewBuffer(ij))
+		require.NoError(t, err, "%s %s", stdOut, stdErr)
+
+		ids := gjson.Parse(stdOut).Array()
+		require.Len(t, ids, 5, stdOut)
+		for _, raw := range ids {
+			id, err := uuid.FromString(raw.Get("id").String())
+			require.NoError(t, err)
+			_, err = reg.Persister().GetIdentity(context.Background(), id)
+			assert.NoError(t, err)
+		}
+	})
+
+	t.Run("case=fails to import from CSV without mapping", func(t *testing.T) {
+		require.NoError(t, ImportCmd.Flags().Set(FlagInputFormat, "csv"))
+		t.Cleanup(func() {
+			require.NoError(t, ImportCmd.Flags().Set(FlagInputFormat, "json"))
+		})
+
+		stdOut, stdErr, err := exec(ImportCmd, bytes.NewBufferString("key\nfoo\n"))
+		assert.True(t, errors.Is(err, cmdx.ErrNoPrintButFail))
+		assert.Contains(t, stdErr, "--mapping is required")
+		assert.Len(t, stdOut, 0)
+	})
 }
@@ -60,6 +60,26 @@ type schemaGetter = func(ctx context.Context, id string) (map[string]interface{}
 // 1. the swagger payload definition and
 // 2. the remote custom identity schema.
 func validateIdentity(cmd *cobra.Command, src, i string, getRemoteSchema schemaGetter) error {
+	var foundValidationErrors bool
+	err := findValidationErrors(cmd, src, i, getRemoteSchema, func(err error) {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "%s: not valid\n", src)
+		jsonschemax.FormatValidationErrorForCLI(cmd.ErrOrStderr(), []byte(i), err)
+		foundValidationErrors = true
+	})
+	if err != nil {
+		return err
+	}
+
+	if foundValidationErrors {
+		return cmdx.FailSilently(cmd)
+	}
+	return nil
+}
+
+// findValidationErrors validates the json payload against the swagger payload definition and the remote
+// custom identity schema and calls report for every validation error. The returned error is only set if
+// the payload could not be validated at all.
+func findValidationErrors(cmd *cobra.Command, src, i string, getRemoteSchema schemaGetter, report func(err error)) error {
 	swaggerSchema, ok := schemas[createIdentityPath]
 	if !ok {
 		// add swagger schema
@@ -80,12 +100,8 @@ func validateIdentity(cmd *cobra.Command, src, i string, getRemoteSchema schemaG
 	}
 
 	// validate against swagger definition
-	var foundValidationErrors bool
-	err := swaggerSchema.Validate(bytes.NewBufferString(i))
-	if err != nil {
-		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "%s: not valid\n", src)
-		jsonschemax.FormatValidationErrorForCLI(cmd.ErrOrStderr(), []byte(i), err)
-		foundValidationErrors = true
+	if err := swaggerSchema.Validate(bytes.NewBufferString(i)); err != nil {
+		report(err)
 	}
 
 	// get custom identity schema id
@@ -120,15 +136,34 @@ func validateIdentity(cmd *cobra.Command, src, i string, getRemoteSchema schemaG
 	}
 
 	// validate against custom identity schema
-	err = customSchema.Validate(bytes.NewBufferString(i))
-	if err != nil {
-		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "%s: not valid\n", src)
-		jsonschemax.FormatValidationErrorForCLI(cmd.ErrOrStderr(), []byte(i), err)
-		foundValidationErrors = true
+	if err := customSchema.Validate(bytes.NewBufferString(i)); err != nil {
+		report(err)
 	}
 
-	if foundValidationErrors {
-		return cmdx.FailSilently(cmd)
-	}
 	return nil
 }
+
+// validationMessages returns one message per invalid value of the validation errors, for example
+// `#/traits: additionalProperties "unknown" not allowed`.
+func validationMessages(errs []error) []string {
+	var messages []string
+	var collect func(e *jsonschema.ValidationError)
+	collect = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			pointer, validation := jsonschemax.FormatError(e)
+			messages = append(messages, fmt.Sprintf("%s: %s", pointer, validation))
+		}
+		for _, cause := range e.Causes {
+			collect(cause)
+		}
+	}
+
+	for _, err := range errs {
+		if e := new(jsonschema.ValidationError); errors.As(err, &e) {
+			collect(e)
+		} else {
+			messages = append(messages, err.Error())
+		}
+	}
+	return messages
+}
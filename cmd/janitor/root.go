@@ -0,0 +1,47 @@
+package janitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ory/kratos/driver"
+	"github.com/ory/x/configx"
+)
+
+// janitorCmd represents the janitor command
+var janitorCmd = &cobra.Command{
+	Use:   "janitor",
+	Short: "Removes identities which deleted their account for good",
+	Long: `Removes all identities which deleted their account in the settings flow longer than
+selfservice.methods.account_deletion.config.retention ago.
+
+Run this command periodically, for example once a day using a cron job.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r := driver.New(cmd.Context(), configx.WithFlags(cmd.Flags()))
+		removed, err := Purge(cmd.Context(), r)
+		if err != nil {
+			return err
+		}
+
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Removed %d deleted identities.\n", removed)
+		return nil
+	},
+}
+
+func init() {
+	configx.RegisterFlags(janitorCmd.PersistentFlags())
+}
+
+func RegisterCommandRecursive(parent *cobra.Command) {
+	parent.AddCommand(janitorCmd)
+}
+
+// Purge removes all identities whose account deletion is older than the retention window and returns
+// how many identities were removed.
+func Purge(ctx context.Context, r driver.Registry) (int, error) {
+	retention := r.Config(ctx).SelfServiceAccountDeletionRetention()
+	return r.PrivilegedIdentityPool().DeleteIdentitiesDeletedBefore(ctx, time.Now().UTC().Add(-retention))
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/ory/kratos/cmd/courier"
 	"github.com/ory/kratos/cmd/hashers"
+	"github.com/ory/kratos/cmd/janitor"
 
 	"github.com/ory/kratos/cmd/remote"
 
@@ -47,6 +48,7 @@ func init() {
 	remote.RegisterCommandRecursive(RootCmd)
 	hashers.RegisterCommandRecursive(RootCmd)
 	courier.RegisterCommandRecursive(RootCmd)
+	janitor.RegisterCommandRecursive(RootCmd)
 
 	RootCmd.AddCommand(cmdx.Version(&config.Version, &config.Commit, &config.Date))
 }
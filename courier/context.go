@@ -0,0 +1,31 @@
+package courier
+
+import (
+	"context"
+	"time"
+)
+
+// detachedContext keeps the values of its parent but is never canceled.
+type detachedContext struct {
+	parent context.Context
+}
+
+func withoutCancel(ctx context.Context) context.Context {
+	return detachedContext{parent: ctx}
+}
+
+func (detachedContext) Deadline() (deadline time.Time, ok bool) {
+	return
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
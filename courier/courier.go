@@ -5,7 +5,9 @@ import (
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/cenkalti/backoff"
@@ -24,11 +26,19 @@ type (
 	smtpDependencies interface {
 		PersistenceProvider
 		x.LoggingProvider
+		x.TenantConnectionProvider
 		config.Provider
 	}
 	Courier struct {
 		Dialer *gomail.Dialer
 		d      smtpDependencies
+
+		// smsClient delivers text messages to the SMS gateway.
+		smsClient *http.Client
+
+		// lastSend is when the dispatcher last started to send a message. It is used to keep
+		// `courier.min_send_interval` between sends.
+		lastSend time.Time
 	}
 	Provider interface {
 		Courier(ctx context.Context) *Courier
@@ -50,7 +60,8 @@ func NewSMTP(d smtpDependencies, c *config.Config) *Courier {
 	}
 
 	return &Courier{
-		d: d,
+		d:         d,
+		smsClient: newSMSClient(),
 		Dialer: &gomail.Dialer{
 			/* #nosec we need to support SMTP servers without TLS */
 			TLSConfig:    tlsConfig,
@@ -106,105 +117,201 @@ func (m *Courier) QueueEmail(ctx context.Context, t EmailTemplate) (uuid.UUID, e
 	return message.ID, nil
 }
 
+// Work dispatches queued messages until ctx is canceled. Once ctx is canceled, no new messages are
+// dequeued and Work waits up to the configured drain timeout for in-flight messages to be delivered.
+// Messages which were not delivered are put back into the queue.
 func (m *Courier) Work(ctx context.Context) error {
-	errChan := make(chan error)
-	defer close(errChan)
+	errChan := make(chan error, 1)
+	done := make(chan struct{})
+
+	// In-flight messages are sent using a context which is not canceled when shutting down. It is
+	// canceled once the drain timeout is reached instead.
+	sendCtx, cancelSend := context.WithCancel(withoutCancel(ctx))
+	defer cancelSend()
 
-	go m.watchMessages(ctx, errChan)
+	go func() {
+		defer close(done)
+		m.watchMessages(ctx, sendCtx, errChan)
+	}()
 
 	select {
 	case <-ctx.Done():
-		if errors.Is(ctx.Err(), context.Canceled) {
-			return nil
-		}
-		return ctx.Err()
 	case err := <-errChan:
 		return err
 	}
+
+	timeout := m.d.Config(sendCtx).CourierDrainTimeout()
+	m.d.Logger().
+		WithField("drain_timeout", timeout).
+		Info("Courier worker is shutting down and waits for in-flight messages to be delivered.")
+
+	select {
+	case <-done:
+		m.d.Logger().Info("Courier worker delivered all in-flight messages.")
+	case <-time.After(timeout):
+		m.d.Logger().
+			WithField("drain_timeout", timeout).
+			Warn("Courier worker reached the drain timeout before all in-flight messages were delivered.")
+	}
+
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return nil
+	}
+	return ctx.Err()
 }
 
-func (m *Courier) watchMessages(ctx context.Context, errChan chan error) {
+func (m *Courier) watchMessages(ctx, sendCtx context.Context, errChan chan error) {
 	for {
 		if err := backoff.Retry(func() error {
-			return m.DispatchQueue(ctx)
-		}, backoff.NewExponentialBackOff()); err != nil {
-			errChan <- err
+			return m.dispatchQueues(ctx, sendCtx)
+		}, backoff.WithContext(backoff.NewExponentialBackOff(), ctx)); err != nil {
+			if ctx.Err() == nil {
+				errChan <- err
+			}
+			return
+		}
+
+		select {
+		case <-ctx.Done():
 			return
+		case <-time.After(time.Second):
 		}
-		time.Sleep(time.Second)
 	}
 }
 
 func (m *Courier) DispatchMessage(ctx context.Context, msg Message) error {
 	switch msg.Type {
 	case MessageTypeEmail:
-		from := m.d.Config(ctx).CourierSMTPFrom()
-		fromName := m.d.Config(ctx).CourierSMTPFromName()
-		gm := gomail.NewMessage()
-		if fromName == "" {
-			gm.SetHeader("From", from)
-		} else {
-			gm.SetAddressHeader("From", from, fromName)
+		if err := m.dispatchEmail(ctx, msg); err != nil {
+			return err
 		}
+	case MessageTypeSMS:
+		if err := m.dispatchSMS(ctx, msg); err != nil {
+			return err
+		}
+	default:
+		return errors.Errorf("received unexpected message type: %d", msg.Type)
+	}
 
-		gm.SetHeader("To", msg.Recipient)
-		gm.SetHeader("Subject", msg.Subject)
-		gm.SetBody("text/plain", msg.Body)
+	if err := m.d.CourierPersister().SetMessageStatus(ctx, msg.ID, MessageStatusSent); err != nil {
+		m.d.Logger().
+			WithError(err).
+			WithField("message_id", msg.ID).
+			Error(`Unable to set the message status to "sent".`)
+		return err
+	}
 
-		tmpl, err := NewEmailTemplateFromMessage(m.d.Config(ctx), msg)
+	m.d.Logger().
+		WithField("message_id", msg.ID).
+		WithField("message_type", msg.Type).
+		WithField("message_template_type", msg.TemplateType).
+		WithField("message_subject", msg.Subject).
+		Debug("Courier sent out message.")
+	return nil
+}
+
+func (m *Courier) dispatchEmail(ctx context.Context, msg Message) error {
+	from := m.d.Config(ctx).CourierSMTPFrom()
+	fromName := m.d.Config(ctx).CourierSMTPFromName()
+	gm := gomail.NewMessage()
+	if fromName == "" {
+		gm.SetHeader("From", from)
+	} else {
+		gm.SetAddressHeader("From", from, fromName)
+	}
+
+	gm.SetHeader("To", msg.Recipient)
+	gm.SetHeader("Subject", msg.Subject)
+	gm.SetBody("text/plain", msg.Body)
+
+	tmpl, err := NewEmailTemplateFromMessage(m.d.Config(ctx), msg)
+	if err != nil {
+		m.d.Logger().
+			WithError(err).
+			WithField("message_id", msg.ID).
+			Error(`Unable to get email template from message.`)
+	} else {
+		htmlBody, err := tmpl.EmailBody()
 		if err != nil {
 			m.d.Logger().
 				WithError(err).
 				WithField("message_id", msg.ID).
-				Error(`Unable to get email template from message.`)
+				Error(`Unable to get email body from template.`)
 		} else {
-			htmlBody, err := tmpl.EmailBody()
-			if err != nil {
-				m.d.Logger().
-					WithError(err).
-					WithField("message_id", msg.ID).
-					Error(`Unable to get email body from template.`)
-			} else {
-				gm.AddAlternative("text/html", htmlBody)
-			}
+			gm.AddAlternative("text/html", htmlBody)
 		}
+	}
 
-		if err := m.Dialer.DialAndSend(ctx, gm); err != nil {
-			m.d.Logger().
-				WithError(err).
-				WithField("smtp_server", fmt.Sprintf("%s:%d", m.Dialer.Host, m.Dialer.Port)).
-				WithField("smtp_ssl_enabled", m.Dialer.SSL).
-				// WithField("email_to", msg.Recipient).
-				WithField("message_from", from).
-				Error("Unable to send email using SMTP connection.")
-			return errors.WithStack(err)
-		}
+	if err := m.Dialer.DialAndSend(ctx, gm); err != nil {
+		m.d.Logger().
+			WithError(err).
+			WithField("smtp_server", fmt.Sprintf("%s:%d", m.Dialer.Host, m.Dialer.Port)).
+			WithField("smtp_ssl_enabled", m.Dialer.SSL).
+			// WithField("email_to", msg.Recipient).
+			WithField("message_from", from).
+			Error("Unable to send email using SMTP connection.")
+		return errors.WithStack(err)
+	}
+	return nil
+}
 
-		if err := m.d.CourierPersister().SetMessageStatus(ctx, msg.ID, MessageStatusSent); err != nil {
-			m.d.Logger().
-				WithError(err).
-				WithField("message_id", msg.ID).
-				Error(`Unable to set the message status to "sent".`)
-			return err
+// DispatchQueue dispatches the queued messages of the default database and of all tenants.
+func (m *Courier) DispatchQueue(ctx context.Context) error {
+	return m.dispatchQueues(ctx, ctx)
+}
+
+// dispatchQueues dispatches the queue of the default database and then the queue of each tenant in
+// `tenancy.tenants`. A tenant whose queue can not be dispatched does not keep the queues of the other
+// tenants from being dispatched. The first error encountered is returned.
+func (m *Courier) dispatchQueues(ctx, sendCtx context.Context) error {
+	result := m.dispatchQueue(ctx, sendCtx)
+	for _, t := range m.d.Config(sendCtx).Tenants() {
+		if err := m.dispatchTenantQueue(ctx, sendCtx, t.ID); err != nil {
+			m.d.Logger().WithError(err).WithField("tenant", t.ID).Warn("Unable to dispatch the messages of the tenant.")
+			if result == nil {
+				result = err
+			}
 		}
+	}
+	return result
+}
 
-		m.d.Logger().
-			WithField("message_id", msg.ID).
-			WithField("message_type", msg.Type).
-			WithField("message_template_type", msg.TemplateType).
-			WithField("message_subject", msg.Subject).
-			Debug("Courier sent out message.")
-		return nil
+// dispatchTenantQueue dispatches the queue of the tenant's database. The connection to the database
+// is only held while the queue is dispatched so that it can be evicted in between.
+func (m *Courier) dispatchTenantQueue(ctx, sendCtx context.Context, tenant string) error {
+	conn, release, err := m.d.TenantConnection(sendCtx, tenant)
+	if err != nil {
+		return err
 	}
-	return errors.Errorf("received unexpected message type: %d", msg.Type)
+	defer release()
+
+	return m.dispatchQueue(x.WithTenantConnection(ctx, conn), x.WithTenantConnection(sendCtx, conn))
 }
 
-func (m *Courier) DispatchQueue(ctx context.Context) error {
+// dispatchQueue stops dequeuing messages once ctx is canceled while messages which are already being
+// processed are sent using sendCtx.
+//
+// Messages are sent in the order they were queued using up to `courier.max_concurrent_sends` concurrent
+// sends, each started at least `courier.min_send_interval` after the previous one. If a send fails, no
+// further sends are started and all messages which were not delivered are put back into the queue.
+func (m *Courier) dispatchQueue(ctx, sendCtx context.Context) error {
 	if len(m.Dialer.Host) == 0 {
 		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Courier tried to deliver an email but courier.smtp_url is not set!"))
 	}
 
-	messages, err := m.d.CourierPersister().NextMessages(ctx, 10)
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	c := m.d.Config(sendCtx)
+	concurrency, interval := c.CourierMaxConcurrentSends(), c.CourierMinSendInterval()
+
+	limit := 10
+	if concurrency > limit {
+		limit = concurrency
+	}
+
+	messages, err := m.d.CourierPersister().NextMessages(sendCtx, uint8(limit))
 	if err != nil {
 		if errors.Is(err, ErrQueueEmpty) {
 			return nil
@@ -212,21 +319,73 @@ func (m *Courier) DispatchQueue(ctx context.Context) error {
 		return err
 	}
 
+	var wg sync.WaitGroup
+	var l sync.Mutex
+	var sendErr error
+	failed := func() error {
+		l.Lock()
+		defer l.Unlock()
+		return sendErr
+	}
+
+	slots := make(chan struct{}, concurrency)
 	for k := range messages {
 		var msg = messages[k]
-		if err := m.DispatchMessage(ctx, msg); err != nil {
-			for _, replace := range messages[k:] {
-				if err := m.d.CourierPersister().SetMessageStatus(ctx, replace.ID, MessageStatusQueued); err != nil {
-					m.d.Logger().
-						WithError(err).
-						WithField("message_id", replace.ID).
-						Error(`Unable to reset the failed message's status to "queued".`)
+
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+
+		if ctx.Err() != nil || failed() != nil || m.waitForSendInterval(ctx, interval) != nil {
+			m.requeue(sendCtx, messages[k:])
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			if err := m.DispatchMessage(sendCtx, msg); err != nil {
+				m.requeue(sendCtx, []Message{msg})
+
+				l.Lock()
+				if sendErr == nil {
+					sendErr = err
 				}
+				l.Unlock()
 			}
+		}()
+	}
 
-			return err
+	wg.Wait()
+	return failed()
+}
+
+// waitForSendInterval blocks until interval passed since the last send was started or ctx is canceled.
+func (m *Courier) waitForSendInterval(ctx context.Context, interval time.Duration) error {
+	if wait := time.Until(m.lastSend.Add(interval)); wait > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
 		}
 	}
 
+	m.lastSend = time.Now()
 	return nil
 }
+
+func (m *Courier) requeue(ctx context.Context, messages []Message) {
+	// Messages must be put back into the queue even if the drain timeout was reached.
+	ctx = withoutCancel(ctx)
+	for _, replace := range messages {
+		if err := m.d.CourierPersister().SetMessageStatus(ctx, replace.ID, MessageStatusQueued); err != nil {
+			m.d.Logger().
+				WithError(err).
+				WithField("message_id", replace.ID).
+				Error(`Unable to reset the failed message's status to "queued".`)
+		}
+	}
+}
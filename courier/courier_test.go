@@ -1,10 +1,16 @@
 package courier_test
 
 import (
+	"bufio"
 	"context"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -12,6 +18,7 @@ import (
 
 	"github.com/ory/kratos/x"
 
+	"github.com/gobuffalo/pop/v5"
 	"github.com/gofrs/uuid"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
@@ -20,9 +27,11 @@ import (
 
 	dhelper "github.com/ory/x/sqlcon/dockertest"
 
+	"github.com/ory/kratos/courier"
 	templates "github.com/ory/kratos/courier/template"
 	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/persistence/sql"
 )
 
 // nolint:staticcheck
@@ -125,3 +134,199 @@ func TestSMTP(t *testing.T) {
 	// Assertion for the third email with sender name
 	assert.Contains(t, string(body), "Bob")
 }
+
+func TestWorkKeepsQueueOnShutdown(t *testing.T) {
+	ctx := context.Background()
+
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyCourierDrainTimeout, "1s")
+	c := reg.Courier(ctx)
+
+	id, err := c.QueueEmail(ctx, templates.NewTestStub(conf, &templates.TestStubModel{
+		To:      "test-recipient-1@example.org",
+		Subject: "test-subject-1",
+		Body:    "test-body-1",
+	}))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	start := time.Now()
+	require.NoError(t, c.Work(ctx))
+	assert.True(t, time.Since(start) < time.Second, "the worker should not wait for the drain timeout if nothing is in-flight")
+
+	message, err := reg.CourierPersister().LatestQueuedMessage(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, id, message.ID)
+}
+
+// newFakeSMTP starts a minimal SMTP server which calls deliver for every message it receives.
+func newFakeSMTP(t *testing.T, deliver func()) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+				rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+				reply := func(line string) {
+					_, _ = rw.WriteString(line + "\r\n")
+					_ = rw.Flush()
+				}
+
+				reply("220 localhost ESMTP")
+				for {
+					line, err := rw.ReadString('\n')
+					if err != nil {
+						return
+					}
+
+					switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+					case strings.HasPrefix(cmd, "DATA"):
+						reply("354 go ahead")
+						for {
+							line, err := rw.ReadString('\n')
+							if err != nil {
+								return
+							} else if line == ".\r\n" {
+								break
+							}
+						}
+						deliver()
+						reply("250 OK")
+					case strings.HasPrefix(cmd, "QUIT"):
+						reply("221 bye")
+						return
+					default:
+						reply("250 OK")
+					}
+				}
+			}()
+		}
+	}()
+
+	return "smtp://" + l.Addr().String() + "/"
+}
+
+func TestDispatchQueueConcurrency(t *testing.T) {
+	ctx := context.Background()
+
+	var l sync.Mutex
+	var active, maxActive int
+	var delivered []time.Time
+	smtp := newFakeSMTP(t, func() {
+		l.Lock()
+		active++
+		if active > maxActive {
+			maxActive = active
+		}
+		delivered = append(delivered, time.Now())
+		l.Unlock()
+
+		time.Sleep(100 * time.Millisecond)
+
+		l.Lock()
+		active--
+		l.Unlock()
+	})
+
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyCourierSMTPURL, smtp)
+
+	queue := func(t *testing.T, n int) {
+		for k := 0; k < n; k++ {
+			_, err := reg.Courier(ctx).QueueEmail(ctx, templates.NewTestStub(conf, &templates.TestStubModel{
+				To:      fmt.Sprintf("test-recipient-%d@example.org", k),
+				Subject: "test-subject",
+				Body:    "test-body",
+			}))
+			require.NoError(t, err)
+		}
+	}
+
+	reset := func() {
+		l.Lock()
+		defer l.Unlock()
+		maxActive = 0
+		delivered = nil
+	}
+
+	t.Run("case=limits concurrent sends", func(t *testing.T) {
+		reset()
+		conf.MustSet(config.ViperKeyCourierMaxConcurrentSends, 3)
+		queue(t, 6)
+
+		require.NoError(t, reg.Courier(ctx).DispatchQueue(ctx))
+		assert.Len(t, delivered, 6)
+		assert.Equal(t, 3, maxActive)
+
+		_, err := reg.CourierPersister().NextMessages(ctx, 10)
+		assert.True(t, errors.Is(err, courier.ErrQueueEmpty), "%+v", err)
+	})
+
+	t.Run("case=keeps the minimum interval between sends", func(t *testing.T) {
+		reset()
+		conf.MustSet(config.ViperKeyCourierMaxConcurrentSends, 3)
+		conf.MustSet(config.ViperKeyCourierMinSendInterval, "250ms")
+		queue(t, 3)
+
+		require.NoError(t, reg.Courier(ctx).DispatchQueue(ctx))
+		require.Len(t, delivered, 3)
+		assert.Equal(t, 1, maxActive, "sends must not overlap if they are shorter than the interval")
+		for k := 1; k < len(delivered); k++ {
+			assert.True(t, delivered[k].Sub(delivered[k-1]) >= 200*time.Millisecond, "%s", delivered[k].Sub(delivered[k-1]))
+		}
+	})
+}
+
+func TestDispatchQueueOfTenants(t *testing.T) {
+	ctx := context.Background()
+
+	var l sync.Mutex
+	var delivered int
+	smtp := newFakeSMTP(t, func() {
+		l.Lock()
+		defer l.Unlock()
+		delivered++
+	})
+
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyCourierSMTPURL, smtp)
+
+	dsn := fmt.Sprintf("sqlite3://%s.sqlite?_fk=true&mode=rwc", filepath.Join(os.TempDir(), x.NewUUID().String()))
+	conf.MustSet(config.ViperKeyTenancyTenants, []map[string]interface{}{{"id": "acme", "dsn": dsn}})
+
+	c, err := pop.NewConnection(&pop.ConnectionDetails{URL: dsn})
+	require.NoError(t, err)
+	require.NoError(t, c.Open())
+	p, err := sql.NewPersister(ctx, reg, c)
+	require.NoError(t, err)
+	require.NoError(t, p.MigrateUp(ctx))
+	require.NoError(t, c.Close())
+
+	tc, release, err := reg.TenantConnection(ctx, "acme")
+	require.NoError(t, err)
+	defer release()
+	tenantCtx := x.WithTenantConnection(ctx, tc)
+
+	_, err = reg.Courier(ctx).QueueEmail(tenantCtx, templates.NewTestStub(conf, &templates.TestStubModel{
+		To:      "test-recipient@example.org",
+		Subject: "test-subject",
+		Body:    "test-body",
+	}))
+	require.NoError(t, err)
+
+	require.NoError(t, reg.Courier(ctx).DispatchQueue(ctx))
+	assert.Equal(t, 1, delivered)
+
+	_, err = reg.CourierPersister().NextMessages(tenantCtx, 10)
+	assert.True(t, errors.Is(err, courier.ErrQueueEmpty), "%+v", err)
+}
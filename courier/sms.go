@@ -0,0 +1,168 @@
+package courier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/google/go-jsonnet"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/fetcher"
+)
+
+type (
+	smsRequestConfig struct {
+		URL     string            `json:"url"`
+		Method  string            `json:"method"`
+		Body    string            `json:"body"`
+		Headers map[string]string `json:"headers"`
+		Auth    *struct {
+			Type   string `json:"type"`
+			Config struct {
+				User     string `json:"user"`
+				Password string `json:"password"`
+				Name     string `json:"name"`
+				Value    string `json:"value"`
+			} `json:"config"`
+		} `json:"auth"`
+	}
+
+	// smsRequestContext is the text message as passed to the request body template.
+	smsRequestContext struct {
+		From         string       `json:"from"`
+		To           string       `json:"to"`
+		Body         string       `json:"body"`
+		TemplateType TemplateType `json:"template_type"`
+		MessageID    uuid.UUID    `json:"message_id"`
+	}
+)
+
+func (m *Courier) QueueSMS(ctx context.Context, t SMSTemplate) (uuid.UUID, error) {
+	recipient, err := t.SMSRecipient()
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	body, err := t.SMSBody()
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	templateType, err := GetTemplateType(t)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	templateData, err := json.Marshal(t)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	message := &Message{
+		Status:       MessageStatusQueued,
+		Type:         MessageTypeSMS,
+		Recipient:    recipient,
+		Body:         body,
+		TemplateType: templateType,
+		TemplateData: templateData,
+	}
+	if err := m.d.CourierPersister().AddMessage(ctx, message); err != nil {
+		return uuid.Nil, err
+	}
+	return message.ID, nil
+}
+
+// dispatchSMS delivers the text message by sending the request configured by `courier.sms.request_config`
+// to the SMS gateway. The request body is rendered by the Jsonnet template `body` which is called with the
+// message as `ctx`. Without a template, the message itself is sent as JSON.
+func (m *Courier) dispatchSMS(ctx context.Context, msg Message) error {
+	c := m.d.Config(ctx)
+	if !c.CourierSMSEnabled() {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Courier tried to deliver an SMS but courier.sms.enabled is false!"))
+	}
+
+	rc := smsRequestConfig{Method: "POST"}
+	if err := json.Unmarshal(c.CourierSMSRequestConfig(), &rc); err != nil {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to decode courier.sms.request_config.").WithDebug(err.Error()))
+	} else if len(rc.URL) == 0 {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Courier tried to deliver an SMS but courier.sms.request_config.url is not set!"))
+	}
+
+	payload, err := json.Marshal(&smsRequestContext{
+		From:         c.CourierSMSFrom(),
+		To:           msg.Recipient,
+		Body:         msg.Body,
+		TemplateType: msg.TemplateType,
+		MessageID:    msg.ID,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	body := string(payload)
+	if len(rc.Body) > 0 {
+		template, err := fetcher.NewFetcher().Fetch(rc.Body)
+		if err != nil {
+			return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to fetch the SMS request body template.").WithDebug(err.Error()))
+		}
+
+		vm := jsonnet.MakeVM()
+		vm.TLACode("ctx", body)
+		if body, err = vm.EvaluateSnippet(rc.Body, template.String()); err != nil {
+			return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to render the SMS request body template.").WithDebug(err.Error()))
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, rc.Method, rc.URL, bytes.NewBufferString(body))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range rc.Headers {
+		req.Header.Set(k, v)
+	}
+
+	if rc.Auth != nil {
+		switch rc.Auth.Type {
+		case "basic_auth":
+			req.SetBasicAuth(rc.Auth.Config.User, rc.Auth.Config.Password)
+		case "api_key":
+			req.Header.Set(rc.Auth.Config.Name, rc.Auth.Config.Value)
+		default:
+			return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("The SMS gateway authentication type %q is unknown.", rc.Auth.Type))
+		}
+	}
+
+	res, err := m.smsClient.Do(req)
+	if err != nil {
+		m.d.Logger().
+			WithError(err).
+			WithField("sms_gateway_url", rc.URL).
+			Error("Unable to send SMS using the SMS gateway.")
+		return errors.WithStack(err)
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		m.d.Logger().
+			WithField("sms_gateway_url", rc.URL).
+			WithField("sms_gateway_status_code", res.StatusCode).
+			Error("Unable to send SMS using the SMS gateway.")
+		return errors.Errorf("the SMS gateway responded with status code %d", res.StatusCode)
+	}
+
+	return nil
+}
+
+func newSMSClient() *http.Client {
+	return &http.Client{Timeout: time.Second * 10}
+}
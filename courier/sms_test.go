@@ -0,0 +1,148 @@
+package courier_test
+
+import (
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/kratos/courier"
+	templates "github.com/ory/kratos/courier/template"
+	"github.com/ory/kratos/driver"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/internal"
+)
+
+func TestSMS(t *testing.T) {
+	ctx := context.Background()
+
+	type request struct {
+		body   []byte
+		header http.Header
+		user   string
+		pass   string
+	}
+	requests := make(chan request, 10)
+	status := http.StatusOK
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		user, pass, _ := r.BasicAuth()
+		requests <- request{body: body, header: r.Header, user: user, pass: pass}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(ts.Close)
+
+	newRegistry := func(t *testing.T, rc map[string]interface{}) (*config.Config, *driver.RegistryDefault) {
+		conf, reg := internal.NewFastRegistryWithMocks(t)
+		conf.MustSet(config.ViperKeyCourierSMSEnabled, true)
+		conf.MustSet(config.ViperKeyCourierSMSFrom, "+15550000000")
+		rc["url"] = ts.URL
+		conf.MustSet(config.ViperKeyCourierSMSRequestConfig, rc)
+		return conf, reg
+	}
+
+	queue := func(t *testing.T, conf *config.Config, reg *driver.RegistryDefault, to string) uuid.UUID {
+		id, err := reg.Courier(ctx).QueueSMS(ctx, templates.NewTestStub(conf, &templates.TestStubModel{
+			To:   to,
+			Body: "test-body",
+		}))
+		require.NoError(t, err)
+		return id
+	}
+
+	next := func(t *testing.T) request {
+		select {
+		case r := <-requests:
+			return r
+		default:
+			t.Fatal("the SMS gateway was not called")
+			return request{}
+		}
+	}
+
+	t.Run("case=renders the request body template", func(t *testing.T) {
+		template := `function(ctx) { recipient: ctx.to, sender: ctx.from, text: ctx.body, ref: ctx.message_id }`
+		conf, reg := newRegistry(t, map[string]interface{}{
+			"method":  "PUT",
+			"body":    "base64://" + base64.StdEncoding.EncodeToString([]byte(template)),
+			"headers": map[string]interface{}{"X-Tenant": "acme"},
+			"auth": map[string]interface{}{
+				"type":   "basic_auth",
+				"config": map[string]interface{}{"user": "kratos", "password": "secret"},
+			},
+		})
+
+		id := queue(t, conf, reg, "+15551234567")
+		require.NoError(t, reg.Courier(ctx).DispatchQueue(ctx))
+
+		r := next(t)
+		assert.JSONEq(t, `{"recipient":"+15551234567","sender":"+15550000000","text":"stub sms body test-body\n","ref":"`+id.String()+`"}`, string(r.body))
+		assert.Equal(t, "acme", r.header.Get("X-Tenant"))
+		assert.Equal(t, "kratos", r.user)
+		assert.Equal(t, "secret", r.pass)
+
+		_, err := reg.CourierPersister().NextMessages(ctx, 10)
+		assert.True(t, errors.Is(err, courier.ErrQueueEmpty), "%+v", err)
+	})
+
+	t.Run("case=sends the message as JSON without a template", func(t *testing.T) {
+		conf, reg := newRegistry(t, map[string]interface{}{
+			"auth": map[string]interface{}{
+				"type":   "api_key",
+				"config": map[string]interface{}{"name": "X-API-Key", "value": "api-key"},
+			},
+		})
+
+		queue(t, conf, reg, "+15551234567")
+		require.NoError(t, reg.Courier(ctx).DispatchQueue(ctx))
+
+		r := next(t)
+		assert.Equal(t, "+15551234567", gjson.GetBytes(r.body, "to").String())
+		assert.Equal(t, "+15550000000", gjson.GetBytes(r.body, "from").String())
+		assert.Equal(t, string(courier.TypeTestStub), gjson.GetBytes(r.body, "template_type").String())
+		assert.Equal(t, "api-key", r.header.Get("X-API-Key"))
+		assert.Equal(t, "application/json", r.header.Get("Content-Type"))
+	})
+
+	t.Run("case=keeps the message queued if the gateway fails", func(t *testing.T) {
+		conf, reg := newRegistry(t, map[string]interface{}{})
+		status = http.StatusInternalServerError
+		t.Cleanup(func() {
+			status = http.StatusOK
+		})
+
+		id := queue(t, conf, reg, "+15551234567")
+		require.Error(t, reg.Courier(ctx).DispatchQueue(ctx))
+		next(t)
+
+		message, err := reg.CourierPersister().LatestQueuedMessage(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, id, message.ID)
+		assert.Equal(t, courier.MessageTypeSMS, message.Type)
+
+		status = http.StatusOK
+		require.NoError(t, reg.Courier(ctx).DispatchQueue(ctx))
+		next(t)
+	})
+
+	t.Run("case=fails if SMS is disabled", func(t *testing.T) {
+		conf, reg := newRegistry(t, map[string]interface{}{})
+		conf.MustSet(config.ViperKeyCourierSMSEnabled, false)
+
+		queue(t, conf, reg, "+15551234567")
+		require.Error(t, reg.Courier(ctx).DispatchQueue(ctx))
+
+		conf.MustSet(config.ViperKeyCourierSMSEnabled, true)
+		require.NoError(t, reg.Courier(ctx).DispatchQueue(ctx))
+		next(t)
+	})
+}
@@ -0,0 +1,45 @@
+package template
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"time"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+type (
+	AccountDeleted struct {
+		c *config.Config
+		m *AccountDeletedModel
+	}
+	AccountDeletedModel struct {
+		To        string
+		DeletedAt time.Time
+		PurgedAt  time.Time
+	}
+)
+
+func NewAccountDeleted(c *config.Config, m *AccountDeletedModel) *AccountDeleted {
+	return &AccountDeleted{c: c, m: m}
+}
+
+func (t *AccountDeleted) EmailRecipient() (string, error) {
+	return t.m.To, nil
+}
+
+func (t *AccountDeleted) EmailSubject() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "settings/account_deleted/email.subject.gotmpl"), t.m)
+}
+
+func (t *AccountDeleted) EmailBody() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "settings/account_deleted/email.body.gotmpl"), t.m)
+}
+
+func (t *AccountDeleted) EmailBodyPlaintext() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "settings/account_deleted/email.body.plaintext.gotmpl"), t.m)
+}
+
+func (t *AccountDeleted) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.m)
+}
@@ -0,0 +1,33 @@
+package template_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/courier/template"
+	"github.com/ory/kratos/internal"
+)
+
+func TestAccountDeleted(t *testing.T) {
+	conf, _ := internal.NewFastRegistryWithMocks(t)
+	tpl := template.NewAccountDeleted(conf, &template.AccountDeletedModel{
+		DeletedAt: time.Date(2021, 5, 14, 8, 30, 0, 0, time.UTC),
+		PurgedAt:  time.Date(2021, 6, 13, 8, 30, 0, 0, time.UTC),
+	})
+
+	rendered, err := tpl.EmailBody()
+	require.NoError(t, err)
+	assert.Contains(t, rendered, "2021-05-14 08:30 UTC")
+	assert.Contains(t, rendered, "2021-06-13 08:30 UTC")
+
+	rendered, err = tpl.EmailBodyPlaintext()
+	require.NoError(t, err)
+	assert.Contains(t, rendered, "2021-06-13 08:30 UTC")
+
+	rendered, err = tpl.EmailSubject()
+	require.NoError(t, err)
+	assert.NotEmpty(t, rendered)
+}
@@ -0,0 +1,46 @@
+package template
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"time"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+type (
+	LoginNotification struct {
+		c *config.Config
+		m *LoginNotificationModel
+	}
+	LoginNotificationModel struct {
+		To         string
+		SignedInAt time.Time
+		IPAddress  string
+		UserAgent  string
+	}
+)
+
+func NewLoginNotification(c *config.Config, m *LoginNotificationModel) *LoginNotification {
+	return &LoginNotification{c: c, m: m}
+}
+
+func (t *LoginNotification) EmailRecipient() (string, error) {
+	return t.m.To, nil
+}
+
+func (t *LoginNotification) EmailSubject() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "login/notification/email.subject.gotmpl"), t.m)
+}
+
+func (t *LoginNotification) EmailBody() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "login/notification/email.body.gotmpl"), t.m)
+}
+
+func (t *LoginNotification) EmailBodyPlaintext() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "login/notification/email.body.plaintext.gotmpl"), t.m)
+}
+
+func (t *LoginNotification) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.m)
+}
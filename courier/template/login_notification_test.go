@@ -0,0 +1,34 @@
+package template_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/courier/template"
+	"github.com/ory/kratos/internal"
+)
+
+func TestLoginNotification(t *testing.T) {
+	conf, _ := internal.NewFastRegistryWithMocks(t)
+	tpl := template.NewLoginNotification(conf, &template.LoginNotificationModel{
+		SignedInAt: time.Date(2021, 5, 10, 8, 30, 0, 0, time.UTC),
+		IPAddress:  "192.0.2.1",
+		UserAgent:  "Mozilla/5.0",
+	})
+
+	rendered, err := tpl.EmailBody()
+	require.NoError(t, err)
+	assert.Contains(t, rendered, "192.0.2.1")
+	assert.Contains(t, rendered, "Mozilla/5.0")
+
+	rendered, err = tpl.EmailBodyPlaintext()
+	require.NoError(t, err)
+	assert.Contains(t, rendered, "2021-05-10 08:30 UTC")
+
+	rendered, err = tpl.EmailSubject()
+	require.NoError(t, err)
+	assert.NotEmpty(t, rendered)
+}
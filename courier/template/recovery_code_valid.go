@@ -0,0 +1,43 @@
+package template
+
+import (
+	"encoding/json"
+	"path/filepath"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+type (
+	RecoveryCodeValid struct {
+		c *config.Config
+		m *RecoveryCodeValidModel
+	}
+	RecoveryCodeValidModel struct {
+		To           string
+		RecoveryCode string
+	}
+)
+
+func NewRecoveryCodeValid(c *config.Config, m *RecoveryCodeValidModel) *RecoveryCodeValid {
+	return &RecoveryCodeValid{c: c, m: m}
+}
+
+func (t *RecoveryCodeValid) EmailRecipient() (string, error) {
+	return t.m.To, nil
+}
+
+func (t *RecoveryCodeValid) EmailSubject() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "recovery_code/valid/email.subject.gotmpl"), t.m)
+}
+
+func (t *RecoveryCodeValid) EmailBody() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "recovery_code/valid/email.body.gotmpl"), t.m)
+}
+
+func (t *RecoveryCodeValid) EmailBodyPlaintext() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "recovery_code/valid/email.body.plaintext.gotmpl"), t.m)
+}
+
+func (t *RecoveryCodeValid) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.m)
+}
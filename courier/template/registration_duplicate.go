@@ -0,0 +1,42 @@
+package template
+
+import (
+	"encoding/json"
+	"path/filepath"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+type (
+	RegistrationDuplicate struct {
+		c *config.Config
+		m *RegistrationDuplicateModel
+	}
+	RegistrationDuplicateModel struct {
+		To string
+	}
+)
+
+func NewRegistrationDuplicate(c *config.Config, m *RegistrationDuplicateModel) *RegistrationDuplicate {
+	return &RegistrationDuplicate{c: c, m: m}
+}
+
+func (t *RegistrationDuplicate) EmailRecipient() (string, error) {
+	return t.m.To, nil
+}
+
+func (t *RegistrationDuplicate) EmailSubject() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "registration/duplicate/email.subject.gotmpl"), t.m)
+}
+
+func (t *RegistrationDuplicate) EmailBody() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "registration/duplicate/email.body.gotmpl"), t.m)
+}
+
+func (t *RegistrationDuplicate) EmailBodyPlaintext() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "registration/duplicate/email.body.plaintext.gotmpl"), t.m)
+}
+
+func (t *RegistrationDuplicate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.m)
+}
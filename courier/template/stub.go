@@ -38,6 +38,14 @@ func (t *TestStub) EmailBodyPlaintext() (string, error) {
 	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "test_stub/email.body.plaintext.gotmpl"), t.m)
 }
 
+func (t *TestStub) SMSRecipient() (string, error) {
+	return t.m.To, nil
+}
+
+func (t *TestStub) SMSBody() (string, error) {
+	return loadTextTemplate(filepath.Join(t.c.CourierTemplatesRoot(), "test_stub/sms.body.gotmpl"), t.m)
+}
+
 func (t *TestStub) MarshalJSON() ([]byte, error) {
 	return json.Marshal(t.m)
 }
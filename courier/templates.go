@@ -12,27 +12,50 @@ import (
 type TemplateType string
 
 const (
-	TypeRecoveryInvalid     TemplateType = "recovery_invalid"
-	TypeRecoveryValid       TemplateType = "recovery_valid"
-	TypeVerificationInvalid TemplateType = "verification_invalid"
-	TypeVerificationValid   TemplateType = "verification_valid"
-	TypeTestStub            TemplateType = "stub"
+	TypeAccountDeleted        TemplateType = "account_deleted"
+	TypeLoginNotification     TemplateType = "login_notification"
+	TypeRecoveryInvalid       TemplateType = "recovery_invalid"
+	TypeRecoveryValid         TemplateType = "recovery_valid"
+	TypeRecoveryCodeValid     TemplateType = "recovery_code_valid"
+	TypeRegistrationDuplicate TemplateType = "registration_duplicate"
+	TypeVerificationInvalid   TemplateType = "verification_invalid"
+	TypeVerificationValid     TemplateType = "verification_valid"
+	TypeTestStub              TemplateType = "stub"
 )
 
-type EmailTemplate interface {
+type Template interface {
 	json.Marshaler
+}
+
+type EmailTemplate interface {
+	Template
 	EmailSubject() (string, error)
 	EmailBody() (string, error)
 	EmailBodyPlaintext() (string, error)
 	EmailRecipient() (string, error)
 }
 
-func GetTemplateType(t EmailTemplate) (TemplateType, error) {
+// SMSTemplate renders a text message. The recipient is a phone number.
+type SMSTemplate interface {
+	Template
+	SMSBody() (string, error)
+	SMSRecipient() (string, error)
+}
+
+func GetTemplateType(t Template) (TemplateType, error) {
 	switch t.(type) {
+	case *template.AccountDeleted:
+		return TypeAccountDeleted, nil
+	case *template.LoginNotification:
+		return TypeLoginNotification, nil
 	case *template.RecoveryInvalid:
 		return TypeRecoveryInvalid, nil
 	case *template.RecoveryValid:
 		return TypeRecoveryValid, nil
+	case *template.RecoveryCodeValid:
+		return TypeRecoveryCodeValid, nil
+	case *template.RegistrationDuplicate:
+		return TypeRegistrationDuplicate, nil
 	case *template.VerificationInvalid:
 		return TypeVerificationInvalid, nil
 	case *template.VerificationValid:
@@ -46,6 +69,18 @@ func GetTemplateType(t EmailTemplate) (TemplateType, error) {
 
 func NewEmailTemplateFromMessage(c *config.Config, m Message) (EmailTemplate, error) {
 	switch m.TemplateType {
+	case TypeAccountDeleted:
+		var t template.AccountDeletedModel
+		if err := json.Unmarshal(m.TemplateData, &t); err != nil {
+			return nil, err
+		}
+		return template.NewAccountDeleted(c, &t), nil
+	case TypeLoginNotification:
+		var t template.LoginNotificationModel
+		if err := json.Unmarshal(m.TemplateData, &t); err != nil {
+			return nil, err
+		}
+		return template.NewLoginNotification(c, &t), nil
 	case TypeRecoveryInvalid:
 		var t template.RecoveryInvalidModel
 		if err := json.Unmarshal(m.TemplateData, &t); err != nil {
@@ -58,6 +93,18 @@ func NewEmailTemplateFromMessage(c *config.Config, m Message) (EmailTemplate, er
 			return nil, err
 		}
 		return template.NewRecoveryValid(c, &t), nil
+	case TypeRecoveryCodeValid:
+		var t template.RecoveryCodeValidModel
+		if err := json.Unmarshal(m.TemplateData, &t); err != nil {
+			return nil, err
+		}
+		return template.NewRecoveryCodeValid(c, &t), nil
+	case TypeRegistrationDuplicate:
+		var t template.RegistrationDuplicateModel
+		if err := json.Unmarshal(m.TemplateData, &t); err != nil {
+			return nil, err
+		}
+		return template.NewRegistrationDuplicate(c, &t), nil
 	case TypeVerificationInvalid:
 		var t template.VerificationInvalidModel
 		if err := json.Unmarshal(m.TemplateData, &t); err != nil {
@@ -80,3 +127,16 @@ func NewEmailTemplateFromMessage(c *config.Config, m Message) (EmailTemplate, er
 		return nil, errors.Errorf("received unexpected message template type: %s", m.TemplateType)
 	}
 }
+
+func NewSMSTemplateFromMessage(c *config.Config, m Message) (SMSTemplate, error) {
+	switch m.TemplateType {
+	case TypeTestStub:
+		var t template.TestStubModel
+		if err := json.Unmarshal(m.TemplateData, &t); err != nil {
+			return nil, err
+		}
+		return template.NewTestStub(c, &t), nil
+	default:
+		return nil, errors.Errorf("received unexpected SMS template type: %s", m.TemplateType)
+	}
+}
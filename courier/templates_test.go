@@ -14,11 +14,13 @@ import (
 
 func TestGetTemplateType(t *testing.T) {
 	for expectedType, tmpl := range map[courier.TemplateType]courier.EmailTemplate{
-		courier.TypeRecoveryInvalid:     &template.RecoveryInvalid{},
-		courier.TypeRecoveryValid:       &template.RecoveryValid{},
-		courier.TypeVerificationInvalid: &template.VerificationInvalid{},
-		courier.TypeVerificationValid:   &template.VerificationValid{},
-		courier.TypeTestStub:            &template.TestStub{},
+		courier.TypeRecoveryInvalid:       &template.RecoveryInvalid{},
+		courier.TypeRecoveryValid:         &template.RecoveryValid{},
+		courier.TypeRecoveryCodeValid:     &template.RecoveryCodeValid{},
+		courier.TypeRegistrationDuplicate: &template.RegistrationDuplicate{},
+		courier.TypeVerificationInvalid:   &template.VerificationInvalid{},
+		courier.TypeVerificationValid:     &template.VerificationValid{},
+		courier.TypeTestStub:              &template.TestStub{},
 	} {
 		t.Run(fmt.Sprintf("case=%s", expectedType), func(t *testing.T) {
 			actualType, err := courier.GetTemplateType(tmpl)
@@ -33,11 +35,13 @@ func TestGetTemplateType(t *testing.T) {
 func TestNewEmailTemplateFromMessage(t *testing.T) {
 	conf := internal.NewConfigurationWithDefaults(t)
 	for tmplType, expectedTmpl := range map[courier.TemplateType]courier.EmailTemplate{
-		courier.TypeRecoveryInvalid:     template.NewRecoveryInvalid(conf, &template.RecoveryInvalidModel{To: "foo"}),
-		courier.TypeRecoveryValid:       template.NewRecoveryValid(conf, &template.RecoveryValidModel{To: "bar", RecoveryURL: "http://foo.bar"}),
-		courier.TypeVerificationInvalid: template.NewVerificationInvalid(conf, &template.VerificationInvalidModel{To: "baz"}),
-		courier.TypeVerificationValid:   template.NewVerificationValid(conf, &template.VerificationValidModel{To: "faz", VerificationURL: "http://bar.foo"}),
-		courier.TypeTestStub:            template.NewTestStub(conf, &template.TestStubModel{To: "far", Subject: "test subject", Body: "test body"}),
+		courier.TypeRecoveryInvalid:       template.NewRecoveryInvalid(conf, &template.RecoveryInvalidModel{To: "foo"}),
+		courier.TypeRecoveryValid:         template.NewRecoveryValid(conf, &template.RecoveryValidModel{To: "bar", RecoveryURL: "http://foo.bar"}),
+		courier.TypeRecoveryCodeValid:     template.NewRecoveryCodeValid(conf, &template.RecoveryCodeValidModel{To: "bar", RecoveryCode: "012345"}),
+		courier.TypeRegistrationDuplicate: template.NewRegistrationDuplicate(conf, &template.RegistrationDuplicateModel{To: "boo"}),
+		courier.TypeVerificationInvalid:   template.NewVerificationInvalid(conf, &template.VerificationInvalidModel{To: "baz"}),
+		courier.TypeVerificationValid:     template.NewVerificationValid(conf, &template.VerificationValidModel{To: "faz", VerificationURL: "http://bar.foo"}),
+		courier.TypeTestStub:              template.NewTestStub(conf, &template.TestStubModel{To: "far", Subject: "test subject", Body: "test body"}),
 	} {
 		t.Run(fmt.Sprintf("case=%s", tmplType), func(t *testing.T) {
 			tmplData, err := json.Marshal(expectedTmpl)
@@ -76,3 +80,26 @@ func TestNewEmailTemplateFromMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestNewSMSTemplateFromMessage(t *testing.T) {
+	conf := internal.NewConfigurationWithDefaults(t)
+	expectedTmpl := template.NewTestStub(conf, &template.TestStubModel{To: "+15551234567", Body: "test body"})
+
+	tmplData, err := json.Marshal(expectedTmpl)
+	require.NoError(t, err)
+
+	actualTmpl, err := courier.NewSMSTemplateFromMessage(conf, courier.Message{TemplateType: courier.TypeTestStub, TemplateData: tmplData})
+	require.NoError(t, err)
+	require.IsType(t, expectedTmpl, actualTmpl)
+
+	recipient, err := actualTmpl.SMSRecipient()
+	require.NoError(t, err)
+	require.Equal(t, "+15551234567", recipient)
+
+	body, err := actualTmpl.SMSBody()
+	require.NoError(t, err)
+	require.Equal(t, "stub sms body test body\n", body)
+
+	_, err = courier.NewSMSTemplateFromMessage(conf, courier.Message{TemplateType: courier.TypeRecoveryValid, TemplateData: tmplData})
+	require.Error(t, err)
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"net"
@@ -45,48 +46,119 @@ const (
 	ViperKeyCourierTemplatesPath                                    = "courier.template_override_path"
 	ViperKeyCourierSMTPFrom                                         = "courier.smtp.from_address"
 	ViperKeyCourierSMTPFromName                                     = "courier.smtp.from_name"
+	ViperKeyCourierDrainTimeout                                     = "courier.drain_timeout"
+	ViperKeyCourierMaxConcurrentSends                               = "courier.max_concurrent_sends"
+	ViperKeyCourierMinSendInterval                                  = "courier.min_send_interval"
+	ViperKeyCourierSMSEnabled                                       = "courier.sms.enabled"
+	ViperKeyCourierSMSFrom                                          = "courier.sms.from"
+	ViperKeyCourierSMSRequestConfig                                 = "courier.sms.request_config"
 	ViperKeySecretsDefault                                          = "secrets.default"
 	ViperKeySecretsCookie                                           = "secrets.cookie"
+	ViperKeySecretsWebHook                                          = "secrets.web_hook"
+	ViperKeySecretsWebHookTLS                                       = "secrets.web_hook_tls"
+	ViperKeySecretsCipher                                           = "secrets.cipher"
+	ViperKeySecretsPepper                                           = "secrets.pepper"
+	ViperKeySecretsSessionJWT                                       = "secrets.session_jwt"
 	ViperKeyPublicBaseURL                                           = "serve.public.base_url"
 	ViperKeyPublicDomainAliases                                     = "serve.public.domain_aliases"
 	ViperKeyPublicPort                                              = "serve.public.port"
 	ViperKeyPublicHost                                              = "serve.public.host"
+	ViperKeyPublicRouteTrailingSlash                                = "serve.public.route_normalization.trailing_slash"
+	ViperKeyPublicRouteCaseInsensitive                              = "serve.public.route_normalization.case_insensitive"
+	ViperKeyPublicMaxRequestBodySize                                = "serve.public.max_request_body_size"
 	ViperKeyAdminBaseURL                                            = "serve.admin.base_url"
 	ViperKeyAdminPort                                               = "serve.admin.port"
 	ViperKeyAdminHost                                               = "serve.admin.host"
+	ViperKeyAdminIPFilterAllow                                      = "serve.admin.ip_filter.allow"
+	ViperKeyAdminIPFilterDeny                                       = "serve.admin.ip_filter.deny"
+	ViperKeyTrustedProxies                                          = "serve.trusted_proxies"
 	ViperKeySessionLifespan                                         = "session.lifespan"
 	ViperKeySessionSameSite                                         = "session.cookie.same_site"
 	ViperKeySessionDomain                                           = "session.cookie.domain"
 	ViperKeySessionName                                             = "session.cookie.name"
 	ViperKeySessionPath                                             = "session.cookie.path"
 	ViperKeySessionPersistentCookie                                 = "session.cookie.persistent"
+	ViperKeySessionClaimsMapperURL                                  = "session.claims_mapper_url"
+	ViperKeySessionCacheTTL                                         = "session.cache.ttl"
+	ViperKeySessionWhoamiCacheTTL                                   = "session.whoami.cache.ttl"
+	ViperKeySessionWhoamiRateLimitRequests                          = "session.whoami.rate_limit.requests"
+	ViperKeySessionWhoamiRateLimitPeriod                            = "session.whoami.rate_limit.period"
+	ViperKeySessionFingerprintMode                                  = "session.fingerprint.mode"
+	ViperKeySessionFingerprintAttributes                            = "session.fingerprint.attributes"
+	ViperKeySessionAnomalyHooks                                     = "session.anomaly.hooks"
+	ViperKeySessionTokenPrefix                                      = "session.token_prefix"
+	ViperKeySessionTokenFormat                                      = "session.token_format"
+	ViperKeySessionJWTTraits                                        = "session.jwt.traits"
+	ViperKeySessionJWTCheckRevocation                               = "session.jwt.check_revocation"
+	ViperKeySessionImpersonationEnabled                             = "session.impersonation.enabled"
+	ViperKeySessionImpersonationLifespan                            = "session.impersonation.lifespan"
+	ViperKeySessionImpersonationAllowedCredentialChanges            = "session.impersonation.allowed_credential_changes"
 	ViperKeySelfServiceStrategyConfig                               = "selfservice.methods"
+	ViperKeySelfServiceUINodeAttributes                             = "selfservice.ui_node_attributes"
+	ViperKeySelfServiceUIMessages                                   = "selfservice.ui_messages"
 	ViperKeySelfServiceBrowserDefaultReturnTo                       = "selfservice." + DefaultBrowserReturnURL
 	ViperKeyURLsWhitelistedReturnToDomains                          = "selfservice.whitelisted_return_urls"
+	ViperKeyURLsRequireHTTPSReturnTo                                = "selfservice.require_https_return_urls"
 	ViperKeySelfServiceRegistrationUI                               = "selfservice.flows.registration.ui_url"
 	ViperKeySelfServiceRegistrationRequestLifespan                  = "selfservice.flows.registration.lifespan"
 	ViperKeySelfServiceRegistrationAfter                            = "selfservice.flows.registration.after"
 	ViperKeySelfServiceRegistrationBeforeHooks                      = "selfservice.flows.registration.before.hooks"
+	ViperKeySelfServiceRegistrationDuplicateIdentifierPolicy        = "selfservice.flows.registration.duplicate_identifier_policy"
+	ViperKeySelfServiceRegistrationBlocklistIdentifiers             = "selfservice.flows.registration.blocklist.identifiers"
+	ViperKeySelfServiceRegistrationBlocklistDomains                 = "selfservice.flows.registration.blocklist.domains"
+	ViperKeySelfServiceRegistrationBlocklistURL                     = "selfservice.flows.registration.blocklist.url"
+	ViperKeySelfServiceRegistrationBlocklistRefreshInterval         = "selfservice.flows.registration.blocklist.refresh_interval"
 	ViperKeySelfServiceLoginUI                                      = "selfservice.flows.login.ui_url"
 	ViperKeySelfServiceLoginRequestLifespan                         = "selfservice.flows.login.lifespan"
 	ViperKeySelfServiceLoginAfter                                   = "selfservice.flows.login.after"
 	ViperKeySelfServiceLoginBeforeHooks                             = "selfservice.flows.login.before.hooks"
+	ViperKeySelfServiceLoginAccessRuleURL                           = "selfservice.flows.login.access_rule.url"
+	ViperKeySelfServiceLoginAccessRuleMessage                       = "selfservice.flows.login.access_rule.message"
+	ViperKeySelfServiceLoginMaxConcurrentFlowsPerIdentifier         = "selfservice.flows.login.max_concurrent_flows_per_identifier"
 	ViperKeySelfServiceErrorUI                                      = "selfservice.flows.error.ui_url"
+	ViperKeySelfServiceErrorStructuredResponse                      = "selfservice.flows.error.structured_response"
 	ViperKeySelfServiceLogoutBrowserDefaultReturnTo                 = "selfservice.flows.logout.after." + DefaultBrowserReturnURL
 	ViperKeySelfServiceSettingsURL                                  = "selfservice.flows.settings.ui_url"
 	ViperKeySelfServiceSettingsAfter                                = "selfservice.flows.settings.after"
 	ViperKeySelfServiceSettingsRequestLifespan                      = "selfservice.flows.settings.lifespan"
 	ViperKeySelfServiceSettingsPrivilegedAuthenticationAfter        = "selfservice.flows.settings.privileged_session_max_age"
+	ViperKeySelfServiceSettingsRequireCurrentPassword               = "selfservice.flows.settings.require_current_password"
+	ViperKeySelfServiceSettingsRequireVerifiedAddress               = "selfservice.flows.settings.require_verified_address"
+	ViperKeySelfServiceSettingsUnchanged                            = "selfservice.flows.settings.unchanged"
 	ViperKeySelfServiceRecoveryEnabled                              = "selfservice.flows.recovery.enabled"
 	ViperKeySelfServiceRecoveryUI                                   = "selfservice.flows.recovery.ui_url"
 	ViperKeySelfServiceRecoveryRequestLifespan                      = "selfservice.flows.recovery.lifespan"
+	ViperKeySelfServiceRecoveryMode                                 = "selfservice.flows.recovery.mode"
+	ViperKeySelfServiceRecoveryAllowedCredentialChanges             = "selfservice.flows.recovery.allowed_credential_changes"
 	ViperKeySelfServiceRecoveryBrowserDefaultReturnTo               = "selfservice.flows.recovery.after." + DefaultBrowserReturnURL
+	ViperKeySelfServiceRecoveryResendCooldown                       = "selfservice.flows.recovery.resend.cooldown"
+	ViperKeySelfServiceRecoveryMaxResends                           = "selfservice.flows.recovery.resend.max_resends"
 	ViperKeySelfServiceVerificationEnabled                          = "selfservice.flows.verification.enabled"
 	ViperKeySelfServiceVerificationUI                               = "selfservice.flows.verification.ui_url"
 	ViperKeySelfServiceVerificationRequestLifespan                  = "selfservice.flows.verification.lifespan"
+	ViperKeySelfServiceVerificationReverification                   = "selfservice.flows.verification.reverification"
 	ViperKeySelfServiceVerificationBrowserDefaultReturnTo           = "selfservice.flows.verification.after." + DefaultBrowserReturnURL
+	ViperKeySelfServiceVerificationResendCooldown                   = "selfservice.flows.verification.resend.cooldown"
+	ViperKeySelfServiceVerificationMaxResends                       = "selfservice.flows.verification.resend.max_resends"
 	ViperKeyDefaultIdentitySchemaURL                                = "identity.default_schema_url"
 	ViperKeyIdentitySchemas                                         = "identity.schemas"
+	ViperKeyIdentityHostSchemas                                     = "identity.host_schemas"
+	ViperKeyIdentitySchemaWebhookURL                                = "identity.schema_webhook.url"
+	ViperKeyIdentitySchemaWebhookTimeout                            = "identity.schema_webhook.timeout"
+	ViperKeyIdentitySchemaWebhookCacheTTL                           = "identity.schema_webhook.cache_ttl"
+	ViperKeyIdentityMaxAddresses                                    = "identity.addresses.max"
+	ViperKeyIdentityMaxAddressesAdminAPI                            = "identity.addresses.enforce_for_admin_api"
+	ViperKeyIdentityTraitsMaxDepth                                  = "identity.traits.max_depth"
+	ViperKeyIdentityTraitsMaxProperties                             = "identity.traits.max_properties"
+	ViperKeyIdentityBatchMaxSize                                    = "identity.batch.max_size"
+	ViperKeyIdentityCacheBackend                                    = "identity.cache.backend"
+	ViperKeyIdentityCacheTTL                                        = "identity.cache.ttl"
+	ViperKeyIdentityCredentialHistoryEnabled                        = "identity.credential_history.enabled"
+	ViperKeyIdentityCacheRedisURL                                   = "identity.cache.redis.url"
+	ViperKeyTenancySource                                           = "tenancy.source"
+	ViperKeyTenancyHeader                                           = "tenancy.header"
+	ViperKeyTenancyMaxOpenDatabases                                 = "tenancy.max_open_databases"
+	ViperKeyTenancyTenants                                          = "tenancy.tenants"
 	ViperKeyHasherAlgorithm                                         = "hashers.algorithm"
 	ViperKeyHasherArgon2ConfigMemory                                = "hashers.argon2.memory"
 	ViperKeyHasherArgon2ConfigIterations                            = "hashers.argon2.iterations"
@@ -99,7 +171,32 @@ const (
 	ViperKeyHasherBcryptCost                                        = "hashers.bcrypt.cost"
 	ViperKeyPasswordMaxBreaches                                     = "selfservice.methods.password.config.max_breaches"
 	ViperKeyIgnoreNetworkErrors                                     = "selfservice.methods.password.config.ignore_network_errors"
+	ViperKeyPasswordIdentifierSimilarityEnabled                     = "selfservice.methods.password.config.identifier_similarity.enabled"
+	ViperKeyPasswordIdentifierSimilarityMinDistance                 = "selfservice.methods.password.config.identifier_similarity.min_distance"
+	ViperKeyPasswordIdentifierSimilarityMaxSubstringRatio           = "selfservice.methods.password.config.identifier_similarity.max_substring_ratio"
+	ViperKeyPasswordIdentifierSimilarityTraits                      = "selfservice.methods.password.config.identifier_similarity.traits"
+	ViperKeyPasswordRehashOnLogin                                   = "selfservice.methods.password.config.rehash_on_login"
+	ViperKeyPasswordLockoutEnabled                                  = "selfservice.methods.password.config.lockout.enabled"
+	ViperKeyPasswordLockoutMaxAttempts                              = "selfservice.methods.password.config.lockout.max_attempts"
+	ViperKeyPasswordLockoutWindow                                   = "selfservice.methods.password.config.lockout.window"
+	ViperKeyPasswordLockoutDuration                                 = "selfservice.methods.password.config.lockout.duration"
+	ViperKeyLinkLifespan                                            = "selfservice.methods.link.config.lifespan"
+	ViperKeyLinkTokenValidationRateLimitRequests                    = "selfservice.methods.link.config.token_validation_rate_limit.requests"
+	ViperKeyLinkTokenValidationRateLimitPeriod                      = "selfservice.methods.link.config.token_validation_rate_limit.period"
+	ViperKeyCodeLifespan                                            = "selfservice.methods.code.config.lifespan"
+	ViperKeyCodeMaxAttempts                                         = "selfservice.methods.code.config.max_attempts"
+	ViperKeyAccountDeletionRetention                                = "selfservice.methods.account_deletion.config.retention"
 	ViperKeyVersion                                                 = "version"
+	ViperKeyLogAnonymization                                        = "log.anonymization"
+	ViperKeyEventsSource                                            = "events.source"
+	ViperKeyEventsSinkType                                          = "events.sink.type"
+	ViperKeyEventsSinkURL                                           = "events.sink.url"
+	ViperKeyEventsSinkTopic                                         = "events.sink.topic"
+	ViperKeyMaintenanceEnabled                                      = "maintenance.enabled"
+	ViperKeyMaintenanceRetryAfter                                   = "maintenance.retry_after"
+	ViperKeyRateLimitHeaders                                        = "rate_limit.headers"
+	ViperKeyRedactionEnabled                                        = "redaction.enabled"
+	ViperKeyRedactionPatterns                                       = "redaction.patterns"
 	Argon2DefaultMemory                                             = 128 * bytesize.MB
 	Argon2DefaultIterations                                  uint32 = 1
 	Argon2DefaultSaltLength                                  uint32 = 16
@@ -142,9 +239,61 @@ type (
 	PasswordPolicy struct {
 		MaxBreaches         uint `json:"max_breaches"`
 		IgnoreNetworkErrors bool `json:"ignore_network_errors"`
+
+		// IdentifierSimilarity rejects passwords which resemble the identity's identifiers.
+		IdentifierSimilarity PasswordIdentifierSimilarity `json:"identifier_similarity"`
+	}
+	PasswordLockout struct {
+		Enabled bool `json:"enabled"`
+
+		// MaxAttempts is the number of failed logins within Window after which the identifier is locked.
+		MaxAttempts int `json:"max_attempts"`
+
+		// Window is the period in which failed logins are counted.
+		Window time.Duration `json:"window"`
+
+		// Duration is how long the identifier stays locked.
+		Duration time.Duration `json:"duration"`
+	}
+	PasswordIdentifierSimilarity struct {
+		Enabled bool `json:"enabled"`
+
+		// MinDistance is the minimum Levenshtein distance between the password and an identifier.
+		MinDistance int `json:"min_distance"`
+
+		// MaxSubstringRatio is the maximum length of the longest substring shared by the password and an
+		// identifier relative to the password's length.
+		MaxSubstringRatio float64 `json:"max_substring_ratio"`
+
+		// Traits are the paths of traits which are compared like identifiers, e.g. `name.first`.
+		Traits []string `json:"traits"`
+	}
+	Schemas    []Schema
+	HostSchema struct {
+		Host     string `json:"host"`
+		SchemaID string `json:"schema_id"`
 	}
-	Schemas []Schema
-	Config  struct {
+	HostSchemas []HostSchema
+	Tenant      struct {
+		ID  string `json:"id"`
+		DSN string `json:"dsn"`
+	}
+	Tenants []Tenant
+	// HSTS configures the Strict-Transport-Security header of an endpoint.
+	HSTS struct {
+		Enabled           bool
+		MaxAge            time.Duration
+		IncludeSubdomains bool
+		Preload           bool
+	}
+	// UINodeAttributes adds custom attributes to the nodes of all flows which match the name (a
+	// glob pattern) and group. Empty selectors match all nodes.
+	UINodeAttributes struct {
+		Name       string            `json:"name"`
+		Group      string            `json:"group"`
+		Attributes map[string]string `json:"attributes"`
+	}
+	Config struct {
 		l *logrusx.Logger
 		p *configx.Provider
 	}
@@ -203,7 +352,7 @@ func MustNew(t *testing.T, l *logrusx.Logger, opts ...configx.OptionModifier) *C
 func New(ctx context.Context, l *logrusx.Logger, opts ...configx.OptionModifier) (*Config, error) {
 	opts = append([]configx.OptionModifier{
 		configx.WithStderrValidationReporter(),
-		configx.OmitKeysFromTracing("dsn", "secrets.default", "secrets.cookie", "client_secret"),
+		configx.OmitKeysFromTracing("dsn", "secrets.default", "secrets.cookie", "secrets.cipher", "client_secret"),
 		configx.WithImmutables("serve", "profiling", "log"),
 		configx.WithLogrusWatcher(l),
 		configx.WithLogger(l),
@@ -223,6 +372,67 @@ func (p *Config) Source() *configx.Provider {
 	return p.p
 }
 
+// AdminIPFilterAllow returns the networks which may access the admin API. An empty list allows all sources
+// which are not denied.
+func (p *Config) AdminIPFilterAllow() []*net.IPNet {
+	return p.networks(ViperKeyAdminIPFilterAllow)
+}
+
+// AdminIPFilterDeny returns the networks which may not access the admin API.
+func (p *Config) AdminIPFilterDeny() []*net.IPNet {
+	return p.networks(ViperKeyAdminIPFilterDeny)
+}
+
+// PublicRouteTrailingSlash returns true if a trailing slash should be ignored when matching the
+// self-service routes.
+func (p *Config) PublicRouteTrailingSlash() bool {
+	return p.p.Bool(ViperKeyPublicRouteTrailingSlash)
+}
+
+// PublicRouteCaseInsensitive returns true if the self-service routes should be matched regardless of case.
+func (p *Config) PublicRouteCaseInsensitive() bool {
+	return p.p.Bool(ViperKeyPublicRouteCaseInsensitive)
+}
+
+// PublicMaxRequestBodySize returns the maximum size in bytes of request bodies sent to the public endpoint
+// or 0 if the size is not limited.
+func (p *Config) PublicMaxRequestBodySize() int64 {
+	return int64(p.p.IntF(ViperKeyPublicMaxRequestBodySize, 1024*1024))
+}
+
+// TrustedProxies returns the networks of reverse proxies whose `X-Forwarded-For` header is trusted when
+// determining the client IP.
+func (p *Config) TrustedProxies() []*net.IPNet {
+	return p.networks(ViperKeyTrustedProxies)
+}
+
+// LogAnonymization returns `off` if identity IDs and IP addresses are logged as they are, `hash` if they
+// are replaced by a keyed hash, and `truncate` if only a prefix of them is logged.
+func (p *Config) LogAnonymization() string {
+	return p.p.StringF(ViperKeyLogAnonymization, "off")
+}
+
+func (p *Config) networks(key string) []*net.IPNet {
+	values := p.p.Strings(key)
+	networks := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		if !strings.Contains(value, "/") {
+			if ip := net.ParseIP(value); ip != nil && ip.To4() != nil {
+				value += "/32"
+			} else {
+				value += "/128"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			p.l.WithError(err).Fatalf("Configuration value from key %s is not a valid IP address or CIDR range: %s", key, value)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
 func (p *Config) CORS(iface string) (cors.Options, bool) {
 	switch iface {
 	case "admin":
@@ -309,6 +519,46 @@ func (p *Config) DefaultIdentityTraitsSchemaURL() *url.URL {
 	return p.ParseURIOrFail(ViperKeyDefaultIdentitySchemaURL)
 }
 
+// IdentityMaxAddresses returns the maximum number of verifiable and recovery addresses an identity may have.
+// Zero means that the number of addresses is not limited.
+func (p *Config) IdentityMaxAddresses() int {
+	return p.p.Int(ViperKeyIdentityMaxAddresses)
+}
+
+// IdentityTraitsMaxDepth returns how deeply the traits may be nested. 0 disables the limit.
+func (p *Config) IdentityTraitsMaxDepth() int {
+	return p.p.IntF(ViperKeyIdentityTraitsMaxDepth, 16)
+}
+
+// IdentityTraitsMaxProperties returns how many properties the traits may have in total. 0 disables the limit.
+func (p *Config) IdentityTraitsMaxProperties() int {
+	return p.p.IntF(ViperKeyIdentityTraitsMaxProperties, 1000)
+}
+
+// IdentityMaxAddressesAdminAPI returns true if the address limit also applies to the admin API.
+func (p *Config) IdentityMaxAddressesAdminAPI() bool {
+	return p.p.Bool(ViperKeyIdentityMaxAddressesAdminAPI)
+}
+
+// IdentityCacheBackend returns the backend of the identity cache. It is either "memory", "redis", or empty if
+// identities are not cached.
+func (p *Config) IdentityCacheBackend() string {
+	return p.p.String(ViperKeyIdentityCacheBackend)
+}
+
+// IdentityCredentialHistoryEnabled returns true if changes of credentials are recorded in the credential history.
+func (p *Config) IdentityCredentialHistoryEnabled() bool {
+	return p.p.Bool(ViperKeyIdentityCredentialHistoryEnabled)
+}
+
+func (p *Config) IdentityCacheTTL() time.Duration {
+	return p.p.DurationF(ViperKeyIdentityCacheTTL, time.Minute)
+}
+
+func (p *Config) IdentityCacheRedisURL() string {
+	return p.p.String(ViperKeyIdentityCacheRedisURL)
+}
+
 func (p *Config) IdentityTraitsSchemas() Schemas {
 	ds := Schema{
 		ID:  DefaultIdentityTraitsSchemaID,
@@ -339,6 +589,110 @@ func (p *Config) IdentityTraitsSchemas() Schemas {
 	return append(ss, ds)
 }
 
+// IdentityHostSchemas returns the identity schemas configured by `identity.host_schemas` for the hosts
+// registrations are started on.
+func (p *Config) IdentityHostSchemas() HostSchemas {
+	if !p.p.Exists(ViperKeyIdentityHostSchemas) {
+		return nil
+	}
+
+	out, err := p.p.Marshal(kjson.Parser())
+	if err != nil {
+		p.l.WithError(err).Fatalf("Unable to decode values from %s.", ViperKeyIdentityHostSchemas)
+		return nil
+	}
+
+	var hs HostSchemas
+	if err := json.Unmarshal([]byte(gjson.GetBytes(out, ViperKeyIdentityHostSchemas).Raw), &hs); err != nil {
+		p.l.WithError(err).Fatalf("Unable to decode values from %s.", ViperKeyIdentityHostSchemas)
+		return nil
+	}
+
+	return hs
+}
+
+// IdentityTraitsSchemaIDForHost returns the ID of the identity traits schema configured for the host, or the
+// default schema's ID if the host has none. Hosts are compared case-insensitively.
+func (p *Config) IdentityTraitsSchemaIDForHost(host string) string {
+	for _, hs := range p.IdentityHostSchemas() {
+		if strings.EqualFold(hs.Host, host) {
+			return hs.SchemaID
+		}
+	}
+	return DefaultIdentityTraitsSchemaID
+}
+
+// IdentitySchemaWebhookURL returns the URL of the web hook which selects the identity traits schema of
+// new identities or nil if `identity.host_schemas` is used instead.
+func (p *Config) IdentitySchemaWebhookURL() *url.URL {
+	return p.p.RequestURIF(ViperKeyIdentitySchemaWebhookURL, nil)
+}
+
+func (p *Config) IdentitySchemaWebhookTimeout() time.Duration {
+	return p.p.DurationF(ViperKeyIdentitySchemaWebhookTimeout, 5*time.Second)
+}
+
+// IdentitySchemaWebhookCacheTTL returns how long the schema selected by the web hook is reused for
+// requests with the same context key.
+func (p *Config) IdentitySchemaWebhookCacheTTL() time.Duration {
+	return p.p.DurationF(ViperKeyIdentitySchemaWebhookCacheTTL, time.Minute)
+}
+
+// IdentityBatchMaxSize returns how many identities may be created with a single batch request.
+func (p *Config) IdentityBatchMaxSize() int {
+	return p.p.IntF(ViperKeyIdentityBatchMaxSize, 1000)
+}
+
+// Tenants returns the tenants configured by `tenancy.tenants`. Each tenant has its own database.
+func (p *Config) Tenants() Tenants {
+	if !p.p.Exists(ViperKeyTenancyTenants) {
+		return nil
+	}
+
+	out, err := p.p.Marshal(kjson.Parser())
+	if err != nil {
+		p.l.WithError(err).Fatalf("Unable to decode values from %s.", ViperKeyTenancyTenants)
+		return nil
+	}
+
+	var tt Tenants
+	if err := json.Unmarshal([]byte(gjson.GetBytes(out, ViperKeyTenancyTenants).Raw), &tt); err != nil {
+		p.l.WithError(err).Fatalf("Unable to decode values from %s.", ViperKeyTenancyTenants)
+		return nil
+	}
+
+	return tt
+}
+
+// TenancyEnabled returns true if at least one tenant is configured.
+func (p *Config) TenancyEnabled() bool {
+	return len(p.Tenants()) > 0
+}
+
+// TenantDSN returns the DSN of the tenant's database and false if the tenant is unknown.
+func (p *Config) TenantDSN(tenant string) (string, bool) {
+	for _, t := range p.Tenants() {
+		if t.ID == tenant {
+			return t.DSN, true
+		}
+	}
+	return "", false
+}
+
+// TenancySource returns where the tenant of a request is taken from: `header` or `subdomain`.
+func (p *Config) TenancySource() string {
+	return p.p.StringF(ViperKeyTenancySource, "header")
+}
+
+func (p *Config) TenancyHeader() string {
+	return p.p.StringF(ViperKeyTenancyHeader, "X-Tenant-ID")
+}
+
+// TenancyMaxOpenDatabases returns how many tenant databases may be connected at the same time.
+func (p *Config) TenancyMaxOpenDatabases() int {
+	return p.p.IntF(ViperKeyTenancyMaxOpenDatabases, 10)
+}
+
 func (p *Config) AdminListenOn() string {
 	return p.listenOn("admin")
 }
@@ -347,6 +701,38 @@ func (p *Config) PublicListenOn() string {
 	return p.listenOn("public")
 }
 
+// TLS returns the paths of the certificate and the private key of the "public" or "admin" endpoint. It returns
+// false if the endpoint is served over plain HTTP.
+func (p *Config) TLS(iface string) (certPath, keyPath string, ok bool) {
+	certPath = p.p.String("serve." + iface + ".tls.cert.path")
+	keyPath = p.p.String("serve." + iface + ".tls.key.path")
+	return certPath, keyPath, len(certPath) > 0 && len(keyPath) > 0
+}
+
+// TLSMinVersion returns the minimum TLS version accepted by the "public" or "admin" endpoint.
+func (p *Config) TLSMinVersion(iface string) uint16 {
+	switch v := p.p.StringF("serve."+iface+".tls.min_version", "1.2"); v {
+	case "1.2":
+		return tls.VersionTLS12
+	case "1.3":
+		return tls.VersionTLS13
+	default:
+		p.l.Fatalf("serve.%s.tls.min_version must be 1.2 or 1.3 but got: %s", iface, v)
+		return tls.VersionTLS12
+	}
+}
+
+// HSTS returns the Strict-Transport-Security configuration of the "public" or "admin" endpoint.
+func (p *Config) HSTS(iface string) *HSTS {
+	prefix := "serve." + iface + ".hsts."
+	return &HSTS{
+		Enabled:           p.p.Bool(prefix + "enabled"),
+		MaxAge:            p.p.DurationF(prefix+"max_age", 365*24*time.Hour),
+		IncludeSubdomains: p.p.Bool(prefix + "include_subdomains"),
+		Preload:           p.p.Bool(prefix + "preload"),
+	}
+}
+
 func (p *Config) DSN() string {
 	dsn := p.p.String(ViperKeyDSN)
 
@@ -427,6 +813,50 @@ func (p *Config) SelfServiceFlowRegistrationAfterHooks(strategy string) []SelfSe
 	return p.selfServiceHooks(HookStrategyKey(ViperKeySelfServiceRegistrationAfter, strategy))
 }
 
+// SelfServiceUINodeAttributes returns the custom node attributes configured by
+// `selfservice.ui_node_attributes`.
+func (p *Config) SelfServiceUINodeAttributes() []UINodeAttributes {
+	if !p.p.Exists(ViperKeySelfServiceUINodeAttributes) {
+		return nil
+	}
+
+	out, err := p.p.Marshal(kjson.Parser())
+	if err != nil {
+		p.l.WithError(err).Warnf("Unable to decode values from %s.", ViperKeySelfServiceUINodeAttributes)
+		return nil
+	}
+
+	var attributes []UINodeAttributes
+	if err := json.Unmarshal([]byte(gjson.GetBytes(out, ViperKeySelfServiceUINodeAttributes).Raw), &attributes); err != nil {
+		p.l.WithError(err).Warnf("Unable to decode values from %s.", ViperKeySelfServiceUINodeAttributes)
+		return nil
+	}
+
+	return attributes
+}
+
+// SelfServiceUIMessages returns the message texts configured by `selfservice.ui_messages` keyed by
+// the message ID.
+func (p *Config) SelfServiceUIMessages() map[string]string {
+	if !p.p.Exists(ViperKeySelfServiceUIMessages) {
+		return nil
+	}
+
+	out, err := p.p.Marshal(kjson.Parser())
+	if err != nil {
+		p.l.WithError(err).Warnf("Unable to decode values from %s.", ViperKeySelfServiceUIMessages)
+		return nil
+	}
+
+	var messages map[string]string
+	if err := json.Unmarshal([]byte(gjson.GetBytes(out, ViperKeySelfServiceUIMessages).Raw), &messages); err != nil {
+		p.l.WithError(err).Warnf("Unable to decode values from %s.", ViperKeySelfServiceUIMessages)
+		return nil
+	}
+
+	return messages
+}
+
 func (p *Config) SelfServiceStrategy(strategy string) *SelfServiceStrategy {
 	config := "{}"
 	out, err := p.p.Marshal(kjson.Parser())
@@ -493,6 +923,70 @@ func (p *Config) SecretsSession() [][]byte {
 	return result
 }
 
+// SecretsCipher returns the secrets used to encrypt identity traits at rest. The first secret encrypts
+// while all secrets decrypt. Falls back to `secrets.default` if unset.
+func (p *Config) SecretsCipher() [][]byte {
+	secrets := p.p.Strings(ViperKeySecretsCipher)
+	if len(secrets) == 0 {
+		return p.SecretsDefault()
+	}
+
+	result := make([][]byte, len(secrets))
+	for k, v := range secrets {
+		result[k] = []byte(v)
+	}
+
+	return result
+}
+
+// SecretsSessionJWT returns the secrets session tokens in the JWT format are signed with. The first secret
+// signs while all secrets verify. Falls back to `secrets.default` if unset.
+func (p *Config) SecretsSessionJWT() [][]byte {
+	secrets := p.p.Strings(ViperKeySecretsSessionJWT)
+	if len(secrets) == 0 {
+		return p.SecretsDefault()
+	}
+
+	result := make([][]byte, len(secrets))
+	for k, v := range secrets {
+		result[k] = []byte(v)
+	}
+
+	return result
+}
+
+// SecretsWebHook returns the secrets used to sign web hook requests. Web hook requests are not signed
+// if it is empty.
+func (p *Config) SecretsWebHook() [][]byte {
+	secrets := p.p.Strings(ViperKeySecretsWebHook)
+	result := make([][]byte, len(secrets))
+	for k, v := range secrets {
+		result[k] = []byte(v)
+	}
+
+	return result
+}
+
+// SecretsWebHookTLS returns the PEM encoded client certificate and private key with the given name which
+// web hooks present to endpoints requiring mutual TLS. It returns false if there is no such certificate.
+func (p *Config) SecretsWebHookTLS(name string) (cert, key []byte, ok bool) {
+	cert = []byte(p.p.String(ViperKeySecretsWebHookTLS + "." + name + ".certificate"))
+	key = []byte(p.p.String(ViperKeySecretsWebHookTLS + "." + name + ".key"))
+	return cert, key, len(cert) > 0 && len(key) > 0
+}
+
+// SecretsPepper returns the secrets password hashes are keyed with. The first one is used for new hashes
+// while the others only verify existing ones.
+func (p *Config) SecretsPepper() [][]byte {
+	secrets := p.p.Strings(ViperKeySecretsPepper)
+	result := make([][]byte, len(secrets))
+	for k, v := range secrets {
+		result[k] = []byte(v)
+	}
+
+	return result
+}
+
 func (p *Config) SelfServiceBrowserDefaultReturnTo() *url.URL {
 	return p.ParseURIOrFail(ViperKeySelfServiceBrowserDefaultReturnTo)
 }
@@ -597,6 +1091,73 @@ func (p *Config) CourierSMTPURL() *url.URL {
 	return p.ParseURIOrFail(ViperKeyCourierSMTPURL)
 }
 
+// EventsSource returns the CloudEvents source of emitted events which defaults to the public base URL.
+func (p *Config) EventsSource() string {
+	return p.p.StringF(ViperKeyEventsSource, p.SelfPublicURL(nil).String())
+}
+
+// EventsSinkType returns `http` if events are POSTed to the sink URL and `kafka` if they are produced
+// through the Kafka REST Proxy at the sink URL.
+func (p *Config) EventsSinkType() string {
+	return p.p.StringF(ViperKeyEventsSinkType, "http")
+}
+
+// EventsSinkURL returns the URL events are sent to or nil if events are not emitted.
+func (p *Config) EventsSinkURL() *url.URL {
+	if len(p.p.String(ViperKeyEventsSinkURL)) == 0 {
+		return nil
+	}
+	return p.ParseURIOrFail(ViperKeyEventsSinkURL)
+}
+
+func (p *Config) EventsSinkTopic() string {
+	return p.p.StringF(ViperKeyEventsSinkTopic, "kratos-events")
+}
+
+// MaintenanceModeEnabled returns true if requests which write data are rejected while read requests
+// such as whoami are still served.
+func (p *Config) MaintenanceModeEnabled() bool {
+	return p.p.Bool(ViperKeyMaintenanceEnabled)
+}
+
+// MaintenanceRetryAfter returns how long clients are asked to wait before retrying a request which was
+// rejected because of the maintenance mode.
+func (p *Config) MaintenanceRetryAfter() time.Duration {
+	return p.p.DurationF(ViperKeyMaintenanceRetryAfter, 5*time.Minute)
+}
+
+// RateLimitHeadersEnabled returns true if responses to rate-limited requests contain the `RateLimit-Limit`,
+// `RateLimit-Remaining`, and `RateLimit-Reset` headers.
+func (p *Config) RateLimitHeadersEnabled() bool {
+	return p.p.BoolF(ViperKeyRateLimitHeaders, true)
+}
+
+// RedactionEnabled returns true if secrets are removed from error responses and logs.
+func (p *Config) RedactionEnabled() bool {
+	return p.p.BoolF(ViperKeyRedactionEnabled, true)
+}
+
+// RedactionPatterns returns the regular expressions whose matches are removed from error responses and
+// logs in addition to the built-in patterns.
+func (p *Config) RedactionPatterns() []string {
+	return p.p.Strings(ViperKeyRedactionPatterns)
+}
+
+// ConfiguredSecrets returns all secrets set in `secrets`. Unlike SecretsDefault, it never generates a secret.
+func (p *Config) ConfiguredSecrets() []string {
+	var secrets []string
+	for _, key := range []string{ViperKeySecretsDefault, ViperKeySecretsCookie, ViperKeySecretsCipher, ViperKeySecretsWebHook, ViperKeySecretsPepper, ViperKeySecretsSessionJWT} {
+		secrets = append(secrets, p.p.Strings(key)...)
+	}
+	for _, name := range p.p.MapKeys(ViperKeySecretsWebHookTLS) {
+		secrets = append(secrets, p.p.String(ViperKeySecretsWebHookTLS+"."+name+".key"))
+	}
+	for _, key := range []string{"auth.config.password", "auth.config.value"} {
+		secrets = append(secrets, p.p.String(ViperKeyCourierSMSRequestConfig+"."+key))
+	}
+	return secrets
+}
+
 func (p *Config) SelfServiceFlowLoginUI() *url.URL {
 	return p.ParseURIOrFail(ViperKeySelfServiceLoginUI)
 }
@@ -609,6 +1170,10 @@ func (p *Config) SelfServiceFlowErrorURL() *url.URL {
 	return p.ParseURIOrFail(ViperKeySelfServiceErrorUI)
 }
 
+func (p *Config) SelfServiceFlowErrorStructuredResponse() bool {
+	return p.p.Bool(ViperKeySelfServiceErrorStructuredResponse)
+}
+
 func (p *Config) SelfServiceFlowRegistrationUI() *url.URL {
 	return p.ParseURIOrFail(ViperKeySelfServiceRegistrationUI)
 }
@@ -626,6 +1191,85 @@ func (p *Config) SessionPersistentCookie() bool {
 	return p.p.Bool(ViperKeySessionPersistentCookie)
 }
 
+// SessionClaimsMapperURL returns nil when no claims mapper is configured.
+func (p *Config) SessionClaimsMapperURL() *url.URL {
+	if len(p.p.String(ViperKeySessionClaimsMapperURL)) == 0 {
+		return nil
+	}
+	return p.ParseURIOrFail(ViperKeySessionClaimsMapperURL)
+}
+
+// SessionCacheTTL returns how long the session cache keeps a session. Sessions are never cached past their expiry.
+func (p *Config) SessionCacheTTL() time.Duration {
+	return p.p.DurationF(ViperKeySessionCacheTTL, time.Minute)
+}
+
+// SessionWhoamiCacheTTL returns 0 if whoami responses are not cached.
+func (p *Config) SessionWhoamiCacheTTL() time.Duration {
+	return p.p.DurationF(ViperKeySessionWhoamiCacheTTL, 0)
+}
+
+// SessionWhoamiRateLimit returns the number of whoami requests allowed per client IP and period. The
+// number is 0 if requests are not limited.
+func (p *Config) SessionWhoamiRateLimit() (int, time.Duration) {
+	return p.p.IntF(ViperKeySessionWhoamiRateLimitRequests, 0), p.p.DurationF(ViperKeySessionWhoamiRateLimitPeriod, time.Second)
+}
+
+// SessionFingerprintMode returns `off`, `log`, or `enforce` and defines what happens if a session is used
+// by a client whose fingerprint differs from the one the session was issued to.
+func (p *Config) SessionFingerprintMode() string {
+	return p.p.StringF(ViperKeySessionFingerprintMode, "off")
+}
+
+// SessionFingerprintAttributes returns the client attributes a session is bound to.
+func (p *Config) SessionFingerprintAttributes() []string {
+	return p.p.StringsF(ViperKeySessionFingerprintAttributes, []string{"user_agent"})
+}
+
+// SessionAnomalyHooks returns the names of the hooks which are executed if a session is used by a client
+// which differs markedly from the client it was issued to.
+func (p *Config) SessionAnomalyHooks() []string {
+	return p.p.StringsF(ViperKeySessionAnomalyHooks, []string{})
+}
+
+// SessionImpersonationEnabled returns true if administrators may create sessions for identities using the admin API.
+func (p *Config) SessionImpersonationEnabled() bool {
+	return p.p.Bool(ViperKeySessionImpersonationEnabled)
+}
+
+// SessionImpersonationLifespan returns how long impersonation sessions are valid.
+func (p *Config) SessionImpersonationLifespan() time.Duration {
+	return p.p.DurationF(ViperKeySessionImpersonationLifespan, 15*time.Minute)
+}
+
+// SessionImpersonationAllowedCredentialChanges returns the credentials which may be changed using an impersonation session.
+func (p *Config) SessionImpersonationAllowedCredentialChanges() []string {
+	return p.p.StringsF(ViperKeySessionImpersonationAllowedCredentialChanges, []string{})
+}
+
+// SessionTokenPrefix returns the prefix of session tokens handed out to clients, e.g. to tell the
+// sessions of several environments apart.
+func (p *Config) SessionTokenPrefix() string {
+	return p.p.String(ViperKeySessionTokenPrefix)
+}
+
+// SessionTokenFormat returns the format of the session tokens handed out in API responses, either "opaque"
+// or "jwt".
+func (p *Config) SessionTokenFormat() string {
+	return p.p.StringF(ViperKeySessionTokenFormat, "opaque")
+}
+
+// SessionJWTTraits returns the paths of the traits which are embedded in session tokens in the JWT format.
+func (p *Config) SessionJWTTraits() []string {
+	return p.p.Strings(ViperKeySessionJWTTraits)
+}
+
+// SessionJWTCheckRevocation returns true if session tokens in the JWT format are only accepted while their
+// session is active in the database.
+func (p *Config) SessionJWTCheckRevocation() bool {
+	return p.p.BoolF(ViperKeySessionJWTCheckRevocation, true)
+}
+
 func (p *Config) SelfServiceBrowserWhitelistedReturnToDomains() (us []url.URL) {
 	src := p.p.Strings(ViperKeyURLsWhitelistedReturnToDomains)
 	for k, u := range src {
@@ -645,6 +1289,34 @@ func (p *Config) SelfServiceBrowserWhitelistedReturnToDomains() (us []url.URL) {
 	return us
 }
 
+// SelfServiceBrowserRequireHTTPSReturnTo returns whether `?return_to=` URLs must use HTTPS. Unless configured
+// explicitly, this is enabled if the public base URL uses HTTPS.
+func (p *Config) SelfServiceBrowserRequireHTTPSReturnTo() bool {
+	if p.p.Exists(ViperKeyURLsRequireHTTPSReturnTo) {
+		return p.p.Bool(ViperKeyURLsRequireHTTPSReturnTo)
+	}
+
+	return p.SelfPublicURL(nil).Scheme == "https"
+}
+
+// SelfServiceFlowLoginAccessRuleURL returns nil when no login access rule is configured.
+func (p *Config) SelfServiceFlowLoginAccessRuleURL() *url.URL {
+	if len(p.p.String(ViperKeySelfServiceLoginAccessRuleURL)) == 0 {
+		return nil
+	}
+	return p.ParseURIOrFail(ViperKeySelfServiceLoginAccessRuleURL)
+}
+
+func (p *Config) SelfServiceFlowLoginAccessRuleMessage() string {
+	return p.p.StringF(ViperKeySelfServiceLoginAccessRuleMessage, "You are not allowed to sign in.")
+}
+
+// SelfServiceFlowLoginMaxConcurrentFlowsPerIdentifier returns how many unexpired login flows may be used
+// with the same identifier. Zero disables the limit.
+func (p *Config) SelfServiceFlowLoginMaxConcurrentFlowsPerIdentifier() int {
+	return p.p.IntF(ViperKeySelfServiceLoginMaxConcurrentFlowsPerIdentifier, 0)
+}
+
 func (p *Config) SelfServiceFlowLoginRequestLifespan() time.Duration {
 	return p.p.DurationF(ViperKeySelfServiceLoginRequestLifespan, time.Hour)
 }
@@ -653,6 +1325,32 @@ func (p *Config) SelfServiceFlowSettingsFlowLifespan() time.Duration {
 	return p.p.DurationF(ViperKeySelfServiceSettingsRequestLifespan, time.Hour)
 }
 
+// SelfServiceFlowRegistrationDuplicateIdentifierPolicy returns `error` if registering with an identifier which
+// is already in use fails with an error and `notify` if the user is told to check their email while the owner
+// of the existing account is notified instead. With `notify`, registrations with new identifiers do not issue a
+// session either.
+func (p *Config) SelfServiceFlowRegistrationDuplicateIdentifierPolicy() string {
+	return p.p.StringF(ViperKeySelfServiceRegistrationDuplicateIdentifierPolicy, "error")
+}
+
+// SelfServiceFlowRegistrationBlocklist returns the identifiers and the (wildcard) email domains which
+// may not be used to sign up.
+func (p *Config) SelfServiceFlowRegistrationBlocklist() (identifiers, domains []string) {
+	return p.p.Strings(ViperKeySelfServiceRegistrationBlocklistIdentifiers), p.p.Strings(ViperKeySelfServiceRegistrationBlocklistDomains)
+}
+
+// SelfServiceFlowRegistrationBlocklistURL returns the location of an additional blocklist or nil if there is none.
+func (p *Config) SelfServiceFlowRegistrationBlocklistURL() *url.URL {
+	if len(p.p.String(ViperKeySelfServiceRegistrationBlocklistURL)) == 0 {
+		return nil
+	}
+	return p.ParseURIOrFail(ViperKeySelfServiceRegistrationBlocklistURL)
+}
+
+func (p *Config) SelfServiceFlowRegistrationBlocklistRefreshInterval() time.Duration {
+	return p.p.DurationF(ViperKeySelfServiceRegistrationBlocklistRefreshInterval, time.Hour)
+}
+
 func (p *Config) SelfServiceFlowRegistrationRequestLifespan() time.Duration {
 	return p.p.DurationF(ViperKeySelfServiceRegistrationRequestLifespan, time.Hour)
 }
@@ -669,6 +1367,49 @@ func (p *Config) CourierSMTPFromName() string {
 	return p.p.StringF(ViperKeyCourierSMTPFromName, "")
 }
 
+func (p *Config) CourierDrainTimeout() time.Duration {
+	return p.p.DurationF(ViperKeyCourierDrainTimeout, time.Second*10)
+}
+
+func (p *Config) CourierMaxConcurrentSends() int {
+	if n := p.p.IntF(ViperKeyCourierMaxConcurrentSends, 1); n > 0 {
+		return n
+	}
+	return 1
+}
+
+func (p *Config) CourierMinSendInterval() time.Duration {
+	return p.p.DurationF(ViperKeyCourierMinSendInterval, 0)
+}
+
+func (p *Config) CourierSMSEnabled() bool {
+	return p.p.Bool(ViperKeyCourierSMSEnabled)
+}
+
+func (p *Config) CourierSMSFrom() string {
+	return p.p.StringF(ViperKeyCourierSMSFrom, "Ory Kratos")
+}
+
+// CourierSMSRequestConfig returns the configuration of the HTTP request which delivers text messages
+// to the SMS gateway.
+func (p *Config) CourierSMSRequestConfig() json.RawMessage {
+	if !p.p.Exists(ViperKeyCourierSMSRequestConfig) {
+		return json.RawMessage("{}")
+	}
+
+	out, err := p.p.Marshal(kjson.Parser())
+	if err != nil {
+		p.l.WithError(err).Warnf("Unable to decode values from %s.", ViperKeyCourierSMSRequestConfig)
+		return json.RawMessage("{}")
+	}
+
+	config := gjson.GetBytes(out, ViperKeyCourierSMSRequestConfig).Raw
+	if len(config) == 0 {
+		return json.RawMessage("{}")
+	}
+	return json.RawMessage(config)
+}
+
 func (p *Config) CourierTemplatesRoot() string {
 	return p.p.StringF(ViperKeyCourierTemplatesPath, "courier/builtin/templates")
 }
@@ -727,6 +1468,23 @@ func (p *Config) SelfServiceFlowVerificationRequestLifespan() time.Duration {
 	return p.p.DurationF(ViperKeySelfServiceVerificationRequestLifespan, time.Hour)
 }
 
+// SelfServiceFlowVerificationReverification returns `off`, `reset`, or `track` and defines how addresses
+// which are verified again are treated.
+func (p *Config) SelfServiceFlowVerificationReverification() string {
+	return p.p.StringF(ViperKeySelfServiceVerificationReverification, "off")
+}
+
+// SelfServiceFlowVerificationResendCooldown returns how long the user has to wait before the verification
+// email of a flow is sent again.
+func (p *Config) SelfServiceFlowVerificationResendCooldown() time.Duration {
+	return p.p.DurationF(ViperKeySelfServiceVerificationResendCooldown, time.Second*30)
+}
+
+// SelfServiceFlowVerificationMaxResends returns how often the verification email of a flow may be sent again.
+func (p *Config) SelfServiceFlowVerificationMaxResends() int {
+	return p.p.IntF(ViperKeySelfServiceVerificationMaxResends, 5)
+}
+
 func (p *Config) SelfServiceFlowVerificationReturnTo(defaultReturnTo *url.URL) *url.URL {
 	return p.p.RequestURIF(ViperKeySelfServiceVerificationBrowserDefaultReturnTo, defaultReturnTo)
 }
@@ -735,14 +1493,94 @@ func (p *Config) SelfServiceFlowRecoveryReturnTo() *url.URL {
 	return p.p.RequestURIF(ViperKeySelfServiceRecoveryBrowserDefaultReturnTo, p.SelfServiceBrowserDefaultReturnTo())
 }
 
+// SelfServiceFlowRecoveryMode returns `settings` if users change their password in the settings flow after
+// recovering their account and `set_password` if the recovery flow asks for the new password.
+func (p *Config) SelfServiceFlowRecoveryMode() string {
+	return p.p.StringF(ViperKeySelfServiceRecoveryMode, "settings")
+}
+
+// SelfServiceFlowRecoveryAllowedCredentialChanges returns the credential types which may be added, changed, or
+// removed using a session which was issued by the recovery flow.
+func (p *Config) SelfServiceFlowRecoveryAllowedCredentialChanges() []string {
+	return p.p.StringsF(ViperKeySelfServiceRecoveryAllowedCredentialChanges, []string{"password"})
+}
+
 func (p *Config) SelfServiceFlowRecoveryRequestLifespan() time.Duration {
 	return p.p.DurationF(ViperKeySelfServiceRecoveryRequestLifespan, time.Hour)
 }
 
+// SelfServiceFlowRecoveryResendCooldown returns how long the user has to wait before the recovery email of a
+// flow is sent again.
+func (p *Config) SelfServiceFlowRecoveryResendCooldown() time.Duration {
+	return p.p.DurationF(ViperKeySelfServiceRecoveryResendCooldown, time.Second*30)
+}
+
+// SelfServiceFlowRecoveryMaxResends returns how often the recovery email of a flow may be sent again.
+func (p *Config) SelfServiceFlowRecoveryMaxResends() int {
+	return p.p.IntF(ViperKeySelfServiceRecoveryMaxResends, 5)
+}
+
+// SelfServiceLinkMethodLifespan returns how long recovery links are valid. It is independent of the recovery
+// flow's lifespan so that links can be used after the flow they were requested from expired.
+func (p *Config) SelfServiceLinkMethodLifespan() time.Duration {
+	return p.p.DurationF(ViperKeyLinkLifespan, time.Hour)
+}
+
+// SelfServiceCodeMethodLifespan returns how long recovery codes are valid.
+func (p *Config) SelfServiceCodeMethodLifespan() time.Duration {
+	return p.p.DurationF(ViperKeyCodeLifespan, 15*time.Minute)
+}
+
+// SelfServiceCodeMethodMaxAttempts returns how often a recovery code may be entered incorrectly before it
+// is invalidated.
+func (p *Config) SelfServiceCodeMethodMaxAttempts() int {
+	return p.p.IntF(ViperKeyCodeMaxAttempts, 5)
+}
+
+// SelfServiceAccountDeletionRetention returns how long identities which deleted their account are kept before
+// `kratos janitor` removes them for good.
+func (p *Config) SelfServiceAccountDeletionRetention() time.Duration {
+	return p.p.DurationF(ViperKeyAccountDeletionRetention, 30*24*time.Hour)
+}
+
+// SelfServiceLinkMethodTokenValidationRateLimit returns how many tokens a client may validate per period.
+func (p *Config) SelfServiceLinkMethodTokenValidationRateLimit() (int, time.Duration) {
+	return p.p.IntF(ViperKeyLinkTokenValidationRateLimitRequests, 10),
+		p.p.DurationF(ViperKeyLinkTokenValidationRateLimitPeriod, time.Minute)
+}
+
 func (p *Config) SelfServiceFlowSettingsPrivilegedSessionMaxAge() time.Duration {
 	return p.p.DurationF(ViperKeySelfServiceSettingsPrivilegedAuthenticationAfter, time.Hour)
 }
 
+// SelfServiceFlowSettingsRequiresCurrentPassword returns true if the given settings strategy (e.g. profile)
+// requires the identity to confirm the change by entering its current password.
+func (p *Config) SelfServiceFlowSettingsRequiresCurrentPassword(strategy string) bool {
+	for _, s := range p.p.Strings(ViperKeySelfServiceSettingsRequireCurrentPassword) {
+		if s == strategy {
+			return true
+		}
+	}
+	return false
+}
+
+// SelfServiceFlowSettingsRequiresVerifiedAddress returns true if the given settings strategy (e.g. password)
+// may only be used by identities with at least one verified address.
+func (p *Config) SelfServiceFlowSettingsRequiresVerifiedAddress(strategy string) bool {
+	for _, s := range p.p.Strings(ViperKeySelfServiceSettingsRequireVerifiedAddress) {
+		if s == strategy {
+			return true
+		}
+	}
+	return false
+}
+
+// SelfServiceFlowSettingsUnchanged returns `skip` or `save` and defines how submissions which do not change
+// the identity are treated.
+func (p *Config) SelfServiceFlowSettingsUnchanged() string {
+	return p.p.StringF(ViperKeySelfServiceSettingsUnchanged, "skip")
+}
+
 func (p *Config) SessionSameSiteMode() http.SameSite {
 	switch p.p.StringF(ViperKeySessionSameSite, "Lax") {
 	case "Lax":
@@ -789,9 +1627,31 @@ func (p *Config) PasswordPolicyConfig() *PasswordPolicy {
 	return &PasswordPolicy{
 		MaxBreaches:         uint(p.p.Int(ViperKeyPasswordMaxBreaches)),
 		IgnoreNetworkErrors: p.p.BoolF(ViperKeyIgnoreNetworkErrors, true),
+		IdentifierSimilarity: PasswordIdentifierSimilarity{
+			Enabled:           p.p.BoolF(ViperKeyPasswordIdentifierSimilarityEnabled, true),
+			MinDistance:       p.p.IntF(ViperKeyPasswordIdentifierSimilarityMinDistance, 5),
+			MaxSubstringRatio: p.p.Float64F(ViperKeyPasswordIdentifierSimilarityMaxSubstringRatio, 0.5),
+			Traits:            p.p.StringsF(ViperKeyPasswordIdentifierSimilarityTraits, []string{}),
+		},
 	}
 }
 
+// PasswordLockoutConfig returns how identifiers are locked after repeated failed password logins.
+func (p *Config) PasswordLockoutConfig() *PasswordLockout {
+	return &PasswordLockout{
+		Enabled:     p.p.Bool(ViperKeyPasswordLockoutEnabled),
+		MaxAttempts: p.p.IntF(ViperKeyPasswordLockoutMaxAttempts, 5),
+		Window:      p.p.DurationF(ViperKeyPasswordLockoutWindow, 15*time.Minute),
+		Duration:    p.p.DurationF(ViperKeyPasswordLockoutDuration, 15*time.Minute),
+	}
+}
+
+// PasswordRehashOnLogin returns true if password hashes which do not match the configured hasher are
+// replaced when the identity signs in.
+func (p *Config) PasswordRehashOnLogin() bool {
+	return p.p.Bool(ViperKeyPasswordRehashOnLogin)
+}
+
 func (p *Config) HasherPasswordHashingAlgorithm() string {
 	configValue := p.p.StringF(ViperKeyHasherAlgorithm, DefaultPasswordHashingAlgorithm)
 	switch configValue {
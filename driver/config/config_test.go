@@ -1,6 +1,7 @@
 package config_test
 
 import (
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -144,7 +145,7 @@ func TestViperProvider(t *testing.T) {
 				config  string
 				enabled bool
 			}{
-				{id: "password", enabled: true, config: `{"ignore_network_errors":true,"max_breaches":0}`},
+				{id: "password", enabled: true, config: `{"identifier_similarity":{"enabled":true,"max_substring_ratio":0.5,"min_distance":5},"ignore_network_errors":true,"lockout":{"duration":"15m","enabled":false,"max_attempts":5,"window":"15m"},"max_breaches":0,"rehash_on_login":false}`},
 				{id: "oidc", enabled: true, config: `{"providers":[{"client_id":"a","client_secret":"b","id":"github","provider":"github","mapper_url":"http://test.kratos.ory.sh/default-identity.schema.json"}]}`},
 			} {
 				strategy := p.SelfServiceStrategy(tc.id)
@@ -497,6 +498,70 @@ func TestViperProvider_ReturnTo(t *testing.T) {
 	assert.Equal(t, "https://www.ory.sh/verification", p.SelfServiceFlowVerificationReturnTo(urlx.ParseOrPanic("https://www.ory.sh/")).String())
 }
 
+func TestViperProvider_RequireHTTPSReturnTo(t *testing.T) {
+	l := logrusx.New("", "")
+
+	t.Run("case=defaults to true if the public base url uses https", func(t *testing.T) {
+		p := config.MustNew(t, l, configx.SkipValidation())
+		p.MustSet(config.ViperKeyPublicBaseURL, "https://www.ory.sh/")
+		assert.True(t, p.SelfServiceBrowserRequireHTTPSReturnTo())
+	})
+
+	t.Run("case=defaults to false if the public base url uses http", func(t *testing.T) {
+		p := config.MustNew(t, l, configx.SkipValidation())
+		p.MustSet(config.ViperKeyPublicBaseURL, "http://localhost:4433/")
+		assert.False(t, p.SelfServiceBrowserRequireHTTPSReturnTo())
+	})
+
+	t.Run("case=can be overridden for local development", func(t *testing.T) {
+		p := config.MustNew(t, l, configx.SkipValidation())
+		p.MustSet(config.ViperKeyPublicBaseURL, "https://www.ory.sh/")
+		p.MustSet(config.ViperKeyURLsRequireHTTPSReturnTo, false)
+		assert.False(t, p.SelfServiceBrowserRequireHTTPSReturnTo())
+	})
+}
+
+func TestViperProvider_Tenancy(t *testing.T) {
+	p := config.MustNew(t, logrusx.New("", ""), configx.SkipValidation())
+	assert.False(t, p.TenancyEnabled())
+	assert.Equal(t, "header", p.TenancySource())
+	assert.Equal(t, "X-Tenant-ID", p.TenancyHeader())
+	assert.Equal(t, 10, p.TenancyMaxOpenDatabases())
+
+	p.MustSet(config.ViperKeyTenancyTenants, []map[string]interface{}{
+		{"id": "acme", "dsn": "postgres://acme"},
+		{"id": "umbrella", "dsn": "postgres://umbrella"},
+	})
+	assert.True(t, p.TenancyEnabled())
+
+	dsn, ok := p.TenantDSN("umbrella")
+	assert.True(t, ok)
+	assert.Equal(t, "postgres://umbrella", dsn)
+
+	_, ok = p.TenantDSN("unknown")
+	assert.False(t, ok)
+}
+
+func TestViperProvider_TLS(t *testing.T) {
+	p := config.MustNew(t, logrusx.New("", ""), configx.SkipValidation())
+	_, _, ok := p.TLS("public")
+	assert.False(t, ok)
+	assert.EqualValues(t, tls.VersionTLS12, p.TLSMinVersion("public"))
+
+	p.MustSet("serve.public.tls.cert.path", "/etc/kratos/tls.crt")
+	p.MustSet("serve.public.tls.key.path", "/etc/kratos/tls.key")
+	p.MustSet("serve.public.tls.min_version", "1.3")
+
+	cert, key, ok := p.TLS("public")
+	assert.True(t, ok)
+	assert.Equal(t, "/etc/kratos/tls.crt", cert)
+	assert.Equal(t, "/etc/kratos/tls.key", key)
+	assert.EqualValues(t, tls.VersionTLS13, p.TLSMinVersion("public"))
+
+	_, _, ok = p.TLS("admin")
+	assert.False(t, ok)
+}
+
 func TestViperProvider_DSN(t *testing.T) {
 	t.Run("case=dsn: memory", func(t *testing.T) {
 		p := config.MustNew(t, logrusx.New("", ""), configx.SkipValidation())
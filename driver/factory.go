@@ -24,6 +24,10 @@ func New(ctx context.Context, opts ...configx.OptionModifier) Registry {
 		l.WithError(err).Fatal("Unable to initialize service registry.")
 	}
 
+	if err = r.ValidateSelfServiceStrategies(ctx); err != nil {
+		l.WithError(err).Fatal("Unable to validate the configuration of the self-service strategies.")
+	}
+
 	c.Source().SetTracer(ctx, r.Tracer(ctx))
 
 	return r
@@ -13,11 +13,13 @@ import (
 
 	"github.com/ory/kratos/continuity"
 	"github.com/ory/kratos/courier"
+	"github.com/ory/kratos/event"
 	"github.com/ory/kratos/hash"
 	"github.com/ory/kratos/schema"
 	"github.com/ory/kratos/selfservice/flow/recovery"
 	"github.com/ory/kratos/selfservice/flow/settings"
 	"github.com/ory/kratos/selfservice/flow/verification"
+	"github.com/ory/kratos/selfservice/strategy/code"
 	"github.com/ory/kratos/selfservice/strategy/link"
 
 	"github.com/ory/x/healthx"
@@ -43,11 +45,28 @@ type Registry interface {
 
 	Init(ctx context.Context) error
 
+	// ValidateSelfServiceStrategies checks the configuration of the enabled self-service strategies.
+	ValidateSelfServiceStrategies(ctx context.Context) error
+
 	WithLogger(l *logrusx.Logger) Registry
 
 	WithCSRFHandler(c x.CSRFHandler)
 	WithCSRFTokenGenerator(cg x.CSRFToken)
 
+	// WithIdentityAuditSink replaces the default AuditSink, which writes audit events to the audit log.
+	WithIdentityAuditSink(s identity.AuditSink)
+
+	// WithSessionAnomalyDetector replaces the default AnomalyDetector, which compares the client's network
+	// and fingerprint.
+	WithSessionAnomalyDetector(d session.AnomalyDetector)
+
+	// WithSessionCache replaces the default SessionCache, which caches nothing, for example with one backed
+	// by Redis.
+	WithSessionCache(c session.SessionCache)
+
+	// WithSessionAnomalyHooks sets hooks which are executed after the ones configured at `session.anomaly.hooks`.
+	WithSessionAnomalyHooks(hooks ...session.AnomalyHook)
+
 	HealthHandler(ctx context.Context) *healthx.Handler
 	CookieManager(ctx context.Context) sessions.Store
 	MetricsHandler() *prometheus.Handler
@@ -65,12 +84,15 @@ type Registry interface {
 	x.CSRFProvider
 	x.WriterProvider
 	x.LoggingProvider
+	x.TenantConnectionProvider
 
 	continuity.ManagementProvider
 	continuity.PersistenceProvider
 
 	courier.Provider
 
+	event.EmitterProvider
+
 	persistence.Provider
 
 	errorx.ManagementProvider
@@ -81,18 +103,25 @@ type Registry interface {
 
 	identity.HandlerProvider
 	identity.ValidationProvider
+	identity.TraitsCipherProvider
+	identity.AuditSinkProvider
 	identity.PoolProvider
 	identity.PrivilegedPoolProvider
 	identity.ManagementProvider
+	identity.CredentialEventPersistenceProvider
 	identity.ActiveCredentialsCounterStrategyProvider
 
 	schema.HandlerProvider
+	schema.IdentityTraitsProvider
 
 	password2.ValidationProvider
 
 	session.HandlerProvider
 	session.ManagementProvider
 	session.PersistenceProvider
+	session.ClaimsMapperProvider
+	session.AnomalyProvider
+	session.SessionCacheProvider
 
 	settings.HandlerProvider
 	settings.ErrorHandlerProvider
@@ -124,6 +153,12 @@ type Registry interface {
 	link.VerificationTokenPersistenceProvider
 	link.RecoveryTokenPersistenceProvider
 
+	code.SenderProvider
+	code.RecoveryCodePersistenceProvider
+
+	password2.LoginAttemptsPersistenceProvider
+	settings.CurrentPasswordLockoutProvider
+
 	recovery.FlowPersistenceProvider
 	recovery.ErrorHandlerProvider
 	recovery.HandlerProvider
@@ -23,16 +23,21 @@ import (
 	"github.com/ory/kratos/selfservice/flow/settings"
 	"github.com/ory/kratos/selfservice/flow/verification"
 	"github.com/ory/kratos/selfservice/hook"
+	"github.com/ory/kratos/selfservice/strategy"
+	"github.com/ory/kratos/selfservice/strategy/code"
+	"github.com/ory/kratos/selfservice/strategy/deletion"
 	"github.com/ory/kratos/selfservice/strategy/link"
 	"github.com/ory/kratos/selfservice/strategy/profile"
 	"github.com/ory/kratos/x"
 
 	"github.com/cenkalti/backoff"
+	"github.com/go-redis/redis/v7"
 	"github.com/gorilla/sessions"
 	"github.com/pkg/errors"
 
 	"github.com/ory/x/dbal"
 	"github.com/ory/x/healthx"
+	"github.com/ory/x/networkx"
 	"github.com/ory/x/sqlcon"
 
 	"github.com/ory/x/tracing"
@@ -40,6 +45,7 @@ import (
 	"github.com/ory/x/logrusx"
 
 	"github.com/ory/kratos/courier"
+	"github.com/ory/kratos/event"
 	"github.com/ory/kratos/persistence"
 	"github.com/ory/kratos/persistence/sql"
 	"github.com/ory/kratos/selfservice/flow/login"
@@ -54,6 +60,7 @@ import (
 	"github.com/ory/kratos/selfservice/errorx"
 	password2 "github.com/ory/kratos/selfservice/strategy/password"
 	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/ui/container"
 )
 
 type RegistryDefault struct {
@@ -70,22 +77,34 @@ type RegistryDefault struct {
 	healthxHandler *healthx.Handler
 	metricsHandler *prometheus.Handler
 
-	persister persistence.Persister
+	persister         persistence.Persister
+	tenantConnections *sql.TenantConnections
 
 	hookVerifier         *hook.Verifier
 	hookSessionIssuer    *hook.SessionIssuer
 	hookSessionDestroyer *hook.SessionDestroyer
+	hookSignInNotifier   *hook.SignInNotifier
 
-	identityHandler   *identity.Handler
-	identityValidator *identity.Validator
-	identityManager   *identity.Manager
+	identityHandler        *identity.Handler
+	identityValidator      *identity.Validator
+	identityManager        *identity.Manager
+	identityCache          *identity.Cache
+	identitySchemaResolver *identity.SchemaResolver
+	identityCipher         *identity.TraitsCipher
+	identityAuditSink      identity.AuditSink
 
 	continuityManager continuity.Manager
 
 	schemaHandler *schema.Handler
 
-	sessionHandler *session.Handler
-	sessionManager session.Manager
+	sessionHandler      *session.Handler
+	sessionManager      session.Manager
+	sessionClaimsMapper *session.ClaimsMapper
+	sessionWhoamiCache  *session.WhoamiCache
+	sessionCache        session.SessionCache
+
+	sessionAnomalyDetector session.AnomalyDetector
+	sessionAnomalyHooks    []session.AnomalyHook
 
 	passwordHasher    hash.Hasher
 	passwordValidator password2.Validator
@@ -111,6 +130,7 @@ type RegistryDefault struct {
 	selfserviceVerifyHandler      *verification.Handler
 
 	selfserviceLinkSender *link.Sender
+	selfserviceCodeSender *code.Sender
 
 	selfserviceRecoveryErrorHandler *recovery.ErrorHandler
 	selfserviceRecoveryHandler      *recovery.Handler
@@ -124,6 +144,8 @@ type RegistryDefault struct {
 	buildDate    string
 
 	csrfTokenGenerator x.CSRFToken
+
+	eventEmitter *event.Emitter
 }
 
 func (m *RegistryDefault) Audit() *logrusx.Logger {
@@ -181,6 +203,8 @@ func NewRegistryDefault() *RegistryDefault {
 }
 
 func (m *RegistryDefault) WithLogger(l *logrusx.Logger) Registry {
+	x.AddLogAnonymizer(l, m.logConfig)
+	x.AddLogRedactor(l, m.logConfig)
 	m.l = l
 	return m
 }
@@ -243,6 +267,14 @@ func (m *RegistryDefault) Config(ctx context.Context) *config.Config {
 	return corp.ContextualizeConfig(ctx, m.c)
 }
 
+// logConfig returns the configuration of the log hooks, which may fire before WithConfig was called.
+func (m *RegistryDefault) logConfig(ctx context.Context) *config.Config {
+	if m.c == nil {
+		return nil
+	}
+	return m.Config(ctx)
+}
+
 func (m *RegistryDefault) selfServiceStrategies() []interface{} {
 	if len(m.selfserviceStrategies) == 0 {
 		m.selfserviceStrategies = []interface{}{
@@ -250,12 +282,26 @@ func (m *RegistryDefault) selfServiceStrategies() []interface{} {
 			oidc.NewStrategy(m),
 			profile.NewStrategy(m),
 			link.NewStrategy(m),
+			code.NewStrategy(m),
+			deletion.NewStrategy(m),
 		}
 	}
 
 	return m.selfserviceStrategies
 }
 
+// ValidateSelfServiceStrategies returns the first configuration error of the enabled strategies.
+func (m *RegistryDefault) ValidateSelfServiceStrategies(ctx context.Context) error {
+	for _, s := range m.selfServiceStrategies() {
+		if v, ok := s.(strategy.ConfigValidator); ok {
+			if err := v.ValidateConfig(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (m *RegistryDefault) RegistrationStrategies(ctx context.Context) (registrationStrategies registration.Strategies) {
 	for _, strategy := range m.selfServiceStrategies() {
 		if s, ok := strategy.(registration.Strategy); ok {
@@ -315,6 +361,24 @@ func (m *RegistryDefault) IdentityValidator() *identity.Validator {
 	return m.identityValidator
 }
 
+func (m *RegistryDefault) IdentityTraitsCipher() *identity.TraitsCipher {
+	if m.identityCipher == nil {
+		m.identityCipher = identity.NewTraitsCipher(m)
+	}
+	return m.identityCipher
+}
+
+func (m *RegistryDefault) WithIdentityAuditSink(s identity.AuditSink) {
+	m.identityAuditSink = s
+}
+
+func (m *RegistryDefault) IdentityAuditSink() identity.AuditSink {
+	if m.identityAuditSink == nil {
+		m.identityAuditSink = identity.NewLogAuditSink(m)
+	}
+	return m.identityAuditSink
+}
+
 func (m *RegistryDefault) WithConfig(c *config.Config) Registry {
 	m.c = c
 	return m
@@ -323,7 +387,8 @@ func (m *RegistryDefault) WithConfig(c *config.Config) Registry {
 func (m *RegistryDefault) Writer() herodot.Writer {
 	if m.writer == nil {
 		h := herodot.NewJSONWriter(m.Logger())
-		m.writer = h
+		h.ErrorEnhancer = x.RedactingErrorEnhancer(m)
+		m.writer = container.NewMessagesWriter(container.NewNodeAttributesWriter(h, m), m)
 	}
 	return m.writer
 }
@@ -331,10 +396,19 @@ func (m *RegistryDefault) Writer() herodot.Writer {
 func (m *RegistryDefault) Logger() *logrusx.Logger {
 	if m.l == nil {
 		m.l = logrusx.New("ORY Kratos", config.Version)
+		x.AddLogAnonymizer(m.l, m.logConfig)
+		x.AddLogRedactor(m.l, m.logConfig)
 	}
 	return m.l
 }
 
+func (m *RegistryDefault) EventEmitter() *event.Emitter {
+	if m.eventEmitter == nil {
+		m.eventEmitter = event.NewEmitter(m)
+	}
+	return m.eventEmitter
+}
+
 func (m *RegistryDefault) IdentityHandler() *identity.Handler {
 	if m.identityHandler == nil {
 		m.identityHandler = identity.NewHandler(m)
@@ -434,6 +508,62 @@ func (m *RegistryDefault) SessionManager() session.Manager {
 	return m.sessionManager
 }
 
+func (m *RegistryDefault) SessionClaimsMapper() *session.ClaimsMapper {
+	if m.sessionClaimsMapper == nil {
+		m.sessionClaimsMapper = session.NewClaimsMapper(m)
+	}
+	return m.sessionClaimsMapper
+}
+
+func (m *RegistryDefault) WithSessionAnomalyDetector(d session.AnomalyDetector) {
+	m.sessionAnomalyDetector = d
+}
+
+func (m *RegistryDefault) SessionAnomalyDetector() session.AnomalyDetector {
+	if m.sessionAnomalyDetector == nil {
+		m.sessionAnomalyDetector = session.NewDefaultAnomalyDetector(m)
+	}
+	return m.sessionAnomalyDetector
+}
+
+func (m *RegistryDefault) WithSessionAnomalyHooks(hooks ...session.AnomalyHook) {
+	m.sessionAnomalyHooks = hooks
+}
+
+func (m *RegistryDefault) SessionAnomalyHooks(ctx context.Context) (hooks []session.AnomalyHook) {
+	for _, name := range m.Config(ctx).SessionAnomalyHooks() {
+		switch name {
+		case session.AnomalyHookEmitEvent:
+			hooks = append(hooks, session.NewAnomalyEventHook(m))
+		case session.AnomalyHookRequireReauthentication:
+			hooks = append(hooks, session.NewAnomalyReauthenticationHook(m))
+		default:
+			m.l.
+				WithField("hook", name).
+				Errorf("A unknown session anomaly hook was requested and can therefore not be used")
+		}
+	}
+	return append(hooks, m.sessionAnomalyHooks...)
+}
+
+func (m *RegistryDefault) WithSessionCache(c session.SessionCache) {
+	m.sessionCache = c
+}
+
+func (m *RegistryDefault) SessionCache() session.SessionCache {
+	if m.sessionCache == nil {
+		m.sessionCache = session.NoopSessionCache{}
+	}
+	return m.sessionCache
+}
+
+func (m *RegistryDefault) SessionWhoamiCache() *session.WhoamiCache {
+	if m.sessionWhoamiCache == nil {
+		m.sessionWhoamiCache = session.NewWhoamiCache()
+	}
+	return m.sessionWhoamiCache
+}
+
 func (m *RegistryDefault) SelfServiceErrorManager() *errorx.Manager {
 	if m.errorManager == nil {
 		m.errorManager = errorx.NewManager(m)
@@ -464,35 +594,10 @@ func (m *RegistryDefault) Init(ctx context.Context) error {
 	bc.Reset()
 	return errors.WithStack(
 		backoff.Retry(func() error {
-			var opts []instrumentedsql.Opt
-			if m.Tracer(ctx).IsLoaded() {
-				opts = []instrumentedsql.Opt{
-					instrumentedsql.WithTracer(opentracing.NewTracer(true)),
-					instrumentedsql.WithOmitArgs(),
-				}
-			}
-
-			pool, idlePool, connMaxLifetime, cleanedDSN := sqlcon.ParseConnectionOptions(m.l, m.Config(ctx).DSN())
-			m.Logger().
-				WithField("pool", pool).
-				WithField("idlePool", idlePool).
-				WithField("connMaxLifetime", connMaxLifetime).
-				Debug("Connecting to SQL Database")
-			c, err := pop.NewConnection(&pop.ConnectionDetails{
-				URL:                       sqlcon.FinalizeDSN(m.l, cleanedDSN),
-				IdlePool:                  idlePool,
-				ConnMaxLifetime:           connMaxLifetime,
-				Pool:                      pool,
-				UseInstrumentedDriver:     m.Tracer(ctx).IsLoaded(),
-				InstrumentedDriverOptions: opts,
-			})
+			c, err := m.newConnection(ctx, m.Config(ctx).DSN())
 			if err != nil {
 				m.Logger().WithError(err).Warnf("Unable to connect to database, retrying.")
-				return errors.WithStack(err)
-			}
-			if err := c.Open(); err != nil {
-				m.Logger().WithError(err).Warnf("Unable to open database, retrying.")
-				return errors.WithStack(err)
+				return err
 			}
 			p, err := sql.NewPersister(ctx, m, c)
 			if err != nil {
@@ -521,11 +626,80 @@ func (m *RegistryDefault) Init(ctx context.Context) error {
 			}
 
 			m.persister = p.WithNetworkID(net.ID)
+			m.tenantConnections = sql.NewTenantConnections(m.Config(ctx).TenancyMaxOpenDatabases(), m.openTenantConnection)
 			return nil
 		}, bc),
 	)
 }
 
+func (m *RegistryDefault) newConnection(ctx context.Context, dsn string) (*pop.Connection, error) {
+	var opts []instrumentedsql.Opt
+	if m.Tracer(ctx).IsLoaded() {
+		opts = []instrumentedsql.Opt{
+			instrumentedsql.WithTracer(opentracing.NewTracer(true)),
+			instrumentedsql.WithOmitArgs(),
+		}
+	}
+
+	pool, idlePool, connMaxLifetime, cleanedDSN := sqlcon.ParseConnectionOptions(m.l, dsn)
+	m.Logger().
+		WithField("pool", pool).
+		WithField("idlePool", idlePool).
+		WithField("connMaxLifetime", connMaxLifetime).
+		Debug("Connecting to SQL Database")
+	c, err := pop.NewConnection(&pop.ConnectionDetails{
+		URL:                       sqlcon.FinalizeDSN(m.l, cleanedDSN),
+		IdlePool:                  idlePool,
+		ConnMaxLifetime:           connMaxLifetime,
+		Pool:                      pool,
+		UseInstrumentedDriver:     m.Tracer(ctx).IsLoaded(),
+		InstrumentedDriverOptions: opts,
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := c.Open(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return c, nil
+}
+
+// openTenantConnection connects to the tenant's database and makes sure that it contains the
+// network of this instance as all tables reference it.
+func (m *RegistryDefault) openTenantConnection(ctx context.Context, tenant string) (*pop.Connection, error) {
+	dsn, ok := m.Config(ctx).TenantDSN(tenant)
+	if !ok {
+		return nil, errors.WithStack(herodot.ErrNotFound.WithReasonf("Tenant %s does not exist.", tenant))
+	}
+
+	c, err := m.newConnection(ctx, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	nid := m.Persister().NetworkID()
+	if err := sqlcon.HandleError(c.WithContext(ctx).Find(new(networkx.Network), nid)); errors.Is(err, sqlcon.ErrNoRows) {
+		if err := sqlcon.HandleError(c.WithContext(ctx).Create(&networkx.Network{ID: nid})); err != nil {
+			_ = c.Close()
+			return nil, err
+		}
+	} else if err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+
+	m.Logger().WithField("tenant", tenant).Debug("Connected to the tenant database.")
+	return c, nil
+}
+
+// TenantConnection returns the connection to the tenant's database. Connections are opened on
+// first use and at most `tenancy.max_open_databases` are kept open. Connections which are evicted
+// from the pool are closed once they were released.
+func (m *RegistryDefault) TenantConnection(ctx context.Context, tenant string) (*pop.Connection, func(), error) {
+	return m.tenantConnections.Get(ctx, tenant)
+}
+
 func (m *RegistryDefault) Courier(ctx context.Context) *courier.Courier {
 	return courier.NewSMTP(m, m.Config(ctx))
 }
@@ -541,6 +715,43 @@ func (m *RegistryDefault) ContinuityPersister() continuity.Persister {
 	return m.persister
 }
 
+func (m *RegistryDefault) WithIdentityCache(c *identity.Cache) {
+	m.identityCache = c
+}
+
+// IdentityCache returns the identity cache configured by `identity.cache` or nil if identities
+// are not cached.
+func (m *RegistryDefault) IdentityCache() *identity.Cache {
+	if m.identityCache != nil {
+		return m.identityCache
+	}
+
+	c := m.Config(context.Background())
+	switch backend := c.IdentityCacheBackend(); backend {
+	case "":
+		return nil
+	case "memory":
+		m.identityCache = identity.NewCache(identity.NewMemoryCacheBackend(), c.IdentityCacheTTL())
+	case "redis":
+		opts, err := redis.ParseURL(c.IdentityCacheRedisURL())
+		if err != nil {
+			m.Logger().WithError(err).Fatalf("Unable to parse the Redis URL of the identity cache.")
+		}
+		m.identityCache = identity.NewCache(identity.NewRedisCacheBackend(redis.NewClient(opts)), c.IdentityCacheTTL())
+	default:
+		m.Logger().Fatalf("Unknown identity cache backend: %s", backend)
+	}
+
+	return m.identityCache
+}
+
+func (m *RegistryDefault) IdentitySchemaResolver() *identity.SchemaResolver {
+	if m.identitySchemaResolver == nil {
+		m.identitySchemaResolver = identity.NewSchemaResolver(m)
+	}
+	return m.identitySchemaResolver
+}
+
 func (m *RegistryDefault) IdentityPool() identity.Pool {
 	return m.persister
 }
@@ -549,6 +760,10 @@ func (m *RegistryDefault) PrivilegedIdentityPool() identity.PrivilegedPool {
 	return m.persister
 }
 
+func (m *RegistryDefault) CredentialEventPersister() identity.CredentialEventPersister {
+	return m.persister
+}
+
 func (m *RegistryDefault) RegistrationFlowPersister() registration.FlowPersister {
 	return m.persister
 }
@@ -581,6 +796,18 @@ func (m *RegistryDefault) RecoveryTokenPersister() link.RecoveryTokenPersister {
 	return m.Persister()
 }
 
+func (m *RegistryDefault) RecoveryCodePersister() code.RecoveryCodePersister {
+	return m.Persister()
+}
+
+func (m *RegistryDefault) LoginAttemptsPersister() password2.LoginAttemptsPersister {
+	return m.Persister()
+}
+
+func (m *RegistryDefault) CurrentPasswordLockout() settings.CurrentPasswordLockout {
+	return password2.NewCurrentPasswordLockout(m)
+}
+
 func (m *RegistryDefault) VerificationTokenPersister() link.VerificationTokenPersister {
 	return m.Persister()
 }
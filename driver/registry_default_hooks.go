@@ -26,6 +26,13 @@ func (m *RegistryDefault) HookSessionDestroyer() *hook.SessionDestroyer {
 	return m.hookSessionDestroyer
 }
 
+func (m *RegistryDefault) HookSignInNotifier() *hook.SignInNotifier {
+	if m.hookSignInNotifier == nil {
+		m.hookSignInNotifier = hook.NewSignInNotifier(m)
+	}
+	return m.hookSignInNotifier
+}
+
 func (m *RegistryDefault) WithHooks(hooks map[string]func(config.SelfServiceHook) interface{}) {
 	m.injectedSelfserviceHooks = hooks
 }
@@ -37,6 +44,10 @@ func (m *RegistryDefault) getHooks(credentialsType string, configs []config.Self
 			i = append(i, m.HookSessionIssuer())
 		case hook.KeySessionDestroyer:
 			i = append(i, m.HookSessionDestroyer())
+		case hook.KeyWebHook:
+			i = append(i, hook.NewWebHook(m, h.Config))
+		case hook.KeySignInNotifier:
+			i = append(i, m.HookSignInNotifier())
 		default:
 			var found bool
 			for name, m := range m.injectedSelfserviceHooks {
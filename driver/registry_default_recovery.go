@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/ory/kratos/selfservice/flow/recovery"
+	"github.com/ory/kratos/selfservice/strategy/code"
 )
 
 func (m *RegistryDefault) RecoveryFlowErrorHandler() *recovery.ErrorHandler {
@@ -22,6 +23,14 @@ func (m *RegistryDefault) RecoveryHandler() *recovery.Handler {
 	return m.selfserviceRecoveryHandler
 }
 
+func (m *RegistryDefault) CodeSender() *code.Sender {
+	if m.selfserviceCodeSender == nil {
+		m.selfserviceCodeSender = code.NewSender(m)
+	}
+
+	return m.selfserviceCodeSender
+}
+
 func (m *RegistryDefault) RecoveryStrategies(ctx context.Context) (recoveryStrategies recovery.Strategies) {
 	for _, strategy := range m.selfServiceStrategies() {
 		if s, ok := strategy.(recovery.Strategy); ok {
@@ -5,6 +5,7 @@ import (
 
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/selfservice/flow/registration"
+	"github.com/ory/kratos/selfservice/hook"
 )
 
 func (m *RegistryDefault) PostRegistrationPrePersistHooks(ctx context.Context, credentialsType identity.CredentialsType) (b []registration.PostHookPrePersistExecutor) {
@@ -21,9 +22,15 @@ func (m *RegistryDefault) PostRegistrationPostPersistHooks(ctx context.Context,
 		b = append(b, m.HookVerifier())
 	}
 
+	// With the `notify` duplicate identifier policy, new users are asked to check their email just like users
+	// registering with an identifier which is in use, so that no session may be issued.
+	notify := m.Config(ctx).SelfServiceFlowRegistrationDuplicateIdentifierPolicy() == "notify"
 	for _, v := range m.getHooks(string(credentialsType), m.Config(ctx).SelfServiceFlowRegistrationAfterHooks(string(credentialsType))) {
-		if hook, ok := v.(registration.PostHookPostPersistExecutor); ok {
-			b = append(b, hook)
+		if _, ok := v.(*hook.SessionIssuer); ok && notify {
+			continue
+		}
+		if h, ok := v.(registration.PostHookPostPersistExecutor); ok {
+			b = append(b, h)
 		}
 	}
 	return
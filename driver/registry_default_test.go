@@ -166,6 +166,16 @@ func TestDriverDefault_Strategies(t *testing.T) {
 						configx.SkipValidation())
 				},
 				expect: []string{"password", "profile"}},
+			{
+				prep: func(t *testing.T) *config.Config {
+					return config.MustNew(t, l,
+						configx.WithValues(map[string]interface{}{
+							config.ViperKeyDSN: config.DefaultSQLiteMemoryDSN,
+							config.ViperKeySelfServiceStrategyConfig + ".account_deletion.enabled": true,
+						}),
+						configx.SkipValidation())
+				},
+				expect: []string{"password", "profile", "account_deletion"}},
 			{
 				prep: func(t *testing.T) *config.Config {
 					return config.MustNew(t, l,
@@ -214,7 +224,7 @@ func TestDefaultRegistry_AllStrategies(t *testing.T) {
 	})
 
 	t.Run("case=all settings strategies", func(t *testing.T) {
-		expects := []string{"password", "oidc", "profile"}
+		expects := []string{"password", "oidc", "profile", "account_deletion"}
 		s := reg.AllSettingsStrategies()
 		require.Len(t, s, len(expects))
 		for k, e := range expects {
@@ -223,7 +233,7 @@ func TestDefaultRegistry_AllStrategies(t *testing.T) {
 	})
 
 	t.Run("case=all recovery strategies", func(t *testing.T) {
-		expects := []string{"link"}
+		expects := []string{"link", "code"}
 		s := reg.AllRecoveryStrategies()
 		require.Len(t, s, len(expects))
 		for k, e := range expects {
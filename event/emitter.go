@@ -0,0 +1,173 @@
+package event
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/url"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/httpx"
+	"github.com/ory/x/urlx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/x"
+)
+
+const (
+	TypeLoginSucceeded        = "sh.ory.kratos.login.succeeded"
+	TypeRegistrationSucceeded = "sh.ory.kratos.registration.succeeded"
+	TypeRecoverySucceeded     = "sh.ory.kratos.recovery.succeeded"
+	TypeSettingsSucceeded     = "sh.ory.kratos.settings.succeeded"
+	TypeSessionRevoked        = "sh.ory.kratos.session.revoked"
+	TypeSessionImpersonated   = "sh.ory.kratos.session.impersonated"
+	TypeSessionAnomaly        = "sh.ory.kratos.session.anomaly_detected"
+	TypeIdentityDeleted       = "sh.ory.kratos.identity.deleted"
+
+	SinkHTTP  = "http"
+	SinkKafka = "kafka"
+
+	// ContentTypeCloudEvents is the content type of CloudEvents in structured mode.
+	ContentTypeCloudEvents = "application/cloudevents+json"
+)
+
+type (
+	// CloudEvent is an event in the JSON format of CloudEvents 1.0.
+	CloudEvent struct {
+		SpecVersion     string     `json:"specversion"`
+		ID              string     `json:"id"`
+		Source          string     `json:"source"`
+		Type            string     `json:"type"`
+		Subject         string     `json:"subject"`
+		Time            time.Time  `json:"time"`
+		DataContentType string     `json:"datacontenttype"`
+		Data            *EventData `json:"data"`
+	}
+
+	// Data is the payload of all events. The subject of an event is the identity's ID.
+	Data struct {
+		IdentityID uuid.UUID  `json:"identity_id"`
+		SessionID  *uuid.UUID `json:"session_id,omitempty"`
+		FlowID     *uuid.UUID `json:"flow_id,omitempty"`
+		FlowType   string     `json:"flow_type,omitempty"`
+		Method     string     `json:"method,omitempty"`
+
+		// ImpersonatedBy is set if an administrator acted as the identity.
+		ImpersonatedBy string `json:"impersonated_by,omitempty"`
+
+		// AnomalyReasons lists how the client using a session differs from the client it was issued to.
+		AnomalyReasons []string `json:"anomaly_reasons,omitempty"`
+	}
+
+	// EventData is the data of an event as it is sent. The identity ID is anonymized like in the logs if
+	// `log.anonymization` is set.
+	EventData struct {
+		*Data
+		IdentityID string `json:"identity_id"`
+	}
+
+	kafkaRecords struct {
+		Records []kafkaRecord `json:"records"`
+	}
+	kafkaRecord struct {
+		Key   string      `json:"key"`
+		Value *CloudEvent `json:"value"`
+	}
+
+	emitterDependencies interface {
+		config.Provider
+		x.LoggingProvider
+	}
+	EmitterProvider interface {
+		EventEmitter() *Emitter
+	}
+	// Emitter sends events to the sink configured at `events.sink`.
+	Emitter struct {
+		d emitterDependencies
+	}
+)
+
+func NewEmitter(d emitterDependencies) *Emitter {
+	return &Emitter{d: d}
+}
+
+// NewCloudEvent returns a new event of the given type about the identity in data. The event's subject is
+// the identity's ID, which is anonymized as configured by `log.anonymization`.
+func NewCloudEvent(c *config.Config, eventType string, data *Data) *CloudEvent {
+	identityID := x.AnonymizeConfiguredID(c, data.IdentityID.String())
+	return &CloudEvent{
+		SpecVersion:     "1.0",
+		ID:              x.NewUUID().String(),
+		Source:          c.EventsSource(),
+		Type:            eventType,
+		Subject:         identityID,
+		Time:            time.Now().UTC(),
+		DataContentType: "application/json",
+		Data:            &EventData{Data: data, IdentityID: identityID},
+	}
+}
+
+// Emit sends the event in the background so that slow or unavailable sinks do not delay the flows.
+// Failures are logged. Nothing happens if no sink is configured.
+func (e *Emitter) Emit(ctx context.Context, eventType string, data *Data) {
+	c := e.d.Config(ctx)
+	sink := c.EventsSinkURL()
+	if sink == nil {
+		return
+	}
+
+	event := NewCloudEvent(c, eventType, data)
+	sinkType, topic := c.EventsSinkType(), c.EventsSinkTopic()
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		if err := e.send(ctx, sinkType, sink, topic, event); err != nil {
+			e.d.Logger().
+				WithError(err).
+				WithField("event_id", event.ID).
+				WithField("event_type", event.Type).
+				Error("Unable to emit event.")
+		}
+	}()
+}
+
+func (e *Emitter) send(ctx context.Context, sinkType string, sink *url.URL, topic string, event *CloudEvent) error {
+	var body interface{} = event
+	contentType := ContentTypeCloudEvents
+	if sinkType == SinkKafka {
+		sink = urlx.AppendPaths(sink, "topics", topic)
+		body = &kafkaRecords{Records: []kafkaRecord{{Key: event.Subject, Value: event}}}
+		contentType = "application/vnd.kafka.json.v2+json"
+	}
+
+	var b bytes.Buffer
+	if err := json.NewEncoder(&b).Encode(body); err != nil {
+		return errors.WithStack(err)
+	}
+
+	req, err := retryablehttp.NewRequest("POST", sink.String(), b.Bytes())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", contentType)
+
+	res, err := httpx.NewResilientClient(
+		httpx.ResilientClientWithConnectionTimeout(5*time.Second),
+		httpx.ResilientClientWithLogger(e.d.Logger()),
+	).Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return errors.Errorf("expected the event sink to respond with 2xx but got %d", res.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package event_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/event"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/x"
+)
+
+type sinkRequest struct {
+	path, contentType string
+	body              []byte
+}
+
+func TestEmitter(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+
+	requests := make(chan sinkRequest, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		requests <- sinkRequest{path: r.URL.Path, contentType: r.Header.Get("Content-Type"), body: body}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(ts.Close)
+
+	receive := func(t *testing.T) sinkRequest {
+		select {
+		case req := <-requests:
+			return req
+		case <-time.After(5 * time.Second):
+			t.Fatal("the event was not sent")
+			return sinkRequest{}
+		}
+	}
+
+	identityID, sessionID := x.NewUUID(), x.NewUUID()
+	data := &event.Data{IdentityID: identityID, SessionID: &sessionID, Method: "password"}
+
+	t.Run("case=does nothing without a sink", func(t *testing.T) {
+		reg.EventEmitter().Emit(ctx, event.TypeLoginSucceeded, data)
+		select {
+		case <-requests:
+			t.Fatal("no event must be sent")
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+
+	t.Run("case=sends structured CloudEvents to http sinks", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyEventsSinkURL, ts.URL+"/events")
+		conf.MustSet(config.ViperKeyEventsSource, "https://auth.example.org/")
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyEventsSinkURL, "")
+		})
+
+		reg.EventEmitter().Emit(ctx, event.TypeLoginSucceeded, data)
+		req := receive(t)
+
+		assert.Equal(t, "/events", req.path)
+		assert.Equal(t, event.ContentTypeCloudEvents, req.contentType)
+		assert.Equal(t, "1.0", gjson.GetBytes(req.body, "specversion").String(), "%s", req.body)
+		assert.NotEmpty(t, gjson.GetBytes(req.body, "id").String(), "%s", req.body)
+		assert.Equal(t, "https://auth.example.org/", gjson.GetBytes(req.body, "source").String(), "%s", req.body)
+		assert.Equal(t, event.TypeLoginSucceeded, gjson.GetBytes(req.body, "type").String(), "%s", req.body)
+		assert.Equal(t, identityID.String(), gjson.GetBytes(req.body, "subject").String(), "%s", req.body)
+		assert.Equal(t, identityID.String(), gjson.GetBytes(req.body, "data.identity_id").String(), "%s", req.body)
+		assert.WithinDuration(t, time.Now(), gjson.GetBytes(req.body, "time").Time(), time.Minute)
+		assert.Equal(t, "application/json", gjson.GetBytes(req.body, "datacontenttype").String(), "%s", req.body)
+		assert.Equal(t, sessionID.String(), gjson.GetBytes(req.body, "data.session_id").String(), "%s", req.body)
+		assert.Equal(t, "password", gjson.GetBytes(req.body, "data.method").String(), "%s", req.body)
+		assert.False(t, gjson.GetBytes(req.body, "data.flow_id").Exists(), "%s", req.body)
+	})
+
+	t.Run("case=anonymizes the identity id", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyEventsSinkURL, ts.URL+"/events")
+		conf.MustSet(config.ViperKeyLogAnonymization, x.LogAnonymizationHash)
+		conf.MustSet(config.ViperKeySecretsDefault, []string{"a-very-secret-log-secret"})
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyEventsSinkURL, "")
+			conf.MustSet(config.ViperKeyLogAnonymization, x.LogAnonymizationOff)
+		})
+
+		reg.EventEmitter().Emit(ctx, event.TypeLoginSucceeded, data)
+		req := receive(t)
+
+		expected := x.AnonymizeID(x.LogAnonymizationHash, []byte("a-very-secret-log-secret"), identityID.String())
+		assert.Equal(t, expected, gjson.GetBytes(req.body, "subject").String(), "%s", req.body)
+		assert.Equal(t, expected, gjson.GetBytes(req.body, "data.identity_id").String(), "%s", req.body)
+		assert.Equal(t, sessionID.String(), gjson.GetBytes(req.body, "data.session_id").String(), "%s", req.body)
+	})
+
+	t.Run("case=produces records through the kafka rest proxy", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyEventsSinkURL, ts.URL)
+		conf.MustSet(config.ViperKeyEventsSinkType, event.SinkKafka)
+		conf.MustSet(config.ViperKeyEventsSinkTopic, "identity-events")
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyEventsSinkURL, "")
+			conf.MustSet(config.ViperKeyEventsSinkType, event.SinkHTTP)
+		})
+
+		reg.EventEmitter().Emit(ctx, event.TypeSessionRevoked, data)
+		req := receive(t)
+
+		assert.Equal(t, "/topics/identity-events", req.path)
+		assert.Equal(t, "application/vnd.kafka.json.v2+json", req.contentType)
+		assert.Equal(t, identityID.String(), gjson.GetBytes(req.body, "records.0.key").String(), "%s", req.body)
+		assert.Equal(t, event.TypeSessionRevoked, gjson.GetBytes(req.body, "records.0.value.type").String(), "%s", req.body)
+		assert.Equal(t, identityID.String(), gjson.GetBytes(req.body, "records.0.value.subject").String(), "%s", req.body)
+	})
+}
@@ -39,6 +39,7 @@ require (
 	github.com/go-errors/errors v1.0.1
 	github.com/go-openapi/strfmt v0.20.0
 	github.com/go-playground/validator/v10 v10.4.1
+	github.com/go-redis/redis/v7 v7.4.1
 	github.com/go-swagger/go-swagger v0.26.1
 	github.com/gobuffalo/fizz v1.13.1-0.20201104174146-3416f0e6618f
 	github.com/gobuffalo/httptest v1.0.2
@@ -27,6 +27,44 @@ func Compare(ctx context.Context, password []byte, hash []byte) error {
 	}
 }
 
+// NeedsRehash returns true if the hash was not generated by the configured password hashing algorithm, with
+// other parameters than the configured ones, or not with the current pepper.
+func NeedsRehash(c *config.Config, hash []byte) bool {
+	if needsRepepper(c, hash) {
+		return true
+	}
+
+	_, hash, err := splitPepper(c, hash)
+	if err != nil {
+		return true
+	}
+
+	if c.HasherPasswordHashingAlgorithm() == "bcrypt" {
+		if !IsBcryptHash(hash) {
+			return true
+		}
+
+		cost, err := bcrypt.Cost(hash)
+		return err != nil || uint32(cost) != c.HasherBcrypt().Cost
+	}
+
+	if !IsArgon2idHash(hash) {
+		return true
+	}
+
+	p, _, _, err := decodeArgon2idHash(string(hash))
+	if err != nil {
+		return true
+	}
+
+	expected := c.HasherArgon2()
+	return uint32(p.Memory) != toKB(expected.Memory) ||
+		p.Iterations != expected.Iterations ||
+		p.Parallelism != expected.Parallelism ||
+		p.SaltLength != expected.SaltLength ||
+		p.KeyLength != expected.KeyLength
+}
+
 func CompareBcrypt(_ context.Context, password []byte, hash []byte) error {
 	if err := validateBcryptPasswordLength(password); err != nil {
 		return err
@@ -70,6 +108,14 @@ func IsArgon2idHash(hash []byte) bool {
 	return res
 }
 
+// IsSupportedHash returns true if the hash, which may be peppered, was generated by a supported algorithm.
+func IsSupportedHash(hash []byte) bool {
+	if m := pepperPrefix.FindSubmatch(hash); m != nil {
+		hash = m[2]
+	}
+	return IsBcryptHash(hash) || IsArgon2idHash(hash)
+}
+
 func decodeArgon2idHash(encodedHash string) (p *config.Argon2, salt, hash []byte, err error) {
 	parts := strings.Split(encodedHash, "$")
 	if len(parts) != 6 {
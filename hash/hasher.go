@@ -4,8 +4,13 @@ import "context"
 
 // Hasher provides methods for generating and comparing password hashes.
 type Hasher interface {
-	// Generate returns a hash derived from the password or an error if the hash method failed.
+	// Generate returns a hash derived from the password or an error if the hash method failed. The password
+	// is peppered with the first secret in `secrets.pepper` if it is set.
 	Generate(ctx context.Context, password []byte) ([]byte, error)
+
+	// Compare returns nil if the hash was derived from the password. Hashes generated by other hashers, with
+	// any of the secrets in `secrets.pepper`, or without a pepper are supported.
+	Compare(ctx context.Context, password []byte, hash []byte) error
 }
 
 type HashProvider interface {
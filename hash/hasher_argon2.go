@@ -38,6 +38,16 @@ func toKB(mem bytesize.ByteSize) uint32 {
 }
 
 func (h *Argon2) Generate(ctx context.Context, password []byte) ([]byte, error) {
+	return generatePeppered(h.c.Config(ctx), password, func(password []byte) ([]byte, error) {
+		return h.generate(ctx, password)
+	})
+}
+
+func (h *Argon2) Compare(ctx context.Context, password []byte, hash []byte) error {
+	return comparePeppered(ctx, h.c.Config(ctx), password, hash)
+}
+
+func (h *Argon2) generate(ctx context.Context, password []byte) ([]byte, error) {
 	p := h.c.Config(ctx).HasherArgon2()
 
 	salt := make([]byte, p.SaltLength)
@@ -27,12 +27,13 @@ func (h *Bcrypt) Generate(ctx context.Context, password []byte) ([]byte, error)
 		return nil, err
 	}
 
-	hash, err := bcrypt.GenerateFromPassword(password, int(h.c.Config(ctx).HasherBcrypt().Cost))
-	if err != nil {
-		return nil, err
-	}
+	return generatePeppered(h.c.Config(ctx), password, func(password []byte) ([]byte, error) {
+		return bcrypt.GenerateFromPassword(password, int(h.c.Config(ctx).HasherBcrypt().Cost))
+	})
+}
 
-	return hash, nil
+func (h *Bcrypt) Compare(ctx context.Context, password []byte, hash []byte) error {
+	return comparePeppered(ctx, h.c.Config(ctx), password, hash)
 }
 
 func validateBcryptPasswordLength(password []byte) error {
@@ -1,6 +1,7 @@
 package hash_test
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
 	"fmt"
@@ -9,6 +10,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/hash"
 	"github.com/ory/kratos/internal"
 )
@@ -149,3 +151,86 @@ func TestCompare(t *testing.T) {
 	assert.Nil(t, hash.CompareArgon2id(context.Background(), []byte("test"), []byte("$argon2id$v=19$m=32,t=5,p=4$cm94YnRVOW5jZzFzcVE4bQ$fBxypOL0nP/zdPE71JtAV71i487LbX3fJI5PoTN6Lp4")))
 	assert.Error(t, hash.Compare(context.Background(), []byte("test"), []byte("$argon2id$v=19$m=32,t=5,p=4$cm94YnRVOW5jZzFzcVE4bQ$fBxypOL0nP/zdPE71JtAV71i487LbX3fJI5PoTN6Lp5")))
 }
+
+func TestNeedsRehash(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	ctx := context.Background()
+
+	argon2, err := hash.NewHasherArgon2(reg).Generate(ctx, []byte("test"))
+	require.NoError(t, err)
+	conf.MustSet(config.ViperKeyHasherBcryptCost, 4)
+	bcrypt, err := hash.NewHasherBcrypt(reg).Generate(ctx, []byte("test"))
+	require.NoError(t, err)
+
+	t.Run("hasher=argon2", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyHasherAlgorithm, "argon2")
+		assert.False(t, hash.NeedsRehash(conf, argon2))
+		assert.True(t, hash.NeedsRehash(conf, bcrypt))
+
+		iterations := conf.HasherArgon2().Iterations
+		conf.MustSet(config.ViperKeyHasherArgon2ConfigIterations, iterations+1)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyHasherArgon2ConfigIterations, iterations)
+		})
+		assert.True(t, hash.NeedsRehash(conf, argon2))
+	})
+
+	t.Run("hasher=bcrypt", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyHasherAlgorithm, "bcrypt")
+		assert.False(t, hash.NeedsRehash(conf, bcrypt))
+		assert.True(t, hash.NeedsRehash(conf, argon2))
+
+		conf.MustSet(config.ViperKeyHasherBcryptCost, 5)
+		assert.True(t, hash.NeedsRehash(conf, bcrypt))
+	})
+}
+
+func TestPepper(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	ctx := context.Background()
+	conf.MustSet(config.ViperKeyHasherBcryptCost, 4)
+	pw := []byte("test")
+
+	for _, h := range []hash.Hasher{hash.NewHasherArgon2(reg), hash.NewHasherBcrypt(reg)} {
+		t.Run(fmt.Sprintf("hasher=%T", h), func(t *testing.T) {
+			t.Run("case=without pepper", func(t *testing.T) {
+				conf.MustSet(config.ViperKeySecretsPepper, []string{})
+
+				hs, err := h.Generate(ctx, pw)
+				require.NoError(t, err)
+				assert.False(t, bytes.HasPrefix(hs, []byte("$pepper$")), "%s", hs)
+				require.NoError(t, hash.Compare(ctx, pw, hs))
+				require.NoError(t, h.Compare(ctx, pw, hs))
+			})
+
+			t.Run("case=rotate pepper", func(t *testing.T) {
+				conf.MustSet(config.ViperKeySecretsPepper, []string{})
+				unpeppered, err := h.Generate(ctx, pw)
+				require.NoError(t, err)
+
+				conf.MustSet(config.ViperKeySecretsPepper, []string{"old-pepper-0123456789"})
+				old, err := h.Generate(ctx, pw)
+				require.NoError(t, err)
+				assert.True(t, bytes.HasPrefix(old, []byte("$pepper$k=")), "%s", old)
+				require.Error(t, hash.Compare(ctx, pw, old), "the pepper is required to verify the hash")
+				require.NoError(t, h.Compare(ctx, pw, old))
+				require.Error(t, h.Compare(ctx, []byte("not-test"), old))
+				require.NoError(t, h.Compare(ctx, pw, unpeppered), "hashes without pepper can still be verified")
+				assert.True(t, hash.NeedsRehash(conf, unpeppered))
+
+				conf.MustSet(config.ViperKeySecretsPepper, []string{"new-pepper-0123456789", "old-pepper-0123456789"})
+				require.NoError(t, h.Compare(ctx, pw, old))
+				assert.True(t, hash.NeedsRehash(conf, old))
+
+				rehashed, err := h.Generate(ctx, pw)
+				require.NoError(t, err)
+				require.NoError(t, h.Compare(ctx, pw, rehashed))
+
+				conf.MustSet(config.ViperKeySecretsPepper, []string{"new-pepper-0123456789"})
+				require.NoError(t, h.Compare(ctx, pw, rehashed))
+				require.ErrorIs(t, h.Compare(ctx, pw, old), hash.ErrUnknownPepper)
+			})
+		})
+	}
+	conf.MustSet(config.ViperKeySecretsPepper, []string{})
+}
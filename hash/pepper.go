@@ -0,0 +1,95 @@
+package hash
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+// ErrUnknownPepper is returned if a hash was peppered with a secret which is no longer listed in `secrets.pepper`.
+var ErrUnknownPepper = errors.New("the hash was peppered with an unknown secret")
+
+// Peppered hashes are prefixed with the ID of the pepper, e.g. `$pepper$k=0a1b2c3d$argon2id$v=19$...`.
+var pepperPrefix = regexp.MustCompile(`^\$pepper\$k=([0-9a-f]{8})(\$.*)$`)
+
+// pepperID identifies a pepper without revealing it.
+func pepperID(pepper []byte) string {
+	sum := sha256.Sum256(pepper)
+	return hex.EncodeToString(sum[:4])
+}
+
+// applyPepper returns the password keyed with the pepper. The HMAC is base64 encoded to stay within
+// the 72 bytes bcrypt can hash.
+func applyPepper(pepper, password []byte) []byte {
+	mac := hmac.New(sha256.New, pepper)
+	_, _ = mac.Write(password)
+	return []byte(base64.RawStdEncoding.EncodeToString(mac.Sum(nil)))
+}
+
+// generatePeppered generates the hash of the password keyed with the first secret in `secrets.pepper`.
+// Without a pepper, the hash is generated as is.
+func generatePeppered(c *config.Config, password []byte, generate func(password []byte) ([]byte, error)) ([]byte, error) {
+	peppers := c.SecretsPepper()
+	if len(peppers) == 0 {
+		return generate(password)
+	}
+
+	hash, err := generate(applyPepper(peppers[0], password))
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(fmt.Sprintf("$pepper$k=%s", pepperID(peppers[0]))), hash...), nil
+}
+
+// splitPepper returns the pepper a hash was peppered with and the hash without its pepper prefix. The
+// pepper is nil if the hash is not peppered.
+func splitPepper(c *config.Config, hash []byte) (pepper []byte, inner []byte, err error) {
+	m := pepperPrefix.FindSubmatch(hash)
+	if m == nil {
+		return nil, hash, nil
+	}
+
+	for _, p := range c.SecretsPepper() {
+		if pepperID(p) == string(m[1]) {
+			return p, m[2], nil
+		}
+	}
+
+	return nil, nil, errors.WithStack(ErrUnknownPepper)
+}
+
+// comparePeppered compares the password with a hash which may be peppered with one of the secrets in
+// `secrets.pepper`.
+func comparePeppered(ctx context.Context, c *config.Config, password []byte, hash []byte) error {
+	pepper, inner, err := splitPepper(c, hash)
+	if err != nil {
+		return err
+	}
+
+	if pepper != nil {
+		password = applyPepper(pepper, password)
+	}
+
+	return Compare(ctx, password, inner)
+}
+
+// needsRepepper returns true if the hash is not peppered with the first secret in `secrets.pepper`.
+func needsRepepper(c *config.Config, hash []byte) bool {
+	peppers := c.SecretsPepper()
+	m := pepperPrefix.FindSubmatch(hash)
+	if len(peppers) == 0 {
+		return m != nil
+	}
+
+	return m == nil || !bytes.Equal(m[1], []byte(pepperID(peppers[0])))
+}
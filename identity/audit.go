@@ -0,0 +1,108 @@
+package identity
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/ory/kratos/x"
+)
+
+const (
+	AuditOperationCreate AuditOperation = "create"
+	AuditOperationUpdate AuditOperation = "update"
+	AuditOperationDelete AuditOperation = "delete"
+
+	// AuditActorAdminAPI is the actor of changes made through the admin API.
+	AuditActorAdminAPI = "admin_api"
+
+	// AuditActorSystem is the actor of changes made without an actor in the context, for example by
+	// self-service flows or the janitor.
+	AuditActorSystem = "system"
+)
+
+type (
+	// AuditOperation is the kind of change recorded by an AuditEvent.
+	AuditOperation string
+
+	// AuditEvent records a change of an identity. Events are only written once the change was committed.
+	AuditEvent struct {
+		// Actor is who changed the identity as set by WithAuditActor.
+		Actor string `json:"actor"`
+
+		IdentityID uuid.UUID      `json:"identity_id"`
+		Operation  AuditOperation `json:"operation"`
+
+		// TraitsDiff is the JSON Patch which turns the traits before the change into the traits after
+		// the change. Its paths are relative to the traits.
+		TraitsDiff []x.JSONPatch `json:"traits_diff,omitempty"`
+
+		Time time.Time `json:"time"`
+	}
+
+	// AuditSink receives the audit events of identity changes.
+	AuditSink interface {
+		WriteAuditEvent(ctx context.Context, e *AuditEvent)
+	}
+
+	AuditSinkProvider interface {
+		IdentityAuditSink() AuditSink
+	}
+
+	// LogAuditSink writes audit events to the audit log. It is the default AuditSink.
+	LogAuditSink struct {
+		d x.LoggingProvider
+	}
+
+	auditActorContextKey int
+)
+
+const auditActorKey auditActorContextKey = 0
+
+var _ AuditSink = new(LogAuditSink)
+
+// WithAuditActor returns a context whose identity changes are attributed to the actor.
+func WithAuditActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, auditActorKey, actor)
+}
+
+// AuditActorFromContext returns the actor set by WithAuditActor or AuditActorSystem.
+func AuditActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(auditActorKey).(string); ok {
+		return actor
+	}
+	return AuditActorSystem
+}
+
+// NewAuditEvent returns the audit event of the identity change. The diff is computed from the
+// traits before and after the change.
+func NewAuditEvent(ctx context.Context, op AuditOperation, id uuid.UUID, before, after Traits) (*AuditEvent, error) {
+	diff, err := x.DiffJSONPatch(before, after)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuditEvent{
+		Actor:      AuditActorFromContext(ctx),
+		IdentityID: id,
+		Operation:  op,
+		TraitsDiff: diff,
+		Time:       time.Now().UTC(),
+	}, nil
+}
+
+func NewLogAuditSink(d x.LoggingProvider) *LogAuditSink {
+	return &LogAuditSink{d: d}
+}
+
+func (s *LogAuditSink) WriteAuditEvent(_ context.Context, e *AuditEvent) {
+	diff, _ := json.Marshal(e.TraitsDiff)
+	s.d.Audit().
+		WithField("actor", e.Actor).
+		WithField("identity_id", e.IdentityID).
+		WithField("operation", e.Operation).
+		WithField("traits_diff", string(diff)).
+		Info("An identity was changed.")
+}
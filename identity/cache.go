@@ -0,0 +1,246 @@
+package identity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/ory/x/sqlxx"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// ErrCacheMiss is returned by a CacheBackend if no value is stored for a key.
+var ErrCacheMiss = errors.New("identity is not cached")
+
+var cacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "kratos_identity_cache_lookups_total",
+	Help: "The number of identity cache lookups partitioned by result (hit or miss).",
+}, []string{"result"})
+
+type (
+	// CacheBackend stores the encoded identities of a Cache.
+	CacheBackend interface {
+		// Get returns the value stored for key or ErrCacheMiss if there is none.
+		Get(ctx context.Context, key string) ([]byte, error)
+
+		// Set stores the value for key until the ttl expires.
+		Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+
+		// Delete removes the values stored for keys.
+		Delete(ctx context.Context, keys ...string) error
+
+		// Generation returns the counter stored for key or zero if there is none.
+		Generation(ctx context.Context, key string) (int64, error)
+
+		// IncrementGeneration increments the counter stored for key and keeps it until the ttl expires.
+		IncrementGeneration(ctx context.Context, key string, ttl time.Duration) error
+
+		// SetIfGeneration stores the value for key until the ttl expires, but only if the counter stored
+		// for generationKey still is generation. Otherwise, the value is dropped.
+		SetIfGeneration(ctx context.Context, key string, value []byte, ttl time.Duration, generationKey string, generation int64) error
+	}
+
+	// Cache is a read-through cache for identities looked up by their ID. Identities are
+	// cached with and without credentials separately and both are removed by Invalidate.
+	//
+	// Invalidate also increments the generation of the identity. Readers take the generation
+	// before loading the identity and Set drops the identity if the generation changed since,
+	// so that an identity loaded before a concurrent update is never cached after it.
+	//
+	// A nil *Cache is valid and caches nothing.
+	Cache struct {
+		b   CacheBackend
+		ttl time.Duration
+	}
+
+	CacheProvider interface {
+		IdentityCache() *Cache
+	}
+
+	// cachedIdentity is the encoded form of a cached identity. Contrary to the identity's JSON
+	// representation, it contains all fields which are stored in the database. The credentials and
+	// addresses are converted to structs without JSON tags for the same reason.
+	cachedIdentity struct {
+		ID                  uuid.UUID
+		SchemaID            string
+		Traits              Traits
+		Credentials         map[CredentialsType]cachedCredentials
+		VerifiableAddresses []cachedVerifiableAddress
+		RecoveryAddresses   []cachedRecoveryAddress
+		DeletedAt           *time.Time
+		CreatedAt           time.Time
+		UpdatedAt           time.Time
+		NID                 uuid.UUID
+	}
+	cachedCredentials struct {
+		ID               uuid.UUID
+		CredentialTypeID uuid.UUID
+		Type             CredentialsType
+		Identifiers      []string
+		Config           sqlxx.JSONRawMessage
+		IdentityID       uuid.UUID
+		CreatedAt        time.Time
+		UpdatedAt        time.Time
+		NID              uuid.UUID
+	}
+	cachedVerifiableAddress struct {
+		ID             uuid.UUID
+		Value          string
+		Verified       bool
+		Via            VerifiableAddressType
+		Status         VerifiableAddressStatus
+		VerifiedAt     sqlxx.NullTime
+		LastVerifiedAt sqlxx.NullTime
+		IdentityID     uuid.UUID
+		CreatedAt      time.Time
+		UpdatedAt      time.Time
+		NID            uuid.UUID
+	}
+	cachedRecoveryAddress struct {
+		ID         uuid.UUID
+		Value      string
+		Via        RecoveryAddressType
+		IdentityID uuid.UUID
+		CreatedAt  time.Time
+		UpdatedAt  time.Time
+		NID        uuid.UUID
+	}
+)
+
+// generationTTL is how long the generation of an identity is kept after it was invalidated. It
+// only needs to outlast the lookups which are running while the identity is invalidated.
+const generationTTL = time.Hour
+
+func NewCache(b CacheBackend, ttl time.Duration) *Cache {
+	return &Cache{b: b, ttl: ttl}
+}
+
+func cacheKey(nid, id uuid.UUID, confidential bool) string {
+	if confidential {
+		return fmt.Sprintf("kratos:identity:%s:%s:confidential", nid, id)
+	}
+	return fmt.Sprintf("kratos:identity:%s:%s", nid, id)
+}
+
+func generationKey(nid, id uuid.UUID) string {
+	return fmt.Sprintf("kratos:identity:%s:%s:generation", nid, id)
+}
+
+// Get returns the cached identity or ErrCacheMiss.
+func (c *Cache) Get(ctx context.Context, nid, id uuid.UUID, confidential bool) (*Identity, error) {
+	if c == nil {
+		return nil, errors.WithStack(ErrCacheMiss)
+	}
+
+	raw, err := c.b.Get(ctx, cacheKey(nid, id, confidential))
+	if errors.Is(err, ErrCacheMiss) {
+		cacheLookups.WithLabelValues("miss").Inc()
+		return nil, errors.WithStack(ErrCacheMiss)
+	} else if err != nil {
+		return nil, err
+	}
+
+	var ci cachedIdentity
+	if err := json.Unmarshal(raw, &ci); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	cacheLookups.WithLabelValues("hit").Inc()
+	return ci.toIdentity(), nil
+}
+
+// Generation returns the generation of the identity. It must be taken before the identity is
+// loaded and passed to Set.
+func (c *Cache) Generation(ctx context.Context, nid, id uuid.UUID) (int64, error) {
+	if c == nil {
+		return 0, nil
+	}
+
+	return c.b.Generation(ctx, generationKey(nid, id))
+}
+
+// Set caches the identity unless it was invalidated since the generation was taken. The identity
+// must contain its credentials if confidential is true.
+func (c *Cache) Set(ctx context.Context, i *Identity, confidential bool, generation int64) error {
+	if c == nil {
+		return nil
+	}
+
+	raw, err := json.Marshal(newCachedIdentity(i))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return c.b.SetIfGeneration(ctx, cacheKey(i.NID, i.ID, confidential), raw, c.ttl, generationKey(i.NID, i.ID), generation)
+}
+
+// Invalidate removes the identity from the cache and increments its generation. It must be called
+// whenever an identity, its credentials, or its addresses changed.
+func (c *Cache) Invalidate(ctx context.Context, nid, id uuid.UUID) error {
+	if c == nil {
+		return nil
+	}
+
+	if err := c.b.IncrementGeneration(ctx, generationKey(nid, id), generationTTL); err != nil {
+		return err
+	}
+	return c.b.Delete(ctx, cacheKey(nid, id, false), cacheKey(nid, id, true))
+}
+
+func newCachedIdentity(i *Identity) *cachedIdentity {
+	ci := &cachedIdentity{
+		ID:        i.ID,
+		SchemaID:  i.SchemaID,
+		Traits:    i.Traits,
+		DeletedAt: i.DeletedAt,
+		CreatedAt: i.CreatedAt,
+		UpdatedAt: i.UpdatedAt,
+		NID:       i.NID,
+	}
+
+	if i.Credentials != nil {
+		ci.Credentials = make(map[CredentialsType]cachedCredentials, len(i.Credentials))
+		for t, c := range i.Credentials {
+			ci.Credentials[t] = cachedCredentials(c)
+		}
+	}
+	for _, a := range i.VerifiableAddresses {
+		ci.VerifiableAddresses = append(ci.VerifiableAddresses, cachedVerifiableAddress(a))
+	}
+	for _, a := range i.RecoveryAddresses {
+		ci.RecoveryAddresses = append(ci.RecoveryAddresses, cachedRecoveryAddress(a))
+	}
+
+	return ci
+}
+
+func (ci *cachedIdentity) toIdentity() *Identity {
+	i := &Identity{
+		ID:        ci.ID,
+		SchemaID:  ci.SchemaID,
+		Traits:    ci.Traits,
+		DeletedAt: ci.DeletedAt,
+		CreatedAt: ci.CreatedAt,
+		UpdatedAt: ci.UpdatedAt,
+		NID:       ci.NID,
+	}
+
+	if ci.Credentials != nil {
+		i.Credentials = make(map[CredentialsType]Credentials, len(ci.Credentials))
+		for t, c := range ci.Credentials {
+			i.Credentials[t] = Credentials(c)
+		}
+	}
+	for _, a := range ci.VerifiableAddresses {
+		i.VerifiableAddresses = append(i.VerifiableAddresses, VerifiableAddress(a))
+	}
+	for _, a := range ci.RecoveryAddresses {
+		i.RecoveryAddresses = append(i.RecoveryAddresses, RecoveryAddress(a))
+	}
+
+	return i
+}
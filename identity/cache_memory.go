@@ -0,0 +1,111 @@
+package identity
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// MemoryCacheBackend keeps cached identities in memory. It is only suitable if a single
+	// instance of ORY Kratos is running as other instances would not see invalidations.
+	MemoryCacheBackend struct {
+		sync.RWMutex
+		items       map[string]memoryCacheItem
+		generations map[string]memoryCacheGeneration
+	}
+	memoryCacheItem struct {
+		value     []byte
+		expiresAt time.Time
+	}
+	memoryCacheGeneration struct {
+		value     int64
+		expiresAt time.Time
+	}
+)
+
+var _ CacheBackend = new(MemoryCacheBackend)
+
+func NewMemoryCacheBackend() *MemoryCacheBackend {
+	return &MemoryCacheBackend{
+		items:       make(map[string]memoryCacheItem),
+		generations: make(map[string]memoryCacheGeneration),
+	}
+}
+
+func (b *MemoryCacheBackend) Get(_ context.Context, key string) ([]byte, error) {
+	b.RLock()
+	item, ok := b.items[key]
+	b.RUnlock()
+
+	if !ok {
+		return nil, errors.WithStack(ErrCacheMiss)
+	} else if time.Now().After(item.expiresAt) {
+		_ = b.Delete(context.Background(), key)
+		return nil, errors.WithStack(ErrCacheMiss)
+	}
+
+	return item.value, nil
+}
+
+func (b *MemoryCacheBackend) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	b.Lock()
+	defer b.Unlock()
+
+	b.items[key] = memoryCacheItem{value: value, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (b *MemoryCacheBackend) Delete(_ context.Context, keys ...string) error {
+	b.Lock()
+	defer b.Unlock()
+
+	for _, k := range keys {
+		delete(b.items, k)
+	}
+	return nil
+}
+
+func (b *MemoryCacheBackend) Generation(_ context.Context, key string) (int64, error) {
+	b.RLock()
+	defer b.RUnlock()
+	return b.generation(key), nil
+}
+
+func (b *MemoryCacheBackend) IncrementGeneration(_ context.Context, key string, ttl time.Duration) error {
+	b.Lock()
+	defer b.Unlock()
+
+	now := time.Now()
+	for k, g := range b.generations {
+		if now.After(g.expiresAt) {
+			delete(b.generations, k)
+		}
+	}
+
+	b.generations[key] = memoryCacheGeneration{value: b.generation(key) + 1, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (b *MemoryCacheBackend) SetIfGeneration(_ context.Context, key string, value []byte, ttl time.Duration, generationKey string, generation int64) error {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.generation(generationKey) != generation {
+		return nil
+	}
+
+	b.items[key] = memoryCacheItem{value: value, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+// generation must be called while holding the lock.
+func (b *MemoryCacheBackend) generation(key string) int64 {
+	g, ok := b.generations[key]
+	if !ok || time.Now().After(g.expiresAt) {
+		return 0
+	}
+	return g.value
+}
@@ -0,0 +1,78 @@
+package identity
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/pkg/errors"
+)
+
+// RedisCacheBackend keeps cached identities in Redis which allows sharing the cache, and its
+// invalidations, between several instances of ORY Kratos.
+type RedisCacheBackend struct {
+	c *redis.Client
+}
+
+// redisSetIfGeneration stores the value of KEYS[1] if the counter of KEYS[2] is ARGV[3].
+var redisSetIfGeneration = redis.NewScript(`
+if (redis.call("GET", KEYS[2]) or "0") == ARGV[3] then
+	return redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+end
+return false
+`)
+
+var _ CacheBackend = new(RedisCacheBackend)
+
+func NewRedisCacheBackend(c *redis.Client) *RedisCacheBackend {
+	return &RedisCacheBackend{c: c}
+}
+
+func (b *RedisCacheBackend) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := b.c.WithContext(ctx).Get(key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, errors.WithStack(ErrCacheMiss)
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return value, nil
+}
+
+func (b *RedisCacheBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return errors.WithStack(b.c.WithContext(ctx).Set(key, value, ttl).Err())
+}
+
+func (b *RedisCacheBackend) Delete(ctx context.Context, keys ...string) error {
+	return errors.WithStack(b.c.WithContext(ctx).Del(keys...).Err())
+}
+
+func (b *RedisCacheBackend) Generation(ctx context.Context, key string) (int64, error) {
+	generation, err := b.c.WithContext(ctx).Get(key).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	} else if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	return generation, nil
+}
+
+func (b *RedisCacheBackend) IncrementGeneration(ctx context.Context, key string, ttl time.Duration) error {
+	_, err := b.c.WithContext(ctx).TxPipelined(func(p redis.Pipeliner) error {
+		p.Incr(key)
+		p.PExpire(key, ttl)
+		return nil
+	})
+	return errors.WithStack(err)
+}
+
+func (b *RedisCacheBackend) SetIfGeneration(ctx context.Context, key string, value []byte, ttl time.Duration, generationKey string, generation int64) error {
+	err := redisSetIfGeneration.Run(b.c.WithContext(ctx), []string{key, generationKey},
+		value, ttl.Milliseconds(), strconv.FormatInt(generation, 10)).Err()
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	return errors.WithStack(err)
+}
@@ -0,0 +1,265 @@
+package identity_test
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/x"
+)
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	nid := x.NewUUID()
+
+	newIdentity := func() *identity.Identity {
+		i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+		i.NID = nid
+		i.Traits = identity.Traits(`{"email":"foo@ory.sh"}`)
+		i.SetCredentials(identity.CredentialsTypePassword, identity.Credentials{
+			Identifiers: []string{"foo@ory.sh"},
+			Config:      []byte(`{"hashed_password":"foo"}`),
+		})
+		return i
+	}
+
+	t.Run("case=a nil cache caches nothing", func(t *testing.T) {
+		var c *identity.Cache
+		i := newIdentity()
+		require.NoError(t, c.Set(ctx, i, true, 0))
+		_, err := c.Get(ctx, nid, i.ID, true)
+		assert.True(t, errors.Is(err, identity.ErrCacheMiss))
+		require.NoError(t, c.Invalidate(ctx, nid, i.ID))
+	})
+
+	t.Run("case=caches identities with and without credentials separately", func(t *testing.T) {
+		c := identity.NewCache(identity.NewMemoryCacheBackend(), time.Minute)
+		i := newIdentity()
+
+		require.NoError(t, c.Set(ctx, i, true, 0))
+		_, err := c.Get(ctx, nid, i.ID, false)
+		assert.True(t, errors.Is(err, identity.ErrCacheMiss))
+
+		actual, err := c.Get(ctx, nid, i.ID, true)
+		require.NoError(t, err)
+		assert.Equal(t, i.ID, actual.ID)
+		assert.JSONEq(t, string(i.Traits), string(actual.Traits))
+		assert.Equal(t, i.Credentials, actual.Credentials)
+
+		_, err = c.Get(ctx, x.NewUUID(), i.ID, true)
+		assert.True(t, errors.Is(err, identity.ErrCacheMiss), "identities of other networks must not be returned")
+	})
+
+	t.Run("case=invalidates identities with and without credentials", func(t *testing.T) {
+		c := identity.NewCache(identity.NewMemoryCacheBackend(), time.Minute)
+		i := newIdentity()
+
+		require.NoError(t, c.Set(ctx, i, true, 0))
+		require.NoError(t, c.Set(ctx, i, false, 0))
+		require.NoError(t, c.Invalidate(ctx, nid, i.ID))
+
+		for _, confidential := range []bool{true, false} {
+			_, err := c.Get(ctx, nid, i.ID, confidential)
+			assert.True(t, errors.Is(err, identity.ErrCacheMiss))
+		}
+	})
+
+	t.Run("case=drops identities loaded before an invalidation", func(t *testing.T) {
+		c := identity.NewCache(identity.NewMemoryCacheBackend(), time.Minute)
+		i := newIdentity()
+
+		generation, err := c.Generation(ctx, nid, i.ID)
+		require.NoError(t, err)
+		require.NoError(t, c.Invalidate(ctx, nid, i.ID))
+		require.NoError(t, c.Set(ctx, i, true, generation))
+
+		_, err = c.Get(ctx, nid, i.ID, true)
+		assert.True(t, errors.Is(err, identity.ErrCacheMiss), "the identity might be older than the invalidation")
+
+		generation, err = c.Generation(ctx, nid, i.ID)
+		require.NoError(t, err)
+		require.NoError(t, c.Set(ctx, i, true, generation))
+		_, err = c.Get(ctx, nid, i.ID, true)
+		require.NoError(t, err)
+	})
+
+	t.Run("case=expires identities", func(t *testing.T) {
+		c := identity.NewCache(identity.NewMemoryCacheBackend(), time.Millisecond)
+		i := newIdentity()
+
+		require.NoError(t, c.Set(ctx, i, false, 0))
+		time.Sleep(time.Millisecond * 5)
+
+		_, err := c.Get(ctx, nid, i.ID, false)
+		assert.True(t, errors.Is(err, identity.ErrCacheMiss))
+	})
+}
+
+func TestCacheBackend(t *testing.T) {
+	ctx := context.Background()
+	backends := map[string]identity.CacheBackend{
+		"memory": identity.NewMemoryCacheBackend(),
+	}
+	if u := os.Getenv("TEST_IDENTITY_CACHE_REDIS_URL"); u != "" {
+		opts, err := redis.ParseURL(u)
+		require.NoError(t, err)
+		backends["redis"] = identity.NewRedisCacheBackend(redis.NewClient(opts))
+	}
+
+	for name, b := range backends {
+		t.Run("backend="+name, func(t *testing.T) {
+			key := x.NewUUID().String()
+
+			_, err := b.Get(ctx, key)
+			assert.True(t, errors.Is(err, identity.ErrCacheMiss))
+
+			require.NoError(t, b.Set(ctx, key, []byte("foo"), time.Minute))
+			actual, err := b.Get(ctx, key)
+			require.NoError(t, err)
+			assert.Equal(t, "foo", string(actual))
+
+			require.NoError(t, b.Delete(ctx, key, x.NewUUID().String()))
+			_, err = b.Get(ctx, key)
+			assert.True(t, errors.Is(err, identity.ErrCacheMiss))
+
+			generationKey := x.NewUUID().String()
+			generation, err := b.Generation(ctx, generationKey)
+			require.NoError(t, err)
+			assert.EqualValues(t, 0, generation)
+
+			require.NoError(t, b.IncrementGeneration(ctx, generationKey, time.Minute))
+			require.NoError(t, b.SetIfGeneration(ctx, key, []byte("bar"), time.Minute, generationKey, generation))
+			_, err = b.Get(ctx, key)
+			assert.True(t, errors.Is(err, identity.ErrCacheMiss), "values of an outdated generation must be dropped")
+
+			generation, err = b.Generation(ctx, generationKey)
+			require.NoError(t, err)
+			assert.EqualValues(t, 1, generation)
+			require.NoError(t, b.SetIfGeneration(ctx, key, []byte("bar"), time.Minute, generationKey, generation))
+			actual, err = b.Get(ctx, key)
+			require.NoError(t, err)
+			assert.Equal(t, "bar", string(actual))
+		})
+	}
+}
+
+func TestCachedPool(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/identity.schema.json")
+	conf.MustSet(config.ViperKeyIdentityCacheBackend, "memory")
+	require.NotNil(t, reg.IdentityCache())
+
+	i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+	i.Traits = identity.Traits(`{"bar":"baz"}`)
+	i.SetCredentials(identity.CredentialsTypePassword, identity.Credentials{
+		Identifiers: []string{x.NewUUID().String()},
+		Config:      []byte(`{"hashed_password":"old"}`),
+	})
+	require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(ctx, i))
+
+	t.Run("case=caches identities on lookup", func(t *testing.T) {
+		_, err := reg.IdentityPool().GetIdentity(ctx, i.ID)
+		require.NoError(t, err)
+		_, err = reg.PrivilegedIdentityPool().GetIdentityConfidential(ctx, i.ID)
+		require.NoError(t, err)
+
+		for _, confidential := range []bool{true, false} {
+			cached, err := reg.IdentityCache().Get(ctx, i.NID, i.ID, confidential)
+			require.NoError(t, err)
+			assert.Equal(t, i.ID, cached.ID)
+		}
+
+		actual, err := reg.IdentityPool().GetIdentity(ctx, i.ID)
+		require.NoError(t, err)
+		assert.NotEmpty(t, actual.SchemaURL)
+		assert.Empty(t, actual.Credentials)
+	})
+
+	t.Run("case=never returns stale credentials after an update", func(t *testing.T) {
+		updated, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(ctx, i.ID)
+		require.NoError(t, err)
+
+		creds := updated.Credentials[identity.CredentialsTypePassword]
+		creds.Config = []byte(`{"hashed_password":"new"}`)
+		updated.Credentials[identity.CredentialsTypePassword] = creds
+		require.NoError(t, reg.PrivilegedIdentityPool().UpdateIdentity(ctx, updated))
+
+		actual, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(ctx, i.ID)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"hashed_password":"new"}`, string(actual.Credentials[identity.CredentialsTypePassword].Config))
+	})
+
+	t.Run("case=does not return deleted identities", func(t *testing.T) {
+		_, err := reg.IdentityPool().GetIdentity(ctx, i.ID)
+		require.NoError(t, err)
+
+		require.NoError(t, reg.PrivilegedIdentityPool().DeleteIdentity(ctx, i.ID))
+		_, err = reg.IdentityPool().GetIdentity(ctx, i.ID)
+		require.Error(t, err)
+	})
+}
+
+// pausingCacheBackend blocks the first SetIfGeneration call until resume is closed.
+type pausingCacheBackend struct {
+	identity.CacheBackend
+	paused, resume chan struct{}
+	calls          int32
+}
+
+func (b *pausingCacheBackend) SetIfGeneration(ctx context.Context, key string, value []byte, ttl time.Duration, generationKey string, generation int64) error {
+	if atomic.AddInt32(&b.calls, 1) == 1 {
+		close(b.paused)
+		<-b.resume
+	}
+	return b.CacheBackend.SetIfGeneration(ctx, key, value, ttl, generationKey, generation)
+}
+
+func TestCachedPoolConcurrentUpdate(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/identity.schema.json")
+
+	b := &pausingCacheBackend{CacheBackend: identity.NewMemoryCacheBackend(), paused: make(chan struct{}), resume: make(chan struct{})}
+	reg.WithIdentityCache(identity.NewCache(b, time.Minute))
+
+	i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+	i.Traits = identity.Traits(`{"bar":"baz"}`)
+	i.SetCredentials(identity.CredentialsTypePassword, identity.Credentials{
+		Identifiers: []string{x.NewUUID().String()},
+		Config:      []byte(`{"hashed_password":"old"}`),
+	})
+	require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(ctx, i))
+
+	// The reader loads the old credentials and is paused before caching them.
+	read := make(chan error)
+	go func() {
+		_, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(ctx, i.ID)
+		read <- err
+	}()
+	<-b.paused
+
+	updated, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(ctx, i.ID)
+	require.NoError(t, err)
+	creds := updated.Credentials[identity.CredentialsTypePassword]
+	creds.Config = []byte(`{"hashed_password":"new"}`)
+	updated.Credentials[identity.CredentialsTypePassword] = creds
+	require.NoError(t, reg.PrivilegedIdentityPool().UpdateIdentity(ctx, updated))
+
+	close(b.resume)
+	require.NoError(t, <-read)
+
+	actual, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(ctx, i.ID)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"hashed_password":"new"}`, string(actual.Credentials[identity.CredentialsTypePassword].Config))
+}
@@ -0,0 +1,184 @@
+package identity
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/ory/kratos/corp"
+	"github.com/ory/kratos/x"
+)
+
+// CredentialEventType describes how the credentials of an identity changed.
+type CredentialEventType string
+
+const (
+	// CredentialEventAdded is recorded when credentials or an identifier were added, for example when a password
+	// was set for the first time or a social sign in provider was linked.
+	CredentialEventAdded CredentialEventType = "added"
+
+	// CredentialEventUpdated is recorded when credentials changed, for example when a password was changed.
+	CredentialEventUpdated CredentialEventType = "updated"
+
+	// CredentialEventRemoved is recorded when credentials or an identifier were removed, for example when a social
+	// sign in provider was unlinked.
+	CredentialEventRemoved CredentialEventType = "removed"
+
+	// CredentialEventRecovered is recorded when the account was recovered.
+	CredentialEventRecovered CredentialEventType = "recovered"
+)
+
+type (
+	// A Credential Event
+	//
+	// Records when the credentials of an identity changed. Credential events never contain secret material.
+	//
+	// swagger:model credentialEvent
+	CredentialEvent struct {
+		// ID is the event's unique ID.
+		//
+		// required: true
+		ID  uuid.UUID `json:"id" db:"id" faker:"-"`
+		NID uuid.UUID `json:"-" db:"nid" faker:"-"`
+
+		// IdentityID is the ID of the identity whose credentials changed.
+		//
+		// required: true
+		IdentityID uuid.UUID `json:"identity_id" db:"identity_id" faker:"-"`
+
+		// Event is one of `added`, `updated`, `removed`, or `recovered`.
+		//
+		// required: true
+		Event CredentialEventType `json:"event" db:"event"`
+
+		// CredentialsType is the type of the changed credentials. It is empty for `recovered` events.
+		CredentialsType CredentialsType `json:"credentials_type" db:"credentials_type"`
+
+		// IPAddress is the IP address of the client which caused the change.
+		IPAddress string `json:"ip_address" db:"ip_address"`
+
+		// CreatedAt is the time (UTC) at which the change happened.
+		//
+		// required: true
+		CreatedAt time.Time `json:"created_at" db:"created_at" faker:"-"`
+
+		// UpdatedAt is a helper struct field for gobuffalo.pop.
+		UpdatedAt time.Time `json:"-" db:"updated_at" faker:"-"`
+	}
+
+	CredentialEventPersister interface {
+		// CreateCredentialEvents appends the events to the credential history. Events can not be updated or deleted
+		// except by deleting the identity.
+		CreateCredentialEvents(ctx context.Context, events ...*CredentialEvent) error
+
+		// ListCredentialEvents lists the credential history of an identity, most recent first.
+		ListCredentialEvents(ctx context.Context, identityID uuid.UUID, page, perPage int) ([]CredentialEvent, error)
+
+		// CountCredentialEvents counts the credential events of an identity.
+		CountCredentialEvents(ctx context.Context, identityID uuid.UUID) (int64, error)
+	}
+
+	CredentialEventPersistenceProvider interface {
+		CredentialEventPersister() CredentialEventPersister
+	}
+)
+
+func (e CredentialEvent) TableName(ctx context.Context) string {
+	return corp.ContextualizeTableName(ctx, "credential_events")
+}
+
+func NewCredentialEvent(identityID uuid.UUID, event CredentialEventType, ct CredentialsType, ip string) *CredentialEvent {
+	return &CredentialEvent{
+		ID:              x.NewUUID(),
+		IdentityID:      identityID,
+		Event:           event,
+		CredentialsType: ct,
+		IPAddress:       ip,
+	}
+}
+
+// NewCredentialEventsFromDiff compares the credentials of the original and the updated identity and returns the
+// events describing the change. Identifiers which were added or removed together with a change of the credentials
+// configuration (e.g. a linked social sign in provider) are recorded as `added` or `removed`, other configuration
+// changes (e.g. a changed password) as `updated`. Changes of the identifiers alone, for example because the email
+// address was updated, are not recorded.
+func NewCredentialEventsFromDiff(original, updated *Identity, ip string) []*CredentialEvent {
+	var events []*CredentialEvent
+	for _, ct := range sortedCredentialsTypes(original, updated) {
+		oc, inOriginal := original.Credentials[ct]
+		uc, inUpdated := updated.Credentials[ct]
+
+		switch {
+		case !inOriginal && inUpdated:
+			events = append(events, NewCredentialEvent(updated.ID, CredentialEventAdded, ct, ip))
+		case inOriginal && !inUpdated:
+			events = append(events, NewCredentialEvent(updated.ID, CredentialEventRemoved, ct, ip))
+		case !credentialsConfigEqual(oc.Config, uc.Config):
+			added, removed := diffIdentifiers(oc.Identifiers, uc.Identifiers)
+			for range added {
+				events = append(events, NewCredentialEvent(updated.ID, CredentialEventAdded, ct, ip))
+			}
+			for range removed {
+				events = append(events, NewCredentialEvent(updated.ID, CredentialEventRemoved, ct, ip))
+			}
+			if len(added)+len(removed) == 0 {
+				events = append(events, NewCredentialEvent(updated.ID, CredentialEventUpdated, ct, ip))
+			}
+		}
+	}
+	return events
+}
+
+func sortedCredentialsTypes(identities ...*Identity) []CredentialsType {
+	var types []CredentialsType
+	seen := map[CredentialsType]bool{}
+	for _, i := range identities {
+		for ct := range i.Credentials {
+			if !seen[ct] {
+				seen[ct] = true
+				types = append(types, ct)
+			}
+		}
+	}
+
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+func credentialsConfigEqual(a, b []byte) bool {
+	var av, bv interface{}
+	if err := json.Unmarshal(a, &av); err != nil {
+		return string(a) == string(b)
+	}
+	if err := json.Unmarshal(b, &bv); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(av, bv)
+}
+
+func diffIdentifiers(original, updated []string) (added, removed []string) {
+	in := func(needle string, haystack []string) bool {
+		for _, s := range haystack {
+			if s == needle {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, id := range updated {
+		if !in(id, original) {
+			added = append(added, id)
+		}
+	}
+	for _, id := range original {
+		if !in(id, updated) {
+			removed = append(removed, id)
+		}
+	}
+	return added, removed
+}
@@ -0,0 +1,84 @@
+package identity
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ory/kratos/x"
+)
+
+func TestNewCredentialEventsFromDiff(t *testing.T) {
+	newIdentity := func(credentials ...Credentials) *Identity {
+		i := &Identity{ID: x.ParseUUID("5ff66179-c240-4703-b0d8-494592cefff5"), Credentials: map[CredentialsType]Credentials{}}
+		for _, c := range credentials {
+			i.Credentials[c.Type] = c
+		}
+		return i
+	}
+
+	password := func(hash string) Credentials {
+		return Credentials{Type: CredentialsTypePassword, Identifiers: []string{"foo@ory.sh"},
+			Config: []byte(`{"hashed_password":"` + hash + `"}`)}
+	}
+	oidc := func(providers ...string) Credentials {
+		return Credentials{Type: CredentialsTypeOIDC, Identifiers: providers,
+			Config: []byte(`{"providers":` + x.MustEncodeJSON(t, providers) + `}`)}
+	}
+
+	type event struct {
+		event CredentialEventType
+		ct    CredentialsType
+	}
+
+	for k, tc := range []struct {
+		original, updated *Identity
+		expected          []event
+	}{
+		{
+			original: newIdentity(password("foo")),
+			updated:  newIdentity(password("foo")),
+		},
+		{
+			original: newIdentity(password("foo")),
+			updated:  newIdentity(password("bar")),
+			expected: []event{{CredentialEventUpdated, CredentialsTypePassword}},
+		},
+		{
+			original: newIdentity(),
+			updated:  newIdentity(password("foo")),
+			expected: []event{{CredentialEventAdded, CredentialsTypePassword}},
+		},
+		{
+			original: newIdentity(password("foo"), oidc("google:1")),
+			updated:  newIdentity(password("foo")),
+			expected: []event{{CredentialEventRemoved, CredentialsTypeOIDC}},
+		},
+		{
+			original: newIdentity(oidc("google:1")),
+			updated:  newIdentity(oidc("google:1", "github:2")),
+			expected: []event{{CredentialEventAdded, CredentialsTypeOIDC}},
+		},
+		{
+			original: newIdentity(oidc("google:1", "github:2")),
+			updated:  newIdentity(oidc("github:2")),
+			expected: []event{{CredentialEventRemoved, CredentialsTypeOIDC}},
+		},
+		{
+			original: newIdentity(password("foo")),
+			updated: newIdentity(Credentials{Type: CredentialsTypePassword, Identifiers: []string{"bar@ory.sh"},
+				Config: []byte(`{"hashed_password": "foo"}`)}),
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			var actual []event
+			for _, e := range NewCredentialEventsFromDiff(tc.original, tc.updated, "192.0.2.1") {
+				assert.Equal(t, tc.updated.ID, e.IdentityID)
+				assert.Equal(t, "192.0.2.1", e.IPAddress)
+				actual = append(actual, event{e.Event, e.CredentialsType})
+			}
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
@@ -22,10 +22,25 @@ func NewSchemaExtensionCredentials(i *Identity) *SchemaExtensionCredentials {
 	return &SchemaExtensionCredentials{i: i}
 }
 
-func (r *SchemaExtensionCredentials) Run(_ jsonschema.ValidationContext, s schema.ExtensionConfig, value interface{}) error {
+func (r *SchemaExtensionCredentials) Run(ctx jsonschema.ValidationContext, s schema.ExtensionConfig, value interface{}) error {
 	r.l.Lock()
 	defer r.l.Unlock()
 	if s.Credentials.Password.Identifier {
+		identifier := strings.ToLower(fmt.Sprintf("%s", value))
+		switch s.Credentials.Password.Via {
+		case "phone":
+			// Phone numbers are stored in E.164 format so that different notations of the same
+			// number map to the same identifier.
+			normalized, err := NormalizePhoneNumber(fmt.Sprintf("%s", value))
+			if err != nil {
+				return ctx.Error("format", "%q is not valid %q", value, "E.164")
+			}
+			identifier = normalized
+		case "", "email":
+		default:
+			return ctx.Error("", "credentials.password.via has unknown value %q", s.Credentials.Password.Via)
+		}
+
 		cred, ok := r.i.GetCredentials(CredentialsTypePassword)
 		if !ok {
 			cred = &Credentials{
@@ -35,7 +50,7 @@ func (r *SchemaExtensionCredentials) Run(_ jsonschema.ValidationContext, s schem
 			}
 		}
 
-		r.v = stringslice.Unique(append(r.v, strings.ToLower(fmt.Sprintf("%s", value))))
+		r.v = stringslice.Unique(append(r.v, identifier))
 		cred.Identifiers = r.v
 		r.i.SetCredentials(CredentialsTypePassword, *cred)
 	}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/pkg/errors"
+
 	"github.com/ory/jsonschema/v3"
 	_ "github.com/ory/jsonschema/v3/fileloader"
 
@@ -41,6 +43,21 @@ func TestSchemaExtensionCredentials(t *testing.T) {
 				Identifiers: []string{"not-foo@ory.sh"},
 			},
 		},
+		{
+			doc:    `{"email":"FOO@ory.sh","phone":"+1 (555) 123-4567"}`,
+			schema: "file://./stub/extension/credentials/phone.schema.json",
+			expect: []string{"foo@ory.sh", "+15551234567"},
+		},
+		{
+			doc:    `{"phone":"0049 30 1234567"}`,
+			schema: "file://./stub/extension/credentials/phone.schema.json",
+			expect: []string{"+49301234567"},
+		},
+		{
+			doc:       `{"phone":"555-1234"}`,
+			schema:    "file://./stub/extension/credentials/phone.schema.json",
+			expectErr: errors.New(`I[#/phone] S[#/properties/phone/format] "555-1234" is not valid "E.164"`),
+		},
 	} {
 		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
 			c := jsonschema.NewCompiler()
@@ -59,6 +76,9 @@ func TestSchemaExtensionCredentials(t *testing.T) {
 				require.EqualError(t, err, tc.expectErr.Error())
 			}
 			require.NoError(t, e.Finish())
+			if tc.expectErr != nil {
+				return
+			}
 
 			credentials, ok := i.GetCredentials(identity.CredentialsTypePassword)
 			require.True(t, ok)
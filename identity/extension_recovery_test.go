@@ -137,6 +137,29 @@ func TestSchemaExtensionRecovery(t *testing.T) {
 				},
 			},
 		},
+		{
+			doc:    `{"email":"foo@ory.sh","recovery_email":"bar@ory.sh"}`,
+			schema: "file://./stub/extension/recovery/secondary.schema.json",
+			expect: []RecoveryAddress{
+				{
+					Value:      "bar@ory.sh",
+					Via:        RecoveryAddressTypeEmail,
+					IdentityID: iid,
+				},
+			},
+			existing: []RecoveryAddress{
+				{
+					Value:      "foo@ory.sh",
+					Via:        RecoveryAddressTypeEmail,
+					IdentityID: iid,
+				},
+			},
+		},
+		{
+			doc:    `{"email":"foo@ory.sh"}`,
+			schema: "file://./stub/extension/recovery/secondary.schema.json",
+			expect: []RecoveryAddress{},
+		},
 	} {
 		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
 			id := &Identity{ID: iid, RecoveryAddresses: tc.existing}
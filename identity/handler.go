@@ -1,14 +1,22 @@
 package identity
 
 import (
+	"bytes"
 	"encoding/json"
+	"io/ioutil"
+	"mime"
 	"net/http"
+	"strings"
 
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/hash"
 
+	"github.com/gofrs/uuid"
 	"github.com/julienschmidt/httprouter"
 	"github.com/pkg/errors"
 
+	"github.com/ory/herodot"
+
 	"github.com/ory/x/jsonx"
 	"github.com/ory/x/urlx"
 
@@ -22,6 +30,7 @@ type (
 		PoolProvider
 		PrivilegedPoolProvider
 		ManagementProvider
+		CredentialEventPersistenceProvider
 		x.WriterProvider
 		config.Provider
 	}
@@ -39,11 +48,29 @@ func NewHandler(r handlerDependencies) *Handler {
 
 func (h *Handler) RegisterAdminRoutes(admin *x.RouterAdmin) {
 	admin.GET(RouteBase, h.list)
+	// The count is served by h.get because httprouter does not allow /identities/count next to /identities/:id.
 	admin.GET(RouteBase+"/:id", h.get)
-	admin.DELETE(RouteBase+"/:id", h.delete)
+	admin.DELETE(RouteBase+"/:id", h.withAuditActor(h.delete))
+	admin.GET(RouteBase+"/:id/credential-history", h.credentialHistory)
+
+	admin.POST(RouteBase, h.withAuditActor(h.create))
+	admin.POST(RouteBase+"/batch", h.withAuditActor(h.batchCreate))
+	admin.PUT(RouteBase+"/:id", h.withAuditActor(h.update))
+	admin.PATCH(RouteBase+"/:id", h.withAuditActor(h.patch))
+}
+
+// withAuditActor attributes the identity changes made by the handler to the admin API.
+func (h *Handler) withAuditActor(handle httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		handle(w, r.WithContext(WithAuditActor(r.Context(), AuditActorAdminAPI)), ps)
+	}
+}
 
-	admin.POST(RouteBase, h.create)
-	admin.PUT(RouteBase+"/:id", h.update)
+func (h *Handler) managerOptions(r *http.Request) []ManagerOption {
+	if h.r.Config(r.Context()).IdentityMaxAddressesAdminAPI() {
+		return nil
+	}
+	return []ManagerOption{ManagerAllowExceedingAddressLimit}
 }
 
 // A single identity.
@@ -87,6 +114,46 @@ type listIdentityParameters struct {
 	// default: 0
 	// min: 0
 	Page int `json:"page"`
+
+	// Page Size
+	//
+	// This is the number of items per page if the list is paginated by page tokens. Setting it or
+	// `page_token` orders the identities by their creation date and paginates them by page tokens
+	// instead of `page` and `per_page`.
+	//
+	// required: false
+	// in: query
+	// default: 250
+	// min: 1
+	// max: 1000
+	PageSize int `json:"page_size"`
+
+	// Page Token
+	//
+	// The token of the page as found in the `next` and `prev` links of the `Link` header.
+	//
+	// required: false
+	// in: query
+	PageToken string `json:"page_token"`
+
+	// Credentials Identifier
+	//
+	// Only lists identities with a credentials identifier, such as an email address or username, equal
+	// to it. Identifiers which are normalized to lower case are matched case-insensitively. Setting it
+	// paginates the identities by page tokens.
+	//
+	// required: false
+	// in: query
+	CredentialsIdentifier string `json:"credentials_identifier"`
+
+	// Similar Credentials Identifier
+	//
+	// Only lists identities with a credentials identifier containing it, ignoring the case. Setting it
+	// paginates the identities by page tokens.
+	//
+	// required: false
+	// in: query
+	CredentialsIdentifierSimilar string `json:"credentials_identifier_similar"`
 }
 
 // swagger:route GET /identities admin listIdentities
@@ -95,6 +162,13 @@ type listIdentityParameters struct {
 //
 // Lists all identities. Does not support search at the moment.
 //
+// The identities are paginated by `page` and `per_page` by default. Identities created or deleted while
+// iterating these pages may shift the results. Use `page_size` and the page tokens of the `next` and
+// `prev` links in the `Link` header for results which stay stable under concurrent writes.
+//
+// Use `credentials_identifier` to find the identity signing in with an identifier such as an email
+// address, or `credentials_identifier_similar` to search identifiers.
+//
 // Learn how identities work in [ORY Kratos' User And Identity Model Documentation](https://www.ory.sh/docs/next/kratos/concepts/identity-user-model).
 //
 //     Produces:
@@ -106,6 +180,11 @@ type listIdentityParameters struct {
 //       200: identityList
 //       500: genericError
 func (h *Handler) list(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	if q := r.URL.Query(); x.IsTokenPagination(r) || q.Get("credentials_identifier") != "" || q.Get("credentials_identifier_similar") != "" {
+		h.listPage(w, r)
+		return
+	}
+
 	page, itemsPerPage := x.ParsePagination(r)
 	is, err := h.r.IdentityPool().ListIdentities(r.Context(), page, itemsPerPage)
 	if err != nil {
@@ -123,6 +202,68 @@ func (h *Handler) list(w http.ResponseWriter, r *http.Request, _ httprouter.Para
 	h.r.Writer().Write(w, r, is)
 }
 
+func (h *Handler) listPage(w http.ResponseWriter, r *http.Request) {
+	pageToken, pageSize := x.ParseTokenPagination(r)
+	opts := ListIdentitiesOptions{
+		PageToken:                    pageToken,
+		PageSize:                     pageSize,
+		CredentialsIdentifier:        r.URL.Query().Get("credentials_identifier"),
+		CredentialsIdentifierSimilar: r.URL.Query().Get("credentials_identifier_similar"),
+	}
+
+	is, next, prev, err := h.r.IdentityPool().ListIdentitiesPage(r.Context(), opts)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	// The links of the other pages keep the filters.
+	u := urlx.AppendPaths(h.r.Config(r.Context()).SelfAdminURL(), RouteBase)
+	q := u.Query()
+	if opts.CredentialsIdentifier != "" {
+		q.Set("credentials_identifier", opts.CredentialsIdentifier)
+	}
+	if opts.CredentialsIdentifierSimilar != "" {
+		q.Set("credentials_identifier_similar", opts.CredentialsIdentifierSimilar)
+	}
+	u.RawQuery = q.Encode()
+
+	x.TokenPaginationHeader(w, u, pageSize, next, prev)
+	h.r.Writer().Write(w, r, is)
+}
+
+// The number of identities.
+//
+// swagger:model identityCount
+type identityCount struct {
+	// required: true
+	Count int64 `json:"count"`
+}
+
+// swagger:route GET /identities/count admin countIdentities
+//
+// Count Identities
+//
+// Returns the number of identities without listing them.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: identityCount
+//       500: genericError
+func (h *Handler) count(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	count, err := h.r.IdentityPool().CountIdentities(r.Context())
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.r.Writer().Write(w, r, &identityCount{Count: count})
+}
+
 // swagger:parameters getIdentity
 // nolint:deadcode,unused
 type getIdentityParameters struct {
@@ -153,6 +294,11 @@ type getIdentityParameters struct {
 //       404: genericError
 //       500: genericError
 func (h *Handler) get(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if ps.ByName("id") == "count" {
+		h.count(w, r, ps)
+		return
+	}
+
 	i, err := h.r.IdentityPool().GetIdentity(r.Context(), x.ParseUUID(ps.ByName("id")))
 	if err != nil {
 		h.r.Writer().WriteError(w, r, err)
@@ -183,14 +329,67 @@ type CreateIdentity struct {
 	// required: true
 	// in: body
 	Traits json.RawMessage `json:"traits"`
+
+	// Credentials imports the identity's credentials, for example when migrating from another system.
+	//
+	// in: body
+	Credentials *CreateIdentityCredentials `json:"credentials,omitempty"`
+}
+
+// CreateIdentityCredentials are the credentials imported when creating an identity.
+//
+// swagger:model createIdentityCredentials
+type CreateIdentityCredentials struct {
+	// Password imports an already hashed password.
+	Password *CreateIdentityPasswordCredentials `json:"password,omitempty"`
+}
+
+// CreateIdentityPasswordCredentials is an already hashed password imported when creating an identity.
+//
+// swagger:model createIdentityPasswordCredentials
+type CreateIdentityPasswordCredentials struct {
+	// HashedPassword is the password hashed with bcrypt or Argon2id, for example `$2a$12$...`. The password
+	// is rehashed with the configured algorithm on sign in if `rehash_on_login` is enabled.
+	//
+	// required: true
+	HashedPassword string `json:"hashed_password"`
+}
+
+// newIdentity returns the identity to be created, including its imported credentials.
+func (cr *CreateIdentity) newIdentity() (*Identity, error) {
+	i := &Identity{SchemaID: cr.SchemaID, Traits: []byte(cr.Traits)}
+	if cr.Credentials == nil || cr.Credentials.Password == nil {
+		return i, nil
+	}
+
+	hashed := cr.Credentials.Password.HashedPassword
+	if !hash.IsSupportedHash([]byte(hashed)) {
+		return nil, errors.WithStack(herodot.ErrBadRequest.
+			WithReasonf("The hashed password must be hashed with bcrypt or Argon2id."))
+	}
+
+	co, err := json.Marshal(&struct {
+		HashedPassword string `json:"hashed_password"`
+	}{HashedPassword: hashed})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// The identifiers are set by the identity's traits schema when the identity is validated.
+	i.SetCredentials(CredentialsTypePassword, Credentials{
+		Type:        CredentialsTypePassword,
+		Identifiers: []string{},
+		Config:      co,
+	})
+	return i, nil
 }
 
 // swagger:route POST /identities admin createIdentity
 //
 // Create an Identity
 //
-// This endpoint creates an identity. It is NOT possible to set an identity's credentials (password, ...)
-// using this method! A way to achieve that will be introduced in the future.
+// This endpoint creates an identity. An identity's password can only be set by importing a password which
+// was already hashed with bcrypt or Argon2id. Other credentials can not be set using this method.
 //
 // Learn how identities work in [ORY Kratos' User And Identity Model Documentation](https://www.ory.sh/docs/next/kratos/concepts/identity-user-model).
 //
@@ -214,8 +413,13 @@ func (h *Handler) create(w http.ResponseWriter, r *http.Request, _ httprouter.Pa
 		return
 	}
 
-	i := &Identity{SchemaID: cr.SchemaID, Traits: []byte(cr.Traits)}
-	if err := h.r.IdentityManager().Create(r.Context(), i); err != nil {
+	i, err := cr.newIdentity()
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if err := h.r.IdentityManager().Create(r.Context(), i, h.managerOptions(r)...); err != nil {
 		h.r.Writer().WriteError(w, r, err)
 		return
 	}
@@ -230,6 +434,166 @@ func (h *Handler) create(w http.ResponseWriter, r *http.Request, _ httprouter.Pa
 	)
 }
 
+// swagger:parameters createIdentities
+// nolint:deadcode,unused
+type createIdentitiesParameters struct {
+	// in: body
+	Body []CreateIdentity
+
+	// All or Nothing
+	//
+	// If set, no identity is created unless all identities of the batch can be created.
+	//
+	// required: false
+	// in: query
+	AllOrNothing bool `json:"all_or_nothing"`
+}
+
+const (
+	createIdentitiesStatusCreated    = "created"
+	createIdentitiesStatusFailed     = "failed"
+	createIdentitiesStatusRolledBack = "rolled_back"
+)
+
+// The result of a batch import of identities.
+//
+// swagger:model createIdentitiesResponse
+type createIdentitiesResponse struct {
+	// Identities holds the result of every identity of the batch, in the order of the request.
+	//
+	// required: true
+	Identities []createIdentitiesResult `json:"identities"`
+}
+
+// The result of an identity of a batch import.
+//
+// swagger:model createIdentitiesResult
+type createIdentitiesResult struct {
+	// Status is `created` if the identity was created, `failed` if it could not be created, and
+	// `rolled_back` if it was valid but other identities of an all or nothing batch could not be created.
+	//
+	// required: true
+	Status string `json:"status"`
+
+	// IdentityID is the ID of the created identity.
+	IdentityID *uuid.UUID `json:"identity_id,omitempty"`
+
+	// Error explains why the identity was not created.
+	Error *herodot.DefaultError `json:"error,omitempty"`
+}
+
+// swagger:route POST /identities/batch admin createIdentities
+//
+// Create Identities in a Batch
+//
+// This endpoint creates up to `identity.batch.max_size` identities within a single transaction, for example
+// to import identities from another system. Each identity is created like with `POST /identities`, including
+// its already hashed password. The response holds the result of every identity of the batch.
+//
+// Unless `all_or_nothing` is set, the identities which could be created are committed even if other
+// identities of the batch failed.
+//
+// Learn how identities work in [ORY Kratos' User And Identity Model Documentation](https://www.ory.sh/docs/next/kratos/concepts/identity-user-model).
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: createIdentitiesResponse
+//       400: genericError
+//       500: genericError
+func (h *Handler) batchCreate(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := r.Context()
+	c := h.r.Config(ctx)
+	allOrNothing := r.URL.Query().Get("all_or_nothing") == "true"
+
+	batch, err := decodeCreateIdentitiesBatch(r, c.IdentityBatchMaxSize())
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	errs := make([]error, len(batch))
+	identities := make([]*Identity, len(batch))
+	is := make([]*Identity, 0, len(batch))
+	positions := make([]int, 0, len(batch))
+	for k := range batch {
+		if identities[k], errs[k] = batch[k].newIdentity(); errs[k] != nil {
+			continue
+		}
+		is = append(is, identities[k])
+		positions = append(positions, k)
+	}
+
+	if allOrNothing && len(is) < len(batch) {
+		for _, k := range positions {
+			errs[k] = errors.WithStack(ErrBatchRolledBack)
+		}
+	} else {
+		created, err := h.r.IdentityManager().CreateIdentities(ctx, is, allOrNothing, h.managerOptions(r)...)
+		if err != nil {
+			h.r.Writer().WriteError(w, r, err)
+			return
+		}
+		for k, err := range created {
+			errs[positions[k]] = err
+		}
+	}
+
+	res := createIdentitiesResponse{Identities: make([]createIdentitiesResult, len(batch))}
+	for k, err := range errs {
+		switch {
+		case err == nil:
+			res.Identities[k] = createIdentitiesResult{Status: createIdentitiesStatusCreated, IdentityID: &identities[k].ID}
+		case errors.Is(err, ErrBatchRolledBack):
+			res.Identities[k] = createIdentitiesResult{Status: createIdentitiesStatusRolledBack,
+				Error: herodot.ToDefaultError(err, r.Header.Get("X-Request-ID"))}
+		default:
+			res.Identities[k] = createIdentitiesResult{Status: createIdentitiesStatusFailed,
+				Error: herodot.ToDefaultError(x.RedactError(c, err), r.Header.Get("X-Request-ID"))}
+		}
+	}
+
+	h.r.Writer().Write(w, r, &res)
+}
+
+// decodeCreateIdentitiesBatch decodes the identities of a batch one by one to reject batches with more than
+// max identities without reading them.
+func decodeCreateIdentitiesBatch(r *http.Request, max int) ([]CreateIdentity, error) {
+	dec := jsonx.NewStrictDecoder(r.Body)
+	if t, err := dec.Token(); err != nil {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithReasonf("Unable to decode the batch of identities.").WithDebug(err.Error()))
+	} else if d, ok := t.(json.Delim); !ok || d != '[' {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithReasonf("The batch of identities must be a JSON array."))
+	}
+
+	batch := make([]CreateIdentity, 0)
+	for dec.More() {
+		if len(batch) >= max {
+			return nil, errors.WithStack(herodot.ErrBadRequest.
+				WithReasonf("The batch must not contain more than %d identities.", max))
+		}
+
+		var cr CreateIdentity
+		if err := dec.Decode(&cr); err != nil {
+			return nil, errors.WithStack(herodot.ErrBadRequest.
+				WithReasonf("Unable to decode identity %d of the batch.", len(batch)).WithDebug(err.Error()))
+		}
+		batch = append(batch, cr)
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithReasonf("Unable to decode the batch of identities.").WithDebug(err.Error()))
+	}
+
+	return batch, nil
+}
+
 // swagger:parameters updateIdentity
 // nolint:deadcode,unused
 type updateIdentityParameters struct {
@@ -262,7 +626,8 @@ type UpdateIdentity struct {
 // This endpoint updates an identity. It is NOT possible to set an identity's credentials (password, ...)
 // using this method! A way to achieve that will be introduced in the future.
 //
-// The full identity payload (except credentials) is expected. This endpoint does not support patching.
+// The full identity payload (except credentials) is expected. Use `PATCH /identities/{id}` to update
+// single traits.
 //
 // Learn how identities work in [ORY Kratos' User And Identity Model Documentation](https://www.ory.sh/docs/next/kratos/concepts/identity-user-model).
 //
@@ -301,7 +666,133 @@ func (h *Handler) update(w http.ResponseWriter, r *http.Request, ps httprouter.P
 	if err := h.r.IdentityManager().Update(
 		r.Context(),
 		identity,
-		ManagerAllowWriteProtectedTraits,
+		append(h.managerOptions(r), ManagerAllowWriteProtectedTraits)...,
+	); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.r.Writer().Write(w, r, identity)
+}
+
+// swagger:parameters patchIdentity
+// nolint:deadcode,unused
+type patchIdentityParameters struct {
+	// ID must be set to the ID of identity you want to update
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+
+	// A JSON Patch (RFC 6902) document which is applied to the identity's `traits`. If the request is sent
+	// with Content-Type `application/merge-patch+json`, a JSON Merge Patch (RFC 7386) document which is
+	// applied to the identity's `schema_id` and `traits` is expected instead.
+	//
+	// in: body
+	Body []x.JSONPatch
+}
+
+// A JSON Merge Patch (RFC 7386) document which is applied to an identity. Omitted fields are left
+// unchanged and fields set to `null` in `traits` are removed.
+//
+// swagger:model identityMergePatch
+// nolint:deadcode,unused
+type identityMergePatch struct {
+	// SchemaID is the ID of the JSON Schema the patched identity is validated against.
+	SchemaID string `json:"schema_id,omitempty"`
+
+	// Traits are merged into the identity's traits.
+	Traits json.RawMessage `json:"traits,omitempty"`
+}
+
+// swagger:route PATCH /identities/{id} admin patchIdentity
+//
+// Patch an Identity
+//
+// This endpoint partially updates an identity, which allows updating a single trait without sending the
+// whole traits object. The patched result is validated against the identity's JSON Schema and the
+// identity is left unchanged if the validation fails.
+//
+// With Content-Type `application/json-patch+json`, the body is a JSON Patch (RFC 6902) whose operations
+// are applied to the identity's `traits`, e.g. `[{"op": "replace", "path": "/traits/name/first", "value": "Jane"}]`.
+// Operations on any other path, such as `/credentials` or `/schema_id`, are rejected.
+//
+// With Content-Type `application/merge-patch+json`, the body is a JSON Merge Patch (RFC 7386) which is
+// applied to the identity's `schema_id` and `traits`. Keys missing from the patch are left unchanged and
+// keys set to `null` are removed.
+//
+// It is NOT possible to set an identity's credentials using this method.
+//
+// Learn how identities work in [ORY Kratos' User And Identity Model Documentation](https://www.ory.sh/docs/next/kratos/concepts/identity-user-model).
+//
+//     Consumes:
+//     - application/json-patch+json
+//     - application/merge-patch+json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: identityResponse
+//       400: genericError
+//       404: genericError
+//       415: genericError
+//       500: genericError
+func (h *Handler) patch(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if ct != x.ContentTypeMergePatch && ct != x.ContentTypeJSONPatch {
+		h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrUnsupportedMediaType.
+			WithReasonf("The request must be sent with Content-Type %s or %s.", x.ContentTypeJSONPatch, x.ContentTypeMergePatch)))
+		return
+	}
+
+	patch, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, errors.WithStack(err))
+		return
+	}
+
+	identity, err := h.r.PrivilegedIdentityPool().GetIdentityConfidential(r.Context(), x.ParseUUID(ps.ByName("id")))
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	original, err := json.Marshal(&UpdateIdentity{SchemaID: identity.SchemaID, Traits: json.RawMessage(identity.Traits)})
+	if err != nil {
+		h.r.Writer().WriteError(w, r, errors.WithStack(err))
+		return
+	}
+
+	var merged json.RawMessage
+	if ct == x.ContentTypeJSONPatch {
+		merged, err = applyTraitsJSONPatch(original, patch)
+	} else if merged, err = x.MergePatch(original, patch); err != nil {
+		err = errors.WithStack(herodot.ErrBadRequest.WithReasonf("Unable to apply the JSON Merge Patch: %s", err))
+	}
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	var ur UpdateIdentity
+	if err := jsonx.NewStrictDecoder(bytes.NewReader(merged)).Decode(&ur); err != nil {
+		h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.
+			WithReasonf("The patched identity is invalid: %s", err)))
+		return
+	}
+
+	if ur.SchemaID != "" {
+		identity.SchemaID = ur.SchemaID
+	}
+
+	identity.Traits = []byte(ur.Traits)
+	if err := h.r.IdentityManager().Update(
+		r.Context(),
+		identity,
+		append(h.managerOptions(r), ManagerAllowWriteProtectedTraits)...,
 	); err != nil {
 		h.r.Writer().WriteError(w, r, err)
 		return
@@ -310,6 +801,37 @@ func (h *Handler) update(w http.ResponseWriter, r *http.Request, ps httprouter.P
 	h.r.Writer().Write(w, r, identity)
 }
 
+// applyTraitsJSONPatch applies the JSON Patch to the identity document. Only operations on the
+// identity's traits are allowed.
+func applyTraitsJSONPatch(original, body []byte) (json.RawMessage, error) {
+	var patch []x.JSONPatch
+	if err := jsonx.NewStrictDecoder(bytes.NewReader(body)).Decode(&patch); err != nil {
+		return nil, errors.WithStack(herodot.ErrBadRequest.
+			WithReasonf("The JSON Patch is invalid: %s", err))
+	}
+
+	for _, op := range patch {
+		paths := []string{op.Path}
+		if op.Op == "move" || op.Op == "copy" {
+			paths = append(paths, op.From)
+		}
+
+		for _, p := range paths {
+			if p != "/traits" && !strings.HasPrefix(p, "/traits/") {
+				return nil, errors.WithStack(herodot.ErrBadRequest.
+					WithReasonf("The JSON Patch operation %q on path %q is not allowed because only the identity's traits can be patched.", op.Op, p))
+			}
+		}
+	}
+
+	patched, err := x.ApplyJSONPatch(original, patch)
+	if err != nil {
+		return nil, errors.WithStack(herodot.ErrBadRequest.
+			WithReasonf("Unable to apply the JSON Patch: %s", err))
+	}
+	return patched, nil
+}
+
 // swagger:parameters deleteIdentity
 // nolint:deadcode,unused
 type deleteIdentityParameters struct {
@@ -347,3 +869,83 @@ func (h *Handler) delete(w http.ResponseWriter, r *http.Request, ps httprouter.P
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// A list of credential events.
+// swagger:response credentialEventList
+// nolint:deadcode,unused
+type credentialEventListResponse struct {
+	// in: body
+	// required: true
+	// type: array
+	Body []CredentialEvent
+}
+
+// swagger:parameters getIdentityCredentialHistory
+// nolint:deadcode,unused
+type getIdentityCredentialHistoryParameters struct {
+	// ID is the identity's ID.
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+
+	// Items per Page
+	//
+	// This is the number of items per page.
+	//
+	// required: false
+	// in: query
+	// default: 100
+	// min: 1
+	// max: 500
+	PerPage int `json:"per_page"`
+
+	// Pagination Page
+	//
+	// required: false
+	// in: query
+	// default: 0
+	// min: 0
+	Page int `json:"page"`
+}
+
+// swagger:route GET /identities/{id}/credential-history admin getIdentityCredentialHistory
+//
+// Get the Credential History of an Identity
+//
+// Lists when the identity's credentials were added, changed, or removed and when the account was recovered,
+// most recent first. Events are only recorded if `identity.credential_history.enabled` is set and never
+// contain secret material.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: credentialEventList
+//       404: genericError
+//       500: genericError
+func (h *Handler) credentialHistory(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	i, err := h.r.IdentityPool().GetIdentity(r.Context(), x.ParseUUID(ps.ByName("id")))
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	page, itemsPerPage := x.ParsePagination(r)
+	es, err := h.r.CredentialEventPersister().ListCredentialEvents(r.Context(), i.ID, page, itemsPerPage)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	total, err := h.r.CredentialEventPersister().CountCredentialEvents(r.Context(), i.ID)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	x.PaginationHeader(w, urlx.AppendPaths(h.r.Config(r.Context()).SelfAdminURL(), RouteBase, i.ID.String(), "credential-history"), total, page, itemsPerPage)
+	h.r.Writer().Write(w, r, es)
+}
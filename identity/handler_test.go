@@ -2,12 +2,16 @@ package identity_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
 	"testing"
 
+	"github.com/ory/x/sqlxx"
 	"github.com/ory/x/urlx"
 
 	"github.com/ory/kratos/internal/testhelpers"
@@ -16,6 +20,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/tidwall/gjson"
+	"golang.org/x/crypto/bcrypt"
 
 	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/identity"
@@ -36,8 +41,9 @@ func TestHandler(t *testing.T) {
 	conf.MustSet(config.ViperKeyAdminBaseURL, ts.URL)
 	testhelpers.SetDefaultIdentitySchema(t, conf, "file://./stub/identity.schema.json")
 	testhelpers.SetIdentitySchemas(t, conf, map[string]string{
-		"customer": "file://./stub/handler/customer.schema.json",
-		"employee": "file://./stub/handler/employee.schema.json",
+		"customer":  "file://./stub/handler/customer.schema.json",
+		"employee":  "file://./stub/handler/employee.schema.json",
+		"addresses": "file://./stub/handler/addresses.schema.json",
 	})
 	conf.MustSet(config.ViperKeyPublicBaseURL, mockServerURL.String())
 
@@ -252,12 +258,190 @@ func TestHandler(t *testing.T) {
 		}
 	})
 
+	t.Run("case=should count all identities", func(t *testing.T) {
+		total, err := reg.IdentityPool().CountIdentities(context.Background())
+		require.NoError(t, err)
+
+		res := get(t, "/identities/count", http.StatusOK)
+		assert.EqualValues(t, total, res.Get("count").Int(), "%s", res.Raw)
+
+		_ = send(t, "POST", "/identities", http.StatusCreated, &identity.CreateIdentity{Traits: []byte(`{"bar":"baz"}`)})
+		res = get(t, "/identities/count", http.StatusOK)
+		assert.EqualValues(t, total+1, res.Get("count").Int(), "%s", res.Raw)
+	})
+
 	t.Run("case=should list all identities", func(t *testing.T) {
 		res := get(t, "/identities", http.StatusOK)
 		assert.Empty(t, res.Get("0.credentials").String(), "%s", res.Raw)
 		assert.EqualValues(t, "baz", res.Get(`#(traits.bar=="baz").traits.bar`).String(), "%s", res.Raw)
 	})
 
+	t.Run("case=should return pagination link headers when listing identities", func(t *testing.T) {
+		res, err := ts.Client().Get(ts.URL + "/identities?per_page=1")
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		require.EqualValues(t, http.StatusOK, res.StatusCode)
+
+		link := res.Header.Get("Link")
+		assert.Contains(t, link, "<"+ts.URL+`/identities?page=0&per_page=1>; rel="first"`)
+		assert.Contains(t, link, "<"+ts.URL+`/identities?page=1&per_page=1>; rel="next"`)
+		assert.Contains(t, link, `rel="last"`)
+		assert.NotContains(t, link, `rel="prev"`)
+
+		total, err := reg.IdentityPool().CountIdentities(context.Background())
+		require.NoError(t, err)
+
+		res, err = ts.Client().Get(fmt.Sprintf("%s/identities?per_page=1&page=%d", ts.URL, total-1))
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		require.EqualValues(t, http.StatusOK, res.StatusCode)
+
+		link = res.Header.Get("Link")
+		assert.Contains(t, link, `rel="first"`)
+		assert.Contains(t, link, `rel="prev"`)
+		assert.NotContains(t, link, `rel="next"`)
+	})
+
+	t.Run("case=should paginate identities by page tokens", func(t *testing.T) {
+		var list = func(t *testing.T, href string) (gjson.Result, map[string]string) {
+			res, err := ts.Client().Get(href)
+			require.NoError(t, err)
+			body, err := ioutil.ReadAll(res.Body)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+			require.EqualValues(t, http.StatusOK, res.StatusCode, "%s", body)
+
+			links := map[string]string{}
+			for _, l := range regexp.MustCompile(`<([^>]+)>; rel="(\w+)"`).FindAllStringSubmatch(res.Header.Get("Link"), -1) {
+				links[l[2]] = l[1]
+			}
+			return gjson.ParseBytes(body), links
+		}
+
+		total, err := reg.IdentityPool().CountIdentities(context.Background())
+		require.NoError(t, err)
+
+		res, links := list(t, ts.URL+"/identities?page_size=1")
+		assert.Len(t, res.Array(), 1)
+		assert.Equal(t, ts.URL+"/identities?page_size=1", links["first"])
+		assert.NotContains(t, links, "prev")
+		require.Contains(t, links, "next")
+
+		seen := map[string]bool{res.Get("0.id").String(): true}
+		next := links["next"]
+		for next != "" {
+			res, links = list(t, next)
+			assert.Contains(t, links, "prev")
+			for _, i := range res.Array() {
+				assert.False(t, seen[i.Get("id").String()], "%s", res.Raw)
+				seen[i.Get("id").String()] = true
+			}
+			next = links["next"]
+		}
+		assert.EqualValues(t, total, len(seen))
+
+		_ = get(t, "/identities?page_size=1&page_token=invalid", http.StatusBadRequest)
+	})
+
+	t.Run("case=should filter identities by credentials identifier", func(t *testing.T) {
+		i := identity.NewIdentity("")
+		i.SetCredentials(identity.CredentialsTypePassword, identity.Credentials{
+			Type: identity.CredentialsTypePassword, Identifiers: []string{"filter-handler@ory.sh"},
+			Config: sqlxx.JSONRawMessage(`{}`),
+		})
+		require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(context.Background(), i))
+
+		res := get(t, "/identities?credentials_identifier=Filter-Handler@ory.sh", http.StatusOK)
+		assert.Len(t, res.Array(), 1, "%s", res.Raw)
+		assert.EqualValues(t, i.ID.String(), res.Get("0.id").String(), "%s", res.Raw)
+
+		res = get(t, "/identities?credentials_identifier=filter-handler", http.StatusOK)
+		assert.Len(t, res.Array(), 0, "%s", res.Raw)
+
+		res = get(t, "/identities?credentials_identifier_similar=filter-hand", http.StatusOK)
+		assert.EqualValues(t, i.ID.String(), res.Get("0.id").String(), "%s", res.Raw)
+
+		hres, err := ts.Client().Get(ts.URL + "/identities?credentials_identifier_similar=ory.sh&page_size=1")
+		require.NoError(t, err)
+		require.NoError(t, hres.Body.Close())
+		assert.Contains(t, hres.Header.Get("Link"), "credentials_identifier_similar=ory.sh")
+	})
+
+	t.Run("case=should create identities in a batch", func(t *testing.T) {
+		hashed, err := bcrypt.GenerateFromPassword([]byte("a-secret-password"), bcrypt.MinCost)
+		require.NoError(t, err)
+
+		var item = func(email, hashedPassword string) identity.CreateIdentity {
+			cr := identity.CreateIdentity{Traits: []byte(`{"email":"` + email + `"}`)}
+			if hashedPassword != "" {
+				cr.Credentials = &identity.CreateIdentityCredentials{
+					Password: &identity.CreateIdentityPasswordCredentials{HashedPassword: hashedPassword},
+				}
+			}
+			return cr
+		}
+
+		_ = send(t, "POST", "/identities", http.StatusCreated, item("batch-existing@ory.sh", string(hashed)))
+
+		t.Run("case=commits the successes", func(t *testing.T) {
+			res := send(t, "POST", "/identities/batch", http.StatusOK, []identity.CreateIdentity{
+				item("batch-1@ory.sh", string(hashed)),
+				item("Batch-Existing@ory.sh", ""),
+				item("batch-1@ory.sh", ""),
+				item("batch-2@ory.sh", "not-a-hash"),
+				item("batch-3@ory.sh", ""),
+			})
+
+			assert.Equal(t, "created", res.Get("identities.0.status").String(), "%s", res.Raw)
+			assert.Equal(t, "failed", res.Get("identities.1.status").String(), "%s", res.Raw)
+			assert.EqualValues(t, http.StatusConflict, res.Get("identities.1.error.code").Int(), "%s", res.Raw)
+			assert.Equal(t, "failed", res.Get("identities.2.status").String(), "%s", res.Raw)
+			assert.EqualValues(t, http.StatusConflict, res.Get("identities.2.error.code").Int(), "%s", res.Raw)
+			assert.Equal(t, "failed", res.Get("identities.3.status").String(), "%s", res.Raw)
+			assert.EqualValues(t, http.StatusBadRequest, res.Get("identities.3.error.code").Int(), "%s", res.Raw)
+			assert.Equal(t, "created", res.Get("identities.4.status").String(), "%s", res.Raw)
+
+			i, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(context.Background(), x.ParseUUID(res.Get("identities.0.identity_id").String()))
+			require.NoError(t, err)
+			c, ok := i.GetCredentials(identity.CredentialsTypePassword)
+			require.True(t, ok)
+			assert.Equal(t, []string{"batch-1@ory.sh"}, c.Identifiers)
+			assert.Equal(t, string(hashed), gjson.GetBytes(c.Config, "hashed_password").String())
+		})
+
+		t.Run("case=rolls back all or nothing", func(t *testing.T) {
+			res := send(t, "POST", "/identities/batch?all_or_nothing=true", http.StatusOK, []identity.CreateIdentity{
+				item("batch-4@ory.sh", ""),
+				item("batch-existing@ory.sh", ""),
+			})
+
+			assert.Equal(t, "rolled_back", res.Get("identities.0.status").String(), "%s", res.Raw)
+			assert.False(t, res.Get("identities.0.identity_id").Exists(), "%s", res.Raw)
+			assert.Equal(t, "failed", res.Get("identities.1.status").String(), "%s", res.Raw)
+
+			res = get(t, "/identities?credentials_identifier=batch-4@ory.sh", http.StatusOK)
+			assert.Len(t, res.Array(), 0, "%s", res.Raw)
+		})
+
+		t.Run("case=rejects batches exceeding the maximum size", func(t *testing.T) {
+			conf.MustSet(config.ViperKeyIdentityBatchMaxSize, 1)
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeyIdentityBatchMaxSize, nil)
+			})
+
+			res := send(t, "POST", "/identities/batch", http.StatusBadRequest, []identity.CreateIdentity{
+				item("batch-5@ory.sh", ""),
+				item("batch-6@ory.sh", ""),
+			})
+			assert.Contains(t, res.Get("error.reason").String(), "must not contain more than 1", "%s", res.Raw)
+		})
+
+		t.Run("case=rejects unsupported password hashes", func(t *testing.T) {
+			res := send(t, "POST", "/identities", http.StatusBadRequest, item("batch-7@ory.sh", "$md5$not-supported"))
+			assert.Contains(t, res.Get("error.reason").String(), "bcrypt or Argon2id", "%s", res.Raw)
+		})
+	})
+
 	t.Run("case=should not be able to update an identity that does not exist yet", func(t *testing.T) {
 		res := send(t, "PUT", "/identities/not-found", http.StatusNotFound, json.RawMessage(`{"traits": {"bar":"baz"}}`))
 		assert.Contains(t, res.Get("error.message").String(), "Unable to locate the resource", "%s", res.Raw)
@@ -266,4 +450,166 @@ func TestHandler(t *testing.T) {
 	t.Run("case=should return 404 for non-existing identities", func(t *testing.T) {
 		remove(t, "/identities/"+x.NewUUID().String(), http.StatusNotFound)
 	})
+
+	t.Run("case=should patch an identity", func(t *testing.T) {
+		var patch = func(t *testing.T, href, contentType, body string, expectCode int) gjson.Result {
+			req, err := http.NewRequest("PATCH", ts.URL+href, bytes.NewBufferString(body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", contentType)
+			res, err := ts.Client().Do(req)
+			require.NoError(t, err)
+			resBody, err := ioutil.ReadAll(res.Body)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+
+			require.EqualValues(t, expectCode, res.StatusCode, "%s", resBody)
+			return gjson.ParseBytes(resBody)
+		}
+
+		email := x.NewUUID().String() + "@ory.sh"
+		res := send(t, "POST", "/identities", http.StatusCreated, &identity.CreateIdentity{Traits: json.RawMessage(`{"bar":"baz","email":"` + email + `"}`)})
+		href := "/identities/" + res.Get("id").String()
+
+		t.Run("case=should update a single trait", func(t *testing.T) {
+			res := patch(t, href, "application/merge-patch+json", `{"traits":{"bar":"qux"}}`, http.StatusOK)
+			assert.Equal(t, "qux", res.Get("traits.bar").String(), "%s", res.Raw)
+			assert.Equal(t, email, res.Get("traits.email").String(), "%s", res.Raw)
+			assert.Equal(t, "default", res.Get("schema_id").String(), "%s", res.Raw)
+
+			res = get(t, href, http.StatusOK)
+			assert.Equal(t, "qux", res.Get("traits.bar").String(), "%s", res.Raw)
+			assert.Equal(t, email, res.Get("traits.email").String(), "%s", res.Raw)
+		})
+
+		t.Run("case=should remove a trait set to null", func(t *testing.T) {
+			res := patch(t, href, "application/merge-patch+json; charset=utf-8", `{"traits":{"bar":null}}`, http.StatusOK)
+			assert.False(t, res.Get("traits.bar").Exists(), "%s", res.Raw)
+			assert.Equal(t, email, res.Get("traits.email").String(), "%s", res.Raw)
+		})
+
+		t.Run("case=should validate the merged traits", func(t *testing.T) {
+			patch(t, href, "application/merge-patch+json", `{"traits":{"bar":1}}`, http.StatusBadRequest)
+			assert.Equal(t, email, get(t, href, http.StatusOK).Get("traits.email").String())
+		})
+
+		t.Run("case=should not allow setting other fields", func(t *testing.T) {
+			res := patch(t, href, "application/merge-patch+json", `{"credentials":{"password":{}}}`, http.StatusBadRequest)
+			assert.Contains(t, res.Get("error.reason").String(), "credentials", "%s", res.Raw)
+		})
+
+		t.Run("case=should apply a JSON Patch to nested traits", func(t *testing.T) {
+			res := patch(t, href, "application/json-patch+json", `[{"op":"add","path":"/traits/name","value":{"first":"Jane"}},{"op":"add","path":"/traits/name/last","value":"Doe"}]`, http.StatusOK)
+			assert.JSONEq(t, `{"first":"Jane","last":"Doe"}`, res.Get("traits.name").Raw, "%s", res.Raw)
+			assert.Equal(t, email, res.Get("traits.email").String(), "%s", res.Raw)
+
+			res = patch(t, href, "application/json-patch+json", `[{"op":"replace","path":"/traits/name/first","value":"John"}]`, http.StatusOK)
+			assert.JSONEq(t, `{"first":"John","last":"Doe"}`, res.Get("traits.name").Raw, "%s", res.Raw)
+
+			res = patch(t, href, "application/json-patch+json", `[{"op":"remove","path":"/traits/name/last"}]`, http.StatusOK)
+			assert.JSONEq(t, `{"first":"John"}`, res.Get("traits.name").Raw, "%s", res.Raw)
+
+			res = get(t, href, http.StatusOK)
+			assert.JSONEq(t, `{"first":"John"}`, res.Get("traits.name").Raw, "%s", res.Raw)
+			assert.Equal(t, email, res.Get("traits.email").String(), "%s", res.Raw)
+		})
+
+		t.Run("case=should not persist a JSON Patch violating the schema", func(t *testing.T) {
+			patch(t, href, "application/json-patch+json", `[{"op":"add","path":"/traits/bar","value":"baz"},{"op":"replace","path":"/traits/name/first","value":1}]`, http.StatusBadRequest)
+
+			res := get(t, href, http.StatusOK)
+			assert.JSONEq(t, `{"first":"John"}`, res.Get("traits.name").Raw, "%s", res.Raw)
+			assert.False(t, res.Get("traits.bar").Exists(), "%s", res.Raw)
+		})
+
+		t.Run("case=should reject JSON Patch operations on other fields", func(t *testing.T) {
+			for _, body := range []string{
+				`[{"op":"add","path":"/credentials/password","value":{}}]`,
+				`[{"op":"replace","path":"/schema_id","value":"customer"}]`,
+				`[{"op":"copy","from":"/schema_id","path":"/traits/bar"}]`,
+			} {
+				res := patch(t, href, "application/json-patch+json", body, http.StatusBadRequest)
+				assert.Contains(t, res.Get("error.reason").String(), "only the identity's traits can be patched", "%s", res.Raw)
+			}
+			assert.Equal(t, "default", get(t, href, http.StatusOK).Get("schema_id").String())
+		})
+
+		t.Run("case=should reject an invalid JSON Patch", func(t *testing.T) {
+			res := patch(t, href, "application/json-patch+json", `[{"op":"remove","path":"/traits/does-not-exist"}]`, http.StatusBadRequest)
+			assert.Contains(t, res.Get("error.reason").String(), "Unable to apply the JSON Patch", "%s", res.Raw)
+		})
+
+		t.Run("case=should reject other content types", func(t *testing.T) {
+			patch(t, href, "application/json", `{"traits":{"bar":"qux"}}`, http.StatusUnsupportedMediaType)
+		})
+
+		t.Run("case=should return 404 for non-existing identities", func(t *testing.T) {
+			patch(t, "/identities/"+x.NewUUID().String(), "application/merge-patch+json", `{"traits":{"bar":"qux"}}`, http.StatusNotFound)
+		})
+	})
+
+	t.Run("case=should return the credential history", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyIdentityCredentialHistoryEnabled, true)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyIdentityCredentialHistoryEnabled, false)
+		})
+
+		_ = get(t, "/identities/"+x.NewUUID().String()+"/credential-history", http.StatusNotFound)
+
+		res := send(t, "POST", "/identities", http.StatusCreated, &identity.CreateIdentity{Traits: json.RawMessage(`{"bar":"baz"}`)})
+		id := x.ParseUUID(res.Get("id").String())
+
+		parsed := get(t, "/identities/"+id.String()+"/credential-history", http.StatusOK)
+		require.True(t, parsed.IsArray(), "%s", parsed.Raw)
+		assert.Len(t, parsed.Array(), 0)
+
+		require.NoError(t, reg.IdentityManager().RecordCredentialEvents(context.Background(),
+			identity.NewCredentialEvent(id, identity.CredentialEventAdded, identity.CredentialsTypePassword, "192.0.2.1")))
+		require.NoError(t, reg.IdentityManager().RecordCredentialEvents(context.Background(),
+			identity.NewCredentialEvent(id, identity.CredentialEventRecovered, "", "192.0.2.2")))
+
+		parsed = get(t, "/identities/"+id.String()+"/credential-history", http.StatusOK)
+		require.Len(t, parsed.Array(), 2, "%s", parsed.Raw)
+		assert.Equal(t, "recovered", parsed.Get("0.event").String(), "%s", parsed.Raw)
+		assert.Equal(t, "192.0.2.2", parsed.Get("0.ip_address").String(), "%s", parsed.Raw)
+		assert.Equal(t, "added", parsed.Get("1.event").String(), "%s", parsed.Raw)
+		assert.Equal(t, "password", parsed.Get("1.credentials_type").String(), "%s", parsed.Raw)
+		assert.Equal(t, id.String(), parsed.Get("1.identity_id").String(), "%s", parsed.Raw)
+
+		parsed = get(t, "/identities/"+id.String()+"/credential-history?per_page=1", http.StatusOK)
+		assert.Len(t, parsed.Array(), 1, "%s", parsed.Raw)
+
+		t.Run("case=does not record events if disabled", func(t *testing.T) {
+			conf.MustSet(config.ViperKeyIdentityCredentialHistoryEnabled, false)
+			require.NoError(t, reg.IdentityManager().RecordCredentialEvents(context.Background(),
+				identity.NewCredentialEvent(id, identity.CredentialEventUpdated, identity.CredentialsTypePassword, "192.0.2.1")))
+			assert.Len(t, get(t, "/identities/"+id.String()+"/credential-history", http.StatusOK).Array(), 2)
+		})
+	})
+
+	t.Run("case=should apply the address limit depending on the configuration", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyIdentityMaxAddresses, 1)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyIdentityMaxAddresses, 0)
+			conf.MustSet(config.ViperKeyIdentityMaxAddressesAdminAPI, false)
+		})
+
+		traits := func() json.RawMessage {
+			return json.RawMessage(`{"emails":["` + x.NewUUID().String() + `@ory.sh","` + x.NewUUID().String() + `@ory.sh"]}`)
+		}
+
+		t.Run("case=admin api bypasses the limit", func(t *testing.T) {
+			conf.MustSet(config.ViperKeyIdentityMaxAddressesAdminAPI, false)
+			res := send(t, "POST", "/identities", http.StatusCreated, &identity.CreateIdentity{SchemaID: "addresses", Traits: traits()})
+			assert.Len(t, res.Get("verifiable_addresses").Array(), 2, "%s", res.Raw)
+		})
+
+		t.Run("case=admin api enforces the limit", func(t *testing.T) {
+			conf.MustSet(config.ViperKeyIdentityMaxAddressesAdminAPI, true)
+			res := send(t, "POST", "/identities", http.StatusBadRequest, &identity.CreateIdentity{SchemaID: "addresses", Traits: traits()})
+			assert.Contains(t, res.Get("error.reason").String(), "can not have more than 1 addresses", "%s", res.Raw)
+
+			res = send(t, "POST", "/identities", http.StatusCreated, &identity.CreateIdentity{SchemaID: "addresses", Traits: json.RawMessage(`{"emails":["` + x.NewUUID().String() + `@ory.sh"]}`)})
+			send(t, "PUT", "/identities/"+res.Get("id").String(), http.StatusBadRequest, &identity.UpdateIdentity{SchemaID: "addresses", Traits: traits()})
+		})
+	})
 }
@@ -0,0 +1,168 @@
+package identity
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/httpx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/x"
+)
+
+type (
+	schemaResolverDependencies interface {
+		config.Provider
+		x.LoggingProvider
+	}
+
+	// SchemaResolver selects the identity traits schema of new identities registered through a request.
+	//
+	// If `identity.schema_webhook` is configured, the web hook is called with the request's metadata and
+	// the selected schema is cached per context key. Otherwise, the schema is selected by the request's
+	// host as configured by `identity.host_schemas`.
+	SchemaResolver struct {
+		d     schemaResolverDependencies
+		cache CacheBackend
+	}
+
+	SchemaResolverProvider interface {
+		IdentitySchemaResolver() *SchemaResolver
+	}
+
+	// SchemaWebhookRequest is the payload sent to the identity traits schema web hook.
+	SchemaWebhookRequest struct {
+		// ContextKey identifies the requests which share the selected schema while it is cached.
+		ContextKey string `json:"context_key"`
+
+		Host     string      `json:"host"`
+		Path     string      `json:"path"`
+		Query    url.Values  `json:"query"`
+		Headers  http.Header `json:"headers"`
+		ClientIP string      `json:"client_ip"`
+	}
+
+	schemaWebhookResponse struct {
+		SchemaID string `json:"schema_id"`
+	}
+)
+
+// schemaWebhookHeaders are the request headers forwarded to the identity traits schema web hook.
+var schemaWebhookHeaders = []string{"Accept-Language", "Origin", "Referer", "User-Agent"}
+
+func NewSchemaResolver(d schemaResolverDependencies) *SchemaResolver {
+	return &SchemaResolver{d: d, cache: NewMemoryCacheBackend()}
+}
+
+// TraitsSchemaForRequest returns the identity traits schema of new identities registered through the
+// request.
+func (s *SchemaResolver) TraitsSchemaForRequest(r *http.Request) (*config.Schema, error) {
+	c := s.d.Config(r.Context())
+	host := x.RequestHost(r, c.TrustedProxies())
+
+	id := c.IdentityTraitsSchemaIDForHost(host)
+	if u := c.IdentitySchemaWebhookURL(); u != nil {
+		var err error
+		if id, err = s.schemaIDFromWebhook(r, u, host); err != nil {
+			return nil, err
+		}
+	}
+
+	schema, err := c.IdentityTraitsSchemas().FindSchemaByID(id)
+	if err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.
+			WithReasonf("The identity traits schema selected for this request does not exist.").WithDebug(err.Error()))
+	}
+	return schema, nil
+}
+
+func (s *SchemaResolver) schemaIDFromWebhook(r *http.Request, u *url.URL, host string) (string, error) {
+	ctx := r.Context()
+	c := s.d.Config(ctx)
+	key := "kratos:identity_schema:" + host
+
+	if id, err := s.cache.Get(ctx, key); err == nil {
+		return string(id), nil
+	}
+
+	p := &SchemaWebhookRequest{
+		ContextKey: host,
+		Host:       host,
+		Path:       r.URL.Path,
+		Query:      r.URL.Query(),
+		Headers:    http.Header{},
+		ClientIP:   x.ClientIPString(r, c.TrustedProxies()),
+	}
+	for _, h := range schemaWebhookHeaders {
+		if v := r.Header.Values(h); len(v) > 0 {
+			p.Headers[h] = v
+		}
+	}
+
+	id, err := s.callSchemaWebhook(ctx, u, c.IdentitySchemaWebhookTimeout(), p)
+	if err != nil {
+		return "", err
+	}
+
+	if ttl := c.IdentitySchemaWebhookCacheTTL(); ttl > 0 {
+		if err := s.cache.Set(ctx, key, []byte(id), ttl); err != nil {
+			s.d.Logger().WithError(err).Warn("Unable to cache the identity traits schema selected by the web hook.")
+		}
+	}
+
+	return id, nil
+}
+
+func (s *SchemaResolver) callSchemaWebhook(ctx context.Context, u *url.URL, timeout time.Duration, p *SchemaWebhookRequest) (string, error) {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(p); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	req, err := retryablehttp.NewRequest("POST", u.String(), body.Bytes())
+	if err != nil {
+		return "", errors.WithStack(herodot.ErrInternalServerError.
+			WithReasonf("Unable to create the identity traits schema web hook request.").WithDebug(err.Error()))
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := httpx.NewResilientClient(
+		httpx.ResilientClientWithConnectionTimeout(timeout),
+		httpx.ResilientClientWithLogger(s.d.Logger()),
+	).Do(req)
+	if err != nil {
+		return "", errors.WithStack(herodot.ErrInternalServerError.
+			WithReasonf("Unable to call the identity traits schema web hook.").WithDebug(err.Error()))
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", errors.WithStack(herodot.ErrInternalServerError.
+			WithReasonf("The identity traits schema web hook responded with an unexpected status code.").
+			WithDebug(fmt.Sprintf("expected 200 but got %d", res.StatusCode)))
+	}
+
+	var out schemaWebhookResponse
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1024*1024)).Decode(&out); err != nil {
+		return "", errors.WithStack(herodot.ErrInternalServerError.
+			WithReasonf("Unable to decode the identity traits schema web hook response.").WithDebug(err.Error()))
+	}
+
+	if _, err := s.d.Config(ctx).IdentityTraitsSchemas().FindSchemaByID(out.SchemaID); err != nil {
+		return "", errors.WithStack(herodot.ErrInternalServerError.
+			WithReasonf("The identity traits schema web hook selected a schema which does not exist.").WithDebug(err.Error()))
+	}
+
+	return out.SchemaID, nil
+}
@@ -0,0 +1,115 @@
+package identity_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+)
+
+func TestTraitsSchemaForRequest(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/identity.schema.json")
+	conf.MustSet(config.ViperKeyIdentitySchemas, []config.Schema{{ID: "brand-a", URL: "file://./stub/identity-2.schema.json"}})
+	conf.MustSet(config.ViperKeyIdentityHostSchemas, []map[string]interface{}{
+		{"host": "brand-a.example.org", "schema_id": "brand-a"},
+		{"host": "broken.example.org", "schema_id": "does-not-exist"},
+	})
+	conf.MustSet(config.ViperKeyTrustedProxies, []string{"10.0.0.0/8"})
+
+	for k, tc := range []struct {
+		d        string
+		host     string
+		remote   string
+		expected string
+	}{
+		{d: "mapped host", host: "brand-a.example.org", expected: "brand-a"},
+		{d: "mapped host with port", host: "Brand-A.example.org:4433", expected: "brand-a"},
+		{d: "unmapped host", host: "brand-b.example.org", expected: config.DefaultIdentityTraitsSchemaID},
+		{d: "forwarded by trusted proxy", remote: "10.0.0.1:1234", expected: "brand-a"},
+	} {
+		t.Run("case="+tc.d, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/self-service/registration", nil)
+			if tc.host != "" {
+				r.Host = tc.host
+			}
+			if tc.remote != "" {
+				r.RemoteAddr = tc.remote
+				r.Header.Set("X-Forwarded-Host", "brand-a.example.org")
+			}
+
+			s, err := reg.IdentitySchemaResolver().TraitsSchemaForRequest(r)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, s.ID, "%d", k)
+		})
+	}
+
+	t.Run("case=unknown schema", func(t *testing.T) {
+		r := httptest.NewRequest("POST", "/self-service/registration", nil)
+		r.Host = "broken.example.org"
+		_, err := reg.IdentitySchemaResolver().TraitsSchemaForRequest(r)
+		require.Error(t, err)
+	})
+
+	t.Run("case=web hook", func(t *testing.T) {
+		var calls int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+
+			var p identity.SchemaWebhookRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&p))
+			assert.Equal(t, p.Host, p.ContextKey)
+			assert.Equal(t, "/self-service/registration", p.Path)
+			assert.Equal(t, "en", p.Headers.Get("Accept-Language"))
+
+			switch p.Host {
+			case "tenant-a.example.org":
+				_ = json.NewEncoder(w).Encode(map[string]string{"schema_id": "brand-a"})
+			case "broken.example.org":
+				_ = json.NewEncoder(w).Encode(map[string]string{"schema_id": "does-not-exist"})
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		t.Cleanup(ts.Close)
+
+		conf.MustSet(config.ViperKeyIdentitySchemaWebhookURL, ts.URL)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyIdentitySchemaWebhookURL, "")
+		})
+
+		newRequest := func(host string) *http.Request {
+			r := httptest.NewRequest("POST", "/self-service/registration", nil)
+			r.Host = host
+			r.Header.Set("Accept-Language", "en")
+			return r
+		}
+
+		t.Run("case=selects and caches the schema", func(t *testing.T) {
+			for i := 0; i < 2; i++ {
+				s, err := reg.IdentitySchemaResolver().TraitsSchemaForRequest(newRequest("tenant-a.example.org"))
+				require.NoError(t, err)
+				assert.Equal(t, "brand-a", s.ID)
+			}
+			assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+		})
+
+		t.Run("case=rejects unknown schema", func(t *testing.T) {
+			_, err := reg.IdentitySchemaResolver().TraitsSchemaForRequest(newRequest("broken.example.org"))
+			require.Error(t, err)
+		})
+
+		t.Run("case=fails if the web hook fails", func(t *testing.T) {
+			_, err := reg.IdentitySchemaResolver().TraitsSchemaForRequest(newRequest("tenant-b.example.org"))
+			require.Error(t, err)
+		})
+	})
+}
@@ -75,6 +75,11 @@ type (
 		// ---
 		RecoveryAddresses []RecoveryAddress `json:"recovery_addresses,omitempty" faker:"-" has_many:"identity_recovery_addresses" fk_id:"identity_id"`
 
+		// DeletedAt is the time at which the identity deleted its account in a self-service manner. Deleted
+		// identities can no longer sign in and are removed for good by `kratos janitor` once the retention
+		// window has passed.
+		DeletedAt *time.Time `json:"deleted_at,omitempty" faker:"-" db:"deleted_at"`
+
 		// CreatedAt is a helper struct field for gobuffalo.pop.
 		CreatedAt time.Time `json:"-" db:"created_at"`
 
@@ -161,6 +166,11 @@ func (i *Identity) ParseCredentials(t CredentialsType, config interface{}) (*Cre
 	return nil, herodot.ErrNotFound.WithReasonf("identity does not have credential type %s", t)
 }
 
+// IsDeleted returns true if the identity deleted its account.
+func (i *Identity) IsDeleted() bool {
+	return i.DeletedAt != nil
+}
+
 func (i *Identity) CopyWithoutCredentials() *Identity {
 	var ii = *i
 	ii.Credentials = nil
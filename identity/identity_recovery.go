@@ -63,3 +63,11 @@ func NewRecoveryEmailAddress(
 		IdentityID: identity,
 	}
 }
+
+func (a RecoveryAddress) GetID() uuid.UUID {
+	return a.ID
+}
+
+func (a RecoveryAddress) GetNID() uuid.UUID {
+	return a.NID
+}
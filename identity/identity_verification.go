@@ -44,6 +44,9 @@ type (
 
 		VerifiedAt sqlxx.NullTime `json:"verified_at" faker:"-" db:"verified_at"`
 
+		// LastVerifiedAt is the time at which ownership of the address was proven most recently.
+		LastVerifiedAt sqlxx.NullTime `json:"last_verified_at" faker:"-" db:"last_verified_at"`
+
 		// IdentityID is a helper struct field for gobuffalo.pop.
 		IdentityID uuid.UUID `json:"-" faker:"-" db:"identity_id"`
 		// CreatedAt is a helper struct field for gobuffalo.pop.
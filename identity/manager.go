@@ -14,16 +14,25 @@ import (
 	"github.com/ory/x/errorsx"
 
 	"github.com/ory/kratos/courier"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/schema"
 )
 
 var ErrProtectedFieldModified = herodot.ErrForbidden.
 	WithReasonf(`A field was modified that updates one or more credentials-related settings. This action was blocked because an unprivileged method was used to execute the update. This is either a configuration issue or a bug and should be reported to the system administrator.`)
 
+// ErrBatchRolledBack is returned for the valid identities of a batch which was rolled back because other
+// identities of the batch could not be created.
+var ErrBatchRolledBack = herodot.ErrConflict.WithError("the batch was rolled back").
+	WithReasonf(`The identity was not created because other identities of the batch could not be created.`)
+
 type (
 	managerDependencies interface {
+		config.Provider
 		PoolProvider
 		courier.Provider
 		ValidationProvider
+		CredentialEventPersistenceProvider
 	}
 	ManagementProvider interface {
 		IdentityManager() *Manager
@@ -33,8 +42,9 @@ type (
 	}
 
 	managerOptions struct {
-		ExposeValidationErrors    bool
-		AllowWriteProtectedTraits bool
+		ExposeValidationErrors     bool
+		AllowWriteProtectedTraits  bool
+		AllowExceedingAddressLimit bool
 	}
 
 	ManagerOption func(*managerOptions)
@@ -52,6 +62,11 @@ func ManagerAllowWriteProtectedTraits(options *managerOptions) {
 	options.AllowWriteProtectedTraits = true
 }
 
+// ManagerAllowExceedingAddressLimit skips the check of `identity.addresses.max`.
+func ManagerAllowExceedingAddressLimit(options *managerOptions) {
+	options.AllowExceedingAddressLimit = true
+}
+
 func newManagerOptions(opts []ManagerOption) *managerOptions {
 	var o managerOptions
 	for _, f := range opts {
@@ -66,9 +81,55 @@ func (m *Manager) Create(ctx context.Context, i *Identity, opts ...ManagerOption
 		return err
 	}
 
+	if err := m.validateAddressLimit(ctx, nil, i, o); err != nil {
+		return err
+	}
+
 	return m.r.IdentityPool().(PrivilegedPool).CreateIdentity(ctx, i)
 }
 
+// CreateIdentities validates and creates the identities within a single transaction. It returns an error for
+// every identity which could not be created. If allOrNothing is set, no identity is created unless all of them
+// can be created.
+func (m *Manager) CreateIdentities(ctx context.Context, is []*Identity, allOrNothing bool, opts ...ManagerOption) ([]error, error) {
+	o := newManagerOptions(opts)
+	errs := make([]error, len(is))
+	valid := make([]*Identity, 0, len(is))
+	positions := make([]int, 0, len(is))
+	for k, i := range is {
+		if err := m.validate(ctx, i, o); err != nil {
+			errs[k] = err
+			continue
+		}
+
+		if err := m.validateAddressLimit(ctx, nil, i, o); err != nil {
+			errs[k] = err
+			continue
+		}
+
+		valid = append(valid, i)
+		positions = append(positions, k)
+	}
+
+	if allOrNothing && len(valid) < len(is) {
+		for _, k := range positions {
+			errs[k] = errors.WithStack(ErrBatchRolledBack)
+		}
+		return errs, nil
+	}
+
+	created, err := m.r.IdentityPool().(PrivilegedPool).CreateIdentities(ctx, valid, allOrNothing)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, err := range created {
+		errs[positions[k]] = err
+	}
+
+	return errs, nil
+}
+
 func (m *Manager) requiresPrivilegedAccess(_ context.Context, original, updated *Identity, o *managerOptions) error {
 	if !o.AllowWriteProtectedTraits {
 		if !CredentialsEqual(updated.Credentials, original.Credentials) {
@@ -103,6 +164,10 @@ func (m *Manager) Update(ctx context.Context, updated *Identity, opts ...Manager
 		return err
 	}
 
+	if err := m.validateAddressLimit(ctx, original, updated, o); err != nil {
+		return err
+	}
+
 	return m.r.IdentityPool().(PrivilegedPool).UpdateIdentity(ctx, updated)
 }
 
@@ -143,6 +208,10 @@ func (m *Manager) SetTraits(ctx context.Context, id uuid.UUID, traits Traits, op
 		return nil, err
 	}
 
+	if err := m.validateAddressLimit(ctx, original, updated, o); err != nil {
+		return nil, err
+	}
+
 	return updated, nil
 }
 
@@ -165,3 +234,43 @@ func (m *Manager) validate(ctx context.Context, i *Identity, o *managerOptions)
 
 	return nil
 }
+
+// validateAddressLimit rejects identities which exceed `identity.addresses.max`. Identities which exceed the limit
+// already (e.g. because it was lowered) may still be updated as long as no addresses are added.
+func (m *Manager) validateAddressLimit(ctx context.Context, original, updated *Identity, o *managerOptions) error {
+	max := m.r.Config(ctx).IdentityMaxAddresses()
+	if max == 0 || o.AllowExceedingAddressLimit {
+		return nil
+	}
+
+	var verifiable, recovery int
+	if original != nil {
+		verifiable, recovery = len(original.VerifiableAddresses), len(original.RecoveryAddresses)
+	}
+
+	if (len(updated.VerifiableAddresses) > max && len(updated.VerifiableAddresses) > verifiable) ||
+		(len(updated.RecoveryAddresses) > max && len(updated.RecoveryAddresses) > recovery) {
+		err := schema.NewTooManyAddressesError(max)
+		if !o.ExposeValidationErrors {
+			return herodot.ErrBadRequest.WithReasonf("%s", err).WithWrap(err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// RecordCredentialEvents appends the events to the credential history if `identity.credential_history.enabled`
+// is set.
+func (m *Manager) RecordCredentialEvents(ctx context.Context, events ...*CredentialEvent) error {
+	if len(events) == 0 || !m.r.Config(ctx).IdentityCredentialHistoryEnabled() {
+		return nil
+	}
+	return m.r.CredentialEventPersister().CreateCredentialEvents(ctx, events...)
+}
+
+// RecordCredentialChanges appends the changes between the credentials of the original and the updated identity
+// to the credential history (see NewCredentialEventsFromDiff).
+func (m *Manager) RecordCredentialChanges(ctx context.Context, original, updated *Identity, ip string) error {
+	return m.RecordCredentialEvents(ctx, NewCredentialEventsFromDiff(original, updated, ip)...)
+}
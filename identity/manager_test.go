@@ -2,6 +2,7 @@ package identity_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"testing"
 
@@ -12,6 +13,8 @@ import (
 	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/schema"
+	"github.com/ory/kratos/text"
 	"github.com/ory/kratos/x"
 )
 
@@ -203,4 +206,64 @@ func TestManager(t *testing.T) {
 			checkExtensionFields(fromStore, "email-updatetraits-1@ory.sh")(t)
 		})
 	})
+
+	t.Run("case=should enforce the address limit", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyIdentityMaxAddresses, 2)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyIdentityMaxAddresses, 0)
+		})
+
+		withEmails := func(unprotected string, emails ...string) identity.Traits {
+			out, err := json.Marshal(map[string]interface{}{"email": emails[0], "emails": emails, "unprotected": unprotected})
+			require.NoError(t, err)
+			return out
+		}
+
+		assertTooManyAddresses := func(t *testing.T, err error) {
+			require.Error(t, err)
+			var ve *schema.ValidationError
+			require.True(t, errors.As(err, &ve), "%+v", err)
+			assert.Equal(t, text.ErrorValidationTooManyAddresses, ve.Messages[0].ID)
+		}
+
+		t.Run("method=Create", func(t *testing.T) {
+			i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+			i.Traits = withEmails("", "limit-create-1@ory.sh", "limit-create-2@ory.sh", "limit-create-3@ory.sh")
+			assertTooManyAddresses(t, reg.IdentityManager().Create(context.Background(), i, identity.ManagerExposeValidationErrorsForInternalTypeAssertion))
+
+			i.Traits = withEmails("", "limit-create-1@ory.sh", "limit-create-2@ory.sh")
+			require.NoError(t, reg.IdentityManager().Create(context.Background(), i))
+		})
+
+		t.Run("method=UpdateTraits", func(t *testing.T) {
+			original := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+			original.Traits = withEmails("", "limit-update-1@ory.sh")
+			require.NoError(t, reg.IdentityManager().Create(context.Background(), original))
+
+			require.NoError(t, reg.IdentityManager().UpdateTraits(context.Background(), original.ID,
+				withEmails("", "limit-update-1@ory.sh", "limit-update-2@ory.sh"), identity.ManagerAllowWriteProtectedTraits))
+
+			err := reg.IdentityManager().UpdateTraits(context.Background(), original.ID,
+				withEmails("", "limit-update-1@ory.sh", "limit-update-2@ory.sh", "limit-update-3@ory.sh"),
+				identity.ManagerAllowWriteProtectedTraits, identity.ManagerExposeValidationErrorsForInternalTypeAssertion)
+			assertTooManyAddresses(t, err)
+
+			fromStore, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(context.Background(), original.ID)
+			require.NoError(t, err)
+			assert.Len(t, fromStore.VerifiableAddresses, 2)
+			assert.Len(t, fromStore.RecoveryAddresses, 2)
+		})
+
+		t.Run("case=should allow exceeding the limit with option", func(t *testing.T) {
+			original := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+			original.Traits = withEmails("", "limit-bypass-1@ory.sh", "limit-bypass-2@ory.sh", "limit-bypass-3@ory.sh")
+			require.NoError(t, reg.IdentityManager().Create(context.Background(), original, identity.ManagerAllowExceedingAddressLimit))
+
+			t.Run("case=should allow updates which do not add addresses", func(t *testing.T) {
+				require.NoError(t, reg.IdentityManager().UpdateTraits(context.Background(), original.ID,
+					withEmails("changed", "limit-bypass-1@ory.sh", "limit-bypass-2@ory.sh", "limit-bypass-3@ory.sh"),
+					identity.ManagerAllowWriteProtectedTraits))
+			})
+		})
+	})
 }
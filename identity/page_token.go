@@ -0,0 +1,49 @@
+package identity
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+)
+
+// PageToken is the cursor of a page of identities. Identities are ordered by their creation date and ID,
+// which keeps pages stable while identities are created or deleted during the iteration.
+type PageToken struct {
+	// CreatedAt and ID identify the last identity seen, or the first one if Backwards is set.
+	CreatedAt time.Time `json:"c"`
+	ID        uuid.UUID `json:"i"`
+
+	// Backwards lists the identities before the cursor instead of after it.
+	Backwards bool `json:"b,omitempty"`
+}
+
+// NewPageToken returns the token of the page starting after (or before, if backwards is set) the identity.
+func NewPageToken(i *Identity, backwards bool) *PageToken {
+	return &PageToken{CreatedAt: i.CreatedAt.UTC(), ID: i.ID, Backwards: backwards}
+}
+
+// ParsePageToken decodes an opaque page token returned by ListIdentitiesPage.
+func ParsePageToken(token string) (*PageToken, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithReasonf("The page token is invalid.").WithDebug(err.Error()))
+	}
+
+	var t PageToken
+	if err := json.Unmarshal(raw, &t); err != nil {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithReasonf("The page token is invalid.").WithDebug(err.Error()))
+	}
+
+	return &t, nil
+}
+
+// Encode returns the opaque representation of the page token.
+func (t *PageToken) Encode() string {
+	raw, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
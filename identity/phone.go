@@ -0,0 +1,54 @@
+package identity
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// e164MinDigits is the length of the shortest phone numbers in use, for example in Niue.
+	e164MinDigits = 7
+
+	// e164MaxDigits is the maximum length of a phone number including its country code (ITU-T E.164).
+	e164MaxDigits = 15
+)
+
+// NormalizePhoneNumber returns the phone number in E.164 format, for example `+15551234567` for
+// `+1 (555) 123-4567`. The number must start with its country code, prefixed by `+` or `00`. Spaces,
+// dashes, dots, slashes, parentheses and a trunk prefix written as `(0)` are removed.
+func NormalizePhoneNumber(number string) (string, error) {
+	n := strings.TrimSpace(number)
+	switch {
+	case strings.HasPrefix(n, "+"):
+		n = n[1:]
+	case strings.HasPrefix(n, "00"):
+		n = n[2:]
+	default:
+		return "", errors.Errorf("the phone number %q must start with + and its country code", number)
+	}
+
+	// The trunk prefix of national numbers is sometimes written in parentheses, for example `+49 (0)30 1234567`.
+	n = strings.Replace(n, "(0)", "", 1)
+
+	var b strings.Builder
+	b.WriteByte('+')
+	for _, c := range n {
+		switch {
+		case c >= '0' && c <= '9':
+			b.WriteRune(c)
+		case strings.ContainsRune(" -./()", c):
+		default:
+			return "", errors.Errorf("the phone number %q contains the invalid character %q", number, c)
+		}
+	}
+
+	normalized := b.String()
+	if digits := len(normalized) - 1; digits < e164MinDigits || digits > e164MaxDigits {
+		return "", errors.Errorf("the phone number %q must have between %d and %d digits", number, e164MinDigits, e164MaxDigits)
+	} else if normalized[1] == '0' {
+		return "", errors.Errorf("the country code of the phone number %q must not start with 0", number)
+	}
+
+	return normalized, nil
+}
@@ -0,0 +1,43 @@
+package identity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizePhoneNumber(t *testing.T) {
+	for _, tc := range []struct{ in, expected string }{
+		{in: "+15551234567", expected: "+15551234567"},
+		{in: "+1 (555) 123-4567", expected: "+15551234567"},
+		{in: "  +1 555.123.4567 ", expected: "+15551234567"},
+		{in: "001 555 123 4567", expected: "+15551234567"},
+		{in: "+49 (0)30 / 1234567", expected: "+49301234567"},
+		{in: "+683 4002", expected: "+6834002"},
+		{in: "+123456789012345", expected: "+123456789012345"},
+	} {
+		t.Run("case="+tc.in, func(t *testing.T) {
+			actual, err := NormalizePhoneNumber(tc.in)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+
+	for _, in := range []string{
+		"",
+		"5551234567",
+		"(555) 123-4567",
+		"+1 555 CALL NOW",
+		"+1 555 123 4567 ext. 12",
+		"+1 555 123+4567",
+		"+012345678",
+		"+123456",
+		"+1234567890123456",
+	} {
+		t.Run("case=rejects "+in, func(t *testing.T) {
+			_, err := NormalizePhoneNumber(in)
+			require.Error(t, err)
+		})
+	}
+}
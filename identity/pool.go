@@ -2,29 +2,58 @@ package identity
 
 import (
 	"context"
+	"time"
 
 	"github.com/gofrs/uuid"
+
+	"github.com/ory/x/sqlxx"
 )
 
 type (
 	Pool interface {
-		// ListIdentities lists all identities in the store given the page and itemsPerPage.
+		// ListIdentities lists all identities in the store given the page and itemsPerPage. Identities which
+		// deleted their account are not listed.
 		ListIdentities(ctx context.Context, page, itemsPerPage int) ([]Identity, error)
 
-		// CountIdentities counts the number of identities in the store.
+		// ListIdentitiesPage lists a page of identities ordered by their creation date and ID. It returns the
+		// tokens of the next and previous page, which are empty if there is no such page. Identities which
+		// deleted their account are not listed.
+		ListIdentitiesPage(ctx context.Context, opts ListIdentitiesOptions) (is []Identity, next, prev string, err error)
+
+		// CountIdentities counts the number of identities in the store which did not delete their account.
 		CountIdentities(ctx context.Context) (int64, error)
 
 		// GetIdentity returns an identity by its id. Will return an error if the identity does not exist or backend
 		// connectivity is broken.
 		GetIdentity(context.Context, uuid.UUID) (*Identity, error)
 
-		// FindVerifiableAddressByValue returns a matching address or sql.ErrNoRows if no address could be found.
+		// FindVerifiableAddressByValue returns a matching address or sql.ErrNoRows if no address could be found
+		// or the identity deleted its account.
 		FindVerifiableAddressByValue(ctx context.Context, via VerifiableAddressType, address string) (*VerifiableAddress, error)
 
-		// FindRecoveryAddressByValue returns a matching address or sql.ErrNoRows if no address could be found.
+		// FindRecoveryAddressByValue returns a matching address or sql.ErrNoRows if no address could be found
+		// or the identity deleted its account.
 		FindRecoveryAddressByValue(ctx context.Context, via RecoveryAddressType, address string) (*RecoveryAddress, error)
 	}
 
+	// ListIdentitiesOptions selects a page of identities for ListIdentitiesPage.
+	ListIdentitiesOptions struct {
+		// PageToken is the token of the page as returned by ListIdentitiesPage. The first page is
+		// returned if it is empty.
+		PageToken string
+
+		// PageSize is the maximum number of identities in the page.
+		PageSize int
+
+		// CredentialsIdentifier only lists identities with a credentials identifier equal to it. Identifiers
+		// which are normalized to lower case on write are matched case-insensitively.
+		CredentialsIdentifier string
+
+		// CredentialsIdentifierSimilar only lists identities with a credentials identifier containing it,
+		// ignoring the case.
+		CredentialsIdentifierSimilar string
+	}
+
 	PoolProvider interface {
 		IdentityPool() Pool
 	}
@@ -43,6 +72,14 @@ type (
 		// if identity exists, backend connectivity is broken, or trait validation fails.
 		DeleteIdentity(context.Context, uuid.UUID) error
 
+		// DeleteIdentitiesDeletedBefore removes all identities which deleted their account before the given time
+		// and returns how many identities were removed.
+		DeleteIdentitiesDeletedBefore(ctx context.Context, before time.Time) (int, error)
+
+		// UpdateCredentialsConfig replaces the config of the identity's credentials with the given ID if it is still
+		// equal to from. Will return sqlcon.ErrNoRows if the credentials no longer exist or were changed concurrently.
+		UpdateCredentialsConfig(ctx context.Context, identityID, credentialsID uuid.UUID, from, to sqlxx.JSONRawMessage) error
+
 		// UpdateVerifiableAddress updates an identity's verifiable address.
 		UpdateVerifiableAddress(ctx context.Context, address *VerifiableAddress) error
 
@@ -50,6 +87,11 @@ type (
 		// if identity exists, backend connectivity is broken, or trait validation fails.
 		CreateIdentity(context.Context, *Identity) error
 
+		// CreateIdentities creates the identities within a single transaction and returns an error for every
+		// identity which could not be created. If allOrNothing is set and an identity could not be created, the
+		// transaction is rolled back and ErrBatchRolledBack is returned for the identities which were valid.
+		CreateIdentities(ctx context.Context, is []*Identity, allOrNothing bool) ([]error, error)
+
 		// UpdateIdentity updates an identity including its confidential / privileged / protected data.
 		UpdateIdentity(context.Context, *Identity) error
 
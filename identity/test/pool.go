@@ -3,6 +3,7 @@ package test
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"strconv"
 	"strings"
 	"testing"
@@ -17,6 +18,7 @@ import (
 
 	"github.com/ory/x/sqlxx"
 
+	"github.com/ory/herodot"
 	"github.com/ory/x/errorsx"
 	"github.com/ory/x/sqlcon"
 	"github.com/ory/x/urlx"
@@ -180,6 +182,19 @@ func TestPool(ctx context.Context, conf *config.Config, p interface {
 			})
 		})
 
+		t.Run("case=fail on duplicate credential identifiers across schemas", func(t *testing.T) {
+			initial := passwordIdentity(defaultSchema.ID, "cross-schema@bar.com")
+			require.NoError(t, p.CreateIdentity(ctx, initial))
+			createdIDs = append(createdIDs, initial.ID)
+
+			expected := passwordIdentity(altSchema.ID, "cross-schema@bar.com")
+			err := p.CreateIdentity(ctx, expected)
+			require.ErrorIs(t, err, sqlcon.ErrUniqueViolation, "%+v", err)
+
+			_, err = p.GetIdentity(ctx, expected.ID)
+			require.Error(t, err)
+		})
+
 		t.Run("case=fail on duplicate credential identifiers if type is password", func(t *testing.T) {
 			initial := passwordIdentity("", "foo@bar.com")
 			require.NoError(t, p.CreateIdentity(ctx, initial))
@@ -376,6 +391,77 @@ func TestPool(ctx context.Context, conf *config.Config, p interface {
 			require.Error(t, err)
 		})
 
+		t.Run("case=delete identities which deleted their account", func(t *testing.T) {
+			identifier := x.NewUUID().String()
+			expected := passwordIdentity("", identifier)
+			require.NoError(t, p.CreateIdentity(ctx, expected))
+
+			count, err := p.CountIdentities(ctx)
+			require.NoError(t, err)
+
+			deleted, err := p.GetIdentityConfidential(ctx, expected.ID)
+			require.NoError(t, err)
+			deletedAt := time.Now().UTC().Add(-2 * time.Hour)
+			deleted.DeletedAt = &deletedAt
+			require.NoError(t, p.UpdateIdentity(ctx, deleted))
+
+			_, _, err = p.FindByCredentialsIdentifier(ctx, identity.CredentialsTypePassword, identifier)
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+
+			t.Run("case=is not listed or counted", func(t *testing.T) {
+				actual, err := p.CountIdentities(ctx)
+				require.NoError(t, err)
+				assert.Equal(t, count-1, actual)
+
+				is, err := p.ListIdentities(ctx, 0, 1000)
+				require.NoError(t, err)
+				for _, i := range is {
+					assert.NotEqual(t, expected.ID, i.ID)
+				}
+
+				is, _, _, err = p.ListIdentitiesPage(ctx, identity.ListIdentitiesOptions{PageSize: 10, CredentialsIdentifier: identifier})
+				require.NoError(t, err)
+				assert.Empty(t, is)
+			})
+
+			removed, err := p.DeleteIdentitiesDeletedBefore(ctx, time.Now().UTC().Add(-3*time.Hour))
+			require.NoError(t, err)
+			assert.Equal(t, 0, removed)
+
+			removed, err = p.DeleteIdentitiesDeletedBefore(ctx, time.Now().UTC().Add(-time.Hour))
+			require.NoError(t, err)
+			assert.Equal(t, 1, removed)
+
+			_, err = p.GetIdentity(ctx, expected.ID)
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+		})
+
+		t.Run("case=update credentials config", func(t *testing.T) {
+			expected := passwordIdentity("", x.NewUUID().String())
+			require.NoError(t, p.CreateIdentity(ctx, expected))
+			createdIDs = append(createdIDs, expected.ID)
+
+			c, ok := expected.GetCredentials(identity.CredentialsTypePassword)
+			require.True(t, ok)
+			require.ErrorIs(t, p.UpdateCredentialsConfig(ctx, expected.ID, c.ID, sqlxx.JSONRawMessage(`{"hashed_password":"outdated"}`), sqlxx.JSONRawMessage(`{}`)), sqlcon.ErrNoRows)
+			require.NoError(t, p.UpdateCredentialsConfig(ctx, expected.ID, c.ID, c.Config, sqlxx.JSONRawMessage(`{"hashed_password":"rehashed"}`)))
+
+			actual, err := p.GetIdentityConfidential(ctx, expected.ID)
+			require.NoError(t, err)
+			ac, ok := actual.GetCredentials(identity.CredentialsTypePassword)
+			require.True(t, ok)
+			assert.JSONEq(t, `{"hashed_password":"rehashed"}`, string(ac.Config))
+
+			t.Run("case=fails if the credentials were changed", func(t *testing.T) {
+				actual.SetCredentials(identity.CredentialsTypePassword, identity.Credentials{
+					Type: identity.CredentialsTypePassword, Identifiers: ac.Identifiers,
+					Config: sqlxx.JSONRawMessage(`{"hashed_password":"changed"}`),
+				})
+				require.NoError(t, p.UpdateIdentity(ctx, actual))
+				require.ErrorIs(t, p.UpdateCredentialsConfig(ctx, expected.ID, c.ID, ac.Config, sqlxx.JSONRawMessage(`{}`)), sqlcon.ErrNoRows)
+			})
+		})
+
 		t.Run("case=create with empty credentials config", func(t *testing.T) {
 			// This test covers a case where the config value of a credentials setting is empty. This causes
 			// issues with postgres' json field.
@@ -411,6 +497,213 @@ func TestPool(ctx context.Context, conf *config.Config, p interface {
 			})
 		})
 
+		t.Run("case=list pages", func(t *testing.T) {
+			_, p := testhelpers.NewNetwork(t, ctx, p)
+
+			var create = func(t *testing.T) uuid.UUID {
+				i := identity.NewIdentity("")
+				require.NoError(t, p.CreateIdentity(ctx, i))
+				return i.ID
+			}
+
+			var initial []uuid.UUID
+			for k := 0; k < 5; k++ {
+				initial = append(initial, create(t))
+			}
+
+			var pages [][]uuid.UUID
+			var token string
+			for {
+				is, next, _, err := p.ListIdentitiesPage(ctx, identity.ListIdentitiesOptions{PageToken: token, PageSize: 2})
+				require.NoError(t, err)
+
+				var page []uuid.UUID
+				for _, i := range is {
+					page = append(page, i.ID)
+				}
+				pages = append(pages, page)
+
+				if len(pages) == 1 {
+					// Neither identities created nor identities deleted after the first page shift the results.
+					create(t)
+					require.NoError(t, p.DeleteIdentity(ctx, page[0]))
+				}
+
+				if next == "" {
+					break
+				}
+				token = next
+			}
+
+			seen := map[uuid.UUID]int{}
+			for _, page := range pages {
+				for _, id := range page {
+					seen[id]++
+				}
+			}
+			for _, id := range initial {
+				assert.Equal(t, 1, seen[id], "%s", id)
+			}
+			for id, count := range seen {
+				assert.Equal(t, 1, count, "%s", id)
+			}
+
+			t.Run("case=previous pages", func(t *testing.T) {
+				_, _, prev, err := p.ListIdentitiesPage(ctx, identity.ListIdentitiesOptions{PageSize: 2})
+				require.NoError(t, err)
+				assert.Empty(t, prev, "the first page has no previous page")
+
+				var all []uuid.UUID
+				token = ""
+				for {
+					is, next, _, err := p.ListIdentitiesPage(ctx, identity.ListIdentitiesOptions{PageToken: token, PageSize: 2})
+					require.NoError(t, err)
+					for _, i := range is {
+						all = append(all, i.ID)
+					}
+					if next == "" {
+						break
+					}
+					token = next
+				}
+
+				// Walk back from the last page, which starts at an offset of 2.
+				is, next, prev, err := p.ListIdentitiesPage(ctx, identity.ListIdentitiesOptions{PageToken: token, PageSize: 2})
+				require.NoError(t, err)
+				assert.Empty(t, next)
+
+				var back []uuid.UUID
+				for _, i := range is {
+					back = append(back, i.ID)
+				}
+				for prev != "" {
+					is, _, prev, err = p.ListIdentitiesPage(ctx, identity.ListIdentitiesOptions{PageToken: prev, PageSize: 2})
+					require.NoError(t, err)
+					var page []uuid.UUID
+					for _, i := range is {
+						page = append(page, i.ID)
+					}
+					back = append(page, back...)
+				}
+				assert.Equal(t, all, back)
+			})
+
+			t.Run("case=invalid page token", func(t *testing.T) {
+				_, _, _, err := p.ListIdentitiesPage(ctx, identity.ListIdentitiesOptions{PageToken: "not-a-token", PageSize: 2})
+				var he *herodot.DefaultError
+				require.ErrorAs(t, err, &he)
+				assert.Equal(t, http.StatusBadRequest, he.StatusCode())
+			})
+		})
+
+		t.Run("case=list pages filtered by credentials identifier", func(t *testing.T) {
+			_, p := testhelpers.NewNetwork(t, ctx, p)
+
+			foo := passwordIdentity("", "foo-list@ory.sh")
+			require.NoError(t, p.CreateIdentity(ctx, foo))
+			bar := passwordIdentity("", "bar-list@ory.sh")
+			require.NoError(t, p.CreateIdentity(ctx, bar))
+			baz := oidcIdentity("", "Baz-Subject_1")
+			require.NoError(t, p.CreateIdentity(ctx, baz))
+
+			var list = func(t *testing.T, opts identity.ListIdentitiesOptions) (ids []uuid.UUID) {
+				opts.PageSize = 10
+				is, _, _, err := p.ListIdentitiesPage(ctx, opts)
+				require.NoError(t, err)
+				for _, i := range is {
+					ids = append(ids, i.ID)
+				}
+				return ids
+			}
+
+			for _, tc := range []struct {
+				d        string
+				opts     identity.ListIdentitiesOptions
+				expected []uuid.UUID
+			}{
+				{d: "exact match", opts: identity.ListIdentitiesOptions{CredentialsIdentifier: "foo-list@ory.sh"}, expected: []uuid.UUID{foo.ID}},
+				{d: "case-insensitive", opts: identity.ListIdentitiesOptions{CredentialsIdentifier: "FOO-List@ory.sh"}, expected: []uuid.UUID{foo.ID}},
+				{d: "case-sensitive subject", opts: identity.ListIdentitiesOptions{CredentialsIdentifier: "Baz-Subject_1"}, expected: []uuid.UUID{baz.ID}},
+				{d: "no match", opts: identity.ListIdentitiesOptions{CredentialsIdentifier: "foo-list"}},
+				{d: "similar", opts: identity.ListIdentitiesOptions{CredentialsIdentifierSimilar: "LIST@"}, expected: []uuid.UUID{foo.ID, bar.ID}},
+				{d: "similar matches underscores", opts: identity.ListIdentitiesOptions{CredentialsIdentifierSimilar: "t_1"}, expected: []uuid.UUID{baz.ID}},
+				{d: "similar escapes underscores", opts: identity.ListIdentitiesOptions{CredentialsIdentifierSimilar: "z_subject"}},
+				{d: "similar escapes percent", opts: identity.ListIdentitiesOptions{CredentialsIdentifierSimilar: "%list"}},
+				{d: "no similar match", opts: identity.ListIdentitiesOptions{CredentialsIdentifierSimilar: "not-found"}},
+			} {
+				t.Run("case="+tc.d, func(t *testing.T) {
+					assert.ElementsMatch(t, tc.expected, list(t, tc.opts))
+				})
+			}
+		})
+
+		t.Run("case=create identities in a batch", func(t *testing.T) {
+			_, p := testhelpers.NewNetwork(t, ctx, p)
+
+			existing := passwordIdentity("", "batch-existing@ory.sh")
+			require.NoError(t, p.CreateIdentity(ctx, existing))
+
+			var batch = func(identifiers ...string) []*identity.Identity {
+				is := make([]*identity.Identity, len(identifiers))
+				for k, id := range identifiers {
+					is[k] = passwordIdentity("", id)
+				}
+				return is
+			}
+
+			var assertCreated = func(t *testing.T, i *identity.Identity, created bool) {
+				_, err := p.GetIdentity(ctx, i.ID)
+				if created {
+					require.NoError(t, err)
+				} else {
+					require.ErrorIs(t, err, sqlcon.ErrNoRows)
+				}
+			}
+
+			t.Run("case=commits the successes", func(t *testing.T) {
+				is := batch("batch-1@ory.sh", "batch-existing@ory.sh", "batch-2@ory.sh", "batch-2@ory.sh")
+				errs, err := p.CreateIdentities(ctx, is, false)
+				require.NoError(t, err)
+				require.Len(t, errs, 4)
+
+				assert.NoError(t, errs[0])
+				assert.ErrorIs(t, errs[1], sqlcon.ErrUniqueViolation, "conflicts with an existing identity")
+				assert.NoError(t, errs[2])
+				assert.ErrorIs(t, errs[3], sqlcon.ErrUniqueViolation, "conflicts with an identity of the batch")
+
+				assertCreated(t, is[0], true)
+				assertCreated(t, is[2], true)
+
+				actual, _, err := p.FindByCredentialsIdentifier(ctx, identity.CredentialsTypePassword, "batch-existing@ory.sh")
+				require.NoError(t, err)
+				assert.Equal(t, existing.ID, actual.ID)
+			})
+
+			t.Run("case=rolls back all or nothing", func(t *testing.T) {
+				is := batch("batch-3@ory.sh", "batch-4@ory.sh", "batch-3@ory.sh")
+				errs, err := p.CreateIdentities(ctx, is, true)
+				require.NoError(t, err)
+				require.Len(t, errs, 3)
+
+				assert.ErrorIs(t, errs[0], identity.ErrBatchRolledBack)
+				assert.ErrorIs(t, errs[1], identity.ErrBatchRolledBack)
+				assert.ErrorIs(t, errs[2], sqlcon.ErrUniqueViolation)
+				for _, i := range is {
+					assertCreated(t, i, false)
+				}
+			})
+
+			t.Run("case=creates all or nothing", func(t *testing.T) {
+				is := batch("batch-5@ory.sh", "batch-6@ory.sh")
+				errs, err := p.CreateIdentities(ctx, is, true)
+				require.NoError(t, err)
+				assert.Equal(t, []error{nil, nil}, errs)
+				for _, i := range is {
+					assertCreated(t, i, true)
+				}
+			})
+		})
+
 		t.Run("case=find identity by its credentials identifier", func(t *testing.T) {
 			expected := passwordIdentity("", "find-credentials-identifier@ory.sh")
 			expected.Traits = identity.Traits(`{}`)
@@ -0,0 +1,201 @@
+package identity
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/jsonschemax"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/schema"
+)
+
+// encryptedTraitPrefix marks encrypted trait values. They have the format `<prefix><key id>:<base64 nonce and ciphertext>`.
+const encryptedTraitPrefix = "kratos:aes-gcm:"
+
+type (
+	traitsCipherDependencies interface {
+		IdentityTraitsSchemas(ctx context.Context) schema.Schemas
+		config.Provider
+	}
+	TraitsCipherProvider interface {
+		IdentityTraitsCipher() *TraitsCipher
+	}
+	// TraitsCipher encrypts the traits marked with `ory.sh/kratos.encrypt` in the identity schema
+	// before they are persisted and decrypts them once they are loaded. Every encrypted value carries
+	// the ID of its key so that keys in `secrets.cipher` can be rotated.
+	TraitsCipher struct {
+		d traitsCipherDependencies
+
+		// paths caches the encrypted trait paths by schema URL.
+		paths sync.Map
+	}
+)
+
+func NewTraitsCipher(d traitsCipherDependencies) *TraitsCipher {
+	return &TraitsCipher{d: d}
+}
+
+// Encrypt returns the identity's traits with all values marked for encryption encrypted. The
+// identity itself is not modified.
+func (c *TraitsCipher) Encrypt(ctx context.Context, i *Identity) (Traits, error) {
+	paths, err := c.encryptedPaths(ctx, i.SchemaID)
+	if err != nil || len(paths) == 0 {
+		return i.Traits, err
+	}
+
+	secrets := c.d.Config(ctx).SecretsCipher()
+	kid, aead, err := newTraitsAEAD(secrets[0])
+	if err != nil {
+		return nil, err
+	}
+
+	traits := []byte(i.Traits)
+	for _, path := range paths {
+		value := gjson.GetBytes(traits, path)
+		if !value.Exists() {
+			continue
+		}
+
+		nonce := make([]byte, aead.NonceSize())
+		if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		sealed := aead.Seal(nonce, nonce, []byte(value.Raw), traitsAdditionalData(i, path))
+		traits, err = sjson.SetBytes(traits, path, encryptedTraitPrefix+kid+":"+base64.RawURLEncoding.EncodeToString(sealed))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	return Traits(traits), nil
+}
+
+// Decrypt decrypts the encrypted values in the identity's traits in place. Values which are not
+// encrypted, for example because they were stored before encryption was enabled, are left as is.
+func (c *TraitsCipher) Decrypt(ctx context.Context, i *Identity) error {
+	paths, err := c.encryptedPaths(ctx, i.SchemaID)
+	if err != nil || len(paths) == 0 {
+		return err
+	}
+
+	aeads := make(map[string]cipher.AEAD)
+	for _, secret := range c.d.Config(ctx).SecretsCipher() {
+		kid, aead, err := newTraitsAEAD(secret)
+		if err != nil {
+			return err
+		}
+		aeads[kid] = aead
+	}
+
+	traits := []byte(i.Traits)
+	for _, path := range paths {
+		value := gjson.GetBytes(traits, path)
+		if value.Type != gjson.String || !strings.HasPrefix(value.String(), encryptedTraitPrefix) {
+			continue
+		}
+
+		parts := strings.SplitN(strings.TrimPrefix(value.String(), encryptedTraitPrefix), ":", 2)
+		if len(parts) != 2 {
+			return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("The encrypted trait %s of identity %s is malformed.", path, i.ID))
+		}
+
+		aead, ok := aeads[parts[0]]
+		if !ok {
+			return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("The trait %s of identity %s was encrypted with key %s which is no longer configured in %s.", path, i.ID, parts[0], config.ViperKeySecretsCipher))
+		}
+
+		sealed, err := base64.RawURLEncoding.DecodeString(parts[1])
+		if err != nil || len(sealed) < aead.NonceSize() {
+			return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("The encrypted trait %s of identity %s is malformed.", path, i.ID))
+		}
+
+		plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], traitsAdditionalData(i, path))
+		if err != nil {
+			return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to decrypt the trait %s of identity %s.", path, i.ID).WithDebug(err.Error()))
+		}
+
+		traits, err = sjson.SetRawBytes(traits, path, plaintext)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	i.Traits = Traits(traits)
+	return nil
+}
+
+// encryptedPaths returns the paths, relative to the traits, of all values marked with `ory.sh/kratos.encrypt`.
+// Values within arrays can not be marked individually, mark the array instead.
+func (c *TraitsCipher) encryptedPaths(ctx context.Context, schemaID string) ([]string, error) {
+	s, err := c.d.IdentityTraitsSchemas(ctx).GetByID(schemaID)
+	if err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf(
+			`The JSON Schema "%s" for this identity's traits could not be found.`, schemaID))
+	}
+
+	if paths, ok := c.paths.Load(s.URL.String()); ok {
+		return paths.([]string), nil
+	}
+
+	runner, err := schema.NewExtensionRunner(schema.ExtensionRunnerIdentityMetaSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	compiler := schema.NewCompiler()
+	runner.Register(compiler)
+
+	schemaPaths, err := jsonschemax.ListPaths(s.URL.String(), compiler)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, p := range schemaPaths {
+		if encrypt, _ := p.CustomProperties["encrypt"].(bool); !encrypt {
+			continue
+		} else if !strings.HasPrefix(p.Name, "traits.") || strings.Contains(p.Name, "#") {
+			continue
+		}
+		paths = append(paths, strings.TrimPrefix(p.Name, "traits."))
+	}
+
+	c.paths.Store(s.URL.String(), paths)
+	return paths, nil
+}
+
+func newTraitsAEAD(secret []byte) (string, cipher.AEAD, error) {
+	key := sha256.Sum256(secret)
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+
+	kid := sha256.Sum256(key[:])
+	return hex.EncodeToString(kid[:4]), aead, nil
+}
+
+// traitsAdditionalData binds encrypted values to their identity and path so that they can not be swapped.
+func traitsAdditionalData(i *Identity, path string) []byte {
+	return []byte(i.ID.String() + ":" + path)
+}
@@ -0,0 +1,128 @@
+package identity_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/driver/config"
+	. "github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+)
+
+func TestTraitsCipher(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/encrypted.schema.json")
+	conf.MustSet(config.ViperKeySecretsCipher, []string{"cipher-secret-0000000"})
+	c := reg.IdentityTraitsCipher()
+
+	const traits = `{"email":"foo@ory.sh","national_id":"123-45-6789","phones":["+49 30 1234567"],"address":{"street":"Main Street 1","city":"Berlin"}}`
+	newIdentity := func() *Identity {
+		i := NewIdentity(config.DefaultIdentityTraitsSchemaID)
+		i.Traits = Traits(traits)
+		return i
+	}
+
+	assertEncrypted := func(t *testing.T, encrypted Traits) {
+		assert.Equal(t, "foo@ory.sh", gjson.GetBytes(encrypted, "email").String())
+		assert.Equal(t, "Berlin", gjson.GetBytes(encrypted, "address.city").String())
+		for _, path := range []string{"national_id", "phones", "address.street"} {
+			value := gjson.GetBytes(encrypted, path).String()
+			assert.True(t, strings.HasPrefix(value, "kratos:aes-gcm:"), "%s: %s", path, value)
+		}
+		assert.NotContains(t, string(encrypted), "123-45-6789")
+		assert.NotContains(t, string(encrypted), "Main Street 1")
+		assert.NotContains(t, string(encrypted), "+49 30 1234567")
+	}
+
+	t.Run("case=encrypts and decrypts marked traits", func(t *testing.T) {
+		i := newIdentity()
+		encrypted, err := c.Encrypt(ctx, i)
+		require.NoError(t, err)
+		assert.JSONEq(t, traits, string(i.Traits), "the identity must not be modified")
+		assertEncrypted(t, encrypted)
+
+		i.Traits = encrypted
+		require.NoError(t, c.Decrypt(ctx, i))
+		assert.JSONEq(t, traits, string(i.Traits))
+	})
+
+	t.Run("case=leaves unencrypted values as is", func(t *testing.T) {
+		i := newIdentity()
+		require.NoError(t, c.Decrypt(ctx, i))
+		assert.JSONEq(t, traits, string(i.Traits))
+	})
+
+	t.Run("case=decrypts with rotated keys", func(t *testing.T) {
+		i := newIdentity()
+		encrypted, err := c.Encrypt(ctx, i)
+		require.NoError(t, err)
+		i.Traits = encrypted
+
+		conf.MustSet(config.ViperKeySecretsCipher, []string{"cipher-secret-1111111", "cipher-secret-0000000"})
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySecretsCipher, []string{"cipher-secret-0000000"})
+		})
+
+		rotated := *i
+		require.NoError(t, c.Decrypt(ctx, &rotated))
+		assert.JSONEq(t, traits, string(rotated.Traits))
+
+		conf.MustSet(config.ViperKeySecretsCipher, []string{"cipher-secret-1111111"})
+		err = c.Decrypt(ctx, i)
+		var he *herodot.DefaultError
+		require.True(t, errors.As(err, &he), "%+v", err)
+		assert.Contains(t, he.Reason(), "no longer configured")
+	})
+
+	t.Run("case=encrypted values are bound to the identity", func(t *testing.T) {
+		i := newIdentity()
+		encrypted, err := c.Encrypt(ctx, i)
+		require.NoError(t, err)
+
+		other := newIdentity()
+		other.Traits = encrypted
+		require.Error(t, c.Decrypt(ctx, other))
+	})
+
+	t.Run("case=persists encrypted traits", func(t *testing.T) {
+		i := newIdentity()
+		require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(ctx, i))
+		assert.JSONEq(t, traits, string(i.Traits))
+
+		var stored struct {
+			Traits Traits `db:"traits"`
+		}
+		require.NoError(t, reg.Persister().GetConnection(ctx).RawQuery("SELECT traits FROM identities WHERE id = ?", i.ID).First(&stored))
+		assertEncrypted(t, stored.Traits)
+
+		actual, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(ctx, i.ID)
+		require.NoError(t, err)
+		assert.JSONEq(t, traits, string(actual.Traits))
+
+		actual.Traits = Traits(`{"email":"bar@ory.sh","national_id":"987-65-4321"}`)
+		require.NoError(t, reg.PrivilegedIdentityPool().UpdateIdentity(ctx, actual))
+		require.NoError(t, reg.Persister().GetConnection(ctx).RawQuery("SELECT traits FROM identities WHERE id = ?", i.ID).First(&stored))
+		assert.NotContains(t, string(stored.Traits), "987-65-4321")
+
+		actual, err = reg.IdentityPool().GetIdentity(ctx, i.ID)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"email":"bar@ory.sh","national_id":"987-65-4321"}`, string(actual.Traits))
+
+		is, err := reg.IdentityPool().ListIdentities(ctx, 0, 100)
+		require.NoError(t, err)
+		for _, listed := range is {
+			if listed.ID == i.ID {
+				assert.JSONEq(t, `{"email":"bar@ory.sh","national_id":"987-65-4321"}`, string(listed.Traits))
+			}
+		}
+	})
+}
@@ -1,7 +1,9 @@
 package identity
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 
 	"github.com/tidwall/sjson"
 
@@ -38,6 +40,11 @@ func (v *Validator) ValidateWithRunner(ctx context.Context, i *Identity, runners
 		return err
 	}
 
+	c := v.d.Config(ctx)
+	if err := checkTraitsComplexity(i.Traits, c.IdentityTraitsMaxDepth(), c.IdentityTraitsMaxProperties()); err != nil {
+		return err
+	}
+
 	traits, err := sjson.SetRawBytes([]byte(`{}`), "traits", i.Traits)
 	if err != nil {
 		return err
@@ -53,3 +60,52 @@ func (v *Validator) Validate(ctx context.Context, i *Identity) error {
 		NewSchemaExtensionRecovery(i),
 	)
 }
+
+// checkTraitsComplexity rejects traits which are nested deeper than maxDepth or have more than maxProperties
+// properties in total. The traits are scanned without decoding them, and the scan stops as soon as a limit
+// is exceeded. Malformed JSON is left for the schema validator to report.
+func checkTraitsComplexity(traits []byte, maxDepth, maxProperties int) error {
+	if maxDepth == 0 && maxProperties == 0 {
+		return nil
+	}
+
+	// Within objects, tokens alternate between keys and values.
+	type level struct {
+		object  bool
+		keyNext bool
+	}
+
+	var stack []level
+	var properties int
+	dec := json.NewDecoder(bytes.NewReader(traits))
+	for {
+		token, err := dec.Token()
+		if err != nil {
+			// Either io.EOF or malformed JSON.
+			return nil
+		}
+
+		if len(stack) > 0 && stack[len(stack)-1].object {
+			top := &stack[len(stack)-1]
+			if token != json.Delim('}') {
+				if top.keyNext {
+					properties++
+					if maxProperties > 0 && properties > maxProperties {
+						return schema.NewTraitsTooManyPropertiesError(maxProperties)
+					}
+				}
+				top.keyNext = !top.keyNext
+			}
+		}
+
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			stack = append(stack, level{object: token == json.Delim('{'), keyNext: true})
+			if maxDepth > 0 && len(stack) > maxDepth {
+				return schema.NewTraitsTooDeepError(maxDepth)
+			}
+		case json.Delim('}'), json.Delim(']'):
+			stack = stack[:len(stack)-1]
+		}
+	}
+}
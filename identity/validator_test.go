@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -115,3 +116,59 @@ func TestSchemaValidator(t *testing.T) {
 		})
 	}
 }
+
+func TestTraitsComplexity(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"type": "object", "properties": {"traits": {"type": "object"}}}`))
+	}))
+	defer ts.Close()
+
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, ts.URL)
+	v := NewValidator(reg)
+
+	nested := func(depth int) Traits {
+		return Traits(`{"a":` + strings.Repeat(`[`, depth-2) + `{}` + strings.Repeat(`]`, depth-2) + `}`)
+	}
+
+	wide := func(properties int) Traits {
+		var b strings.Builder
+		b.WriteString(`{"a":{`)
+		for i := 1; i < properties; i++ {
+			if i > 1 {
+				b.WriteString(",")
+			}
+			fmt.Fprintf(&b, `"p%d":{"k":"v"}`, i)
+		}
+		b.WriteString(`}}`)
+		return Traits(b.String())
+	}
+
+	for k, tc := range []struct {
+		traits        Traits
+		maxDepth      int
+		maxProperties int
+		err           string
+	}{
+		{traits: nested(16), maxDepth: 16},
+		{traits: nested(17), maxDepth: 16, err: "I[#/traits] S[] traits must not be nested more than 16 levels deep"},
+		{traits: nested(100000), maxDepth: 16, err: "I[#/traits] S[] traits must not be nested more than 16 levels deep"},
+		{traits: nested(17)},
+		{traits: wide(5), maxProperties: 9},
+		{traits: wide(6), maxProperties: 10, err: "I[#/traits] S[] traits must not have more than 10 properties"},
+		{traits: wide(6)},
+		{traits: Traits(`{"a":"{{{","b":["}"],"c":{}}`), maxDepth: 2, maxProperties: 3},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			conf.MustSet(config.ViperKeyIdentityTraitsMaxDepth, tc.maxDepth)
+			conf.MustSet(config.ViperKeyIdentityTraitsMaxProperties, tc.maxProperties)
+
+			err := v.Validate(context.Background(), &Identity{Traits: tc.traits})
+			if tc.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.err)
+			}
+		})
+	}
+}
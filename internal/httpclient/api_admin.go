@@ -28,6 +28,259 @@ var (
 // AdminApiService AdminApi service
 type AdminApiService service
 
+type AdminApiApiCountIdentitiesRequest struct {
+	ctx        context.Context
+	ApiService *AdminApiService
+}
+
+func (r AdminApiApiCountIdentitiesRequest) Execute() (IdentityCount, *http.Response, error) {
+	return r.ApiService.CountIdentitiesExecute(r)
+}
+
+/*
+ * CountIdentities Count Identities
+ * Returns the number of identities without listing them.
+ * @param ctx context.Context - for authentication, logging, cancellation, deadlines, tracing, etc. Passed from http.Request or context.Background().
+ * @return AdminApiApiCountIdentitiesRequest
+*/
+func (a *AdminApiService) CountIdentities(ctx context.Context) AdminApiApiCountIdentitiesRequest {
+	return AdminApiApiCountIdentitiesRequest{
+		ApiService: a,
+		ctx:        ctx,
+	}
+}
+
+/*
+ * Execute executes the request
+ * @return IdentityCount
+ */
+func (a *AdminApiService) CountIdentitiesExecute(r AdminApiApiCountIdentitiesRequest) (IdentityCount, *http.Response, error) {
+	var (
+		localVarHTTPMethod   = http.MethodGet
+		localVarPostBody     interface{}
+		localVarFormFileName string
+		localVarFileName     string
+		localVarFileBytes    []byte
+		localVarReturnValue  IdentityCount
+	)
+
+	localBasePath, err := a.client.cfg.ServerURLWithContext(r.ctx, "AdminApiService.CountIdentities")
+	if err != nil {
+		return localVarReturnValue, nil, &GenericOpenAPIError{error: err.Error()}
+	}
+
+	localVarPath := localBasePath + "/identities/count"
+
+	localVarHeaderParams := make(map[string]string)
+	localVarQueryParams := url.Values{}
+	localVarFormParams := url.Values{}
+
+	// to determine the Content-Type header
+	localVarHTTPContentTypes := []string{}
+
+	// set Content-Type header
+	localVarHTTPContentType := selectHeaderContentType(localVarHTTPContentTypes)
+	if localVarHTTPContentType != "" {
+		localVarHeaderParams["Content-Type"] = localVarHTTPContentType
+	}
+
+	// to determine the Accept header
+	localVarHTTPHeaderAccepts := []string{"application/json"}
+
+	// set Accept header
+	localVarHTTPHeaderAccept := selectHeaderAccept(localVarHTTPHeaderAccepts)
+	if localVarHTTPHeaderAccept != "" {
+		localVarHeaderParams["Accept"] = localVarHTTPHeaderAccept
+	}
+	req, err := a.client.prepareRequest(r.ctx, localVarPath, localVarHTTPMethod, localVarPostBody, localVarHeaderParams, localVarQueryParams, localVarFormParams, localVarFormFileName, localVarFileName, localVarFileBytes)
+	if err != nil {
+		return localVarReturnValue, nil, err
+	}
+
+	localVarHTTPResponse, err := a.client.callAPI(req)
+	if err != nil || localVarHTTPResponse == nil {
+		return localVarReturnValue, localVarHTTPResponse, err
+	}
+
+	localVarBody, err := ioutil.ReadAll(localVarHTTPResponse.Body)
+	localVarHTTPResponse.Body.Close()
+	localVarHTTPResponse.Body = ioutil.NopCloser(bytes.NewBuffer(localVarBody))
+	if err != nil {
+		return localVarReturnValue, localVarHTTPResponse, err
+	}
+
+	if localVarHTTPResponse.StatusCode >= 300 {
+		newErr := &GenericOpenAPIError{
+			body:  localVarBody,
+			error: localVarHTTPResponse.Status,
+		}
+		if localVarHTTPResponse.StatusCode == 500 {
+			var v GenericError
+			err = a.client.decode(&v, localVarBody, localVarHTTPResponse.Header.Get("Content-Type"))
+			if err != nil {
+				newErr.error = err.Error()
+				return localVarReturnValue, localVarHTTPResponse, newErr
+			}
+			newErr.model = v
+		}
+		return localVarReturnValue, localVarHTTPResponse, newErr
+	}
+
+	err = a.client.decode(&localVarReturnValue, localVarBody, localVarHTTPResponse.Header.Get("Content-Type"))
+	if err != nil {
+		newErr := &GenericOpenAPIError{
+			body:  localVarBody,
+			error: err.Error(),
+		}
+		return localVarReturnValue, localVarHTTPResponse, newErr
+	}
+
+	return localVarReturnValue, localVarHTTPResponse, nil
+}
+
+type AdminApiApiCreateIdentitiesRequest struct {
+	ctx            context.Context
+	ApiService     *AdminApiService
+	allOrNothing   *bool
+	createIdentity *[]CreateIdentity
+}
+
+func (r AdminApiApiCreateIdentitiesRequest) AllOrNothing(allOrNothing bool) AdminApiApiCreateIdentitiesRequest {
+	r.allOrNothing = &allOrNothing
+	return r
+}
+func (r AdminApiApiCreateIdentitiesRequest) CreateIdentity(createIdentity []CreateIdentity) AdminApiApiCreateIdentitiesRequest {
+	r.createIdentity = &createIdentity
+	return r
+}
+
+func (r AdminApiApiCreateIdentitiesRequest) Execute() (*CreateIdentitiesResponse, *http.Response, error) {
+	return r.ApiService.CreateIdentitiesExecute(r)
+}
+
+/*
+ * CreateIdentities Create Identities in a Batch
+ * This endpoint creates up to `identity.batch.max_size` identities within a single transaction, for example
+to import identities from another system. Each identity is created like with `POST /identities`, including
+its already hashed password. The response holds the result of every identity of the batch.
+
+Unless `all_or_nothing` is set, the identities which could be created are committed even if other
+identities of the batch failed.
+
+Learn how identities work in [ORY Kratos' User And Identity Model Documentation](https://www.ory.sh/docs/next/kratos/concepts/identity-user-model).
+ * @param ctx context.Context - for authentication, logging, cancellation, deadlines, tracing, etc. Passed from http.Request or context.Background().
+ * @return AdminApiApiCreateIdentitiesRequest
+*/
+func (a *AdminApiService) CreateIdentities(ctx context.Context) AdminApiApiCreateIdentitiesRequest {
+	return AdminApiApiCreateIdentitiesRequest{
+		ApiService: a,
+		ctx:        ctx,
+	}
+}
+
+/*
+ * Execute executes the request
+ * @return CreateIdentitiesResponse
+ */
+func (a *AdminApiService) CreateIdentitiesExecute(r AdminApiApiCreateIdentitiesRequest) (*CreateIdentitiesResponse, *http.Response, error) {
+	var (
+		localVarHTTPMethod   = http.MethodPost
+		localVarPostBody     interface{}
+		localVarFormFileName string
+		localVarFileName     string
+		localVarFileBytes    []byte
+		localVarReturnValue  *CreateIdentitiesResponse
+	)
+
+	localBasePath, err := a.client.cfg.ServerURLWithContext(r.ctx, "AdminApiService.CreateIdentities")
+	if err != nil {
+		return localVarReturnValue, nil, &GenericOpenAPIError{error: err.Error()}
+	}
+
+	localVarPath := localBasePath + "/identities/batch"
+
+	localVarHeaderParams := make(map[string]string)
+	localVarQueryParams := url.Values{}
+	localVarFormParams := url.Values{}
+
+	if r.allOrNothing != nil {
+		localVarQueryParams.Add("all_or_nothing", parameterToString(*r.allOrNothing, ""))
+	}
+	// to determine the Content-Type header
+	localVarHTTPContentTypes := []string{"application/json"}
+
+	// set Content-Type header
+	localVarHTTPContentType := selectHeaderContentType(localVarHTTPContentTypes)
+	if localVarHTTPContentType != "" {
+		localVarHeaderParams["Content-Type"] = localVarHTTPContentType
+	}
+
+	// to determine the Accept header
+	localVarHTTPHeaderAccepts := []string{"application/json"}
+
+	// set Accept header
+	localVarHTTPHeaderAccept := selectHeaderAccept(localVarHTTPHeaderAccepts)
+	if localVarHTTPHeaderAccept != "" {
+		localVarHeaderParams["Accept"] = localVarHTTPHeaderAccept
+	}
+	// body params
+	localVarPostBody = r.createIdentity
+	req, err := a.client.prepareRequest(r.ctx, localVarPath, localVarHTTPMethod, localVarPostBody, localVarHeaderParams, localVarQueryParams, localVarFormParams, localVarFormFileName, localVarFileName, localVarFileBytes)
+	if err != nil {
+		return localVarReturnValue, nil, err
+	}
+
+	localVarHTTPResponse, err := a.client.callAPI(req)
+	if err != nil || localVarHTTPResponse == nil {
+		return localVarReturnValue, localVarHTTPResponse, err
+	}
+
+	localVarBody, err := ioutil.ReadAll(localVarHTTPResponse.Body)
+	localVarHTTPResponse.Body.Close()
+	localVarHTTPResponse.Body = ioutil.NopCloser(bytes.NewBuffer(localVarBody))
+	if err != nil {
+		return localVarReturnValue, localVarHTTPResponse, err
+	}
+
+	if localVarHTTPResponse.StatusCode >= 300 {
+		newErr := &GenericOpenAPIError{
+			body:  localVarBody,
+			error: localVarHTTPResponse.Status,
+		}
+		if localVarHTTPResponse.StatusCode == 400 {
+			var v GenericError
+			err = a.client.decode(&v, localVarBody, localVarHTTPResponse.Header.Get("Content-Type"))
+			if err != nil {
+				newErr.error = err.Error()
+				return localVarReturnValue, localVarHTTPResponse, newErr
+			}
+			newErr.model = v
+			return localVarReturnValue, localVarHTTPResponse, newErr
+		}
+		if localVarHTTPResponse.StatusCode == 500 {
+			var v GenericError
+			err = a.client.decode(&v, localVarBody, localVarHTTPResponse.Header.Get("Content-Type"))
+			if err != nil {
+				newErr.error = err.Error()
+				return localVarReturnValue, localVarHTTPResponse, newErr
+			}
+			newErr.model = v
+		}
+		return localVarReturnValue, localVarHTTPResponse, newErr
+	}
+
+	err = a.client.decode(&localVarReturnValue, localVarBody, localVarHTTPResponse.Header.Get("Content-Type"))
+	if err != nil {
+		newErr := &GenericOpenAPIError{
+			body:  localVarBody,
+			error: err.Error(),
+		}
+		return localVarReturnValue, localVarHTTPResponse, newErr
+	}
+
+	return localVarReturnValue, localVarHTTPResponse, nil
+}
+
 type AdminApiApiCreateIdentityRequest struct {
 	ctx            context.Context
 	ApiService     *AdminApiService
@@ -45,8 +298,8 @@ func (r AdminApiApiCreateIdentityRequest) Execute() (*Identity, *http.Response,
 
 /*
  * CreateIdentity Create an Identity
- * This endpoint creates an identity. It is NOT possible to set an identity's credentials (password, ...)
-using this method! A way to achieve that will be introduced in the future.
+ * This endpoint creates an identity. An identity's password can only be set by importing a password which
+was already hashed with bcrypt or Argon2id. Other credentials can not be set using this method.
 
 Learn how identities work in [ORY Kratos' User And Identity Model Documentation](https://www.ory.sh/docs/next/kratos/concepts/identity-user-model).
  * @param ctx context.Context - for authentication, logging, cancellation, deadlines, tracing, etc. Passed from http.Request or context.Background().
@@ -559,25 +812,38 @@ func (a *AdminApiService) GetIdentityExecute(r AdminApiApiGetIdentityRequest) (*
 	return localVarReturnValue, localVarHTTPResponse, nil
 }
 
-type AdminApiApiGetSchemaRequest struct {
+type AdminApiApiGetIdentityCredentialHistoryRequest struct {
 	ctx        context.Context
 	ApiService *AdminApiService
 	id         string
+	perPage    *int64
+	page       *int64
 }
 
-func (r AdminApiApiGetSchemaRequest) Execute() (map[string]interface{}, *http.Response, error) {
-	return r.ApiService.GetSchemaExecute(r)
+func (r AdminApiApiGetIdentityCredentialHistoryRequest) PerPage(perPage int64) AdminApiApiGetIdentityCredentialHistoryRequest {
+	r.perPage = &perPage
+	return r
+}
+func (r AdminApiApiGetIdentityCredentialHistoryRequest) Page(page int64) AdminApiApiGetIdentityCredentialHistoryRequest {
+	r.page = &page
+	return r
+}
+
+func (r AdminApiApiGetIdentityCredentialHistoryRequest) Execute() ([]CredentialEvent, *http.Response, error) {
+	return r.ApiService.GetIdentityCredentialHistoryExecute(r)
 }
 
 /*
- * GetSchema Method for GetSchema
- * Get a Traits Schema Definition
+ * GetIdentityCredentialHistory Get the Credential History of an Identity
+ * Lists when the identity's credentials were added, changed, or removed and when the account was recovered,
+most recent first. Events are only recorded if `identity.credential_history.enabled` is set and never
+contain secret material.
  * @param ctx context.Context - for authentication, logging, cancellation, deadlines, tracing, etc. Passed from http.Request or context.Background().
- * @param id ID must be set to the ID of schema you want to get
- * @return AdminApiApiGetSchemaRequest
- */
-func (a *AdminApiService) GetSchema(ctx context.Context, id string) AdminApiApiGetSchemaRequest {
-	return AdminApiApiGetSchemaRequest{
+ * @param id ID is the identity's ID.
+ * @return AdminApiApiGetIdentityCredentialHistoryRequest
+*/
+func (a *AdminApiService) GetIdentityCredentialHistory(ctx context.Context, id string) AdminApiApiGetIdentityCredentialHistoryRequest {
+	return AdminApiApiGetIdentityCredentialHistoryRequest{
 		ApiService: a,
 		ctx:        ctx,
 		id:         id,
@@ -586,30 +852,36 @@ func (a *AdminApiService) GetSchema(ctx context.Context, id string) AdminApiApiG
 
 /*
  * Execute executes the request
- * @return map[string]interface{}
+ * @return []CredentialEvent
  */
-func (a *AdminApiService) GetSchemaExecute(r AdminApiApiGetSchemaRequest) (map[string]interface{}, *http.Response, error) {
+func (a *AdminApiService) GetIdentityCredentialHistoryExecute(r AdminApiApiGetIdentityCredentialHistoryRequest) ([]CredentialEvent, *http.Response, error) {
 	var (
 		localVarHTTPMethod   = http.MethodGet
 		localVarPostBody     interface{}
 		localVarFormFileName string
 		localVarFileName     string
 		localVarFileBytes    []byte
-		localVarReturnValue  map[string]interface{}
+		localVarReturnValue  []CredentialEvent
 	)
 
-	localBasePath, err := a.client.cfg.ServerURLWithContext(r.ctx, "AdminApiService.GetSchema")
+	localBasePath, err := a.client.cfg.ServerURLWithContext(r.ctx, "AdminApiService.GetIdentityCredentialHistory")
 	if err != nil {
 		return localVarReturnValue, nil, &GenericOpenAPIError{error: err.Error()}
 	}
 
-	localVarPath := localBasePath + "/schemas/{id}"
+	localVarPath := localBasePath + "/identities/{id}/credential-history"
 	localVarPath = strings.Replace(localVarPath, "{"+"id"+"}", url.PathEscape(parameterToString(r.id, "")), -1)
 
 	localVarHeaderParams := make(map[string]string)
 	localVarQueryParams := url.Values{}
 	localVarFormParams := url.Values{}
 
+	if r.perPage != nil {
+		localVarQueryParams.Add("per_page", parameterToString(*r.perPage, ""))
+	}
+	if r.page != nil {
+		localVarQueryParams.Add("page", parameterToString(*r.page, ""))
+	}
 	// to determine the Content-Type header
 	localVarHTTPContentTypes := []string{}
 
@@ -683,15 +955,139 @@ func (a *AdminApiService) GetSchemaExecute(r AdminApiApiGetSchemaRequest) (map[s
 	return localVarReturnValue, localVarHTTPResponse, nil
 }
 
-type AdminApiApiGetSelfServiceErrorRequest struct {
+type AdminApiApiGetSchemaRequest struct {
 	ctx        context.Context
 	ApiService *AdminApiService
-	error_     *string
+	id         string
 }
 
-func (r AdminApiApiGetSelfServiceErrorRequest) Error_(error_ string) AdminApiApiGetSelfServiceErrorRequest {
-	r.error_ = &error_
-	return r
+func (r AdminApiApiGetSchemaRequest) Execute() (map[string]interface{}, *http.Response, error) {
+	return r.ApiService.GetSchemaExecute(r)
+}
+
+/*
+ * GetSchema Method for GetSchema
+ * Get a Traits Schema Definition
+ * @param ctx context.Context - for authentication, logging, cancellation, deadlines, tracing, etc. Passed from http.Request or context.Background().
+ * @param id ID must be set to the ID of schema you want to get
+ * @return AdminApiApiGetSchemaRequest
+ */
+func (a *AdminApiService) GetSchema(ctx context.Context, id string) AdminApiApiGetSchemaRequest {
+	return AdminApiApiGetSchemaRequest{
+		ApiService: a,
+		ctx:        ctx,
+		id:         id,
+	}
+}
+
+/*
+ * Execute executes the request
+ * @return map[string]interface{}
+ */
+func (a *AdminApiService) GetSchemaExecute(r AdminApiApiGetSchemaRequest) (map[string]interface{}, *http.Response, error) {
+	var (
+		localVarHTTPMethod   = http.MethodGet
+		localVarPostBody     interface{}
+		localVarFormFileName string
+		localVarFileName     string
+		localVarFileBytes    []byte
+		localVarReturnValue  map[string]interface{}
+	)
+
+	localBasePath, err := a.client.cfg.ServerURLWithContext(r.ctx, "AdminApiService.GetSchema")
+	if err != nil {
+		return localVarReturnValue, nil, &GenericOpenAPIError{error: err.Error()}
+	}
+
+	localVarPath := localBasePath + "/schemas/{id}"
+	localVarPath = strings.Replace(localVarPath, "{"+"id"+"}", url.PathEscape(parameterToString(r.id, "")), -1)
+
+	localVarHeaderParams := make(map[string]string)
+	localVarQueryParams := url.Values{}
+	localVarFormParams := url.Values{}
+
+	// to determine the Content-Type header
+	localVarHTTPContentTypes := []string{}
+
+	// set Content-Type header
+	localVarHTTPContentType := selectHeaderContentType(localVarHTTPContentTypes)
+	if localVarHTTPContentType != "" {
+		localVarHeaderParams["Content-Type"] = localVarHTTPContentType
+	}
+
+	// to determine the Accept header
+	localVarHTTPHeaderAccepts := []string{"application/json"}
+
+	// set Accept header
+	localVarHTTPHeaderAccept := selectHeaderAccept(localVarHTTPHeaderAccepts)
+	if localVarHTTPHeaderAccept != "" {
+		localVarHeaderParams["Accept"] = localVarHTTPHeaderAccept
+	}
+	req, err := a.client.prepareRequest(r.ctx, localVarPath, localVarHTTPMethod, localVarPostBody, localVarHeaderParams, localVarQueryParams, localVarFormParams, localVarFormFileName, localVarFileName, localVarFileBytes)
+	if err != nil {
+		return localVarReturnValue, nil, err
+	}
+
+	localVarHTTPResponse, err := a.client.callAPI(req)
+	if err != nil || localVarHTTPResponse == nil {
+		return localVarReturnValue, localVarHTTPResponse, err
+	}
+
+	localVarBody, err := ioutil.ReadAll(localVarHTTPResponse.Body)
+	localVarHTTPResponse.Body.Close()
+	localVarHTTPResponse.Body = ioutil.NopCloser(bytes.NewBuffer(localVarBody))
+	if err != nil {
+		return localVarReturnValue, localVarHTTPResponse, err
+	}
+
+	if localVarHTTPResponse.StatusCode >= 300 {
+		newErr := &GenericOpenAPIError{
+			body:  localVarBody,
+			error: localVarHTTPResponse.Status,
+		}
+		if localVarHTTPResponse.StatusCode == 404 {
+			var v GenericError
+			err = a.client.decode(&v, localVarBody, localVarHTTPResponse.Header.Get("Content-Type"))
+			if err != nil {
+				newErr.error = err.Error()
+				return localVarReturnValue, localVarHTTPResponse, newErr
+			}
+			newErr.model = v
+			return localVarReturnValue, localVarHTTPResponse, newErr
+		}
+		if localVarHTTPResponse.StatusCode == 500 {
+			var v GenericError
+			err = a.client.decode(&v, localVarBody, localVarHTTPResponse.Header.Get("Content-Type"))
+			if err != nil {
+				newErr.error = err.Error()
+				return localVarReturnValue, localVarHTTPResponse, newErr
+			}
+			newErr.model = v
+		}
+		return localVarReturnValue, localVarHTTPResponse, newErr
+	}
+
+	err = a.client.decode(&localVarReturnValue, localVarBody, localVarHTTPResponse.Header.Get("Content-Type"))
+	if err != nil {
+		newErr := &GenericOpenAPIError{
+			body:  localVarBody,
+			error: err.Error(),
+		}
+		return localVarReturnValue, localVarHTTPResponse, newErr
+	}
+
+	return localVarReturnValue, localVarHTTPResponse, nil
+}
+
+type AdminApiApiGetSelfServiceErrorRequest struct {
+	ctx        context.Context
+	ApiService *AdminApiService
+	error_     *string
+}
+
+func (r AdminApiApiGetSelfServiceErrorRequest) Error_(error_ string) AdminApiApiGetSelfServiceErrorRequest {
+	r.error_ = &error_
+	return r
 }
 
 func (r AdminApiApiGetSelfServiceErrorRequest) Execute() (*ErrorContainer, *http.Response, error) {
@@ -1694,30 +2090,34 @@ func (a *AdminApiService) GetVersionExecute(r AdminApiApiGetVersionRequest) (*In
 	return localVarReturnValue, localVarHTTPResponse, nil
 }
 
-type AdminApiApiIsAliveRequest struct {
-	ctx        context.Context
-	ApiService *AdminApiService
+type AdminApiApiImpersonateIdentityRequest struct {
+	ctx                 context.Context
+	ApiService          *AdminApiService
+	impersonateIdentity *ImpersonateIdentity
 }
 
-func (r AdminApiApiIsAliveRequest) Execute() (*InlineResponse200, *http.Response, error) {
-	return r.ApiService.IsAliveExecute(r)
+func (r AdminApiApiImpersonateIdentityRequest) ImpersonateIdentity(impersonateIdentity ImpersonateIdentity) AdminApiApiImpersonateIdentityRequest {
+	r.impersonateIdentity = &impersonateIdentity
+	return r
 }
 
-/*
- * IsAlive Check HTTP Server Status
- * This endpoint returns a HTTP 200 status code when Ory Kratos is accepting incoming
-HTTP requests. This status does currently not include checks whether the database connection is working.
+func (r AdminApiApiImpersonateIdentityRequest) Execute() (*ImpersonationResponse, *http.Response, error) {
+	return r.ApiService.ImpersonateIdentityExecute(r)
+}
 
-If the service supports TLS Edge Termination, this endpoint does not require the
-`X-Forwarded-Proto` header to be set.
+/*
+ * ImpersonateIdentity Impersonate an Identity
+ * Issues a short-lived session for the identity to an administrator, for example to reproduce what a user
+sees. The session carries the administrator's ID and reason, which are returned by whoami and recorded in
+audit logs and events. Credentials can only be changed using the session if allowed in
+`session.impersonation.allowed_credential_changes`.
 
-Be aware that if you are running multiple nodes of this service, the health status will never
-refer to the cluster state, only to a single instance.
+This endpoint is only available if `session.impersonation.enabled` is set.
  * @param ctx context.Context - for authentication, logging, cancellation, deadlines, tracing, etc. Passed from http.Request or context.Background().
- * @return AdminApiApiIsAliveRequest
+ * @return AdminApiApiImpersonateIdentityRequest
 */
-func (a *AdminApiService) IsAlive(ctx context.Context) AdminApiApiIsAliveRequest {
-	return AdminApiApiIsAliveRequest{
+func (a *AdminApiService) ImpersonateIdentity(ctx context.Context) AdminApiApiImpersonateIdentityRequest {
+	return AdminApiApiImpersonateIdentityRequest{
 		ApiService: a,
 		ctx:        ctx,
 	}
@@ -1725,31 +2125,34 @@ func (a *AdminApiService) IsAlive(ctx context.Context) AdminApiApiIsAliveRequest
 
 /*
  * Execute executes the request
- * @return InlineResponse200
+ * @return ImpersonationResponse
  */
-func (a *AdminApiService) IsAliveExecute(r AdminApiApiIsAliveRequest) (*InlineResponse200, *http.Response, error) {
+func (a *AdminApiService) ImpersonateIdentityExecute(r AdminApiApiImpersonateIdentityRequest) (*ImpersonationResponse, *http.Response, error) {
 	var (
-		localVarHTTPMethod   = http.MethodGet
+		localVarHTTPMethod   = http.MethodPost
 		localVarPostBody     interface{}
 		localVarFormFileName string
 		localVarFileName     string
 		localVarFileBytes    []byte
-		localVarReturnValue  *InlineResponse200
+		localVarReturnValue  *ImpersonationResponse
 	)
 
-	localBasePath, err := a.client.cfg.ServerURLWithContext(r.ctx, "AdminApiService.IsAlive")
+	localBasePath, err := a.client.cfg.ServerURLWithContext(r.ctx, "AdminApiService.ImpersonateIdentity")
 	if err != nil {
 		return localVarReturnValue, nil, &GenericOpenAPIError{error: err.Error()}
 	}
 
-	localVarPath := localBasePath + "/health/alive"
+	localVarPath := localBasePath + "/sessions/impersonate"
 
 	localVarHeaderParams := make(map[string]string)
 	localVarQueryParams := url.Values{}
 	localVarFormParams := url.Values{}
+	if r.impersonateIdentity == nil {
+		return localVarReturnValue, nil, reportError("impersonateIdentity is required and must be specified")
+	}
 
 	// to determine the Content-Type header
-	localVarHTTPContentTypes := []string{}
+	localVarHTTPContentTypes := []string{"application/json"}
 
 	// set Content-Type header
 	localVarHTTPContentType := selectHeaderContentType(localVarHTTPContentTypes)
@@ -1765,6 +2168,8 @@ func (a *AdminApiService) IsAliveExecute(r AdminApiApiIsAliveRequest) (*InlineRe
 	if localVarHTTPHeaderAccept != "" {
 		localVarHeaderParams["Accept"] = localVarHTTPHeaderAccept
 	}
+	// body params
+	localVarPostBody = r.impersonateIdentity
 	req, err := a.client.prepareRequest(r.ctx, localVarPath, localVarHTTPMethod, localVarPostBody, localVarHeaderParams, localVarQueryParams, localVarFormParams, localVarFormFileName, localVarFileName, localVarFileBytes)
 	if err != nil {
 		return localVarReturnValue, nil, err
@@ -1787,6 +2192,36 @@ func (a *AdminApiService) IsAliveExecute(r AdminApiApiIsAliveRequest) (*InlineRe
 			body:  localVarBody,
 			error: localVarHTTPResponse.Status,
 		}
+		if localVarHTTPResponse.StatusCode == 400 {
+			var v GenericError
+			err = a.client.decode(&v, localVarBody, localVarHTTPResponse.Header.Get("Content-Type"))
+			if err != nil {
+				newErr.error = err.Error()
+				return localVarReturnValue, localVarHTTPResponse, newErr
+			}
+			newErr.model = v
+			return localVarReturnValue, localVarHTTPResponse, newErr
+		}
+		if localVarHTTPResponse.StatusCode == 403 {
+			var v GenericError
+			err = a.client.decode(&v, localVarBody, localVarHTTPResponse.Header.Get("Content-Type"))
+			if err != nil {
+				newErr.error = err.Error()
+				return localVarReturnValue, localVarHTTPResponse, newErr
+			}
+			newErr.model = v
+			return localVarReturnValue, localVarHTTPResponse, newErr
+		}
+		if localVarHTTPResponse.StatusCode == 404 {
+			var v GenericError
+			err = a.client.decode(&v, localVarBody, localVarHTTPResponse.Header.Get("Content-Type"))
+			if err != nil {
+				newErr.error = err.Error()
+				return localVarReturnValue, localVarHTTPResponse, newErr
+			}
+			newErr.model = v
+			return localVarReturnValue, localVarHTTPResponse, newErr
+		}
 		if localVarHTTPResponse.StatusCode == 500 {
 			var v GenericError
 			err = a.client.decode(&v, localVarBody, localVarHTTPResponse.Header.Get("Content-Type"))
@@ -1811,30 +2246,30 @@ func (a *AdminApiService) IsAliveExecute(r AdminApiApiIsAliveRequest) (*InlineRe
 	return localVarReturnValue, localVarHTTPResponse, nil
 }
 
-type AdminApiApiIsReadyRequest struct {
+type AdminApiApiIsAliveRequest struct {
 	ctx        context.Context
 	ApiService *AdminApiService
 }
 
-func (r AdminApiApiIsReadyRequest) Execute() (*InlineResponse200, *http.Response, error) {
-	return r.ApiService.IsReadyExecute(r)
+func (r AdminApiApiIsAliveRequest) Execute() (*InlineResponse200, *http.Response, error) {
+	return r.ApiService.IsAliveExecute(r)
 }
 
 /*
- * IsReady Check HTTP Server and Database Status
- * This endpoint returns a HTTP 200 status code when Ory Kratos is up running and the environment dependencies (e.g.
-the database) are responsive as well.
+ * IsAlive Check HTTP Server Status
+ * This endpoint returns a HTTP 200 status code when Ory Kratos is accepting incoming
+HTTP requests. This status does currently not include checks whether the database connection is working.
 
 If the service supports TLS Edge Termination, this endpoint does not require the
 `X-Forwarded-Proto` header to be set.
 
-Be aware that if you are running multiple nodes of Ory Kratos, the health status will never
+Be aware that if you are running multiple nodes of this service, the health status will never
 refer to the cluster state, only to a single instance.
  * @param ctx context.Context - for authentication, logging, cancellation, deadlines, tracing, etc. Passed from http.Request or context.Background().
- * @return AdminApiApiIsReadyRequest
+ * @return AdminApiApiIsAliveRequest
 */
-func (a *AdminApiService) IsReady(ctx context.Context) AdminApiApiIsReadyRequest {
-	return AdminApiApiIsReadyRequest{
+func (a *AdminApiService) IsAlive(ctx context.Context) AdminApiApiIsAliveRequest {
+	return AdminApiApiIsAliveRequest{
 		ApiService: a,
 		ctx:        ctx,
 	}
@@ -1844,7 +2279,7 @@ func (a *AdminApiService) IsReady(ctx context.Context) AdminApiApiIsReadyRequest
  * Execute executes the request
  * @return InlineResponse200
  */
-func (a *AdminApiService) IsReadyExecute(r AdminApiApiIsReadyRequest) (*InlineResponse200, *http.Response, error) {
+func (a *AdminApiService) IsAliveExecute(r AdminApiApiIsAliveRequest) (*InlineResponse200, *http.Response, error) {
 	var (
 		localVarHTTPMethod   = http.MethodGet
 		localVarPostBody     interface{}
@@ -1854,12 +2289,12 @@ func (a *AdminApiService) IsReadyExecute(r AdminApiApiIsReadyRequest) (*InlineRe
 		localVarReturnValue  *InlineResponse200
 	)
 
-	localBasePath, err := a.client.cfg.ServerURLWithContext(r.ctx, "AdminApiService.IsReady")
+	localBasePath, err := a.client.cfg.ServerURLWithContext(r.ctx, "AdminApiService.IsAlive")
 	if err != nil {
 		return localVarReturnValue, nil, &GenericOpenAPIError{error: err.Error()}
 	}
 
-	localVarPath := localBasePath + "/health/ready"
+	localVarPath := localBasePath + "/health/alive"
 
 	localVarHeaderParams := make(map[string]string)
 	localVarQueryParams := url.Values{}
@@ -1904,8 +2339,8 @@ func (a *AdminApiService) IsReadyExecute(r AdminApiApiIsReadyRequest) (*InlineRe
 			body:  localVarBody,
 			error: localVarHTTPResponse.Status,
 		}
-		if localVarHTTPResponse.StatusCode == 503 {
-			var v InlineResponse503
+		if localVarHTTPResponse.StatusCode == 500 {
+			var v GenericError
 			err = a.client.decode(&v, localVarBody, localVarHTTPResponse.Header.Get("Content-Type"))
 			if err != nil {
 				newErr.error = err.Error()
@@ -1928,36 +2363,30 @@ func (a *AdminApiService) IsReadyExecute(r AdminApiApiIsReadyRequest) (*InlineRe
 	return localVarReturnValue, localVarHTTPResponse, nil
 }
 
-type AdminApiApiListIdentitiesRequest struct {
+type AdminApiApiIsReadyRequest struct {
 	ctx        context.Context
 	ApiService *AdminApiService
-	perPage    *int64
-	page       *int64
-}
-
-func (r AdminApiApiListIdentitiesRequest) PerPage(perPage int64) AdminApiApiListIdentitiesRequest {
-	r.perPage = &perPage
-	return r
-}
-func (r AdminApiApiListIdentitiesRequest) Page(page int64) AdminApiApiListIdentitiesRequest {
-	r.page = &page
-	return r
 }
 
-func (r AdminApiApiListIdentitiesRequest) Execute() ([]Identity, *http.Response, error) {
-	return r.ApiService.ListIdentitiesExecute(r)
+func (r AdminApiApiIsReadyRequest) Execute() (*InlineResponse200, *http.Response, error) {
+	return r.ApiService.IsReadyExecute(r)
 }
 
 /*
- * ListIdentities List Identities
- * Lists all identities. Does not support search at the moment.
+ * IsReady Check HTTP Server and Database Status
+ * This endpoint returns a HTTP 200 status code when Ory Kratos is up running and the environment dependencies (e.g.
+the database) are responsive as well.
 
-Learn how identities work in [ORY Kratos' User And Identity Model Documentation](https://www.ory.sh/docs/next/kratos/concepts/identity-user-model).
+If the service supports TLS Edge Termination, this endpoint does not require the
+`X-Forwarded-Proto` header to be set.
+
+Be aware that if you are running multiple nodes of Ory Kratos, the health status will never
+refer to the cluster state, only to a single instance.
  * @param ctx context.Context - for authentication, logging, cancellation, deadlines, tracing, etc. Passed from http.Request or context.Background().
- * @return AdminApiApiListIdentitiesRequest
+ * @return AdminApiApiIsReadyRequest
 */
-func (a *AdminApiService) ListIdentities(ctx context.Context) AdminApiApiListIdentitiesRequest {
-	return AdminApiApiListIdentitiesRequest{
+func (a *AdminApiService) IsReady(ctx context.Context) AdminApiApiIsReadyRequest {
+	return AdminApiApiIsReadyRequest{
 		ApiService: a,
 		ctx:        ctx,
 	}
@@ -1965,37 +2394,496 @@ func (a *AdminApiService) ListIdentities(ctx context.Context) AdminApiApiListIde
 
 /*
  * Execute executes the request
- * @return []Identity
+ * @return InlineResponse200
  */
-func (a *AdminApiService) ListIdentitiesExecute(r AdminApiApiListIdentitiesRequest) ([]Identity, *http.Response, error) {
+func (a *AdminApiService) IsReadyExecute(r AdminApiApiIsReadyRequest) (*InlineResponse200, *http.Response, error) {
 	var (
 		localVarHTTPMethod   = http.MethodGet
 		localVarPostBody     interface{}
 		localVarFormFileName string
 		localVarFileName     string
 		localVarFileBytes    []byte
-		localVarReturnValue  []Identity
+		localVarReturnValue  *InlineResponse200
+	)
+
+	localBasePath, err := a.client.cfg.ServerURLWithContext(r.ctx, "AdminApiService.IsReady")
+	if err != nil {
+		return localVarReturnValue, nil, &GenericOpenAPIError{error: err.Error()}
+	}
+
+	localVarPath := localBasePath + "/health/ready"
+
+	localVarHeaderParams := make(map[string]string)
+	localVarQueryParams := url.Values{}
+	localVarFormParams := url.Values{}
+
+	// to determine the Content-Type header
+	localVarHTTPContentTypes := []string{}
+
+	// set Content-Type header
+	localVarHTTPContentType := selectHeaderContentType(localVarHTTPContentTypes)
+	if localVarHTTPContentType != "" {
+		localVarHeaderParams["Content-Type"] = localVarHTTPContentType
+	}
+
+	// to determine the Accept header
+	localVarHTTPHeaderAccepts := []string{"application/json"}
+
+	// set Accept header
+	localVarHTTPHeaderAccept := selectHeaderAccept(localVarHTTPHeaderAccepts)
+	if localVarHTTPHeaderAccept != "" {
+		localVarHeaderParams["Accept"] = localVarHTTPHeaderAccept
+	}
+	req, err := a.client.prepareRequest(r.ctx, localVarPath, localVarHTTPMethod, localVarPostBody, localVarHeaderParams, localVarQueryParams, localVarFormParams, localVarFormFileName, localVarFileName, localVarFileBytes)
+	if err != nil {
+		return localVarReturnValue, nil, err
+	}
+
+	localVarHTTPResponse, err := a.client.callAPI(req)
+	if err != nil || localVarHTTPResponse == nil {
+		return localVarReturnValue, localVarHTTPResponse, err
+	}
+
+	localVarBody, err := ioutil.ReadAll(localVarHTTPResponse.Body)
+	localVarHTTPResponse.Body.Close()
+	localVarHTTPResponse.Body = ioutil.NopCloser(bytes.NewBuffer(localVarBody))
+	if err != nil {
+		return localVarReturnValue, localVarHTTPResponse, err
+	}
+
+	if localVarHTTPResponse.StatusCode >= 300 {
+		newErr := &GenericOpenAPIError{
+			body:  localVarBody,
+			error: localVarHTTPResponse.Status,
+		}
+		if localVarHTTPResponse.StatusCode == 503 {
+			var v InlineResponse503
+			err = a.client.decode(&v, localVarBody, localVarHTTPResponse.Header.Get("Content-Type"))
+			if err != nil {
+				newErr.error = err.Error()
+				return localVarReturnValue, localVarHTTPResponse, newErr
+			}
+			newErr.model = v
+		}
+		return localVarReturnValue, localVarHTTPResponse, newErr
+	}
+
+	err = a.client.decode(&localVarReturnValue, localVarBody, localVarHTTPResponse.Header.Get("Content-Type"))
+	if err != nil {
+		newErr := &GenericOpenAPIError{
+			body:  localVarBody,
+			error: err.Error(),
+		}
+		return localVarReturnValue, localVarHTTPResponse, newErr
+	}
+
+	return localVarReturnValue, localVarHTTPResponse, nil
+}
+
+type AdminApiApiListIdentitiesRequest struct {
+	ctx                          context.Context
+	ApiService                   *AdminApiService
+	perPage                      *int64
+	page                         *int64
+	pageSize                     *int64
+	pageToken                    *string
+	credentialsIdentifier        *string
+	credentialsIdentifierSimilar *string
+}
+
+func (r AdminApiApiListIdentitiesRequest) PerPage(perPage int64) AdminApiApiListIdentitiesRequest {
+	r.perPage = &perPage
+	return r
+}
+func (r AdminApiApiListIdentitiesRequest) Page(page int64) AdminApiApiListIdentitiesRequest {
+	r.page = &page
+	return r
+}
+func (r AdminApiApiListIdentitiesRequest) PageSize(pageSize int64) AdminApiApiListIdentitiesRequest {
+	r.pageSize = &pageSize
+	return r
+}
+func (r AdminApiApiListIdentitiesRequest) PageToken(pageToken string) AdminApiApiListIdentitiesRequest {
+	r.pageToken = &pageToken
+	return r
+}
+func (r AdminApiApiListIdentitiesRequest) CredentialsIdentifier(credentialsIdentifier string) AdminApiApiListIdentitiesRequest {
+	r.credentialsIdentifier = &credentialsIdentifier
+	return r
+}
+func (r AdminApiApiListIdentitiesRequest) CredentialsIdentifierSimilar(credentialsIdentifierSimilar string) AdminApiApiListIdentitiesRequest {
+	r.credentialsIdentifierSimilar = &credentialsIdentifierSimilar
+	return r
+}
+
+func (r AdminApiApiListIdentitiesRequest) Execute() ([]Identity, *http.Response, error) {
+	return r.ApiService.ListIdentitiesExecute(r)
+}
+
+/*
+ * ListIdentities List Identities
+ * Lists all identities. Does not support search at the moment.
+
+The identities are paginated by `page` and `per_page` by default. Identities created or deleted while
+iterating these pages may shift the results. Use `page_size` and the page tokens of the `next` and
+`prev` links in the `Link` header for results which stay stable under concurrent writes.
+
+Use `credentials_identifier` to find the identity signing in with an identifier such as an email
+address, or `credentials_identifier_similar` to search identifiers.
+
+Learn how identities work in [ORY Kratos' User And Identity Model Documentation](https://www.ory.sh/docs/next/kratos/concepts/identity-user-model).
+ * @param ctx context.Context - for authentication, logging, cancellation, deadlines, tracing, etc. Passed from http.Request or context.Background().
+ * @return AdminApiApiListIdentitiesRequest
+*/
+func (a *AdminApiService) ListIdentities(ctx context.Context) AdminApiApiListIdentitiesRequest {
+	return AdminApiApiListIdentitiesRequest{
+		ApiService: a,
+		ctx:        ctx,
+	}
+}
+
+/*
+ * Execute executes the request
+ * @return []Identity
+ */
+func (a *AdminApiService) ListIdentitiesExecute(r AdminApiApiListIdentitiesRequest) ([]Identity, *http.Response, error) {
+	var (
+		localVarHTTPMethod   = http.MethodGet
+		localVarPostBody     interface{}
+		localVarFormFileName string
+		localVarFileName     string
+		localVarFileBytes    []byte
+		localVarReturnValue  []Identity
+	)
+
+	localBasePath, err := a.client.cfg.ServerURLWithContext(r.ctx, "AdminApiService.ListIdentities")
+	if err != nil {
+		return localVarReturnValue, nil, &GenericOpenAPIError{error: err.Error()}
+	}
+
+	localVarPath := localBasePath + "/identities"
+
+	localVarHeaderParams := make(map[string]string)
+	localVarQueryParams := url.Values{}
+	localVarFormParams := url.Values{}
+
+	if r.perPage != nil {
+		localVarQueryParams.Add("per_page", parameterToString(*r.perPage, ""))
+	}
+	if r.page != nil {
+		localVarQueryParams.Add("page", parameterToString(*r.page, ""))
+	}
+	if r.pageSize != nil {
+		localVarQueryParams.Add("page_size", parameterToString(*r.pageSize, ""))
+	}
+	if r.pageToken != nil {
+		localVarQueryParams.Add("page_token", parameterToString(*r.pageToken, ""))
+	}
+	if r.credentialsIdentifier != nil {
+		localVarQueryParams.Add("credentials_identifier", parameterToString(*r.credentialsIdentifier, ""))
+	}
+	if r.credentialsIdentifierSimilar != nil {
+		localVarQueryParams.Add("credentials_identifier_similar", parameterToString(*r.credentialsIdentifierSimilar, ""))
+	}
+	// to determine the Content-Type header
+	localVarHTTPContentTypes := []string{}
+
+	// set Content-Type header
+	localVarHTTPContentType := selectHeaderContentType(localVarHTTPContentTypes)
+	if localVarHTTPContentType != "" {
+		localVarHeaderParams["Content-Type"] = localVarHTTPContentType
+	}
+
+	// to determine the Accept header
+	localVarHTTPHeaderAccepts := []string{"application/json"}
+
+	// set Accept header
+	localVarHTTPHeaderAccept := selectHeaderAccept(localVarHTTPHeaderAccepts)
+	if localVarHTTPHeaderAccept != "" {
+		localVarHeaderParams["Accept"] = localVarHTTPHeaderAccept
+	}
+	req, err := a.client.prepareRequest(r.ctx, localVarPath, localVarHTTPMethod, localVarPostBody, localVarHeaderParams, localVarQueryParams, localVarFormParams, localVarFormFileName, localVarFileName, localVarFileBytes)
+	if err != nil {
+		return localVarReturnValue, nil, err
+	}
+
+	localVarHTTPResponse, err := a.client.callAPI(req)
+	if err != nil || localVarHTTPResponse == nil {
+		return localVarReturnValue, localVarHTTPResponse, err
+	}
+
+	localVarBody, err := ioutil.ReadAll(localVarHTTPResponse.Body)
+	localVarHTTPResponse.Body.Close()
+	localVarHTTPResponse.Body = ioutil.NopCloser(bytes.NewBuffer(localVarBody))
+	if err != nil {
+		return localVarReturnValue, localVarHTTPResponse, err
+	}
+
+	if localVarHTTPResponse.StatusCode >= 300 {
+		newErr := &GenericOpenAPIError{
+			body:  localVarBody,
+			error: localVarHTTPResponse.Status,
+		}
+		if localVarHTTPResponse.StatusCode == 500 {
+			var v GenericError
+			err = a.client.decode(&v, localVarBody, localVarHTTPResponse.Header.Get("Content-Type"))
+			if err != nil {
+				newErr.error = err.Error()
+				return localVarReturnValue, localVarHTTPResponse, newErr
+			}
+			newErr.model = v
+		}
+		return localVarReturnValue, localVarHTTPResponse, newErr
+	}
+
+	err = a.client.decode(&localVarReturnValue, localVarBody, localVarHTTPResponse.Header.Get("Content-Type"))
+	if err != nil {
+		newErr := &GenericOpenAPIError{
+			body:  localVarBody,
+			error: err.Error(),
+		}
+		return localVarReturnValue, localVarHTTPResponse, newErr
+	}
+
+	return localVarReturnValue, localVarHTTPResponse, nil
+}
+
+type AdminApiApiListIdentitySessionsRequest struct {
+	ctx        context.Context
+	ApiService *AdminApiService
+	id         string
+	perPage    *int64
+	page       *int64
+	active     *bool
+}
+
+func (r AdminApiApiListIdentitySessionsRequest) PerPage(perPage int64) AdminApiApiListIdentitySessionsRequest {
+	r.perPage = &perPage
+	return r
+}
+func (r AdminApiApiListIdentitySessionsRequest) Page(page int64) AdminApiApiListIdentitySessionsRequest {
+	r.page = &page
+	return r
+}
+func (r AdminApiApiListIdentitySessionsRequest) Active(active bool) AdminApiApiListIdentitySessionsRequest {
+	r.active = &active
+	return r
+}
+
+func (r AdminApiApiListIdentitySessionsRequest) Execute() ([]Session, *http.Response, error) {
+	return r.ApiService.ListIdentitySessionsExecute(r)
+}
+
+/*
+ * ListIdentitySessions List the Sessions of an Identity
+ * Lists the sessions of the identity, most recently issued first, for example to diagnose sign in issues.
+The `active` field of a session is false if the session was revoked or has expired. The `devices` field
+describes the client each session was issued to.
+
+The sessions are paginated by `page` and `per_page`.
+ * @param ctx context.Context - for authentication, logging, cancellation, deadlines, tracing, etc. Passed from http.Request or context.Background().
+ * @param id ID is the identity's ID.
+ * @return AdminApiApiListIdentitySessionsRequest
+*/
+func (a *AdminApiService) ListIdentitySessions(ctx context.Context, id string) AdminApiApiListIdentitySessionsRequest {
+	return AdminApiApiListIdentitySessionsRequest{
+		ApiService: a,
+		ctx:        ctx,
+		id:         id,
+	}
+}
+
+/*
+ * Execute executes the request
+ * @return []Session
+ */
+func (a *AdminApiService) ListIdentitySessionsExecute(r AdminApiApiListIdentitySessionsRequest) ([]Session, *http.Response, error) {
+	var (
+		localVarHTTPMethod   = http.MethodGet
+		localVarPostBody     interface{}
+		localVarFormFileName string
+		localVarFileName     string
+		localVarFileBytes    []byte
+		localVarReturnValue  []Session
+	)
+
+	localBasePath, err := a.client.cfg.ServerURLWithContext(r.ctx, "AdminApiService.ListIdentitySessions")
+	if err != nil {
+		return localVarReturnValue, nil, &GenericOpenAPIError{error: err.Error()}
+	}
+
+	localVarPath := localBasePath + "/identities/{id}/sessions"
+	localVarPath = strings.Replace(localVarPath, "{"+"id"+"}", url.PathEscape(parameterToString(r.id, "")), -1)
+
+	localVarHeaderParams := make(map[string]string)
+	localVarQueryParams := url.Values{}
+	localVarFormParams := url.Values{}
+
+	if r.perPage != nil {
+		localVarQueryParams.Add("per_page", parameterToString(*r.perPage, ""))
+	}
+	if r.page != nil {
+		localVarQueryParams.Add("page", parameterToString(*r.page, ""))
+	}
+	if r.active != nil {
+		localVarQueryParams.Add("active", parameterToString(*r.active, ""))
+	}
+	// to determine the Content-Type header
+	localVarHTTPContentTypes := []string{}
+
+	// set Content-Type header
+	localVarHTTPContentType := selectHeaderContentType(localVarHTTPContentTypes)
+	if localVarHTTPContentType != "" {
+		localVarHeaderParams["Content-Type"] = localVarHTTPContentType
+	}
+
+	// to determine the Accept header
+	localVarHTTPHeaderAccepts := []string{"application/json"}
+
+	// set Accept header
+	localVarHTTPHeaderAccept := selectHeaderAccept(localVarHTTPHeaderAccepts)
+	if localVarHTTPHeaderAccept != "" {
+		localVarHeaderParams["Accept"] = localVarHTTPHeaderAccept
+	}
+	req, err := a.client.prepareRequest(r.ctx, localVarPath, localVarHTTPMethod, localVarPostBody, localVarHeaderParams, localVarQueryParams, localVarFormParams, localVarFormFileName, localVarFileName, localVarFileBytes)
+	if err != nil {
+		return localVarReturnValue, nil, err
+	}
+
+	localVarHTTPResponse, err := a.client.callAPI(req)
+	if err != nil || localVarHTTPResponse == nil {
+		return localVarReturnValue, localVarHTTPResponse, err
+	}
+
+	localVarBody, err := ioutil.ReadAll(localVarHTTPResponse.Body)
+	localVarHTTPResponse.Body.Close()
+	localVarHTTPResponse.Body = ioutil.NopCloser(bytes.NewBuffer(localVarBody))
+	if err != nil {
+		return localVarReturnValue, localVarHTTPResponse, err
+	}
+
+	if localVarHTTPResponse.StatusCode >= 300 {
+		newErr := &GenericOpenAPIError{
+			body:  localVarBody,
+			error: localVarHTTPResponse.Status,
+		}
+		if localVarHTTPResponse.StatusCode == 400 {
+			var v GenericError
+			err = a.client.decode(&v, localVarBody, localVarHTTPResponse.Header.Get("Content-Type"))
+			if err != nil {
+				newErr.error = err.Error()
+				return localVarReturnValue, localVarHTTPResponse, newErr
+			}
+			newErr.model = v
+			return localVarReturnValue, localVarHTTPResponse, newErr
+		}
+		if localVarHTTPResponse.StatusCode == 404 {
+			var v GenericError
+			err = a.client.decode(&v, localVarBody, localVarHTTPResponse.Header.Get("Content-Type"))
+			if err != nil {
+				newErr.error = err.Error()
+				return localVarReturnValue, localVarHTTPResponse, newErr
+			}
+			newErr.model = v
+			return localVarReturnValue, localVarHTTPResponse, newErr
+		}
+		if localVarHTTPResponse.StatusCode == 500 {
+			var v GenericError
+			err = a.client.decode(&v, localVarBody, localVarHTTPResponse.Header.Get("Content-Type"))
+			if err != nil {
+				newErr.error = err.Error()
+				return localVarReturnValue, localVarHTTPResponse, newErr
+			}
+			newErr.model = v
+		}
+		return localVarReturnValue, localVarHTTPResponse, newErr
+	}
+
+	err = a.client.decode(&localVarReturnValue, localVarBody, localVarHTTPResponse.Header.Get("Content-Type"))
+	if err != nil {
+		newErr := &GenericOpenAPIError{
+			body:  localVarBody,
+			error: err.Error(),
+		}
+		return localVarReturnValue, localVarHTTPResponse, newErr
+	}
+
+	return localVarReturnValue, localVarHTTPResponse, nil
+}
+
+type AdminApiApiPatchIdentityRequest struct {
+	ctx        context.Context
+	ApiService *AdminApiService
+	id         string
+	jsonPatch  *[]JsonPatch
+}
+
+func (r AdminApiApiPatchIdentityRequest) JsonPatch(jsonPatch []JsonPatch) AdminApiApiPatchIdentityRequest {
+	r.jsonPatch = &jsonPatch
+	return r
+}
+
+func (r AdminApiApiPatchIdentityRequest) Execute() (*Identity, *http.Response, error) {
+	return r.ApiService.PatchIdentityExecute(r)
+}
+
+/*
+ * PatchIdentity Patch an Identity
+ * This endpoint partially updates an identity, which allows updating a single trait without sending the
+whole traits object. The patched result is validated against the identity's JSON Schema and the
+identity is left unchanged if the validation fails.
+
+With Content-Type `application/json-patch+json`, the body is a JSON Patch (RFC 6902) whose operations
+are applied to the identity's `traits`, e.g. `[{"op": "replace", "path": "/traits/name/first", "value": "Jane"}]`.
+Operations on any other path, such as `/credentials` or `/schema_id`, are rejected.
+
+With Content-Type `application/merge-patch+json`, the body is a JSON Merge Patch (RFC 7386) which is
+applied to the identity's `schema_id` and `traits`. Keys missing from the patch are left unchanged and
+keys set to `null` are removed.
+
+It is NOT possible to set an identity's credentials using this method.
+
+Learn how identities work in [ORY Kratos' User And Identity Model Documentation](https://www.ory.sh/docs/next/kratos/concepts/identity-user-model).
+ * @param ctx context.Context - for authentication, logging, cancellation, deadlines, tracing, etc. Passed from http.Request or context.Background().
+ * @param id ID must be set to the ID of identity you want to update
+ * @return AdminApiApiPatchIdentityRequest
+*/
+func (a *AdminApiService) PatchIdentity(ctx context.Context, id string) AdminApiApiPatchIdentityRequest {
+	return AdminApiApiPatchIdentityRequest{
+		ApiService: a,
+		ctx:        ctx,
+		id:         id,
+	}
+}
+
+/*
+ * Execute executes the request
+ * @return Identity
+ */
+func (a *AdminApiService) PatchIdentityExecute(r AdminApiApiPatchIdentityRequest) (*Identity, *http.Response, error) {
+	var (
+		localVarHTTPMethod   = http.MethodPatch
+		localVarPostBody     interface{}
+		localVarFormFileName string
+		localVarFileName     string
+		localVarFileBytes    []byte
+		localVarReturnValue  *Identity
 	)
 
-	localBasePath, err := a.client.cfg.ServerURLWithContext(r.ctx, "AdminApiService.ListIdentities")
+	localBasePath, err := a.client.cfg.ServerURLWithContext(r.ctx, "AdminApiService.PatchIdentity")
 	if err != nil {
 		return localVarReturnValue, nil, &GenericOpenAPIError{error: err.Error()}
 	}
 
-	localVarPath := localBasePath + "/identities"
+	localVarPath := localBasePath + "/identities/{id}"
+	localVarPath = strings.Replace(localVarPath, "{"+"id"+"}", url.PathEscape(parameterToString(r.id, "")), -1)
 
 	localVarHeaderParams := make(map[string]string)
 	localVarQueryParams := url.Values{}
 	localVarFormParams := url.Values{}
 
-	if r.perPage != nil {
-		localVarQueryParams.Add("per_page", parameterToString(*r.perPage, ""))
-	}
-	if r.page != nil {
-		localVarQueryParams.Add("page", parameterToString(*r.page, ""))
-	}
 	// to determine the Content-Type header
-	localVarHTTPContentTypes := []string{}
+	localVarHTTPContentTypes := []string{"application/json-patch+json", "application/merge-patch+json"}
 
 	// set Content-Type header
 	localVarHTTPContentType := selectHeaderContentType(localVarHTTPContentTypes)
@@ -2011,6 +2899,8 @@ func (a *AdminApiService) ListIdentitiesExecute(r AdminApiApiListIdentitiesReque
 	if localVarHTTPHeaderAccept != "" {
 		localVarHeaderParams["Accept"] = localVarHTTPHeaderAccept
 	}
+	// body params
+	localVarPostBody = r.jsonPatch
 	req, err := a.client.prepareRequest(r.ctx, localVarPath, localVarHTTPMethod, localVarPostBody, localVarHeaderParams, localVarQueryParams, localVarFormParams, localVarFormFileName, localVarFileName, localVarFileBytes)
 	if err != nil {
 		return localVarReturnValue, nil, err
@@ -2033,6 +2923,36 @@ func (a *AdminApiService) ListIdentitiesExecute(r AdminApiApiListIdentitiesReque
 			body:  localVarBody,
 			error: localVarHTTPResponse.Status,
 		}
+		if localVarHTTPResponse.StatusCode == 400 {
+			var v GenericError
+			err = a.client.decode(&v, localVarBody, localVarHTTPResponse.Header.Get("Content-Type"))
+			if err != nil {
+				newErr.error = err.Error()
+				return localVarReturnValue, localVarHTTPResponse, newErr
+			}
+			newErr.model = v
+			return localVarReturnValue, localVarHTTPResponse, newErr
+		}
+		if localVarHTTPResponse.StatusCode == 404 {
+			var v GenericError
+			err = a.client.decode(&v, localVarBody, localVarHTTPResponse.Header.Get("Content-Type"))
+			if err != nil {
+				newErr.error = err.Error()
+				return localVarReturnValue, localVarHTTPResponse, newErr
+			}
+			newErr.model = v
+			return localVarReturnValue, localVarHTTPResponse, newErr
+		}
+		if localVarHTTPResponse.StatusCode == 415 {
+			var v GenericError
+			err = a.client.decode(&v, localVarBody, localVarHTTPResponse.Header.Get("Content-Type"))
+			if err != nil {
+				newErr.error = err.Error()
+				return localVarReturnValue, localVarHTTPResponse, newErr
+			}
+			newErr.model = v
+			return localVarReturnValue, localVarHTTPResponse, newErr
+		}
 		if localVarHTTPResponse.StatusCode == 500 {
 			var v GenericError
 			err = a.client.decode(&v, localVarBody, localVarHTTPResponse.Header.Get("Content-Type"))
@@ -2152,6 +3072,131 @@ func (a *AdminApiService) PrometheusExecute(r AdminApiApiPrometheusRequest) (*ht
 	return localVarHTTPResponse, nil
 }
 
+type AdminApiApiRevokeIdentitySessionsRequest struct {
+	ctx        context.Context
+	ApiService *AdminApiService
+	id         string
+}
+
+func (r AdminApiApiRevokeIdentitySessionsRequest) Execute() (*RevokedSessions, *http.Response, error) {
+	return r.ApiService.RevokeIdentitySessionsExecute(r)
+}
+
+/*
+ * RevokeIdentitySessions Revoke all Sessions of an Identity
+ * Revokes all sessions of the identity which have not expired yet, for example when an employee leaves
+the company. The identity is not changed and can sign in again.
+ * @param ctx context.Context - for authentication, logging, cancellation, deadlines, tracing, etc. Passed from http.Request or context.Background().
+ * @param id ID is the identity's ID.
+ * @return AdminApiApiRevokeIdentitySessionsRequest
+*/
+func (a *AdminApiService) RevokeIdentitySessions(ctx context.Context, id string) AdminApiApiRevokeIdentitySessionsRequest {
+	return AdminApiApiRevokeIdentitySessionsRequest{
+		ApiService: a,
+		ctx:        ctx,
+		id:         id,
+	}
+}
+
+/*
+ * Execute executes the request
+ * @return RevokedSessions
+ */
+func (a *AdminApiService) RevokeIdentitySessionsExecute(r AdminApiApiRevokeIdentitySessionsRequest) (*RevokedSessions, *http.Response, error) {
+	var (
+		localVarHTTPMethod   = http.MethodDelete
+		localVarPostBody     interface{}
+		localVarFormFileName string
+		localVarFileName     string
+		localVarFileBytes    []byte
+		localVarReturnValue  *RevokedSessions
+	)
+
+	localBasePath, err := a.client.cfg.ServerURLWithContext(r.ctx, "AdminApiService.RevokeIdentitySessions")
+	if err != nil {
+		return localVarReturnValue, nil, &GenericOpenAPIError{error: err.Error()}
+	}
+
+	localVarPath := localBasePath + "/identities/{id}/sessions"
+	localVarPath = strings.Replace(localVarPath, "{"+"id"+"}", url.PathEscape(parameterToString(r.id, "")), -1)
+
+	localVarHeaderParams := make(map[string]string)
+	localVarQueryParams := url.Values{}
+	localVarFormParams := url.Values{}
+
+	// to determine the Content-Type header
+	localVarHTTPContentTypes := []string{}
+
+	// set Content-Type header
+	localVarHTTPContentType := selectHeaderContentType(localVarHTTPContentTypes)
+	if localVarHTTPContentType != "" {
+		localVarHeaderParams["Content-Type"] = localVarHTTPContentType
+	}
+
+	// to determine the Accept header
+	localVarHTTPHeaderAccepts := []string{"application/json"}
+
+	// set Accept header
+	localVarHTTPHeaderAccept := selectHeaderAccept(localVarHTTPHeaderAccepts)
+	if localVarHTTPHeaderAccept != "" {
+		localVarHeaderParams["Accept"] = localVarHTTPHeaderAccept
+	}
+	req, err := a.client.prepareRequest(r.ctx, localVarPath, localVarHTTPMethod, localVarPostBody, localVarHeaderParams, localVarQueryParams, localVarFormParams, localVarFormFileName, localVarFileName, localVarFileBytes)
+	if err != nil {
+		return localVarReturnValue, nil, err
+	}
+
+	localVarHTTPResponse, err := a.client.callAPI(req)
+	if err != nil || localVarHTTPResponse == nil {
+		return localVarReturnValue, localVarHTTPResponse, err
+	}
+
+	localVarBody, err := ioutil.ReadAll(localVarHTTPResponse.Body)
+	localVarHTTPResponse.Body.Close()
+	localVarHTTPResponse.Body = ioutil.NopCloser(bytes.NewBuffer(localVarBody))
+	if err != nil {
+		return localVarReturnValue, localVarHTTPResponse, err
+	}
+
+	if localVarHTTPResponse.StatusCode >= 300 {
+		newErr := &GenericOpenAPIError{
+			body:  localVarBody,
+			error: localVarHTTPResponse.Status,
+		}
+		if localVarHTTPResponse.StatusCode == 404 {
+			var v GenericError
+			err = a.client.decode(&v, localVarBody, localVarHTTPResponse.Header.Get("Content-Type"))
+			if err != nil {
+				newErr.error = err.Error()
+				return localVarReturnValue, localVarHTTPResponse, newErr
+			}
+			newErr.model = v
+			return localVarReturnValue, localVarHTTPResponse, newErr
+		}
+		if localVarHTTPResponse.StatusCode == 500 {
+			var v GenericError
+			err = a.client.decode(&v, localVarBody, localVarHTTPResponse.Header.Get("Content-Type"))
+			if err != nil {
+				newErr.error = err.Error()
+				return localVarReturnValue, localVarHTTPResponse, newErr
+			}
+			newErr.model = v
+		}
+		return localVarReturnValue, localVarHTTPResponse, newErr
+	}
+
+	err = a.client.decode(&localVarReturnValue, localVarBody, localVarHTTPResponse.Header.Get("Content-Type"))
+	if err != nil {
+		newErr := &GenericOpenAPIError{
+			body:  localVarBody,
+			error: err.Error(),
+		}
+		return localVarReturnValue, localVarHTTPResponse, newErr
+	}
+
+	return localVarReturnValue, localVarHTTPResponse, nil
+}
+
 type AdminApiApiUpdateIdentityRequest struct {
 	ctx            context.Context
 	ApiService     *AdminApiService
@@ -2173,7 +3218,8 @@ func (r AdminApiApiUpdateIdentityRequest) Execute() (*Identity, *http.Response,
  * This endpoint updates an identity. It is NOT possible to set an identity's credentials (password, ...)
 using this method! A way to achieve that will be introduced in the future.
 
-The full identity payload (except credentials) is expected. This endpoint does not support patching.
+The full identity payload (except credentials) is expected. Use `PATCH /identities/{id}` to update
+single traits.
 
 Learn how identities work in [ORY Kratos' User And Identity Model Documentation](https://www.ory.sh/docs/next/kratos/concepts/identity-user-model).
  * @param ctx context.Context - for authentication, logging, cancellation, deadlines, tracing, etc. Passed from http.Request or context.Background().
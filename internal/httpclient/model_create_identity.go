@@ -17,6 +17,7 @@ import (
 
 // CreateIdentity struct for CreateIdentity
 type CreateIdentity struct {
+	Credentials *CreateIdentityCredentials `json:"credentials,omitempty"`
 	// SchemaID is the ID of the JSON Schema to be used for validating the identity's traits.
 	SchemaId string `json:"schema_id"`
 	// Traits represent an identity's traits. The identity is able to create, modify, and delete traits in a self-service manner. The input will always be validated against the JSON Schema defined in `schema_url`.
@@ -42,6 +43,38 @@ func NewCreateIdentityWithDefaults() *CreateIdentity {
 	return &this
 }
 
+// GetCredentials returns the Credentials field value if set, zero value otherwise.
+func (o *CreateIdentity) GetCredentials() CreateIdentityCredentials {
+	if o == nil || o.Credentials == nil {
+		var ret CreateIdentityCredentials
+		return ret
+	}
+	return *o.Credentials
+}
+
+// GetCredentialsOk returns a tuple with the Credentials field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *CreateIdentity) GetCredentialsOk() (*CreateIdentityCredentials, bool) {
+	if o == nil || o.Credentials == nil {
+		return nil, false
+	}
+	return o.Credentials, true
+}
+
+// HasCredentials returns a boolean if a field has been set.
+func (o *CreateIdentity) HasCredentials() bool {
+	if o != nil && o.Credentials != nil {
+		return true
+	}
+
+	return false
+}
+
+// SetCredentials gets a reference to the given CreateIdentityCredentials and assigns it to the Credentials field.
+func (o *CreateIdentity) SetCredentials(v CreateIdentityCredentials) {
+	o.Credentials = &v
+}
+
 // GetSchemaId returns the SchemaId field value
 func (o *CreateIdentity) GetSchemaId() string {
 	if o == nil {
@@ -92,6 +125,9 @@ func (o *CreateIdentity) SetTraits(v map[string]interface{}) {
 
 func (o CreateIdentity) MarshalJSON() ([]byte, error) {
 	toSerialize := map[string]interface{}{}
+	if o.Credentials != nil {
+		toSerialize["credentials"] = o.Credentials
+	}
 	if true {
 		toSerialize["schema_id"] = o.SchemaId
 	}
@@ -20,10 +20,16 @@ import (
 type Session struct {
 	Active          *bool     `json:"active,omitempty"`
 	AuthenticatedAt time.Time `json:"authenticated_at"`
-	ExpiresAt       time.Time `json:"expires_at"`
-	Id              string    `json:"id"`
-	Identity        Identity  `json:"identity"`
-	IssuedAt        time.Time `json:"issued_at"`
+	// Devices lists the clients the session was issued to. It is only returned by the admin API.
+	Devices   []SessionDevice `json:"devices,omitempty"`
+	ExpiresAt time.Time       `json:"expires_at"`
+	Id        string          `json:"id"`
+	Identity  Identity        `json:"identity"`
+	// ImpersonatedBy is set if the session was issued to an administrator acting as the identity. It contains the administrator's ID as given to the impersonation endpoint.
+	ImpersonatedBy *string `json:"impersonated_by,omitempty"`
+	// ImpersonationReason is the reason the administrator gave for impersonating the identity.
+	ImpersonationReason *string   `json:"impersonation_reason,omitempty"`
+	IssuedAt            time.Time `json:"issued_at"`
 }
 
 // NewSession instantiates a new Session object
@@ -104,6 +110,38 @@ func (o *Session) SetAuthenticatedAt(v time.Time) {
 	o.AuthenticatedAt = v
 }
 
+// GetDevices returns the Devices field value if set, zero value otherwise.
+func (o *Session) GetDevices() []SessionDevice {
+	if o == nil || o.Devices == nil {
+		var ret []SessionDevice
+		return ret
+	}
+	return o.Devices
+}
+
+// GetDevicesOk returns a tuple with the Devices field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *Session) GetDevicesOk() ([]SessionDevice, bool) {
+	if o == nil || o.Devices == nil {
+		return nil, false
+	}
+	return o.Devices, true
+}
+
+// HasDevices returns a boolean if a field has been set.
+func (o *Session) HasDevices() bool {
+	if o != nil && o.Devices != nil {
+		return true
+	}
+
+	return false
+}
+
+// SetDevices gets a reference to the given []SessionDevice and assigns it to the Devices field.
+func (o *Session) SetDevices(v []SessionDevice) {
+	o.Devices = v
+}
+
 // GetExpiresAt returns the ExpiresAt field value
 func (o *Session) GetExpiresAt() time.Time {
 	if o == nil {
@@ -176,6 +214,70 @@ func (o *Session) SetIdentity(v Identity) {
 	o.Identity = v
 }
 
+// GetImpersonatedBy returns the ImpersonatedBy field value if set, zero value otherwise.
+func (o *Session) GetImpersonatedBy() string {
+	if o == nil || o.ImpersonatedBy == nil {
+		var ret string
+		return ret
+	}
+	return *o.ImpersonatedBy
+}
+
+// GetImpersonatedByOk returns a tuple with the ImpersonatedBy field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *Session) GetImpersonatedByOk() (*string, bool) {
+	if o == nil || o.ImpersonatedBy == nil {
+		return nil, false
+	}
+	return o.ImpersonatedBy, true
+}
+
+// HasImpersonatedBy returns a boolean if a field has been set.
+func (o *Session) HasImpersonatedBy() bool {
+	if o != nil && o.ImpersonatedBy != nil {
+		return true
+	}
+
+	return false
+}
+
+// SetImpersonatedBy gets a reference to the given string and assigns it to the ImpersonatedBy field.
+func (o *Session) SetImpersonatedBy(v string) {
+	o.ImpersonatedBy = &v
+}
+
+// GetImpersonationReason returns the ImpersonationReason field value if set, zero value otherwise.
+func (o *Session) GetImpersonationReason() string {
+	if o == nil || o.ImpersonationReason == nil {
+		var ret string
+		return ret
+	}
+	return *o.ImpersonationReason
+}
+
+// GetImpersonationReasonOk returns a tuple with the ImpersonationReason field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *Session) GetImpersonationReasonOk() (*string, bool) {
+	if o == nil || o.ImpersonationReason == nil {
+		return nil, false
+	}
+	return o.ImpersonationReason, true
+}
+
+// HasImpersonationReason returns a boolean if a field has been set.
+func (o *Session) HasImpersonationReason() bool {
+	if o != nil && o.ImpersonationReason != nil {
+		return true
+	}
+
+	return false
+}
+
+// SetImpersonationReason gets a reference to the given string and assigns it to the ImpersonationReason field.
+func (o *Session) SetImpersonationReason(v string) {
+	o.ImpersonationReason = &v
+}
+
 // GetIssuedAt returns the IssuedAt field value
 func (o *Session) GetIssuedAt() time.Time {
 	if o == nil {
@@ -208,6 +310,9 @@ func (o Session) MarshalJSON() ([]byte, error) {
 	if true {
 		toSerialize["authenticated_at"] = o.AuthenticatedAt
 	}
+	if o.Devices != nil {
+		toSerialize["devices"] = o.Devices
+	}
 	if true {
 		toSerialize["expires_at"] = o.ExpiresAt
 	}
@@ -217,6 +322,12 @@ func (o Session) MarshalJSON() ([]byte, error) {
 	if true {
 		toSerialize["identity"] = o.Identity
 	}
+	if o.ImpersonatedBy != nil {
+		toSerialize["impersonated_by"] = o.ImpersonatedBy
+	}
+	if o.ImpersonationReason != nil {
+		toSerialize["impersonation_reason"] = o.ImpersonationReason
+	}
 	if true {
 		toSerialize["issued_at"] = o.IssuedAt
 	}
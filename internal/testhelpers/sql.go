@@ -16,7 +16,9 @@ import (
 	"github.com/ory/kratos/selfservice/flow/registration"
 	"github.com/ory/kratos/selfservice/flow/settings"
 	"github.com/ory/kratos/selfservice/flow/verification"
+	"github.com/ory/kratos/selfservice/strategy/code"
 	"github.com/ory/kratos/selfservice/strategy/link"
+	"github.com/ory/kratos/selfservice/strategy/password"
 	"github.com/ory/kratos/session"
 )
 
@@ -32,6 +34,7 @@ func CleanSQL(t *testing.T, c *pop.Connection) {
 
 		new(link.RecoveryToken).TableName(ctx),
 		new(link.VerificationToken).TableName(ctx),
+		new(code.RecoveryCode).TableName(ctx),
 
 		new(recovery.Flow).TableName(ctx),
 
@@ -40,6 +43,8 @@ func CleanSQL(t *testing.T, c *pop.Connection) {
 		new(errorx.ErrorContainer).TableName(ctx),
 
 		new(session.Session).TableName(ctx),
+		new(identity.CredentialEvent).TableName(ctx),
+		new(password.LoginAttempts).TableName(ctx),
 		new(identity.CredentialIdentifierCollection).TableName(ctx),
 		new(identity.CredentialsCollection).TableName(ctx),
 		new(identity.VerifiableAddress).TableName(ctx),
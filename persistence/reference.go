@@ -20,7 +20,9 @@ import (
 	"github.com/ory/kratos/selfservice/flow/registration"
 	"github.com/ory/kratos/selfservice/flow/settings"
 	"github.com/ory/kratos/selfservice/flow/verification"
+	"github.com/ory/kratos/selfservice/strategy/code"
 	"github.com/ory/kratos/selfservice/strategy/link"
+	"github.com/ory/kratos/selfservice/strategy/password"
 	"github.com/ory/kratos/session"
 )
 
@@ -31,6 +33,7 @@ type Provider interface {
 type Persister interface {
 	continuity.Persister
 	identity.PrivilegedPool
+	identity.CredentialEventPersister
 	registration.FlowPersister
 	login.FlowPersister
 	settings.FlowPersister
@@ -41,6 +44,8 @@ type Persister interface {
 	recovery.FlowPersister
 	link.RecoveryTokenPersister
 	link.VerificationTokenPersister
+	code.RecoveryCodePersister
+	password.LoginAttemptsPersister
 
 	Close(context.Context) error
 	Ping() error
@@ -19,13 +19,16 @@ import (
 
 	"github.com/ory/kratos/driver"
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/internal/testhelpers"
 	"github.com/ory/kratos/selfservice/flow/login"
 	"github.com/ory/kratos/selfservice/flow/recovery"
 	"github.com/ory/kratos/selfservice/flow/registration"
 	"github.com/ory/kratos/selfservice/flow/settings"
 	"github.com/ory/kratos/selfservice/flow/verification"
+	"github.com/ory/kratos/selfservice/strategy/code"
 	"github.com/ory/kratos/selfservice/strategy/link"
+	"github.com/ory/kratos/selfservice/strategy/password"
 	"github.com/ory/kratos/session"
 	"github.com/ory/kratos/x"
 	"github.com/ory/x/configx"
@@ -262,6 +265,45 @@ func TestMigrations(t *testing.T) {
 					containsExpectedIds(t, filepath.Join("fixtures", "recovery_token"), found)
 				})
 
+				t.Run("case=recovery_code", func(t *testing.T) {
+					var ids []code.RecoveryCode
+					require.NoError(t, c.All(&ids))
+					require.NotEmpty(t, ids)
+
+					var found []string
+					for _, id := range ids {
+						found = append(found, id.ID.String())
+						compareWithFixture(t, id, "recovery_code", id.ID.String())
+					}
+					containsExpectedIds(t, filepath.Join("fixtures", "recovery_code"), found)
+				})
+
+				t.Run("case=credential_event", func(t *testing.T) {
+					var ids []identity.CredentialEvent
+					require.NoError(t, c.All(&ids))
+					require.NotEmpty(t, ids)
+
+					var found []string
+					for _, id := range ids {
+						found = append(found, id.ID.String())
+						compareWithFixture(t, id, "credential_event", id.ID.String())
+					}
+					containsExpectedIds(t, filepath.Join("fixtures", "credential_event"), found)
+				})
+
+				t.Run("case=login_attempts", func(t *testing.T) {
+					var ids []password.LoginAttempts
+					require.NoError(t, c.All(&ids))
+					require.NotEmpty(t, ids)
+
+					var found []string
+					for _, id := range ids {
+						found = append(found, id.ID.String())
+						compareWithFixture(t, id, "login_attempts", id.ID.String())
+					}
+					containsExpectedIds(t, filepath.Join("fixtures", "login_attempts"), found)
+				})
+
 				t.Run("suite=constraints", func(t *testing.T) {
 					sr, err := d.SettingsFlowPersister().GetSettingsFlow(context.Background(), x.ParseUUID("a79bfcf1-68ae-49de-8b23-4f96921b8341"))
 					require.NoError(t, err)
@@ -21,3 +21,6 @@ INSERT INTO identity_verifiable_addresses (id, nid, status, via, verified, value
 
 INSERT INTO identities (id, nid, schema_id, traits, created_at, updated_at) VALUES ('196d8c1e-4f04-40f0-94b3-5ec43996b28a', '884f556e-eb3a-4b9f-bee3-11345642c6c0', 'default', '{"email":"foobar@ory.sh"}', '2013-10-07 08:23:19', '2013-10-07 08:23:19');
 INSERT INTO identities (id, nid, schema_id, traits, created_at, updated_at) VALUES ('ed253b2c-48ed-4c58-9b6f-1dc963c30a66', '884f556e-eb3a-4b9f-bee3-11345642c6c0', 'default', '{"email":"bazbar@ory.sh"}', '2013-10-07 08:23:19', '2013-10-07 08:23:19');
+
+INSERT INTO identities (id, nid, schema_id, traits, created_at, updated_at) VALUES ('0b5a0b8e-5f7b-4e0e-8c3c-2d6a3f8f3b1e', '884f556e-eb3a-4b9f-bee3-11345642c6c0', 'default', '{"email":"primary@ory.sh","recovery_email":"recovery@ory.sh"}', '2013-10-07 08:23:19', '2013-10-07 08:23:19');
+INSERT INTO identity_recovery_addresses (id, nid, via, value, identity_id, created_at, updated_at) VALUES ('3f3a9c2e-6a0d-4d8e-9c55-0c1b0f8e2a71', '884f556e-eb3a-4b9f-bee3-11345642c6c0', 'email', 'recovery@ory.sh', '0b5a0b8e-5f7b-4e0e-8c3c-2d6a3f8f3b1e', '2013-10-07 08:23:19', '2013-10-07 08:23:19');
@@ -0,0 +1,2 @@
+INSERT INTO sessions (id, nid, issued_at, expires_at, authenticated_at, created_at, updated_at, token, identity_id, active, extra)
+VALUES ('7458af86-c1d8-401c-978a-8da89133f78b', '884f556e-eb3a-4b9f-bee3-11345642c6c0', '2013-10-07 08:23:19', '2013-10-07 08:23:19', '2013-10-07 08:23:19', '2013-10-07 08:23:19', '2013-10-07 08:23:19', '7458af86c1d8401c978a8da89133f78b', '5ff66179-c240-4703-b0d8-494592cefff5', true, '{"tenant_id":"acme"}');
@@ -0,0 +1,2 @@
+INSERT INTO credential_events (id, nid, identity_id, event, credentials_type, ip_address, created_at, updated_at)
+VALUES ('a2bb6f51-6c5d-4e0a-9d35-d1f7e5e2c3c8', '884f556e-eb3a-4b9f-bee3-11345642c6c0', '5ff66179-c240-4703-b0d8-494592cefff5', 'updated', 'password', '192.0.2.1', '2013-10-07 08:23:19', '2013-10-07 08:23:19');
@@ -0,0 +1,2 @@
+INSERT INTO identity_recovery_codes (id, nid, code, used, used_at, attempts, expires_at, issued_at, identity_recovery_address_id, selfservice_recovery_flow_id, created_at, updated_at)
+VALUES ('c8e4f4b0-2b0e-4e2e-9f5a-6a1f4d3b2c71', '884f556e-eb3a-4b9f-bee3-11345642c6c0', 'f0b6e1c3d2a4958776655443322110ffeeddccbbaa99887766554433221100aa', false, null, 2, '2013-10-07 08:23:19', '2013-10-07 08:23:19', '3f3a9c2e-6a0d-4d8e-9c55-0c1b0f8e2a71', '68fb4010-84a9-4d1e-9f92-2705978ee89e', '2013-10-07 08:23:19', '2013-10-07 08:23:19');
@@ -0,0 +1,2 @@
+INSERT INTO identity_login_attempts (id, nid, identifier, failed_attempts, window_started_at, locked_until, created_at, updated_at)
+VALUES ('5d3e2f0a-8c4b-4f1e-a7d2-9b6c1e0f3a58', '884f556e-eb3a-4b9f-bee3-11345642c6c0', 'lockout@ory.sh', 5, '2013-10-07 08:23:19', '2013-10-07 08:38:19', '2013-10-07 08:23:19', '2013-10-07 08:23:19');
@@ -0,0 +1 @@
+ALTER TABLE "sessions" ADD COLUMN "extra" json;
\ No newline at end of file
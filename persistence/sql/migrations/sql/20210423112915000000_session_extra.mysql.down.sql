@@ -0,0 +1 @@
+ALTER TABLE `sessions` DROP COLUMN `extra`;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE `sessions` ADD COLUMN `extra` JSON;
\ No newline at end of file
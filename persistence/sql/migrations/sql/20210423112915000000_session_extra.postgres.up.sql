@@ -0,0 +1 @@
+ALTER TABLE "sessions" ADD COLUMN "extra" jsonb;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "sessions" ADD COLUMN "extra" TEXT;
\ No newline at end of file
@@ -0,0 +1 @@
+UPDATE identity_credential_identifiers SET identifier = SUBSTR(identifier, 1, INSTR(identifier, '|') - 1) || ':' || SUBSTR(identifier, INSTR(identifier, '|') + 1) WHERE INSTR(identifier, '|') > 0 AND identity_credential_id IN (SELECT ic.id FROM identity_credentials ic INNER JOIN identity_credential_types ict ON ict.id = ic.identity_credential_type_id WHERE ict.name = 'oidc');
\ No newline at end of file
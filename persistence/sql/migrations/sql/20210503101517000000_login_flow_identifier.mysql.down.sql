@@ -0,0 +1,2 @@
+DROP INDEX `selfservice_login_flows_identifier_idx` ON `selfservice_login_flows`;
+ALTER TABLE `selfservice_login_flows` DROP COLUMN `identifier`;
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP INDEX "selfservice_login_flows_identifier_idx";
+ALTER TABLE "selfservice_login_flows" DROP COLUMN "identifier";
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE "selfservice_login_flows" ADD COLUMN "identifier" TEXT NOT NULL DEFAULT '';
+CREATE INDEX "selfservice_login_flows_identifier_idx" ON "selfservice_login_flows" (nid, identifier, expires_at);
\ No newline at end of file
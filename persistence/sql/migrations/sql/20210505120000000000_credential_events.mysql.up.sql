@@ -0,0 +1,14 @@
+CREATE TABLE `credential_events` (
+`id` char(36) NOT NULL,
+PRIMARY KEY(`id`),
+`nid` char(36) NOT NULL,
+`identity_id` char(36) NOT NULL,
+`event` VARCHAR (32) NOT NULL,
+`credentials_type` VARCHAR (32) NOT NULL DEFAULT '',
+`ip_address` VARCHAR (64) NOT NULL DEFAULT '',
+`created_at` DATETIME NOT NULL,
+`updated_at` DATETIME NOT NULL,
+FOREIGN KEY (`nid`) REFERENCES `networks` (`id`) ON DELETE cascade,
+FOREIGN KEY (`identity_id`) REFERENCES `identities` (`id`) ON DELETE cascade
+) ENGINE=InnoDB;
+CREATE INDEX `credential_events_identity_id_idx` ON `credential_events` (`identity_id`, `nid`, `created_at`);
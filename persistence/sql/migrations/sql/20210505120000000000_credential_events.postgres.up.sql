@@ -0,0 +1,14 @@
+CREATE TABLE "credential_events" (
+"id" UUID NOT NULL,
+PRIMARY KEY("id"),
+"nid" UUID NOT NULL,
+"identity_id" UUID NOT NULL,
+"event" VARCHAR (32) NOT NULL,
+"credentials_type" VARCHAR (32) NOT NULL DEFAULT '',
+"ip_address" VARCHAR (64) NOT NULL DEFAULT '',
+"created_at" timestamp NOT NULL,
+"updated_at" timestamp NOT NULL,
+FOREIGN KEY ("nid") REFERENCES "networks" ("id") ON DELETE cascade,
+FOREIGN KEY ("identity_id") REFERENCES "identities" ("id") ON DELETE cascade
+);
+CREATE INDEX "credential_events_identity_id_idx" ON "credential_events" (identity_id, nid, created_at);
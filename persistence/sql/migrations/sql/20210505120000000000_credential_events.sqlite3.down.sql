@@ -0,0 +1 @@
+DROP TABLE "credential_events";
\ No newline at end of file
@@ -0,0 +1,13 @@
+CREATE TABLE "credential_events" (
+"id" TEXT PRIMARY KEY,
+"nid" char(36) NOT NULL,
+"identity_id" char(36) NOT NULL,
+"event" TEXT NOT NULL,
+"credentials_type" TEXT NOT NULL DEFAULT '',
+"ip_address" TEXT NOT NULL DEFAULT '',
+"created_at" DATETIME NOT NULL,
+"updated_at" DATETIME NOT NULL,
+FOREIGN KEY (nid) REFERENCES networks (id) ON DELETE cascade,
+FOREIGN KEY (identity_id) REFERENCES identities (id) ON DELETE cascade
+);
+CREATE INDEX "credential_events_identity_id_idx" ON "credential_events" (identity_id, nid, created_at);
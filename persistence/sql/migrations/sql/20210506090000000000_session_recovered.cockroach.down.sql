@@ -0,0 +1 @@
+ALTER TABLE "sessions" DROP COLUMN "recovered";
\ No newline at end of file
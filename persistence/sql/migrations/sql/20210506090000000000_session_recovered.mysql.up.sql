@@ -0,0 +1 @@
+ALTER TABLE `sessions` ADD COLUMN `recovered` bool NOT NULL DEFAULT false;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "identity_verifiable_addresses" ADD COLUMN "last_verified_at" timestamp;
\ No newline at end of file
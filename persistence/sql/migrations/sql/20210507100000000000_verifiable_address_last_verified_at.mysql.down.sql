@@ -0,0 +1 @@
+ALTER TABLE `identity_verifiable_addresses` DROP COLUMN `last_verified_at`;
\ No newline at end of file
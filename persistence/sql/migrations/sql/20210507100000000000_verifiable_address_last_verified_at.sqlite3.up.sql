@@ -0,0 +1 @@
+ALTER TABLE "identity_verifiable_addresses" ADD COLUMN "last_verified_at" DATETIME;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "sessions" ADD COLUMN "fingerprint" json;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE `sessions` ADD COLUMN `fingerprint` JSON;
\ No newline at end of file
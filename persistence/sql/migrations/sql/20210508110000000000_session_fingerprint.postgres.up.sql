@@ -0,0 +1 @@
+ALTER TABLE "sessions" ADD COLUMN "fingerprint" jsonb;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "sessions" DROP COLUMN "fingerprint";
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "sessions" ADD COLUMN "fingerprint" TEXT;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE `sessions` DROP COLUMN `impersonation_reason`;
+ALTER TABLE `sessions` DROP COLUMN `impersonated_by`;
@@ -0,0 +1,2 @@
+ALTER TABLE `sessions` ADD COLUMN `impersonated_by` VARCHAR (255) NOT NULL DEFAULT '';
+ALTER TABLE `sessions` ADD COLUMN `impersonation_reason` VARCHAR (255) NOT NULL DEFAULT '';
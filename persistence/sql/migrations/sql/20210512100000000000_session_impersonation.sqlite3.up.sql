@@ -0,0 +1,2 @@
+ALTER TABLE "sessions" ADD COLUMN "impersonated_by" TEXT NOT NULL DEFAULT '';
+ALTER TABLE "sessions" ADD COLUMN "impersonation_reason" TEXT NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE `identities` ADD COLUMN `deleted_at` DATETIME;
@@ -0,0 +1 @@
+ALTER TABLE "identities" DROP COLUMN "deleted_at";
@@ -0,0 +1 @@
+ALTER TABLE `sessions` ADD COLUMN `ip_address` VARCHAR (64) NOT NULL DEFAULT '';
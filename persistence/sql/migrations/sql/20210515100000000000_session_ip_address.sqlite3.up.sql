@@ -0,0 +1 @@
+ALTER TABLE "sessions" ADD COLUMN "ip_address" TEXT NOT NULL DEFAULT '';
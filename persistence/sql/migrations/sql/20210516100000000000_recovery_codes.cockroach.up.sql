@@ -0,0 +1,19 @@
+CREATE TABLE "identity_recovery_codes" (
+"id" UUID NOT NULL,
+PRIMARY KEY("id"),
+"nid" UUID NOT NULL,
+"code" VARCHAR (64) NOT NULL,
+"used" bool NOT NULL DEFAULT 'false',
+"used_at" timestamp,
+"attempts" integer NOT NULL DEFAULT '0',
+"expires_at" timestamp NOT NULL,
+"issued_at" timestamp NOT NULL,
+"identity_recovery_address_id" UUID NOT NULL,
+"selfservice_recovery_flow_id" UUID NOT NULL,
+"created_at" timestamp NOT NULL,
+"updated_at" timestamp NOT NULL,
+FOREIGN KEY ("nid") REFERENCES "networks" ("id") ON DELETE cascade,
+FOREIGN KEY ("identity_recovery_address_id") REFERENCES "identity_recovery_addresses" ("id") ON DELETE cascade,
+FOREIGN KEY ("selfservice_recovery_flow_id") REFERENCES "selfservice_recovery_flows" ("id") ON DELETE cascade
+);
+CREATE INDEX "identity_recovery_codes_flow_id_idx" ON "identity_recovery_codes" (selfservice_recovery_flow_id, nid);
@@ -0,0 +1,18 @@
+CREATE TABLE "identity_recovery_codes" (
+"id" TEXT PRIMARY KEY,
+"nid" char(36) NOT NULL,
+"code" TEXT NOT NULL,
+"used" bool NOT NULL DEFAULT 'false',
+"used_at" DATETIME,
+"attempts" INTEGER NOT NULL DEFAULT '0',
+"expires_at" DATETIME NOT NULL,
+"issued_at" DATETIME NOT NULL,
+"identity_recovery_address_id" char(36) NOT NULL,
+"selfservice_recovery_flow_id" char(36) NOT NULL,
+"created_at" DATETIME NOT NULL,
+"updated_at" DATETIME NOT NULL,
+FOREIGN KEY (nid) REFERENCES networks (id) ON DELETE cascade,
+FOREIGN KEY (identity_recovery_address_id) REFERENCES identity_recovery_addresses (id) ON DELETE cascade,
+FOREIGN KEY (selfservice_recovery_flow_id) REFERENCES selfservice_recovery_flows (id) ON DELETE cascade
+);
+CREATE INDEX "identity_recovery_codes_flow_id_idx" ON "identity_recovery_codes" (selfservice_recovery_flow_id, nid);
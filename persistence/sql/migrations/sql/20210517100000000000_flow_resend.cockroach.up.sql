@@ -0,0 +1,4 @@
+ALTER TABLE "selfservice_recovery_flows" ADD COLUMN "last_sent_at" timestamp;
+ALTER TABLE "selfservice_recovery_flows" ADD COLUMN "resend_count" INT NOT NULL DEFAULT 0;
+ALTER TABLE "selfservice_verification_flows" ADD COLUMN "last_sent_at" timestamp;
+ALTER TABLE "selfservice_verification_flows" ADD COLUMN "resend_count" INT NOT NULL DEFAULT 0;
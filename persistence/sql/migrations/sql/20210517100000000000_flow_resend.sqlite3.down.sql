@@ -0,0 +1,4 @@
+ALTER TABLE "selfservice_verification_flows" DROP COLUMN "resend_count";
+ALTER TABLE "selfservice_verification_flows" DROP COLUMN "last_sent_at";
+ALTER TABLE "selfservice_recovery_flows" DROP COLUMN "resend_count";
+ALTER TABLE "selfservice_recovery_flows" DROP COLUMN "last_sent_at";
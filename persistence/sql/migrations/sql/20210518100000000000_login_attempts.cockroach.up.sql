@@ -0,0 +1,13 @@
+CREATE TABLE "identity_login_attempts" (
+"id" UUID NOT NULL,
+PRIMARY KEY("id"),
+"nid" UUID NOT NULL,
+"identifier" VARCHAR (255) NOT NULL,
+"failed_attempts" integer NOT NULL DEFAULT '0',
+"window_started_at" timestamp NOT NULL,
+"locked_until" timestamp,
+"created_at" timestamp NOT NULL,
+"updated_at" timestamp NOT NULL,
+FOREIGN KEY ("nid") REFERENCES "networks" ("id") ON DELETE cascade
+);
+CREATE UNIQUE INDEX "identity_login_attempts_identifier_uq_idx" ON "identity_login_attempts" (nid, identifier);
@@ -0,0 +1,13 @@
+CREATE TABLE `identity_login_attempts` (
+`id` char(36) NOT NULL,
+PRIMARY KEY(`id`),
+`nid` char(36) NOT NULL,
+`identifier` VARCHAR (255) NOT NULL,
+`failed_attempts` INTEGER NOT NULL DEFAULT 0,
+`window_started_at` DATETIME NOT NULL,
+`locked_until` DATETIME,
+`created_at` DATETIME NOT NULL,
+`updated_at` DATETIME NOT NULL,
+FOREIGN KEY (`nid`) REFERENCES `networks` (`id`) ON DELETE cascade
+) ENGINE=InnoDB;
+CREATE UNIQUE INDEX `identity_login_attempts_identifier_uq_idx` ON `identity_login_attempts` (`nid`, `identifier`);
@@ -0,0 +1 @@
+ALTER TABLE "selfservice_login_flows" ADD COLUMN "link_credentials" TEXT;
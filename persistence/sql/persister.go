@@ -21,6 +21,7 @@ import (
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/persistence"
 	"github.com/ory/kratos/schema"
+	"github.com/ory/kratos/session"
 	"github.com/ory/kratos/x"
 )
 
@@ -33,6 +34,11 @@ type (
 	persisterDependencies interface {
 		IdentityTraitsSchemas(ctx context.Context) schema.Schemas
 		identity.ValidationProvider
+		identity.TraitsCipherProvider
+		identity.CacheProvider
+		identity.AuditSinkProvider
+		session.WhoamiCacheProvider
+		session.SessionCacheProvider
 		x.LoggingProvider
 		config.Provider
 		x.TracingProvider
@@ -80,7 +86,7 @@ func (p *Persister) DetermineNetwork(ctx context.Context) (*networkx.Network, er
 }
 
 func (p *Persister) Connection(ctx context.Context) *pop.Connection {
-	return p.c.WithContext(ctx)
+	return p.connection(ctx)
 }
 
 func (p *Persister) MigrationStatus(ctx context.Context) (popx.MigrationStatuses, error) {
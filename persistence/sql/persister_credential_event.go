@@ -0,0 +1,52 @@
+package sql
+
+import (
+	"context"
+
+	"github.com/gobuffalo/pop/v5"
+	"github.com/gofrs/uuid"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/corp"
+	"github.com/ory/kratos/identity"
+)
+
+var _ identity.CredentialEventPersister = new(Persister)
+
+func (p *Persister) CreateCredentialEvents(ctx context.Context, events ...*identity.CredentialEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	return p.Transaction(ctx, func(ctx context.Context, tx *pop.Connection) error {
+		for _, e := range events {
+			e.NID = corp.ContextualizeNID(ctx, p.nid)
+			if err := tx.Create(e); err != nil {
+				return sqlcon.HandleError(err)
+			}
+		}
+		return nil
+	})
+}
+
+func (p *Persister) ListCredentialEvents(ctx context.Context, identityID uuid.UUID, page, perPage int) ([]identity.CredentialEvent, error) {
+	es := make([]identity.CredentialEvent, 0)
+	if err := p.GetConnection(ctx).
+		Where("identity_id = ? AND nid = ?", identityID, corp.ContextualizeNID(ctx, p.nid)).
+		Paginate(page, perPage).Order("created_at DESC").
+		All(&es); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+	return es, nil
+}
+
+func (p *Persister) CountCredentialEvents(ctx context.Context, identityID uuid.UUID) (int64, error) {
+	count, err := p.GetConnection(ctx).
+		Where("identity_id = ? AND nid = ?", identityID, corp.ContextualizeNID(ctx, p.nid)).
+		Count(new(identity.CredentialEvent))
+	if err != nil {
+		return 0, sqlcon.HandleError(err)
+	}
+	return int64(count), nil
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/ory/herodot"
 	"github.com/ory/x/sqlcon"
 
+	"github.com/ory/kratos/schema"
 	"github.com/ory/kratos/selfservice/errorx"
 )
 
@@ -86,6 +87,8 @@ func (p *Persister) encodeSelfServiceErrors(errs []error) (*bytes.Buffer, error)
 
 		if c := new(herodot.DefaultError); errors.As(e, &c) {
 			es[k] = c
+		} else if c := new(schema.ValidationError); errors.As(e, &c) {
+			es[k] = c
 		} else if c := new(jsonschema.ValidationError); errors.As(e, &c) {
 			es[k] = c
 		} else {
@@ -18,6 +18,7 @@ import (
 	"github.com/ory/x/logrusx"
 
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/session"
 )
 
 type logRegistryOnly struct {
@@ -33,6 +34,22 @@ func (l *logRegistryOnly) IdentityValidator() *identity.Validator {
 	panic("implement me")
 }
 
+func (l *logRegistryOnly) IdentityTraitsCipher() *identity.TraitsCipher {
+	panic("implement me")
+}
+
+func (l *logRegistryOnly) IdentityCache() *identity.Cache {
+	return nil
+}
+
+func (l *logRegistryOnly) SessionWhoamiCache() *session.WhoamiCache {
+	return session.NewWhoamiCache()
+}
+
+func (l *logRegistryOnly) SessionCache() session.SessionCache {
+	return session.NoopSessionCache{}
+}
+
 func (l *logRegistryOnly) Logger() *logrusx.Logger {
 	if l.l == nil {
 		l.l = logrusx.New("kratos", "testing")
@@ -48,6 +65,10 @@ func (l *logRegistryOnly) Audit() *logrusx.Logger {
 	panic("implement me")
 }
 
+func (l *logRegistryOnly) IdentityAuditSink() identity.AuditSink {
+	return identity.NewLogAuditSink(l)
+}
+
 func (l *logRegistryOnly) Tracer(ctx context.Context) *tracing.Tracer {
 	return nil
 }
@@ -3,7 +3,9 @@ package sql
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"reflect"
 	"strings"
 	"time"
 
@@ -14,6 +16,7 @@ import (
 
 	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/otp"
+	"github.com/ory/kratos/session"
 	"github.com/ory/kratos/x"
 
 	"github.com/gobuffalo/pop/v5"
@@ -94,6 +97,11 @@ WHERE ici.identifier = ?
 		return nil, nil, err
 	}
 
+	// Identities which deleted their account must not be able to sign in anymore.
+	if i.IsDeleted() {
+		return nil, nil, errors.WithStack(sqlcon.ErrNoRows)
+	}
+
 	creds, ok := i.GetCredentials(ct)
 	if !ok {
 		return nil, nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("The SQL adapter failed to return the appropriate credentials_type \"%s\". This is a bug in the code.", ct))
@@ -180,6 +188,86 @@ func (p *Persister) createRecoveryAddresses(ctx context.Context, i *identity.Ide
 	return nil
 }
 
+// reconcileVerifiableAddresses makes the stored verifiable addresses match the identity's addresses. Unlike
+// deleting and re-creating all addresses, this keeps pending verification tokens of unchanged addresses.
+func (p *Persister) reconcileVerifiableAddresses(ctx context.Context, i *identity.Identity) error {
+	var stored []identity.VerifiableAddress
+	if err := p.GetConnection(ctx).Where("identity_id = ? AND nid = ?", i.ID, corp.ContextualizeNID(ctx, p.nid)).All(&stored); err != nil {
+		return err
+	}
+
+	keep := make(map[uuid.UUID]bool, len(i.VerifiableAddresses))
+	for _, a := range i.VerifiableAddresses {
+		keep[a.ID] = true
+	}
+
+	exists := make(map[uuid.UUID]bool, len(stored))
+	for k := range stored {
+		if !keep[stored[k].ID] {
+			if err := p.GetConnection(ctx).Destroy(&stored[k]); err != nil {
+				return err
+			}
+			continue
+		}
+		exists[stored[k].ID] = true
+	}
+
+	for k := range i.VerifiableAddresses {
+		i.VerifiableAddresses[k].IdentityID = i.ID
+		i.VerifiableAddresses[k].NID = corp.ContextualizeNID(ctx, p.nid)
+		if exists[i.VerifiableAddresses[k].ID] {
+			if err := p.update(ctx, &i.VerifiableAddresses[k]); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := p.GetConnection(ctx).Create(&i.VerifiableAddresses[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reconcileRecoveryAddresses makes the stored recovery addresses match the identity's addresses. Unlike
+// deleting and re-creating all addresses, this keeps pending recovery tokens of unchanged addresses.
+func (p *Persister) reconcileRecoveryAddresses(ctx context.Context, i *identity.Identity) error {
+	var stored []identity.RecoveryAddress
+	if err := p.GetConnection(ctx).Where("identity_id = ? AND nid = ?", i.ID, corp.ContextualizeNID(ctx, p.nid)).All(&stored); err != nil {
+		return err
+	}
+
+	keep := make(map[uuid.UUID]bool, len(i.RecoveryAddresses))
+	for _, a := range i.RecoveryAddresses {
+		keep[a.ID] = true
+	}
+
+	exists := make(map[uuid.UUID]bool, len(stored))
+	for k := range stored {
+		if !keep[stored[k].ID] {
+			if err := p.GetConnection(ctx).Destroy(&stored[k]); err != nil {
+				return err
+			}
+			continue
+		}
+		exists[stored[k].ID] = true
+	}
+
+	for k := range i.RecoveryAddresses {
+		i.RecoveryAddresses[k].IdentityID = i.ID
+		i.RecoveryAddresses[k].NID = corp.ContextualizeNID(ctx, p.nid)
+		if exists[i.RecoveryAddresses[k].ID] {
+			if err := p.update(ctx, &i.RecoveryAddresses[k]); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := p.GetConnection(ctx).Create(&i.RecoveryAddresses[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (p *Persister) findVerifiableAddresses(ctx context.Context, i *identity.Identity) error {
 	var addresses []identity.VerifiableAddress
 	if err := p.GetConnection(ctx).Where("identity_id = ? AND nid = ?", i.ID, corp.ContextualizeNID(ctx, p.nid)).Order("id ASC").All(&addresses); err != nil {
@@ -199,7 +287,7 @@ func (p *Persister) findRecoveryAddresses(ctx context.Context, i *identity.Ident
 }
 
 func (p *Persister) CountIdentities(ctx context.Context) (int64, error) {
-	count, err := p.c.WithContext(ctx).Where("nid = ?", corp.ContextualizeNID(ctx, p.nid)).Count(new(identity.Identity))
+	count, err := p.GetConnection(ctx).Where("nid = ? AND deleted_at IS NULL", corp.ContextualizeNID(ctx, p.nid)).Count(new(identity.Identity))
 	if err != nil {
 		return 0, sqlcon.HandleError(err)
 	}
@@ -225,8 +313,21 @@ func (p *Persister) CreateIdentity(ctx context.Context, i *identity.Identity) er
 		return err
 	}
 
+	if i.ID == uuid.Nil {
+		i.ID = x.NewUUID()
+	}
+
+	encrypted, err := p.r.IdentityTraitsCipher().Encrypt(ctx, i)
+	if err != nil {
+		return err
+	}
+
 	return p.Transaction(ctx, func(ctx context.Context, tx *pop.Connection) error {
-		if err := tx.Create(i); err != nil {
+		plaintext := i.Traits
+		i.Traits = encrypted
+		err := tx.Create(i)
+		i.Traits = plaintext
+		if err != nil {
 			return sqlcon.HandleError(err)
 		}
 
@@ -238,38 +339,197 @@ func (p *Persister) CreateIdentity(ctx context.Context, i *identity.Identity) er
 			return sqlcon.HandleError(err)
 		}
 
-		return p.createIdentityCredentials(ctx, i)
+		if err := p.createIdentityCredentials(ctx, i); err != nil {
+			return err
+		}
+
+		return p.auditIdentityChange(ctx, identity.AuditOperationCreate, i.ID, identity.Traits("{}"), i.Traits)
+	})
+}
+
+// errBatchRolledBack rolls back the transaction of a batch which must be created all or nothing.
+var errBatchRolledBack = errors.New("the batch was rolled back")
+
+func (p *Persister) CreateIdentities(ctx context.Context, is []*identity.Identity, allOrNothing bool) ([]error, error) {
+	errs := make([]error, len(is))
+	err := p.Transaction(ctx, func(ctx context.Context, tx *pop.Connection) error {
+		var failed bool
+		for k, i := range is {
+			// Savepoints keep the transaction usable after an identity failed, for example because one of
+			// its identifiers is already taken.
+			if err := tx.RawQuery("SAVEPOINT create_identity").Exec(); err != nil {
+				return sqlcon.HandleError(err)
+			}
+
+			mark := afterCommitMark(ctx)
+			if errs[k] = p.CreateIdentity(ctx, i); errs[k] != nil {
+				failed = true
+				discardAfterCommit(ctx, mark)
+				if err := tx.RawQuery("ROLLBACK TO SAVEPOINT create_identity").Exec(); err != nil {
+					return sqlcon.HandleError(err)
+				}
+				continue
+			}
+
+			if err := tx.RawQuery("RELEASE SAVEPOINT create_identity").Exec(); err != nil {
+				return sqlcon.HandleError(err)
+			}
+		}
+
+		if allOrNothing && failed {
+			return errors.WithStack(errBatchRolledBack)
+		}
+		return nil
 	})
+	if errors.Is(err, errBatchRolledBack) {
+		for k := range errs {
+			if errs[k] == nil {
+				errs[k] = errors.WithStack(identity.ErrBatchRolledBack)
+			}
+		}
+		return errs, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return errs, nil
 }
 
 func (p *Persister) ListIdentities(ctx context.Context, page, perPage int) ([]identity.Identity, error) {
 	is := make([]identity.Identity, 0)
 
 	/* #nosec G201 TableName is static */
-	if err := sqlcon.HandleError(p.GetConnection(ctx).Where("nid = ?", corp.ContextualizeNID(ctx, p.nid)).
+	if err := sqlcon.HandleError(p.GetConnection(ctx).Where("nid = ? AND deleted_at IS NULL", corp.ContextualizeNID(ctx, p.nid)).
 		Paginate(page, perPage).Order("id DESC").
 		All(&is)); err != nil {
 		return nil, err
 	}
 
+	if err := p.hydrateIdentities(ctx, is); err != nil {
+		return nil, err
+	}
+
+	return is, nil
+}
+
+func (p *Persister) ListIdentitiesPage(ctx context.Context, opts identity.ListIdentitiesOptions) ([]identity.Identity, string, string, error) {
+	size := opts.PageSize
+	if size < 1 {
+		size = 1
+	}
+
+	var token *identity.PageToken
+	if opts.PageToken != "" {
+		var err error
+		if token, err = identity.ParsePageToken(opts.PageToken); err != nil {
+			return nil, "", "", err
+		}
+	}
+
+	nid := corp.ContextualizeNID(ctx, p.nid)
+	is := make([]identity.Identity, 0)
+	q := p.GetConnection(ctx).Where("nid = ? AND deleted_at IS NULL", nid)
+	if opts.CredentialsIdentifier != "" {
+		// Identifiers such as emails are stored in lower case while others such as OIDC subjects are stored as is.
+		q = q.Where(p.credentialsIdentifierSubquery(ctx, "ici.identifier IN (?, ?)"),
+			nid, nid, opts.CredentialsIdentifier, strings.ToLower(opts.CredentialsIdentifier))
+	}
+	if opts.CredentialsIdentifierSimilar != "" {
+		q = q.Where(p.credentialsIdentifierSubquery(ctx, "LOWER(ici.identifier) LIKE ? ESCAPE '!'"),
+			nid, nid, "%"+likeEscaper.Replace(strings.ToLower(opts.CredentialsIdentifierSimilar))+"%")
+	}
+
+	backwards := token != nil && token.Backwards
+	if backwards {
+		q = q.Where("(created_at < ? OR (created_at = ? AND id < ?))", token.CreatedAt, token.CreatedAt, token.ID).
+			Order("created_at DESC, id DESC")
+	} else if token != nil {
+		q = q.Where("(created_at > ? OR (created_at = ? AND id > ?))", token.CreatedAt, token.CreatedAt, token.ID).
+			Order("created_at ASC, id ASC")
+	} else {
+		q = q.Order("created_at ASC, id ASC")
+	}
+
+	// Fetching one more identity than requested tells whether there is another page in this direction.
+	if err := sqlcon.HandleError(q.Limit(size + 1).All(&is)); err != nil {
+		return nil, "", "", err
+	}
+
+	more := len(is) > size
+	if more {
+		is = is[:size]
+	}
+
+	if backwards {
+		for l, r := 0, len(is)-1; l < r; l, r = l+1, r-1 {
+			is[l], is[r] = is[r], is[l]
+		}
+	}
+
+	// Coming from a page means there is another page in the opposite direction.
+	hasNext, hasPrev := more, token != nil
+	if backwards {
+		hasNext, hasPrev = true, more
+	}
+
+	var next, prev string
+	if len(is) > 0 && hasNext {
+		next = identity.NewPageToken(&is[len(is)-1], false).Encode()
+	}
+	if len(is) > 0 && hasPrev {
+		prev = identity.NewPageToken(&is[0], true).Encode()
+	}
+
+	if err := p.hydrateIdentities(ctx, is); err != nil {
+		return nil, "", "", err
+	}
+
+	return is, next, prev, nil
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern using the escape character `!`.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// credentialsIdentifierSubquery returns the condition matching identities with a credentials identifier
+// matching the given condition. The condition's arguments follow the network ID of the credentials and
+// identifiers.
+func (p *Persister) credentialsIdentifierSubquery(ctx context.Context, condition string) string {
+	// #nosec G201 -- the table names and condition are static
+	return fmt.Sprintf(`id IN (SELECT
+    ic.identity_id
+FROM %s ic
+         INNER JOIN %s ici on ic.id = ici.identity_credential_id
+WHERE ic.nid = ?
+  AND ici.nid = ?
+  AND %s)`,
+		corp.ContextualizeTableName(ctx, "identity_credentials"),
+		corp.ContextualizeTableName(ctx, "identity_credential_identifiers"),
+		condition,
+	)
+}
+
+// hydrateIdentities loads the addresses of the listed identities and decrypts their traits.
+func (p *Persister) hydrateIdentities(ctx context.Context, is []identity.Identity) error {
 	for k := range is {
 		i := &is[k]
 		if err := p.findVerifiableAddresses(ctx, i); err != nil {
-			return nil, sqlcon.HandleError(err)
+			return sqlcon.HandleError(err)
 		}
 
 		if err := p.findRecoveryAddresses(ctx, i); err != nil {
-			return nil, sqlcon.HandleError(err)
+			return sqlcon.HandleError(err)
 		}
 
 		if err := p.injectTraitsSchemaURL(ctx, i); err != nil {
-			return nil, err
+			return err
 		}
 
-		is[k] = *i
+		if err := p.r.IdentityTraitsCipher().Decrypt(ctx, i); err != nil {
+			return err
+		}
 	}
 
-	return is, nil
+	return nil
 }
 
 func (p *Persister) UpdateIdentity(ctx context.Context, i *identity.Identity) error {
@@ -277,47 +537,209 @@ func (p *Persister) UpdateIdentity(ctx context.Context, i *identity.Identity) er
 		return err
 	}
 
+	encrypted, err := p.r.IdentityTraitsCipher().Encrypt(ctx, i)
+	if err != nil {
+		return err
+	}
+
 	i.NID = corp.ContextualizeNID(ctx, p.nid)
-	return sqlcon.HandleError(p.Transaction(ctx, func(ctx context.Context, tx *pop.Connection) error {
-		if count, err := tx.Where("id = ? AND nid = ?", i.ID, corp.ContextualizeNID(ctx, p.nid)).Count(i); err != nil {
+	if err := sqlcon.HandleError(p.Transaction(ctx, func(ctx context.Context, tx *pop.Connection) error {
+		var before identity.Identity
+		if err := tx.Where("id = ? AND nid = ?", i.ID, corp.ContextualizeNID(ctx, p.nid)).First(&before); err != nil {
 			return err
-		} else if count == 0 {
-			return sql.ErrNoRows
 		}
 
-		for _, tn := range []string{
-			new(identity.Credentials).TableName(ctx),
-			new(identity.VerifiableAddress).TableName(ctx),
-			new(identity.RecoveryAddress).TableName(ctx),
-		} {
-			/* #nosec G201 TableName is static */
-			if err := tx.RawQuery(fmt.Sprintf(
-				`DELETE FROM %s WHERE identity_id = ? AND nid = ?`, tn), i.ID, corp.ContextualizeNID(ctx, p.nid)).Exec(); err != nil {
-				return err
-			}
+		/* #nosec G201 TableName is static */
+		if err := tx.RawQuery(fmt.Sprintf(
+			`DELETE FROM %s WHERE identity_id = ? AND nid = ?`, new(identity.Credentials).TableName(ctx)), i.ID, corp.ContextualizeNID(ctx, p.nid)).Exec(); err != nil {
+			return err
 		}
 
-		if err := p.update(WithTransaction(ctx, tx), i); err != nil {
+		plaintext := i.Traits
+		i.Traits = encrypted
+		err := p.update(WithTransaction(ctx, tx), i)
+		i.Traits = plaintext
+		if err != nil {
 			return err
 		}
 
-		if err := p.createVerifiableAddresses(ctx, i); err != nil {
+		if err := p.reconcileVerifiableAddresses(ctx, i); err != nil {
 			return err
 		}
 
-		if err := p.createRecoveryAddresses(ctx, i); err != nil {
+		if err := p.reconcileRecoveryAddresses(ctx, i); err != nil {
+			return err
+		}
+
+		if err := p.createIdentityCredentials(ctx, i); err != nil {
 			return err
 		}
 
-		return p.createIdentityCredentials(ctx, i)
-	}))
+		// The traits of the audit event stay encrypted if they can no longer be decrypted.
+		_ = p.r.IdentityTraitsCipher().Decrypt(ctx, &before)
+		return p.auditIdentityChange(ctx, identity.AuditOperationUpdate, i.ID, before.Traits, i.Traits)
+	})); err != nil {
+		return err
+	}
+
+	return p.invalidateCachedIdentity(ctx, i.NID, i.ID)
+}
+
+func (p *Persister) UpdateCredentialsConfig(ctx context.Context, identityID, credentialsID uuid.UUID, from, to sqlxx.JSONRawMessage) error {
+	nid := corp.ContextualizeNID(ctx, p.nid)
+	if err := p.Transaction(ctx, func(ctx context.Context, tx *pop.Connection) error {
+		var c identity.Credentials
+		if err := tx.Where("id = ? AND identity_id = ? AND nid = ?", credentialsID, identityID, nid).First(&c); err != nil {
+			return sqlcon.HandleError(err)
+		}
+
+		var current, expected interface{}
+		if err := json.Unmarshal(c.Config, &current); err != nil {
+			return errors.WithStack(err)
+		} else if err := json.Unmarshal(from, &expected); err != nil {
+			return errors.WithStack(err)
+		} else if !reflect.DeepEqual(current, expected) {
+			return errors.WithStack(sqlcon.ErrNoRows)
+		}
+
+		// The updated_at condition prevents overwriting credentials which were changed since they were read.
+		if count, err := tx.RawQuery(
+			// #nosec G201 TableName is static
+			fmt.Sprintf("UPDATE %s SET config = ?, updated_at = ? WHERE id = ? AND nid = ? AND updated_at = ?",
+				new(identity.Credentials).TableName(ctx)),
+			to, time.Now().UTC(), c.ID, nid, c.UpdatedAt).ExecWithCount(); err != nil {
+			return sqlcon.HandleError(err)
+		} else if count == 0 {
+			return errors.WithStack(sqlcon.ErrNoRows)
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return p.invalidateCachedIdentity(ctx, nid, identityID)
 }
 
 func (p *Persister) DeleteIdentity(ctx context.Context, id uuid.UUID) error {
-	return p.delete(ctx, new(identity.Identity), id)
+	if err := p.delete(ctx, new(identity.Identity), id); err != nil {
+		return err
+	}
+
+	if err := p.auditIdentityChange(ctx, identity.AuditOperationDelete, id, nil, nil); err != nil {
+		return err
+	}
+
+	return p.invalidateCachedIdentity(ctx, corp.ContextualizeNID(ctx, p.nid), id)
+}
+
+func (p *Persister) DeleteIdentitiesDeletedBefore(ctx context.Context, before time.Time) (int, error) {
+	var is []identity.Identity
+	if err := p.GetConnection(ctx).Select("id").
+		Where("nid = ? AND deleted_at IS NOT NULL AND deleted_at < ?", corp.ContextualizeNID(ctx, p.nid), before).
+		All(&is); err != nil {
+		return 0, sqlcon.HandleError(err)
+	}
+
+	for _, i := range is {
+		if err := p.DeleteIdentity(ctx, i.ID); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(is), nil
+}
+
+// auditIdentityChange writes the audit event of the identity change once the transaction of the
+// context was committed.
+func (p *Persister) auditIdentityChange(ctx context.Context, op identity.AuditOperation, id uuid.UUID, before, after identity.Traits) error {
+	e, err := identity.NewAuditEvent(ctx, op, id, before, after)
+	if err != nil {
+		return err
+	}
+
+	afterCommit(ctx, func() {
+		p.r.IdentityAuditSink().WriteAuditEvent(withoutTransaction(ctx), e)
+	})
+	return nil
+}
+
+// identityCache returns the identity cache unless the context uses a tenant's database. Tenants
+// share the network ID, so their identities can not be told apart by the cache.
+func (p *Persister) identityCache(ctx context.Context) *identity.Cache {
+	if _, ok := x.TenantConnectionFromContext(ctx); ok {
+		return nil
+	}
+	return p.r.IdentityCache()
+}
+
+// invalidateCachedIdentity removes the identity and its sessions from all caches.
+func (p *Persister) invalidateCachedIdentity(ctx context.Context, nid, id uuid.UUID) error {
+	if err := session.InvalidateCachedIdentity(ctx, p.r, id); err != nil {
+		return err
+	}
+	if err := p.identityCache(ctx).Invalidate(ctx, nid, id); err != nil {
+		return err
+	}
+
+	// Until the surrounding transaction commits, lookups still load the identity as it was before
+	// and could cache it under the new generation. It is therefore invalidated again after the commit.
+	if _, ok := ctx.Value(afterCommitKey).(*afterCommitHooks); ok {
+		afterCommit(ctx, func() {
+			if err := p.identityCache(ctx).Invalidate(withoutTransaction(ctx), nid, id); err != nil {
+				p.r.Logger().WithError(err).WithField("identity_id", id).Warn("Unable to invalidate cached identity.")
+			}
+		})
+	}
+	return nil
+}
+
+// getCachedIdentity returns the cached identity or nil if it is not cached. In the latter case, it also
+// returns the generation the identity must be cached with once it was loaded and false if it must not
+// be cached.
+func (p *Persister) getCachedIdentity(ctx context.Context, id uuid.UUID, confidential bool) (*identity.Identity, int64, bool) {
+	c, nid := p.identityCache(ctx), corp.ContextualizeNID(ctx, p.nid)
+	if c == nil {
+		return nil, 0, false
+	}
+
+	// The generation must be taken before the identity is looked up. Otherwise, an invalidation
+	// between the lookup and taking the generation would go unnoticed.
+	generation, err := c.Generation(ctx, nid, id)
+	if err != nil {
+		p.r.Logger().WithError(err).WithField("identity_id", id).Warn("Unable to look up identity in cache.")
+		return nil, 0, false
+	}
+
+	i, err := c.Get(ctx, nid, id, confidential)
+	if err != nil {
+		if !errors.Is(err, identity.ErrCacheMiss) {
+			p.r.Logger().WithError(err).WithField("identity_id", id).Warn("Unable to look up identity in cache.")
+		}
+		return nil, generation, true
+	}
+
+	return i, 0, false
+}
+
+func (p *Persister) setCachedIdentity(ctx context.Context, i *identity.Identity, confidential bool, generation int64) {
+	if err := p.identityCache(ctx).Set(ctx, i, confidential, generation); err != nil {
+		p.r.Logger().WithError(err).WithField("identity_id", i.ID).Warn("Unable to cache identity.")
+	}
 }
 
 func (p *Persister) GetIdentity(ctx context.Context, id uuid.UUID) (*identity.Identity, error) {
+	cached, generation, cache := p.getCachedIdentity(ctx, id, false)
+	if cached != nil {
+		if err := p.injectTraitsSchemaURL(ctx, cached); err != nil {
+			return nil, err
+		}
+		if err := p.r.IdentityTraitsCipher().Decrypt(ctx, cached); err != nil {
+			return nil, err
+		}
+		return cached, nil
+	}
+
 	var i identity.Identity
 	if err := p.GetConnection(ctx).Where("id = ? AND nid = ?", id, corp.ContextualizeNID(ctx, p.nid)).First(&i); err != nil {
 		return nil, sqlcon.HandleError(err)
@@ -333,14 +755,32 @@ func (p *Persister) GetIdentity(ctx context.Context, id uuid.UUID) (*identity.Id
 		return nil, sqlcon.HandleError(err)
 	}
 
+	if cache {
+		p.setCachedIdentity(ctx, &i, false, generation)
+	}
 	if err := p.injectTraitsSchemaURL(ctx, &i); err != nil {
 		return nil, err
 	}
 
+	if err := p.r.IdentityTraitsCipher().Decrypt(ctx, &i); err != nil {
+		return nil, err
+	}
+
 	return &i, nil
 }
 
 func (p *Persister) GetIdentityConfidential(ctx context.Context, id uuid.UUID) (*identity.Identity, error) {
+	cached, generation, cache := p.getCachedIdentity(ctx, id, true)
+	if cached != nil {
+		if err := p.injectTraitsSchemaURL(ctx, cached); err != nil {
+			return nil, err
+		}
+		if err := p.r.IdentityTraitsCipher().Decrypt(ctx, cached); err != nil {
+			return nil, err
+		}
+		return cached, nil
+	}
+
 	var i identity.Identity
 
 	nid := corp.ContextualizeNID(ctx, p.nid)
@@ -383,16 +823,25 @@ func (p *Persister) GetIdentityConfidential(ctx context.Context, id uuid.UUID) (
 		return nil, err
 	}
 
+	if cache {
+		p.setCachedIdentity(ctx, &i, true, generation)
+	}
 	if err := p.injectTraitsSchemaURL(ctx, &i); err != nil {
 		return nil, err
 	}
 
+	if err := p.r.IdentityTraitsCipher().Decrypt(ctx, &i); err != nil {
+		return nil, err
+	}
+
 	return &i, nil
 }
 
 func (p *Persister) FindVerifiableAddressByValue(ctx context.Context, via identity.VerifiableAddressType, value string) (*identity.VerifiableAddress, error) {
 	var address identity.VerifiableAddress
-	if err := p.GetConnection(ctx).Where("nid = ? AND via = ? AND value = ?", corp.ContextualizeNID(ctx, p.nid), via, value).First(&address); err != nil {
+	nid := corp.ContextualizeNID(ctx, p.nid)
+	if err := p.GetConnection(ctx).Where("nid = ? AND via = ? AND value = ?", nid, via, value).
+		Where(p.notDeletedIdentitySubquery(ctx), nid).First(&address); err != nil {
 		return nil, sqlcon.HandleError(err)
 	}
 
@@ -401,27 +850,43 @@ func (p *Persister) FindVerifiableAddressByValue(ctx context.Context, via identi
 
 func (p *Persister) FindRecoveryAddressByValue(ctx context.Context, via identity.RecoveryAddressType, value string) (*identity.RecoveryAddress, error) {
 	var address identity.RecoveryAddress
-	if err := p.GetConnection(ctx).Where("nid = ? AND via = ? AND value = ?", corp.ContextualizeNID(ctx, p.nid), via, value).First(&address); err != nil {
+	nid := corp.ContextualizeNID(ctx, p.nid)
+	if err := p.GetConnection(ctx).Where("nid = ? AND via = ? AND value = ?", nid, via, value).
+		Where(p.notDeletedIdentitySubquery(ctx), nid).First(&address); err != nil {
 		return nil, sqlcon.HandleError(err)
 	}
 
 	return &address, nil
 }
 
+// notDeletedIdentitySubquery matches addresses of identities which did not delete their account, so that
+// they are neither recovered nor verified.
+func (p *Persister) notDeletedIdentitySubquery(ctx context.Context) string {
+	// #nosec G201 -- the table name is static
+	return fmt.Sprintf("identity_id IN (SELECT id FROM %s WHERE nid = ? AND deleted_at IS NULL)",
+		corp.ContextualizeTableName(ctx, "identities"))
+}
+
 func (p *Persister) VerifyAddress(ctx context.Context, code string) error {
 	newCode, err := otp.New()
 	if err != nil {
 		return err
 	}
 
+	var addresses []identity.VerifiableAddress
+	if err := p.GetConnection(ctx).Where("nid = ? AND code = ?", corp.ContextualizeNID(ctx, p.nid), code).All(&addresses); err != nil {
+		return sqlcon.HandleError(err)
+	}
+
 	count, err := p.GetConnection(ctx).RawQuery(
 		/* #nosec G201 TableName is static */
 		fmt.Sprintf(
-			"UPDATE %s SET status = ?, verified = true, verified_at = ?, code = ? WHERE nid = ? AND code = ? AND expires_at > ?",
+			"UPDATE %s SET status = ?, verified = true, verified_at = ?, last_verified_at = ?, code = ? WHERE nid = ? AND code = ? AND expires_at > ?",
 			new(identity.VerifiableAddress).TableName(ctx),
 		),
 		identity.VerifiableAddressStatusCompleted,
 		time.Now().UTC().Round(time.Second),
+		time.Now().UTC().Round(time.Second),
 		newCode,
 		corp.ContextualizeNID(ctx, p.nid),
 		code,
@@ -435,12 +900,22 @@ func (p *Persister) VerifyAddress(ctx context.Context, code string) error {
 		return sqlcon.HandleError(sqlcon.ErrNoRows)
 	}
 
+	for _, address := range addresses {
+		if err := p.invalidateCachedIdentity(ctx, address.NID, address.IdentityID); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
 func (p *Persister) UpdateVerifiableAddress(ctx context.Context, address *identity.VerifiableAddress) error {
 	address.NID = corp.ContextualizeNID(ctx, p.nid)
-	return p.update(ctx, address)
+	if err := p.update(ctx, address); err != nil {
+		return err
+	}
+
+	return p.invalidateCachedIdentity(ctx, address.NID, address.IdentityID)
 }
 
 func (p *Persister) validateIdentity(ctx context.Context, i *identity.Identity) error {
@@ -2,6 +2,7 @@ package sql
 
 import (
 	"context"
+	"time"
 
 	"github.com/ory/kratos/corp"
 
@@ -38,6 +39,20 @@ func (p *Persister) GetLoginFlow(ctx context.Context, id uuid.UUID) (*login.Flow
 	return &r, nil
 }
 
+// CountActiveLoginFlowsByIdentifier counts the unexpired login flows which were submitted with the
+// identifier, not counting the flow with the given ID. Flows which completed with a successful login are
+// released from their identifier and not counted.
+func (p *Persister) CountActiveLoginFlowsByIdentifier(ctx context.Context, identifier string, except uuid.UUID) (int, error) {
+	count, err := p.GetConnection(ctx).
+		Where("nid = ? AND identifier = ? AND expires_at > ? AND id != ?",
+			corp.ContextualizeNID(ctx, p.nid), identifier, time.Now().UTC(), except).
+		Count(new(login.Flow))
+	if err != nil {
+		return 0, sqlcon.HandleError(err)
+	}
+	return count, nil
+}
+
 func (p *Persister) ForceLoginFlow(ctx context.Context, id uuid.UUID) error {
 	return p.Transaction(ctx, func(ctx context.Context, tx *pop.Connection) error {
 		lr, err := p.GetLoginFlow(ctx, id)
@@ -0,0 +1,83 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gobuffalo/pop/v5"
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/sqlcon"
+	"github.com/ory/x/sqlxx"
+
+	"github.com/ory/kratos/corp"
+	"github.com/ory/kratos/selfservice/strategy/password"
+	"github.com/ory/kratos/x"
+)
+
+var _ password.LoginAttemptsPersister = new(Persister)
+
+func (p *Persister) FindLoginAttempts(ctx context.Context, identifier string) (*password.LoginAttempts, error) {
+	var a password.LoginAttempts
+	if err := p.GetConnection(ctx).Where("identifier = ? AND nid = ?", identifier, corp.ContextualizeNID(ctx, p.nid)).First(&a); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+	return &a, nil
+}
+
+func (p *Persister) RecordFailedLoginAttempt(ctx context.Context, identifier string, maxAttempts int, window, lockoutDuration time.Duration) (*password.LoginAttempts, error) {
+	var a password.LoginAttempts
+
+	nid := corp.ContextualizeNID(ctx, p.nid)
+	now := time.Now().UTC()
+	if err := p.Transaction(ctx, func(ctx context.Context, tx *pop.Connection) error {
+		if err := sqlcon.HandleError(tx.Where("identifier = ? AND nid = ?", identifier, nid).First(&a)); errors.Is(err, sqlcon.ErrNoRows) {
+			a = password.LoginAttempts{ID: x.NewUUID(), NID: nid, Identifier: identifier, FailedAttempts: 1, WindowStartedAt: now}
+			if a.FailedAttempts >= maxAttempts {
+				a.LockedUntil = sqlxx.NullTime(now.Add(lockoutDuration))
+			}
+			return sqlcon.HandleError(tx.Create(&a))
+		} else if err != nil {
+			return err
+		}
+
+		if a.WindowStartedAt.Add(window).Before(now) || (!time.Time(a.LockedUntil).IsZero() && !a.IsLocked(now)) {
+			// The window or the lockout elapsed, counting starts over.
+			a.FailedAttempts = 0
+			a.WindowStartedAt = now
+			a.LockedUntil = sqlxx.NullTime{}
+			if err := tx.Update(&a); err != nil {
+				return sqlcon.HandleError(err)
+			}
+		}
+
+		// The counter is incremented by the database so that concurrent logins on other instances are not lost.
+		/* #nosec G201 TableName is static */
+		if err := tx.RawQuery(fmt.Sprintf("UPDATE %s SET failed_attempts=failed_attempts+1, updated_at=? WHERE id=? AND nid=?", a.TableName(ctx)),
+			now, a.ID, nid).Exec(); err != nil {
+			return sqlcon.HandleError(err)
+		}
+
+		if err := tx.Where("id = ? AND nid = ?", a.ID, nid).First(&a); err != nil {
+			return sqlcon.HandleError(err)
+		}
+
+		if a.FailedAttempts < maxAttempts || a.IsLocked(now) {
+			return nil
+		}
+
+		a.LockedUntil = sqlxx.NullTime(now.Add(lockoutDuration))
+		return sqlcon.HandleError(tx.Update(&a))
+	}); err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+func (p *Persister) ResetLoginAttempts(ctx context.Context, identifier string) error {
+	/* #nosec G201 TableName is static */
+	return sqlcon.HandleError(p.GetConnection(ctx).RawQuery(fmt.Sprintf("DELETE FROM %s WHERE identifier=? AND nid=?", new(password.LoginAttempts).TableName(ctx)),
+		identifier, corp.ContextualizeNID(ctx, p.nid)).Exec())
+}
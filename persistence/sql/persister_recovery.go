@@ -46,9 +46,20 @@ func (p *Persister) CreateRecoveryToken(ctx context.Context, token *link.Recover
 	token.Token = p.hmacValue(ctx, t)
 	token.NID = corp.ContextualizeNID(ctx, p.nid)
 
-	// This should not create the request eagerly because otherwise we might accidentally create an address that isn't
-	// supposed to be in the database.
-	if err := p.GetConnection(ctx).Create(token); err != nil {
+	if err := p.Transaction(ctx, func(ctx context.Context, tx *pop.Connection) error {
+		// Only the link which was sent last may be used.
+		if token.FlowID.Valid {
+			/* #nosec G201 TableName is static */
+			if err := tx.RawQuery(fmt.Sprintf("UPDATE %s SET used=true, used_at=? WHERE selfservice_recovery_flow_id=? AND nid=? AND NOT used", token.TableName(ctx)),
+				time.Now().UTC(), token.FlowID.UUID, token.NID).Exec(); err != nil {
+				return sqlcon.HandleError(err)
+			}
+		}
+
+		// This should not create the request eagerly because otherwise we might accidentally create an address that isn't
+		// supposed to be in the database.
+		return tx.Create(token)
+	}); err != nil {
 		return err
 	}
 
@@ -90,6 +101,24 @@ func (p *Persister) UseRecoveryToken(ctx context.Context, token string) (*link.R
 	return &rt, nil
 }
 
+// GetRecoveryToken returns the token without using it. Contrary to UseRecoveryToken, used tokens are
+// returned as well.
+func (p *Persister) GetRecoveryToken(ctx context.Context, token string) (*link.RecoveryToken, error) {
+	var rt link.RecoveryToken
+
+	nid := corp.ContextualizeNID(ctx, p.nid)
+	var err error
+	for _, secret := range p.r.Config(ctx).SecretsSession() {
+		if err = p.GetConnection(ctx).Where("token = ? AND nid = ?", p.hmacValueWithSecret(token, secret), nid).First(&rt); err == nil {
+			return &rt, nil
+		} else if !errors.Is(sqlcon.HandleError(err), sqlcon.ErrNoRows) {
+			break
+		}
+	}
+
+	return nil, sqlcon.HandleError(err)
+}
+
 func (p *Persister) DeleteRecoveryToken(ctx context.Context, token string) error {
 	/* #nosec G201 TableName is static */
 	return p.GetConnection(ctx).RawQuery(fmt.Sprintf("DELETE FROM %s WHERE token=? AND nid = ?", new(link.RecoveryToken).TableName(ctx)), token, corp.ContextualizeNID(ctx, p.nid)).Exec()
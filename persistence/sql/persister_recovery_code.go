@@ -0,0 +1,97 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gobuffalo/pop/v5"
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/corp"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/selfservice/strategy/code"
+)
+
+var _ code.RecoveryCodePersister = new(Persister)
+
+func (p *Persister) CreateRecoveryCode(ctx context.Context, rc *code.RecoveryCode) error {
+	c := rc.Code
+	rc.Code = p.hmacValue(ctx, c)
+	rc.NID = corp.ContextualizeNID(ctx, p.nid)
+
+	if err := p.Transaction(ctx, func(ctx context.Context, tx *pop.Connection) error {
+		// Only the code which was sent last may be used.
+		/* #nosec G201 TableName is static */
+		if err := tx.RawQuery(fmt.Sprintf("UPDATE %s SET used=true, used_at=? WHERE selfservice_recovery_flow_id=? AND nid=? AND NOT used", rc.TableName(ctx)),
+			time.Now().UTC(), rc.FlowID, rc.NID).Exec(); err != nil {
+			return sqlcon.HandleError(err)
+		}
+
+		return sqlcon.HandleError(tx.Create(rc))
+	}); err != nil {
+		return err
+	}
+
+	rc.Code = c
+	return nil
+}
+
+func (p *Persister) UseRecoveryCode(ctx context.Context, flowID uuid.UUID, value string, maxAttempts int) (*code.RecoveryCode, error) {
+	var rc code.RecoveryCode
+	var matched bool
+
+	nid := corp.ContextualizeNID(ctx, p.nid)
+	if err := p.Transaction(ctx, func(ctx context.Context, tx *pop.Connection) error {
+		if err := tx.Where("selfservice_recovery_flow_id = ? AND nid = ? AND NOT used", flowID, nid).First(&rc); err != nil {
+			return sqlcon.HandleError(err)
+		}
+
+		if rc.ExpiresAt.Before(time.Now()) {
+			return errors.WithStack(sqlcon.ErrNoRows)
+		}
+
+		now := time.Now().UTC()
+		if matched = p.hmacConstantCompare(ctx, value, rc.Code); !matched {
+			rc.Attempts++
+			rc.Used = rc.Attempts >= maxAttempts
+
+			/* #nosec G201 TableName is static */
+			if err := tx.RawQuery(fmt.Sprintf("UPDATE %s SET attempts=attempts+1 WHERE id=? AND nid=?", rc.TableName(ctx)), rc.ID, nid).Exec(); err != nil {
+				return sqlcon.HandleError(err)
+			}
+
+			// Invalidate the code once it was entered incorrectly too often.
+			/* #nosec G201 TableName is static */
+			return sqlcon.HandleError(tx.RawQuery(fmt.Sprintf("UPDATE %s SET used=true, used_at=? WHERE id=? AND nid=? AND attempts >= ?", rc.TableName(ctx)),
+				now, rc.ID, nid, maxAttempts).Exec())
+		}
+
+		/* #nosec G201 TableName is static */
+		count, err := tx.RawQuery(fmt.Sprintf("UPDATE %s SET used=true, used_at=? WHERE id=? AND nid=? AND NOT used", rc.TableName(ctx)), now, rc.ID, nid).ExecWithCount()
+		if err != nil {
+			return sqlcon.HandleError(err)
+		} else if count == 0 {
+			// The code was used concurrently.
+			return errors.WithStack(sqlcon.ErrNoRows)
+		}
+		rc.Used = true
+
+		var ra identity.RecoveryAddress
+		if err := tx.Where("id = ? AND nid = ?", rc.RecoveryAddressID, nid).First(&ra); err != nil {
+			return sqlcon.HandleError(err)
+		}
+		rc.RecoveryAddress = &ra
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if !matched {
+		return &rc, errors.WithStack(code.ErrInvalidRecoveryCode)
+	}
+	return &rc, nil
+}
@@ -3,6 +3,7 @@ package sql
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/gofrs/uuid"
 
 	"github.com/ory/x/sqlcon"
+	"github.com/ory/x/sqlxx"
 
 	"github.com/ory/kratos/session"
 )
@@ -41,25 +43,63 @@ func (p *Persister) CreateSession(ctx context.Context, s *session.Session) error
 }
 
 func (p *Persister) DeleteSession(ctx context.Context, sid uuid.UUID) error {
-	return p.delete(ctx, new(session.Session), sid)
+	if err := p.delete(ctx, new(session.Session), sid); err != nil {
+		return err
+	}
+
+	return session.InvalidateCachedSession(ctx, p.r, sid)
 }
 
-func (p *Persister) DeleteSessionsByIdentity(ctx context.Context, identityID uuid.UUID) error {
+func (p *Persister) DeleteSessionsByIdentity(ctx context.Context, identityID uuid.UUID) (int, error) {
 	// #nosec G201
 	count, err := p.GetConnection(ctx).RawQuery(fmt.Sprintf(
-		"DELETE FROM %s WHERE identity_id = ? AND nid = ?",
+		"DELETE FROM %s WHERE identity_id = ? AND nid = ? AND expires_at > ?",
 		corp.ContextualizeTableName(ctx, "sessions"),
 	),
 		identityID,
 		corp.ContextualizeNID(ctx, p.nid),
+		time.Now().UTC(),
 	).ExecWithCount()
 	if err != nil {
-		return sqlcon.HandleError(err)
+		return 0, sqlcon.HandleError(err)
 	}
-	if count == 0 {
-		return errors.WithStack(sqlcon.ErrNoRows)
+
+	if err := session.InvalidateCachedIdentity(ctx, p.r, identityID); err != nil {
+		return 0, err
 	}
-	return nil
+	return count, nil
+}
+
+func (p *Persister) ListSessionsByIdentity(ctx context.Context, identityID uuid.UUID, active *bool, page, perPage int) ([]*session.Session, int64, error) {
+	q := p.GetConnection(ctx).Where("identity_id = ? AND nid = ?", identityID, corp.ContextualizeNID(ctx, p.nid))
+	if active != nil && *active {
+		q = q.Where("active = ? AND expires_at > ?", true, time.Now().UTC())
+	} else if active != nil {
+		q = q.Where("(active = ? OR expires_at <= ?)", false, time.Now().UTC())
+	}
+
+	total, err := q.Count(new(session.Session))
+	if err != nil {
+		return nil, 0, sqlcon.HandleError(err)
+	}
+
+	ss := make([]*session.Session, 0)
+	// Pop's pages start at one.
+	if err := q.Paginate(page+1, perPage).Order("issued_at DESC, id DESC").All(&ss); err != nil {
+		return nil, 0, sqlcon.HandleError(err)
+	}
+
+	if len(ss) > 0 {
+		i, err := p.GetIdentity(ctx, identityID)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, s := range ss {
+			s.Identity = i
+		}
+	}
+
+	return ss, int64(total), nil
 }
 
 func (p *Persister) GetSessionByToken(ctx context.Context, token string) (*session.Session, error) {
@@ -93,6 +133,10 @@ func (p *Persister) DeleteSessionByToken(ctx context.Context, token string) erro
 	if err != nil {
 		return sqlcon.HandleError(err)
 	}
+
+	if err := session.InvalidateCachedToken(ctx, p.r, token); err != nil {
+		return err
+	}
 	if count == 0 {
 		return errors.WithStack(sqlcon.ErrNoRows)
 	}
@@ -111,6 +155,56 @@ func (p *Persister) RevokeSessionByToken(ctx context.Context, token string) erro
 	if err != nil {
 		return sqlcon.HandleError(err)
 	}
+
+	if err := session.InvalidateCachedToken(ctx, p.r, token); err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.WithStack(sqlcon.ErrNoRows)
+	}
+	return nil
+}
+
+func (p *Persister) UpdateSessionAuthenticatedAt(ctx context.Context, sid uuid.UUID, authenticatedAt time.Time) error {
+	// #nosec G201
+	count, err := p.GetConnection(ctx).RawQuery(fmt.Sprintf(
+		"UPDATE %s SET authenticated_at = ? WHERE id = ? AND nid = ?",
+		corp.ContextualizeTableName(ctx, "sessions"),
+	),
+		authenticatedAt,
+		sid,
+		corp.ContextualizeNID(ctx, p.nid),
+	).ExecWithCount()
+	if err != nil {
+		return sqlcon.HandleError(err)
+	}
+
+	if err := session.InvalidateCachedSession(ctx, p.r, sid); err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.WithStack(sqlcon.ErrNoRows)
+	}
+	return nil
+}
+
+func (p *Persister) UpdateSessionExtra(ctx context.Context, sid uuid.UUID, extra sqlxx.NullJSONRawMessage) error {
+	// #nosec G201
+	count, err := p.GetConnection(ctx).RawQuery(fmt.Sprintf(
+		"UPDATE %s SET extra = ? WHERE id = ? AND nid = ?",
+		corp.ContextualizeTableName(ctx, "sessions"),
+	),
+		extra,
+		sid,
+		corp.ContextualizeNID(ctx, p.nid),
+	).ExecWithCount()
+	if err != nil {
+		return sqlcon.HandleError(err)
+	}
+
+	if err := session.InvalidateCachedSession(ctx, p.r, sid); err != nil {
+		return err
+	}
 	if count == 0 {
 		return errors.WithStack(sqlcon.ErrNoRows)
 	}
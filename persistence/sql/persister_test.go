@@ -32,7 +32,9 @@ import (
 	registration "github.com/ory/kratos/selfservice/flow/registration/test"
 	settings "github.com/ory/kratos/selfservice/flow/settings/test"
 	verification "github.com/ory/kratos/selfservice/flow/verification/test"
+	code "github.com/ory/kratos/selfservice/strategy/code/test"
 	link "github.com/ory/kratos/selfservice/strategy/link/test"
+	password "github.com/ory/kratos/selfservice/strategy/password/test"
 	session "github.com/ory/kratos/session/test"
 	"github.com/ory/kratos/x"
 	"github.com/ory/x/sqlcon"
@@ -191,6 +193,14 @@ func TestPersister(t *testing.T) {
 				pop.SetLogger(pl(t))
 				link.TestPersister(ctx, conf, p)(t)
 			})
+			t.Run("contract=code.TestPersister", func(t *testing.T) {
+				pop.SetLogger(pl(t))
+				code.TestPersister(ctx, conf, p)(t)
+			})
+			t.Run("contract=password.TestPersister", func(t *testing.T) {
+				pop.SetLogger(pl(t))
+				password.TestPersister(ctx, conf, p)(t)
+			})
 			t.Run("contract=continuity.TestPersister", func(t *testing.T) {
 				pop.SetLogger(pl(t))
 				continuity.TestPersister(ctx, p)(t)
@@ -250,3 +260,104 @@ func TestPersister_Transaction(t *testing.T) {
 		assert.Equal(t, sqlcon.ErrNoRows.Error(), err.Error())
 	})
 }
+
+type auditRecorder struct {
+	sync.Mutex
+	events []*ri.AuditEvent
+}
+
+func (r *auditRecorder) WriteAuditEvent(_ context.Context, e *ri.AuditEvent) {
+	r.Lock()
+	defer r.Unlock()
+	r.events = append(r.events, e)
+}
+
+func (r *auditRecorder) reset() []*ri.AuditEvent {
+	r.Lock()
+	defer r.Unlock()
+	events := r.events
+	r.events = nil
+	return events
+}
+
+func TestPersister_IdentityAudit(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	testhelpers.SetDefaultIdentitySchema(t, conf, "file://./stub/identity.schema.json")
+	rec := new(auditRecorder)
+	reg.WithIdentityAuditSink(rec)
+	p := reg.Persister()
+	ctx := ri.WithAuditActor(context.Background(), "tester")
+
+	i := ri.NewIdentity("")
+	i.Traits = ri.Traits(`{"email":"audit@ory.sh","name":"a"}`)
+	require.NoError(t, p.CreateIdentity(ctx, i))
+
+	events := rec.reset()
+	require.Len(t, events, 1)
+	assert.Equal(t, ri.AuditOperationCreate, events[0].Operation)
+	assert.Equal(t, i.ID, events[0].IdentityID)
+	assert.Equal(t, "tester", events[0].Actor)
+
+	t.Run("case=should emit an event on update", func(t *testing.T) {
+		i.Traits = ri.Traits(`{"email":"audit@ory.sh","name":"b"}`)
+		require.NoError(t, p.UpdateIdentity(context.Background(), i))
+
+		events := rec.reset()
+		require.Len(t, events, 1)
+		assert.Equal(t, ri.AuditOperationUpdate, events[0].Operation)
+		assert.Equal(t, i.ID, events[0].IdentityID)
+		assert.Equal(t, ri.AuditActorSystem, events[0].Actor)
+		assert.Equal(t, []x.JSONPatch{{Op: "replace", Path: "/name", Value: []byte(`"b"`)}}, events[0].TraitsDiff)
+	})
+
+	t.Run("case=should emit the event once the transaction is committed", func(t *testing.T) {
+		require.NoError(t, p.Transaction(ctx, func(ctx context.Context, _ *pop.Connection) error {
+			i.Traits = ri.Traits(`{"email":"audit@ory.sh","name":"c"}`)
+			require.NoError(t, p.UpdateIdentity(ctx, i))
+			assert.Empty(t, rec.reset())
+			return nil
+		}))
+
+		events := rec.reset()
+		require.Len(t, events, 1)
+		assert.Equal(t, ri.AuditOperationUpdate, events[0].Operation)
+	})
+
+	t.Run("case=should not emit an event if the transaction is rolled back", func(t *testing.T) {
+		require.Error(t, p.Transaction(ctx, func(ctx context.Context, _ *pop.Connection) error {
+			i.Traits = ri.Traits(`{"email":"audit@ory.sh","name":"d"}`)
+			require.NoError(t, p.UpdateIdentity(ctx, i))
+			return errors.Errorf("rolling back")
+		}))
+		assert.Empty(t, rec.reset())
+
+		actual, err := p.GetIdentity(ctx, i.ID)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"email":"audit@ory.sh","name":"c"}`, string(actual.Traits))
+	})
+
+	t.Run("case=should not emit events of identities rolled back in a batch", func(t *testing.T) {
+		taken := ri.NewIdentity("")
+		taken.ID = i.ID
+		created := ri.NewIdentity("")
+		created.Traits = ri.Traits(`{"email":"audit-batch@ory.sh"}`)
+
+		errs, err := p.CreateIdentities(ctx, []*ri.Identity{taken, created}, false)
+		require.NoError(t, err)
+		require.Error(t, errs[0])
+		require.NoError(t, errs[1])
+
+		events := rec.reset()
+		require.Len(t, events, 1)
+		assert.Equal(t, created.ID, events[0].IdentityID)
+	})
+
+	t.Run("case=should emit an event on delete", func(t *testing.T) {
+		require.NoError(t, p.DeleteIdentity(ctx, i.ID))
+
+		events := rec.reset()
+		require.Len(t, events, 1)
+		assert.Equal(t, ri.AuditOperationDelete, events[0].Operation)
+		assert.Equal(t, i.ID, events[0].IdentityID)
+	})
+}
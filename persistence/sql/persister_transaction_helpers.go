@@ -4,11 +4,21 @@ import (
 	"context"
 
 	"github.com/gobuffalo/pop/v5"
+
+	"github.com/ory/kratos/x"
 )
 
 type transactionContextKey int
 
-const transactionKey transactionContextKey = 0
+const (
+	transactionKey transactionContextKey = iota
+	afterCommitKey
+)
+
+// afterCommitHooks are run once the outermost transaction started by Transaction was committed.
+type afterCommitHooks struct {
+	hooks []func()
+}
 
 func WithTransaction(ctx context.Context, tx *pop.Connection) context.Context {
 	return context.WithValue(ctx, transactionKey, tx)
@@ -22,9 +32,49 @@ func (p *Persister) Transaction(ctx context.Context, callback func(ctx context.C
 		}
 	}
 
-	return p.c.WithContext(ctx).Transaction(func(tx *pop.Connection) error {
-		return callback(WithTransaction(ctx, tx), tx)
-	})
+	hooks := new(afterCommitHooks)
+	if err := p.connection(ctx).Transaction(func(tx *pop.Connection) error {
+		return callback(context.WithValue(WithTransaction(ctx, tx), afterCommitKey, hooks), tx)
+	}); err != nil {
+		return err
+	}
+
+	for _, f := range hooks.hooks {
+		f()
+	}
+	return nil
+}
+
+// afterCommit runs f once the transaction of the context was committed and not at all if it is rolled
+// back. Outside of transactions started by Transaction, f runs right away.
+func afterCommit(ctx context.Context, f func()) {
+	if h, ok := ctx.Value(afterCommitKey).(*afterCommitHooks); ok {
+		h.hooks = append(h.hooks, f)
+		return
+	}
+	f()
+}
+
+// withoutTransaction returns the context without its transaction, for example to use it once the
+// transaction ended.
+func withoutTransaction(ctx context.Context) context.Context {
+	return context.WithValue(context.WithValue(ctx, transactionKey, nil), afterCommitKey, nil)
+}
+
+// afterCommitMark returns the number of hooks registered in the transaction of the context so far.
+func afterCommitMark(ctx context.Context) int {
+	if h, ok := ctx.Value(afterCommitKey).(*afterCommitHooks); ok {
+		return len(h.hooks)
+	}
+	return 0
+}
+
+// discardAfterCommit drops the hooks registered since the mark, for example because the savepoint
+// they were registered in was rolled back.
+func discardAfterCommit(ctx context.Context, mark int) {
+	if h, ok := ctx.Value(afterCommitKey).(*afterCommitHooks); ok && mark < len(h.hooks) {
+		h.hooks = h.hooks[:mark]
+	}
 }
 
 func (p *Persister) GetConnection(ctx context.Context) *pop.Connection {
@@ -34,5 +84,14 @@ func (p *Persister) GetConnection(ctx context.Context) *pop.Connection {
 			return conn.WithContext(ctx)
 		}
 	}
+	return p.connection(ctx)
+}
+
+// connection returns the connection to the tenant's database if the context has one and the
+// default connection otherwise.
+func (p *Persister) connection(ctx context.Context) *pop.Connection {
+	if c, ok := x.TenantConnectionFromContext(ctx); ok {
+		return c.WithContext(ctx)
+	}
 	return p.c.WithContext(ctx)
 }
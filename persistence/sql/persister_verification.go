@@ -47,11 +47,23 @@ func (p *Persister) CreateVerificationToken(ctx context.Context, token *link.Ver
 	token.Token = p.hmacValue(ctx, t)
 	token.NID = corp.ContextualizeNID(ctx, p.nid)
 
-	// This should not create the request eagerly because otherwise we might accidentally create an address that isn't
-	// supposed to be in the database.
-	if err := p.GetConnection(ctx).Create(token); err != nil {
+	if err := p.Transaction(ctx, func(ctx context.Context, tx *pop.Connection) error {
+		// Only the link which was sent last may be used.
+		if token.FlowID.Valid {
+			/* #nosec G201 TableName is static */
+			if err := tx.RawQuery(fmt.Sprintf("UPDATE %s SET used=true, used_at=? WHERE selfservice_verification_flow_id=? AND nid=? AND NOT used", token.TableName(ctx)),
+				time.Now().UTC(), token.FlowID.UUID, token.NID).Exec(); err != nil {
+				return sqlcon.HandleError(err)
+			}
+		}
+
+		// This should not create the request eagerly because otherwise we might accidentally create an address that isn't
+		// supposed to be in the database.
+		return tx.Create(token)
+	}); err != nil {
 		return err
 	}
+
 	token.Token = t
 	return nil
 }
@@ -90,6 +102,24 @@ func (p *Persister) UseVerificationToken(ctx context.Context, token string) (*li
 	return &rt, nil
 }
 
+// GetVerificationToken returns the token without using it. Contrary to UseVerificationToken, used tokens are
+// returned as well.
+func (p *Persister) GetVerificationToken(ctx context.Context, token string) (*link.VerificationToken, error) {
+	var rt link.VerificationToken
+
+	nid := corp.ContextualizeNID(ctx, p.nid)
+	var err error
+	for _, secret := range p.r.Config(ctx).SecretsSession() {
+		if err = p.GetConnection(ctx).Where("token = ? AND nid = ?", p.hmacValueWithSecret(token, secret), nid).First(&rt); err == nil {
+			return &rt, nil
+		} else if !errors.Is(sqlcon.HandleError(err), sqlcon.ErrNoRows) {
+			break
+		}
+	}
+
+	return nil, sqlcon.HandleError(err)
+}
+
 func (p *Persister) DeleteVerificationToken(ctx context.Context, token string) error {
 	nid := corp.ContextualizeNID(ctx, p.nid)
 	/* #nosec G201 TableName is static */
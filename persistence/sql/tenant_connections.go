@@ -0,0 +1,156 @@
+package sql
+
+import (
+	"container/list"
+	"context"
+	"sync"
+
+	"github.com/gobuffalo/pop/v5"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/singleflight"
+)
+
+type (
+	// TenantConnections lazily opens connections to the tenants' databases and keeps at most
+	// max of them open. When the limit is reached, the connection which was least recently used
+	// is evicted. Evicted connections are closed once every caller of Get released them.
+	TenantConnections struct {
+		sync.Mutex
+		max   int
+		open  func(ctx context.Context, tenant string) (*pop.Connection, error)
+		conns map[string]*list.Element
+		lru   *list.List
+
+		// dials makes sure that only one connection per tenant is opened at a time. Connections
+		// are opened without holding the lock so that other tenants are not blocked.
+		dials singleflight.Group
+	}
+	tenantConnection struct {
+		tenant  string
+		c       *pop.Connection
+		refs    int
+		evicted bool
+	}
+)
+
+func NewTenantConnections(max int, open func(ctx context.Context, tenant string) (*pop.Connection, error)) *TenantConnections {
+	if max < 1 {
+		max = 1
+	}
+
+	return &TenantConnections{
+		max:   max,
+		open:  open,
+		conns: make(map[string]*list.Element),
+		lru:   list.New(),
+	}
+}
+
+// Get returns the connection to the tenant's database and opens it if necessary. The connection
+// is not closed before release is called, even if it is evicted in the meantime.
+func (t *TenantConnections) Get(ctx context.Context, tenant string) (_ *pop.Connection, release func(), _ error) {
+	for {
+		if tc := t.acquire(tenant); tc != nil {
+			return tc.c, t.releaser(tc), nil
+		}
+
+		// The connection might have been evicted again before it was acquired, in which case
+		// it is opened once more.
+		if _, err, _ := t.dials.Do(tenant, func() (interface{}, error) {
+			if tc := t.acquire(tenant); tc != nil {
+				t.release(tc)
+				return nil, nil
+			}
+
+			c, err := t.open(ctx, tenant)
+			if err != nil {
+				return nil, err
+			}
+
+			t.add(tenant, c)
+			return nil, nil
+		}); err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+// acquire returns the open connection of the tenant with its reference count incremented or nil.
+func (t *TenantConnections) acquire(tenant string) *tenantConnection {
+	t.Lock()
+	defer t.Unlock()
+
+	e, ok := t.conns[tenant]
+	if !ok {
+		return nil
+	}
+
+	t.lru.MoveToFront(e)
+	tc := e.Value.(*tenantConnection)
+	tc.refs++
+	return tc
+}
+
+func (t *TenantConnections) add(tenant string, c *pop.Connection) {
+	t.Lock()
+	defer t.Unlock()
+
+	for t.lru.Len() >= t.max {
+		oldest := t.lru.Remove(t.lru.Back()).(*tenantConnection)
+		delete(t.conns, oldest.tenant)
+
+		oldest.evicted = true
+		if oldest.refs == 0 {
+			_ = oldest.c.Close()
+		}
+	}
+
+	t.conns[tenant] = t.lru.PushFront(&tenantConnection{tenant: tenant, c: c})
+}
+
+func (t *TenantConnections) releaser(tc *tenantConnection) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() { t.release(tc) })
+	}
+}
+
+func (t *TenantConnections) release(tc *tenantConnection) {
+	t.Lock()
+	defer t.Unlock()
+
+	tc.refs--
+	if tc.evicted && tc.refs == 0 {
+		_ = tc.c.Close()
+	}
+}
+
+// Len returns the number of connections which were not evicted.
+func (t *TenantConnections) Len() int {
+	t.Lock()
+	defer t.Unlock()
+	return t.lru.Len()
+}
+
+// Close evicts all connections and returns the first error encountered while closing them.
+// Connections which were not released yet are closed once they are released.
+func (t *TenantConnections) Close() error {
+	t.Lock()
+	defer t.Unlock()
+
+	var result error
+	for e := t.lru.Front(); e != nil; e = e.Next() {
+		tc := e.Value.(*tenantConnection)
+		tc.evicted = true
+		if tc.refs > 0 {
+			continue
+		}
+		if err := tc.c.Close(); err != nil && result == nil {
+			result = errors.WithStack(err)
+		}
+	}
+
+	t.conns = make(map[string]*list.Element)
+	t.lru.Init()
+	return result
+}
@@ -0,0 +1,135 @@
+package sql_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/gobuffalo/pop/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/persistence/sql"
+	"github.com/ory/kratos/x"
+)
+
+func TestTenantConnections(t *testing.T) {
+	ctx := context.Background()
+
+	var l sync.Mutex
+	var opened []string
+	tc := sql.NewTenantConnections(2, func(_ context.Context, tenant string) (*pop.Connection, error) {
+		l.Lock()
+		opened = append(opened, tenant)
+		l.Unlock()
+
+		c, err := pop.NewConnection(&pop.ConnectionDetails{URL: "sqlite3://file:" + tenant + "?mode=memory&_fk=true"})
+		if err != nil {
+			return nil, err
+		}
+		return c, c.Open()
+	})
+	t.Cleanup(func() {
+		require.NoError(t, tc.Close())
+	})
+
+	get := func(t *testing.T, tenant string) *pop.Connection {
+		c, release, err := tc.Get(ctx, tenant)
+		require.NoError(t, err)
+		release()
+		return c
+	}
+
+	t.Run("case=reuses and evicts connections", func(t *testing.T) {
+		a := get(t, "a")
+		b := get(t, "b")
+
+		assert.Same(t, a, get(t, "a"), "connections must be reused")
+		assert.Equal(t, []string{"a", "b"}, opened)
+
+		get(t, "c")
+		assert.Equal(t, 2, tc.Len(), "the least recently used connection must be evicted")
+		require.Error(t, b.RawQuery("SELECT 1").Exec(), "evicted connections which were released must be closed")
+
+		get(t, "a")
+		get(t, "b")
+		assert.Equal(t, []string{"a", "b", "c", "b"}, opened)
+	})
+
+	t.Run("case=does not close connections which are in use", func(t *testing.T) {
+		d, release, err := tc.Get(ctx, "d")
+		require.NoError(t, err)
+
+		get(t, "e")
+		get(t, "f")
+		assert.Equal(t, 2, tc.Len())
+
+		require.NoError(t, d.RawQuery("SELECT 1").Exec(), "evicted connections must stay open until they are released")
+		release()
+		release()
+		require.Error(t, d.RawQuery("SELECT 1").Exec(), "evicted connections must be closed once they are released")
+	})
+
+	t.Run("case=opens a connection only once", func(t *testing.T) {
+		l.Lock()
+		opened = nil
+		l.Unlock()
+
+		var wg sync.WaitGroup
+		for k := 0; k < 10; k++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, release, err := tc.Get(ctx, "g")
+				assert.NoError(t, err)
+				release()
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, []string{"g"}, opened)
+	})
+}
+
+func TestPersister_TenantConnection(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/identity.schema.json")
+	conf.MustSet(config.ViperKeyIdentityCacheBackend, "memory")
+
+	dsn := fmt.Sprintf("sqlite3://%s.sqlite?_fk=true&mode=rwc", filepath.Join(os.TempDir(), x.NewUUID().String()))
+	conf.MustSet(config.ViperKeyTenancyTenants, []map[string]interface{}{{"id": "acme", "dsn": dsn}})
+
+	c, err := pop.NewConnection(&pop.ConnectionDetails{URL: dsn})
+	require.NoError(t, err)
+	require.NoError(t, c.Open())
+	p, err := sql.NewPersister(ctx, reg, c)
+	require.NoError(t, err)
+	require.NoError(t, p.MigrateUp(ctx))
+	require.NoError(t, c.Close())
+
+	_, _, err = reg.TenantConnection(ctx, "unknown")
+	require.Error(t, err)
+
+	tc, release, err := reg.TenantConnection(ctx, "acme")
+	require.NoError(t, err)
+	defer release()
+	tenantCtx := x.WithTenantConnection(ctx, tc)
+
+	i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+	require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(tenantCtx, i))
+
+	_, err = reg.IdentityPool().GetIdentity(tenantCtx, i.ID)
+	require.NoError(t, err)
+
+	_, err = reg.IdentityPool().GetIdentity(ctx, i.ID)
+	require.ErrorIs(t, err, sqlcon.ErrNoRows, "identities of a tenant must not be visible in the default database")
+}
@@ -2,6 +2,7 @@ package schema
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -59,6 +60,79 @@ func NewTOTPVerifierWrongError(instancePtr string) error {
 	})
 }
 
+func NewVerificationAddressNotOwnedError(instancePtr, address string) error {
+	t := text.NewErrorValidationVerificationAddressNotOwned(address)
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     t.Text,
+			InstancePtr: instancePtr,
+		},
+		Messages: new(text.Messages).Add(t),
+	})
+}
+
+func NewRecoveryCodeInvalidError(instancePtr string) error {
+	t := text.NewErrorValidationRecoveryCodeInvalid()
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     t.Text,
+			InstancePtr: instancePtr,
+		},
+		Messages: new(text.Messages).Add(t),
+	})
+}
+
+func NewRecoveryCodeInvalidOrAlreadyUsedError(instancePtr string) error {
+	t := text.NewErrorValidationRecoveryCodeInvalidOrAlreadyUsed()
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     t.Text,
+			InstancePtr: instancePtr,
+		},
+		Messages: new(text.Messages).Add(t),
+	})
+}
+
+func NewRecoveryResendCooldownError(resendAt time.Time) error {
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     `the recovery email was sent too recently to be sent again`,
+			InstancePtr: "#/",
+		},
+		Messages: new(text.Messages).Add(text.NewErrorValidationRecoveryResendCooldown(resendAt)),
+	})
+}
+
+func NewRecoveryResendLimitError(max int) error {
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     fmt.Sprintf("the recovery email can not be sent again more than %d times", max),
+			InstancePtr: "#/",
+		},
+		Messages: new(text.Messages).Add(text.NewErrorValidationRecoveryResendLimit(max)),
+	})
+}
+
+func NewVerificationResendCooldownError(resendAt time.Time) error {
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     `the verification email was sent too recently to be sent again`,
+			InstancePtr: "#/",
+		},
+		Messages: new(text.Messages).Add(text.NewErrorValidationVerificationResendCooldown(resendAt)),
+	})
+}
+
+func NewVerificationResendLimitError(max int) error {
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     fmt.Sprintf("the verification email can not be sent again more than %d times", max),
+			InstancePtr: "#/",
+		},
+		Messages: new(text.Messages).Add(text.NewErrorValidationVerificationResendLimit(max)),
+	})
+}
+
 type ValidationErrorContextPasswordPolicyViolation struct {
 	Reason string
 }
@@ -80,6 +154,39 @@ func NewPasswordPolicyViolationError(instancePtr string, reason string) error {
 	})
 }
 
+func NewCurrentPasswordInvalidError() error {
+	t := text.NewErrorValidationSettingsCurrentPasswordInvalid()
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     t.Text,
+			InstancePtr: "#/current_password",
+		},
+		Messages: new(text.Messages).Add(t),
+	})
+}
+
+func NewCurrentPasswordLockedOutError(lockedUntil time.Time) error {
+	t := text.NewErrorValidationSettingsCurrentPasswordLockedOut(lockedUntil)
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     `the current password was entered incorrectly too many times`,
+			InstancePtr: "#/current_password",
+		},
+		Messages: new(text.Messages).Add(t),
+	})
+}
+
+func NewVerifiedAddressRequiredError() error {
+	t := text.NewErrorValidationSettingsVerifiedAddressRequired()
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     t.Text,
+			InstancePtr: "#/",
+		},
+		Messages: new(text.Messages).Add(t),
+	})
+}
+
 type ValidationErrorContextInvalidCredentialsError struct{}
 
 func (r *ValidationErrorContextInvalidCredentialsError) AddContext(_, _ string) {}
@@ -114,6 +221,45 @@ func NewDuplicateCredentialsError() error {
 	})
 }
 
+type ValidationErrorContextTooManyAddressesError struct {
+	Max int
+}
+
+func (r *ValidationErrorContextTooManyAddressesError) AddContext(_, _ string) {}
+
+func (r *ValidationErrorContextTooManyAddressesError) FinishInstanceContext() {}
+
+func NewTooManyAddressesError(max int) error {
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     fmt.Sprintf("an account can not have more than %d addresses of the same kind", max),
+			InstancePtr: "#/",
+			Context:     &ValidationErrorContextTooManyAddressesError{Max: max},
+		},
+		Messages: new(text.Messages).Add(text.NewErrorValidationTooManyAddresses(max)),
+	})
+}
+
+func NewTraitsTooDeepError(max int) error {
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     fmt.Sprintf("traits must not be nested more than %d levels deep", max),
+			InstancePtr: "#/traits",
+		},
+		Messages: new(text.Messages).Add(text.NewErrorValidationTraitsTooDeep(max)),
+	})
+}
+
+func NewTraitsTooManyPropertiesError(max int) error {
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     fmt.Sprintf("traits must not have more than %d properties", max),
+			InstancePtr: "#/traits",
+		},
+		Messages: new(text.Messages).Add(text.NewErrorValidationTraitsTooManyProperties(max)),
+	})
+}
+
 func NewNoLoginStrategyResponsible() error {
 	return errors.WithStack(&ValidationError{
 		ValidationError: &jsonschema.ValidationError{
@@ -124,6 +270,56 @@ func NewNoLoginStrategyResponsible() error {
 	})
 }
 
+func NewLoginNotAllowedError(reason string) error {
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     `the identity is not allowed to sign in`,
+			InstancePtr: "#/",
+		},
+		Messages: new(text.Messages).Add(text.NewErrorValidationLoginNotAllowed(reason)),
+	})
+}
+
+func NewBlockedIdentifierError(identifier string) error {
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     fmt.Sprintf("signing up with %s is not allowed", identifier),
+			InstancePtr: "#/",
+		},
+		Messages: new(text.Messages).Add(text.NewErrorValidationRegistrationIdentifierBlocked(identifier)),
+	})
+}
+
+func NewTooManyLoginFlowsError() error {
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     `too many login flows are active for this identifier`,
+			InstancePtr: "#/",
+		},
+		Messages: new(text.Messages).Add(text.NewErrorValidationLoginTooManyFlows()),
+	})
+}
+
+func NewLoginLockedOutError(lockedUntil time.Time) error {
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     `too many failed logins, the identifier is locked temporarily`,
+			InstancePtr: "#/",
+		},
+		Messages: new(text.Messages).Add(text.NewErrorValidationLoginLockedOut(lockedUntil)),
+	})
+}
+
+func NewLoginRefreshIdentityMismatchError() error {
+	return errors.WithStack(&ValidationError{
+		ValidationError: &jsonschema.ValidationError{
+			Message:     `the refresh login flow must be completed by the identity of the current session`,
+			InstancePtr: "#/",
+		},
+		Messages: new(text.Messages).Add(text.NewErrorValidationLoginIdentityMismatch()),
+	})
+}
+
 func NewNoRegistrationStrategyResponsible() error {
 	return errors.WithStack(&ValidationError{
 		ValidationError: &jsonschema.ValidationError{
@@ -8,6 +8,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/ory/jsonschema/v3"
+	"github.com/ory/x/jsonschemax"
 )
 
 //go:embed .schema/extension/*.json
@@ -23,7 +24,8 @@ type (
 	ExtensionConfig           struct {
 		Credentials struct {
 			Password struct {
-				Identifier bool `json:"identifier"`
+				Identifier bool   `json:"identifier"`
+				Via        string `json:"via"`
 			} `json:"password"`
 		} `json:"credentials"`
 		Verification struct {
@@ -32,6 +34,7 @@ type (
 		Recovery struct {
 			Via string `json:"via"`
 		} `json:"recovery"`
+		Encrypt  bool `json:"encrypt"`
 		Mappings struct {
 			Identity struct {
 				Traits []struct {
@@ -55,6 +58,15 @@ type (
 	}
 )
 
+// EnhancePath exposes the extension config to jsonschemax.ListPaths. Paths of values which are encrypted
+// at rest carry the custom property `encrypt`.
+func (e *ExtensionConfig) EnhancePath(_ jsonschemax.Path) map[string]interface{} {
+	if !e.Encrypt {
+		return nil
+	}
+	return map[string]interface{}{"encrypt": true}
+}
+
 func NewExtensionRunner(meta ExtensionRunnerMetaSchema, runners ...Extension) (*ExtensionRunner, error) {
 	var err error
 	schema, err := extensionSchemas.ReadFile(string(meta))
@@ -0,0 +1,359 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/jsonschema/v3"
+)
+
+// documents caches the dereferenced JSON Schemas by URL.
+var documents sync.Map
+
+// LoadURL loads the JSON Schema at the URL and inlines its references (`$ref`). Relative references are
+// resolved against the URL of the schema which contains them, for example `file://./shared.schema.json`
+// within `file://./identity.schema.json`.
+//
+// Keywords next to a `$ref` are ignored by JSON Schema draft 7. The keywords of the referencing schema
+// are therefore merged into the referenced schema, which preserves the `ory.sh/kratos` extension config
+// of properties whose definition is shared. Recursive references are left in place.
+//
+// The result is cached for the lifetime of the process. LoadURL is compatible with jsonschema.LoadURL
+// and can be used as the loader of a jsonschema.Compiler.
+func LoadURL(href string) (io.ReadCloser, error) {
+	base, _ := splitRef(href)
+	if doc, ok := documents.Load(base); ok {
+		return ioutil.NopCloser(bytes.NewReader(doc.([]byte))), nil
+	}
+
+	d := &dereferencer{document: base, raw: map[string]interface{}{}}
+	root, err := d.load(base)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved, err := d.dereference(base, root, []string{base + "#"})
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := json.Marshal(resolved)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	documents.Store(base, doc)
+	return ioutil.NopCloser(bytes.NewReader(doc)), nil
+}
+
+// NewCompiler returns a JSON Schema compiler which loads schemas using LoadURL.
+func NewCompiler() *jsonschema.Compiler {
+	compiler := jsonschema.NewCompiler()
+	compiler.LoadURL = LoadURL
+	return compiler
+}
+
+type dereferencer struct {
+	// document is the URL of the schema which is being dereferenced.
+	document string
+
+	// raw holds the documents loaded while dereferencing a schema.
+	raw map[string]interface{}
+}
+
+func (d *dereferencer) load(base string) (interface{}, error) {
+	if doc, ok := d.raw[base]; ok {
+		return doc, nil
+	}
+
+	f, err := jsonschema.LoadURL(base)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	dec.UseNumber()
+
+	doc, err := decodeObjects(dec)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to decode JSON Schema %s", base)
+	}
+
+	d.raw[base] = doc
+	return doc, nil
+}
+
+// dereference inlines the references of the schema which is part of the document at base. The stack holds
+// the references which are currently being inlined and is used to detect recursion.
+func (d *dereferencer) dereference(base string, schema interface{}, stack []string) (interface{}, error) {
+	switch s := schema.(type) {
+	case []interface{}:
+		result := make([]interface{}, len(s))
+		for k, v := range s {
+			var err error
+			if result[k], err = d.dereference(base, v, stack); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	case *object:
+		result := newObject()
+		for _, k := range s.keys {
+			switch k {
+			case "$ref", "enum", "const", "default", "examples", extensionName:
+				// These keywords hold values, not schemas.
+				result.set(k, s.values[k])
+			default:
+				v, err := d.dereference(base, s.values[k], stack)
+				if err != nil {
+					return nil, err
+				}
+				result.set(k, v)
+			}
+		}
+
+		ref, ok := s.values["$ref"].(string)
+		if !ok {
+			return result, nil
+		}
+		result.delete("$ref")
+
+		target, err := d.resolve(base, ref, result, stack)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to resolve $ref %q in JSON Schema %s", ref, base)
+		}
+		return target, nil
+	default:
+		return schema, nil
+	}
+}
+
+// resolve returns the schema the reference points to merged with the keywords next to the reference.
+func (d *dereferencer) resolve(base, ref string, siblings *object, stack []string) (interface{}, error) {
+	targetBase, fragment := base, ref
+	if !strings.HasPrefix(ref, "#") {
+		u, err := url.Parse(base)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		r, err := url.Parse(ref)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		targetBase, fragment = splitRef(u.ResolveReference(r).String())
+	}
+
+	if fragment != "#" && !strings.HasPrefix(fragment, "#/") {
+		// Only JSON Pointers can be inlined, the compiler resolves other fragments such as `$id` anchors.
+		return withRef(siblings, ref), nil
+	}
+
+	for _, s := range stack {
+		if s == targetBase+fragment {
+			// Recursive schemas can not be inlined.
+			if targetBase == d.document {
+				return withRef(siblings, fragment), nil
+			}
+			return withRef(siblings, targetBase+fragment), nil
+		}
+	}
+
+	root, err := d.load(targetBase)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := resolvePointer(root, fragment)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved, err := d.dereference(targetBase, target, append(stack, targetBase+fragment))
+	if err != nil {
+		return nil, err
+	}
+
+	o, ok := resolved.(*object)
+	if !ok {
+		if len(siblings.keys) == 0 {
+			return resolved, nil
+		}
+		return withRef(siblings, targetBase+fragment), nil
+	}
+
+	merged := newObject()
+	for _, k := range o.keys {
+		switch k {
+		case "$id", "$schema", "definitions":
+			// The inlined schema is no longer a document of its own.
+		default:
+			merged.set(k, o.values[k])
+		}
+	}
+	for _, k := range siblings.keys {
+		merged.set(k, siblings.values[k])
+	}
+	return merged, nil
+}
+
+func withRef(siblings *object, ref string) *object {
+	result := newObject()
+	result.set("$ref", ref)
+	for _, k := range siblings.keys {
+		result.set(k, siblings.values[k])
+	}
+	return result
+}
+
+// resolvePointer returns the value the JSON Pointer fragment (RFC 6901) points to.
+func resolvePointer(doc interface{}, fragment string) (interface{}, error) {
+	pointer := strings.TrimPrefix(strings.TrimPrefix(fragment, "#"), "/")
+	if pointer == "" {
+		return doc, nil
+	}
+
+	for _, token := range strings.Split(pointer, "/") {
+		token, err := url.PathUnescape(token)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+
+		switch d := doc.(type) {
+		case *object:
+			v, ok := d.values[token]
+			if !ok {
+				return nil, errors.Errorf("the JSON Pointer %q does not exist", fragment)
+			}
+			doc = v
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(d) {
+				return nil, errors.Errorf("the JSON Pointer %q does not exist", fragment)
+			}
+			doc = d[i]
+		default:
+			return nil, errors.Errorf("the JSON Pointer %q does not exist", fragment)
+		}
+	}
+	return doc, nil
+}
+
+func splitRef(ref string) (string, string) {
+	if i := strings.IndexByte(ref, '#'); i >= 0 {
+		return ref[:i], ref[i:]
+	}
+	return ref, "#"
+}
+
+// object is a JSON object which keeps the order of its keys. The order of the properties of a schema
+// determines the order of the form fields, see GetKeysInOrder.
+type object struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newObject() *object {
+	return &object{values: map[string]interface{}{}}
+}
+
+func (o *object) set(key string, value interface{}) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+func (o *object) delete(key string) {
+	if _, ok := o.values[key]; !ok {
+		return
+	}
+	delete(o.values, key)
+	for k, kk := range o.keys {
+		if kk == key {
+			o.keys = append(o.keys[:k], o.keys[k+1:]...)
+			break
+		}
+	}
+}
+
+func (o *object) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for k, key := range o.keys {
+		if k > 0 {
+			b.WriteByte(',')
+		}
+
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		value, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		b.Write(name)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// decodeObjects decodes the next JSON value and uses object for JSON objects.
+func decodeObjects(dec *json.Decoder) (interface{}, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	switch t {
+	case json.Delim('{'):
+		o := newObject()
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			value, err := decodeObjects(dec)
+			if err != nil {
+				return nil, err
+			}
+			o.set(key.(string), value)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return o, nil
+	case json.Delim('['):
+		a := []interface{}{}
+		for dec.More() {
+			value, err := decodeObjects(dec)
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, value)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return a, nil
+	default:
+		return t, nil
+	}
+}
@@ -0,0 +1,98 @@
+package schema
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestLoadURL(t *testing.T) {
+	load := func(t *testing.T, href string) []byte {
+		f, err := LoadURL(href)
+		require.NoError(t, err)
+		defer f.Close()
+
+		doc, err := ioutil.ReadAll(f)
+		require.NoError(t, err)
+		return doc
+	}
+
+	t.Run("case=inlines local and remote references", func(t *testing.T) {
+		doc := load(t, "file://./stub/ref/identity.schema.json")
+		assert.NotContains(t, string(doc), "$ref")
+
+		email := gjson.GetBytes(doc, "properties.traits.properties.email")
+		assert.Equal(t, "email", email.Get("format").String())
+		assert.True(t, email.Get(`ory\.sh/kratos.credentials.password.identifier`).Bool(), "%s", email.Raw)
+		assert.False(t, email.Get("definitions").Exists())
+
+		address := gjson.GetBytes(doc, "properties.traits.properties.address")
+		assert.EqualValues(t, 2, address.Get("properties.country.minLength").Int(), "%s", address.Raw)
+		assert.Equal(t, `["city"]`, address.Get("required").Raw)
+
+		assert.EqualValues(t, 1, gjson.GetBytes(doc, "properties.traits.properties.name.minLength").Int())
+	})
+
+	t.Run("case=keeps the order of keys", func(t *testing.T) {
+		var keys []string
+		gjson.GetBytes(load(t, "file://./stub/ref/identity.schema.json"), "properties.traits.properties.address.properties").
+			ForEach(func(key, _ gjson.Result) bool {
+				keys = append(keys, key.String())
+				return true
+			})
+		assert.Equal(t, []string{"street", "city", "country"}, keys)
+	})
+
+	t.Run("case=leaves recursive references in place", func(t *testing.T) {
+		doc := load(t, "file://./stub/ref/recursive.schema.json")
+		assert.Equal(t, "#/definitions/category", gjson.GetBytes(doc, "properties.category.properties.children.items.$ref").String())
+
+		require.NoError(t, NewValidator().Validate("file://./stub/ref/recursive.schema.json",
+			[]byte(`{"category":{"name":"a","children":[{"name":"b","children":[{"name":"c"}]}]}}`)))
+		require.Error(t, NewValidator().Validate("file://./stub/ref/recursive.schema.json",
+			[]byte(`{"category":{"name":"a","children":[{"name":"b","children":[{"name":1}]}]}}`)))
+	})
+
+	t.Run("case=fails if a reference can not be loaded", func(t *testing.T) {
+		_, err := LoadURL("file://./stub/ref/missing.schema.json")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `unable to resolve $ref "does-not-exist.schema.json#/definitions/email" in JSON Schema file://./stub/ref/missing.schema.json`)
+
+		_, err = LoadURL("file://./stub/ref/shared.schema.json#/definitions/unknown")
+		require.NoError(t, err, "fragments are resolved by the compiler")
+	})
+
+	t.Run("case=caches resolved references", func(t *testing.T) {
+		dir := t.TempDir()
+		write := func(name, content string) {
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+		}
+		write("shared.json", `{"type":"string"}`)
+		write("parent.json", `{"properties":{"name":{"$ref":"shared.json"}}}`)
+
+		href := "file://" + filepath.Join(dir, "parent.json")
+		expected := load(t, href)
+		assert.Equal(t, "string", gjson.GetBytes(expected, "properties.name.type").String())
+
+		require.NoError(t, os.Remove(filepath.Join(dir, "shared.json")))
+		assert.True(t, bytes.Equal(expected, load(t, href)))
+	})
+}
+
+func TestSchemas_Compile(t *testing.T) {
+	require.NoError(t, Schemas{{ID: "default", RawURL: "file://./stub/ref/identity.schema.json"}}.Compile())
+
+	err := Schemas{
+		{ID: "default", RawURL: "file://./stub/ref/identity.schema.json"},
+		{ID: "missing", RawURL: "file://./stub/ref/missing.schema.json"},
+	}.Compile()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to compile JSON Schema missing (file://./stub/ref/missing.schema.json)")
+	assert.Contains(t, err.Error(), "does-not-exist.schema.json")
+}
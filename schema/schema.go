@@ -11,7 +11,6 @@ import (
 	"github.com/tidwall/gjson"
 
 	"github.com/ory/herodot"
-	"github.com/ory/jsonschema/v3"
 	_ "github.com/ory/jsonschema/v3/base64loader"
 	_ "github.com/ory/jsonschema/v3/fileloader"
 	_ "github.com/ory/jsonschema/v3/httploader"
@@ -62,7 +61,7 @@ func GetKeysInOrder(schemaRef string) ([]string, error) {
 	keysInOrder, ok := orderedKeyCache[schemaRef]
 	orderedKeyCacheMutex.RUnlock()
 	if !ok {
-		sio, err := jsonschema.LoadURL(schemaRef)
+		sio, err := LoadURL(schemaRef)
 		if err != nil {
 			return nil, errors.WithStack(err)
 		}
@@ -86,6 +85,24 @@ type Schema struct {
 	RawURL string   `json:"url"`
 }
 
+// Compile loads and compiles the schemas including their references. It is used to report schemas which
+// can not be loaded on start up rather than when the schema is first used.
+func (s Schemas) Compile() error {
+	for _, ss := range s {
+		runner, err := NewExtensionRunner(ExtensionRunnerIdentityMetaSchema)
+		if err != nil {
+			return err
+		}
+
+		compiler := NewCompiler()
+		runner.Register(compiler)
+		if _, err := compiler.Compile(ss.RawURL); err != nil {
+			return errors.Wrapf(err, "unable to compile JSON Schema %s (%s)", ss.ID, ss.RawURL)
+		}
+	}
+	return nil
+}
+
 func (s *Schema) SchemaURL(host *url.URL) *url.URL {
 	return urlx.AppendPaths(host, SchemasPath, s.ID)
 }
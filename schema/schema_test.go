@@ -80,6 +80,8 @@ func TestGetKeysInOrder(t *testing.T) {
 		{schemaRef: "file://./stub/complex.schema.json", keys: []string{"meal.name", "meal.chef", "traits.email",
 			"traits.stringy", "traits.numby", "traits.booly", "traits.should_big_number", "traits.should_long_string",
 			"fruits", "vegetables"}},
+		{schemaRef: "file://./stub/ref/identity.schema.json", keys: []string{"traits.email", "traits.address.street",
+			"traits.address.city", "traits.address.country", "traits.name"}},
 	} {
 		t.Run(fmt.Sprintf("case=%d schemaRef=%s", i, tc.schemaRef), func(t *testing.T) {
 			actual, err := GetKeysInOrder(tc.schemaRef)
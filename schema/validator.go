@@ -8,8 +8,6 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/ory/herodot"
-
-	"github.com/ory/jsonschema/v3"
 )
 
 type Validator struct {
@@ -44,8 +42,8 @@ func (v *Validator) Validate(
 		opt(&o)
 	}
 
-	compiler := jsonschema.NewCompiler()
-	resource, err := jsonschema.LoadURL(href)
+	compiler := NewCompiler()
+	resource, err := LoadURL(href)
 	if err != nil {
 		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to parse validate JSON object against JSON schema.").WithDebugf("%s", err))
 	}
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/ory/x/stringsx"
@@ -68,3 +69,47 @@ func TestSchemaValidator(t *testing.T) {
 		})
 	}
 }
+
+func TestSchemaValidatorWithReferences(t *testing.T) {
+	const href = "file://./stub/ref/identity.schema.json"
+
+	for k, tc := range []struct {
+		i           json.RawMessage
+		err         string
+		identifiers []string
+	}{
+		{
+			i:           json.RawMessage(`{"traits":{"email":"foo@ory.sh","name":"Foo","address":{"city":"Berlin","country":"DE"}}}`),
+			identifiers: []string{"foo@ory.sh"},
+		},
+		{
+			i:           json.RawMessage(`{"traits":{"email":"not-an-email"}}`),
+			err:         `I[#/traits/email] S[#/properties/traits/properties/email/format] "not-an-email" is not valid "email"`,
+			identifiers: []string{"not-an-email"},
+		},
+		{
+			i:           json.RawMessage(`{"traits":{"email":"foo@ory.sh","address":{"country":"DE"}}}`),
+			err:         `I[#/traits/address] S[#/properties/traits/properties/address/required] missing properties: "city"`,
+			identifiers: []string{"foo@ory.sh"},
+		},
+		{
+			i:           json.RawMessage(`{"traits":{"email":"foo@ory.sh","name":""}}`),
+			err:         `I[#/traits/name] S[#/properties/traits/properties/name/minLength] length must be >= 1, but got 0`,
+			identifiers: []string{"foo@ory.sh"},
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			runner, err := NewExtensionRunner(ExtensionRunnerIdentityMetaSchema)
+			require.NoError(t, err)
+
+			e := new(extensionStub)
+			err = NewValidator().Validate(href, tc.i, WithExtensionRunner(runner.AddRunner(e)))
+			if tc.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.err)
+			}
+			assert.Equal(t, tc.identifiers, e.identifiers)
+		})
+	}
+}
@@ -4,11 +4,15 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"net/http"
+	"strings"
 	"time"
 
-	"github.com/ory/kratos/corp"
-
 	"github.com/gofrs/uuid"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/kratos/corp"
+	"github.com/ory/kratos/text"
 )
 
 // swagger:model errorContainer
@@ -38,3 +42,105 @@ type ErrorContainer struct {
 func (e ErrorContainer) TableName(ctx context.Context) string {
 	return corp.ContextualizeTableName(ctx, "selfservice_errors")
 }
+
+// ErrorTypeValidation is the type of errors caused by invalid input.
+const ErrorTypeValidation = "validation_error"
+
+// Error is the structured representation of a self-service error. It is returned by the error
+// endpoint if `selfservice.flows.error.structured_response` is enabled.
+//
+// swagger:model selfServiceError
+type Error struct {
+	// Code is the HTTP status code of the error.
+	//
+	// required: true
+	Code int `json:"code"`
+
+	// Status is the HTTP status text of the error.
+	//
+	// required: true
+	Status string `json:"status"`
+
+	// Type is a machine-readable identifier of the error, for example `not_found` or `validation_error`.
+	//
+	// required: true
+	Type string `json:"type"`
+
+	// Reason contains a human-readable explanation of why the error occurred.
+	Reason string `json:"reason,omitempty"`
+
+	// Message is a human-readable description of the error.
+	//
+	// required: true
+	Message string `json:"message"`
+
+	// Details contains further information on the error.
+	Details map[string]interface{} `json:"details,omitempty"`
+
+	// Messages contains the error as UI texts which can be displayed to the user.
+	//
+	// required: true
+	Messages text.Messages `json:"messages"`
+}
+
+// NewErrors converts the errors stored in an error container into their structured representation.
+func NewErrors(stored json.RawMessage) []Error {
+	results := gjson.ParseBytes(stored).Array()
+	errs := make([]Error, len(results))
+	for k, result := range results {
+		errs[k] = newError(result)
+	}
+	return errs
+}
+
+func newError(stored gjson.Result) Error {
+	if !stored.Get("code").Exists() && stored.Get("Message").Exists() {
+		// This is a JSON schema validation error.
+		e := Error{
+			Code:    http.StatusBadRequest,
+			Status:  http.StatusText(http.StatusBadRequest),
+			Type:    ErrorTypeValidation,
+			Message: stored.Get("Message").String(),
+		}
+
+		if ptr := stored.Get("InstancePtr").String(); len(ptr) > 0 {
+			e.Details = map[string]interface{}{"instance_ptr": ptr}
+		}
+
+		if messages := stored.Get("Messages"); messages.IsArray() {
+			_ = json.Unmarshal([]byte(messages.Raw), &e.Messages)
+		}
+
+		if len(e.Messages) == 0 {
+			e.Messages.Add(text.NewValidationErrorGeneric(e.Message))
+		}
+		return e
+	}
+
+	e := Error{
+		Code:    int(stored.Get("code").Int()),
+		Status:  stored.Get("status").String(),
+		Reason:  stored.Get("reason").String(),
+		Message: stored.Get("message").String(),
+	}
+
+	if e.Code == 0 {
+		e.Code = http.StatusInternalServerError
+	}
+	if len(e.Status) == 0 {
+		e.Status = http.StatusText(e.Code)
+	}
+	e.Type = strings.ToLower(strings.ReplaceAll(e.Status, " ", "_"))
+
+	if details := stored.Get("details"); details.IsObject() {
+		_ = json.Unmarshal([]byte(details.Raw), &e.Details)
+	}
+
+	reason := e.Reason
+	if len(reason) == 0 {
+		reason = e.Message
+	}
+	e.Messages.Add(text.NewErrorSystemGeneric(reason))
+
+	return e
+}
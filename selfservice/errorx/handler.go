@@ -3,12 +3,18 @@ package errorx
 import (
 	"encoding/json"
 	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
 
 	"github.com/julienschmidt/httprouter"
 
 	"github.com/ory/herodot"
-	"github.com/ory/kratos/x"
 	"github.com/ory/nosurf"
+	"github.com/ory/x/urlx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/x"
 )
 
 const RouteGet = "/self-service/errors"
@@ -19,6 +25,7 @@ type (
 	handlerDependencies interface {
 		x.WriterProvider
 		PersistenceProvider
+		config.Provider
 	}
 	HandlerProvider interface {
 		SelfServiceErrorHandler() *Handler
@@ -76,6 +83,10 @@ type getSelfServiceErrorParameters struct {
 //
 // - `?error=stub:500` - returns a stub 500 (Internal Server Error) error.
 //
+// If `selfservice.flows.error.structured_response` is enabled, all errors are returned in the stable
+// `selfServiceError` format and browsers calling the public endpoint are redirected to the error UI instead.
+// The admin endpoint always responds with JSON.
+//
 // More information can be found at [ORY Kratos User User Facing Error Documentation](https://www.ory.sh/docs/kratos/self-service/flows/user-facing-errors).
 //
 //     Produces:
@@ -89,6 +100,12 @@ type getSelfServiceErrorParameters struct {
 //       404: genericError
 //       500: genericError
 func (h *Handler) publicFetchError(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if h.r.Config(r.Context()).SelfServiceFlowErrorStructuredResponse() && x.IsBrowserRequest(r) {
+		// Browsers are sent to the error UI which fetches the error on their behalf.
+		http.Redirect(w, r, urlx.CopyWithQuery(h.r.Config(r.Context()).SelfServiceFlowErrorURL(), url.Values{"error": {r.URL.Query().Get("error")}}).String(), http.StatusFound)
+		return
+	}
+
 	if err := h.fetchError(w, r); err != nil {
 		h.r.Writer().WriteError(w, r, x.ErrInvalidCSRFToken.WithTrace(err).WithDebugf("%s", err))
 		return
@@ -104,15 +121,26 @@ func (h *Handler) adminFetchError(w http.ResponseWriter, r *http.Request, ps htt
 
 func (h *Handler) fetchError(w http.ResponseWriter, r *http.Request) error {
 	id := r.URL.Query().Get("error")
+	structured := h.r.Config(r.Context()).SelfServiceFlowErrorStructuredResponse()
+
+	var es *ErrorContainer
 	switch id {
 	case "stub:500":
-		h.r.Writer().Write(w, r, &ErrorContainer{ID: x.NewUUID(), Errors: stub500})
-		return nil
+		es = &ErrorContainer{ID: x.NewUUID(), Errors: stub500}
+	default:
+		var err error
+		es, err = h.r.SelfServiceErrorPersister().Read(r.Context(), x.ParseUUID(id))
+		if err != nil {
+			return err
+		}
 	}
 
-	es, err := h.r.SelfServiceErrorPersister().Read(r.Context(), x.ParseUUID(id))
-	if err != nil {
-		return err
+	if structured {
+		errs, err := json.Marshal(NewErrors(es.Errors))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		es.Errors = errs
 	}
 
 	h.r.Writer().Write(w, r, es)
@@ -16,15 +16,18 @@ import (
 	"github.com/tidwall/gjson"
 
 	"github.com/ory/herodot"
+	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/schema"
 	"github.com/ory/kratos/selfservice/errorx"
+	"github.com/ory/kratos/text"
 	"github.com/ory/kratos/x"
 	"github.com/ory/nosurf"
 	"github.com/ory/x/errorsx"
 )
 
 func TestHandler(t *testing.T) {
-	_, reg := internal.NewFastRegistryWithMocks(t)
+	conf, reg := internal.NewFastRegistryWithMocks(t)
 	h := errorx.NewHandler(reg)
 
 	t.Run("case=public authorization", func(t *testing.T) {
@@ -150,4 +153,82 @@ func TestHandler(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("case=structured response", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceErrorStructuredResponse, true)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceErrorStructuredResponse, false)
+		})
+
+		router := x.NewRouterAdmin()
+		h.RegisterAdminRoutes(router)
+		ts := httptest.NewServer(router)
+		defer ts.Close()
+
+		id, err := reg.SelfServiceErrorPersister().Add(context.Background(), x.NewUUID().String(),
+			herodot.ErrNotFound.WithReason("foobar"),
+			schema.NewRequiredError("#/email", "email"))
+		require.NoError(t, err)
+
+		t.Run("type=api", func(t *testing.T) {
+			req, err := http.NewRequest("GET", ts.URL+errorx.RouteGet+"?error="+id.String(), nil)
+			require.NoError(t, err)
+			req.Header.Set("Accept", "application/json")
+
+			res, err := ts.Client().Do(req)
+			require.NoError(t, err)
+			defer res.Body.Close()
+			assert.EqualValues(t, http.StatusOK, res.StatusCode)
+
+			actual, err := ioutil.ReadAll(res.Body)
+			require.NoError(t, err)
+
+			assert.EqualValues(t, http.StatusNotFound, gjson.GetBytes(actual, "errors.0.code").Int(), "%s", actual)
+			assert.EqualValues(t, "not_found", gjson.GetBytes(actual, "errors.0.type").String(), "%s", actual)
+			assert.EqualValues(t, "foobar", gjson.GetBytes(actual, "errors.0.reason").String(), "%s", actual)
+			assert.EqualValues(t, "foobar", gjson.GetBytes(actual, "errors.0.messages.0.text").String(), "%s", actual)
+
+			assert.EqualValues(t, http.StatusBadRequest, gjson.GetBytes(actual, "errors.1.code").Int(), "%s", actual)
+			assert.EqualValues(t, errorx.ErrorTypeValidation, gjson.GetBytes(actual, "errors.1.type").String(), "%s", actual)
+			assert.EqualValues(t, "#/email", gjson.GetBytes(actual, "errors.1.details.instance_ptr").String(), "%s", actual)
+			assert.EqualValues(t, text.ErrorValidationRequired, gjson.GetBytes(actual, "errors.1.messages.0.id").Int(), "%s", actual)
+		})
+
+		t.Run("type=browser", func(t *testing.T) {
+			public := x.NewRouterPublic()
+			h.RegisterPublicRoutes(public)
+			pts := httptest.NewServer(public)
+			defer pts.Close()
+
+			c := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			}}
+			get := func(t *testing.T, base string) *http.Response {
+				req, err := http.NewRequest("GET", base+errorx.RouteGet+"?error="+id.String(), nil)
+				require.NoError(t, err)
+				req.Header.Set("Accept", "text/html")
+
+				res, err := c.Do(req)
+				require.NoError(t, err)
+				t.Cleanup(func() { _ = res.Body.Close() })
+				return res
+			}
+
+			t.Run("endpoint=public", func(t *testing.T) {
+				res := get(t, pts.URL)
+				assert.EqualValues(t, http.StatusFound, res.StatusCode)
+				assert.EqualValues(t, conf.SelfServiceFlowErrorURL().String()+"?error="+id.String(), res.Header.Get("Location"))
+			})
+
+			t.Run("endpoint=admin", func(t *testing.T) {
+				res := get(t, ts.URL)
+				assert.EqualValues(t, http.StatusOK, res.StatusCode)
+				assert.Contains(t, res.Header.Get("Content-Type"), "application/json")
+
+				actual, err := ioutil.ReadAll(res.Body)
+				require.NoError(t, err)
+				assert.EqualValues(t, "foobar", gjson.GetBytes(actual, "errors.0.reason").String(), "%s", actual)
+			})
+		})
+	})
 }
@@ -38,8 +38,9 @@ func NewManager(d managerDependencies) *Manager {
 // Create is a simple helper that saves all errors in the store and returns the
 // error url, appending the error ID.
 func (m *Manager) Create(ctx context.Context, w http.ResponseWriter, r *http.Request, errs ...error) (string, error) {
-	for _, err := range errs {
+	for k, err := range errs {
 		m.d.Logger().WithError(err).WithRequest(r).Errorf("An error occurred and is being forwarded to the error user interface.")
+		errs[k] = x.RedactError(m.d.Config(ctx), err)
 	}
 
 	id, emerr := m.d.SelfServiceErrorPersister().Add(ctx, m.d.GenerateCSRFToken(r), errs...)
@@ -0,0 +1,68 @@
+package login
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/google/go-jsonnet"
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/schema"
+)
+
+// checkAccessRule evaluates the Jsonnet rule configured at `selfservice.flows.login.access_rule.url`
+// and returns a validation error carrying the configured message if the identity may not sign in.
+//
+// The rule receives the identity (without credentials) as `std.extVar('identity')` and must return
+// an object with the boolean key `allow`.
+func (e *HookExecutor) checkAccessRule(ctx context.Context, i *identity.Identity) error {
+	c := e.d.Config(ctx)
+	rule := c.SelfServiceFlowLoginAccessRuleURL()
+	if rule == nil {
+		return nil
+	}
+
+	jn, err := e.f.Fetch(rule.String())
+	if err != nil {
+		return errors.WithStack(herodot.ErrInternalServerError.
+			WithReasonf("Unable to fetch the login access rule.").WithDebug(err.Error()))
+	}
+
+	var input bytes.Buffer
+	if err := json.NewEncoder(&input).Encode(i.CopyWithoutCredentials()); err != nil {
+		return errors.WithStack(err)
+	}
+
+	vm := jsonnet.MakeVM()
+	vm.ExtCode("identity", input.String())
+	evaluated, err := vm.EvaluateSnippet(rule.String(), jn.String())
+	if err != nil {
+		return errors.WithStack(herodot.ErrInternalServerError.
+			WithReasonf("Unable to evaluate the login access rule.").WithDebug(err.Error()))
+	}
+
+	allow := gjson.Get(evaluated, "allow")
+	if allow.Type != gjson.True && allow.Type != gjson.False {
+		e.d.Logger().
+			WithField("identity_id", i.ID).
+			WithField("access_rule_jsonnet_output", evaluated).
+			WithField("access_rule_jsonnet_url", rule.String()).
+			Error("Login access rule did not return a boolean for key allow. Please check your Jsonnet code!")
+		return errors.WithStack(herodot.ErrInternalServerError.
+			WithReasonf(`The login access rule must return a boolean for key "allow".`))
+	}
+
+	if !allow.Bool() {
+		e.d.Audit().
+			WithField("identity_id", i.ID).
+			Info("The login access rule refused to issue a session for the identity.")
+		return schema.NewLoginNotAllowedError(c.SelfServiceFlowLoginAccessRuleMessage())
+	}
+
+	return nil
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/gofrs/uuid"
 	"github.com/pkg/errors"
 
+	"github.com/ory/x/sqlxx"
 	"github.com/ory/x/urlx"
 
 	"github.com/ory/kratos/identity"
@@ -82,6 +83,13 @@ type Flow struct {
 
 	// Forced stores whether this login flow should enforce re-authentication.
 	Forced bool `json:"forced" db:"forced"`
+
+	// Identifier is the (lowercased) identifier the flow was last submitted with. It is used to limit
+	// the number of flows which are active for one identifier at the same time.
+	Identifier string `json:"-" faker:"-" db:"identifier"`
+
+	// LinkCredentials are credentials which are linked to the identity once it signed in, see CredentialsToLink.
+	LinkCredentials sqlxx.NullJSONRawMessage `json:"-" faker:"-" db:"link_credentials"`
 }
 
 func NewFlow(conf *config.Config, exp time.Duration, csrf string, r *http.Request, flowType flow.Type) *Flow {
@@ -110,6 +118,10 @@ func (f *Flow) GetRequestURL() string {
 	return f.RequestURL
 }
 
+func (f *Flow) GetUI() *container.Container {
+	return f.UI
+}
+
 func (f Flow) TableName(ctx context.Context) string {
 	return corp.ContextualizeTableName(ctx, "selfservice_login_flows")
 }
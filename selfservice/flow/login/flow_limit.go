@@ -0,0 +1,51 @@
+package login
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/schema"
+)
+
+// BindFlowToIdentifier records that the flow is submitted with the identifier. If more unexpired flows
+// than allowed by `selfservice.flows.login.max_concurrent_flows_per_identifier` were already submitted
+// with the identifier, it returns an error which does not tell whether an account with the identifier
+// exists. Flows free up capacity once they expire or complete with a successful login.
+func BindFlowToIdentifier(ctx context.Context, d interface {
+	config.Provider
+	FlowPersistenceProvider
+}, f *Flow, identifier string) error {
+	limit := d.Config(ctx).SelfServiceFlowLoginMaxConcurrentFlowsPerIdentifier()
+	if limit == 0 {
+		return nil
+	}
+
+	identifier = strings.ToLower(identifier)
+	count, err := d.LoginFlowPersister().CountActiveLoginFlowsByIdentifier(ctx, identifier, f.ID)
+	if err != nil {
+		return err
+	}
+
+	if count >= limit {
+		return schema.NewTooManyLoginFlowsError()
+	}
+
+	if f.Identifier == identifier {
+		return nil
+	}
+
+	f.Identifier = identifier
+	return d.LoginFlowPersister().UpdateLoginFlow(ctx, f)
+}
+
+// ReleaseFlowFromIdentifier frees the capacity the flow takes up for its identifier. It is called once the
+// flow completed with a successful login, so that signing in repeatedly does not exhaust the limit.
+func ReleaseFlowFromIdentifier(ctx context.Context, d FlowPersistenceProvider, f *Flow) error {
+	if len(f.Identifier) == 0 {
+		return nil
+	}
+
+	f.Identifier = ""
+	return d.LoginFlowPersister().UpdateLoginFlow(ctx, f)
+}
@@ -103,7 +103,8 @@ type initializeSelfServiceBrowserLoginFlow struct {
 	//
 	// If set to true, this will refresh an existing login session by
 	// asking the user to sign in again. This will reset the
-	// authenticated_at time of the session.
+	// authenticated_at time of the session. The user must sign in with
+	// the identity of the existing session and no new session is issued.
 	//
 	// in: query
 	Refresh bool `json:"refresh"`
@@ -206,6 +207,7 @@ func (h *Handler) initBrowserFlow(w http.ResponseWriter, r *http.Request, ps htt
 	returnTo, err := x.SecureRedirectTo(r, h.d.Config(r.Context()).SelfServiceBrowserDefaultReturnTo(),
 		x.SecureRedirectAllowSelfServiceURLs(h.d.Config(r.Context()).SelfPublicURL(r)),
 		x.SecureRedirectAllowURLs(h.d.Config(r.Context()).SelfServiceBrowserWhitelistedReturnToDomains()),
+		x.SecureRedirectRequireHTTPS(h.d.Config(r.Context()).SelfServiceBrowserRequireHTTPSReturnTo()),
 	)
 	if err != nil {
 		h.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
@@ -379,6 +381,10 @@ func (h *Handler) submitFlow(w http.ResponseWriter, r *http.Request, _ httproute
 	// TODO Handle n+1 authentication factor
 
 	if err := h.d.LoginHookExecutor().PostLoginHook(w, r, s, f, i); err != nil {
+		if e := new(schema.ValidationError); errors.As(err, &e) {
+			h.d.LoginFlowErrorHandler().WriteFlowError(w, r, f, node.DefaultGroup, err)
+			return
+		}
 		h.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
 		return
 	}
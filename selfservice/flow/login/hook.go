@@ -8,8 +8,12 @@ import (
 
 	"github.com/pkg/errors"
 
+	"github.com/ory/x/fetcher"
+
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/event"
 	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/schema"
 	"github.com/ory/kratos/selfservice/flow"
 	"github.com/ory/kratos/session"
 	"github.com/ory/kratos/x"
@@ -35,13 +39,17 @@ type (
 		config.Provider
 		session.ManagementProvider
 		session.PersistenceProvider
+		session.ClaimsMapperProvider
+		event.EmitterProvider
 		x.WriterProvider
 		x.LoggingProvider
 
 		HooksProvider
+		StrategyProvider
 	}
 	HookExecutor struct {
 		d executorDependencies
+		f *fetcher.Fetcher
 	}
 	HookExecutorProvider interface {
 		LoginHookExecutor() *HookExecutor
@@ -57,10 +65,24 @@ func PostHookExecutorNames(e []PostHookExecutor) []string {
 }
 
 func NewHookExecutor(d executorDependencies) *HookExecutor {
-	return &HookExecutor{d: d}
+	return &HookExecutor{d: d, f: fetcher.NewFetcher()}
 }
 
-func (e *HookExecutor) PostLoginHook(w http.ResponseWriter, r *http.Request, ct identity.CredentialsType, a *Flow, i *identity.Identity) error {
+func (e *HookExecutor) PostLoginHook(w http.ResponseWriter, r *http.Request, ct identity.CredentialsType, a *Flow, i *identity.Identity) (err error) {
+	if err := e.checkAccessRule(r.Context(), i); err != nil {
+		return err
+	}
+
+	if err := e.linkCredentials(r, a, i); err != nil {
+		return err
+	}
+
+	if a.Forced {
+		if s, err := e.d.SessionManager().FetchFromRequest(r.Context(), r); err == nil {
+			return e.refreshSession(w, r, ct, a, i, s)
+		}
+	}
+
 	s := session.NewActiveSession(i, e.d.Config(r.Context()), time.Now().UTC()).Declassify()
 
 	e.d.Logger().
@@ -95,6 +117,9 @@ func (e *HookExecutor) PostLoginHook(w http.ResponseWriter, r *http.Request, ct
 	}
 
 	if a.Type == flow.TypeAPI {
+		if err := s.BindFingerprint(e.d.Config(r.Context()), r); err != nil {
+			return err
+		}
 		if err := e.d.SessionPersister().CreateSession(r.Context(), s); err != nil {
 			return errors.WithStack(err)
 		}
@@ -103,8 +128,19 @@ func (e *HookExecutor) PostLoginHook(w http.ResponseWriter, r *http.Request, ct
 			WithField("session_id", s.ID).
 			WithField("identity_id", i.ID).
 			Info("Identity authenticated successfully and was issued an ORY Kratos Session Token.")
+		e.d.EventEmitter().Emit(r.Context(), event.TypeLoginSucceeded, &event.Data{
+			IdentityID: i.ID, SessionID: &s.ID, FlowID: &a.ID, FlowType: string(a.Type), Method: ct.String()})
+
+		if s.Claims, err = e.d.SessionClaimsMapper().MapClaims(r.Context(), s); err != nil {
+			return err
+		}
+
+		token, err := session.IssuedToken(e.d.Config(r.Context()), s)
+		if err != nil {
+			return err
+		}
 
-		e.d.Writer().Write(w, r, &APIFlowResponse{Session: s, Token: s.Token})
+		e.d.Writer().Write(w, r, &APIFlowResponse{Session: s, Token: token})
 		return nil
 	}
 
@@ -117,6 +153,51 @@ func (e *HookExecutor) PostLoginHook(w http.ResponseWriter, r *http.Request, ct
 		WithField("identity_id", i.ID).
 		WithField("session_id", s.ID).
 		Info("Identity authenticated successfully and was issued an ORY Kratos Session Cookie.")
+	e.d.EventEmitter().Emit(r.Context(), event.TypeLoginSucceeded, &event.Data{
+		IdentityID: i.ID, SessionID: &s.ID, FlowID: &a.ID, FlowType: string(a.Type), Method: ct.String()})
+	return x.SecureContentNegotiationRedirection(w, r, s.Declassify(), a.RequestURL,
+		e.d.Writer(), e.d.Config(r.Context()), x.SecureRedirectOverrideDefaultReturnTo(e.d.Config(r.Context()).SelfServiceFlowLoginReturnTo(ct.String())))
+}
+
+// refreshSession completes a refresh login flow of a signed in identity. Instead of issuing a new session, the
+// time at which the current session was authenticated is updated. Post-login hooks are not executed.
+func (e *HookExecutor) refreshSession(w http.ResponseWriter, r *http.Request, ct identity.CredentialsType, a *Flow, i *identity.Identity, s *session.Session) (err error) {
+	if s.IdentityID != i.ID {
+		e.d.Audit().
+			WithRequest(r).
+			WithField("identity_id", i.ID).
+			WithField("session_id", s.ID).
+			Info("A refresh login flow was completed by an identity other than the one of the current session.")
+		return schema.NewLoginRefreshIdentityMismatchError()
+	}
+
+	s.AuthenticatedAt = time.Now().UTC()
+	if err := e.d.SessionPersister().UpdateSessionAuthenticatedAt(r.Context(), s.ID, s.AuthenticatedAt); err != nil {
+		return err
+	}
+
+	e.d.Audit().
+		WithRequest(r).
+		WithField("identity_id", i.ID).
+		WithField("session_id", s.ID).
+		Info("Identity re-authenticated successfully and the session was refreshed.")
+	e.d.EventEmitter().Emit(r.Context(), event.TypeLoginSucceeded, &event.Data{
+		IdentityID: i.ID, SessionID: &s.ID, FlowID: &a.ID, FlowType: string(a.Type), Method: ct.String()})
+
+	if a.Type == flow.TypeAPI {
+		if s.Claims, err = e.d.SessionClaimsMapper().MapClaims(r.Context(), s); err != nil {
+			return err
+		}
+
+		token, err := session.IssuedToken(e.d.Config(r.Context()), s)
+		if err != nil {
+			return err
+		}
+
+		e.d.Writer().Write(w, r, &APIFlowResponse{Session: s, Token: token})
+		return nil
+	}
+
 	return x.SecureContentNegotiationRedirection(w, r, s.Declassify(), a.RequestURL,
 		e.d.Writer(), e.d.Config(r.Context()), x.SecureRedirectOverrideDefaultReturnTo(e.d.Config(r.Context()).SelfServiceFlowLoginReturnTo(ct.String())))
 }
@@ -1,6 +1,8 @@
 package login_test
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"net/url"
 	"testing"
@@ -8,15 +10,20 @@ import (
 
 	"github.com/gobuffalo/httptest"
 	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/tidwall/gjson"
 
 	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/internal"
 	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/schema"
 	"github.com/ory/kratos/selfservice/flow"
 	"github.com/ory/kratos/selfservice/flow/login"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/text"
 	"github.com/ory/kratos/x"
 )
 
@@ -159,3 +166,124 @@ func TestLoginExecutor(t *testing.T) {
 		})
 	}
 }
+
+func TestLoginExecutorAccessRule(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/login.schema.json")
+	conf.MustSet(config.ViperKeySelfServiceLoginAccessRuleURL, "file://./stub/access_rule.jsonnet")
+
+	login := func(t *testing.T, traits string) (string, error) {
+		var err error
+		router := httprouter.New()
+		router.GET("/login/post", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+			i := testhelpers.SelfServiceHookFakeIdentity(t)
+			i.Traits = identity.Traits(traits)
+			require.NoError(t, reg.IdentityManager().Create(r.Context(), i))
+
+			err = reg.LoginHookExecutor().PostLoginHook(w, r, identity.CredentialsTypePassword, login.NewFlow(conf, time.Minute, "", r, flow.TypeAPI), i)
+			testhelpers.SelfServiceHookLoginErrorHandler(t, w, r, err)
+		})
+
+		ts := httptest.NewServer(router)
+		defer ts.Close()
+
+		_, body := testhelpers.SelfServiceMakeLoginPostHookRequest(t, ts, true, url.Values{})
+		return body, err
+	}
+
+	var reason = func(t *testing.T, err error) string {
+		var ve *schema.ValidationError
+		require.True(t, errors.As(err, &ve), "%+v", err)
+		require.Len(t, ve.Messages, 1)
+		assert.EqualValues(t, text.ErrorValidationLoginNotAllowed, ve.Messages[0].ID)
+		return ve.Messages[0].Text
+	}
+
+	t.Run("case=allows identities matching the rule", func(t *testing.T) {
+		body, err := login(t, `{"bar":"active"}`)
+		require.NoError(t, err)
+		assert.NotEmpty(t, gjson.Get(body, "session_token").String(), "%s", body)
+	})
+
+	t.Run("case=refuses identities not matching the rule", func(t *testing.T) {
+		body, err := login(t, `{"bar":"suspended"}`)
+		assert.Equal(t, "You are not allowed to sign in.", reason(t, err))
+		assert.Empty(t, gjson.Get(body, "session_token").String(), "%s", body)
+	})
+
+	t.Run("case=uses the configured message", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceLoginAccessRuleMessage, "Your account is suspended.")
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceLoginAccessRuleMessage, "You are not allowed to sign in.")
+		})
+
+		_, err := login(t, `{"bar":"suspended"}`)
+		assert.Equal(t, "Your account is suspended.", reason(t, err))
+	})
+
+	t.Run("case=fails if the rule does not return a boolean", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceLoginAccessRuleURL, "file://./stub/access_rule.invalid.jsonnet")
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceLoginAccessRuleURL, "file://./stub/access_rule.jsonnet")
+		})
+
+		body, err := login(t, `{"bar":"active"}`)
+		require.Error(t, err)
+		assert.Contains(t, fmt.Sprintf("%+v", err), `must return a boolean for key "allow"`)
+		assert.Empty(t, gjson.Get(body, "session_token").String(), "%s", body)
+	})
+}
+
+func TestLoginExecutorRefresh(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/login.schema.json")
+
+	signedIn := testhelpers.SelfServiceHookCreateFakeIdentity(t, reg)
+	sess := session.NewActiveSession(signedIn, conf, time.Now().UTC().Add(-time.Hour))
+	require.NoError(t, reg.SessionPersister().CreateSession(context.Background(), sess))
+
+	refresh := func(t *testing.T, i *identity.Identity) (string, error) {
+		var err error
+		router := httprouter.New()
+		router.GET("/login/post", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+			f := login.NewFlow(conf, time.Minute, "", r, flow.TypeAPI)
+			f.Forced = true
+			err = reg.LoginHookExecutor().PostLoginHook(w, r, identity.CredentialsTypePassword, f, i)
+			testhelpers.SelfServiceHookLoginErrorHandler(t, w, r, err)
+		})
+
+		ts := httptest.NewServer(router)
+		defer ts.Close()
+
+		req, rerr := http.NewRequest("GET", ts.URL+"/login/post", nil)
+		require.NoError(t, rerr)
+		req.Header.Set("X-Session-Token", sess.Token)
+		res, rerr := ts.Client().Do(req)
+		require.NoError(t, rerr)
+		defer res.Body.Close()
+		return string(x.MustReadAll(res.Body)), err
+	}
+
+	t.Run("case=refuses a different identity", func(t *testing.T) {
+		_, err := refresh(t, testhelpers.SelfServiceHookCreateFakeIdentity(t, reg))
+		var ve *schema.ValidationError
+		require.True(t, errors.As(err, &ve), "%+v", err)
+		assert.EqualValues(t, text.ErrorValidationLoginIdentityMismatch, ve.Messages[0].ID)
+
+		actual, err := reg.SessionPersister().GetSession(context.Background(), sess.ID)
+		require.NoError(t, err)
+		assert.Equal(t, sess.AuthenticatedAt.Unix(), actual.AuthenticatedAt.Unix())
+	})
+
+	t.Run("case=refreshes the current session", func(t *testing.T) {
+		body, err := refresh(t, signedIn)
+		require.NoError(t, err)
+		assert.Equal(t, sess.ID.String(), gjson.Get(body, "session.id").String(), "%s", body)
+		assert.Equal(t, sess.Token, gjson.Get(body, "session_token").String(), "%s", body)
+
+		actual, err := reg.SessionPersister().GetSession(context.Background(), sess.ID)
+		require.NoError(t, err)
+		assert.True(t, actual.AuthenticatedAt.After(sess.AuthenticatedAt.Add(time.Minute)))
+		assert.Equal(t, sess.IssuedAt.Unix(), actual.IssuedAt.Unix())
+	})
+}
@@ -0,0 +1,90 @@
+package login
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/kratos/identity"
+)
+
+type (
+	// CredentialsToLink are credentials, for example of a social sign in provider, which belong to an existing
+	// identity but were not yet added to it. They are added once the identity confirmed the link by completing
+	// the login flow with its existing credentials.
+	CredentialsToLink struct {
+		// IdentityID is the identity which must sign in for the credentials to be linked.
+		IdentityID uuid.UUID `json:"identity_id"`
+
+		// Type is the type of the credentials, for example `oidc`.
+		Type identity.CredentialsType `json:"type"`
+
+		// Config is the strategy specific configuration of the credentials.
+		Config json.RawMessage `json:"config"`
+	}
+
+	// LinkableStrategy is implemented by strategies whose credentials can be linked to an existing identity.
+	LinkableStrategy interface {
+		// LinkCredentials adds the credentials to the identity and stores the identity.
+		LinkCredentials(r *http.Request, i *identity.Identity, config json.RawMessage) error
+	}
+)
+
+// SetCredentialsToLink stores the credentials which are linked to the identity once the flow completes.
+func (f *Flow) SetCredentialsToLink(c *CredentialsToLink) error {
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	f.LinkCredentials = raw
+	return nil
+}
+
+// CredentialsToLink returns the credentials which are linked to the identity once the flow completes or nil
+// if there are none.
+func (f *Flow) CredentialsToLink() (*CredentialsToLink, error) {
+	if len(f.LinkCredentials) == 0 || string(f.LinkCredentials) == "null" {
+		return nil, nil
+	}
+
+	var c CredentialsToLink
+	if err := json.Unmarshal(f.LinkCredentials, &c); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &c, nil
+}
+
+// linkCredentials links the flow's credentials to the identity which completed the flow. The credentials are
+// not linked if a different identity signed in.
+func (e *HookExecutor) linkCredentials(r *http.Request, a *Flow, i *identity.Identity) error {
+	c, err := a.CredentialsToLink()
+	if err != nil || c == nil {
+		return err
+	}
+
+	l := e.d.Logger().WithRequest(r).WithField("identity_id", i.ID).WithField("credentials_type", c.Type)
+	if c.IdentityID != i.ID {
+		l.Debug("Not linking the credentials because a different identity signed in.")
+		return nil
+	}
+
+	s, err := e.d.AllLoginStrategies().Strategy(c.Type)
+	if err != nil {
+		return err
+	}
+
+	ls, ok := s.(LinkableStrategy)
+	if !ok {
+		return errors.Errorf("the strategy %s does not support linking credentials", c.Type)
+	}
+
+	if err := ls.LinkCredentials(r, i, c.Config); err != nil {
+		return err
+	}
+
+	l.Info("Linked the credentials to the identity.")
+	return nil
+}
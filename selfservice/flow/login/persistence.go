@@ -12,6 +12,7 @@ type (
 		CreateLoginFlow(context.Context, *Flow) error
 		GetLoginFlow(context.Context, uuid.UUID) (*Flow, error)
 		ForceLoginFlow(ctx context.Context, id uuid.UUID) error
+		CountActiveLoginFlowsByIdentifier(ctx context.Context, identifier string, except uuid.UUID) (int, error)
 	}
 	FlowPersistenceProvider interface {
 		LoginFlowPersister() FlowPersister
@@ -111,6 +111,24 @@ func TestFlowPersister(ctx context.Context, p persistence.Persister) func(t *tes
 			assertx.EqualAsJSON(t, expected.UI, actual.UI)
 		})
 
+		t.Run("case=should count active flows by identifier", func(t *testing.T) {
+			identifier := x.NewUUID().String()
+			for _, exp := range []time.Duration{time.Hour, time.Hour, -time.Hour} {
+				f := newFlow(t)
+				f.ExpiresAt = time.Now().Add(exp)
+				f.Identifier = identifier
+				require.NoError(t, p.CreateLoginFlow(ctx, f))
+			}
+
+			count, err := p.CountActiveLoginFlowsByIdentifier(ctx, identifier, x.NewUUID())
+			require.NoError(t, err)
+			assert.Equal(t, 2, count)
+
+			count, err = p.CountActiveLoginFlowsByIdentifier(ctx, x.NewUUID().String(), x.NewUUID())
+			require.NoError(t, err)
+			assert.Equal(t, 0, count)
+		})
+
 		t.Run("case=network", func(t *testing.T) {
 			id := x.NewUUID()
 			nid, p := testhelpers.NewNetwork(t, ctx, p)
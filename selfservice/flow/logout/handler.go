@@ -7,6 +7,7 @@ import (
 	"github.com/julienschmidt/httprouter"
 
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/event"
 	"github.com/ory/kratos/selfservice/errorx"
 	"github.com/ory/kratos/session"
 	"github.com/ory/kratos/x"
@@ -22,6 +23,7 @@ type (
 		session.ManagementProvider
 		errorx.ManagementProvider
 		config.Provider
+		event.EmitterProvider
 	}
 	HandlerProvider interface {
 		LogoutHandler() *Handler
@@ -61,15 +63,21 @@ func (h *Handler) RegisterPublicRoutes(router *x.RouterPublic) {
 func (h *Handler) logout(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	_ = h.d.CSRFHandler().RegenerateToken(w, r)
 
+	s, _ := h.d.SessionManager().FetchFromRequest(r.Context(), r)
 	if err := h.d.SessionManager().PurgeFromRequest(r.Context(), w, r); err != nil {
 		h.d.SelfServiceErrorManager().Forward(r.Context(), w, r, err)
 		return
 	}
 
+	if s != nil {
+		h.d.EventEmitter().Emit(r.Context(), event.TypeSessionRevoked, &event.Data{IdentityID: s.IdentityID, SessionID: &s.ID})
+	}
+
 	ret, err := x.SecureRedirectTo(r, h.d.Config(r.Context()).SelfServiceFlowLogoutRedirectURL(),
 		x.SecureRedirectUseSourceURL(r.RequestURI),
 		x.SecureRedirectAllowURLs(h.d.Config(r.Context()).SelfServiceBrowserWhitelistedReturnToDomains()),
 		x.SecureRedirectAllowSelfServiceURLs(h.d.Config(r.Context()).SelfPublicURL(r)),
+		x.SecureRedirectRequireHTTPS(h.d.Config(r.Context()).SelfServiceBrowserRequireHTTPSReturnTo()),
 	)
 	if err != nil {
 		fmt.Printf("\n%s\n\n", err.Error())
@@ -70,6 +70,8 @@ func MethodToNodeGroup(method string) node.Group {
 	switch method {
 	case StrategyRecoveryLinkName:
 		return node.RecoveryLinkGroup
+	case StrategyRecoveryCodeName:
+		return node.RecoveryCodeGroup
 	default:
 		return node.DefaultGroup
 	}
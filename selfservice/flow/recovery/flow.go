@@ -11,6 +11,7 @@ import (
 
 	"github.com/ory/kratos/corp"
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/schema"
 	"github.com/ory/kratos/selfservice/flow"
 	"github.com/ory/kratos/ui/container"
 	"github.com/ory/kratos/x"
@@ -67,6 +68,7 @@ type Flow struct {
 	//
 	// - choose_method: ask the user to choose a method (e.g. recover account via email)
 	// - sent_email: the email has been sent to the user
+	// - set_password: the recovery link was used and the user needs to set a new password
 	// - passed_challenge: the request was successful and the recovery challenge was passed.
 	//
 	// required: true
@@ -81,6 +83,12 @@ type Flow struct {
 	// UpdatedAt is a helper struct field for gobuffalo.pop.
 	UpdatedAt time.Time `json:"-" faker:"-" db:"updated_at"`
 
+	// LastSentAt is the time (UTC) when the last recovery email of this flow was sent.
+	LastSentAt sqlxx.NullTime `json:"-" faker:"-" db:"last_sent_at"`
+
+	// ResendCount is the number of recovery emails which were sent after the first one.
+	ResendCount int `json:"-" faker:"-" db:"resend_count"`
+
 	// RecoveredIdentityID is a helper struct field for gobuffalo.pop.
 	RecoveredIdentityID uuid.NullUUID `json:"-" faker:"-" db:"recovered_identity_id"`
 	NID                 uuid.UUID     `json:"-"  faker:"-" db:"nid"`
@@ -119,6 +127,10 @@ func (f *Flow) GetRequestURL() string {
 	return f.RequestURL
 }
 
+func (f *Flow) GetUI() *container.Container {
+	return f.UI
+}
+
 func (f Flow) TableName(ctx context.Context) string {
 	return corp.ContextualizeTableName(ctx, "selfservice_recovery_flows")
 }
@@ -141,3 +153,29 @@ func (f *Flow) Valid() error {
 func (f *Flow) AppendTo(src *url.URL) *url.URL {
 	return urlx.CopyWithQuery(src, url.Values{"flow": {f.ID.String()}})
 }
+
+// EnsureCanSend returns an error if the recovery email of this flow was sent too recently or too often to be
+// sent again. The first email can always be sent.
+func (f *Flow) EnsureCanSend(conf *config.Config) error {
+	if time.Time(f.LastSentAt).IsZero() {
+		return nil
+	}
+
+	if max := conf.SelfServiceFlowRecoveryMaxResends(); f.ResendCount >= max {
+		return schema.NewRecoveryResendLimitError(max)
+	}
+
+	if resendAt := time.Time(f.LastSentAt).Add(conf.SelfServiceFlowRecoveryResendCooldown()); resendAt.After(time.Now()) {
+		return schema.NewRecoveryResendCooldownError(resendAt)
+	}
+
+	return nil
+}
+
+// MarkSent records that a recovery email of this flow was sent.
+func (f *Flow) MarkSent() {
+	if !time.Time(f.LastSentAt).IsZero() {
+		f.ResendCount++
+	}
+	f.LastSentAt = sqlxx.NullTime(time.Now().UTC())
+}
@@ -11,10 +11,14 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/ory/x/sqlxx"
 	"github.com/ory/x/urlx"
 
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/schema"
 	"github.com/ory/kratos/selfservice/flow"
 	"github.com/ory/kratos/selfservice/flow/recovery"
+	"github.com/ory/kratos/text"
 )
 
 func TestFlow(t *testing.T) {
@@ -64,3 +68,30 @@ func TestGetRequestURL(t *testing.T) {
 	f := &recovery.Flow{RequestURL: expectedURL}
 	assert.Equal(t, expectedURL, f.GetRequestURL())
 }
+
+func TestEnsureCanSend(t *testing.T) {
+	conf := internal.NewConfigurationWithDefaults(t)
+	conf.MustSet(config.ViperKeySelfServiceRecoveryResendCooldown, "1m")
+	conf.MustSet(config.ViperKeySelfServiceRecoveryMaxResends, 1)
+
+	expectError := func(t *testing.T, f *recovery.Flow, expected text.ID) {
+		var e *schema.ValidationError
+		require.ErrorAs(t, f.EnsureCanSend(conf), &e)
+		assert.Equal(t, expected, e.Messages[0].ID)
+	}
+
+	f := new(recovery.Flow)
+	require.NoError(t, f.EnsureCanSend(conf), "the first email can always be sent")
+
+	f.MarkSent()
+	assert.Equal(t, 0, f.ResendCount)
+	expectError(t, f, text.ErrorValidationRecoveryResendCooldown)
+
+	f.LastSentAt = sqlxx.NullTime(time.Now().Add(-time.Minute))
+	require.NoError(t, f.EnsureCanSend(conf))
+
+	f.MarkSent()
+	assert.Equal(t, 1, f.ResendCount)
+	f.LastSentAt = sqlxx.NullTime(time.Now().Add(-time.Minute))
+	expectError(t, f, text.ErrorValidationRecoveryResendLimit)
+}
@@ -291,10 +291,9 @@ func (h *Handler) submitFlow(w http.ResponseWriter, r *http.Request, ps httprout
 		return
 	}
 
-	if err := f.Valid(); err != nil {
-		h.d.RecoveryFlowErrorHandler().WriteFlowError(w, r, f, node.DefaultGroup, err)
-		return
-	}
+	// The expiry of the flow is validated by the strategies instead of here because recovery links may outlive
+	// the flow they were requested from, regardless of whether the token is sent in the query or the body.
+	// Strategies redeeming a token validate the token's expiry and continue in a new flow if necessary.
 
 	var g node.Group
 	var found bool
@@ -5,10 +5,11 @@ type State string
 const (
 	StateChooseMethod    State = "choose_method"
 	StateEmailSent       State = "sent_email"
+	StateSetPassword     State = "set_password"
 	StatePassedChallenge State = "passed_challenge"
 )
 
-var states = []State{StateChooseMethod, StateEmailSent, StatePassedChallenge}
+var states = []State{StateChooseMethod, StateEmailSent, StateSetPassword, StatePassedChallenge}
 
 func indexOf(current State) int {
 	for k, s := range states {
@@ -8,10 +8,12 @@ import (
 
 func TestState(t *testing.T) {
 	assert.EqualValues(t, StateEmailSent, NextState(StateChooseMethod))
-	assert.EqualValues(t, StatePassedChallenge, NextState(StateEmailSent))
+	assert.EqualValues(t, StateSetPassword, NextState(StateEmailSent))
+	assert.EqualValues(t, StatePassedChallenge, NextState(StateSetPassword))
 	assert.EqualValues(t, StatePassedChallenge, NextState(StatePassedChallenge))
 
 	assert.True(t, HasReachedState(StatePassedChallenge, StatePassedChallenge))
 	assert.False(t, HasReachedState(StatePassedChallenge, StateEmailSent))
+	assert.False(t, HasReachedState(StatePassedChallenge, StateSetPassword))
 	assert.False(t, HasReachedState(StateEmailSent, StateChooseMethod))
 }
@@ -0,0 +1,124 @@
+package registration
+
+import (
+	"bufio"
+	"context"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ory/x/fetcher"
+
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/schema"
+)
+
+// blocklist caches the entries loaded from `selfservice.flows.registration.blocklist.url`.
+type blocklist struct {
+	sync.Mutex
+	f           *fetcher.Fetcher
+	source      string
+	fetchedAt   time.Time
+	identifiers []string
+	domains     []string
+}
+
+func newBlocklist() *blocklist {
+	return &blocklist{f: fetcher.NewFetcher()}
+}
+
+// checkBlocklist returns a validation error if any identifier or address of the identity is blocked
+// by `selfservice.flows.registration.blocklist`.
+func (e *HookExecutor) checkBlocklist(ctx context.Context, i *identity.Identity) error {
+	c := e.d.Config(ctx)
+	identifiers, domains := c.SelfServiceFlowRegistrationBlocklist()
+	if source := c.SelfServiceFlowRegistrationBlocklistURL(); source != nil {
+		li, ld, err := e.blocklist.load(source.String(), c.SelfServiceFlowRegistrationBlocklistRefreshInterval())
+		if err != nil {
+			e.d.Logger().WithError(err).WithField("blocklist_url", source.String()).
+				Error("Unable to load the registration blocklist, using the previously loaded entries.")
+		}
+		identifiers = append(append([]string{}, identifiers...), li...)
+		domains = append(append([]string{}, domains...), ld...)
+	}
+
+	if len(identifiers)+len(domains) == 0 {
+		return nil
+	}
+
+	for _, candidate := range blocklistCandidates(i) {
+		for _, blocked := range identifiers {
+			if strings.EqualFold(candidate, strings.TrimSpace(blocked)) {
+				return schema.NewBlockedIdentifierError(candidate)
+			}
+		}
+
+		at := strings.LastIndex(candidate, "@")
+		if at < 0 {
+			continue
+		}
+
+		domain := strings.ToLower(candidate[at+1:])
+		for _, pattern := range domains {
+			if ok, _ := path.Match(strings.ToLower(strings.TrimSpace(pattern)), domain); ok {
+				return schema.NewBlockedIdentifierError(candidate)
+			}
+		}
+	}
+
+	return nil
+}
+
+// load returns the entries of the list at source and fetches it again once the refresh interval passed.
+// If fetching fails, the previously loaded entries are returned with the error and used until the next refresh.
+func (b *blocklist) load(source string, refresh time.Duration) (identifiers, domains []string, err error) {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.source == source && time.Since(b.fetchedAt) < refresh {
+		return b.identifiers, b.domains, nil
+	}
+
+	if b.source != source {
+		b.identifiers, b.domains = nil, nil
+	}
+	b.source, b.fetchedAt = source, time.Now()
+
+	list, err := b.f.Fetch(source)
+	if err != nil {
+		return b.identifiers, b.domains, err
+	}
+
+	identifiers, domains = []string{}, []string{}
+	scanner := bufio.NewScanner(list)
+	for scanner.Scan() {
+		entry := strings.TrimSpace(scanner.Text())
+		if len(entry) == 0 || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		if strings.Contains(entry, "@") {
+			identifiers = append(identifiers, entry)
+		} else {
+			domains = append(domains, entry)
+		}
+	}
+
+	b.identifiers, b.domains = identifiers, domains
+	return b.identifiers, b.domains, nil
+}
+
+func blocklistCandidates(i *identity.Identity) []string {
+	var candidates []string
+	for _, c := range i.Credentials {
+		candidates = append(candidates, c.Identifiers...)
+	}
+	for _, a := range i.VerifiableAddresses {
+		candidates = append(candidates, a.Value)
+	}
+	for _, a := range i.RecoveryAddresses {
+		candidates = append(candidates, a.Value)
+	}
+	return candidates
+}
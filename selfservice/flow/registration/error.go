@@ -16,6 +16,7 @@ import (
 	"github.com/ory/x/urlx"
 
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/selfservice/errorx"
 	"github.com/ory/kratos/x"
 )
@@ -31,6 +32,7 @@ type (
 		x.WriterProvider
 		x.LoggingProvider
 		config.Provider
+		identity.SchemaResolverProvider
 
 		FlowPersistenceProvider
 		HandlerProvider
@@ -111,7 +113,13 @@ func (s *ErrorHandler) WriteFlowError(
 		return
 	}
 
-	if err := SortNodes(f.UI.Nodes, s.d.Config(r.Context()).DefaultIdentityTraitsSchemaURL().String()); err != nil {
+	ts, err := s.d.IdentitySchemaResolver().TraitsSchemaForRequest(r)
+	if err != nil {
+		s.forward(w, r, f, err)
+		return
+	}
+
+	if err := SortNodes(f.UI.Nodes, ts.URL); err != nil {
 		s.forward(w, r, f, err)
 		return
 	}
@@ -116,3 +116,7 @@ func (f *Flow) GetType() flow.Type {
 func (f *Flow) GetRequestURL() string {
 	return f.RequestURL
 }
+
+func (f *Flow) GetUI() *container.Container {
+	return f.UI
+}
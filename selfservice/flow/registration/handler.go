@@ -34,6 +34,7 @@ type (
 	handlerDependencies interface {
 		config.Provider
 		errorx.ManagementProvider
+		identity.SchemaResolverProvider
 		session.HandlerProvider
 		session.ManagementProvider
 		x.WriterProvider
@@ -91,7 +92,12 @@ func (h *Handler) NewRegistrationFlow(w http.ResponseWriter, r *http.Request, ft
 		}
 	}
 
-	if err := SortNodes(f.UI.Nodes, h.d.Config(r.Context()).DefaultIdentityTraitsSchemaURL().String()); err != nil {
+	ts, err := h.d.IdentitySchemaResolver().TraitsSchemaForRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := SortNodes(f.UI.Nodes, ts.URL); err != nil {
 		return nil, err
 	}
 
@@ -317,7 +323,13 @@ func (h *Handler) submitFlow(w http.ResponseWriter, r *http.Request, _ httproute
 		return
 	}
 
-	i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+	ts, err := h.d.IdentitySchemaResolver().TraitsSchemaForRequest(r)
+	if err != nil {
+		h.d.RegistrationFlowErrorHandler().WriteFlowError(w, r, f, node.DefaultGroup, err)
+		return
+	}
+
+	i := identity.NewIdentity(ts.ID)
 	var found bool
 	var s identity.CredentialsType
 	for _, ss := range h.d.AllRegistrationStrategies() {
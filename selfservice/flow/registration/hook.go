@@ -10,11 +10,15 @@ import (
 
 	"github.com/ory/x/sqlcon"
 
+	"github.com/ory/kratos/courier"
+	templates "github.com/ory/kratos/courier/template"
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/event"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/schema"
 	"github.com/ory/kratos/selfservice/flow"
 	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/text"
 	"github.com/ory/kratos/x"
 )
 
@@ -62,15 +66,20 @@ func (f PostHookPrePersistExecutorFunc) ExecutePostRegistrationPrePersistHook(w
 type (
 	executorDependencies interface {
 		config.Provider
+		courier.Provider
+		event.EmitterProvider
 		identity.ManagementProvider
+		identity.PrivilegedPoolProvider
 		identity.ValidationProvider
+		FlowPersistenceProvider
 		session.PersistenceProvider
 		HooksProvider
 		x.LoggingProvider
 		x.WriterProvider
 	}
 	HookExecutor struct {
-		d executorDependencies
+		d         executorDependencies
+		blocklist *blocklist
 	}
 	HookExecutorProvider interface {
 		RegistrationExecutor() *HookExecutor
@@ -78,7 +87,7 @@ type (
 )
 
 func NewHookExecutor(d executorDependencies) *HookExecutor {
-	return &HookExecutor{d: d}
+	return &HookExecutor{d: d, blocklist: newBlocklist()}
 }
 
 func (e *HookExecutor) PostRegistrationHook(w http.ResponseWriter, r *http.Request, ct identity.CredentialsType, a *Flow, i *identity.Identity) error {
@@ -115,10 +124,15 @@ func (e *HookExecutor) PostRegistrationHook(w http.ResponseWriter, r *http.Reque
 	// We need to make sure that the identity has a valid schema before passing it down to the identity pool.
 	if err := e.d.IdentityValidator().Validate(r.Context(), i); err != nil {
 		return err
+	} else if err := e.checkBlocklist(r.Context(), i); err != nil {
+		return err
 		// We're now creating the identity because any of the hooks could trigger a "redirect" or a "session" which
 		// would imply that the identity has to exist already.
 	} else if err := e.d.IdentityManager().Create(r.Context(), i); err != nil {
 		if errors.Is(err, sqlcon.ErrUniqueViolation) {
+			if e.d.Config(r.Context()).SelfServiceFlowRegistrationDuplicateIdentifierPolicy() == "notify" {
+				return e.notifyDuplicateIdentifier(w, r, a, i)
+			}
 			return schema.NewDuplicateCredentialsError()
 		}
 		return err
@@ -128,6 +142,8 @@ func (e *HookExecutor) PostRegistrationHook(w http.ResponseWriter, r *http.Reque
 		WithRequest(r).
 		WithField("identity_id", i.ID).
 		Info("A new identity has registered using self-service registration.")
+	e.d.EventEmitter().Emit(r.Context(), event.TypeRegistrationSucceeded, &event.Data{
+		IdentityID: i.ID, FlowID: &a.ID, FlowType: string(a.Type), Method: ct.String()})
 
 	s := session.NewActiveSession(i, e.d.Config(r.Context()), time.Now().UTC())
 	e.d.Logger().
@@ -166,6 +182,10 @@ func (e *HookExecutor) PostRegistrationHook(w http.ResponseWriter, r *http.Reque
 		WithField("identity_id", i.ID).
 		Debug("Post registration execution hooks completed successfully.")
 
+	if e.d.Config(r.Context()).SelfServiceFlowRegistrationDuplicateIdentifierPolicy() == "notify" {
+		return e.respondEmailSent(w, r, a)
+	}
+
 	if a.Type == flow.TypeAPI {
 		e.d.Writer().Write(w, r, &APIFlowResponse{Identity: i})
 		return nil
@@ -175,6 +195,79 @@ func (e *HookExecutor) PostRegistrationHook(w http.ResponseWriter, r *http.Reque
 		e.d.Writer(), e.d.Config(r.Context()), x.SecureRedirectOverrideDefaultReturnTo(e.d.Config(r.Context()).SelfServiceFlowRegistrationReturnTo(ct.String())))
 }
 
+// notifyDuplicateIdentifier sends a notice to the email addresses of the identities which already use one of the
+// identifiers of i and responds like to a registration with new identifiers. This way the response does not reveal
+// whether an account exists.
+func (e *HookExecutor) notifyDuplicateIdentifier(w http.ResponseWriter, r *http.Request, a *Flow, i *identity.Identity) error {
+	ctx := r.Context()
+
+	notified := map[string]bool{}
+	for ct, c := range i.Credentials {
+		for _, identifier := range c.Identifiers {
+			existing, _, err := e.d.PrivilegedIdentityPool().FindByCredentialsIdentifier(ctx, ct, identifier)
+			if errors.Is(err, sqlcon.ErrNoRows) {
+				continue
+			} else if err != nil {
+				return err
+			}
+
+			for _, to := range duplicateNoticeRecipients(existing) {
+				if notified[to] {
+					continue
+				}
+				notified[to] = true
+
+				if _, err := e.d.Courier(ctx).QueueEmail(ctx, templates.NewRegistrationDuplicate(e.d.Config(ctx),
+					&templates.RegistrationDuplicateModel{To: to})); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	e.d.Audit().
+		WithRequest(r).
+		WithField("notified_addresses", len(notified)).
+		Info("A registration attempt used an identifier which is already in use.")
+
+	return e.respondEmailSent(w, r, a)
+}
+
+// respondEmailSent asks the user to check their email without issuing a session. It is the response to every
+// registration if the duplicate identifier policy is `notify`, whether the identifiers were in use or not.
+func (e *HookExecutor) respondEmailSent(w http.ResponseWriter, r *http.Request, a *Flow) error {
+	ctx := r.Context()
+
+	a.UI.ResetMessages()
+	a.UI.Messages.Set(text.NewRegistrationEmailSent())
+	if err := e.d.RegistrationFlowPersister().UpdateRegistrationFlow(ctx, a); err != nil {
+		return err
+	}
+
+	if a.Type == flow.TypeBrowser && !x.IsJSONRequest(r) {
+		http.Redirect(w, r, a.AppendTo(e.d.Config(ctx).SelfServiceFlowRegistrationUI()).String(), http.StatusSeeOther)
+		return nil
+	}
+
+	e.d.Writer().Write(w, r, a)
+	return nil
+}
+
+func duplicateNoticeRecipients(i *identity.Identity) []string {
+	var to []string
+	for _, a := range i.VerifiableAddresses {
+		if a.Via == identity.VerifiableAddressTypeEmail {
+			to = append(to, a.Value)
+		}
+	}
+	for _, a := range i.RecoveryAddresses {
+		if a.Via == identity.RecoveryAddressTypeEmail {
+			to = append(to, a.Value)
+		}
+	}
+	return to
+}
+
 func (e *HookExecutor) PreRegistrationHook(w http.ResponseWriter, r *http.Request, a *Flow) error {
 	for _, executor := range e.d.PreRegistrationHooks(r.Context()) {
 		if err := executor.ExecuteRegistrationPreHook(w, r, a); err != nil {
@@ -19,6 +19,8 @@ import (
 	"github.com/ory/kratos/internal/testhelpers"
 	"github.com/ory/kratos/selfservice/flow"
 	"github.com/ory/kratos/selfservice/flow/registration"
+	"github.com/ory/kratos/selfservice/hook"
+	"github.com/ory/kratos/text"
 	"github.com/ory/kratos/x"
 )
 
@@ -174,3 +176,152 @@ func TestRegistrationExecutor(t *testing.T) {
 		})
 	}
 }
+
+func TestRegistrationExecutorDuplicateIdentifier(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/identity.schema.json")
+	conf.MustSet(config.ViperKeySelfServiceBrowserDefaultReturnTo, "https://www.ory.sh/")
+
+	existing := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+	existing.Traits = identity.Traits(`{"email":"duplicate@ory.sh"}`)
+	require.NoError(t, reg.IdentityManager().Create(ctx, existing))
+
+	register := func(t *testing.T, ft flow.Type, email string) (*identity.Identity, *http.Response, string) {
+		i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+		i.Traits = identity.Traits(`{"email":"` + email + `"}`)
+
+		router := httprouter.New()
+		router.GET("/registration/post", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+			a := registration.NewFlow(conf, time.Minute, x.FakeCSRFToken, r, ft)
+			require.NoError(t, reg.RegistrationFlowPersister().CreateRegistrationFlow(r.Context(), a))
+
+			_ = testhelpers.SelfServiceHookRegistrationErrorHandler(t, w, r,
+				reg.RegistrationHookExecutor().PostRegistrationHook(w, r, identity.CredentialsTypePassword, a, i))
+		})
+		router.GET("/registration/ui", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+			_, _ = w.Write([]byte("registration ui"))
+		})
+
+		ts := httptest.NewServer(router)
+		t.Cleanup(ts.Close)
+		conf.MustSet(config.ViperKeyPublicBaseURL, ts.URL)
+		conf.MustSet(config.ViperKeySelfServiceRegistrationUI, ts.URL+"/registration/ui")
+
+		res, body := testhelpers.SelfServiceMakeRegistrationPostHookRequest(t, ts, ft == flow.TypeAPI, url.Values{})
+		return i, res, body
+	}
+
+	t.Run("policy=error", func(t *testing.T) {
+		_, res, body := register(t, flow.TypeAPI, "duplicate@ory.sh")
+		assert.EqualValues(t, http.StatusInternalServerError, res.StatusCode)
+		assert.Contains(t, body, "an account with the same identifier (email, phone, username, ...) exists already")
+	})
+
+	t.Run("policy=notify", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceRegistrationDuplicateIdentifierPolicy, "notify")
+		testhelpers.SelfServiceHookRegistrationViperSetPost(t, conf, identity.CredentialsTypePassword.String(),
+			[]config.SelfServiceHook{{Name: hook.KeySessionIssuer}})
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceRegistrationDuplicateIdentifierPolicy, "error")
+			testhelpers.SelfServiceHookConfigReset(t, conf)()
+		})
+
+		for _, ft := range []flow.Type{flow.TypeAPI, flow.TypeBrowser} {
+			t.Run("flow="+string(ft), func(t *testing.T) {
+				// Registrations with new and with duplicate identifiers must not be told apart.
+				var responses []string
+				for _, email := range []string{"duplicate@ory.sh", "new-" + string(ft) + "@ory.sh"} {
+					i, res, body := register(t, ft, email)
+					assert.EqualValues(t, http.StatusOK, res.StatusCode, "%s", body)
+					assert.Empty(t, res.Header.Get("Set-Cookie"), "%s", body)
+					if ft == flow.TypeBrowser {
+						assert.Equal(t, "registration ui", body)
+						responses = append(responses, body)
+						continue
+					}
+
+					assert.EqualValues(t, text.InfoSelfServiceRegistrationEmailSent, gjson.Get(body, "ui.messages.0.id").Int(), "%s", body)
+					for _, key := range []string{"identity", "session", "session_token"} {
+						assert.False(t, gjson.Get(body, key).Exists(), "%s", body)
+					}
+					responses = append(responses, gjson.Get(body, "ui.messages").Raw)
+
+					if email != "duplicate@ory.sh" {
+						_, err := reg.IdentityPool().GetIdentity(ctx, i.ID)
+						require.NoError(t, err, "the new identity must be created")
+						sessions, _, err := reg.SessionPersister().ListSessionsByIdentity(ctx, i.ID, nil, 0, 10)
+						require.NoError(t, err)
+						assert.Empty(t, sessions, "no session may be issued")
+					}
+				}
+				assert.Equal(t, responses[0], responses[1])
+			})
+		}
+
+		testhelpers.CourierExpectMessage(t, reg, "duplicate@ory.sh", "Account already exists")
+	})
+}
+
+func TestRegistrationExecutorBlocklist(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/identity.schema.json")
+	conf.MustSet(config.ViperKeySelfServiceBrowserDefaultReturnTo, "https://www.ory.sh/")
+	conf.MustSet(config.ViperKeySelfServiceRegistrationBlocklistIdentifiers, []string{"Abuse@ory.sh"})
+	conf.MustSet(config.ViperKeySelfServiceRegistrationBlocklistDomains, []string{"blocked.example", "*.blocked.example"})
+
+	register := func(t *testing.T, email string) (*http.Response, string) {
+		router := httprouter.New()
+		router.GET("/registration/post", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+			a := registration.NewFlow(conf, time.Minute, x.FakeCSRFToken, r, flow.TypeAPI)
+			require.NoError(t, reg.RegistrationFlowPersister().CreateRegistrationFlow(r.Context(), a))
+
+			i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+			i.Traits = identity.Traits(`{"email":"` + email + `"}`)
+			_ = testhelpers.SelfServiceHookRegistrationErrorHandler(t, w, r,
+				reg.RegistrationHookExecutor().PostRegistrationHook(w, r, identity.CredentialsTypePassword, a, i))
+		})
+
+		ts := httptest.NewServer(router)
+		t.Cleanup(ts.Close)
+		conf.MustSet(config.ViperKeyPublicBaseURL, ts.URL)
+		return testhelpers.SelfServiceMakeRegistrationPostHookRequest(t, ts, true, url.Values{})
+	}
+
+	for _, tc := range []struct {
+		email   string
+		blocked bool
+	}{
+		{email: "abuse@ory.sh", blocked: true},
+		{email: "someone@blocked.example", blocked: true},
+		{email: "someone@mail.BLOCKED.example", blocked: true},
+		{email: "someone@notblocked.example"},
+		{email: "someone@tempmail.example"},
+	} {
+		t.Run("email="+tc.email, func(t *testing.T) {
+			res, body := register(t, tc.email)
+			if tc.blocked {
+				assert.EqualValues(t, http.StatusInternalServerError, res.StatusCode, "%s", body)
+				assert.Contains(t, body, "is not allowed", "%s", body)
+				return
+			}
+			assert.EqualValues(t, http.StatusOK, res.StatusCode, "%s", body)
+		})
+	}
+
+	t.Run("case=loads the blocklist from a url", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceRegistrationBlocklistURL, "file://./stub/blocklist.txt")
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceRegistrationBlocklistURL, "")
+		})
+
+		for _, email := range []string{"spammer@ory.sh", "someone@tempmail.example", "someone@mx.tempmail.example", "abuse@ory.sh"} {
+			res, body := register(t, email)
+			assert.EqualValues(t, http.StatusInternalServerError, res.StatusCode, "%s", body)
+			assert.Contains(t, body, "is not allowed", "%s", body)
+		}
+
+		res, body := register(t, "someone@ory.sh")
+		assert.EqualValues(t, http.StatusOK, res.StatusCode, "%s", body)
+	})
+}
@@ -139,6 +139,10 @@ func (f *Flow) GetRequestURL() string {
 	return f.RequestURL
 }
 
+func (f *Flow) GetUI() *container.Container {
+	return f.UI
+}
+
 func (f Flow) TableName(ctx context.Context) string {
 	return corp.ContextualizeTableName(ctx, "selfservice_settings_flows")
 }
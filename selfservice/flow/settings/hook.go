@@ -1,20 +1,29 @@
 package settings
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"reflect"
+	"sort"
 	"time"
 
+	"github.com/ory/kratos/event"
 	"github.com/ory/kratos/text"
+	"github.com/ory/kratos/ui/container"
 	"github.com/ory/kratos/ui/node"
 
 	"github.com/ory/kratos/schema"
+	"github.com/ory/x/jsonx"
 	"github.com/ory/x/sqlcon"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
+	"github.com/ory/herodot"
+
 	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/selfservice/flow"
@@ -37,7 +46,9 @@ type (
 	executorDependencies interface {
 		identity.ManagementProvider
 		identity.ValidationProvider
+		identity.PrivilegedPoolProvider
 		config.Provider
+		event.EmitterProvider
 
 		HooksProvider
 		FlowPersistenceProvider
@@ -105,6 +116,20 @@ func (e *HookExecutor) PostSettingsHook(w http.ResponseWriter, r *http.Request,
 		f(config)
 	}
 
+	original, err := e.d.PrivilegedIdentityPool().GetIdentityConfidential(r.Context(), i.ID)
+	if err != nil {
+		return err
+	}
+
+	if e.d.Config(r.Context()).SelfServiceFlowSettingsUnchanged() == "skip" && !identityChanged(original, i) {
+		e.d.Logger().
+			WithRequest(r).
+			WithField("identity_id", i.ID).
+			WithField("flow_method", settingsType).
+			Debug("Skipping the settings update because the submission did not change the identity.")
+		return e.skipUnchangedSettings(w, r, ctxUpdate, i)
+	}
+
 	for k, executor := range e.d.PostSettingsPrePersistHooks(r.Context(), settingsType) {
 		logFields := logrus.Fields{
 			"executor":          fmt.Sprintf("%T", executor),
@@ -132,6 +157,27 @@ func (e *HookExecutor) PostSettingsHook(w http.ResponseWriter, r *http.Request,
 		options = append(options, identity.ManagerAllowWriteProtectedTraits)
 	}
 
+	if ctxUpdate.Session.Recovered {
+		if ct, ok := disallowedCredentialChange(e.d.Config(r.Context()).SelfServiceFlowRecoveryAllowedCredentialChanges(), original, i); !ok {
+			e.d.Logger().WithRequest(r).WithField("credentials_type", ct).
+				Debug("Changing these credentials using a session issued by the recovery flow requires re-authentication.")
+			return errors.WithStack(&FlowNeedsReAuth{DefaultError: herodot.ErrForbidden.
+				WithReasonf("The %s credentials can not be changed using the session issued by the account recovery. Please re-authenticate.", ct)})
+		}
+	}
+
+	if ctxUpdate.Session.IsImpersonation() {
+		if ct, ok := disallowedCredentialChange(e.d.Config(r.Context()).SessionImpersonationAllowedCredentialChanges(), original, i); !ok {
+			e.d.Audit().WithRequest(r).
+				WithField("identity_id", i.ID).
+				WithField("impersonated_by", ctxUpdate.Session.ImpersonatedBy).
+				WithField("credentials_type", ct).
+				Info("An administrator tried to change credentials while impersonating an identity.")
+			return errors.WithStack(herodot.ErrForbidden.
+				WithReasonf("The %s credentials can not be changed while impersonating an identity.", ct))
+		}
+	}
+
 	if err := e.d.IdentityManager().Update(r.Context(), i, options...); err != nil {
 		if errors.Is(err, identity.ErrProtectedFieldModified) {
 			e.d.Logger().WithError(err).Debug("Modifying protected field requires re-authentication.")
@@ -142,10 +188,20 @@ func (e *HookExecutor) PostSettingsHook(w http.ResponseWriter, r *http.Request,
 		}
 		return err
 	}
+
+	ip := x.ClientIPString(r, e.d.Config(r.Context()).TrustedProxies())
+	if err := e.d.IdentityManager().RecordCredentialChanges(r.Context(), original, i, ip); err != nil {
+		return err
+	}
+
 	e.d.Audit().
 		WithRequest(r).
 		WithField("identity_id", i.ID).
+		WithField("impersonated_by", ctxUpdate.Session.ImpersonatedBy).
 		Debug("An identity's settings have been updated.")
+	e.d.EventEmitter().Emit(r.Context(), event.TypeSettingsSucceeded, &event.Data{
+		IdentityID: i.ID, FlowID: &ctxUpdate.Flow.ID, FlowType: string(ctxUpdate.Flow.Type), Method: settingsType,
+		ImpersonatedBy: ctxUpdate.Session.ImpersonatedBy})
 
 	ctxUpdate.UpdateIdentity(i)
 	ctxUpdate.Flow.State = StateSuccess
@@ -157,6 +213,7 @@ func (e *HookExecutor) PostSettingsHook(w http.ResponseWriter, r *http.Request,
 
 	ctxUpdate.Flow.UI.ResetMessages()
 	ctxUpdate.Flow.UI.AddMessage(node.DefaultGroup, text.NewInfoSelfServiceSettingsUpdateSuccess())
+	addChangedNodeMessages(ctxUpdate.Flow.UI, original, i)
 	if err := e.d.SettingsFlowPersister().UpdateSettingsFlow(r.Context(), ctxUpdate.Flow); err != nil {
 		return err
 	}
@@ -207,3 +264,155 @@ func (e *HookExecutor) PostSettingsHook(w http.ResponseWriter, r *http.Request,
 			e.d.Config(r.Context()).SelfServiceFlowSettingsReturnTo(settingsType,
 				ctxUpdate.Flow.AppendTo(e.d.Config(r.Context()).SelfServiceFlowSettingsUI()))))
 }
+
+// skipUnchangedSettings tells the user that there was nothing to save without writing the identity or
+// running any hooks.
+func (e *HookExecutor) skipUnchangedSettings(w http.ResponseWriter, r *http.Request, ctxUpdate *UpdateContext, i *identity.Identity) error {
+	ctxUpdate.Flow.UI.ResetMessages()
+	ctxUpdate.Flow.UI.AddMessage(node.DefaultGroup, text.NewInfoSelfServiceSettingsNoChanges())
+	if err := e.d.SettingsFlowPersister().UpdateSettingsFlow(r.Context(), ctxUpdate.Flow); err != nil {
+		return err
+	}
+
+	if ctxUpdate.Flow.Type == flow.TypeAPI {
+		updatedFlow, err := e.d.SettingsFlowPersister().GetSettingsFlow(r.Context(), ctxUpdate.Flow.ID)
+		if err != nil {
+			return err
+		}
+
+		e.d.Writer().Write(w, r, &APIFlowResponse{Flow: updatedFlow, Identity: i})
+		return nil
+	}
+
+	http.Redirect(w, r, ctxUpdate.Flow.AppendTo(e.d.Config(r.Context()).SelfServiceFlowSettingsUI()).String(), http.StatusFound)
+	return nil
+}
+
+// identityChanged returns true if the updated identity differs from the original one in its schema, traits,
+// or credentials.
+func identityChanged(original, updated *identity.Identity) bool {
+	if original.SchemaID != updated.SchemaID ||
+		len(changedTraits(original.Traits, updated.Traits)) > 0 ||
+		len(changedCredentials(original.Credentials, updated.Credentials)) > 0 {
+		return true
+	}
+
+	for ct := range original.Credentials {
+		if _, ok := updated.Credentials[ct]; !ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// addChangedNodeMessages adds a success message to the nodes of each trait and credential which differs
+// between the original and the updated identity.
+func addChangedNodeMessages(c *container.Container, original, updated *identity.Identity) {
+	for _, trait := range changedTraits(original.Traits, updated.Traits) {
+		if n := c.Nodes.Find("traits." + trait); n != nil {
+			n.Messages = append(n.Messages, *text.NewInfoSelfServiceSettingsUpdateSuccessTrait(trait))
+		}
+	}
+
+	for _, ct := range changedCredentials(original.Credentials, updated.Credentials) {
+		message := text.NewInfoSelfServiceSettingsUpdateSuccessCredentials(string(ct))
+
+		var found bool
+		for _, n := range c.Nodes {
+			if a, ok := n.Attributes.(*node.InputAttributes); ok && n.Group == node.Group(ct) &&
+				a.Type != node.InputAttributeTypeHidden && a.Type != node.InputAttributeTypeSubmit {
+				n.Messages = append(n.Messages, *message)
+				found = true
+			}
+		}
+
+		if !found {
+			c.Messages = append(c.Messages, *message)
+		}
+	}
+}
+
+func changedTraits(original, updated identity.Traits) (changed []string) {
+	o, u := jsonx.Flatten(json.RawMessage(original)), jsonx.Flatten(json.RawMessage(updated))
+	for k, v := range u {
+		if ov, ok := o[k]; !ok || !reflect.DeepEqual(ov, v) {
+			changed = append(changed, k)
+		}
+	}
+
+	for k := range o {
+		if _, ok := u[k]; !ok {
+			changed = append(changed, k)
+		}
+	}
+
+	sort.Strings(changed)
+	return changed
+}
+
+// changedCredentials returns the types of the updated credentials which were added or changed. Credentials
+// which are not part of the update are not considered changed.
+func changedCredentials(original, updated map[identity.CredentialsType]identity.Credentials) (changed []identity.CredentialsType) {
+	for ct, u := range updated {
+		o, ok := original[ct]
+		if !ok || !sameIdentifiers(o.Identifiers, u.Identifiers) || !jsonEqual(o.Config, u.Config) {
+			changed = append(changed, ct)
+		}
+	}
+
+	sort.Slice(changed, func(i, j int) bool {
+		return changed[i] < changed[j]
+	})
+	return changed
+}
+
+// disallowedCredentialChange returns the first credentials type which was added, removed, or
+// reconfigured between the original and the updated identity but is not part of allowed. Changed identifiers
+// alone are not considered, as they follow from changed traits.
+func disallowedCredentialChange(allowed []string, original, updated *identity.Identity) (identity.CredentialsType, bool) {
+	isAllowed := func(ct identity.CredentialsType) bool {
+		for _, a := range allowed {
+			if a == string(ct) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for ct, u := range updated.Credentials {
+		if o, ok := original.Credentials[ct]; (!ok || !jsonEqual(o.Config, u.Config)) && !isAllowed(ct) {
+			return ct, false
+		}
+	}
+
+	for ct := range original.Credentials {
+		if _, ok := updated.Credentials[ct]; !ok && !isAllowed(ct) {
+			return ct, false
+		}
+	}
+
+	return "", true
+}
+
+func sameIdentifiers(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	a, b = append([]string{}, a...), append([]string{}, b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	return reflect.DeepEqual(a, b)
+}
+
+func jsonEqual(a, b []byte) bool {
+	var av, bv interface{}
+	if err := json.Unmarshal(a, &av); err != nil {
+		return bytes.Equal(a, b)
+	}
+	if err := json.Unmarshal(b, &bv); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(av, bv)
+}
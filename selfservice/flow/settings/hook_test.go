@@ -1,6 +1,8 @@
 package settings_test
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/url"
 	"testing"
@@ -13,6 +15,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/ory/herodot"
+	"github.com/ory/x/sqlcon"
+
 	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/internal"
@@ -34,6 +39,8 @@ func TestSettingsExecutor(t *testing.T) {
 			conf, reg := internal.NewFastRegistryWithMocks(t)
 			conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/identity.schema.json")
 			conf.MustSet(config.ViperKeySelfServiceBrowserDefaultReturnTo, "https://www.ory.sh/")
+			// The fake identity is submitted as it is.
+			conf.MustSet(config.ViperKeySelfServiceSettingsUnchanged, "save")
 
 			reg.WithHooks(map[string]func(config.SelfServiceHook) interface{}{
 				"err": func(c config.SelfServiceHook) interface{} {
@@ -153,3 +160,170 @@ func TestSettingsExecutor(t *testing.T) {
 		})
 	}
 }
+
+func TestSettingsExecutorRecoveredSession(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/identity.schema.json")
+	conf.MustSet(config.ViperKeySelfServiceBrowserDefaultReturnTo, "https://www.ory.sh/")
+
+	i := testhelpers.SelfServiceHookCreateFakeIdentity(t, reg)
+	i.SetCredentials(identity.CredentialsTypePassword, identity.Credentials{
+		Type: identity.CredentialsTypePassword, Identifiers: []string{x.NewUUID().String()}, Config: []byte(`{"hashed_password":"foo"}`)})
+	require.NoError(t, reg.PrivilegedIdentityPool().UpdateIdentity(context.Background(), i))
+
+	run := func(t *testing.T, recovered bool, update func(i *identity.Identity)) error {
+		i, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(context.Background(), i.ID)
+		require.NoError(t, err)
+		update(i)
+
+		sess := session.NewActiveSession(i, conf, time.Now().UTC())
+		sess.Recovered = recovered
+
+		r := httptest.NewRequest("POST", "/settings", nil)
+		r.Header.Set("Accept", "application/json")
+		f := settings.NewFlow(conf, time.Minute, r, i, flow.TypeAPI)
+		require.NoError(t, reg.SettingsFlowPersister().CreateSettingsFlow(r.Context(), f))
+		return reg.SettingsHookExecutor().PostSettingsHook(httptest.NewRecorder(), r,
+			settings.StrategyProfile, &settings.UpdateContext{Flow: f, Session: sess}, i)
+	}
+
+	setPassword := func(i *identity.Identity) {
+		c, _ := i.GetCredentials(identity.CredentialsTypePassword)
+		c.Config = []byte(`{"hashed_password":"` + x.NewUUID().String() + `"}`)
+		i.SetCredentials(identity.CredentialsTypePassword, *c)
+	}
+	linkOIDC := func(i *identity.Identity) {
+		i.SetCredentials(identity.CredentialsTypeOIDC, identity.Credentials{
+			Type: identity.CredentialsTypeOIDC, Identifiers: []string{"google:" + x.NewUUID().String()}, Config: []byte(`{"providers":[]}`)})
+	}
+
+	t.Run("case=allows resetting the password by default", func(t *testing.T) {
+		require.NoError(t, run(t, true, setPassword))
+	})
+
+	t.Run("case=refuses linking a social sign in provider by default", func(t *testing.T) {
+		err := run(t, true, linkOIDC)
+		require.Error(t, err)
+		assert.True(t, errors.As(err, new(*settings.FlowNeedsReAuth)), "%+v", err)
+
+		actual, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(context.Background(), i.ID)
+		require.NoError(t, err)
+		_, ok := actual.GetCredentials(identity.CredentialsTypeOIDC)
+		assert.False(t, ok)
+	})
+
+	t.Run("case=allows linking a social sign in provider in regular sessions", func(t *testing.T) {
+		require.NoError(t, run(t, false, linkOIDC))
+	})
+
+	t.Run("case=refuses removing the credentials if not allowed", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceRecoveryAllowedCredentialChanges, []string{"password"})
+		err := run(t, true, func(i *identity.Identity) {
+			delete(i.Credentials, identity.CredentialsTypeOIDC)
+		})
+		assert.True(t, errors.As(err, new(*settings.FlowNeedsReAuth)), "%+v", err)
+	})
+
+	t.Run("case=allows configured credential changes", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceRecoveryAllowedCredentialChanges, []string{"oidc"})
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceRecoveryAllowedCredentialChanges, []string{"password"})
+		})
+
+		require.NoError(t, run(t, true, func(i *identity.Identity) {
+			delete(i.Credentials, identity.CredentialsTypeOIDC)
+		}))
+		err := run(t, true, setPassword)
+		assert.True(t, errors.As(err, new(*settings.FlowNeedsReAuth)), "%+v", err)
+	})
+}
+
+func TestSettingsExecutorImpersonatedSession(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/identity.schema.json")
+	conf.MustSet(config.ViperKeySelfServiceBrowserDefaultReturnTo, "https://www.ory.sh/")
+
+	i := testhelpers.SelfServiceHookCreateFakeIdentity(t, reg)
+	i.SetCredentials(identity.CredentialsTypePassword, identity.Credentials{
+		Type: identity.CredentialsTypePassword, Identifiers: []string{x.NewUUID().String()}, Config: []byte(`{"hashed_password":"foo"}`)})
+	require.NoError(t, reg.PrivilegedIdentityPool().UpdateIdentity(context.Background(), i))
+
+	run := func(t *testing.T) error {
+		i, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(context.Background(), i.ID)
+		require.NoError(t, err)
+		c, _ := i.GetCredentials(identity.CredentialsTypePassword)
+		c.Config = []byte(`{"hashed_password":"` + x.NewUUID().String() + `"}`)
+		i.SetCredentials(identity.CredentialsTypePassword, *c)
+
+		sess := session.NewActiveSession(i, conf, time.Now().UTC())
+		sess.ImpersonatedBy = "admin@ory.sh"
+		sess.ImpersonationReason = "support ticket"
+
+		r := httptest.NewRequest("POST", "/settings", nil)
+		r.Header.Set("Accept", "application/json")
+		f := settings.NewFlow(conf, time.Minute, r, i, flow.TypeAPI)
+		require.NoError(t, reg.SettingsFlowPersister().CreateSettingsFlow(r.Context(), f))
+		return reg.SettingsHookExecutor().PostSettingsHook(httptest.NewRecorder(), r,
+			settings.StrategyProfile, &settings.UpdateContext{Flow: f, Session: sess}, i)
+	}
+
+	t.Run("case=refuses credential changes by default", func(t *testing.T) {
+		err := run(t)
+		var e *herodot.DefaultError
+		require.True(t, errors.As(err, &e), "%+v", err)
+		assert.Equal(t, http.StatusForbidden, e.StatusCode())
+	})
+
+	t.Run("case=allows configured credential changes", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySessionImpersonationAllowedCredentialChanges, []string{"password"})
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySessionImpersonationAllowedCredentialChanges, []string{})
+		})
+		require.NoError(t, run(t))
+	})
+}
+
+func TestSettingsExecutorReconcilesAddresses(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/identity.schema.json")
+	conf.MustSet(config.ViperKeySelfServiceBrowserDefaultReturnTo, "https://www.ory.sh/")
+
+	i := testhelpers.SelfServiceHookFakeIdentity(t)
+	i.Traits = identity.Traits(`{"emails":["reconcile-1@ory.sh","reconcile-2@ory.sh"]}`)
+	require.NoError(t, reg.IdentityManager().Create(context.Background(), i))
+
+	original, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(context.Background(), i.ID)
+	require.NoError(t, err)
+	require.Len(t, original.VerifiableAddresses, 2)
+	require.Len(t, original.RecoveryAddresses, 2)
+
+	updated, err := reg.IdentityManager().SetTraits(context.Background(), i.ID,
+		identity.Traits(`{"emails":["reconcile-1@ory.sh"]}`), identity.ManagerAllowWriteProtectedTraits)
+	require.NoError(t, err)
+
+	r := httptest.NewRequest("POST", "/settings", nil)
+	r.Header.Set("Accept", "application/json")
+	f := settings.NewFlow(conf, time.Minute, r, updated, flow.TypeAPI)
+	require.NoError(t, reg.SettingsFlowPersister().CreateSettingsFlow(r.Context(), f))
+	require.NoError(t, reg.SettingsHookExecutor().PostSettingsHook(httptest.NewRecorder(), r, settings.StrategyProfile,
+		&settings.UpdateContext{Flow: f, Session: session.NewActiveSession(updated, conf, time.Now().UTC())}, updated))
+
+	actual, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(context.Background(), i.ID)
+	require.NoError(t, err)
+
+	require.Len(t, actual.VerifiableAddresses, 1)
+	assert.Equal(t, "reconcile-1@ory.sh", actual.VerifiableAddresses[0].Value)
+	require.Len(t, actual.RecoveryAddresses, 1)
+	assert.Equal(t, "reconcile-1@ory.sh", actual.RecoveryAddresses[0].Value)
+
+	for _, a := range original.VerifiableAddresses {
+		if a.Value == "reconcile-1@ory.sh" {
+			assert.Equal(t, a.ID, actual.VerifiableAddresses[0].ID, "unchanged addresses must be kept")
+		}
+	}
+
+	_, err = reg.PrivilegedIdentityPool().FindVerifiableAddressByValue(context.Background(), identity.VerifiableAddressTypeEmail, "reconcile-2@ory.sh")
+	assert.ErrorIs(t, err, sqlcon.ErrNoRows)
+	_, err = reg.PrivilegedIdentityPool().FindRecoveryAddressByValue(context.Background(), identity.RecoveryAddressTypeEmail, "reconcile-2@ory.sh")
+	assert.ErrorIs(t, err, sqlcon.ErrNoRows)
+}
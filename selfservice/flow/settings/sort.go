@@ -10,6 +10,7 @@ func sortNodes(n node.Nodes, schemaRef string) error {
 			node.ProfileGroup,
 			node.PasswordGroup,
 			node.OpenIDConnectGroup,
+			node.AccountDeletionGroup,
 		}),
 	)
 }
@@ -1,6 +1,7 @@
 package settings
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"runtime/debug"
@@ -9,14 +10,19 @@ import (
 	"github.com/gofrs/uuid"
 	"github.com/julienschmidt/httprouter"
 	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
 
 	"github.com/ory/herodot"
 
 	"github.com/ory/kratos/continuity"
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/hash"
 	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/schema"
 	"github.com/ory/kratos/selfservice/flow"
 	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/text"
+	"github.com/ory/kratos/ui/node"
 	"github.com/ory/kratos/x"
 )
 
@@ -111,3 +117,101 @@ func OnUnauthenticated(reg interface {
 		handler(w, r, ps)
 	}
 }
+
+// NewCurrentPasswordNode returns the input used to confirm a settings change with the
+// identity's current password.
+func NewCurrentPasswordNode(group node.Group) *node.Node {
+	return node.NewInputField("current_password", nil, group,
+		node.InputAttributeTypePassword,
+		node.WithRequiredInputAttribute).
+		WithMetaLabel(text.NewInfoNodeLabelCurrentPassword())
+}
+
+type (
+	// CurrentPasswordLockout limits how often an identity may enter a wrong current password, so
+	// that a hijacked session can not be used to guess the password.
+	CurrentPasswordLockout interface {
+		// EnsureNotLockedOut returns an error if the identity may currently not confirm changes.
+		EnsureNotLockedOut(ctx context.Context, id uuid.UUID) error
+
+		// RecordFailedAttempt counts a wrong current password and returns the error to show.
+		RecordFailedAttempt(ctx context.Context, id uuid.UUID) error
+
+		// ResetAttempts forgets the wrong current passwords of the identity.
+		ResetAttempts(ctx context.Context, id uuid.UUID)
+	}
+
+	CurrentPasswordLockoutProvider interface {
+		CurrentPasswordLockout() CurrentPasswordLockout
+	}
+)
+
+// VerifyCurrentPassword compares the submitted current password against the identity's
+// password credentials if the settings strategy is listed in
+// `selfservice.flows.settings.require_current_password`. Identities without password
+// credentials can not confirm a change this way and are therefore not checked.
+func VerifyCurrentPassword(ctx context.Context, d interface {
+	config.Provider
+	hash.HashProvider
+	identity.PrivilegedPoolProvider
+	CurrentPasswordLockoutProvider
+}, strategy string, id uuid.UUID, password string) error {
+	if !d.Config(ctx).SelfServiceFlowSettingsRequiresCurrentPassword(strategy) {
+		return nil
+	}
+
+	i, err := d.PrivilegedIdentityPool().GetIdentityConfidential(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	c, ok := i.GetCredentials(identity.CredentialsTypePassword)
+	if !ok {
+		return nil
+	}
+
+	hashed := gjson.GetBytes(c.Config, "hashed_password").String()
+	if len(hashed) == 0 {
+		return nil
+	}
+
+	lockout := d.CurrentPasswordLockout()
+	if err := lockout.EnsureNotLockedOut(ctx, id); err != nil {
+		return err
+	}
+
+	if len(password) == 0 {
+		return schema.NewRequiredError("#/current_password", "current_password")
+	}
+
+	if err := d.Hasher().Compare(ctx, []byte(password), []byte(hashed)); err != nil {
+		return lockout.RecordFailedAttempt(ctx, id)
+	}
+
+	lockout.ResetAttempts(ctx, id)
+	return nil
+}
+
+// RequireVerifiedAddress returns an error if the settings strategy is listed in
+// `selfservice.flows.settings.require_verified_address` and the identity has no verified address.
+func RequireVerifiedAddress(ctx context.Context, d interface {
+	config.Provider
+	identity.PrivilegedPoolProvider
+}, strategy string, id uuid.UUID) error {
+	if !d.Config(ctx).SelfServiceFlowSettingsRequiresVerifiedAddress(strategy) {
+		return nil
+	}
+
+	i, err := d.PrivilegedIdentityPool().GetIdentity(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range i.VerifiableAddresses {
+		if a.Verified {
+			return nil
+		}
+	}
+
+	return schema.NewVerifiedAddressRequiredError()
+}
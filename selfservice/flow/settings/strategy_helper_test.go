@@ -0,0 +1,59 @@
+package settings_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/schema"
+	"github.com/ory/kratos/selfservice/flow/settings"
+	"github.com/ory/kratos/text"
+)
+
+func TestRequireVerifiedAddress(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/identity.schema.json")
+
+	i := testhelpers.SelfServiceHookFakeIdentity(t)
+	i.Traits = identity.Traits(`{"email":"require-verified@ory.sh"}`)
+	require.NoError(t, reg.IdentityManager().Create(ctx, i))
+
+	t.Run("case=passes if not configured", func(t *testing.T) {
+		require.NoError(t, settings.RequireVerifiedAddress(ctx, reg, "password", i.ID))
+	})
+
+	conf.MustSet(config.ViperKeySelfServiceSettingsRequireVerifiedAddress, []string{"password"})
+
+	t.Run("case=passes for strategies which are not listed", func(t *testing.T) {
+		require.NoError(t, settings.RequireVerifiedAddress(ctx, reg, "oidc", i.ID))
+	})
+
+	t.Run("case=fails without verified address", func(t *testing.T) {
+		err := settings.RequireVerifiedAddress(ctx, reg, "password", i.ID)
+		var ve *schema.ValidationError
+		require.True(t, errors.As(err, &ve), "%+v", err)
+		require.Len(t, ve.Messages, 1)
+		assert.Equal(t, text.ErrorValidationSettingsVerifiedAddressRequired, ve.Messages[0].ID)
+	})
+
+	t.Run("case=passes with verified address", func(t *testing.T) {
+		actual, err := reg.PrivilegedIdentityPool().GetIdentity(ctx, i.ID)
+		require.NoError(t, err)
+		require.Len(t, actual.VerifiableAddresses, 1)
+
+		address := actual.VerifiableAddresses[0]
+		address.Verified = true
+		address.Status = identity.VerifiableAddressStatusCompleted
+		require.NoError(t, reg.PrivilegedIdentityPool().UpdateVerifiableAddress(ctx, &address))
+
+		require.NoError(t, settings.RequireVerifiedAddress(ctx, reg, "password", i.ID))
+	})
+}
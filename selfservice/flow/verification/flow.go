@@ -11,6 +11,7 @@ import (
 
 	"github.com/ory/kratos/corp"
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/schema"
 	"github.com/ory/kratos/selfservice/flow"
 	"github.com/ory/kratos/ui/container"
 	"github.com/ory/kratos/x"
@@ -71,6 +72,12 @@ type Flow struct {
 	// CSRFToken contains the anti-csrf token associated with this request.
 	CSRFToken string `json:"-" db:"csrf_token"`
 
+	// LastSentAt is the time (UTC) when the last verification email of this flow was sent.
+	LastSentAt sqlxx.NullTime `json:"-" faker:"-" db:"last_sent_at"`
+
+	// ResendCount is the number of verification emails which were sent after the first one.
+	ResendCount int `json:"-" faker:"-" db:"resend_count"`
+
 	// CreatedAt is a helper struct field for gobuffalo.pop.
 	CreatedAt time.Time `json:"-" faker:"-" db:"created_at"`
 	// UpdatedAt is a helper struct field for gobuffalo.pop.
@@ -86,6 +93,10 @@ func (f *Flow) GetRequestURL() string {
 	return f.RequestURL
 }
 
+func (f *Flow) GetUI() *container.Container {
+	return f.UI
+}
+
 func (f Flow) TableName(ctx context.Context) string {
 	return corp.ContextualizeTableName(ctx, "selfservice_verification_flows")
 }
@@ -150,3 +161,29 @@ func (f Flow) GetID() uuid.UUID {
 func (f Flow) GetNID() uuid.UUID {
 	return f.NID
 }
+
+// EnsureCanSend returns an error if the verification email of this flow was sent too recently or too often to
+// be sent again. The first email can always be sent.
+func (f *Flow) EnsureCanSend(conf *config.Config) error {
+	if time.Time(f.LastSentAt).IsZero() {
+		return nil
+	}
+
+	if max := conf.SelfServiceFlowVerificationMaxResends(); f.ResendCount >= max {
+		return schema.NewVerificationResendLimitError(max)
+	}
+
+	if resendAt := time.Time(f.LastSentAt).Add(conf.SelfServiceFlowVerificationResendCooldown()); resendAt.After(time.Now()) {
+		return schema.NewVerificationResendCooldownError(resendAt)
+	}
+
+	return nil
+}
+
+// MarkSent records that a verification email of this flow was sent.
+func (f *Flow) MarkSent() {
+	if !time.Time(f.LastSentAt).IsZero() {
+		f.ResendCount++
+	}
+	f.LastSentAt = sqlxx.NullTime(time.Now().UTC())
+}
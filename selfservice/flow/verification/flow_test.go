@@ -18,10 +18,13 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/ory/x/sqlxx"
 	"github.com/ory/x/urlx"
 
+	"github.com/ory/kratos/schema"
 	"github.com/ory/kratos/selfservice/flow"
 	"github.com/ory/kratos/selfservice/flow/registration"
+	"github.com/ory/kratos/text"
 )
 
 func TestFlow(t *testing.T) {
@@ -106,3 +109,30 @@ func TestNewPostHookFlow(t *testing.T) {
 	})
 
 }
+
+func TestEnsureCanSend(t *testing.T) {
+	conf := internal.NewConfigurationWithDefaults(t)
+	conf.MustSet(config.ViperKeySelfServiceVerificationResendCooldown, "1m")
+	conf.MustSet(config.ViperKeySelfServiceVerificationMaxResends, 1)
+
+	expectError := func(t *testing.T, f *verification.Flow, expected text.ID) {
+		var e *schema.ValidationError
+		require.ErrorAs(t, f.EnsureCanSend(conf), &e)
+		assert.Equal(t, expected, e.Messages[0].ID)
+	}
+
+	f := new(verification.Flow)
+	require.NoError(t, f.EnsureCanSend(conf), "the first email can always be sent")
+
+	f.MarkSent()
+	assert.Equal(t, 0, f.ResendCount)
+	expectError(t, f, text.ErrorValidationVerificationResendCooldown)
+
+	f.LastSentAt = sqlxx.NullTime(time.Now().Add(-time.Minute))
+	require.NoError(t, f.EnsureCanSend(conf))
+
+	f.MarkSent()
+	assert.Equal(t, 1, f.ResendCount)
+	f.LastSentAt = sqlxx.NullTime(time.Now().Add(-time.Minute))
+	expectError(t, f, text.ErrorValidationVerificationResendLimit)
+}
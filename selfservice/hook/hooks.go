@@ -3,4 +3,6 @@ package hook
 const (
 	KeySessionIssuer    = "session"
 	KeySessionDestroyer = "revoke_active_sessions"
+	KeyWebHook          = "web_hook"
+	KeySignInNotifier   = "notify_sign_in"
 )
@@ -6,6 +6,7 @@ import (
 
 	"github.com/pkg/errors"
 
+	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/selfservice/flow"
 	"github.com/ory/kratos/selfservice/flow/registration"
 	"github.com/ory/kratos/session"
@@ -18,6 +19,7 @@ var (
 
 type (
 	sessionIssuerDependencies interface {
+		config.Provider
 		session.ManagementProvider
 		session.PersistenceProvider
 		x.WriterProvider
@@ -36,13 +38,21 @@ func NewSessionIssuer(r sessionIssuerDependencies) *SessionIssuer {
 
 func (e *SessionIssuer) ExecutePostRegistrationPostPersistHook(w http.ResponseWriter, r *http.Request, a *registration.Flow, s *session.Session) error {
 	s.AuthenticatedAt = time.Now().UTC()
+	if err := s.BindFingerprint(e.r.Config(r.Context()), r); err != nil {
+		return err
+	}
 	if err := e.r.SessionPersister().CreateSession(r.Context(), s); err != nil {
 		return err
 	}
 
 	if a.Type == flow.TypeAPI {
+		token, err := session.IssuedToken(e.r.Config(r.Context()), s)
+		if err != nil {
+			return err
+		}
+
 		e.r.Writer().Write(w, r, &registration.APIFlowResponse{
-			Session: s, Token: s.Token,
+			Session: s, Token: token,
 			Identity: s.Identity,
 		})
 		return errors.WithStack(registration.ErrHookAbortFlow)
@@ -0,0 +1,62 @@
+package hook
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/ory/kratos/courier"
+	templates "github.com/ory/kratos/courier/template"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/selfservice/flow/login"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/x"
+)
+
+var _ login.PostHookExecutor = new(SignInNotifier)
+
+type (
+	signInNotifierDependencies interface {
+		config.Provider
+		courier.Provider
+		x.LoggingProvider
+	}
+	SignInNotifier struct {
+		r signInNotifierDependencies
+	}
+)
+
+func NewSignInNotifier(r signInNotifierDependencies) *SignInNotifier {
+	return &SignInNotifier{r: r}
+}
+
+// ExecuteLoginPostHook emails a sign in notice to the identity's verified email addresses. Unverified
+// addresses are never notified because they might belong to someone else.
+func (e *SignInNotifier) ExecuteLoginPostHook(_ http.ResponseWriter, r *http.Request, _ *login.Flow, s *session.Session) error {
+	ctx := r.Context()
+	c := e.r.Config(ctx)
+
+	var notified int
+	for _, address := range s.Identity.VerifiableAddresses {
+		if !address.Verified || address.Via != identity.VerifiableAddressTypeEmail {
+			continue
+		}
+
+		if _, err := e.r.Courier(ctx).QueueEmail(ctx, templates.NewLoginNotification(c, &templates.LoginNotificationModel{
+			To:         address.Value,
+			SignedInAt: time.Now().UTC(),
+			IPAddress:  x.ClientIPString(r, c.TrustedProxies()),
+			UserAgent:  r.UserAgent(),
+		})); err != nil {
+			return err
+		}
+		notified++
+	}
+
+	e.r.Audit().
+		WithRequest(r).
+		WithField("identity_id", s.Identity.ID).
+		WithField("notified_addresses", notified).
+		Debug("Sent sign in notifications to the verified addresses of the identity.")
+	return nil
+}
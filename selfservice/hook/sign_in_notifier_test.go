@@ -0,0 +1,48 @@
+package hook_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gobuffalo/httptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/courier"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/selfservice/hook"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/x"
+)
+
+func TestSignInNotifier(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyPublicBaseURL, "http://localhost/")
+	conf.MustSet(config.ViperKeyCourierSMTPURL, "smtp://foo@bar@dev.null/")
+
+	h := hook.NewSignInNotifier(reg)
+
+	verified := identity.NewVerifiableEmailAddress("verified@ory.sh", x.NewUUID())
+	verified.Verified = true
+	unverified := identity.NewVerifiableEmailAddress("unverified@ory.sh", x.NewUUID())
+
+	r, err := http.NewRequest("POST", "http://localhost/self-service/login", nil)
+	require.NoError(t, err)
+	r.Header.Set("User-Agent", "Mozilla/5.0")
+	r.RemoteAddr = "192.0.2.1:1234"
+	require.NoError(t, h.ExecuteLoginPostHook(httptest.NewRecorder(), r, nil, &session.Session{Identity: &identity.Identity{
+		ID:                  x.NewUUID(),
+		VerifiableAddresses: []identity.VerifiableAddress{*unverified, *verified},
+	}}))
+
+	messages, err := reg.CourierPersister().NextMessages(context.Background(), 10)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "verified@ory.sh", messages[0].Recipient)
+	assert.Equal(t, courier.TypeLoginNotification, messages[0].TemplateType)
+	assert.Contains(t, messages[0].Body, "192.0.2.1")
+	assert.Contains(t, messages[0].Body, "Mozilla/5.0")
+}
@@ -0,0 +1,480 @@
+package hook
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/google/go-jsonnet"
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/fetcher"
+	"github.com/ory/x/httpx"
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/selfservice/flow"
+	"github.com/ory/kratos/selfservice/flow/login"
+	"github.com/ory/kratos/selfservice/flow/registration"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/x"
+)
+
+var (
+	_ login.PostHookExecutor                   = new(WebHook)
+	_ registration.PostHookPostPersistExecutor = new(WebHook)
+)
+
+const (
+	// DefaultWebHookMaxSize is the default limit for session context returned by a web hook.
+	DefaultWebHookMaxSize = 4096
+
+	// DefaultWebHookRetries is the default number of retries of a failed web hook call.
+	DefaultWebHookRetries = 4
+
+	webHookConnectionTimeout = 5 * time.Second
+)
+
+// webHookTLSClients caches the HTTP clients of web hooks with a TLS configuration by the hash of their TLS
+// material, so that certificates are only parsed once and connections are reused. Changing the material
+// results in a new client.
+var webHookTLSClients sync.Map
+
+type (
+	webHookDependencies interface {
+		config.Provider
+		identity.ManagementProvider
+		identity.PrivilegedPoolProvider
+		session.PersistenceProvider
+		x.LoggingProvider
+	}
+	webHookConfig struct {
+		URL             string `json:"url"`
+		Method          string `json:"method"`
+		EnrichSession   bool   `json:"enrich_session"`
+		MaxSize         int64  `json:"max_size"`
+		SignatureHeader string `json:"signature_header"`
+		Retries         int    `json:"retries"`
+		InitialInterval string `json:"initial_interval"`
+		MaxInterval     string `json:"max_interval"`
+		Async           bool   `json:"async"`
+
+		ResponseMapperURL string            `json:"response_mapper_url"`
+		TLS               *webHookTLSConfig `json:"tls"`
+
+		initialInterval time.Duration
+		maxInterval     time.Duration
+		client          *http.Client
+	}
+	webHookTLSConfig struct {
+		ClientCertificate string `json:"client_certificate"`
+		CA                string `json:"ca"`
+	}
+	webHookPayload struct {
+		FlowID   uuid.UUID          `json:"flow_id"`
+		FlowType flow.Type          `json:"flow_type"`
+		Identity *identity.Identity `json:"identity"`
+	}
+	// WebHook calls an external HTTP endpoint after login and registration. If `enrich_session` is
+	// enabled, the endpoint's response must be a JSON object which is stored on the session as `extra`.
+	// After registration, the session is updated if the `session` hook stored it already.
+	// If `secrets.web_hook` is set, the request is signed (see WebHookSignature).
+	//
+	// Network errors and 5xx or 429 responses are retried with exponential backoff. Blocking web hooks
+	// are retried until the request context is done, while `async` web hooks are called and retried in
+	// the background.
+	//
+	// If `response_mapper_url` is set, the Jsonnet mapper it points to is evaluated with the body of a 200
+	// response and the traits it returns are merged into the identity's traits (see mapResponse).
+	//
+	// If `tls` is set, the client certificate referenced from `secrets.web_hook_tls` is presented to the
+	// endpoint, whose certificate is verified with the certificate authorities in `tls.ca`.
+	WebHook struct {
+		r webHookDependencies
+		c json.RawMessage
+		f *fetcher.Fetcher
+	}
+)
+
+func NewWebHook(r webHookDependencies, c json.RawMessage) *WebHook {
+	return &WebHook{r: r, c: c, f: fetcher.NewFetcher()}
+}
+
+func (e *WebHook) ExecuteLoginPostHook(_ http.ResponseWriter, r *http.Request, a *login.Flow, s *session.Session) error {
+	return e.execute(r.Context(), &webHookPayload{FlowID: a.ID, FlowType: a.Type, Identity: s.Identity}, s)
+}
+
+func (e *WebHook) ExecutePostRegistrationPostPersistHook(_ http.ResponseWriter, r *http.Request, a *registration.Flow, s *session.Session) error {
+	extra := s.Extra
+	if err := e.execute(r.Context(), &webHookPayload{FlowID: a.ID, FlowType: a.Type, Identity: s.Identity}, s); err != nil {
+		return err
+	}
+
+	if bytes.Equal(extra, s.Extra) {
+		return nil
+	}
+
+	// If the session hook ran before this hook, the session was stored already and the enriched context
+	// would be lost. Otherwise the session hook stores it with the session.
+	if err := e.r.SessionPersister().UpdateSessionExtra(r.Context(), s.ID, s.Extra); err != nil && !errors.Is(err, sqlcon.ErrNoRows) {
+		return err
+	}
+	return nil
+}
+
+func (e *WebHook) config(ctx context.Context) (*webHookConfig, error) {
+	c := webHookConfig{
+		Method:          "POST",
+		MaxSize:         DefaultWebHookMaxSize,
+		SignatureHeader: DefaultWebHookSignatureHeader,
+		Retries:         DefaultWebHookRetries,
+		InitialInterval: "1s",
+		MaxInterval:     "30s",
+	}
+	if err := json.NewDecoder(bytes.NewReader(e.c)).Decode(&c); err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.
+			WithReasonf("Unable to decode the web hook configuration.").WithDebug(err.Error()))
+	}
+
+	if len(c.URL) == 0 {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.
+			WithReasonf("The web hook configuration is missing the url."))
+	}
+
+	if c.Async && (c.EnrichSession || len(c.ResponseMapperURL) > 0) {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.
+			WithReasonf("The web hook can not enrich the session or identity if it runs asynchronously."))
+	}
+
+	var err error
+	if c.initialInterval, err = time.ParseDuration(c.InitialInterval); err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.
+			WithReasonf("Unable to parse the web hook's initial_interval.").WithDebug(err.Error()))
+	}
+	if c.maxInterval, err = time.ParseDuration(c.MaxInterval); err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.
+			WithReasonf("Unable to parse the web hook's max_interval.").WithDebug(err.Error()))
+	}
+
+	if c.client, err = e.tlsClient(ctx, c.TLS); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (e *WebHook) execute(ctx context.Context, p *webHookPayload, s *session.Session) error {
+	c, err := e.config(ctx)
+	if err != nil {
+		return err
+	}
+
+	if p.Identity != nil {
+		p.Identity = p.Identity.CopyWithoutCredentials()
+	}
+
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(p); err != nil {
+		return errors.WithStack(err)
+	}
+
+	req, err := retryablehttp.NewRequest(c.Method, c.URL, body.Bytes())
+	if err != nil {
+		return errors.WithStack(herodot.ErrInternalServerError.
+			WithReasonf("Unable to create the web hook request.").WithDebug(err.Error()))
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// Every attempt is signed separately so that retries do not carry an outdated timestamp and are not
+	// rejected as replayed.
+	var sign func(*http.Request)
+	if secrets := e.r.Config(ctx).SecretsWebHook(); len(secrets) > 0 {
+		payload := body.Bytes()
+		sign = func(r *http.Request) {
+			r.Header.Set(c.SignatureHeader, WebHookSignature(secrets, time.Now(), payload))
+		}
+	}
+
+	if c.Async {
+		go func() {
+			res, err := e.do(context.Background(), c, req, sign)
+			if err != nil {
+				e.r.Logger().
+					WithError(err).
+					WithField("web_hook_url", c.URL).
+					Error("Unable to call the asynchronous web hook.")
+				return
+			}
+			_ = res.Body.Close()
+		}()
+		return nil
+	}
+
+	res, err := e.do(ctx, c, req, sign)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	mapResponse := len(c.ResponseMapperURL) > 0 && res.StatusCode == http.StatusOK
+	if !c.EnrichSession && !mapResponse {
+		return nil
+	}
+
+	extra, err := ioutil.ReadAll(io.LimitReader(res.Body, c.MaxSize+1))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if int64(len(extra)) > c.MaxSize {
+		return errors.WithStack(herodot.ErrInternalServerError.
+			WithReasonf("The web hook response exceeds the maximum size of %d bytes.", c.MaxSize))
+	}
+
+	if mapResponse {
+		if err := e.mapResponse(ctx, c, extra, s); err != nil {
+			return err
+		}
+	}
+
+	if !c.EnrichSession {
+		return nil
+	}
+
+	if !gjson.ValidBytes(extra) || !gjson.ParseBytes(extra).IsObject() {
+		e.r.Logger().
+			WithField("web_hook_url", c.URL).
+			WithField("web_hook_response", string(extra)).
+			Error("The web hook must respond with a JSON object to enrich the session.")
+		return errors.WithStack(herodot.ErrInternalServerError.
+			WithReasonf("The web hook must respond with a JSON object to enrich the session."))
+	}
+
+	s.Extra = extra
+	return nil
+}
+
+// mapResponse evaluates the response mapper and merges the traits it returns into the identity's traits
+// using JSON Merge Patch (RFC 7386). The merged traits are validated against the identity schema and
+// persisted. Protected traits, such as credential identifiers, can not be changed.
+//
+// The mapper receives the response body as `std.extVar('response')` and the identity (without
+// credentials) as `std.extVar('identity')`, and must return an object with key `identity.traits`.
+func (e *WebHook) mapResponse(ctx context.Context, c *webHookConfig, body []byte, s *session.Session) error {
+	if s.Identity == nil {
+		return nil
+	}
+
+	if !gjson.ValidBytes(body) {
+		e.r.Logger().
+			WithField("web_hook_url", c.URL).
+			WithField("web_hook_response", string(body)).
+			Error("The web hook must respond with JSON to map the response.")
+		return errors.WithStack(herodot.ErrInternalServerError.
+			WithReasonf("The web hook must respond with JSON to map the response."))
+	}
+
+	jn, err := e.f.Fetch(c.ResponseMapperURL)
+	if err != nil {
+		return errors.WithStack(herodot.ErrInternalServerError.
+			WithReasonf("Unable to fetch the web hook response mapper.").WithDebug(err.Error()))
+	}
+
+	var input bytes.Buffer
+	if err := json.NewEncoder(&input).Encode(s.Identity.CopyWithoutCredentials()); err != nil {
+		return errors.WithStack(err)
+	}
+
+	vm := jsonnet.MakeVM()
+	vm.ExtCode("response", string(body))
+	vm.ExtCode("identity", input.String())
+	evaluated, err := vm.EvaluateSnippet(c.ResponseMapperURL, jn.String())
+	if err != nil {
+		return errors.WithStack(herodot.ErrInternalServerError.
+			WithReasonf("Unable to evaluate the web hook response mapper.").WithDebug(err.Error()))
+	}
+
+	traits := gjson.Get(evaluated, "identity.traits")
+	if !traits.IsObject() {
+		e.r.Logger().
+			WithField("identity_id", s.Identity.ID).
+			WithField("mapper_jsonnet_output", evaluated).
+			WithField("mapper_jsonnet_url", c.ResponseMapperURL).
+			Error("Web hook response Jsonnet mapper did not return an object for key identity.traits. Please check your Jsonnet code!")
+		return errors.WithStack(herodot.ErrInternalServerError.
+			WithReasonf(`The web hook response mapper must return an object for key "identity.traits".`))
+	}
+
+	merged, err := x.MergePatch(s.Identity.Traits, []byte(traits.Raw))
+	if err != nil {
+		return errors.WithStack(herodot.ErrInternalServerError.
+			WithReasonf("Unable to merge the traits returned by the web hook response mapper.").WithDebug(err.Error()))
+	}
+
+	updated, err := e.r.IdentityManager().SetTraits(ctx, s.Identity.ID, identity.Traits(merged))
+	if err != nil {
+		e.r.Logger().
+			WithError(err).
+			WithField("identity_id", s.Identity.ID).
+			WithField("mapper_jsonnet_output", evaluated).
+			WithField("mapper_jsonnet_url", c.ResponseMapperURL).
+			Error("The traits returned by the web hook response Jsonnet mapper are invalid. Please check your Jsonnet code!")
+		return errors.WithStack(herodot.ErrInternalServerError.
+			WithReasonf("The traits returned by the web hook response mapper are invalid.").WithDebug(err.Error()))
+	}
+
+	if err := e.r.PrivilegedIdentityPool().UpdateIdentity(ctx, updated); err != nil {
+		return err
+	}
+
+	*s.Identity = *updated
+	return nil
+}
+
+// do sends the request and retries it according to the configuration until it succeeds, the retries are
+// exhausted, or the context is done. If sign is set, it is called before every attempt.
+func (e *WebHook) do(ctx context.Context, c *webHookConfig, req *retryablehttp.Request, sign func(*http.Request)) (*http.Response, error) {
+	opts := []httpx.ResilientOptions{
+		httpx.ResilientClientWithConnectionTimeout(webHookConnectionTimeout),
+		httpx.ResilientClientWithLogger(e.r.Logger()),
+		httpx.ResilientClientWithMaxRetry(c.Retries),
+		httpx.ResilientClientWithMinxRetryWait(c.initialInterval),
+		httpx.ResilientClientWithMaxRetryWait(c.maxInterval),
+	}
+	if c.client != nil {
+		opts = append(opts, httpx.ResilientClientWithClient(c.client))
+	}
+
+	client := httpx.NewResilientClient(opts...)
+	client.CheckRetry = webHookRetryPolicy
+	client.Backoff = webHookBackoff
+	if sign != nil {
+		// The request log hook is the only hook which is called before every attempt.
+		client.RequestLogHook = func(_ retryablehttp.Logger, r *http.Request, _ int) {
+			sign(r)
+		}
+	}
+
+	res, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.
+			WithReasonf("Unable to call the web hook.").WithDebug(err.Error()))
+	}
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		_ = res.Body.Close()
+		return nil, errors.WithStack(herodot.ErrInternalServerError.
+			WithReasonf("The web hook responded with an unexpected status code.").
+			WithDebug(fmt.Sprintf("expected 2xx but got %d", res.StatusCode)))
+	}
+
+	return res, nil
+}
+
+// tlsClient returns the HTTP client for the TLS configuration, or nil if there is no TLS material
+// configured and the default client should be used.
+func (e *WebHook) tlsClient(ctx context.Context, c *webHookTLSConfig) (*http.Client, error) {
+	if c == nil || (len(c.ClientCertificate) == 0 && len(c.CA) == 0) {
+		return nil, nil
+	}
+
+	var cert, key []byte
+	if len(c.ClientCertificate) > 0 {
+		var ok bool
+		if cert, key, ok = e.r.Config(ctx).SecretsWebHookTLS(c.ClientCertificate); !ok {
+			return nil, errors.WithStack(herodot.ErrInternalServerError.
+				WithReasonf("The web hook's client certificate %q is not set in secrets.web_hook_tls.", c.ClientCertificate))
+		}
+	}
+
+	h := sha256.New()
+	for _, material := range [][]byte{cert, key, []byte(c.CA)} {
+		_, _ = h.Write(material)
+		// PEM never contains a null byte, which makes the hash unambiguous.
+		_, _ = h.Write([]byte{0})
+	}
+	id := hex.EncodeToString(h.Sum(nil))
+
+	if client, ok := webHookTLSClients.Load(id); ok {
+		return client.(*http.Client), nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(cert) > 0 {
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, errors.WithStack(herodot.ErrInternalServerError.
+				WithReasonf("Unable to load the web hook's client certificate %q.", c.ClientCertificate).WithDebug(err.Error()))
+		}
+		tlsConfig.Certificates = []tls.Certificate{pair}
+	}
+
+	if len(c.CA) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(c.CA)) {
+			return nil, errors.WithStack(herodot.ErrInternalServerError.
+				WithReasonf("The web hook's certificate authorities do not contain a PEM encoded certificate."))
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	client, _ := webHookTLSClients.LoadOrStore(id, &http.Client{Timeout: webHookConnectionTimeout, Transport: transport})
+	return client.(*http.Client), nil
+}
+
+// webHookRetryPolicy retries network errors and 5xx or 429 responses. Other responses, in particular
+// 4xx responses, are never retried.
+func webHookRetryPolicy(ctx context.Context, res *http.Response, err error) (bool, error) {
+	if err != nil {
+		// The default policy does not retry errors which are not going to go away, such as invalid
+		// certificates or too many redirects.
+		return retryablehttp.DefaultRetryPolicy(ctx, res, err)
+	}
+
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	return res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests, nil
+}
+
+// webHookBackoff doubles the wait time between min and max with every attempt and randomizes it by up to
+// half of its length, so that clients failing at the same time do not retry at the same time. The
+// Retry-After header of 429 responses is respected.
+func webHookBackoff(min, max time.Duration, attempt int, res *http.Response) time.Duration {
+	if res != nil && res.StatusCode == http.StatusTooManyRequests && len(res.Header.Get("Retry-After")) > 0 {
+		return retryablehttp.DefaultBackoff(min, max, attempt, res)
+	}
+
+	wait := min
+	for i := 0; i < attempt && wait < max; i++ {
+		wait *= 2
+	}
+	if wait > max {
+		wait = max
+	}
+
+	if half := int64(wait / 2); half > 0 {
+		wait = wait - time.Duration(rand.Int63n(half+1))
+	}
+	return wait
+}
@@ -0,0 +1,78 @@
+package hook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultWebHookSignatureHeader is the default header which carries the signature of web hook requests.
+const DefaultWebHookSignatureHeader = "X-Kratos-Signature"
+
+var (
+	ErrWebHookSignatureInvalid = errors.New("the web hook signature is invalid")
+	ErrWebHookSignatureExpired = errors.New("the web hook signature timestamp is outside of the tolerance")
+)
+
+// WebHookSignature returns the signature header value for the body at the given time. The value has
+// the form `t=<unix timestamp>,v1=<signature>` with one `v1` entry per secret, where each signature is
+// the hex encoded HMAC-SHA256 of `<unix timestamp>.<body>`.
+func WebHookSignature(secrets [][]byte, at time.Time, body []byte) string {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	parts := []string{"t=" + ts}
+	for _, secret := range secrets {
+		parts = append(parts, "v1="+webHookMAC(secret, ts, body))
+	}
+	return strings.Join(parts, ",")
+}
+
+// VerifyWebHookSignature checks the signature header value of a web hook request. It fails if none of
+// the signatures was made with the secret or if the timestamp is further than tolerance away from now.
+func VerifyWebHookSignature(header string, secret []byte, body []byte, tolerance time.Duration) error {
+	var ts string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "t":
+			ts = kv[1]
+		case "v1":
+			signatures = append(signatures, kv[1])
+		}
+	}
+
+	at, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return errors.WithStack(ErrWebHookSignatureInvalid)
+	}
+
+	if age := time.Since(time.Unix(at, 0)); age > tolerance || age < -tolerance {
+		return errors.Wrap(ErrWebHookSignatureExpired, fmt.Sprintf("signed %s ago", age))
+	}
+
+	expected := webHookMAC(secret, ts, body)
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return nil
+		}
+	}
+
+	return errors.WithStack(ErrWebHookSignatureInvalid)
+}
+
+func webHookMAC(secret []byte, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write([]byte(ts))
+	_, _ = mac.Write([]byte("."))
+	_, _ = mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
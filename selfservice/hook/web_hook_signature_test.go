@@ -0,0 +1,63 @@
+package hook_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ory/kratos/selfservice/hook"
+)
+
+func TestWebHookSignature(t *testing.T) {
+	secret := []byte("a-web-hook-secret")
+	body := []byte(`{"flow_id":"1"}`)
+	now := time.Now()
+
+	t.Run("case=is stable", func(t *testing.T) {
+		assert.Equal(t, hook.WebHookSignature([][]byte{secret}, now, body), hook.WebHookSignature([][]byte{secret}, now, body))
+		assert.NotEqual(t, hook.WebHookSignature([][]byte{secret}, now, body), hook.WebHookSignature([][]byte{secret}, now.Add(time.Second), body))
+	})
+
+	t.Run("case=matches an independent computation", func(t *testing.T) {
+		at := time.Unix(1600000000, 0)
+		sign := func(secret []byte) string {
+			mac := hmac.New(sha256.New, secret)
+			_, _ = mac.Write([]byte("1600000000." + string(body)))
+			return hex.EncodeToString(mac.Sum(nil))
+		}
+
+		assert.Equal(t, "t=1600000000,v1="+sign(secret), hook.WebHookSignature([][]byte{secret}, at, body))
+		assert.Equal(t, "t=1600000000,v1="+sign([]byte("a-new-web-hook-secret"))+",v1="+sign(secret),
+			hook.WebHookSignature([][]byte{[]byte("a-new-web-hook-secret"), secret}, at, body))
+	})
+
+	for k, tc := range []struct {
+		d      string
+		header string
+		secret []byte
+		body   []byte
+		err    error
+	}{
+		{d: "valid", header: hook.WebHookSignature([][]byte{secret}, now, body), secret: secret, body: body},
+		{d: "one of several signatures", header: hook.WebHookSignature([][]byte{[]byte("another-web-hook-secret"), secret}, now, body), secret: secret, body: body},
+		{d: "wrong secret", header: hook.WebHookSignature([][]byte{secret}, now, body), secret: []byte("another-web-hook-secret"), body: body, err: hook.ErrWebHookSignatureInvalid},
+		{d: "modified body", header: hook.WebHookSignature([][]byte{secret}, now, body), secret: secret, body: []byte(`{"flow_id":"2"}`), err: hook.ErrWebHookSignatureInvalid},
+		{d: "replayed", header: hook.WebHookSignature([][]byte{secret}, now.Add(-time.Hour), body), secret: secret, body: body, err: hook.ErrWebHookSignatureExpired},
+		{d: "missing timestamp", header: "v1=abcdef", secret: secret, body: body, err: hook.ErrWebHookSignatureInvalid},
+		{d: "empty", secret: secret, body: body, err: hook.ErrWebHookSignatureInvalid},
+	} {
+		t.Run("case="+tc.d, func(t *testing.T) {
+			err := hook.VerifyWebHookSignature(tc.header, tc.secret, tc.body, 5*time.Minute)
+			if tc.err == nil {
+				assert.NoError(t, err, "%d", k)
+				return
+			}
+			assert.True(t, errors.Is(err, tc.err), "%d: %+v", k, err)
+		})
+	}
+}
@@ -0,0 +1,481 @@
+package hook_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/errorsx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/selfservice/flow"
+	"github.com/ory/kratos/selfservice/flow/login"
+	"github.com/ory/kratos/selfservice/flow/registration"
+	"github.com/ory/kratos/selfservice/hook"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/x"
+)
+
+// newClientCertificate returns a PEM encoded self-signed client certificate and its private key.
+func newClientCertificate(t *testing.T) (cert, key []byte) {
+	pk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "kratos"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &pk.PublicKey, pk)
+	require.NoError(t, err)
+
+	rawKey, err := x509.MarshalECPrivateKey(pk)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: rawKey})
+}
+
+func TestWebHook(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+
+	var received []byte
+	var receivedHeader http.Header
+	response := `{"tenant_id":"acme"}`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = ioutil.ReadAll(r.Body)
+		receivedHeader = r.Header
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(ts.Close)
+
+	newHook := func(t *testing.T, c string) *hook.WebHook {
+		return hook.NewWebHook(reg, json.RawMessage(fmt.Sprintf(c, ts.URL)))
+	}
+
+	t.Run("case=sends identity without credentials", func(t *testing.T) {
+		s := &session.Session{ID: x.NewUUID(), Identity: identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)}
+		s.Identity.Credentials = map[identity.CredentialsType]identity.Credentials{
+			identity.CredentialsTypePassword: {Type: identity.CredentialsTypePassword, Config: []byte(`{"hashed_password":"secret"}`)},
+		}
+		f := &login.Flow{ID: x.NewUUID(), Type: flow.TypeBrowser}
+
+		require.NoError(t, newHook(t, `{"url":"%s"}`).ExecuteLoginPostHook(nil, new(http.Request), f, s))
+		assert.Equal(t, f.ID.String(), gjson.GetBytes(received, "flow_id").String())
+		assert.Equal(t, "browser", gjson.GetBytes(received, "flow_type").String())
+		assert.Equal(t, s.Identity.ID.String(), gjson.GetBytes(received, "identity.id").String())
+		assert.False(t, gjson.GetBytes(received, "identity.credentials").Exists())
+		assert.NotContains(t, string(received), "secret")
+		assert.Empty(t, s.Extra, "the session must not be enriched unless enabled")
+	})
+
+	t.Run("case=signs the request", func(t *testing.T) {
+		s := &session.Session{ID: x.NewUUID(), Identity: identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)}
+		f := &login.Flow{ID: x.NewUUID(), Type: flow.TypeBrowser}
+
+		require.NoError(t, newHook(t, `{"url":"%s"}`).ExecuteLoginPostHook(nil, new(http.Request), f, s))
+		assert.Empty(t, receivedHeader.Get(hook.DefaultWebHookSignatureHeader), "requests must not be signed without a secret")
+
+		conf.MustSet(config.ViperKeySecretsWebHook, []string{"a-new-web-hook-secret", "an-old-web-hook-secret"})
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySecretsWebHook, []string{})
+		})
+
+		require.NoError(t, newHook(t, `{"url":"%s"}`).ExecuteLoginPostHook(nil, new(http.Request), f, s))
+		signature := receivedHeader.Get(hook.DefaultWebHookSignatureHeader)
+		require.NotEmpty(t, signature)
+		assert.NoError(t, hook.VerifyWebHookSignature(signature, []byte("a-new-web-hook-secret"), received, time.Minute))
+		assert.NoError(t, hook.VerifyWebHookSignature(signature, []byte("an-old-web-hook-secret"), received, time.Minute))
+
+		require.NoError(t, newHook(t, `{"url":"%s","signature_header":"X-Signature"}`).ExecuteLoginPostHook(nil, new(http.Request), f, s))
+		assert.Empty(t, receivedHeader.Get(hook.DefaultWebHookSignatureHeader))
+		assert.NoError(t, hook.VerifyWebHookSignature(receivedHeader.Get("X-Signature"), []byte("a-new-web-hook-secret"), received, time.Minute))
+	})
+
+	t.Run("case=enriches the session", func(t *testing.T) {
+		s := &session.Session{ID: x.NewUUID(), Identity: identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)}
+		f := &registration.Flow{ID: x.NewUUID(), Type: flow.TypeAPI}
+
+		require.NoError(t, newHook(t, `{"url":"%s","enrich_session":true}`).ExecutePostRegistrationPostPersistHook(nil, new(http.Request), f, s))
+		assert.JSONEq(t, response, string(s.Extra))
+	})
+
+	t.Run("case=rejects responses which are not a JSON object", func(t *testing.T) {
+		for k, tc := range []string{`not json`, `["a","b"]`, `"string"`} {
+			t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+				response = tc
+				s := &session.Session{ID: x.NewUUID(), Identity: identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)}
+				err := newHook(t, `{"url":"%s","enrich_session":true}`).ExecuteLoginPostHook(nil, new(http.Request), &login.Flow{ID: x.NewUUID()}, s)
+				require.Error(t, err)
+				assert.Empty(t, s.Extra)
+			})
+		}
+	})
+
+	t.Run("case=rejects responses which exceed the size limit", func(t *testing.T) {
+		response = `{"tenant_id":"` + strings.Repeat("a", 64) + `"}`
+		s := &session.Session{ID: x.NewUUID(), Identity: identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)}
+		err := newHook(t, `{"url":"%s","enrich_session":true,"max_size":32}`).ExecuteLoginPostHook(nil, new(http.Request), &login.Flow{ID: x.NewUUID()}, s)
+		require.Error(t, err)
+		assert.Contains(t, fmt.Sprintf("%+v", err), "maximum size of 32 bytes")
+		assert.Empty(t, s.Extra)
+	})
+
+	t.Run("case=fails on unexpected status codes", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		t.Cleanup(ts.Close)
+
+		s := &session.Session{ID: x.NewUUID(), Identity: identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)}
+		err := hook.NewWebHook(reg, json.RawMessage(`{"url":"`+ts.URL+`"}`)).ExecuteLoginPostHook(nil, new(http.Request), &login.Flow{ID: x.NewUUID()}, s)
+		require.Error(t, err)
+	})
+
+	t.Run("suite=retries", func(t *testing.T) {
+		// newFlakyServer returns a server which responds with the status code, or drops the connection if
+		// it is zero, until it was called failures times, and with 200 afterwards.
+		newFlakyServer := func(t *testing.T, failures int32, status int) (*httptest.Server, *int32) {
+			var calls int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&calls, 1) <= failures {
+					if status == 0 {
+						conn, _, err := w.(http.Hijacker).Hijack()
+						require.NoError(t, err)
+						_ = conn.Close()
+						return
+					}
+					w.WriteHeader(status)
+					return
+				}
+				_, _ = w.Write([]byte(`{"tenant_id":"acme"}`))
+			}))
+			t.Cleanup(ts.Close)
+			return ts, &calls
+		}
+
+		execute := func(t *testing.T, r *http.Request, c string, url string) error {
+			s := &session.Session{ID: x.NewUUID(), Identity: identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)}
+			return hook.NewWebHook(reg, json.RawMessage(fmt.Sprintf(c, url))).
+				ExecuteLoginPostHook(nil, r, &login.Flow{ID: x.NewUUID()}, s)
+		}
+
+		const retryConfig = `{"url":"%s","retries":3,"initial_interval":"1ms","max_interval":"5ms"}`
+
+		t.Run("case=retries server errors until the call succeeds", func(t *testing.T) {
+			for _, status := range []int{http.StatusServiceUnavailable, http.StatusInternalServerError, http.StatusTooManyRequests} {
+				t.Run(fmt.Sprintf("status=%d", status), func(t *testing.T) {
+					ts, calls := newFlakyServer(t, 2, status)
+					require.NoError(t, execute(t, new(http.Request), retryConfig, ts.URL))
+					assert.EqualValues(t, 3, atomic.LoadInt32(calls))
+				})
+			}
+		})
+
+		t.Run("case=retries network errors", func(t *testing.T) {
+			ts, calls := newFlakyServer(t, 2, 0)
+			require.NoError(t, execute(t, new(http.Request), retryConfig, ts.URL))
+			assert.EqualValues(t, 3, atomic.LoadInt32(calls))
+		})
+
+		t.Run("case=signs every attempt", func(t *testing.T) {
+			conf.MustSet(config.ViperKeySecretsWebHook, []string{"a-new-web-hook-secret"})
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeySecretsWebHook, []string{})
+			})
+
+			var signatures []string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				signature := r.Header.Get(hook.DefaultWebHookSignatureHeader)
+				assert.NoError(t, hook.VerifyWebHookSignature(signature, []byte("a-new-web-hook-secret"), body, time.Minute))
+				if signatures = append(signatures, signature); len(signatures) == 1 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+			}))
+			t.Cleanup(ts.Close)
+
+			// The timestamp has a resolution of one second and the randomized wait is at least half of the interval.
+			require.NoError(t, execute(t, new(http.Request), `{"url":"%s","retries":1,"initial_interval":"2100ms","max_interval":"2100ms"}`, ts.URL))
+			require.Len(t, signatures, 2)
+			assert.NotEqual(t, strings.Split(signatures[0], ",")[0], strings.Split(signatures[1], ",")[0])
+		})
+
+		t.Run("case=gives up once the retries are exhausted", func(t *testing.T) {
+			ts, calls := newFlakyServer(t, 10, http.StatusBadGateway)
+			require.Error(t, execute(t, new(http.Request), retryConfig, ts.URL))
+			assert.EqualValues(t, 4, atomic.LoadInt32(calls))
+		})
+
+		t.Run("case=does not retry client errors", func(t *testing.T) {
+			for _, status := range []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict} {
+				t.Run(fmt.Sprintf("status=%d", status), func(t *testing.T) {
+					ts, calls := newFlakyServer(t, 10, status)
+					require.Error(t, execute(t, new(http.Request), retryConfig, ts.URL))
+					assert.EqualValues(t, 1, atomic.LoadInt32(calls))
+				})
+			}
+		})
+
+		t.Run("case=does not retry if retries are disabled", func(t *testing.T) {
+			ts, calls := newFlakyServer(t, 10, http.StatusServiceUnavailable)
+			require.Error(t, execute(t, new(http.Request), `{"url":"%s","retries":0}`, ts.URL))
+			assert.EqualValues(t, 1, atomic.LoadInt32(calls))
+		})
+
+		t.Run("case=stops retrying once the request context is done", func(t *testing.T) {
+			ts, calls := newFlakyServer(t, 100, http.StatusServiceUnavailable)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			t.Cleanup(cancel)
+
+			start := time.Now()
+			require.Error(t, execute(t, new(http.Request).WithContext(ctx), `{"url":"%s","retries":100,"initial_interval":"50ms","max_interval":"50ms"}`, ts.URL))
+			assert.Less(t, int64(time.Since(start)), int64(time.Second))
+			assert.Less(t, atomic.LoadInt32(calls), int32(100))
+		})
+
+		t.Run("case=retries asynchronous web hooks in the background", func(t *testing.T) {
+			ts, calls := newFlakyServer(t, 2, http.StatusServiceUnavailable)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			require.NoError(t, execute(t, new(http.Request).WithContext(ctx), `{"url":"%s","async":true,"retries":3,"initial_interval":"50ms","max_interval":"50ms"}`, ts.URL))
+			cancel()
+
+			assert.Eventually(t, func() bool {
+				return atomic.LoadInt32(calls) == 3
+			}, 5*time.Second, 10*time.Millisecond)
+		})
+
+		t.Run("case=asynchronous web hooks can not enrich the session", func(t *testing.T) {
+			ts, calls := newFlakyServer(t, 0, 0)
+			require.Error(t, execute(t, new(http.Request), `{"url":"%s","async":true,"enrich_session":true}`, ts.URL))
+			assert.EqualValues(t, 0, atomic.LoadInt32(calls))
+		})
+	})
+
+	t.Run("suite=mutual tls", func(t *testing.T) {
+		trustedCert, trustedKey := newClientCertificate(t)
+		untrustedCert, untrustedKey := newClientCertificate(t)
+
+		block, _ := pem.Decode(trustedCert)
+		trusted, err := x509.ParseCertificate(block.Bytes)
+		require.NoError(t, err)
+		clientCAs := x509.NewCertPool()
+		clientCAs.AddCert(trusted)
+
+		var calls int32
+		ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+		}))
+		ts.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+		ts.StartTLS()
+		t.Cleanup(ts.Close)
+
+		ca, err := json.Marshal(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})))
+		require.NoError(t, err)
+
+		setClientCertificate := func(t *testing.T, name string, cert, key []byte) {
+			conf.MustSet(config.ViperKeySecretsWebHookTLS+"."+name+".certificate", string(cert))
+			conf.MustSet(config.ViperKeySecretsWebHookTLS+"."+name+".key", string(key))
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeySecretsWebHookTLS, map[string]interface{}{})
+			})
+		}
+
+		execute := func(t *testing.T, tls string) error {
+			atomic.StoreInt32(&calls, 0)
+			s := &session.Session{ID: x.NewUUID(), Identity: identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)}
+			return hook.NewWebHook(reg, json.RawMessage(`{"url":"`+ts.URL+`","retries":0,"tls":`+tls+`}`)).
+				ExecuteLoginPostHook(nil, new(http.Request), &login.Flow{ID: x.NewUUID()}, s)
+		}
+
+		t.Run("case=presents the client certificate", func(t *testing.T) {
+			setClientCertificate(t, "internal", trustedCert, trustedKey)
+			require.NoError(t, execute(t, `{"client_certificate":"internal","ca":`+string(ca)+`}`))
+			assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+		})
+
+		t.Run("case=uses the system certificate authorities without a ca", func(t *testing.T) {
+			setClientCertificate(t, "internal", trustedCert, trustedKey)
+			err := execute(t, `{"client_certificate":"internal"}`)
+			require.Error(t, err)
+			assert.Contains(t, fmt.Sprintf("%+v", err), "x509")
+		})
+
+		t.Run("case=fails without a client certificate", func(t *testing.T) {
+			require.Error(t, execute(t, `{"ca":`+string(ca)+`}`))
+			assert.EqualValues(t, 0, atomic.LoadInt32(&calls))
+
+			require.Error(t, execute(t, `{}`))
+			assert.EqualValues(t, 0, atomic.LoadInt32(&calls))
+		})
+
+		t.Run("case=fails with an untrusted client certificate", func(t *testing.T) {
+			setClientCertificate(t, "internal", untrustedCert, untrustedKey)
+			require.Error(t, execute(t, `{"client_certificate":"internal","ca":`+string(ca)+`}`))
+			assert.EqualValues(t, 0, atomic.LoadInt32(&calls))
+		})
+
+		t.Run("case=reloads the client certificate once it changes", func(t *testing.T) {
+			setClientCertificate(t, "internal", trustedCert, trustedKey)
+			require.NoError(t, execute(t, `{"client_certificate":"internal","ca":`+string(ca)+`}`))
+
+			setClientCertificate(t, "internal", untrustedCert, untrustedKey)
+			require.Error(t, execute(t, `{"client_certificate":"internal","ca":`+string(ca)+`}`))
+
+			setClientCertificate(t, "internal", trustedCert, trustedKey)
+			require.NoError(t, execute(t, `{"client_certificate":"internal","ca":`+string(ca)+`}`))
+		})
+
+		t.Run("case=fails if the client certificate is not set", func(t *testing.T) {
+			err := execute(t, `{"client_certificate":"unknown","ca":`+string(ca)+`}`)
+			require.Error(t, err)
+			assert.Contains(t, fmt.Sprintf("%+v", err), `"unknown" is not set`)
+		})
+
+		t.Run("case=fails if the certificate authorities are invalid", func(t *testing.T) {
+			require.Error(t, execute(t, `{"ca":"not a certificate"}`))
+		})
+	})
+
+	t.Run("suite=response mapper", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/web_hook.schema.json")
+
+		var status int
+		var response string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(response))
+		}))
+		t.Cleanup(ts.Close)
+
+		newIdentity := func(t *testing.T) *identity.Identity {
+			email := x.NewUUID().String() + "@ory.sh"
+			i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+			i.Traits = identity.Traits(`{"email":"` + email + `","tier":"free"}`)
+			i.Credentials[identity.CredentialsTypePassword] = identity.Credentials{
+				Type: identity.CredentialsTypePassword, Identifiers: []string{email}, Config: []byte(`{}`)}
+			require.NoError(t, reg.IdentityManager().Create(context.Background(), i))
+			return i
+		}
+
+		execute := func(t *testing.T, i *identity.Identity, mapper string) error {
+			s := &session.Session{ID: x.NewUUID(), Identity: i}
+			return hook.NewWebHook(reg, json.RawMessage(`{"url":"`+ts.URL+`","retries":0,"response_mapper_url":"`+mapper+`"}`)).
+				ExecutePostRegistrationPostPersistHook(nil, new(http.Request), &registration.Flow{ID: x.NewUUID(), Type: flow.TypeBrowser}, s)
+		}
+
+		const mapper = "file://./stub/web_hook.jsonnet"
+
+		t.Run("case=enriches the traits", func(t *testing.T) {
+			status, response = http.StatusOK, `{"traits":{"company":"acme","tier":"pro"}}`
+			i := newIdentity(t)
+			email := gjson.GetBytes(i.Traits, "email").String()
+
+			require.NoError(t, execute(t, i, mapper))
+			expected := `{"email":"` + email + `","company":"acme","tier":"pro"}`
+			assert.JSONEq(t, expected, string(i.Traits))
+			assert.NotEmpty(t, i.Credentials, "the identity must still carry its credentials")
+
+			actual, err := reg.PrivilegedIdentityPool().GetIdentity(context.Background(), i.ID)
+			require.NoError(t, err)
+			assert.JSONEq(t, expected, string(actual.Traits))
+		})
+
+		t.Run("case=removes traits set to null", func(t *testing.T) {
+			status, response = http.StatusOK, `{"traits":{"tier":null}}`
+			i := newIdentity(t)
+
+			require.NoError(t, execute(t, i, mapper))
+			assert.False(t, gjson.GetBytes(i.Traits, "tier").Exists())
+		})
+
+		t.Run("case=ignores responses other than 200", func(t *testing.T) {
+			status, response = http.StatusAccepted, `{"traits":{"company":"acme"}}`
+			i := newIdentity(t)
+			before := string(i.Traits)
+
+			require.NoError(t, execute(t, i, mapper))
+			assert.JSONEq(t, before, string(i.Traits))
+		})
+
+		for _, tc := range []struct {
+			d, response, mapper, reason string
+		}{
+			{
+				d:        "traits which violate the identity schema",
+				response: `{"traits":{"tier":"gold"}}`, mapper: mapper,
+				reason: "The traits returned by the web hook response mapper are invalid.",
+			},
+			{
+				d:        "changes of protected traits",
+				response: `{"traits":{"email":"someone-else@ory.sh"}}`, mapper: mapper,
+				reason: "The traits returned by the web hook response mapper are invalid.",
+			},
+			{
+				d:        "mapper output without traits",
+				response: `{"traits":{"company":"acme"}}`, mapper: "base64://" + base64.StdEncoding.EncodeToString([]byte(`{identity: {traits: "acme"}}`)),
+				reason: `The web hook response mapper must return an object for key "identity.traits".`,
+			},
+			{
+				d:        "mappers which fail to evaluate",
+				response: `{"traits":{"company":"acme"}}`, mapper: "base64://" + base64.StdEncoding.EncodeToString([]byte(`error "nope"`)),
+				reason: "Unable to evaluate the web hook response mapper.",
+			},
+			{
+				d:        "responses which are not JSON",
+				response: `not json`, mapper: mapper,
+				reason: "The web hook must respond with JSON to map the response.",
+			},
+		} {
+			t.Run("case=aborts the flow on "+tc.d, func(t *testing.T) {
+				status, response = http.StatusOK, tc.response
+				i := newIdentity(t)
+				before := string(i.Traits)
+
+				err := execute(t, i, tc.mapper)
+				require.Error(t, err)
+				assert.Equal(t, tc.reason, errorsx.Cause(err).(*herodot.DefaultError).Reason())
+				assert.JSONEq(t, before, string(i.Traits))
+
+				actual, err := reg.PrivilegedIdentityPool().GetIdentity(context.Background(), i.ID)
+				require.NoError(t, err)
+				assert.JSONEq(t, before, string(actual.Traits))
+			})
+		}
+
+		t.Run("case=can not run asynchronously", func(t *testing.T) {
+			s := &session.Session{ID: x.NewUUID(), Identity: newIdentity(t)}
+			err := hook.NewWebHook(reg, json.RawMessage(`{"url":"`+ts.URL+`","async":true,"response_mapper_url":"`+mapper+`"}`)).
+				ExecuteLoginPostHook(nil, new(http.Request), &login.Flow{ID: x.NewUUID()}, s)
+			require.Error(t, err)
+		})
+	})
+}
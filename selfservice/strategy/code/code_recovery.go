@@ -0,0 +1,77 @@
+package code
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/randx"
+
+	"github.com/ory/kratos/corp"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/selfservice/flow/recovery"
+	"github.com/ory/kratos/x"
+)
+
+// RecoveryCodeLength is the number of digits of a recovery code.
+const RecoveryCodeLength = 6
+
+var ErrInvalidRecoveryCode = errors.New("the recovery code is invalid")
+
+type RecoveryCode struct {
+	// ID represents the code's unique ID.
+	//
+	// required: true
+	// type: string
+	// format: uuid
+	ID uuid.UUID `json:"id" db:"id" faker:"-"`
+
+	// Code represents the recovery code. It is stored as a HMAC.
+	Code string `json:"-" db:"code"`
+
+	// RecoveryAddress links this code to a recovery address.
+	// required: true
+	RecoveryAddress *identity.RecoveryAddress `json:"recovery_address" belongs_to:"identity_recovery_addresses" fk_id:"RecoveryAddressID"`
+
+	// ExpiresAt is the time (UTC) when the code expires.
+	// required: true
+	ExpiresAt time.Time `json:"expires_at" faker:"time_type" db:"expires_at"`
+
+	// IssuedAt is the time (UTC) when the code was issued.
+	// required: true
+	IssuedAt time.Time `json:"issued_at" faker:"time_type" db:"issued_at"`
+
+	// Used is true if the code was already used or entered incorrectly too often.
+	Used bool `json:"-" faker:"-" db:"used"`
+
+	// Attempts counts how often the code was entered incorrectly.
+	Attempts int `json:"attempts" faker:"-" db:"attempts"`
+
+	// CreatedAt is a helper struct field for gobuffalo.pop.
+	CreatedAt time.Time `json:"-" faker:"-" db:"created_at"`
+	// UpdatedAt is a helper struct field for gobuffalo.pop.
+	UpdatedAt time.Time `json:"-" faker:"-" db:"updated_at"`
+	// RecoveryAddressID is a helper struct field for gobuffalo.pop.
+	RecoveryAddressID uuid.UUID `json:"-" faker:"-" db:"identity_recovery_address_id"`
+	// FlowID is a helper struct field for gobuffalo.pop.
+	FlowID uuid.UUID `json:"-" faker:"-" db:"selfservice_recovery_flow_id"`
+	NID    uuid.UUID `json:"-"  faker:"-" db:"nid"`
+}
+
+func (RecoveryCode) TableName(ctx context.Context) string {
+	return corp.ContextualizeTableName(ctx, "identity_recovery_codes")
+}
+
+func NewRecoveryCode(address *identity.RecoveryAddress, f *recovery.Flow, expiresIn time.Duration) *RecoveryCode {
+	now := time.Now().UTC()
+	return &RecoveryCode{
+		ID:              x.NewUUID(),
+		Code:            randx.MustString(RecoveryCodeLength, randx.Numeric),
+		RecoveryAddress: address,
+		ExpiresAt:       now.Add(expiresIn),
+		IssuedAt:        now,
+		FlowID:          f.ID,
+	}
+}
@@ -0,0 +1,24 @@
+package code
+
+import (
+	"context"
+
+	"github.com/gofrs/uuid"
+)
+
+type (
+	RecoveryCodePersister interface {
+		// CreateRecoveryCode stores the code and invalidates all other codes of the code's flow.
+		CreateRecoveryCode(ctx context.Context, code *RecoveryCode) error
+
+		// UseRecoveryCode marks the active code of the flow as used if it matches. If the code does
+		// not match, the attempt is counted and ErrInvalidRecoveryCode is returned. The code is
+		// invalidated once maxAttempts is reached. If the flow has no active code, sqlcon.ErrNoRows
+		// is returned.
+		UseRecoveryCode(ctx context.Context, flowID uuid.UUID, code string, maxAttempts int) (*RecoveryCode, error)
+	}
+
+	RecoveryCodePersistenceProvider interface {
+		RecoveryCodePersister() RecoveryCodePersister
+	}
+)
@@ -0,0 +1,81 @@
+package code
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/kratos/courier"
+	templates "github.com/ory/kratos/courier/template"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/selfservice/flow/recovery"
+	"github.com/ory/kratos/x"
+)
+
+type (
+	senderDependencies interface {
+		courier.Provider
+		identity.PoolProvider
+		x.LoggingProvider
+		config.Provider
+
+		RecoveryCodePersistenceProvider
+	}
+
+	SenderProvider interface {
+		CodeSender() *Sender
+	}
+
+	Sender struct {
+		r senderDependencies
+	}
+)
+
+var ErrUnknownAddress = errors.New("recovery requested for unknown address")
+
+func NewSender(r senderDependencies) *Sender {
+	return &Sender{r: r}
+}
+
+// SendRecoveryCode sends a recovery code to the specified address. If the address does not exist in the store, an email is
+// still being sent to prevent account enumeration attacks. In that case, this function returns the ErrUnknownAddress
+// error.
+func (s *Sender) SendRecoveryCode(ctx context.Context, f *recovery.Flow, via identity.VerifiableAddressType, to string) error {
+	s.r.Logger().
+		WithField("via", via).
+		WithSensitiveField("address", to).
+		Debug("Preparing recovery code.")
+
+	address, err := s.r.IdentityPool().FindRecoveryAddressByValue(ctx, identity.RecoveryAddressTypeEmail, to)
+	if err != nil {
+		if err := s.send(ctx, string(via), templates.NewRecoveryInvalid(s.r.Config(ctx), &templates.RecoveryInvalidModel{To: to})); err != nil {
+			return err
+		}
+		return errors.Cause(ErrUnknownAddress)
+	}
+
+	code := NewRecoveryCode(address, f, s.r.Config(ctx).SelfServiceCodeMethodLifespan())
+	if err := s.r.RecoveryCodePersister().CreateRecoveryCode(ctx, code); err != nil {
+		return err
+	}
+
+	s.r.Audit().
+		WithField("via", address.Via).
+		WithField("identity_id", address.IdentityID).
+		WithField("recovery_code_id", code.ID).
+		WithSensitiveField("email_address", address.Value).
+		Info("Sending out recovery email with recovery code.")
+	return s.send(ctx, string(address.Via), templates.NewRecoveryCodeValid(s.r.Config(ctx),
+		&templates.RecoveryCodeValidModel{To: address.Value, RecoveryCode: code.Code}))
+}
+
+func (s *Sender) send(ctx context.Context, via string, t courier.EmailTemplate) error {
+	switch via {
+	case identity.AddressTypeEmail:
+		_, err := s.r.Courier(ctx).QueueEmail(ctx, t)
+		return err
+	default:
+		return errors.Errorf("received unexpected via type: %s", via)
+	}
+}
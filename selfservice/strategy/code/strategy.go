@@ -0,0 +1,54 @@
+package code
+
+import (
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/selfservice/flow/recovery"
+	"github.com/ory/kratos/selfservice/flow/settings"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/ui/node"
+	"github.com/ory/kratos/x"
+	"github.com/ory/x/decoderx"
+)
+
+var _ recovery.Strategy = new(Strategy)
+
+type (
+	strategyDependencies interface {
+		x.CSRFProvider
+		x.CSRFTokenGeneratorProvider
+		x.WriterProvider
+		x.LoggingProvider
+
+		config.Provider
+
+		session.ManagementProvider
+		settings.HandlerProvider
+		settings.FlowPersistenceProvider
+
+		identity.PoolProvider
+		identity.ManagementProvider
+
+		recovery.ErrorHandlerProvider
+		recovery.FlowPersistenceProvider
+		recovery.StrategyProvider
+
+		RecoveryCodePersistenceProvider
+		SenderProvider
+	}
+
+	// Strategy recovers accounts using a one-time code which is sent to the recovery address. Contrary to
+	// the link strategy, the code is entered in the recovery flow it was requested from.
+	Strategy struct {
+		d  strategyDependencies
+		dx *decoderx.HTTP
+	}
+)
+
+func NewStrategy(d strategyDependencies) *Strategy {
+	return &Strategy{d: d, dx: decoderx.NewHTTP()}
+}
+
+func (s *Strategy) RecoveryNodeGroup() node.Group {
+	return node.RecoveryCodeGroup
+}
@@ -0,0 +1,302 @@
+package code
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/decoderx"
+	"github.com/ory/x/sqlcon"
+	"github.com/ory/x/sqlxx"
+	"github.com/ory/x/urlx"
+
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/schema"
+	"github.com/ory/kratos/selfservice/flow"
+	"github.com/ory/kratos/selfservice/flow/recovery"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/text"
+	"github.com/ory/kratos/ui/node"
+	"github.com/ory/kratos/x"
+)
+
+func (s *Strategy) RecoveryStrategyID() string {
+	return recovery.StrategyRecoveryCodeName
+}
+
+func (s *Strategy) PopulateRecoveryMethod(r *http.Request, f *recovery.Flow) error {
+	f.UI.SetCSRF(s.d.GenerateCSRFToken(r))
+	f.UI.GetNodes().Upsert(
+		node.NewInputField("email", nil, node.RecoveryCodeGroup, node.InputAttributeTypeEmail, node.WithRequiredInputAttribute),
+	)
+	f.UI.GetNodes().Append(node.NewInputField("method", s.RecoveryStrategyID(), node.RecoveryCodeGroup, node.InputAttributeTypeSubmit).WithMetaLabel(text.NewInfoNodeLabelSubmit()))
+
+	return nil
+}
+
+// swagger:model submitSelfServiceRecoveryFlowWithCodeMethod
+// nolint:deadcode,unused
+type submitSelfServiceRecoveryFlowWithCodeMethod struct {
+	// Email to Recover
+	//
+	// Needs to be set when initiating the flow. If the email is a registered
+	// recovery email, a recovery code will be sent. If the email is not known,
+	// a email with details on what happened will be sent instead.
+	//
+	// format: email
+	// in: body
+	Email string `json:"email" form:"email"`
+
+	// Recovery Code
+	//
+	// The code which was sent to the recovery email. Needs to be set to complete
+	// the flow once the code was sent.
+	//
+	// in: body
+	Code string `json:"code" form:"code"`
+
+	// Sending the anti-csrf token is only required for browser login flows.
+	CSRFToken string `form:"csrf_token" json:"csrf_token"`
+
+	// Resend the Recovery Code
+	//
+	// Set to `code` instead of `method` to send a new recovery code to `email`. The code is only sent again once
+	// `selfservice.flows.recovery.resend.cooldown` has passed and at most
+	// `selfservice.flows.recovery.resend.max_resends` times per flow.
+	Resend string `json:"resend" form:"resend"`
+}
+
+// Recover handles the recovery flow if the `code` method is used. In the `choose_method` state, a recovery code
+// is sent to `email`. In the `sent_email` state, the `code` is validated. If it is valid, the identity is signed
+// in and redirected to the Settings UI URL where it can update its password. If `code` is empty or `resend` is
+// set, a new code is sent instead. A code is invalidated once it was entered incorrectly
+// `selfservice.methods.code.config.max_attempts` times or once a new code was sent.
+func (s *Strategy) Recover(w http.ResponseWriter, r *http.Request, f *recovery.Flow) (err error) {
+	body, err := s.decodeRecovery(r)
+	if err != nil {
+		return s.handleRecoveryError(r, nil, body, err)
+	}
+
+	// The resend button is submitted instead of the method.
+	if len(body.Method) == 0 {
+		body.Method = body.Resend
+	}
+
+	if err := flow.MethodEnabledAndAllowed(r.Context(), s.RecoveryStrategyID(), body.Method, s.d); err != nil {
+		return s.handleRecoveryError(r, nil, body, err)
+	}
+
+	if err := f.Valid(); err != nil {
+		return s.handleRecoveryError(r, f, body, err)
+	}
+
+	if err := flow.EnsureCSRF(r, f.Type, s.d.Config(r.Context()).DisableAPIFlowEnforcement(), s.d.GenerateCSRFToken, body.CSRFToken); err != nil {
+		return s.handleRecoveryError(r, f, body, err)
+	}
+
+	switch f.State {
+	case recovery.StateChooseMethod:
+		return s.recoverySendCode(r, f, body)
+	case recovery.StateEmailSent:
+		if len(body.Code) == 0 || len(body.Resend) > 0 {
+			return s.recoverySendCode(r, f, body)
+		}
+		return s.recoveryUseCode(w, r, f, body)
+	case recovery.StatePassedChallenge:
+		// was already handled, do not allow retry
+		return s.retryRecoveryFlowWithMessage(w, r, f.Type, text.NewErrorValidationRecoveryRetrySuccess())
+	default:
+		return s.retryRecoveryFlowWithMessage(w, r, f.Type, text.NewErrorValidationRecoveryStateFailure())
+	}
+}
+
+func (s *Strategy) recoverySendCode(r *http.Request, f *recovery.Flow, body *recoverySubmitPayload) error {
+	if len(body.Email) == 0 {
+		return s.handleRecoveryError(r, f, body, schema.NewRequiredError("#/email", "email"))
+	}
+
+	if err := f.EnsureCanSend(s.d.Config(r.Context())); err != nil {
+		return s.handleRecoveryError(r, f, body, err)
+	}
+
+	if err := s.d.CodeSender().SendRecoveryCode(r.Context(), f, identity.VerifiableAddressTypeEmail, body.Email); err != nil {
+		if !errors.Is(err, ErrUnknownAddress) {
+			return s.handleRecoveryError(r, f, body, err)
+		}
+		// Continue execution
+	}
+
+	s.populateRecoveryCodeNodes(r, f, body)
+	f.MarkSent()
+	f.Active = sqlxx.NullString(s.RecoveryNodeGroup())
+	f.State = recovery.StateEmailSent
+	f.UI.Messages.Set(text.NewRecoveryCodeSent())
+	if err := s.d.RecoveryFlowPersister().UpdateRecoveryFlow(r.Context(), f); err != nil {
+		return s.handleRecoveryError(r, f, body, err)
+	}
+
+	return nil
+}
+
+func (s *Strategy) recoveryUseCode(w http.ResponseWriter, r *http.Request, f *recovery.Flow, body *recoverySubmitPayload) error {
+	rc, err := s.d.RecoveryCodePersister().UseRecoveryCode(r.Context(), f.ID, body.Code, s.d.Config(r.Context()).SelfServiceCodeMethodMaxAttempts())
+	if errors.Is(err, sqlcon.ErrNoRows) || (errors.Is(err, ErrInvalidRecoveryCode) && rc.Used) {
+		return s.handleRecoveryError(r, f, body, schema.NewRecoveryCodeInvalidOrAlreadyUsedError("#/code"))
+	} else if errors.Is(err, ErrInvalidRecoveryCode) {
+		return s.handleRecoveryError(r, f, body, schema.NewRecoveryCodeInvalidError("#/code"))
+	} else if err != nil {
+		return s.handleRecoveryError(r, f, body, err)
+	}
+
+	return s.recoveryIssueSession(w, r, f, body, rc.RecoveryAddress.IdentityID)
+}
+
+// recoveryIssueSession signs the recovered identity in and redirects it to the settings flow. Contrary to the
+// link strategy, `selfservice.flows.recovery.mode` is not taken into account.
+func (s *Strategy) recoveryIssueSession(w http.ResponseWriter, r *http.Request, f *recovery.Flow, body *recoverySubmitPayload, recoveredID uuid.UUID) error {
+	recovered, err := s.d.IdentityPool().GetIdentity(r.Context(), recoveredID)
+	if err != nil {
+		return s.handleRecoveryError(r, f, body, err)
+	}
+
+	// The code might have been sent before the identity deleted its account.
+	if recovered.IsDeleted() {
+		return s.handleRecoveryError(r, f, body, schema.NewRecoveryCodeInvalidOrAlreadyUsedError("#/code"))
+	}
+
+	f.UI.Messages.Clear()
+	f.State = recovery.StatePassedChallenge
+	f.RecoveredIdentityID = uuid.NullUUID{
+		UUID:  recoveredID,
+		Valid: true,
+	}
+	if err := s.d.RecoveryFlowPersister().UpdateRecoveryFlow(r.Context(), f); err != nil {
+		return s.handleRecoveryError(r, f, body, err)
+	}
+
+	sess := session.NewActiveSession(recovered, s.d.Config(r.Context()), time.Now().UTC())
+	sess.Recovered = true
+	if err := s.d.SessionManager().CreateAndIssueCookie(r.Context(), w, r, sess); err != nil {
+		return s.handleRecoveryError(r, f, body, err)
+	}
+
+	if err := s.d.IdentityManager().RecordCredentialEvents(r.Context(), identity.NewCredentialEvent(recoveredID,
+		identity.CredentialEventRecovered, "", x.ClientIPString(r, s.d.Config(r.Context()).TrustedProxies()))); err != nil {
+		return s.handleRecoveryError(r, f, body, err)
+	}
+
+	sf, err := s.d.SettingsHandler().NewFlow(w, r, sess.Identity, flow.TypeBrowser)
+	if err != nil {
+		return s.handleRecoveryError(r, f, body, err)
+	}
+
+	sf.UI.Messages.Set(text.NewRecoverySuccessful(time.Now().Add(s.d.Config(r.Context()).SelfServiceFlowSettingsPrivilegedSessionMaxAge())))
+	if err := s.d.SettingsFlowPersister().UpdateSettingsFlow(r.Context(), sf); err != nil {
+		return s.handleRecoveryError(r, f, body, err)
+	}
+
+	s.d.Audit().
+		WithField("identity_id", recoveredID).
+		WithField("recovery_flow_id", f.ID).
+		Info("The identity was recovered using a recovery code.")
+
+	http.Redirect(w, r, sf.AppendTo(s.d.Config(r.Context()).SelfServiceFlowSettingsUI()).String(), http.StatusFound)
+	return errors.WithStack(flow.ErrCompletedByStrategy)
+}
+
+func (s *Strategy) retryRecoveryFlowWithMessage(w http.ResponseWriter, r *http.Request, ft flow.Type, message *text.Message) error {
+	s.d.Logger().WithRequest(r).WithField("message", message).Debug("A recovery flow is being retried because a validation error occurred.")
+
+	req, err := recovery.NewFlow(s.d.Config(r.Context()), s.d.Config(r.Context()).SelfServiceFlowRecoveryRequestLifespan(), s.d.GenerateCSRFToken(r), r, s.d.RecoveryStrategies(r.Context()), ft)
+	if err != nil {
+		return err
+	}
+
+	req.UI.Messages.Add(message)
+	if err := s.d.RecoveryFlowPersister().CreateRecoveryFlow(r.Context(), req); err != nil {
+		return err
+	}
+
+	if ft == flow.TypeBrowser {
+		http.Redirect(w, r, req.AppendTo(s.d.Config(r.Context()).SelfServiceFlowRecoveryUI()).String(), http.StatusFound)
+	} else {
+		http.Redirect(w, r, urlx.CopyWithQuery(urlx.AppendPaths(s.d.Config(r.Context()).SelfPublicURL(r),
+			recovery.RouteGetFlow), url.Values{"id": {req.ID.String()}}).String(), http.StatusFound)
+	}
+
+	return errors.WithStack(flow.ErrCompletedByStrategy)
+}
+
+// populateRecoveryCodeNodes shows the form in which the recovery code is entered. The email field and the
+// resend button are kept so that a new code can be requested.
+func (s *Strategy) populateRecoveryCodeNodes(r *http.Request, f *recovery.Flow, body *recoverySubmitPayload) {
+	var email string
+	if body != nil {
+		email = body.Email
+	}
+
+	f.UI.Nodes = node.Nodes{}
+	f.UI.SetCSRF(s.d.GenerateCSRFToken(r))
+	f.UI.GetNodes().Append(node.NewInputField("email", email, node.RecoveryCodeGroup, node.InputAttributeTypeEmail, node.WithRequiredInputAttribute))
+	f.UI.GetNodes().Append(node.NewInputField("code", nil, node.RecoveryCodeGroup, node.InputAttributeTypeText).WithMetaLabel(text.NewInfoNodeLabelRecoveryCode()))
+	f.UI.GetNodes().Append(node.NewInputField("method", s.RecoveryStrategyID(), node.RecoveryCodeGroup, node.InputAttributeTypeSubmit).WithMetaLabel(text.NewInfoNodeLabelSubmit()))
+	f.UI.GetNodes().Append(node.NewInputField("resend", s.RecoveryStrategyID(), node.RecoveryCodeGroup, node.InputAttributeTypeSubmit).WithMetaLabel(text.NewInfoNodeLabelResend()))
+}
+
+func (s *Strategy) handleRecoveryError(r *http.Request, f *recovery.Flow, body *recoverySubmitPayload, err error) error {
+	if f == nil {
+		return err
+	}
+
+	if f.State == recovery.StateEmailSent {
+		f.UI.Messages.Clear()
+		s.populateRecoveryCodeNodes(r, f, body)
+		return err
+	}
+
+	var email string
+	if body != nil {
+		email = body.Email
+	}
+
+	f.UI.Reset("email")
+	f.UI.SetCSRF(s.d.GenerateCSRFToken(r))
+	f.UI.GetNodes().Upsert(
+		node.NewInputField("email", email, node.RecoveryCodeGroup, node.InputAttributeTypeEmail, node.WithRequiredInputAttribute),
+	)
+
+	return err
+}
+
+type recoverySubmitPayload struct {
+	Method    string `json:"method" form:"method"`
+	Code      string `json:"code" form:"code"`
+	CSRFToken string `json:"csrf_token" form:"csrf_token"`
+	Flow      string `json:"flow" form:"flow"`
+	Email     string `json:"email" form:"email"`
+	Resend    string `json:"resend" form:"resend"`
+}
+
+func (s *Strategy) decodeRecovery(r *http.Request) (*recoverySubmitPayload, error) {
+	var body recoverySubmitPayload
+
+	compiler, err := decoderx.HTTPRawJSONSchemaCompiler(recoveryMethodSchema)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if err := s.dx.Decode(r, &body, compiler,
+		decoderx.HTTPDecoderUseQueryAndBody(),
+		decoderx.HTTPKeepRequestBody(true),
+		decoderx.HTTPDecoderAllowedMethods("POST", "GET"),
+		decoderx.HTTPDecoderSetValidatePayloads(false),
+		decoderx.HTTPDecoderJSONFollowsFormFormat(),
+	); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &body, nil
+}
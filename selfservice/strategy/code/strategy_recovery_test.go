@@ -0,0 +1,337 @@
+package code_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/kratos-client-go"
+	"github.com/ory/x/sqlxx"
+
+	"github.com/ory/kratos/corpx"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/selfservice/flow/recovery"
+	"github.com/ory/kratos/text"
+	"github.com/ory/kratos/ui/node"
+	"github.com/ory/kratos/x"
+)
+
+func init() {
+	corpx.RegisterFakes()
+}
+
+func initViper(t *testing.T, c *config.Config) {
+	c.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/default.schema.json")
+	c.MustSet(config.ViperKeySelfServiceBrowserDefaultReturnTo, "https://www.ory.sh")
+	c.MustSet(config.ViperKeySelfServiceStrategyConfig+"."+identity.CredentialsTypePassword.String()+".enabled", true)
+	c.MustSet(config.ViperKeySelfServiceStrategyConfig+"."+recovery.StrategyRecoveryLinkName+".enabled", false)
+	c.MustSet(config.ViperKeySelfServiceStrategyConfig+"."+recovery.StrategyRecoveryCodeName+".enabled", true)
+	c.MustSet(config.ViperKeySelfServiceRecoveryEnabled, true)
+	c.MustSet(config.ViperKeySelfServiceRecoveryResendCooldown, "0s")
+}
+
+var codePattern = regexp.MustCompile(`\b[0-9]{6}\b`)
+
+func TestRecovery(t *testing.T) {
+	var identityToRecover = &identity.Identity{
+		Credentials: map[identity.CredentialsType]identity.Credentials{
+			"password": {Type: "password", Identifiers: []string{"recoverme@ory.sh"}, Config: sqlxx.JSONRawMessage(`{"hashed_password":"foo"}`)}},
+		Traits:   identity.Traits(`{"email":"recoverme@ory.sh"}`),
+		SchemaID: config.DefaultIdentityTraitsSchemaID,
+	}
+	var recoveryEmail = gjson.GetBytes(identityToRecover.Traits, "email").String()
+
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	initViper(t, conf)
+
+	_ = testhelpers.NewRecoveryUIFlowEchoServer(t, reg)
+	_ = testhelpers.NewLoginUIFlowEchoServer(t, reg)
+	_ = testhelpers.NewSettingsUIFlowEchoServer(t, reg)
+	_ = testhelpers.NewErrorTestServer(t, reg)
+
+	public, _ := testhelpers.NewKratosServer(t, reg)
+
+	require.NoError(t, reg.IdentityManager().Create(context.Background(), identityToRecover,
+		identity.ManagerAllowWriteProtectedTraits))
+
+	var getFlow = func(t *testing.T, hc *http.Client, id string) *kratos.RecoveryFlow {
+		f, _, err := testhelpers.NewSDKCustomClient(public, hc).PublicApi.GetSelfServiceRecoveryFlow(context.Background()).Id(id).Execute()
+		require.NoError(t, err)
+		return f
+	}
+
+	var submit = func(t *testing.T, hc *http.Client, f *kratos.RecoveryFlow, values func(url.Values)) (string, *http.Response) {
+		payload := testhelpers.SDKFormFieldsToURLValues(f.Ui.Nodes)
+		values(payload)
+		return testhelpers.RecoveryMakeRequest(t, false, f, hc, payload.Encode())
+	}
+
+	var submitCode = func(t *testing.T, hc *http.Client, f *kratos.RecoveryFlow, code string) (string, *http.Response) {
+		return submit(t, hc, getFlow(t, hc, f.Id), func(v url.Values) {
+			v.Del("resend")
+			v.Set("code", code)
+		})
+	}
+
+	var resendCode = func(t *testing.T, hc *http.Client, f *kratos.RecoveryFlow) (string, *http.Response) {
+		return submit(t, hc, getFlow(t, hc, f.Id), func(v url.Values) {
+			v.Del("method")
+			v.Set("code", "123456")
+		})
+	}
+
+	var sendCode = func(t *testing.T, hc *http.Client, f *kratos.RecoveryFlow, email string) string {
+		body, res := submit(t, hc, f, func(v url.Values) {
+			v.Set("email", email)
+		})
+		assert.EqualValues(t, http.StatusOK, res.StatusCode, "%s", body)
+		assert.Contains(t, res.Request.URL.String(), conf.SelfServiceFlowRecoveryUI().String())
+
+		assert.EqualValues(t, recovery.StateEmailSent, gjson.Get(body, "state").String(), "%s", body)
+		assert.EqualValues(t, node.RecoveryCodeGroup, gjson.Get(body, "active").String(), "%s", body)
+		assert.EqualValues(t, email, gjson.Get(body, "ui.nodes.#(attributes.name==email).attributes.value").String(), "%s", body)
+		assert.True(t, gjson.Get(body, "ui.nodes.#(attributes.name==code)").Exists(), "%s", body)
+		assert.EqualValues(t, text.NewRecoveryCodeSent().Text, gjson.Get(body, "ui.messages.0.text").String(), "%s", body)
+		return body
+	}
+
+	var expectCode = func(t *testing.T) string {
+		message := testhelpers.CourierExpectMessage(t, reg, recoveryEmail, "Recover access to your account")
+		assert.Contains(t, message.Body, "please recover access to your account by entering the following code")
+
+		code := codePattern.FindString(message.Body)
+		require.Len(t, code, 6, "%s", message.Body)
+		return code
+	}
+
+	var wrongCode = func(code string) string {
+		if code[0] == '0' {
+			return "1" + code[1:]
+		}
+		return "0" + code[1:]
+	}
+
+	var expectCodeError = func(t *testing.T, body string, res *http.Response, expected *text.Message) {
+		assert.EqualValues(t, http.StatusOK, res.StatusCode, "%s", body)
+		assert.Contains(t, res.Request.URL.String(), conf.SelfServiceFlowRecoveryUI().String())
+		assert.EqualValues(t, expected.ID, gjson.Get(body, "ui.nodes.#(attributes.name==code).messages.0.id").Int(), "%s", body)
+	}
+
+	t.Run("description=should show the code method", func(t *testing.T) {
+		f := testhelpers.InitializeRecoveryFlowViaBrowser(t, testhelpers.NewClientWithCookies(t), public)
+		var names []string
+		for _, n := range f.Ui.Nodes {
+			names = append(names, n.Attributes.UiNodeInputAttributes.Name)
+			if n.Attributes.UiNodeInputAttributes.Name != "csrf_token" {
+				assert.EqualValues(t, node.RecoveryCodeGroup, n.Group)
+			}
+		}
+		assert.Equal(t, []string{"csrf_token", "email", "method"}, names)
+		assert.Equal(t, recovery.StrategyRecoveryCodeName, testhelpers.SDKFormFieldsToURLValues(f.Ui.Nodes).Get("method"))
+	})
+
+	t.Run("description=should require an email to be sent", func(t *testing.T) {
+		hc := testhelpers.NewClientWithCookies(t)
+		body, res := submit(t, hc, testhelpers.InitializeRecoveryFlowViaBrowser(t, hc, public), func(v url.Values) {
+			v.Del("email")
+		})
+		assert.EqualValues(t, http.StatusOK, res.StatusCode)
+		assert.EqualValues(t, "Property email is missing.",
+			gjson.Get(body, "ui.nodes.#(attributes.name==email).messages.0.text").String(), "%s", body)
+	})
+
+	t.Run("description=should try to recover an email that does not exist", func(t *testing.T) {
+		hc := testhelpers.NewClientWithCookies(t)
+		email := x.NewUUID().String() + "@ory.sh"
+		sendCode(t, hc, testhelpers.InitializeRecoveryFlowViaBrowser(t, hc, public), email)
+
+		message := testhelpers.CourierExpectMessage(t, reg, email, "Account access attempted")
+		assert.Contains(t, message.Body, "If this was you, check if you signed up using a different address.")
+	})
+
+	t.Run("description=should send a code in API flows", func(t *testing.T) {
+		hc := testhelpers.NewDebugClient(t)
+		f := testhelpers.InitializeRecoveryFlowViaAPI(t, hc, public)
+
+		payload := testhelpers.SDKFormFieldsToURLValues(f.Ui.Nodes)
+		payload.Set("email", recoveryEmail)
+		body, res := testhelpers.RecoveryMakeRequest(t, true, f, hc, testhelpers.EncodeFormAsJSON(t, true, payload))
+		assert.EqualValues(t, http.StatusOK, res.StatusCode, "%s", body)
+		assert.EqualValues(t, recovery.StateEmailSent, gjson.Get(body, "state").String(), "%s", body)
+		expectCode(t)
+	})
+
+	t.Run("description=should recover an account", func(t *testing.T) {
+		hc := testhelpers.NewClientWithCookies(t)
+		f := testhelpers.InitializeRecoveryFlowViaBrowser(t, hc, public)
+		sendCode(t, hc, f, recoveryEmail)
+
+		body, res := submitCode(t, hc, f, expectCode(t))
+		assert.EqualValues(t, http.StatusOK, res.StatusCode, "%s", body)
+		assert.Contains(t, res.Request.URL.String(), conf.SelfServiceFlowSettingsUI().String())
+		assert.Equal(t, text.NewRecoverySuccessful(time.Now().Add(time.Hour)).Text,
+			gjson.Get(body, "ui.messages.0.text").String(), "%s", body)
+		assert.EqualValues(t, identityToRecover.ID.String(), gjson.Get(body, "identity.id").String(), "%s", body)
+
+		assert.EqualValues(t, recovery.StatePassedChallenge, getFlow(t, hc, f.Id).State)
+	})
+
+	t.Run("description=should record the recovery in the credential history", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyIdentityCredentialHistoryEnabled, true)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyIdentityCredentialHistoryEnabled, false)
+		})
+
+		before, err := reg.CredentialEventPersister().CountCredentialEvents(context.Background(), identityToRecover.ID)
+		require.NoError(t, err)
+
+		hc := testhelpers.NewClientWithCookies(t)
+		f := testhelpers.InitializeRecoveryFlowViaBrowser(t, hc, public)
+		sendCode(t, hc, f, recoveryEmail)
+		body, res := submitCode(t, hc, f, expectCode(t))
+		assert.Contains(t, res.Request.URL.String(), conf.SelfServiceFlowSettingsUI().String(), "%s", body)
+
+		events, err := reg.CredentialEventPersister().ListCredentialEvents(context.Background(), identityToRecover.ID, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, events, int(before)+1)
+		assert.Equal(t, identity.CredentialEventRecovered, events[0].Event)
+	})
+
+	t.Run("description=should only accept the code which was sent last", func(t *testing.T) {
+		hc := testhelpers.NewClientWithCookies(t)
+		f := testhelpers.InitializeRecoveryFlowViaBrowser(t, hc, public)
+
+		sendCode(t, hc, f, recoveryEmail)
+		first := expectCode(t)
+
+		sendCode(t, hc, getFlow(t, hc, f.Id), recoveryEmail)
+		second := expectCode(t)
+		if first == second {
+			t.Skip("the same code was generated twice")
+		}
+
+		body, res := submitCode(t, hc, f, first)
+		expectCodeError(t, body, res, text.NewErrorValidationRecoveryCodeInvalid())
+
+		body, res = submitCode(t, hc, f, second)
+		assert.Contains(t, res.Request.URL.String(), conf.SelfServiceFlowSettingsUI().String(), "%s", body)
+	})
+
+	t.Run("description=should invalidate the code after too many attempts", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyCodeMaxAttempts, 2)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyCodeMaxAttempts, 5)
+		})
+
+		hc := testhelpers.NewClientWithCookies(t)
+		f := testhelpers.InitializeRecoveryFlowViaBrowser(t, hc, public)
+		sendCode(t, hc, f, recoveryEmail)
+		code := expectCode(t)
+
+		body, res := submitCode(t, hc, f, wrongCode(code))
+		expectCodeError(t, body, res, text.NewErrorValidationRecoveryCodeInvalid())
+
+		body, res = submitCode(t, hc, f, wrongCode(code))
+		expectCodeError(t, body, res, text.NewErrorValidationRecoveryCodeInvalidOrAlreadyUsed())
+
+		body, res = submitCode(t, hc, f, code)
+		expectCodeError(t, body, res, text.NewErrorValidationRecoveryCodeInvalidOrAlreadyUsed())
+
+		t.Run("case=a new code can be requested", func(t *testing.T) {
+			sendCode(t, hc, getFlow(t, hc, f.Id), recoveryEmail)
+
+			body, res := submitCode(t, hc, f, expectCode(t))
+			assert.Contains(t, res.Request.URL.String(), conf.SelfServiceFlowSettingsUI().String(), "%s", body)
+		})
+	})
+
+	t.Run("description=should resend the code", func(t *testing.T) {
+		hc := testhelpers.NewClientWithCookies(t)
+		f := testhelpers.InitializeRecoveryFlowViaBrowser(t, hc, public)
+		sendCode(t, hc, f, recoveryEmail)
+		first := expectCode(t)
+
+		assert.EqualValues(t, recovery.StrategyRecoveryCodeName, testhelpers.SDKFormFieldsToURLValues(getFlow(t, hc, f.Id).Ui.Nodes).Get("resend"))
+
+		body, res := resendCode(t, hc, f)
+		assert.EqualValues(t, http.StatusOK, res.StatusCode, "%s", body)
+		assert.EqualValues(t, text.NewRecoveryCodeSent().Text, gjson.Get(body, "ui.messages.0.text").String(), "%s", body)
+		second := expectCode(t)
+		if first == second {
+			t.Skip("the same code was generated twice")
+		}
+
+		body, res = submitCode(t, hc, f, first)
+		expectCodeError(t, body, res, text.NewErrorValidationRecoveryCodeInvalid())
+
+		body, res = submitCode(t, hc, f, second)
+		assert.Contains(t, res.Request.URL.String(), conf.SelfServiceFlowSettingsUI().String(), "%s", body)
+	})
+
+	t.Run("description=should enforce the resend cooldown", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceRecoveryResendCooldown, "1m")
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceRecoveryResendCooldown, "0s")
+		})
+
+		hc := testhelpers.NewClientWithCookies(t)
+		f := testhelpers.InitializeRecoveryFlowViaBrowser(t, hc, public)
+		sendCode(t, hc, f, recoveryEmail)
+		code := expectCode(t)
+
+		body, res := resendCode(t, hc, f)
+		assert.EqualValues(t, http.StatusOK, res.StatusCode, "%s", body)
+		assert.EqualValues(t, text.ErrorValidationRecoveryResendCooldown, gjson.Get(body, "ui.messages.0.id").Int(), "%s", body)
+		assert.EqualValues(t, recovery.StrategyRecoveryCodeName, gjson.Get(body, "ui.nodes.#(attributes.name==resend).attributes.value").String(), "%s", body)
+
+		body, res = submitCode(t, hc, f, code)
+		assert.Contains(t, res.Request.URL.String(), conf.SelfServiceFlowSettingsUI().String(), "the previous code must still be valid: %s", body)
+	})
+
+	t.Run("description=should limit the number of resends", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceRecoveryMaxResends, 1)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceRecoveryMaxResends, 5)
+		})
+
+		hc := testhelpers.NewClientWithCookies(t)
+		f := testhelpers.InitializeRecoveryFlowViaBrowser(t, hc, public)
+		sendCode(t, hc, f, recoveryEmail)
+		expectCode(t)
+
+		body, res := resendCode(t, hc, f)
+		assert.EqualValues(t, text.NewRecoveryCodeSent().Text, gjson.Get(body, "ui.messages.0.text").String(), "%s", body)
+		expectCode(t)
+
+		body, res = resendCode(t, hc, f)
+		assert.EqualValues(t, http.StatusOK, res.StatusCode, "%s", body)
+		assert.EqualValues(t, text.ErrorValidationRecoveryResendLimit, gjson.Get(body, "ui.messages.0.id").Int(), "%s", body)
+	})
+
+	t.Run("description=should not accept an expired code", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyCodeLifespan, time.Millisecond*200)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyCodeLifespan, time.Minute*15)
+		})
+
+		hc := testhelpers.NewClientWithCookies(t)
+		f := testhelpers.InitializeRecoveryFlowViaBrowser(t, hc, public)
+		sendCode(t, hc, f, recoveryEmail)
+		code := expectCode(t)
+
+		time.Sleep(time.Millisecond * 201)
+
+		body, res := submitCode(t, hc, f, code)
+		expectCodeError(t, body, res, text.NewErrorValidationRecoveryCodeInvalidOrAlreadyUsed())
+	})
+}
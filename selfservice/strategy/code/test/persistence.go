@@ -0,0 +1,118 @@
+package code
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bxcodec/faker/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/assertx"
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/persistence"
+	"github.com/ory/kratos/selfservice/flow/recovery"
+	"github.com/ory/kratos/selfservice/strategy/code"
+	"github.com/ory/kratos/x"
+)
+
+func TestPersister(ctx context.Context, conf *config.Config, p interface {
+	persistence.Persister
+}) func(t *testing.T) {
+	return func(t *testing.T) {
+		nid, p := testhelpers.NewNetworkUnlessExisting(t, ctx, p)
+
+		conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/identity.schema.json")
+		conf.MustSet(config.ViperKeySecretsDefault, []string{"secret-a", "secret-b"})
+
+		t.Run("code=recovery", func(t *testing.T) {
+			newRecoveryCode := func(t *testing.T, email string, expiresIn time.Duration) *code.RecoveryCode {
+				var f recovery.Flow
+				require.NoError(t, faker.FakeData(&f))
+				require.NoError(t, p.CreateRecoveryFlow(ctx, &f))
+
+				var i identity.Identity
+				require.NoError(t, faker.FakeData(&i))
+
+				address := &identity.RecoveryAddress{Value: email, Via: identity.RecoveryAddressTypeEmail}
+				i.RecoveryAddresses = append(i.RecoveryAddresses, *address)
+
+				require.NoError(t, p.CreateIdentity(ctx, &i))
+
+				return code.NewRecoveryCode(&i.RecoveryAddresses[0], &f, expiresIn)
+			}
+
+			t.Run("case=should error when the flow has no code", func(t *testing.T) {
+				_, err := p.UseRecoveryCode(ctx, x.NewUUID(), "123456", 5)
+				require.ErrorIs(t, err, sqlcon.ErrNoRows)
+			})
+
+			t.Run("case=should create a recovery code and use it", func(t *testing.T) {
+				expected := newRecoveryCode(t, "code-user@ory.sh", time.Hour)
+				require.NoError(t, p.CreateRecoveryCode(ctx, expected))
+				assert.Len(t, expected.Code, code.RecoveryCodeLength)
+
+				t.Run("not work on another network", func(t *testing.T) {
+					_, p := testhelpers.NewNetwork(t, ctx, p)
+					_, err := p.UseRecoveryCode(ctx, expected.FlowID, expected.Code, 5)
+					require.ErrorIs(t, err, sqlcon.ErrNoRows)
+				})
+
+				actual, err := p.UseRecoveryCode(ctx, expected.FlowID, expected.Code, 5)
+				require.NoError(t, err)
+				assertx.EqualAsJSON(t, expected.RecoveryAddress, actual.RecoveryAddress)
+				assert.Equal(t, nid, actual.NID)
+				assert.Equal(t, expected.ID, actual.ID)
+				assert.NotEqual(t, expected.Code, actual.Code, "the code must be stored as a HMAC")
+				assert.True(t, actual.Used)
+
+				_, err = p.UseRecoveryCode(ctx, expected.FlowID, expected.Code, 5)
+				require.ErrorIs(t, err, sqlcon.ErrNoRows)
+			})
+
+			t.Run("case=should count failed attempts and invalidate the code", func(t *testing.T) {
+				expected := newRecoveryCode(t, "code-attempts-user@ory.sh", time.Hour)
+				require.NoError(t, p.CreateRecoveryCode(ctx, expected))
+
+				for k := 1; k <= 3; k++ {
+					actual, err := p.UseRecoveryCode(ctx, expected.FlowID, "not-the-code", 3)
+					require.ErrorIs(t, err, code.ErrInvalidRecoveryCode)
+					assert.Equal(t, k, actual.Attempts)
+					assert.Equal(t, k == 3, actual.Used)
+				}
+
+				_, err := p.UseRecoveryCode(ctx, expected.FlowID, expected.Code, 3)
+				require.ErrorIs(t, err, sqlcon.ErrNoRows)
+			})
+
+			t.Run("case=should invalidate previous codes of the flow", func(t *testing.T) {
+				first := newRecoveryCode(t, "code-resend-user@ory.sh", time.Hour)
+				require.NoError(t, p.CreateRecoveryCode(ctx, first))
+
+				second := code.NewRecoveryCode(first.RecoveryAddress, &recovery.Flow{ID: first.FlowID}, time.Hour)
+				second.Code = "not-" + first.Code
+				require.NoError(t, p.CreateRecoveryCode(ctx, second))
+
+				_, err := p.UseRecoveryCode(ctx, first.FlowID, first.Code, 5)
+				require.ErrorIs(t, err, code.ErrInvalidRecoveryCode)
+
+				actual, err := p.UseRecoveryCode(ctx, first.FlowID, second.Code, 5)
+				require.NoError(t, err)
+				assert.Equal(t, second.ID, actual.ID)
+			})
+
+			t.Run("case=should not use an expired code", func(t *testing.T) {
+				expected := newRecoveryCode(t, "code-expired-user@ory.sh", -time.Minute)
+				require.NoError(t, p.CreateRecoveryCode(ctx, expected))
+
+				_, err := p.UseRecoveryCode(ctx, expected.FlowID, expected.Code, 5)
+				require.ErrorIs(t, err, sqlcon.ErrNoRows)
+			})
+		})
+	}
+}
@@ -0,0 +1,9 @@
+package strategy
+
+import "context"
+
+// ConfigValidator is implemented by strategies which check their configuration when the service starts, so
+// that a misconfiguration fails fast instead of when the first user signs in.
+type ConfigValidator interface {
+	ValidateConfig(ctx context.Context) error
+}
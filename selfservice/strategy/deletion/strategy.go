@@ -0,0 +1,279 @@
+package deletion
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/decoderx"
+
+	"github.com/ory/kratos/continuity"
+	"github.com/ory/kratos/courier"
+	templates "github.com/ory/kratos/courier/template"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/event"
+	"github.com/ory/kratos/hash"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/selfservice/flow"
+	"github.com/ory/kratos/selfservice/flow/settings"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/text"
+	"github.com/ory/kratos/ui/node"
+	"github.com/ory/kratos/x"
+)
+
+var _ settings.Strategy = new(Strategy)
+
+type (
+	strategyDependencies interface {
+		x.CSRFProvider
+		x.CSRFTokenGeneratorProvider
+		x.LoggingProvider
+
+		config.Provider
+
+		continuity.ManagementProvider
+
+		courier.Provider
+		event.EmitterProvider
+
+		hash.HashProvider
+
+		identity.PrivilegedPoolProvider
+
+		settings.CurrentPasswordLockoutProvider
+
+		session.ManagementProvider
+		session.PersistenceProvider
+	}
+
+	// Strategy lets identities delete their own account in the settings flow. The identity is only
+	// marked as deleted and removed for good by `kratos janitor` once the retention window has passed.
+	Strategy struct {
+		d strategyDependencies
+	}
+)
+
+func NewStrategy(d strategyDependencies) *Strategy {
+	return &Strategy{d: d}
+}
+
+func (s *Strategy) SettingsStrategyID() string {
+	return settings.StrategyAccountDeletion
+}
+
+func (s *Strategy) NodeGroup() node.Group {
+	return node.AccountDeletionGroup
+}
+
+func (s *Strategy) RegisterSettingsRoutes(_ *x.RouterPublic) {}
+
+func (s *Strategy) PopulateSettingsMethod(r *http.Request, _ *identity.Identity, f *settings.Flow) error {
+	f.UI.SetCSRF(s.d.GenerateCSRFToken(r))
+	if s.d.Config(r.Context()).SelfServiceFlowSettingsRequiresCurrentPassword(s.SettingsStrategyID()) {
+		f.UI.Nodes.Upsert(settings.NewCurrentPasswordNode(node.AccountDeletionGroup))
+	}
+	f.UI.Nodes.Append(node.NewInputField("method", s.SettingsStrategyID(), node.AccountDeletionGroup, node.InputAttributeTypeSubmit).WithMetaLabel(text.NewInfoNodeLabelDeleteAccount()))
+
+	return nil
+}
+
+// nolint:deadcode,unused
+// swagger:parameters submitSelfServiceSettingsFlowWithAccountDeletionMethod
+type submitSelfServiceSettingsFlowWithAccountDeletionMethod struct {
+	// in: body
+	Body submitSelfServiceSettingsFlowWithAccountDeletionMethodBody
+
+	// Flow is flow ID.
+	//
+	// in: query
+	Flow string `json:"flow"`
+}
+
+// swagger:model submitSelfServiceSettingsFlowWithAccountDeletionMethod
+type submitSelfServiceSettingsFlowWithAccountDeletionMethodBody struct {
+	// CurrentPassword is the identity's current password
+	//
+	// Only required if the account_deletion method is listed in `selfservice.flows.settings.require_current_password`.
+	//
+	// type: string
+	CurrentPassword string `json:"current_password"`
+
+	// CSRFToken is the anti-CSRF token
+	//
+	// type: string
+	CSRFToken string `json:"csrf_token"`
+
+	// Method
+	//
+	// Should be set to account_deletion when trying to delete the account.
+	//
+	// type: string
+	// required: true
+	Method string `json:"method"`
+
+	// Flow is flow ID.
+	//
+	// swagger:ignore
+	Flow string `json:"flow"`
+}
+
+func (p *submitSelfServiceSettingsFlowWithAccountDeletionMethodBody) GetFlowID() uuid.UUID {
+	return x.ParseUUID(p.Flow)
+}
+
+func (p *submitSelfServiceSettingsFlowWithAccountDeletionMethodBody) SetFlowID(rid uuid.UUID) {
+	p.Flow = rid.String()
+}
+
+func (s *Strategy) Settings(w http.ResponseWriter, r *http.Request, f *settings.Flow, ss *session.Session) (*settings.UpdateContext, error) {
+	var p submitSelfServiceSettingsFlowWithAccountDeletionMethodBody
+	ctxUpdate, err := settings.PrepareUpdate(s.d, w, r, f, ss, settings.ContinuityKey(s.SettingsStrategyID()), &p)
+	if errors.Is(err, settings.ErrContinuePreviousAction) {
+		return ctxUpdate, s.continueSettingsFlow(w, r, ctxUpdate, &p)
+	} else if err != nil {
+		return ctxUpdate, s.handleSettingsError(w, r, ctxUpdate, &p, err)
+	}
+
+	if err := flow.MethodEnabledAndAllowedFromRequest(r, s.SettingsStrategyID(), s.d); err != nil {
+		return ctxUpdate, err
+	}
+
+	compiler, err := decoderx.HTTPRawJSONSchemaCompiler(settingsSchema)
+	if err != nil {
+		return ctxUpdate, s.handleSettingsError(w, r, ctxUpdate, &p, errors.WithStack(err))
+	}
+
+	if err := decoderx.NewHTTP().Decode(r, &p, compiler,
+		decoderx.HTTPDecoderSetValidatePayloads(true),
+		decoderx.HTTPDecoderJSONFollowsFormFormat(),
+	); err != nil {
+		return ctxUpdate, s.handleSettingsError(w, r, ctxUpdate, &p, err)
+	}
+
+	// This does not come from the payload!
+	p.Flow = ctxUpdate.Flow.ID.String()
+	if err := s.continueSettingsFlow(w, r, ctxUpdate, &p); err != nil {
+		return ctxUpdate, s.handleSettingsError(w, r, ctxUpdate, &p, err)
+	}
+
+	return ctxUpdate, nil
+}
+
+func (s *Strategy) continueSettingsFlow(
+	w http.ResponseWriter, r *http.Request,
+	ctxUpdate *settings.UpdateContext, p *submitSelfServiceSettingsFlowWithAccountDeletionMethodBody,
+) error {
+	ctx := r.Context()
+	if err := flow.MethodEnabledAndAllowed(ctx, s.SettingsStrategyID(), p.Method, s.d); err != nil {
+		return err
+	}
+
+	if err := flow.EnsureCSRF(r, ctxUpdate.Flow.Type, s.d.Config(ctx).DisableAPIFlowEnforcement(), s.d.GenerateCSRFToken, p.CSRFToken); err != nil {
+		return err
+	}
+
+	if ctxUpdate.Session.IsImpersonation() {
+		s.d.Audit().WithRequest(r).
+			WithField("identity_id", ctxUpdate.Session.IdentityID).
+			WithField("impersonated_by", ctxUpdate.Session.ImpersonatedBy).
+			Info("An administrator tried to delete an account while impersonating an identity.")
+		return errors.WithStack(herodot.ErrForbidden.WithReasonf("The account can not be deleted while impersonating an identity."))
+	}
+
+	// Sessions issued by the account recovery do not prove that the identity can still sign in.
+	if ctxUpdate.Session.Recovered ||
+		ctxUpdate.Session.AuthenticatedAt.Add(s.d.Config(ctx).SelfServiceFlowSettingsPrivilegedSessionMaxAge()).Before(time.Now()) {
+		return errors.WithStack(settings.NewFlowNeedsReAuth())
+	}
+
+	if err := settings.VerifyCurrentPassword(ctx, s.d, s.SettingsStrategyID(), ctxUpdate.Session.IdentityID, p.CurrentPassword); err != nil {
+		return err
+	}
+
+	if err := s.deleteAccount(w, r, ctxUpdate); err != nil {
+		return err
+	}
+
+	if ctxUpdate.Flow.Type == flow.TypeAPI {
+		w.WriteHeader(http.StatusNoContent)
+	} else {
+		http.Redirect(w, r, s.d.Config(ctx).SelfServiceBrowserDefaultReturnTo().String(), http.StatusFound)
+	}
+
+	return errors.WithStack(flow.ErrCompletedByStrategy)
+}
+
+// deleteAccount marks the identity as deleted, revokes all of its sessions, and notifies its verified
+// email addresses.
+func (s *Strategy) deleteAccount(w http.ResponseWriter, r *http.Request, ctxUpdate *settings.UpdateContext) error {
+	ctx := r.Context()
+	c := s.d.Config(ctx)
+
+	i, err := s.d.PrivilegedIdentityPool().GetIdentityConfidential(ctx, ctxUpdate.Session.IdentityID)
+	if err != nil {
+		return err
+	}
+
+	deletedAt := time.Now().UTC()
+	i.DeletedAt = &deletedAt
+	if err := s.d.PrivilegedIdentityPool().UpdateIdentity(ctx, i); err != nil {
+		return err
+	}
+
+	if err := s.d.SessionManager().PurgeFromRequest(ctx, w, r); err != nil {
+		return err
+	}
+
+	if _, err := s.d.SessionPersister().DeleteSessionsByIdentity(ctx, i.ID); err != nil {
+		return err
+	}
+
+	for _, address := range i.VerifiableAddresses {
+		if !address.Verified || address.Via != identity.VerifiableAddressTypeEmail {
+			continue
+		}
+
+		if _, err := s.d.Courier(ctx).QueueEmail(ctx, templates.NewAccountDeleted(c, &templates.AccountDeletedModel{
+			To:        address.Value,
+			DeletedAt: deletedAt,
+			PurgedAt:  deletedAt.Add(c.SelfServiceAccountDeletionRetention()),
+		})); err != nil {
+			return err
+		}
+	}
+
+	s.d.Audit().
+		WithRequest(r).
+		WithField("identity_id", i.ID).
+		Info("An identity deleted its account.")
+
+	s.d.EventEmitter().Emit(ctx, event.TypeIdentityDeleted, &event.Data{
+		IdentityID: i.ID,
+		SessionID:  &ctxUpdate.Session.ID,
+		FlowID:     &ctxUpdate.Flow.ID,
+		FlowType:   string(ctxUpdate.Flow.Type),
+		Method:     s.SettingsStrategyID(),
+	})
+
+	return nil
+}
+
+func (s *Strategy) handleSettingsError(w http.ResponseWriter, r *http.Request, ctxUpdate *settings.UpdateContext, p *submitSelfServiceSettingsFlowWithAccountDeletionMethodBody, err error) error {
+	// Do not pause flow if the flow type is an API flow as we can't save cookies in those flows.
+	if e := new(settings.FlowNeedsReAuth); errors.As(err, &e) && ctxUpdate.Flow != nil && ctxUpdate.Flow.Type == flow.TypeBrowser {
+		if err := s.d.ContinuityManager().Pause(r.Context(), w, r, settings.ContinuityKey(s.SettingsStrategyID()), settings.ContinuityOptions(p, ctxUpdate.GetSessionIdentity())...); err != nil {
+			return err
+		}
+	}
+
+	if ctxUpdate.Flow != nil {
+		ctxUpdate.Flow.UI.ResetMessages()
+		ctxUpdate.Flow.UI.SetCSRF(s.d.GenerateCSRFToken(r))
+	}
+
+	return err
+}
@@ -0,0 +1,167 @@
+package deletion_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/kratos/corpx"
+	"github.com/ory/kratos/courier"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/selfservice/flow/settings"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/x"
+)
+
+func init() {
+	corpx.RegisterFakes()
+}
+
+func newIdentity(email string) *identity.Identity {
+	return &identity.Identity{
+		ID: x.NewUUID(),
+		Credentials: map[identity.CredentialsType]identity.Credentials{
+			identity.CredentialsTypePassword: {
+				Type:        identity.CredentialsTypePassword,
+				Identifiers: []string{email},
+				Config:      []byte(`{"hashed_password":"foo"}`),
+			},
+		},
+		Traits:   identity.Traits(`{"email":"` + email + `"}`),
+		SchemaID: config.DefaultIdentityTraitsSchemaID,
+	}
+}
+
+func TestAccountDeletion(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/identity.schema.json")
+	conf.MustSet(config.ViperKeyCourierSMTPURL, "smtp://foo@bar@dev.null/")
+	conf.MustSet(config.ViperKeySelfServiceSettingsPrivilegedAuthenticationAfter, "5m")
+	testhelpers.StrategyEnable(t, conf, settings.StrategyProfile, true)
+	testhelpers.StrategyEnable(t, conf, settings.StrategyAccountDeletion, true)
+
+	_ = testhelpers.NewSettingsUIFlowEchoServer(t, reg)
+	_ = testhelpers.NewErrorTestServer(t, reg)
+	_ = testhelpers.NewLoginUIWith401Response(t, conf)
+	redirTS := testhelpers.NewRedirTS(t, "", conf)
+
+	publicTS, _ := testhelpers.NewKratosServer(t, reg)
+
+	var newClient = func(t *testing.T, isAPI bool, i *identity.Identity, authenticatedAt time.Time) (*http.Client, *session.Session) {
+		sess := session.NewActiveSession(i, testhelpers.NewSessionLifespanProvider(time.Hour), authenticatedAt)
+		if isAPI {
+			return testhelpers.NewHTTPClientWithSessionToken(t, reg, sess), sess
+		}
+		return testhelpers.NewHTTPClientWithSessionCookie(t, reg, sess), sess
+	}
+
+	var deleteAccount = func(v url.Values) {
+		v.Set("method", settings.StrategyAccountDeletion)
+	}
+
+	t.Run("description=should render the delete button", func(t *testing.T) {
+		hc, _ := newClient(t, true, newIdentity(x.NewUUID().String()+"@ory.sh"), time.Now())
+		f := testhelpers.InitializeSettingsFlowViaAPI(t, hc, publicTS)
+
+		values := testhelpers.SDKFormFieldsToURLValues(f.Ui.Nodes)
+		assert.Equal(t, settings.StrategyAccountDeletion, values.Get("method"))
+	})
+
+	for _, isAPI := range []bool{true, false} {
+		t.Run("type="+map[bool]string{true: "api", false: "browser"}[isAPI], func(t *testing.T) {
+			t.Run("description=should require a privileged session", func(t *testing.T) {
+				i := newIdentity(x.NewUUID().String() + "@ory.sh")
+				hc, _ := newClient(t, isAPI, i, time.Now().Add(-10*time.Minute))
+
+				actual := testhelpers.SubmitSettingsForm(t, isAPI, hc, publicTS, deleteAccount,
+					testhelpers.ExpectStatusCode(isAPI, http.StatusForbidden, http.StatusUnauthorized),
+					testhelpers.ExpectURL(isAPI, publicTS.URL+settings.RouteSubmitFlow, conf.SelfServiceFlowLoginUI().String()))
+				if isAPI {
+					assert.Equal(t, settings.NewFlowNeedsReAuth().ReasonField, gjson.Get(actual, "error.reason").String(), actual)
+				}
+
+				actualIdentity, err := reg.PrivilegedIdentityPool().GetIdentity(context.Background(), i.ID)
+				require.NoError(t, err)
+				assert.False(t, actualIdentity.IsDeleted())
+			})
+
+			t.Run("description=should delete the account and revoke all sessions", func(t *testing.T) {
+				email := x.NewUUID().String() + "@ory.sh"
+				i := newIdentity(email)
+				verified := identity.NewVerifiableEmailAddress(email, i.ID)
+				verified.Verified = true
+				i.VerifiableAddresses = []identity.VerifiableAddress{*verified}
+				hc, sess := newClient(t, isAPI, i, time.Now())
+				_, other := newClient(t, true, i, time.Now())
+
+				testhelpers.SubmitSettingsForm(t, isAPI, hc, publicTS, deleteAccount,
+					testhelpers.ExpectStatusCode(isAPI, http.StatusNoContent, http.StatusNoContent),
+					testhelpers.ExpectURL(isAPI, publicTS.URL+settings.RouteSubmitFlow, redirTS.URL))
+
+				actualIdentity, err := reg.PrivilegedIdentityPool().GetIdentity(context.Background(), i.ID)
+				require.NoError(t, err)
+				assert.True(t, actualIdentity.IsDeleted())
+
+				for _, s := range []*session.Session{sess, other} {
+					_, err := reg.SessionPersister().GetSession(context.Background(), s.ID)
+					assert.Error(t, err)
+				}
+
+				messages, err := reg.CourierPersister().NextMessages(context.Background(), 10)
+				require.NoError(t, err)
+				require.Len(t, messages, 1)
+				assert.Equal(t, email, messages[0].Recipient)
+				assert.Equal(t, courier.TypeAccountDeleted, messages[0].TemplateType)
+			})
+		})
+	}
+
+	t.Run("description=should require the current password if configured", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceSettingsRequireCurrentPassword, []string{settings.StrategyAccountDeletion})
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceSettingsRequireCurrentPassword, []string{})
+		})
+
+		i := newIdentity(x.NewUUID().String() + "@ory.sh")
+		hc, _ := newClient(t, true, i, time.Now())
+
+		actual := testhelpers.SubmitSettingsForm(t, true, hc, publicTS, func(v url.Values) {
+			deleteAccount(v)
+			v.Set("current_password", "not-the-password")
+		}, http.StatusBadRequest, publicTS.URL+settings.RouteSubmitFlow)
+		assert.NotEmpty(t, gjson.Get(actual, "ui.nodes.#(attributes.name==current_password).messages.0.text").String(), actual)
+
+		actualIdentity, err := reg.PrivilegedIdentityPool().GetIdentity(context.Background(), i.ID)
+		require.NoError(t, err)
+		assert.False(t, actualIdentity.IsDeleted())
+	})
+
+	t.Run("description=should not delete the account if the method is disabled", func(t *testing.T) {
+		testhelpers.StrategyEnable(t, conf, settings.StrategyAccountDeletion, false)
+		t.Cleanup(func() {
+			testhelpers.StrategyEnable(t, conf, settings.StrategyAccountDeletion, true)
+		})
+
+		i := newIdentity(x.NewUUID().String() + "@ory.sh")
+		hc, _ := newClient(t, true, i, time.Now())
+		f := testhelpers.InitializeSettingsFlowViaAPI(t, hc, publicTS)
+
+		values := testhelpers.SDKFormFieldsToURLValues(f.Ui.Nodes)
+		deleteAccount(values)
+		_, res := testhelpers.SettingsMakeRequest(t, true, f, hc, testhelpers.EncodeFormAsJSON(t, true, values))
+		assert.NotEqual(t, http.StatusNoContent, res.StatusCode)
+
+		actualIdentity, err := reg.PrivilegedIdentityPool().GetIdentity(context.Background(), i.ID)
+		require.NoError(t, err)
+		assert.False(t, actualIdentity.IsDeleted())
+	})
+}
@@ -8,6 +8,7 @@ type (
 	RecoveryTokenPersister interface {
 		CreateRecoveryToken(ctx context.Context, token *RecoveryToken) error
 		UseRecoveryToken(ctx context.Context, token string) (*RecoveryToken, error)
+		GetRecoveryToken(ctx context.Context, token string) (*RecoveryToken, error)
 		DeleteRecoveryToken(ctx context.Context, token string) error
 	}
 
@@ -18,6 +19,7 @@ type (
 	VerificationTokenPersister interface {
 		CreateVerificationToken(ctx context.Context, token *VerificationToken) error
 		UseVerificationToken(ctx context.Context, token string) (*VerificationToken, error)
+		GetVerificationToken(ctx context.Context, token string) (*VerificationToken, error)
 		DeleteVerificationToken(ctx context.Context, token string) error
 	}
 
@@ -9,6 +9,7 @@ import (
 
 	"github.com/ory/x/errorsx"
 	"github.com/ory/x/sqlcon"
+	"github.com/ory/x/sqlxx"
 	"github.com/ory/x/urlx"
 
 	"github.com/ory/kratos/courier"
@@ -24,6 +25,7 @@ type (
 	senderDependencies interface {
 		courier.Provider
 		identity.PoolProvider
+		identity.PrivilegedPoolProvider
 		identity.ManagementProvider
 		x.LoggingProvider
 		config.Provider
@@ -64,7 +66,7 @@ func (s *Sender) SendRecoveryLink(ctx context.Context, r *http.Request, f *recov
 		return errors.Cause(ErrUnknownAddress)
 	}
 
-	token := NewSelfServiceRecoveryToken(address, f)
+	token := NewSelfServiceRecoveryToken(address, f, s.r.Config(ctx).SelfServiceLinkMethodLifespan())
 	if err := s.r.RecoveryTokenPersister().CreateRecoveryToken(ctx, token); err != nil {
 		return err
 	}
@@ -100,6 +102,15 @@ func (s *Sender) SendVerificationLink(ctx context.Context, f *verification.Flow,
 		return err
 	}
 
+	if address.Verified && s.r.Config(ctx).SelfServiceFlowVerificationReverification() == "reset" {
+		address.Verified = false
+		address.VerifiedAt = sqlxx.NullTime{}
+		address.Status = identity.VerifiableAddressStatusPending
+		if err := s.r.PrivilegedIdentityPool().UpdateVerifiableAddress(ctx, address); err != nil {
+			return err
+		}
+	}
+
 	token := NewSelfServiceVerificationToken(address, f)
 	if err := s.r.VerificationTokenPersister().CreateVerificationToken(ctx, token); err != nil {
 		return err
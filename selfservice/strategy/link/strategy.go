@@ -3,12 +3,15 @@ package link
 import (
 	"github.com/ory/kratos/courier"
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/event"
+	"github.com/ory/kratos/hash"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/schema"
 	"github.com/ory/kratos/selfservice/errorx"
 	"github.com/ory/kratos/selfservice/flow/recovery"
 	"github.com/ory/kratos/selfservice/flow/settings"
 	"github.com/ory/kratos/selfservice/flow/verification"
+	"github.com/ory/kratos/selfservice/strategy/password"
 	"github.com/ory/kratos/session"
 	"github.com/ory/kratos/ui/container"
 	"github.com/ory/kratos/ui/node"
@@ -49,6 +52,7 @@ type (
 		identity.PrivilegedPoolProvider
 
 		courier.Provider
+		event.EmitterProvider
 
 		errorx.ManagementProvider
 
@@ -65,16 +69,21 @@ type (
 		SenderProvider
 
 		schema.IdentityTraitsProvider
+
+		hash.HashProvider
+		password.ValidationProvider
 	}
 
 	Strategy struct {
 		d  strategyDependencies
 		dx *decoderx.HTTP
+
+		tokenValidationLimiter *x.RateLimiter
 	}
 )
 
 func NewStrategy(d strategyDependencies) *Strategy {
-	return &Strategy{d: d, dx: decoderx.NewHTTP()}
+	return &Strategy{d: d, dx: decoderx.NewHTTP(), tokenValidationLimiter: x.NewRateLimiter()}
 }
 
 func (s *Strategy) RecoveryNodeGroup() node.Group {
@@ -1,6 +1,7 @@
 package link
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/url"
 	"time"
@@ -10,16 +11,20 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/ory/herodot"
+	"github.com/ory/kratos/event"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/schema"
 	"github.com/ory/kratos/selfservice/flow"
 	"github.com/ory/kratos/selfservice/flow/recovery"
 	"github.com/ory/kratos/selfservice/strategy"
+	"github.com/ory/kratos/selfservice/strategy/password"
 	"github.com/ory/kratos/session"
 	"github.com/ory/kratos/text"
 	"github.com/ory/kratos/ui/node"
 	"github.com/ory/kratos/x"
+	"github.com/ory/nosurf"
 	"github.com/ory/x/decoderx"
+	"github.com/ory/x/errorsx"
 	"github.com/ory/x/sqlcon"
 	"github.com/ory/x/sqlxx"
 	"github.com/ory/x/urlx"
@@ -34,6 +39,7 @@ func (s *Strategy) RecoveryStrategyID() string {
 }
 
 func (s *Strategy) RegisterPublicRecoveryRoutes(public *x.RouterPublic) {
+	public.GET(RouteRecoveryTokenValidation, strategy.IsRecoveryDisabled(s.d, s.RecoveryStrategyID(), s.validateRecoveryToken))
 }
 
 func (s *Strategy) RegisterAdminRecoveryRoutes(admin *x.RouterAdmin) {
@@ -160,6 +166,11 @@ func (s *Strategy) createRecoveryLink(w http.ResponseWriter, r *http.Request, _
 		return
 	}
 
+	if id.IsDeleted() {
+		s.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf("The identity deleted its account and can not be recovered.")))
+		return
+	}
+
 	if len(id.RecoveryAddresses) == 0 {
 		s.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf("The identity does not have any recovery addresses set.")))
 		return
@@ -231,6 +242,13 @@ type submitSelfServiceRecoveryFlowWithLinkMethod struct {
 
 	// Sending the anti-csrf token is only required for browser login flows.
 	CSRFToken string `form:"csrf_token" json:"csrf_token"`
+
+	// Resend the Recovery Email
+	//
+	// Set to `link` instead of `method` to send the recovery email again. The email is only sent again once
+	// `selfservice.flows.recovery.resend.cooldown` has passed and at most
+	// `selfservice.flows.recovery.resend.max_resends` times per flow.
+	Resend string `json:"resend" form:"resend"`
 }
 
 // swagger:route POST /self-service/recovery/methods/link public submitSelfServiceRecoveryFlowWithLinkMethod
@@ -245,12 +263,16 @@ type submitSelfServiceRecoveryFlowWithLinkMethod struct {
 //	 - For API clients it either returns a HTTP 200 OK when the form is valid and HTTP 400 OK when the form is invalid
 //     and a HTTP 302 Found redirect with a fresh recovery flow if the flow was otherwise invalid (e.g. expired).
 //	 - For Browser clients it returns a HTTP 302 Found redirect to the Recovery UI URL with the Recovery Flow ID appended.
-// - `sent_email` is the success state after `choose_method` and allows the user to request another recovery email. It
-//   works for both API and Browser-initiated flows and returns the same responses as the flow in `choose_method` state.
+// - `sent_email` is the success state after `choose_method` and allows the user to request another recovery email by
+//   sending `resend`. It works for both API and Browser-initiated flows and returns the same responses as the flow in
+//   `choose_method` state.
 // - `passed_challenge` expects a `token` to be sent in the URL query and given the nature of the flow ("sending a recovery link")
 //   does not have any API capabilities. The server responds with a HTTP 302 Found redirect either to the Settings UI URL
 //   (if the link was valid) and instructs the user to update their password, or a redirect to the Recover UI URL with
 //   a new Recovery Flow ID which contains an error message that the recovery link was invalid.
+// - `set_password` is reached instead of `passed_challenge` if `selfservice.flows.recovery.mode` is `set_password`. It
+//   expects `password` and `csrf_token` to be sent in the body, sets the new password, signs the user in, and redirects
+//   to `selfservice.flows.recovery.after.default_browser_return_url`.
 //
 // More information can be found at [ORY Kratos Account Recovery Documentation](../self-service/flows/account-recovery.mdx).
 //
@@ -281,6 +303,15 @@ func (s *Strategy) Recover(w http.ResponseWriter, r *http.Request, f *recovery.F
 		return s.recoveryUseToken(w, r, body)
 	}
 
+	if err := f.Valid(); err != nil {
+		return s.handleRecoveryError(w, r, f, body, err)
+	}
+
+	// The resend button is submitted instead of the method.
+	if len(body.Method) == 0 {
+		body.Method = body.Resend
+	}
+
 	if err := flow.MethodEnabledAndAllowed(r.Context(), s.RecoveryStrategyID(), body.Method, s.d); err != nil {
 		return s.handleRecoveryError(w, r, nil, body, err)
 	}
@@ -299,6 +330,8 @@ func (s *Strategy) Recover(w http.ResponseWriter, r *http.Request, f *recovery.F
 		fallthrough
 	case recovery.StateEmailSent:
 		return s.recoveryHandleFormSubmission(w, r, req)
+	case recovery.StateSetPassword:
+		return s.recoverySetPassword(w, r, req, body)
 	case recovery.StatePassedChallenge:
 		// was already handled, do not allow retry
 		return s.retryRecoveryFlowWithMessage(w, r, req.Type, text.NewErrorValidationRecoveryRetrySuccess())
@@ -313,6 +346,15 @@ func (s *Strategy) recoveryIssueSession(w http.ResponseWriter, r *http.Request,
 		return s.handleRecoveryError(w, r, f, nil, err)
 	}
 
+	// The link might have been sent before the identity deleted its account.
+	if recovered.IsDeleted() {
+		return s.retryRecoveryFlowWithMessage(w, r, flow.TypeBrowser, text.NewErrorValidationRecoveryTokenInvalidOrAlreadyUsed())
+	}
+
+	if s.d.Config(r.Context()).SelfServiceFlowRecoveryMode() == "set_password" {
+		return s.recoveryAskForPassword(w, r, f, recoveredID)
+	}
+
 	f.UI.Messages.Clear()
 	f.State = recovery.StatePassedChallenge
 	f.RecoveredIdentityID = uuid.NullUUID{
@@ -324,10 +366,16 @@ func (s *Strategy) recoveryIssueSession(w http.ResponseWriter, r *http.Request,
 	}
 
 	sess := session.NewActiveSession(recovered, s.d.Config(r.Context()), time.Now().UTC())
+	sess.Recovered = true
 	if err := s.d.SessionManager().CreateAndIssueCookie(r.Context(), w, r, sess); err != nil {
 		return s.handleRecoveryError(w, r, f, nil, err)
 	}
 
+	if err := s.d.IdentityManager().RecordCredentialEvents(r.Context(), identity.NewCredentialEvent(recoveredID,
+		identity.CredentialEventRecovered, "", x.ClientIPString(r, s.d.Config(r.Context()).TrustedProxies()))); err != nil {
+		return s.handleRecoveryError(w, r, f, nil, err)
+	}
+
 	sf, err := s.d.SettingsHandler().NewFlow(w, r, sess.Identity, flow.TypeBrowser)
 	if err != nil {
 		return s.handleRecoveryError(w, r, f, nil, err)
@@ -342,6 +390,135 @@ func (s *Strategy) recoveryIssueSession(w http.ResponseWriter, r *http.Request,
 	return errors.WithStack(flow.ErrCompletedByStrategy)
 }
 
+// recoveryAskForPassword moves the flow to the set_password state in which the user chooses a new password. The
+// flow's anti-CSRF token is bound to the browser which used the recovery link, so that the browser which requested
+// the link can not set the password.
+func (s *Strategy) recoveryAskForPassword(w http.ResponseWriter, r *http.Request, f *recovery.Flow, recoveredID uuid.UUID) error {
+	f.State = recovery.StateSetPassword
+	f.RecoveredIdentityID = uuid.NullUUID{
+		UUID:  recoveredID,
+		Valid: true,
+	}
+	f.CSRFToken = s.d.GenerateCSRFToken(r)
+	f.UI.Messages.Set(text.NewRecoverySetPassword())
+	s.populateRecoveryPasswordNodes(r, f)
+
+	if err := s.d.RecoveryFlowPersister().UpdateRecoveryFlow(r.Context(), f); err != nil {
+		return s.handleRecoveryError(w, r, f, nil, err)
+	}
+
+	http.Redirect(w, r, f.AppendTo(s.d.Config(r.Context()).SelfServiceFlowRecoveryUI()).String(), http.StatusFound)
+	return errors.WithStack(flow.ErrCompletedByStrategy)
+}
+
+func (s *Strategy) populateRecoveryPasswordNodes(r *http.Request, f *recovery.Flow) {
+	f.UI.Nodes = node.Nodes{}
+	f.UI.SetCSRF(s.d.GenerateCSRFToken(r))
+	f.UI.GetNodes().Append(password.NewPasswordNode("password"))
+	f.UI.GetNodes().Append(node.NewInputField("method", s.RecoveryStrategyID(), node.RecoveryLinkGroup, node.InputAttributeTypeSubmit).WithMetaLabel(text.NewInfoNodeLabelSubmit()))
+}
+
+func (s *Strategy) recoverySetPassword(w http.ResponseWriter, r *http.Request, f *recovery.Flow, body *recoverySubmitPayload) error {
+	handleError := func(err error) error {
+		s.populateRecoveryPasswordNodes(r, f)
+		return err
+	}
+
+	if err := flow.EnsureCSRF(r, f.Type, s.d.Config(r.Context()).DisableAPIFlowEnforcement(), s.d.GenerateCSRFToken, body.CSRFToken); err != nil {
+		return handleError(err)
+	}
+
+	// Only the browser which used the recovery link may set the new password.
+	if !nosurf.VerifyToken(s.d.GenerateCSRFToken(r), f.CSRFToken) {
+		return handleError(errors.WithStack(x.ErrInvalidCSRFToken))
+	}
+
+	if !f.RecoveredIdentityID.Valid {
+		return s.retryRecoveryFlowWithMessage(w, r, f.Type, text.NewErrorValidationRecoveryStateFailure())
+	}
+
+	if len(body.Password) == 0 {
+		return handleError(schema.NewRequiredError("#/password", "password"))
+	}
+
+	i, err := s.d.PrivilegedIdentityPool().GetIdentityConfidential(r.Context(), f.RecoveredIdentityID.UUID)
+	if err != nil {
+		return handleError(err)
+	}
+
+	hpw, err := s.d.Hasher().Generate(r.Context(), []byte(body.Password))
+	if err != nil {
+		return handleError(err)
+	}
+
+	co, err := json.Marshal(&password.CredentialsConfig{HashedPassword: string(hpw)})
+	if err != nil {
+		return handleError(errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to encode password options to JSON: %s", err)))
+	}
+
+	c, ok := i.GetCredentials(identity.CredentialsTypePassword)
+	if !ok {
+		c = &identity.Credentials{Type: identity.CredentialsTypePassword,
+			// The identifiers are set by the identity validator.
+			Identifiers: []string{x.NewUUID().String()}}
+	}
+	c.Config = co
+	i.SetCredentials(identity.CredentialsTypePassword, *c)
+
+	if err := s.d.IdentityValidator().Validate(r.Context(), i); err != nil {
+		return handleError(err)
+	}
+
+	c, _ = i.GetCredentials(identity.CredentialsTypePassword)
+	for _, id := range password.ComparedIdentifiers(s.d.Config(r.Context()), i, c.Identifiers) {
+		if err := s.d.PasswordValidator().Validate(r.Context(), id, body.Password); err != nil {
+			if _, ok := errorsx.Cause(err).(*herodot.DefaultError); ok {
+				return handleError(err)
+			}
+			return handleError(schema.NewPasswordPolicyViolationError("#/password", err.Error()))
+		}
+	}
+
+	if err := s.d.PrivilegedIdentityPool().UpdateIdentity(r.Context(), i); err != nil {
+		return handleError(err)
+	}
+
+	passwordEvent := identity.CredentialEventUpdated
+	if !ok {
+		passwordEvent = identity.CredentialEventAdded
+	}
+	ip := x.ClientIPString(r, s.d.Config(r.Context()).TrustedProxies())
+	if err := s.d.IdentityManager().RecordCredentialEvents(r.Context(),
+		identity.NewCredentialEvent(i.ID, identity.CredentialEventRecovered, "", ip),
+		identity.NewCredentialEvent(i.ID, passwordEvent, identity.CredentialsTypePassword, ip),
+	); err != nil {
+		return handleError(err)
+	}
+
+	f.State = recovery.StatePassedChallenge
+	f.UI.Nodes = node.Nodes{}
+	f.UI.Messages.Clear()
+	if err := s.d.RecoveryFlowPersister().UpdateRecoveryFlow(r.Context(), f); err != nil {
+		return handleError(err)
+	}
+
+	sess := session.NewActiveSession(i, s.d.Config(r.Context()), time.Now().UTC())
+	sess.Recovered = true
+	if err := s.d.SessionManager().CreateAndIssueCookie(r.Context(), w, r, sess); err != nil {
+		return handleError(err)
+	}
+
+	s.d.Audit().
+		WithField("identity_id", i.ID).
+		WithField("recovery_flow_id", f.ID).
+		Info("The password was changed using the recovery flow.")
+	s.d.EventEmitter().Emit(r.Context(), event.TypeRecoverySucceeded, &event.Data{
+		IdentityID: i.ID, SessionID: &sess.ID, FlowID: &f.ID, FlowType: string(f.Type), Method: s.RecoveryStrategyID()})
+
+	http.Redirect(w, r, s.d.Config(r.Context()).SelfServiceFlowRecoveryReturnTo().String(), http.StatusFound)
+	return errors.WithStack(flow.ErrCompletedByStrategy)
+}
+
 func (s *Strategy) recoveryUseToken(w http.ResponseWriter, r *http.Request, body *recoverySubmitPayload) error {
 	token, err := s.d.RecoveryTokenPersister().UseRecoveryToken(r.Context(), body.Token)
 	if err != nil {
@@ -353,19 +530,23 @@ func (s *Strategy) recoveryUseToken(w http.ResponseWriter, r *http.Request, body
 	}
 
 	var f *recovery.Flow
-	if !token.FlowID.Valid {
-		f, err = recovery.NewFlow(s.d.Config(r.Context()), time.Until(token.ExpiresAt), s.d.GenerateCSRFToken(r),
-			r, s.d.RecoveryStrategies(r.Context()), flow.TypeBrowser)
+	if token.FlowID.Valid {
+		f, err = s.d.RecoveryFlowPersister().GetRecoveryFlow(r.Context(), token.FlowID.UUID)
 		if err != nil {
 			return s.handleRecoveryError(w, r, nil, body, err)
 		}
+	}
 
-		if err := s.d.RecoveryFlowPersister().CreateRecoveryFlow(r.Context(), f); err != nil {
+	// The recovery link may outlive the flow it was requested from. In that case, or if the
+	// token was issued without a flow, recovery continues in a new flow.
+	if f == nil || (f.Valid() != nil && token.Valid() == nil) {
+		f, err = recovery.NewFlow(s.d.Config(r.Context()), s.d.Config(r.Context()).SelfServiceFlowRecoveryRequestLifespan(), s.d.GenerateCSRFToken(r),
+			r, s.d.RecoveryStrategies(r.Context()), flow.TypeBrowser)
+		if err != nil {
 			return s.handleRecoveryError(w, r, nil, body, err)
 		}
-	} else {
-		f, err = s.d.RecoveryFlowPersister().GetRecoveryFlow(r.Context(), token.FlowID.UUID)
-		if err != nil {
+
+		if err := s.d.RecoveryFlowPersister().CreateRecoveryFlow(r.Context(), f); err != nil {
 			return s.handleRecoveryError(w, r, nil, body, err)
 		}
 	}
@@ -414,6 +595,10 @@ func (s *Strategy) recoveryHandleFormSubmission(w http.ResponseWriter, r *http.R
 		return s.handleRecoveryError(w, r, req, body, err)
 	}
 
+	if err := req.EnsureCanSend(s.d.Config(r.Context())); err != nil {
+		return s.handleRecoveryError(w, r, req, body, err)
+	}
+
 	if err := s.d.LinkSender().SendRecoveryLink(r.Context(), r, req, identity.VerifiableAddressTypeEmail, body.Email); err != nil {
 		if !errors.Is(err, ErrUnknownAddress) {
 			return s.handleRecoveryError(w, r, req, body, err)
@@ -427,7 +612,9 @@ func (s *Strategy) recoveryHandleFormSubmission(w http.ResponseWriter, r *http.R
 		// v0.5: form.Field{Name: "email", Type: "email", Required: true, Value: body.Body.Email}
 		node.NewInputField("email", body.Email, node.RecoveryLinkGroup, node.InputAttributeTypeEmail, node.WithRequiredInputAttribute),
 	)
+	s.populateRecoveryResend(req)
 
+	req.MarkSent()
 	req.Active = sqlxx.NullString(s.RecoveryNodeGroup())
 	req.State = recovery.StateEmailSent
 	req.UI.Messages.Set(text.NewRecoveryEmailSent())
@@ -446,17 +633,28 @@ func (s *Strategy) handleRecoveryError(w http.ResponseWriter, r *http.Request, r
 			// v0.5: form.Field{Name: "email", Type: "email", Required: true, Value: body.Body.Email}
 			node.NewInputField("email", body.Email, node.RecoveryLinkGroup, node.InputAttributeTypeEmail, node.WithRequiredInputAttribute),
 		)
+		if req.State == recovery.StateEmailSent {
+			s.populateRecoveryResend(req)
+		}
 	}
 
 	return err
 }
 
+// populateRecoveryResend adds the button which sends the recovery email to the address in the email field again.
+func (s *Strategy) populateRecoveryResend(f *recovery.Flow) {
+	f.UI.GetNodes().Upsert(node.NewInputField("resend", s.RecoveryStrategyID(), node.RecoveryLinkGroup, node.InputAttributeTypeSubmit).
+		WithMetaLabel(text.NewInfoNodeLabelResend()))
+}
+
 type recoverySubmitPayload struct {
 	Method    string `json:"method" form:"method"`
+	Password  string `json:"password" form:"password"`
 	Token     string `json:"token" form:"token"`
 	CSRFToken string `json:"csrf_token" form:"csrf_token"`
 	Flow      string `json:"flow" form:"flow"`
 	Email     string `json:"email" form:"email"`
+	Resend    string `json:"resend" form:"resend"`
 }
 
 func (s *Strategy) decodeRecovery(r *http.Request) (*recoverySubmitPayload, error) {
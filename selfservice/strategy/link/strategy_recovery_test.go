@@ -2,8 +2,11 @@ package link_test
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
 	"time"
@@ -27,12 +30,15 @@ import (
 	"github.com/ory/x/assertx"
 
 	"github.com/ory/x/pointerx"
+	"github.com/ory/x/randx"
 
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/hash"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/internal"
 	"github.com/ory/kratos/internal/testhelpers"
 	"github.com/ory/kratos/selfservice/flow/recovery"
+	"github.com/ory/kratos/session"
 	"github.com/ory/kratos/text"
 	"github.com/ory/kratos/x"
 )
@@ -310,6 +316,116 @@ func TestRecovery(t *testing.T) {
 		})
 	})
 
+	t.Run("description=should record the recovery in the credential history", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyIdentityCredentialHistoryEnabled, true)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyIdentityCredentialHistoryEnabled, false)
+		})
+
+		before, err := reg.CredentialEventPersister().CountCredentialEvents(context.Background(), identityToRecover.ID)
+		require.NoError(t, err)
+
+		expectSuccess(t, false, func(v url.Values) {
+			v.Set("email", recoveryEmail)
+		})
+		message := testhelpers.CourierExpectMessage(t, reg, recoveryEmail, "Recover access to your account")
+
+		res, err := testhelpers.NewClientWithCookies(t).Get(testhelpers.CourierExpectLinkInMessage(t, message, 1))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		assert.Contains(t, res.Request.URL.String(), conf.SelfServiceFlowSettingsUI().String())
+
+		events, err := reg.CredentialEventPersister().ListCredentialEvents(context.Background(), identityToRecover.ID, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, events, int(before)+1)
+		assert.Equal(t, identity.CredentialEventRecovered, events[0].Event)
+	})
+
+	t.Run("description=should resend the recovery link", func(t *testing.T) {
+		var send = func(t *testing.T, c *http.Client, f *kratos.RecoveryFlow, values url.Values) string {
+			values.Set("csrf_token", x.FakeCSRFToken)
+			values.Set("email", recoveryEmail)
+			res, err := c.PostForm(f.Ui.Action, values)
+			require.NoError(t, err)
+			defer res.Body.Close()
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			return string(ioutilx.MustReadAll(res.Body))
+		}
+
+		var expectLink = func(t *testing.T) string {
+			return testhelpers.CourierExpectLinkInMessage(t, testhelpers.CourierExpectMessage(t, reg, recoveryEmail, "Recover access to your account"), 1)
+		}
+
+		t.Run("case=only the last link is valid", func(t *testing.T) {
+			conf.MustSet(config.ViperKeySelfServiceRecoveryResendCooldown, "0s")
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeySelfServiceRecoveryResendCooldown, "30s")
+			})
+
+			c := testhelpers.NewClientWithCookies(t)
+			f := testhelpers.InitializeRecoveryFlowViaBrowser(t, c, public)
+
+			body := send(t, c, f, url.Values{"method": {"link"}})
+			assert.EqualValues(t, "link", gjson.Get(body, "ui.nodes.#(attributes.name==resend).attributes.value").String(), "%s", body)
+			first := expectLink(t)
+
+			body = send(t, c, f, url.Values{"resend": {"link"}})
+			assertx.EqualAsJSON(t, text.NewRecoveryEmailSent(), json.RawMessage(gjson.Get(body, "ui.messages.0").Raw))
+			second := expectLink(t)
+
+			res, err := testhelpers.NewClientWithCookies(t).Get(first)
+			require.NoError(t, err)
+			assert.Contains(t, res.Request.URL.String(), conf.SelfServiceFlowRecoveryUI().String())
+			assert.Equal(t, text.NewErrorValidationRecoveryTokenInvalidOrAlreadyUsed().Text,
+				gjson.GetBytes(ioutilx.MustReadAll(res.Body), "ui.messages.0.text").String())
+
+			res, err = testhelpers.NewClientWithCookies(t).Get(second)
+			require.NoError(t, err)
+			assert.Contains(t, res.Request.URL.String(), conf.SelfServiceFlowSettingsUI().String())
+		})
+
+		t.Run("case=enforces the cooldown", func(t *testing.T) {
+			conf.MustSet(config.ViperKeySelfServiceRecoveryResendCooldown, "1m")
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeySelfServiceRecoveryResendCooldown, "30s")
+			})
+
+			c := testhelpers.NewClientWithCookies(t)
+			f := testhelpers.InitializeRecoveryFlowViaBrowser(t, c, public)
+
+			send(t, c, f, url.Values{"method": {"link"}})
+			first := expectLink(t)
+
+			body := send(t, c, f, url.Values{"resend": {"link"}})
+			assert.True(t, gjson.Get(body, fmt.Sprintf("ui.messages.#(id==%d)", text.ErrorValidationRecoveryResendCooldown)).Exists(), "%s", body)
+			assert.EqualValues(t, "link", gjson.Get(body, "ui.nodes.#(attributes.name==resend).attributes.value").String(), "%s", body)
+
+			res, err := testhelpers.NewClientWithCookies(t).Get(first)
+			require.NoError(t, err)
+			assert.Contains(t, res.Request.URL.String(), conf.SelfServiceFlowSettingsUI().String(), "the link must still be valid")
+		})
+
+		t.Run("case=limits the number of resends", func(t *testing.T) {
+			conf.MustSet(config.ViperKeySelfServiceRecoveryResendCooldown, "0s")
+			conf.MustSet(config.ViperKeySelfServiceRecoveryMaxResends, 1)
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeySelfServiceRecoveryResendCooldown, "30s")
+				conf.MustSet(config.ViperKeySelfServiceRecoveryMaxResends, 5)
+			})
+
+			c := testhelpers.NewClientWithCookies(t)
+			f := testhelpers.InitializeRecoveryFlowViaBrowser(t, c, public)
+
+			send(t, c, f, url.Values{"method": {"link"}})
+			expectLink(t)
+			send(t, c, f, url.Values{"resend": {"link"}})
+			expectLink(t)
+
+			body := send(t, c, f, url.Values{"resend": {"link"}})
+			assert.True(t, gjson.Get(body, fmt.Sprintf("ui.messages.#(id==%d)", text.ErrorValidationRecoveryResendLimit)).Exists(), "%s", body)
+		})
+	})
+
 	t.Run("description=should not be able to use an invalid link", func(t *testing.T) {
 		c := testhelpers.NewClientWithCookies(t)
 		f := testhelpers.InitializeRecoveryFlowViaBrowser(t, c, public)
@@ -343,10 +459,89 @@ func TestRecovery(t *testing.T) {
 		assert.Contains(t, res.Request.URL.String(), conf.SelfServiceFlowRecoveryUI().String())
 	})
 
+	t.Run("description=should recover an account after the flow expired if the link is still valid", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceRecoveryRequestLifespan, time.Millisecond*200)
+		conf.MustSet(config.ViperKeyLinkLifespan, time.Minute)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceRecoveryRequestLifespan, time.Minute)
+			conf.MustSet(config.ViperKeyLinkLifespan, time.Hour)
+		})
+
+		body := expectSuccess(t, false, func(v url.Values) {
+			v.Set("email", recoveryEmail)
+		})
+
+		message := testhelpers.CourierExpectMessage(t, reg, recoveryEmail, "Recover access to your account")
+		recoveryLink := testhelpers.CourierExpectLinkInMessage(t, message, 1)
+
+		time.Sleep(time.Millisecond * 201)
+
+		c := testhelpers.NewClientWithCookies(t)
+		res, err := c.Get(recoveryLink)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Contains(t, res.Request.URL.String(), conf.SelfServiceFlowSettingsUI().String())
+		assert.NotContains(t, res.Request.URL.String(), gjson.Get(body, "id").String())
+	})
+
+	t.Run("description=should treat a link token sent in the body like one sent in the query", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceRecoveryRequestLifespan, time.Millisecond*200)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceRecoveryRequestLifespan, time.Minute)
+			conf.MustSet(config.ViperKeyLinkLifespan, time.Hour)
+		})
+
+		submitInBody := func(t *testing.T) (*http.Client, *http.Response) {
+			expectSuccess(t, false, func(v url.Values) {
+				v.Set("email", recoveryEmail)
+			})
+
+			message := testhelpers.CourierExpectMessage(t, reg, recoveryEmail, "Recover access to your account")
+			recoveryLink, err := url.Parse(testhelpers.CourierExpectLinkInMessage(t, message, 1))
+			require.NoError(t, err)
+
+			time.Sleep(time.Millisecond * 201)
+
+			token := recoveryLink.Query().Get("token")
+			q := recoveryLink.Query()
+			q.Del("token")
+			recoveryLink.RawQuery = q.Encode()
+
+			c := testhelpers.NewClientWithCookies(t)
+			res, err := c.PostForm(recoveryLink.String(), url.Values{"token": {token}})
+			require.NoError(t, err)
+			return c, res
+		}
+
+		t.Run("case=valid link after the flow expired", func(t *testing.T) {
+			conf.MustSet(config.ViperKeyLinkLifespan, time.Minute)
+
+			_, res := submitInBody(t)
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Contains(t, res.Request.URL.String(), conf.SelfServiceFlowSettingsUI().String())
+		})
+
+		t.Run("case=expired link", func(t *testing.T) {
+			conf.MustSet(config.ViperKeyLinkLifespan, time.Millisecond*200)
+
+			c, res := submitInBody(t)
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Contains(t, res.Request.URL.String(), conf.SelfServiceFlowRecoveryUI().String())
+
+			rs, _, err := testhelpers.NewSDKCustomClient(public, c).PublicApi.GetSelfServiceRecoveryFlow(context.Background()).Id(res.Request.URL.Query().Get("flow")).Execute()
+			require.NoError(t, err)
+			require.Len(t, rs.Ui.Messages, 1)
+			assert.Contains(t, rs.Ui.Messages[0].Text, "The recovery flow expired")
+		})
+	})
+
 	t.Run("description=should not be able to use an outdated flow", func(t *testing.T) {
 		conf.MustSet(config.ViperKeySelfServiceRecoveryRequestLifespan, time.Millisecond*200)
+		conf.MustSet(config.ViperKeyLinkLifespan, time.Millisecond*200)
 		t.Cleanup(func() {
 			conf.MustSet(config.ViperKeySelfServiceRecoveryRequestLifespan, time.Minute)
+			conf.MustSet(config.ViperKeyLinkLifespan, time.Hour)
 		})
 
 		body := expectSuccess(t, false, func(v url.Values) {
@@ -374,6 +569,99 @@ func TestRecovery(t *testing.T) {
 		require.Len(t, rs.Ui.Messages, 1)
 		assert.Contains(t, rs.Ui.Messages[0].Text, "The recovery flow expired")
 	})
+
+	t.Run("description=should set the new password in the recovery flow", func(t *testing.T) {
+		returnTS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		t.Cleanup(returnTS.Close)
+
+		conf.MustSet(config.ViperKeySelfServiceRecoveryMode, "set_password")
+		conf.MustSet(config.ViperKeySelfServiceRecoveryBrowserDefaultReturnTo, returnTS.URL+"/return-ts")
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceRecoveryMode, "settings")
+			conf.MustSet(config.ViperKeySelfServiceRecoveryBrowserDefaultReturnTo, "")
+		})
+
+		var useLink = func(t *testing.T) (*http.Client, []byte) {
+			expectSuccess(t, false, func(v url.Values) {
+				v.Set("email", recoveryEmail)
+			})
+
+			message := testhelpers.CourierExpectMessage(t, reg, recoveryEmail, "Recover access to your account")
+			recoveryLink := testhelpers.CourierExpectLinkInMessage(t, message, 1)
+
+			c := testhelpers.NewClientWithCookies(t)
+			res, err := c.Get(recoveryLink)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Contains(t, res.Request.URL.String(), conf.SelfServiceFlowRecoveryUI().String())
+
+			body := ioutilx.MustReadAll(res.Body)
+			assert.EqualValues(t, recovery.StateSetPassword, gjson.GetBytes(body, "state").String(), "%s", body)
+			assert.True(t, gjson.GetBytes(body, "ui.nodes.#(attributes.name==password)").Exists(), "%s", body)
+			assert.EqualValues(t, text.NewRecoverySetPassword().ID, gjson.GetBytes(body, "ui.messages.0.id").Int(), "%s", body)
+			return c, body
+		}
+
+		var submit = func(t *testing.T, c *http.Client, body []byte, password string) *http.Response {
+			res, err := c.PostForm(gjson.GetBytes(body, "ui.action").String(), url.Values{
+				"method":     {"link"},
+				"csrf_token": {gjson.GetBytes(body, "ui.nodes.#(attributes.name==csrf_token).attributes.value").String()},
+				"password":   {password},
+			})
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = res.Body.Close() })
+			return res
+		}
+
+		t.Run("case=requires a password", func(t *testing.T) {
+			c, body := useLink(t)
+			res := submit(t, c, body, "")
+			assert.Contains(t, res.Request.URL.String(), conf.SelfServiceFlowRecoveryUI().String())
+
+			actual := ioutilx.MustReadAll(res.Body)
+			assert.EqualValues(t, recovery.StateSetPassword, gjson.GetBytes(actual, "state").String(), "%s", actual)
+			assert.Contains(t, gjson.GetBytes(actual, "ui.nodes.#(attributes.name==password).messages.0.text").String(), "missing", "%s", actual)
+		})
+
+		t.Run("case=sets the password and issues a session", func(t *testing.T) {
+			password := x.NewUUID().String()
+			c, body := useLink(t)
+			res := submit(t, c, body, password)
+			assert.Equal(t, http.StatusNoContent, res.StatusCode)
+			assert.Equal(t, returnTS.URL+"/return-ts", res.Request.URL.String())
+
+			i, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(context.Background(), identityToRecover.ID)
+			require.NoError(t, err)
+			creds, ok := i.GetCredentials(identity.CredentialsTypePassword)
+			require.True(t, ok)
+			require.NoError(t, hash.Compare(context.Background(), []byte(password), []byte(gjson.GetBytes(creds.Config, "hashed_password").String())))
+
+			res, err = c.Get(public.URL + session.RouteWhoami)
+			require.NoError(t, err)
+			defer res.Body.Close()
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+		})
+
+		t.Run("case=does not allow other browsers to set the password", func(t *testing.T) {
+			_, body := useLink(t)
+
+			// The fake anti-CSRF token generator returns the same token for all browsers.
+			reg.WithCSRFTokenGenerator(x.FakeCSRFTokenGeneratorWithToken(base64.StdEncoding.EncodeToString([]byte(randx.MustString(32, randx.AlphaLowerNum)))))
+			t.Cleanup(func() {
+				reg.WithCSRFTokenGenerator(x.FakeCSRFTokenGenerator)
+			})
+
+			res := submit(t, testhelpers.NewClientWithCookies(t), body, x.NewUUID().String())
+
+			actual := ioutilx.MustReadAll(res.Body)
+			assert.NotEqual(t, returnTS.URL+"/return-ts", res.Request.URL.String(), "%s", actual)
+			assert.EqualValues(t, x.ErrInvalidCSRFToken.ReasonField, gjson.GetBytes(actual, "0.reason").String(), "%s", actual)
+		})
+	})
 }
 
 func TestDisabledEndpoint(t *testing.T) {
@@ -429,3 +717,53 @@ func TestDisabledEndpoint(t *testing.T) {
 		})
 	})
 }
+
+func TestRecoveryOfDeletedIdentity(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	initViper(t, conf)
+
+	_ = testhelpers.NewRecoveryUIFlowEchoServer(t, reg)
+	_ = testhelpers.NewSettingsUIFlowEchoServer(t, reg)
+	_ = testhelpers.NewErrorTestServer(t, reg)
+
+	public, _ := testhelpers.NewKratosServer(t, reg)
+
+	email := x.NewUUID().String() + "@ory.sh"
+	i := &identity.Identity{Traits: identity.Traits(`{"email":"` + email + `"}`), SchemaID: config.DefaultIdentityTraitsSchemaID}
+	require.NoError(t, reg.IdentityManager().Create(context.Background(), i, identity.ManagerAllowWriteProtectedTraits))
+
+	request := func(t *testing.T) {
+		testhelpers.SubmitRecoveryForm(t, false, testhelpers.NewClientWithCookies(t), public, func(v url.Values) {
+			v.Set("email", email)
+		}, http.StatusOK, conf.SelfServiceFlowRecoveryUI().String())
+	}
+
+	request(t)
+	recoveryLink := testhelpers.CourierExpectLinkInMessage(t, testhelpers.CourierExpectMessage(t, reg, email, "Recover access to your account"), 1)
+
+	deletedAt := time.Now().UTC()
+	i.DeletedAt = &deletedAt
+	require.NoError(t, reg.PrivilegedIdentityPool().UpdateIdentity(context.Background(), i))
+
+	t.Run("case=does not accept links sent before the deletion", func(t *testing.T) {
+		c := testhelpers.NewClientWithCookies(t)
+		res, err := c.Get(recoveryLink)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		assert.Contains(t, res.Request.URL.String(), conf.SelfServiceFlowRecoveryUI().String())
+		body := ioutilx.MustReadAll(res.Body)
+		assert.EqualValues(t, text.NewErrorValidationRecoveryTokenInvalidOrAlreadyUsed().ID, gjson.GetBytes(body, "ui.messages.0.id").Int(), "%s", body)
+
+		res, err = c.Get(public.URL + session.RouteWhoami)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("case=does not send recovery links", func(t *testing.T) {
+		request(t)
+		message := testhelpers.CourierExpectMessage(t, reg, email, "Account access attempted")
+		assert.NotContains(t, message.Body, public.URL+recovery.RouteSubmitFlow)
+	})
+}
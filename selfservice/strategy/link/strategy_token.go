@@ -0,0 +1,143 @@
+package link
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/x"
+)
+
+const (
+	RouteRecoveryTokenValidation     = "/self-service/recovery/link/validate"
+	RouteVerificationTokenValidation = "/self-service/verification/link/validate"
+
+	TokenStateValid   TokenState = "valid"
+	TokenStateExpired TokenState = "expired"
+	TokenStateUsed    TokenState = "used"
+)
+
+// The state of a recovery or verification token
+//
+// swagger:model linkTokenState
+type TokenState string
+
+// The result of validating a recovery or verification token
+//
+// swagger:model linkTokenValidation
+type tokenValidation struct {
+	// State is either `valid`, `expired`, or `used`.
+	//
+	// required: true
+	State TokenState `json:"state"`
+
+	// ExpiresAt is the time (UTC) when the token expires.
+	//
+	// required: true
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// swagger:parameters validateSelfServiceRecoveryLinkToken validateSelfServiceVerificationLinkToken
+// nolint:deadcode,unused
+type validateLinkTokenParameters struct {
+	// The token to validate.
+	//
+	// required: true
+	// in: query
+	Token string `json:"token"`
+}
+
+// swagger:route GET /self-service/recovery/link/validate public validateSelfServiceRecoveryLinkToken
+//
+// Validate a Recovery Link Token
+//
+// This endpoint reports whether a recovery token is valid, expired, or already used without using it. Frontends can
+// use it to tell the user up front that a recovery link expired.
+//
+// Requests are rate limited per client IP as configured by `selfservice.methods.link.config.token_validation_rate_limit`.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: linkTokenValidation
+//       404: genericError
+//       429: genericError
+//       500: genericError
+func (s *Strategy) validateRecoveryToken(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	s.validateToken(w, r, func(token string) (time.Time, bool, error) {
+		t, err := s.d.RecoveryTokenPersister().GetRecoveryToken(r.Context(), token)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		return t.ExpiresAt, t.Used, nil
+	})
+}
+
+// swagger:route GET /self-service/verification/link/validate public validateSelfServiceVerificationLinkToken
+//
+// Validate a Verification Link Token
+//
+// This endpoint reports whether a verification token is valid, expired, or already used without using it.
+//
+// Requests are rate limited per client IP as configured by `selfservice.methods.link.config.token_validation_rate_limit`.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: linkTokenValidation
+//       404: genericError
+//       429: genericError
+//       500: genericError
+func (s *Strategy) validateVerificationToken(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	s.validateToken(w, r, func(token string) (time.Time, bool, error) {
+		t, err := s.d.VerificationTokenPersister().GetVerificationToken(r.Context(), token)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		return t.ExpiresAt, t.Used, nil
+	})
+}
+
+func (s *Strategy) validateToken(w http.ResponseWriter, r *http.Request, get func(token string) (expiresAt time.Time, used bool, err error)) {
+	c := s.d.Config(r.Context())
+	limit, period := c.SelfServiceLinkMethodTokenValidationRateLimit()
+	if rl, ok := s.tokenValidationLimiter.Allow(x.ClientIPString(r, c.TrustedProxies()), limit, period); !ok {
+		x.WriteRateLimitExceeded(w, r, s.d, rl, "Too many tokens were validated from this IP address.")
+		return
+	}
+
+	token := r.URL.Query().Get("token")
+	if len(token) == 0 {
+		s.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf("The token query parameter is missing.")))
+		return
+	}
+
+	expiresAt, used, err := get(token)
+	if errors.Is(err, sqlcon.ErrNoRows) {
+		s.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrNotFound.WithReasonf("The token is invalid.")))
+		return
+	} else if err != nil {
+		s.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	state := TokenStateValid
+	if used {
+		state = TokenStateUsed
+	} else if expiresAt.Before(time.Now()) {
+		state = TokenStateExpired
+	}
+
+	s.d.Writer().Write(w, r, &tokenValidation{State: state, ExpiresAt: expiresAt.UTC()})
+}
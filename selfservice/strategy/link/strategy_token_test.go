@@ -0,0 +1,101 @@
+package link_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/x/ioutilx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/selfservice/strategy/link"
+	"github.com/ory/kratos/x"
+)
+
+func TestTokenValidation(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	initViper(t, conf)
+	publicTS, _ := testhelpers.NewKratosServer(t, reg)
+
+	i := &identity.Identity{Traits: identity.Traits(`{"email":"validate-token@ory.sh"}`)}
+	require.NoError(t, reg.IdentityManager().Create(ctx, i, identity.ManagerAllowWriteProtectedTraits))
+	require.Len(t, i.RecoveryAddresses, 1)
+	require.Len(t, i.VerifiableAddresses, 1)
+
+	validate := func(t *testing.T, route, token string) (int, []byte) {
+		res, err := publicTS.Client().Get(publicTS.URL + route + "?" + url.Values{"token": {token}}.Encode())
+		require.NoError(t, err)
+		defer res.Body.Close()
+		return res.StatusCode, ioutilx.MustReadAll(res.Body)
+	}
+
+	t.Run("flow=recovery", func(t *testing.T) {
+		valid := link.NewRecoveryToken(&i.RecoveryAddresses[0], time.Hour)
+		expired := link.NewRecoveryToken(&i.RecoveryAddresses[0], -time.Hour)
+		used := link.NewRecoveryToken(&i.RecoveryAddresses[0], time.Hour)
+		for _, token := range []*link.RecoveryToken{valid, expired, used} {
+			require.NoError(t, reg.RecoveryTokenPersister().CreateRecoveryToken(ctx, token))
+		}
+		_, err := reg.RecoveryTokenPersister().UseRecoveryToken(ctx, used.Token)
+		require.NoError(t, err)
+
+		for token, state := range map[string]link.TokenState{
+			valid.Token:   link.TokenStateValid,
+			expired.Token: link.TokenStateExpired,
+			used.Token:    link.TokenStateUsed,
+		} {
+			code, body := validate(t, link.RouteRecoveryTokenValidation, token)
+			require.Equal(t, http.StatusOK, code, "%s", body)
+			assert.EqualValues(t, state, gjson.GetBytes(body, "state").String(), "%s", body)
+		}
+
+		t.Run("case=does not use the token", func(t *testing.T) {
+			_, err := reg.RecoveryTokenPersister().UseRecoveryToken(ctx, valid.Token)
+			require.NoError(t, err)
+		})
+
+		t.Run("case=unknown token", func(t *testing.T) {
+			code, body := validate(t, link.RouteRecoveryTokenValidation, "i-do-not-exist")
+			assert.Equal(t, http.StatusNotFound, code, "%s", body)
+		})
+	})
+
+	t.Run("flow=verification", func(t *testing.T) {
+		valid := &link.VerificationToken{
+			Token:             x.NewUUID().String(),
+			VerifiableAddress: &i.VerifiableAddresses[0],
+			ExpiresAt:         time.Now().Add(time.Hour),
+			IssuedAt:          time.Now(),
+		}
+		require.NoError(t, reg.VerificationTokenPersister().CreateVerificationToken(ctx, valid))
+
+		code, body := validate(t, link.RouteVerificationTokenValidation, valid.Token)
+		require.Equal(t, http.StatusOK, code, "%s", body)
+		assert.EqualValues(t, link.TokenStateValid, gjson.GetBytes(body, "state").String(), "%s", body)
+
+		_, err := reg.VerificationTokenPersister().UseVerificationToken(ctx, valid.Token)
+		require.NoError(t, err)
+	})
+
+	t.Run("case=rate limits validations", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyLinkTokenValidationRateLimitRequests, 1)
+		conf.MustSet(config.ViperKeyLinkTokenValidationRateLimitPeriod, "1h")
+
+		var codes []int
+		for k := 0; k < 3; k++ {
+			code, _ := validate(t, link.RouteRecoveryTokenValidation, "i-do-not-exist")
+			codes = append(codes, code)
+		}
+		assert.Contains(t, codes, http.StatusTooManyRequests)
+	})
+}
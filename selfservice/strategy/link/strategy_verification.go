@@ -3,6 +3,7 @@ package link
 import (
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/gofrs/uuid"
@@ -12,6 +13,7 @@ import (
 	"github.com/ory/kratos/schema"
 	"github.com/ory/kratos/selfservice/flow"
 	"github.com/ory/kratos/selfservice/flow/verification"
+	"github.com/ory/kratos/selfservice/strategy"
 	"github.com/ory/kratos/text"
 	"github.com/ory/kratos/ui/node"
 	"github.com/ory/kratos/x"
@@ -26,6 +28,7 @@ func (s *Strategy) VerificationStrategyID() string {
 }
 
 func (s *Strategy) RegisterPublicVerificationRoutes(public *x.RouterPublic) {
+	public.GET(RouteVerificationTokenValidation, strategy.IsVerificationDisabled(s.d, s.VerificationStrategyID(), s.validateVerificationToken))
 }
 
 func (s *Strategy) RegisterAdminVerificationRoutes(admin *x.RouterAdmin) {
@@ -33,20 +36,50 @@ func (s *Strategy) RegisterAdminVerificationRoutes(admin *x.RouterAdmin) {
 
 func (s *Strategy) PopulateVerificationMethod(r *http.Request, f *verification.Flow) error {
 	f.UI.SetCSRF(s.d.GenerateCSRFToken(r))
-	f.UI.GetNodes().Upsert(
-		// v0.5: form.Field{Name: "email", Type: "email", Required: true}
-		node.NewInputField("email", nil, node.VerificationLinkGroup, node.InputAttributeTypeEmail, node.WithRequiredInputAttribute),
-	)
-	f.UI.GetNodes().Append(node.NewInputField("method", s.VerificationStrategyID(), node.VerificationLinkGroup, node.InputAttributeTypeSubmit).WithMetaLabel(text.NewInfoNodeLabelSubmit()))
+	s.populateVerificationAddress(r, f, nil)
 	return nil
 }
 
+// populateVerificationAddress renders the email field. If the request has a session whose identity has several
+// unverified email addresses, the field is replaced by one submit button per address so that the user can choose
+// which one to verify. Once the email was sent, a button to send it again is shown next to the email field.
+func (s *Strategy) populateVerificationAddress(r *http.Request, f *verification.Flow, email interface{}) {
+	nodes := f.UI.GetNodes()
+
+	var unverified []identity.VerifiableAddress
+	if sess, err := s.d.SessionManager().FetchFromRequest(r.Context(), r); err == nil {
+		for _, address := range sess.Identity.VerifiableAddresses {
+			if address.Via == identity.VerifiableAddressTypeEmail && !address.Verified {
+				unverified = append(unverified, address)
+			}
+		}
+	}
+
+	if len(unverified) < 2 {
+		// v0.5: form.Field{Name: "email", Type: "email", Required: true, Value: body.Body.Email}
+		nodes.Upsert(node.NewInputField("email", email, node.VerificationLinkGroup, node.InputAttributeTypeEmail, node.WithRequiredInputAttribute))
+		nodes.Upsert(node.NewInputField("method", s.VerificationStrategyID(), node.VerificationLinkGroup, node.InputAttributeTypeSubmit).WithMetaLabel(text.NewInfoNodeLabelSubmit()))
+		if f.State == verification.StateEmailSent {
+			nodes.Upsert(node.NewInputField("resend", s.VerificationStrategyID(), node.VerificationLinkGroup, node.InputAttributeTypeSubmit).WithMetaLabel(text.NewInfoNodeLabelResend()))
+		}
+		return
+	}
+
+	nodes.Remove("email", "method", "resend")
+	for _, address := range unverified {
+		nodes.Append(node.NewInputField("email", address.Value, node.VerificationLinkGroup, node.InputAttributeTypeSubmit).
+			WithMetaLabel(text.NewInfoNodeLabelVerifyAddress(address.Value)))
+	}
+	nodes.Append(node.NewInputField("method", s.VerificationStrategyID(), node.VerificationLinkGroup, node.InputAttributeTypeHidden))
+}
+
 type verificationSubmitPayload struct {
 	Method    string `json:"method" form:"method"`
 	Token     string `json:"token" form:"token"`
 	CSRFToken string `json:"csrf_token" form:"csrf_token"`
 	Flow      string `json:"flow" form:"flow"`
 	Email     string `json:"email" form:"email"`
+	Resend    string `json:"resend" form:"resend"`
 }
 
 func (s *Strategy) decodeVerification(r *http.Request) (*verificationSubmitPayload, error) {
@@ -74,10 +107,7 @@ func (s *Strategy) decodeVerification(r *http.Request) (*verificationSubmitPaylo
 func (s *Strategy) handleVerificationError(w http.ResponseWriter, r *http.Request, f *verification.Flow, body *verificationSubmitPayload, err error) error {
 	if f != nil {
 		f.UI.SetCSRF(s.d.GenerateCSRFToken(r))
-		f.UI.GetNodes().Upsert(
-			// v0.5: form.Field{Name: "email", Type: "email", Required: true, Value: body.Body.Email}
-			node.NewInputField("email", body.Email, node.VerificationLinkGroup, node.InputAttributeTypeEmail, node.WithRequiredInputAttribute),
-		)
+		s.populateVerificationAddress(r, f, body.Email)
 	}
 
 	return err
@@ -122,6 +152,13 @@ type submitSelfServiceVerificationFlowWithLinkMethod struct {
 
 	// Sending the anti-csrf token is only required for browser login flows.
 	CSRFToken string `form:"csrf_token" json:"csrf_token"`
+
+	// Resend the Verification Email
+	//
+	// Set to `link` instead of `method` to send the verification email again. The email is only sent again once
+	// `selfservice.flows.verification.resend.cooldown` has passed and at most
+	// `selfservice.flows.verification.resend.max_resends` times per flow.
+	Resend string `json:"resend"`
 }
 
 func (s *Strategy) Verify(w http.ResponseWriter, r *http.Request, f *verification.Flow) (err error) {
@@ -138,6 +175,11 @@ func (s *Strategy) Verify(w http.ResponseWriter, r *http.Request, f *verificatio
 		return s.verificationUseToken(w, r, body)
 	}
 
+	// The resend button is submitted instead of the method.
+	if len(body.Method) == 0 {
+		body.Method = body.Resend
+	}
+
 	if err := flow.MethodEnabledAndAllowed(r.Context(), s.VerificationStrategyID(), body.Method, s.d); err != nil {
 		return s.handleVerificationError(w, r, f, body, err)
 	}
@@ -174,6 +216,19 @@ func (s *Strategy) verificationHandleFormSubmission(w http.ResponseWriter, r *ht
 		return s.handleVerificationError(w, r, f, body, err)
 	}
 
+	// Users who are signed in may only verify the addresses of their own identity.
+	if sess, err := s.d.SessionManager().FetchFromRequest(r.Context(), r); err == nil {
+		address, ok := findVerifiableAddress(sess.Identity, body.Email)
+		if !ok {
+			return s.handleVerificationError(w, r, f, body, schema.NewVerificationAddressNotOwnedError("#/email", body.Email))
+		}
+		body.Email = address.Value
+	}
+
+	if err := f.EnsureCanSend(s.d.Config(r.Context())); err != nil {
+		return s.handleVerificationError(w, r, f, body, err)
+	}
+
 	if err := s.d.LinkSender().SendVerificationLink(r.Context(), f, identity.VerifiableAddressTypeEmail, body.Email); err != nil {
 		if !errors.Is(err, ErrUnknownAddress) {
 			return s.handleVerificationError(w, r, f, body, err)
@@ -181,14 +236,11 @@ func (s *Strategy) verificationHandleFormSubmission(w http.ResponseWriter, r *ht
 		// Continue execution
 	}
 
-	f.UI.SetCSRF(s.d.GenerateCSRFToken(r))
-	f.UI.GetNodes().Upsert(
-		// v0.5: form.Field{Name: "email", Type: "email", Required: true, Value: body.Body.Email}
-		node.NewInputField("email", body.Email, node.VerificationLinkGroup, node.InputAttributeTypeEmail, node.WithRequiredInputAttribute),
-	)
-
+	f.MarkSent()
 	f.Active = sqlxx.NullString(s.VerificationNodeGroup())
 	f.State = verification.StateEmailSent
+	f.UI.SetCSRF(s.d.GenerateCSRFToken(r))
+	s.populateVerificationAddress(r, f, body.Email)
 	f.UI.Messages.Set(text.NewVerificationEmailSent())
 	if err := s.d.VerificationFlowPersister().UpdateVerificationFlow(r.Context(), f); err != nil {
 		return s.handleVerificationError(w, r, f, body, err)
@@ -236,15 +288,27 @@ func (s *Strategy) verificationUseToken(w http.ResponseWriter, r *http.Request,
 		return s.handleVerificationError(w, r, f, body, err)
 	}
 
+	// The link might have been sent before the identity deleted its account.
+	i, err := s.d.IdentityPool().GetIdentity(r.Context(), token.VerifiableAddress.IdentityID)
+	if err != nil {
+		return s.handleVerificationError(w, r, f, body, err)
+	} else if i.IsDeleted() {
+		return s.retryVerificationFlowWithMessage(w, r, flow.TypeBrowser, text.NewErrorValidationVerificationTokenInvalidOrAlreadyUsed())
+	}
+
 	f.UI.Messages.Clear()
 	f.State = verification.StatePassedChallenge
 	if err := s.d.VerificationFlowPersister().UpdateVerificationFlow(r.Context(), f); err != nil {
 		return s.handleVerificationError(w, r, f, body, err)
 	}
 
+	now := time.Now().UTC()
 	address := token.VerifiableAddress
+	if !address.Verified || s.d.Config(r.Context()).SelfServiceFlowVerificationReverification() != "track" {
+		address.VerifiedAt = sqlxx.NullTime(now)
+	}
+	address.LastVerifiedAt = sqlxx.NullTime(now)
 	address.Verified = true
-	address.VerifiedAt = sqlxx.NullTime(time.Now().UTC())
 	address.Status = identity.VerifiableAddressStatusCompleted
 	if err := s.d.PrivilegedIdentityPool().UpdateVerifiableAddress(r.Context(), address); err != nil {
 		return s.handleVerificationError(w, r, f, body, err)
@@ -263,6 +327,7 @@ func (s *Strategy) verificationUseToken(w http.ResponseWriter, r *http.Request,
 	returnTo, err := x.SecureRedirectTo(&verificationRequest, defaultRedirectURL,
 		x.SecureRedirectAllowSelfServiceURLs(s.d.Config(r.Context()).SelfPublicURL(r)),
 		x.SecureRedirectAllowURLs(s.d.Config(r.Context()).SelfServiceBrowserWhitelistedReturnToDomains()),
+		x.SecureRedirectRequireHTTPS(s.d.Config(r.Context()).SelfServiceBrowserRequireHTTPSReturnTo()),
 	)
 	if err != nil {
 		s.d.Logger().Debugf("error parsing redirectTo from verification: %s\n", err)
@@ -274,6 +339,17 @@ func (s *Strategy) verificationUseToken(w http.ResponseWriter, r *http.Request,
 	return errors.WithStack(flow.ErrCompletedByStrategy)
 }
 
+// findVerifiableAddress returns the identity's email address which matches value regardless of its case.
+func findVerifiableAddress(i *identity.Identity, value string) (*identity.VerifiableAddress, bool) {
+	for k := range i.VerifiableAddresses {
+		address := &i.VerifiableAddresses[k]
+		if address.Via == identity.VerifiableAddressTypeEmail && strings.EqualFold(address.Value, value) {
+			return address, true
+		}
+	}
+	return nil, false
+}
+
 func (s *Strategy) retryVerificationFlowWithMessage(w http.ResponseWriter, r *http.Request, ft flow.Type, message *text.Message) error {
 	s.d.Logger().WithRequest(r).WithField("message", message).Debug("A verification flow is being retried because a validation error occurred.")
 
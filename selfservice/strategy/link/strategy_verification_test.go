@@ -8,9 +8,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/ory/kratos-client-go"
+
 	"github.com/ory/kratos/selfservice/strategy/link"
 	"github.com/ory/kratos/ui/node"
 
@@ -284,6 +287,7 @@ func TestVerification(t *testing.T) {
 			assert.True(t, address.Verified)
 			assert.EqualValues(t, identity.VerifiableAddressStatusCompleted, address.Status)
 			assert.True(t, time.Time(address.VerifiedAt).Add(time.Second*5).After(time.Now()))
+			assert.True(t, time.Time(address.LastVerifiedAt).Add(time.Second*5).After(time.Now()))
 		}
 
 		var values = func(v url.Values) {
@@ -299,6 +303,147 @@ func TestVerification(t *testing.T) {
 		})
 	})
 
+	t.Run("description=should resend the verification link", func(t *testing.T) {
+		var send = func(t *testing.T, c *http.Client, f *kratos.VerificationFlow, values url.Values) string {
+			values.Set("csrf_token", x.FakeCSRFToken)
+			values.Set("email", verificationEmail)
+			res, err := c.PostForm(f.Ui.Action, values)
+			require.NoError(t, err)
+			defer res.Body.Close()
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			return string(ioutilx.MustReadAll(res.Body))
+		}
+
+		var expectLink = func(t *testing.T) string {
+			return testhelpers.CourierExpectLinkInMessage(t, testhelpers.CourierExpectMessage(t, reg, verificationEmail, "Please verify your email address"), 1)
+		}
+
+		t.Run("case=only the last link is valid", func(t *testing.T) {
+			conf.MustSet(config.ViperKeySelfServiceVerificationResendCooldown, "0s")
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeySelfServiceVerificationResendCooldown, "30s")
+			})
+
+			c := testhelpers.NewClientWithCookies(t)
+			f := testhelpers.InitializeVerificationFlowViaBrowser(t, c, public)
+
+			body := send(t, c, f, url.Values{"method": {"link"}})
+			assert.EqualValues(t, "link", gjson.Get(body, "ui.nodes.#(attributes.name==resend).attributes.value").String(), "%s", body)
+			first := expectLink(t)
+
+			body = send(t, c, f, url.Values{"resend": {"link"}})
+			assertx.EqualAsJSON(t, text.NewVerificationEmailSent(), json.RawMessage(gjson.Get(body, "ui.messages.0").Raw))
+			second := expectLink(t)
+
+			res, err := testhelpers.NewClientWithCookies(t).Get(first)
+			require.NoError(t, err)
+			assert.Equal(t, text.NewErrorValidationVerificationTokenInvalidOrAlreadyUsed().Text,
+				gjson.GetBytes(ioutilx.MustReadAll(res.Body), "ui.messages.0.text").String())
+
+			res, err = testhelpers.NewClientWithCookies(t).Get(second)
+			require.NoError(t, err)
+			assert.EqualValues(t, "passed_challenge", gjson.GetBytes(ioutilx.MustReadAll(res.Body), "state").String())
+		})
+
+		t.Run("case=enforces the cooldown", func(t *testing.T) {
+			conf.MustSet(config.ViperKeySelfServiceVerificationResendCooldown, "1m")
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeySelfServiceVerificationResendCooldown, "30s")
+			})
+
+			c := testhelpers.NewClientWithCookies(t)
+			f := testhelpers.InitializeVerificationFlowViaBrowser(t, c, public)
+
+			send(t, c, f, url.Values{"method": {"link"}})
+			expectLink(t)
+
+			body := send(t, c, f, url.Values{"resend": {"link"}})
+			assert.True(t, gjson.Get(body, fmt.Sprintf("ui.messages.#(id==%d)", text.ErrorValidationVerificationResendCooldown)).Exists(), "%s", body)
+			assert.EqualValues(t, "link", gjson.Get(body, "ui.nodes.#(attributes.name==resend).attributes.value").String(), "%s", body)
+		})
+
+		t.Run("case=limits the number of resends", func(t *testing.T) {
+			conf.MustSet(config.ViperKeySelfServiceVerificationResendCooldown, "0s")
+			conf.MustSet(config.ViperKeySelfServiceVerificationMaxResends, 1)
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeySelfServiceVerificationResendCooldown, "30s")
+				conf.MustSet(config.ViperKeySelfServiceVerificationMaxResends, 5)
+			})
+
+			c := testhelpers.NewClientWithCookies(t)
+			f := testhelpers.InitializeVerificationFlowViaBrowser(t, c, public)
+
+			send(t, c, f, url.Values{"method": {"link"}})
+			expectLink(t)
+			send(t, c, f, url.Values{"resend": {"link"}})
+			expectLink(t)
+
+			body := send(t, c, f, url.Values{"resend": {"link"}})
+			assert.True(t, gjson.Get(body, fmt.Sprintf("ui.messages.#(id==%d)", text.ErrorValidationVerificationResendLimit)).Exists(), "%s", body)
+		})
+	})
+
+	t.Run("description=should re-verify a verified address", func(t *testing.T) {
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceVerificationReverification, "off")
+		})
+
+		var getAddress = func(t *testing.T) identity.VerifiableAddress {
+			id, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(context.Background(), identityToVerify.ID)
+			require.NoError(t, err)
+			require.Len(t, id.VerifiableAddresses, 1)
+			return id.VerifiableAddresses[0]
+		}
+
+		var request = func(t *testing.T) string {
+			expectSuccess(t, false, func(v url.Values) {
+				v.Set("email", verificationEmail)
+			})
+			message := testhelpers.CourierExpectMessage(t, reg, verificationEmail, "Please verify your email address")
+			return testhelpers.CourierExpectLinkInMessage(t, message, 1)
+		}
+
+		var use = func(t *testing.T, verificationLink string) {
+			res, err := testhelpers.NewClientWithCookies(t).Get(verificationLink)
+			require.NoError(t, err)
+			defer res.Body.Close()
+			assert.EqualValues(t, "passed_challenge", gjson.Get(string(ioutilx.MustReadAll(res.Body)), "state").String())
+		}
+
+		require.True(t, getAddress(t).Verified)
+
+		t.Run("mode=reset", func(t *testing.T) {
+			conf.MustSet(config.ViperKeySelfServiceVerificationReverification, "reset")
+
+			verificationLink := request(t)
+			address := getAddress(t)
+			assert.False(t, address.Verified)
+			assert.EqualValues(t, identity.VerifiableAddressStatusPending, address.Status)
+			assert.True(t, time.Time(address.VerifiedAt).IsZero())
+
+			use(t, verificationLink)
+			address = getAddress(t)
+			assert.True(t, address.Verified)
+			assert.EqualValues(t, identity.VerifiableAddressStatusCompleted, address.Status)
+			assert.Equal(t, time.Time(address.VerifiedAt), time.Time(address.LastVerifiedAt))
+		})
+
+		t.Run("mode=track", func(t *testing.T) {
+			conf.MustSet(config.ViperKeySelfServiceVerificationReverification, "track")
+			before := getAddress(t)
+
+			verificationLink := request(t)
+			assert.True(t, getAddress(t).Verified)
+
+			time.Sleep(time.Second)
+			use(t, verificationLink)
+			address := getAddress(t)
+			assert.True(t, address.Verified)
+			assert.Equal(t, time.Time(before.VerifiedAt), time.Time(address.VerifiedAt))
+			assert.True(t, time.Time(address.LastVerifiedAt).After(time.Time(before.LastVerifiedAt)))
+		})
+	})
+
 	newValidFlow := func(t *testing.T, requestURL string) (*verification.Flow, *link.VerificationToken) {
 		f, err := verification.NewFlow(conf, time.Hour, x.FakeCSRFToken, httptest.NewRequest("GET", requestURL, nil), nil, flow.TypeBrowser)
 		require.NoError(t, err)
@@ -342,3 +487,118 @@ func TestVerification(t *testing.T) {
 
 	})
 }
+
+func TestVerificationAddressSelection(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	initViper(t, conf)
+	conf.MustSet(config.ViperKeyIdentitySchemas, []config.Schema{{ID: "multiple-emails", URL: "file://./stub/multiple-emails.schema.json"}})
+
+	_ = testhelpers.NewVerificationUIFlowEchoServer(t, reg)
+	_ = testhelpers.NewErrorTestServer(t, reg)
+
+	public, _ := testhelpers.NewKratosServer(t, reg)
+
+	personal, work := x.NewUUID().String()+"@personal.ory.sh", x.NewUUID().String()+"@work.ory.sh"
+	i := &identity.Identity{
+		ID:       x.NewUUID(),
+		Traits:   identity.Traits(`{"emails":["` + personal + `","` + work + `"]}`),
+		SchemaID: "multiple-emails",
+	}
+	require.NoError(t, reg.IdentityManager().Create(context.Background(), i, identity.ManagerAllowWriteProtectedTraits))
+
+	submit := func(t *testing.T, c *http.Client, email string) string {
+		rs := testhelpers.GetVerificationFlow(t, c, public)
+		res, err := c.PostForm(rs.Ui.Action, url.Values{"method": {"link"}, "email": {email}, "csrf_token": {x.FakeCSRFToken}})
+		require.NoError(t, err)
+		defer res.Body.Close()
+		assert.EqualValues(t, http.StatusOK, res.StatusCode)
+		return string(ioutilx.MustReadAll(res.Body))
+	}
+
+	t.Run("case=renders a chooser for signed in identities with several unverified addresses", func(t *testing.T) {
+		rs := testhelpers.GetVerificationFlow(t, testhelpers.NewHTTPClientWithIdentitySessionCookie(t, reg, i), public)
+
+		var chooser []string
+		for _, n := range rs.Ui.Nodes {
+			if n.Attributes.UiNodeInputAttributes.Name == "email" {
+				assert.Equal(t, "submit", n.Attributes.UiNodeInputAttributes.Type)
+				chooser = append(chooser, n.Meta.Label.Text)
+			}
+			if n.Attributes.UiNodeInputAttributes.Name == "method" {
+				assert.Equal(t, "hidden", n.Attributes.UiNodeInputAttributes.Type)
+			}
+		}
+		assert.ElementsMatch(t, []string{"Verify " + personal, "Verify " + work}, chooser)
+	})
+
+	t.Run("case=renders the email field for anonymous users", func(t *testing.T) {
+		rs := testhelpers.GetVerificationFlow(t, testhelpers.NewClientWithCookies(t), public)
+		body, err := json.Marshal(rs.Ui.Nodes)
+		require.NoError(t, err)
+		assert.Equal(t, "email", gjson.GetBytes(body, "#(attributes.name==email).attributes.type").String(), "%s", body)
+		assert.Equal(t, "submit", gjson.GetBytes(body, "#(attributes.name==method).attributes.type").String(), "%s", body)
+	})
+
+	t.Run("case=sends the link to the selected address", func(t *testing.T) {
+		actual := submit(t, testhelpers.NewHTTPClientWithIdentitySessionCookie(t, reg, i), strings.ToUpper(work))
+		assertx.EqualAsJSON(t, text.NewVerificationEmailSent(), json.RawMessage(gjson.Get(actual, "ui.messages.0").Raw), "%s", actual)
+
+		message := testhelpers.CourierExpectMessage(t, reg, work, "Please verify your email address")
+		assert.Contains(t, message.Body, "please verify your account by clicking the following link")
+	})
+
+	t.Run("case=refuses addresses of other identities", func(t *testing.T) {
+		other := x.NewUUID().String() + "@ory.sh"
+		actual := submit(t, testhelpers.NewHTTPClientWithIdentitySessionCookie(t, reg, i), other)
+		assert.EqualValues(t, "The address "+other+" does not belong to your account.",
+			gjson.Get(actual, "ui.nodes.#(attributes.name==email).messages.0.text").String(), "%s", actual)
+	})
+}
+
+func TestVerificationOfDeletedIdentity(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	initViper(t, conf)
+
+	_ = testhelpers.NewVerificationUIFlowEchoServer(t, reg)
+	_ = testhelpers.NewErrorTestServer(t, reg)
+
+	public, _ := testhelpers.NewKratosServer(t, reg)
+
+	email := x.NewUUID().String() + "@ory.sh"
+	i := &identity.Identity{Traits: identity.Traits(`{"email":"` + email + `"}`), SchemaID: config.DefaultIdentityTraitsSchemaID}
+	require.NoError(t, reg.IdentityManager().Create(context.Background(), i, identity.ManagerAllowWriteProtectedTraits))
+
+	request := func(t *testing.T) {
+		testhelpers.SubmitVerificationForm(t, false, testhelpers.NewClientWithCookies(t), public, func(v url.Values) {
+			v.Set("email", email)
+		}, http.StatusOK, conf.SelfServiceFlowVerificationUI().String())
+	}
+
+	request(t)
+	verificationLink := testhelpers.CourierExpectLinkInMessage(t, testhelpers.CourierExpectMessage(t, reg, email, "Please verify your email address"), 1)
+
+	deletedAt := time.Now().UTC()
+	i.DeletedAt = &deletedAt
+	require.NoError(t, reg.PrivilegedIdentityPool().UpdateIdentity(context.Background(), i))
+
+	t.Run("case=does not accept links sent before the deletion", func(t *testing.T) {
+		res, err := testhelpers.NewClientWithCookies(t).Get(verificationLink)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		assert.Contains(t, res.Request.URL.String(), conf.SelfServiceFlowVerificationUI().String())
+		body := ioutilx.MustReadAll(res.Body)
+		assert.EqualValues(t, text.NewErrorValidationVerificationTokenInvalidOrAlreadyUsed().ID, gjson.GetBytes(body, "ui.messages.0.id").Int(), "%s", body)
+
+		actual, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(context.Background(), i.ID)
+		require.NoError(t, err)
+		require.Len(t, actual.VerifiableAddresses, 1)
+		assert.False(t, actual.VerifiableAddresses[0].Verified)
+	})
+
+	t.Run("case=does not send verification links", func(t *testing.T) {
+		request(t)
+		message := testhelpers.CourierExpectMessage(t, reg, email, "Someone tried to verify this email address")
+		assert.NotContains(t, message.Body, public.URL+verification.RouteSubmitFlow)
+	})
+}
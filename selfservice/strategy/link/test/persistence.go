@@ -91,6 +91,42 @@ func TestPersister(ctx context.Context, conf *config.Config, p interface {
 				require.Error(t, err)
 			})
 
+			t.Run("case=should get a recovery token without using it", func(t *testing.T) {
+				expected := newRecoveryToken(t, "get-recovery-user@ory.sh")
+				require.NoError(t, p.CreateRecoveryToken(ctx, expected))
+
+				_, err := p.GetRecoveryToken(ctx, "i-do-not-exist")
+				require.ErrorIs(t, err, sqlcon.ErrNoRows)
+
+				actual, err := p.GetRecoveryToken(ctx, expected.Token)
+				require.NoError(t, err)
+				assert.Equal(t, expected.ID, actual.ID)
+				assert.False(t, actual.Used)
+
+				_, err = p.UseRecoveryToken(ctx, expected.Token)
+				require.NoError(t, err)
+
+				actual, err = p.GetRecoveryToken(ctx, expected.Token)
+				require.NoError(t, err)
+				assert.True(t, actual.Used)
+			})
+
+			t.Run("case=should invalidate previous tokens of the flow", func(t *testing.T) {
+				first := newRecoveryToken(t, "resend-recovery-user@ory.sh")
+				require.NoError(t, p.CreateRecoveryToken(ctx, first))
+
+				second := &link.RecoveryToken{Token: x.NewUUID().String(), FlowID: first.FlowID,
+					RecoveryAddress: first.RecoveryAddress, ExpiresAt: time.Now(), IssuedAt: time.Now()}
+				require.NoError(t, p.CreateRecoveryToken(ctx, second))
+
+				_, err := p.UseRecoveryToken(ctx, first.Token)
+				require.ErrorIs(t, err, sqlcon.ErrNoRows)
+
+				actual, err := p.UseRecoveryToken(ctx, second.Token)
+				require.NoError(t, err)
+				assert.Equal(t, second.ID, actual.ID)
+			})
+
 		})
 
 		t.Run("token=verification", func(t *testing.T) {
@@ -151,6 +187,42 @@ func TestPersister(ctx context.Context, conf *config.Config, p interface {
 				_, err = p.UseVerificationToken(ctx, expected.Token)
 				require.Error(t, err)
 			})
+
+			t.Run("case=should get a verification token without using it", func(t *testing.T) {
+				expected := newVerificationToken(t, "get-verification-user@ory.sh")
+				require.NoError(t, p.CreateVerificationToken(ctx, expected))
+
+				_, err := p.GetVerificationToken(ctx, "i-do-not-exist")
+				require.ErrorIs(t, err, sqlcon.ErrNoRows)
+
+				actual, err := p.GetVerificationToken(ctx, expected.Token)
+				require.NoError(t, err)
+				assert.Equal(t, expected.ID, actual.ID)
+				assert.False(t, actual.Used)
+
+				_, err = p.UseVerificationToken(ctx, expected.Token)
+				require.NoError(t, err)
+
+				actual, err = p.GetVerificationToken(ctx, expected.Token)
+				require.NoError(t, err)
+				assert.True(t, actual.Used)
+			})
+
+			t.Run("case=should invalidate previous tokens of the flow", func(t *testing.T) {
+				first := newVerificationToken(t, "resend-verification-user@ory.sh")
+				require.NoError(t, p.CreateVerificationToken(ctx, first))
+
+				second := &link.VerificationToken{Token: x.NewUUID().String(), FlowID: first.FlowID,
+					VerifiableAddress: first.VerifiableAddress, ExpiresAt: time.Now(), IssuedAt: time.Now()}
+				require.NoError(t, p.CreateVerificationToken(ctx, second))
+
+				_, err := p.UseVerificationToken(ctx, first.Token)
+				require.ErrorIs(t, err, sqlcon.ErrNoRows)
+
+				actual, err := p.UseVerificationToken(ctx, second.Token)
+				require.NoError(t, err)
+				assert.Equal(t, second.ID, actual.ID)
+			})
 		})
 	}
 }
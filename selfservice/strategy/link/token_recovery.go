@@ -39,6 +39,9 @@ type RecoveryToken struct {
 	// required: true
 	IssuedAt time.Time `json:"issued_at" faker:"time_type" db:"issued_at"`
 
+	// Used is true if the token was already used.
+	Used bool `json:"-" faker:"-" db:"used"`
+
 	// CreatedAt is a helper struct field for gobuffalo.pop.
 	CreatedAt time.Time `json:"-" faker:"-" db:"created_at"`
 	// UpdatedAt is a helper struct field for gobuffalo.pop.
@@ -54,13 +57,14 @@ func (RecoveryToken) TableName(ctx context.Context) string {
 	return corp.ContextualizeTableName(ctx, "identity_recovery_tokens")
 }
 
-func NewSelfServiceRecoveryToken(address *identity.RecoveryAddress, f *recovery.Flow) *RecoveryToken {
+func NewSelfServiceRecoveryToken(address *identity.RecoveryAddress, f *recovery.Flow, expiresIn time.Duration) *RecoveryToken {
+	now := time.Now().UTC()
 	return &RecoveryToken{
 		ID:              x.NewUUID(),
 		Token:           randx.MustString(32, randx.AlphaNum),
 		RecoveryAddress: address,
-		ExpiresAt:       f.ExpiresAt,
-		IssuedAt:        time.Now().UTC(),
+		ExpiresAt:       now.Add(expiresIn),
+		IssuedAt:        now,
 		FlowID:          uuid.NullUUID{UUID: f.ID, Valid: true}}
 }
 
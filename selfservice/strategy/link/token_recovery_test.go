@@ -32,20 +32,29 @@ func TestRecoveryToken(t *testing.T) {
 
 			tokens := make([]string, 10)
 			for k := range tokens {
-				tokens[k] = NewSelfServiceRecoveryToken(nil, f).Token
+				tokens[k] = NewSelfServiceRecoveryToken(nil, f, time.Hour).Token
 			}
 
 			assert.Len(t, stringslice.Unique(tokens), len(tokens))
 		})
 	})
 	t.Run("method=Valid", func(t *testing.T) {
-		t.Run("case=is invalid when the flow is expired", func(t *testing.T) {
-			f, err := recovery.NewFlow(conf, -time.Hour, "", req, nil, flow.TypeBrowser)
+		t.Run("case=is invalid when the token is expired", func(t *testing.T) {
+			f, err := recovery.NewFlow(conf, time.Hour, "", req, nil, flow.TypeBrowser)
 			require.NoError(t, err)
 
-			token := NewSelfServiceRecoveryToken(nil, f)
+			token := NewSelfServiceRecoveryToken(nil, f, -time.Hour)
 			require.Error(t, token.Valid())
-			assert.EqualError(t, token.Valid(), f.Valid().Error())
+			assert.EqualError(t, token.Valid(), recovery.NewFlowExpiredError(token.ExpiresAt).Error())
+		})
+
+		t.Run("case=is valid even if the flow is expired", func(t *testing.T) {
+			f, err := recovery.NewFlow(conf, -time.Hour, "", req, nil, flow.TypeBrowser)
+			require.NoError(t, err)
+
+			token := NewSelfServiceRecoveryToken(nil, f, time.Hour)
+			require.Error(t, f.Valid())
+			require.NoError(t, token.Valid())
 		})
 	})
 }
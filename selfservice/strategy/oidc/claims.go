@@ -0,0 +1,79 @@
+package oidc
+
+import (
+	"encoding/json"
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+var jsonnetFieldMissing = regexp.MustCompile(`Field does not exist: (\S+)`)
+
+// requireClaims returns ErrClaimMissing if one of the provider's required claims is missing or empty.
+func requireClaims(c *Configuration, claims *Claims) error {
+	if len(c.RequiredClaims) == 0 {
+		return nil
+	}
+
+	present, err := claimsMap(claims)
+	if err != nil {
+		return err
+	}
+
+	for _, claim := range c.RequiredClaims {
+		if _, ok := present[claim]; !ok {
+			return errors.WithStack(ErrClaimMissing.
+				WithReasonf(`The OpenID Connect provider "%s" did not return the required claim "%s".`, c.ID, claim).
+				WithDetail("claim", claim))
+		}
+	}
+
+	return nil
+}
+
+// claimsWithDefaults encodes the claims for the Jsonnet mapper and adds the provider's claim defaults
+// for all claims which were not returned.
+func claimsWithDefaults(c *Configuration, claims *Claims) (json.RawMessage, error) {
+	encoded, err := claimsMap(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	for claim, value := range c.ClaimDefaults {
+		if _, ok := encoded[claim]; !ok {
+			encoded[claim] = value
+		}
+	}
+
+	out, err := json.Marshal(encoded)
+	return out, errors.WithStack(err)
+}
+
+// mapperError names the missing claim if the Jsonnet mapper failed because it accessed a claim which the
+// provider did not return.
+func mapperError(c *Configuration, err error) error {
+	m := jsonnetFieldMissing.FindStringSubmatch(err.Error())
+	if len(m) != 2 {
+		return err
+	}
+
+	return errors.WithStack(ErrClaimMissing.
+		WithReasonf(`The OpenID Connect provider "%s" did not return the claim "%s" which is used by the Jsonnet mapper. Set a default for it in "claim_defaults" if the claim is optional.`, c.ID, m[1]).
+		WithDetail("claim", m[1]).
+		WithDebug(err.Error()))
+}
+
+// claimsMap returns the claims keyed by name. Claims which are empty are omitted.
+func claimsMap(claims *Claims) (map[string]json.RawMessage, error) {
+	raw, err := json.Marshal(claims)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return m, nil
+}
@@ -1,5 +1,12 @@
 package oidc
 
+import "time"
+
 const (
 	sessionName = "ory_kratos_oidc_auth_code_session"
+
+	// callbackCookieName is the cookie which keeps the values of a posted callback until it is requested again
+	// using GET.
+	callbackCookieName     = "ory_kratos_oidc_callback"
+	callbackCookieLifespan = time.Minute * 5
 )
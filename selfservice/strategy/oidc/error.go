@@ -11,6 +11,9 @@ var (
 				WithError("authentication failed because id_token is missing").
 				WithReasonf(`Authentication failed because no id_token was returned. Please accept the "openid" permission and try again.`)
 
+	ErrClaimMissing = herodot.ErrBadRequest.
+			WithError("authentication failed because a required claim is missing")
+
 	ErrAPIFlowNotSupported = herodot.ErrBadRequest.WithError("API-based flows are not supported for this method").
 				WithReasonf("Social Sign In and OpenID Connect are only supported for flows initiated using the Browser endpoint.")
 )
@@ -0,0 +1,153 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/google/go-jsonnet"
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/ory/herodot"
+	"github.com/ory/jsonschema/v3"
+
+	"github.com/ory/kratos/selfservice/strategy"
+)
+
+var _ strategy.ConfigValidator = new(Strategy)
+
+// sampleClaims are passed to the Jsonnet mappers at startup if the provider does not configure
+// `mapper_sample_claims`.
+var sampleClaims = Claims{
+	Issuer:              "https://sample.example.org/",
+	Subject:             "sample-subject",
+	Name:                "Sample User",
+	GivenName:           "Sample",
+	FamilyName:          "User",
+	LastName:            "User",
+	MiddleName:          "Middle",
+	Nickname:            "sample",
+	PreferredUsername:   "sample",
+	Profile:             "https://sample.example.org/profile",
+	Picture:             "https://sample.example.org/picture.png",
+	Website:             "https://sample.example.org",
+	Email:               "sample@example.org",
+	EmailVerified:       true,
+	Gender:              "female",
+	Birthdate:           "1970-01-01",
+	Zoneinfo:            "Europe/Berlin",
+	Locale:              "en-US",
+	PhoneNumber:         "+15555550100",
+	PhoneNumberVerified: true,
+	UpdatedAt:           1,
+	HD:                  "example.org",
+}
+
+// evaluateMapper runs the provider's Jsonnet mapper with the claims.
+func evaluateMapper(c *Configuration, code string, claims json.RawMessage) (string, error) {
+	vm := jsonnet.MakeVM()
+	vm.ExtCode("claims", string(claims))
+	return vm.EvaluateSnippet(c.Mapper, code)
+}
+
+// ValidateConfig checks that the Jsonnet mapper of every provider compiles and that it maps the sample claims to
+// traits which match the structure of the default identity schema.
+func (s *Strategy) ValidateConfig(ctx context.Context) error {
+	if !s.d.Config(ctx).SelfServiceStrategy(s.ID().String()).Enabled {
+		return nil
+	}
+
+	conf, err := s.Config(ctx)
+	if err != nil {
+		return err
+	}
+
+	for k := range conf.Providers {
+		if err := conf.Providers[k].validate(); err != nil {
+			return err
+		}
+		if err := s.validateMapper(ctx, &conf.Providers[k]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Strategy) validateMapper(ctx context.Context, c *Configuration) error {
+	jn, err := s.f.Fetch(c.Mapper)
+	if err != nil {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf(
+			`Unable to load the Jsonnet mapper of OpenID Connect Provider "%s": %s`, c.ID, err))
+	}
+
+	claims := c.MapperSampleClaims
+	configured := len(claims) > 0 && gjson.ParseBytes(claims).Type != gjson.Null
+	if !configured {
+		if claims, err = claimsWithDefaults(c, &sampleClaims); err != nil {
+			return err
+		}
+	} else if !gjson.ParseBytes(claims).IsObject() {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf(
+			`The mapper sample claims of OpenID Connect Provider "%s" must be a JSON object.`, c.ID))
+	}
+
+	evaluated, err := evaluateMapper(c, jn.String(), claims)
+	if err != nil {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf(
+			`The Jsonnet mapper of OpenID Connect Provider "%s" failed for the sample claims: %s`, c.ID, err))
+	}
+
+	traits := gjson.Get(evaluated, "identity.traits")
+	if !traits.IsObject() {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf(
+			`The Jsonnet mapper of OpenID Connect Provider "%s" did not return an object for key identity.traits.`, c.ID))
+	}
+
+	document, err := sjson.SetRawBytes([]byte(`{}`), "traits", []byte(traits.Raw))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := s.validator.Validate(s.d.Config(ctx).DefaultIdentityTraitsSchemaURL().String(), document); err != nil {
+		// Traits which the user fills in during registration are missing, and the derived sample claims
+		// can not satisfy every format.
+		if err := structuralError(err, !configured); err != nil {
+			return errors.WithStack(herodot.ErrInternalServerError.WithReasonf(
+				`The Jsonnet mapper of OpenID Connect Provider "%s" returned traits which do not match the identity schema: %s`, c.ID, err))
+		}
+	}
+
+	return nil
+}
+
+// structuralError returns nil if the validation error is only caused by missing required properties or,
+// if ignoreFormat is true, by values in the wrong format.
+func structuralError(err error, ignoreFormat bool) error {
+	var e *jsonschema.ValidationError
+	if !errors.As(err, &e) {
+		return err
+	}
+
+	var ignorable func(e *jsonschema.ValidationError) bool
+	ignorable = func(e *jsonschema.ValidationError) bool {
+		if len(e.Causes) == 0 {
+			_, required := e.Context.(*jsonschema.ValidationErrorContextRequired)
+			return required || (ignoreFormat && strings.HasSuffix(e.SchemaPtr, "/format"))
+		}
+
+		for _, cause := range e.Causes {
+			if !ignorable(cause) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if ignorable(e) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,120 @@
+package oidc_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/selfservice/strategy/oidc"
+)
+
+func TestValidateConfig(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/registration.schema.json")
+
+	var mapper = func(code string) string {
+		return "base64://" + base64.StdEncoding.EncodeToString([]byte(code))
+	}
+
+	var provider = func(id, mapper string, sampleClaims json.RawMessage) oidc.Configuration {
+		return oidc.Configuration{
+			Provider:           "generic",
+			ID:                 id,
+			ClientID:           "client",
+			ClientSecret:       "secret",
+			IssuerURL:          "https://www.ory.sh",
+			Mapper:             mapper,
+			MapperSampleClaims: sampleClaims,
+		}
+	}
+
+	for _, tc := range []struct {
+		d            string
+		mapper       string
+		sampleClaims json.RawMessage
+		expectErr    string
+	}{
+		{
+			d:      "should pass with a valid mapper",
+			mapper: "file://./stub/oidc.hydra.jsonnet",
+		},
+		{
+			d:            "should pass with valid sample claims",
+			mapper:       "file://./stub/oidc.hydra.jsonnet",
+			sampleClaims: json.RawMessage(`{"sub":"user@example.org","website":"https://www.ory.sh"}`),
+		},
+		{
+			d:      "should pass if required traits are missing",
+			mapper: mapper(`{identity: {traits: {website: std.extVar('claims').website}}}`),
+		},
+		{
+			d:         "should fail if the mapper does not compile",
+			mapper:    mapper(`local claims = std.extVar('claims'); {identity: {traits: {subject: claims.sub}}`),
+			expectErr: `The Jsonnet mapper of OpenID Connect Provider "broken" failed for the sample claims`,
+		},
+		{
+			d:         "should fail if the mapper can not be loaded",
+			mapper:    "file://./stub/does-not-exist.jsonnet",
+			expectErr: `Unable to load the Jsonnet mapper of OpenID Connect Provider "broken"`,
+		},
+		{
+			d:         "should fail if the mapper uses an unknown claim",
+			mapper:    mapper(`{identity: {traits: {subject: std.extVar('claims').does_not_exist}}}`),
+			expectErr: `The Jsonnet mapper of OpenID Connect Provider "broken" failed for the sample claims`,
+		},
+		{
+			d:         "should fail if the mapper does not return traits",
+			mapper:    mapper(`{identity: {}}`),
+			expectErr: `The Jsonnet mapper of OpenID Connect Provider "broken" did not return an object for key identity.traits`,
+		},
+		{
+			d:         "should fail if the traits do not match the identity schema",
+			mapper:    mapper(`{identity: {traits: {subject: 1234}}}`),
+			expectErr: `The Jsonnet mapper of OpenID Connect Provider "broken" returned traits which do not match the identity schema`,
+		},
+		{
+			d:            "should fail if the traits of the sample claims have the wrong format",
+			mapper:       "file://./stub/oidc.hydra.jsonnet",
+			sampleClaims: json.RawMessage(`{"sub":"not-an-email"}`),
+			expectErr:    `The Jsonnet mapper of OpenID Connect Provider "broken" returned traits which do not match the identity schema`,
+		},
+		{
+			d:            "should fail if the sample claims are not an object",
+			mapper:       "file://./stub/oidc.hydra.jsonnet",
+			sampleClaims: json.RawMessage(`"foo"`),
+			expectErr:    `The mapper sample claims of OpenID Connect Provider "broken" must be a JSON object`,
+		},
+	} {
+		t.Run("case="+tc.d, func(t *testing.T) {
+			viperSetProviderConfig(t, conf,
+				provider("valid", "file://./stub/oidc.hydra.jsonnet", nil),
+				provider("broken", tc.mapper, tc.sampleClaims))
+
+			err := reg.ValidateSelfServiceStrategies(context.Background())
+			if tc.expectErr == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			var he *herodot.DefaultError
+			require.True(t, errors.As(err, &he), "%+v", err)
+			assert.Contains(t, he.Reason(), tc.expectErr)
+		})
+	}
+
+	t.Run("case=should not validate a disabled strategy", func(t *testing.T) {
+		viperSetProviderConfig(t, conf, provider("broken", mapper(`{`), nil))
+		conf.MustSet(config.ViperKeySelfServiceStrategyConfig+"."+string(identity.CredentialsTypeOIDC)+".enabled", false)
+		require.NoError(t, reg.ValidateSelfServiceStrategies(context.Background()))
+	})
+}
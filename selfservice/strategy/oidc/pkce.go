@@ -0,0 +1,96 @@
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/randx"
+	"github.com/ory/x/stringslice"
+)
+
+const (
+	PKCEAuto  = "auto"
+	PKCEForce = "force"
+	PKCENever = "never"
+
+	pkceMethodS256 = "S256"
+)
+
+// pkceSupporter is implemented by providers which can tell whether they support PKCE with the S256 method.
+type pkceSupporter interface {
+	supportsPKCE(ctx context.Context) bool
+}
+
+// usePKCE returns true if the authorization code flow with the provider uses PKCE.
+func usePKCE(ctx context.Context, p Provider) bool {
+	switch p.Config().PKCE {
+	case PKCEForce:
+		return true
+	case PKCENever:
+		return false
+	}
+
+	s, ok := p.(pkceSupporter)
+	return ok && s.supportsPKCE(ctx)
+}
+
+// newPKCEVerifier returns a new code verifier if the authorization code flow with the provider uses PKCE and
+// an empty string otherwise. The verifier is stored in the continuity container together with the state.
+func newPKCEVerifier(ctx context.Context, p Provider) string {
+	if !usePKCE(ctx, p) {
+		return ""
+	}
+	return randx.MustString(64, randx.AlphaNum)
+}
+
+// pkceChallenge returns the S256 code challenge of the verifier.
+func pkceChallenge(verifier string) string {
+	hash := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+// pkceAuthCodeURLOptions returns the options which add the code challenge to the authorization request.
+func pkceAuthCodeURLOptions(verifier string) []oauth2.AuthCodeOption {
+	if len(verifier) == 0 {
+		return nil
+	}
+
+	return []oauth2.AuthCodeOption{
+		oauth2.SetAuthURLParam("code_challenge", pkceChallenge(verifier)),
+		oauth2.SetAuthURLParam("code_challenge_method", pkceMethodS256),
+	}
+}
+
+// pkceExchangeOptions returns the options which add the code verifier to the token request. It fails if the
+// provider uses PKCE but the flow was started without a code verifier.
+func pkceExchangeOptions(ctx context.Context, p Provider, cntnr *authCodeContainer) ([]oauth2.AuthCodeOption, error) {
+	if len(cntnr.PKCEVerifier) == 0 {
+		if usePKCE(ctx, p) {
+			return nil, errors.WithStack(herodot.ErrBadRequest.WithReasonf(`Unable to complete OpenID Connect flow because the PKCE code verifier is missing from the session cookie. Please try again.`))
+		}
+		return nil, nil
+	}
+
+	return []oauth2.AuthCodeOption{oauth2.SetAuthURLParam("code_verifier", cntnr.PKCEVerifier)}, nil
+}
+
+func (g *ProviderGenericOIDC) supportsPKCE(ctx context.Context) bool {
+	p, err := g.provider(ctx)
+	if err != nil {
+		return false
+	}
+
+	var discovery struct {
+		CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported"`
+	}
+	if err := p.Claims(&discovery); err != nil {
+		return false
+	}
+
+	return stringslice.Has(discovery.CodeChallengeMethodsSupported, pkceMethodS256)
+}
@@ -0,0 +1,148 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/herodot"
+)
+
+func newPKCEDiscoveryServer(t *testing.T, methods []string) *httptest.Server {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			require.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{
+				"issuer":                           ts.URL,
+				"authorization_endpoint":           ts.URL + "/oauth2/auth",
+				"token_endpoint":                   ts.URL + "/oauth2/token",
+				"jwks_uri":                         ts.URL + "/.well-known/jwks.json",
+				"code_challenge_methods_supported": methods,
+			}))
+		case "/oauth2/token":
+			require.NoError(t, r.ParseForm())
+			if pkceChallenge(r.PostForm.Get("code_verifier")) != r.PostForm.Get("code") {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"access_token":"access-token","token_type":"bearer"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func newPKCEProvider(t *testing.T, issuer, pkce string) *ProviderGenericOIDC {
+	public, err := url.Parse("https://www.ory.sh")
+	require.NoError(t, err)
+	return NewProviderGenericOIDC(&Configuration{
+		Provider:     "generic",
+		ID:           "valid",
+		ClientID:     "client",
+		ClientSecret: "secret",
+		IssuerURL:    issuer,
+		PKCE:         pkce,
+	}, public)
+}
+
+func TestPKCE(t *testing.T) {
+	ctx := context.Background()
+	supported := newPKCEDiscoveryServer(t, []string{"plain", "S256"})
+	unsupported := newPKCEDiscoveryServer(t, []string{"plain"})
+
+	t.Run("case=should use PKCE depending on the configuration and discovery", func(t *testing.T) {
+		for _, tc := range []struct {
+			issuer   string
+			pkce     string
+			expected bool
+		}{
+			{issuer: supported.URL, pkce: "", expected: true},
+			{issuer: supported.URL, pkce: PKCEAuto, expected: true},
+			{issuer: supported.URL, pkce: PKCENever, expected: false},
+			{issuer: unsupported.URL, pkce: PKCEAuto, expected: false},
+			{issuer: unsupported.URL, pkce: PKCEForce, expected: true},
+		} {
+			t.Run("issuer="+tc.issuer+"/pkce="+tc.pkce, func(t *testing.T) {
+				assert.Equal(t, tc.expected, usePKCE(ctx, newPKCEProvider(t, tc.issuer, tc.pkce)))
+			})
+		}
+	})
+
+	t.Run("case=should not use PKCE if the provider can not be discovered", func(t *testing.T) {
+		assert.False(t, usePKCE(ctx, newPKCEProvider(t, "http://127.0.0.1:1", PKCEAuto)))
+	})
+
+	t.Run("case=should reject unknown configuration values", func(t *testing.T) {
+		require.Error(t, Configuration{ID: "valid", PKCE: "sometimes"}.validate())
+		for _, pkce := range []string{"", PKCEAuto, PKCEForce, PKCENever} {
+			require.NoError(t, Configuration{ID: "valid", PKCE: pkce}.validate())
+		}
+	})
+
+	t.Run("case=should send the challenge and the verifier", func(t *testing.T) {
+		p := newPKCEProvider(t, supported.URL, PKCEAuto)
+		c, err := p.OAuth2(ctx)
+		require.NoError(t, err)
+
+		verifier := newPKCEVerifier(ctx, p)
+		assert.Len(t, verifier, 64)
+		assert.NotEqual(t, verifier, newPKCEVerifier(ctx, p))
+
+		authURL, err := url.Parse(c.AuthCodeURL("state", pkceAuthCodeURLOptions(verifier)...))
+		require.NoError(t, err)
+		challenge := authURL.Query().Get("code_challenge")
+		assert.Equal(t, pkceChallenge(verifier), challenge)
+		assert.NotEqual(t, verifier, challenge)
+		assert.Equal(t, "S256", authURL.Query().Get("code_challenge_method"))
+
+		// The stub token endpoint expects the code challenge as the code.
+		opts, err := pkceExchangeOptions(ctx, p, &authCodeContainer{PKCEVerifier: verifier})
+		require.NoError(t, err)
+		token, err := c.Exchange(ctx, challenge, opts...)
+		require.NoError(t, err)
+		assert.Equal(t, "access-token", token.AccessToken)
+
+		t.Run("case=should fail with the wrong verifier", func(t *testing.T) {
+			opts, err := pkceExchangeOptions(ctx, p, &authCodeContainer{PKCEVerifier: verifier + "x"})
+			require.NoError(t, err)
+			_, err = c.Exchange(ctx, challenge, opts...)
+			require.Error(t, err)
+		})
+	})
+
+	t.Run("case=should not send a challenge without PKCE", func(t *testing.T) {
+		p := newPKCEProvider(t, supported.URL, PKCENever)
+		c, err := p.OAuth2(ctx)
+		require.NoError(t, err)
+
+		verifier := newPKCEVerifier(ctx, p)
+		assert.Empty(t, verifier)
+
+		authURL, err := url.Parse(c.AuthCodeURL("state", pkceAuthCodeURLOptions(verifier)...))
+		require.NoError(t, err)
+		assert.Empty(t, authURL.Query().Get("code_challenge"))
+
+		opts, err := pkceExchangeOptions(ctx, p, &authCodeContainer{})
+		require.NoError(t, err)
+		assert.Empty(t, opts)
+	})
+
+	t.Run("case=should reject the callback if the verifier is missing", func(t *testing.T) {
+		_, err := pkceExchangeOptions(ctx, newPKCEProvider(t, supported.URL, PKCEAuto), &authCodeContainer{State: "state"})
+		require.ErrorIs(t, err, herodot.ErrBadRequest)
+		assert.Contains(t, errors.Cause(err).(*herodot.DefaultError).Reason(), "PKCE code verifier is missing")
+	})
+}
@@ -0,0 +1,146 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+
+	gooidc "github.com/coreos/go-oidc"
+	"github.com/form3tech-oss/jwt-go"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+
+	"github.com/ory/herodot"
+)
+
+// callbackClaimsDecoder is implemented by providers which pass claims in the callback instead of the ID token.
+type callbackClaimsDecoder interface {
+	decodeCallbackClaims(query url.Values, claims *Claims)
+}
+
+var (
+	_ Provider              = new(ProviderApple)
+	_ callbackClaimsDecoder = new(ProviderApple)
+)
+
+type ProviderApple struct {
+	*ProviderGenericOIDC
+}
+
+func NewProviderApple(
+	config *Configuration,
+	public *url.URL,
+) *ProviderApple {
+	config.IssuerURL = "https://appleid.apple.com"
+	return &ProviderApple{
+		ProviderGenericOIDC: &ProviderGenericOIDC{
+			config: config,
+			public: public,
+		},
+	}
+}
+
+func (a *ProviderApple) OAuth2(ctx context.Context) (*oauth2.Config, error) {
+	c, err := a.ProviderGenericOIDC.OAuth2(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(a.config.ApplePrivateKey) > 0 {
+		if c.ClientSecret, err = a.newClientSecret(time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// newClientSecret returns the client secret, a JSON Web Token signed with the private key of the Apple
+// developer account.
+//
+// See: https://developer.apple.com/documentation/sign_in_with_apple/generate_and_validate_tokens
+func (a *ProviderApple) newClientSecret(now time.Time) (string, error) {
+	key, err := jwt.ParseECPrivateKeyFromPEM([]byte(a.config.ApplePrivateKey))
+	if err != nil {
+		return "", errors.WithStack(herodot.ErrInternalServerError.WithReasonf(
+			`Unable to parse the private key of OpenID Connect Provider "%s": %s`, a.config.ID, err))
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.StandardClaims{
+		Audience:  []string{"https://appleid.apple.com"},
+		Issuer:    a.config.AppleTeamID,
+		Subject:   a.config.ClientID,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(time.Minute * 5).Unix(),
+	})
+	token.Header["kid"] = a.config.ApplePrivateKeyID
+
+	secret, err := token.SignedString(key)
+	if err != nil {
+		return "", errors.WithStack(herodot.ErrInternalServerError.WithReasonf(
+			`Unable to sign the client secret of OpenID Connect Provider "%s": %s`, a.config.ID, err))
+	}
+	return secret, nil
+}
+
+// AuthCodeURLOptions makes Apple post the callback because it only returns the name of the user
+// if the response mode is `form_post`.
+func (a *ProviderApple) AuthCodeURLOptions(r ider) []oauth2.AuthCodeOption {
+	return append(a.ProviderGenericOIDC.AuthCodeURLOptions(r), oauth2.SetAuthURLParam("response_mode", "form_post"))
+}
+
+func (a *ProviderApple) Claims(ctx context.Context, exchange *oauth2.Token) (*Claims, error) {
+	raw, ok := exchange.Extra("id_token").(string)
+	if !ok || len(raw) == 0 {
+		return nil, errors.WithStack(ErrIDTokenMissing)
+	}
+
+	p, err := a.provider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := p.Verifier(&gooidc.Config{ClientID: a.config.ClientID}).Verify(ctx, raw)
+	if err != nil {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithReasonf("%s", err))
+	}
+
+	// Apple returns `email_verified` as a string.
+	var claims struct {
+		Claims
+		EmailVerified interface{} `json:"email_verified"`
+	}
+	if err := token.Claims(&claims); err != nil {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithReasonf("%s", err))
+	}
+
+	claims.Claims.EmailVerified = claims.EmailVerified == true || claims.EmailVerified == "true"
+	return &claims.Claims, nil
+}
+
+// decodeCallbackClaims adds the name of the user to the claims. Apple does not include the name in the ID
+// token and only posts it to the callback when the user authorizes the application for the first time.
+// Traits are not updated on login, so later sign ins without the name keep the traits of the identity.
+func (a *ProviderApple) decodeCallbackClaims(query url.Values, claims *Claims) {
+	var user struct {
+		Name struct {
+			FirstName string `json:"firstName"`
+			LastName  string `json:"lastName"`
+		} `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(query.Get("user")), &user); err != nil {
+		return
+	}
+
+	if len(claims.GivenName) == 0 {
+		claims.GivenName = user.Name.FirstName
+	}
+	if len(claims.FamilyName) == 0 {
+		claims.FamilyName = user.Name.LastName
+	}
+	if len(claims.Name) == 0 {
+		claims.Name = strings.TrimSpace(user.Name.FirstName + " " + user.Name.LastName)
+	}
+}
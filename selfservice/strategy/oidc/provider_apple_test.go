@@ -0,0 +1,169 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+	"github.com/gorilla/sessions"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func newAppleProvider(t *testing.T, key string) *ProviderApple {
+	public, err := url.Parse("https://www.ory.sh")
+	require.NoError(t, err)
+	return NewProviderApple(&Configuration{
+		Provider:          "apple",
+		ID:                "apple",
+		ClientID:          "sh.ory.kratos",
+		AppleTeamID:       "KP76DQS54M",
+		ApplePrivateKeyID: "UX56C66723",
+		ApplePrivateKey:   key,
+	}, public)
+}
+
+func TestProviderApple_ClientSecret(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	p := newAppleProvider(t, string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})))
+
+	now := time.Now()
+	secret, err := p.newClientSecret(now)
+	require.NoError(t, err)
+
+	var claims jwt.StandardClaims
+	token, err := jwt.ParseWithClaims(secret, &claims, func(token *jwt.Token) (interface{}, error) {
+		return &key.PublicKey, nil
+	})
+	require.NoError(t, err)
+	require.True(t, token.Valid)
+
+	assert.Equal(t, "ES256", token.Header["alg"])
+	assert.Equal(t, "UX56C66723", token.Header["kid"])
+	assert.Equal(t, "KP76DQS54M", claims.Issuer)
+	assert.Equal(t, "sh.ory.kratos", claims.Subject)
+	assert.True(t, claims.VerifyAudience("https://appleid.apple.com", true))
+	assert.Equal(t, now.Unix(), claims.IssuedAt)
+	assert.True(t, claims.ExpiresAt > now.Unix())
+
+	t.Run("case=should fail with an invalid private key", func(t *testing.T) {
+		_, err := newAppleProvider(t, "not-a-key").newClientSecret(now)
+		require.Error(t, err)
+	})
+}
+
+func TestProviderApple_CallbackClaims(t *testing.T) {
+	p := newAppleProvider(t, "")
+
+	t.Run("case=should capture the name on the first sign in", func(t *testing.T) {
+		claims := &Claims{Subject: "001234.abc"}
+		p.decodeCallbackClaims(url.Values{"user": {`{"name":{"firstName":"Jane","lastName":"Doe"},"email":"jane@example.org"}`}}, claims)
+		assert.Equal(t, "Jane", claims.GivenName)
+		assert.Equal(t, "Doe", claims.FamilyName)
+		assert.Equal(t, "Jane Doe", claims.Name)
+	})
+
+	t.Run("case=should leave the claims alone on later sign ins", func(t *testing.T) {
+		claims := &Claims{Subject: "001234.abc"}
+		p.decodeCallbackClaims(url.Values{"code": {"foo"}}, claims)
+		assert.Equal(t, &Claims{Subject: "001234.abc"}, claims)
+
+		p.decodeCallbackClaims(url.Values{"user": {"not-json"}}, claims)
+		assert.Equal(t, &Claims{Subject: "001234.abc"}, claims)
+	})
+
+	t.Run("case=should not override claims from the ID token", func(t *testing.T) {
+		claims := &Claims{GivenName: "Janet"}
+		p.decodeCallbackClaims(url.Values{"user": {`{"name":{"firstName":"Jane","lastName":"Doe"}}`}}, claims)
+		assert.Equal(t, "Janet", claims.GivenName)
+		assert.Equal(t, "Doe", claims.FamilyName)
+	})
+
+	t.Run("case=should request the callback as form post", func(t *testing.T) {
+		c := p.oauth2ConfigFromEndpoint(oauth2.Endpoint{AuthURL: "https://appleid.apple.com/auth/authorize"})
+		assert.Contains(t, c.AuthCodeURL("state", p.AuthCodeURLOptions(nil)...), "response_mode=form_post")
+	})
+}
+
+// cookieDependencies provides the continuity cookie store which is all that redirectToGET and
+// restorePostedCallback need.
+type cookieDependencies struct {
+	dependencies
+	store sessions.Store
+}
+
+func (d *cookieDependencies) ContinuityCookieManager(context.Context) sessions.Store {
+	return d.store
+}
+
+func TestRedirectToGET(t *testing.T) {
+	s := NewStrategy(&cookieDependencies{store: sessions.NewCookieStore([]byte("secret-secret-secret-secret"))})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			s.redirectToGET(w, r, httprouter.Params{})
+			return
+		}
+
+		require.NoError(t, s.restorePostedCallback(w, r))
+		_, _ = w.Write([]byte(r.URL.RawQuery))
+	}))
+	t.Cleanup(ts.Close)
+
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	c := &http.Client{Jar: jar, CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	posted := url.Values{"code": {"code"}, "state": {"state"}, "id_token": {"id-token"}, "user": {`{"name":{"firstName":"Jane"}}`}}
+	res, err := c.Post(ts.URL+RouteBase+"/callback/apple?foo=bar", "application/x-www-form-urlencoded", strings.NewReader(posted.Encode()))
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	t.Run("case=redirects with only the state", func(t *testing.T) {
+		assert.Equal(t, http.StatusSeeOther, res.StatusCode)
+		location, err := url.Parse(res.Header.Get("Location"))
+		require.NoError(t, err)
+		assert.Equal(t, RouteBase+"/callback/apple", location.Path)
+		assert.Equal(t, url.Values{"foo": {"bar"}, "state": {"state"}}, location.Query())
+	})
+
+	t.Run("case=restores the posted values", func(t *testing.T) {
+		res, err := c.Get(ts.URL + res.Header.Get("Location"))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+
+		query, err := url.ParseQuery(string(body))
+		require.NoError(t, err)
+		assert.Equal(t, "bar", query.Get("foo"))
+		for k := range posted {
+			assert.Equal(t, posted.Get(k), query.Get(k), k)
+		}
+	})
+
+	t.Run("case=restores the posted values only once", func(t *testing.T) {
+		res, err := c.Get(ts.URL + RouteBase + "/callback/apple?state=state")
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "state=state", string(body))
+	})
+}
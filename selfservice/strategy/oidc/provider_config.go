@@ -2,13 +2,19 @@ package oidc
 
 import (
 	"encoding/json"
+	"net/http"
 	"net/url"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
 
 	"github.com/ory/herodot"
 
+	"github.com/ory/x/httpx"
+	"github.com/ory/x/logrusx"
 	"github.com/ory/x/urlx"
 )
 
@@ -25,6 +31,7 @@ type Configuration struct {
 	// - discord
 	// - slack
 	// - facebook
+	// - apple
 	Provider string `json:"provider"`
 
 	// Label represents an optional label which can be used in the UI generation.
@@ -55,7 +62,19 @@ type Configuration struct {
 	// `8eaef023-2b34-4da1-9baa-8bc8c9d6a490` or `contoso.onmicrosoft.com`.
 	Tenant string `json:"tenant"`
 
-	// Scope specifies optional requested permissions.
+	// AppleTeamID is the Team ID of the Apple developer account. It must be set together with
+	// ApplePrivateKeyID and ApplePrivateKey when `provider` is set to `apple`.
+	AppleTeamID string `json:"apple_team_id"`
+
+	// ApplePrivateKeyID is the Key ID of the private key which signs the client secret.
+	ApplePrivateKeyID string `json:"apple_private_key_id"`
+
+	// ApplePrivateKey is the PEM encoded private key which signs the client secret. If set, the client secret
+	// is generated from it and `client_secret` is ignored.
+	ApplePrivateKey string `json:"apple_private_key"`
+
+	// Scope specifies optional requested permissions. Each scope must be a scope token as defined in
+	// RFC 6749, Section 3.3.
 	Scope []string `json:"scope"`
 
 	// Mapper specifies the JSONNet code snippet which uses the OpenID Connect Provider's data (e.g. GitHub or Google
@@ -64,11 +83,63 @@ type Configuration struct {
 	// It can be either a URL (file://, http(s)://, base64://) or an inline JSONNet code snippet.
 	Mapper string `json:"mapper_url"`
 
+	// MapperSampleClaims are the claims which are passed to the Jsonnet mapper when the configuration is
+	// validated at startup. If empty, sample values for all standard claims are used.
+	MapperSampleClaims json.RawMessage `json:"mapper_sample_claims"`
+
 	// RequestedClaims string encoded json object that specifies claims and optionally their properties which should be
 	// included in the id_token or returned from the UserInfo Endpoint.
 	//
 	// More information: https://openid.net/specs/openid-connect-core-1_0.html#ClaimsParameter
 	RequestedClaims json.RawMessage `json:"requested_claims"`
+
+	// NormalizeSubject removes surrounding whitespace and an issuer prefix from the subject claim before it is used
+	// to identify the provider account. Enable this if the provider returns the subject inconsistently.
+	NormalizeSubject bool `json:"normalize_subject"`
+
+	// LowercaseSubject lowercases the subject claim before it is used to identify the provider account. Enable this
+	// if the provider returns the subject with inconsistent casing.
+	LowercaseSubject bool `json:"lowercase_subject"`
+
+	// RequiredClaims lists the claims which the provider must return. Authentication fails if one of them is missing
+	// or empty.
+	RequiredClaims []string `json:"required_claims"`
+
+	// ClaimDefaults sets the values of claims which the provider did not return before the claims are passed to the
+	// Jsonnet mapper. Use this for optional claims, such as `email_verified`, which some providers omit.
+	ClaimDefaults map[string]json.RawMessage `json:"claim_defaults"`
+
+	// PKCE configures whether the authorization code flow uses PKCE. It is either `auto` (default), `force`,
+	// or `never`. If set to `auto`, PKCE is used if the provider advertises support for it.
+	PKCE string `json:"pkce"`
+}
+
+// scopeToken matches a scope token as defined in RFC 6749, Section 3.3.
+var scopeToken = regexp.MustCompile(`^[\x21\x23-\x5B\x5D-\x7E]+$`)
+
+// validate returns an error if the requested scopes or claims are malformed. They would otherwise only be
+// rejected by the provider once a user tries to sign in.
+func (p Configuration) validate() error {
+	for _, scope := range p.Scope {
+		if !scopeToken.MatchString(scope) {
+			return errors.WithStack(herodot.ErrInternalServerError.WithReasonf(
+				`OpenID Connect Provider "%s" requests the scope "%s" which is empty or contains characters which are not allowed in scopes.`, p.ID, scope))
+		}
+	}
+
+	if claims := gjson.ParseBytes(p.RequestedClaims); claims.Exists() && claims.Type != gjson.Null && !claims.IsObject() {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf(
+			`The requested claims of OpenID Connect Provider "%s" must be a JSON object.`, p.ID))
+	}
+
+	switch p.PKCE {
+	case "", PKCEAuto, PKCEForce, PKCENever:
+	default:
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf(
+			`OpenID Connect Provider "%s" sets PKCE to "%s" but only "%s", "%s", and "%s" are supported.`, p.ID, p.PKCE, PKCEAuto, PKCEForce, PKCENever))
+	}
+
+	return nil
 }
 
 func (p Configuration) Redir(public *url.URL) string {
@@ -79,12 +150,65 @@ func (p Configuration) Redir(public *url.URL) string {
 
 type ConfigurationCollection struct {
 	Providers []Configuration `json:"providers"`
+
+	// Retry configures how failed calls to the providers' token and user info endpoints are retried.
+	Retry RetryConfiguration `json:"retry"`
+
+	// Linking configures whether provider accounts are linked to existing identities.
+	Linking LinkingConfiguration `json:"linking"`
+}
+
+// LinkingConfiguration configures the linking of provider accounts to existing identities with the same
+// email address. Only email addresses which the provider verified are matched, and the link is only added
+// once the user signed in with the existing identity's credentials.
+type LinkingConfiguration struct {
+	Enabled bool `json:"enabled"`
+}
+
+// RetryConfiguration configures the retries of calls to the providers' token and user info endpoints.
+// Network errors, 429 Too Many Requests, and 5xx responses are retried while all other errors, such as
+// an `invalid_grant` error, fail immediately.
+type RetryConfiguration struct {
+	// MaxAttempts is the number of attempts including the first one. Defaults to 3, set to 1 to disable retries.
+	MaxAttempts int `json:"max_attempts"`
+
+	// Timeout limits every attempt. Defaults to 10s.
+	Timeout string `json:"timeout"`
+}
+
+// HTTPClient returns a client which retries failed requests as configured.
+func (c RetryConfiguration) HTTPClient(l *logrusx.Logger) (*http.Client, error) {
+	attempts := 3
+	if c.MaxAttempts > 0 {
+		attempts = c.MaxAttempts
+	}
+
+	timeout := 10 * time.Second
+	if len(c.Timeout) > 0 {
+		var err error
+		if timeout, err = time.ParseDuration(c.Timeout); err != nil {
+			return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf(
+				`Unable to parse the OpenID Connect retry timeout "%s": %s`, c.Timeout, err))
+		}
+	}
+
+	return httpx.NewResilientClient(
+		httpx.ResilientClientWithMaxRetry(attempts-1),
+		httpx.ResilientClientWithMinxRetryWait(100*time.Millisecond),
+		httpx.ResilientClientWithMaxRetryWait(time.Second),
+		httpx.ResilientClientWithConnectionTimeout(timeout),
+		httpx.ResilientClientWithLogger(l),
+	).StandardClient(), nil
 }
 
 func (c ConfigurationCollection) Provider(id string, public *url.URL) (Provider, error) {
 	for k := range c.Providers {
 		p := c.Providers[k]
 		if p.ID == id {
+			if err := p.validate(); err != nil {
+				return nil, err
+			}
+
 			var providerNames []string
 			var addProviderName = func(pn string) string {
 				providerNames = append(providerNames, pn)
@@ -108,6 +232,8 @@ func (c ConfigurationCollection) Provider(id string, public *url.URL) (Provider,
 				return NewProviderSlack(&p, public), nil
 			case addProviderName("facebook"):
 				return NewProviderFacebook(&p, public), nil
+			case addProviderName("apple"):
+				return NewProviderApple(&p, public), nil
 			}
 			return nil, errors.Errorf("provider type %s is not supported, supported are: %v", p.Provider, providerNames)
 		}
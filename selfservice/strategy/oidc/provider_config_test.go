@@ -4,10 +4,17 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/ory/x/logrusx"
+	"github.com/ory/x/urlx"
 
 	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/identity"
@@ -30,3 +37,73 @@ func TestConfig(t *testing.T) {
 	require.Len(t, collection.Providers, 1)
 	assert.Equal(t, "generic", collection.Providers[0].Provider)
 }
+
+func TestConfigurationCollectionProvider(t *testing.T) {
+	public := urlx.ParseOrPanic("https://www.ory.sh")
+	provider := func(scope []string, claims string) error {
+		_, err := oidc.ConfigurationCollection{Providers: []oidc.Configuration{{
+			ID:              "provider-a",
+			Provider:        "generic",
+			Scope:           scope,
+			RequestedClaims: json.RawMessage(claims),
+		}}}.Provider("provider-a", public)
+		return err
+	}
+
+	require.NoError(t, provider([]string{"offline_access", "https://www.googleapis.com/auth/userinfo.email", "custom:scope"}, `{"id_token":{"email":null}}`))
+	require.NoError(t, provider(nil, ""))
+
+	for _, scope := range []string{"", "two scopes", `quo"te`, `back\slash`, "ünicode"} {
+		assert.Error(t, provider([]string{"profile", scope}, ""), "%q", scope)
+	}
+	assert.Error(t, provider(nil, `["email"]`))
+}
+
+func TestRetryConfiguration(t *testing.T) {
+	exchange := func(t *testing.T, c oidc.RetryConfiguration, responses ...int) (int, error) {
+		var hits int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			code := responses[int(atomic.AddInt32(&hits, 1)-1)%len(responses)]
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(code)
+			if code == http.StatusOK {
+				_, _ = w.Write([]byte(`{"access_token":"access-token","token_type":"bearer"}`))
+			} else {
+				_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+			}
+		}))
+		t.Cleanup(ts.Close)
+
+		client, err := c.HTTPClient(logrusx.New("", ""))
+		require.NoError(t, err)
+
+		// A fixed auth style prevents the oauth2 package from retrying with the other style on its own.
+		conf := &oauth2.Config{ClientID: "client", Endpoint: oauth2.Endpoint{TokenURL: ts.URL, AuthStyle: oauth2.AuthStyleInParams}}
+		_, err = conf.Exchange(context.WithValue(context.Background(), oauth2.HTTPClient, client), "code")
+		return int(atomic.LoadInt32(&hits)), err
+	}
+
+	t.Run("case=retries transient errors", func(t *testing.T) {
+		hits, err := exchange(t, oidc.RetryConfiguration{}, http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK)
+		require.NoError(t, err)
+		assert.Equal(t, 3, hits)
+	})
+
+	t.Run("case=gives up after the configured attempts", func(t *testing.T) {
+		hits, err := exchange(t, oidc.RetryConfiguration{MaxAttempts: 2}, http.StatusServiceUnavailable)
+		require.Error(t, err)
+		assert.Equal(t, 2, hits)
+	})
+
+	t.Run("case=does not retry terminal errors", func(t *testing.T) {
+		hits, err := exchange(t, oidc.RetryConfiguration{}, http.StatusBadRequest, http.StatusOK)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid_grant")
+		assert.Equal(t, 1, hits)
+	})
+
+	t.Run("case=rejects invalid timeouts", func(t *testing.T) {
+		_, err := oidc.RetryConfiguration{Timeout: "soon"}.HTTPClient(logrusx.New("", ""))
+		require.Error(t, err)
+	})
+}
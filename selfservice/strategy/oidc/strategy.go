@@ -20,14 +20,17 @@ import (
 	"github.com/julienschmidt/httprouter"
 	"github.com/pkg/errors"
 	"github.com/tidwall/gjson"
+	"golang.org/x/oauth2"
 
 	"github.com/ory/x/jsonx"
+	"github.com/ory/x/sqlcon"
 
 	"github.com/ory/x/fetcher"
 
 	"github.com/ory/herodot"
 	"github.com/ory/kratos/continuity"
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/hash"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/schema"
 	"github.com/ory/kratos/selfservice/errorx"
@@ -58,10 +61,13 @@ type dependencies interface {
 	x.LoggingProvider
 	x.CookieProvider
 	x.CSRFTokenGeneratorProvider
+	x.CSRFProvider
 	x.WriterProvider
 
 	identity.ValidationProvider
+	identity.SchemaResolverProvider
 	identity.PrivilegedPoolProvider
+	identity.ManagementProvider
 	identity.ActiveCredentialsCounterStrategyProvider
 
 	session.ManagementProvider
@@ -84,6 +90,9 @@ type dependencies interface {
 	settings.ErrorHandlerProvider
 	settings.FlowPersistenceProvider
 	settings.HookExecutorProvider
+	settings.CurrentPasswordLockoutProvider
+
+	hash.HashProvider
 
 	continuity.ManagementProvider
 }
@@ -108,6 +117,14 @@ type authCodeContainer struct {
 	FlowID string     `json:"flow_id"`
 	State  string     `json:"state"`
 	Form   url.Values `json:"form"`
+
+	// ReturnTo is the return_to of the flow which started the OpenID Connect round trip. It is restored if
+	// the callback continues with a different flow, for example with login because the identity already exists.
+	ReturnTo string `json:"return_to"`
+
+	// PKCEVerifier is the PKCE code verifier which is sent to the provider when exchanging the code. It is empty
+	// if the provider does not use PKCE.
+	PKCEVerifier string `json:"pkce_verifier,omitempty"`
 }
 
 func (s *Strategy) CountActiveCredentials(cc map[identity.CredentialsType]identity.Credentials) (count int, err error) {
@@ -119,13 +136,8 @@ func (s *Strategy) CountActiveCredentials(cc map[identity.CredentialsType]identi
 			}
 
 			for _, ider := range c.Identifiers {
-				parts := strings.Split(ider, ":")
-				if len(parts) != 2 {
-					continue
-				}
-
 				for _, prov := range conf.Providers {
-					if parts[0] == prov.Provider && parts[1] == prov.Subject && len(prov.Subject) > 1 && len(prov.Provider) > 1 {
+					if ider == prov.identifier() && len(prov.Subject) > 1 && len(prov.Provider) > 1 {
 						count++
 					}
 				}
@@ -140,6 +152,77 @@ func (s *Strategy) setRoutes(r *x.RouterPublic) {
 	if handle, _, _ := r.Lookup("GET", RouteCallback); handle == nil {
 		r.GET(RouteCallback, wrappedHandleCallback)
 	}
+
+	// Providers such as Apple post the callback (`response_mode=form_post`) from their own origin, so the
+	// request carries neither a CSRF token nor the continuity cookie.
+	if handle, _, _ := r.Lookup("POST", RouteCallback); handle == nil {
+		s.d.CSRFHandler().ExemptGlob(strings.Replace(RouteCallback, ":provider", "*", 1))
+		r.POST(RouteCallback, strategy.IsDisabled(s.d, s.ID().String(), s.redirectToGET))
+	}
+}
+
+// redirectToGET redirects a callback which was posted by the provider to the same URL using GET. The browser
+// then sends the continuity cookie which is not sent with cross-site POST requests. The posted values, which
+// include the code and the ID token, are kept in a short-lived cookie instead of the URL so that they do not end
+// up in the browser history or access logs. Only the state is added to the query.
+func (s *Strategy) redirectToGET(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	if err := r.ParseForm(); err != nil {
+		s.d.SelfServiceErrorManager().Forward(r.Context(), w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf("Unable to parse HTTP form request: %s", err.Error())))
+		return
+	}
+
+	state := r.PostForm.Get("state")
+	if state == "" {
+		s.d.SelfServiceErrorManager().Forward(r.Context(), w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf(`Unable to complete OpenID Connect flow because the OpenID Provider did not return the state parameter.`)))
+		return
+	}
+
+	// The error does not matter because the cookie is overwritten in any case.
+	cookie, _ := s.d.ContinuityCookieManager(r.Context()).New(r, callbackCookieName)
+	cookie.Options.MaxAge = int(callbackCookieLifespan.Seconds())
+	cookie.Values = map[interface{}]interface{}{state: r.PostForm.Encode()}
+	if err := cookie.Save(r, w); err != nil {
+		s.d.SelfServiceErrorManager().Forward(r.Context(), w, r, errors.WithStack(err))
+		return
+	}
+
+	dest := *r.URL
+	q := dest.Query()
+	q.Set("state", state)
+	dest.RawQuery = q.Encode()
+
+	http.Redirect(w, r, dest.String(), http.StatusSeeOther)
+}
+
+// restorePostedCallback adds the values of a callback which was posted by the provider to the query of the
+// request and removes them from the callback cookie. It does nothing if the callback was not posted.
+func (s *Strategy) restorePostedCallback(w http.ResponseWriter, r *http.Request) error {
+	state := r.URL.Query().Get("state")
+	if state == "" {
+		return nil
+	}
+
+	store := s.d.ContinuityCookieManager(r.Context())
+	encoded, err := x.SessionGetString(r, store, callbackCookieName, state)
+	if err != nil {
+		return nil
+	}
+
+	if err := x.SessionUnset(w, r, store, callbackCookieName); err != nil {
+		return err
+	}
+
+	posted, err := url.ParseQuery(encoded)
+	if err != nil {
+		return errors.WithStack(herodot.ErrBadRequest.WithReasonf("Unable to parse the posted OpenID Connect callback: %s", err.Error()))
+	}
+
+	q := r.URL.Query()
+	for k, v := range posted {
+		q[k] = v
+	}
+	r.URL.RawQuery = q.Encode()
+	return nil
 }
 
 func NewStrategy(d dependencies) *Strategy {
@@ -251,10 +334,12 @@ func (s *Strategy) alreadyAuthenticated(w http.ResponseWriter, r *http.Request,
 }
 
 func (s *Strategy) handleCallback(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-	var (
-		code = r.URL.Query().Get("code")
-		pid  = ps.ByName("provider")
-	)
+	pid := ps.ByName("provider")
+	if err := s.restorePostedCallback(w, r); err != nil {
+		s.d.SelfServiceErrorManager().Forward(r.Context(), w, r, s.handleError(w, r, nil, pid, nil, err))
+		return
+	}
+	code := r.URL.Query().Get("code")
 
 	req, cntnr, err := s.validateCallback(w, r)
 	if err != nil {
@@ -282,18 +367,38 @@ func (s *Strategy) handleCallback(w http.ResponseWriter, r *http.Request, ps htt
 		return
 	}
 
-	token, err := conf.Exchange(r.Context(), code)
+	ctx, err := s.retryContext(r.Context())
+	if err != nil {
+		s.forwardError(w, r, req, s.handleError(w, r, req, pid, nil, err))
+		return
+	}
+
+	opts, err := pkceExchangeOptions(ctx, provider, cntnr)
 	if err != nil {
 		s.forwardError(w, r, req, s.handleError(w, r, req, pid, nil, err))
 		return
 	}
 
-	claims, err := provider.Claims(r.Context(), token)
+	token, err := conf.Exchange(ctx, code, opts...)
 	if err != nil {
 		s.forwardError(w, r, req, s.handleError(w, r, req, pid, nil, err))
 		return
 	}
 
+	claims, err := provider.Claims(ctx, token)
+	if err != nil {
+		s.forwardError(w, r, req, s.handleError(w, r, req, pid, nil, err))
+		return
+	}
+	if d, ok := provider.(callbackClaimsDecoder); ok {
+		d.decodeCallbackClaims(r.URL.Query(), claims)
+	}
+	claims.Subject = canonicalSubject(provider.Config(), claims)
+	if err := requireClaims(provider.Config(), claims); err != nil {
+		s.forwardError(w, r, req, s.handleError(w, r, req, pid, nil, err))
+		return
+	}
+
 	switch a := req.(type) {
 	case *login.Flow:
 		if ff, err := s.processLogin(w, r, a, claims, provider, cntnr); err != nil {
@@ -331,8 +436,79 @@ func (s *Strategy) handleCallback(w http.ResponseWriter, r *http.Request, ps htt
 	}
 }
 
-func uid(provider, subject string) string {
-	return fmt.Sprintf("%s:%s", provider, subject)
+// requestReturnTo returns the return_to query parameter of a flow's request URL.
+func requestReturnTo(requestURL string) string {
+	u, err := url.Parse(requestURL)
+	if err != nil {
+		return ""
+	}
+	return u.Query().Get("return_to")
+}
+
+// withReturnTo sets the return_to query parameter of a flow's request URL. The request URL is returned
+// unchanged if returnTo is empty.
+func withReturnTo(requestURL, returnTo string) string {
+	if len(returnTo) == 0 {
+		return requestURL
+	}
+
+	u, err := url.Parse(requestURL)
+	if err != nil {
+		return requestURL
+	}
+
+	q := u.Query()
+	q.Set("return_to", returnTo)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// uid returns the credentials identifier of a provider account. The subject is qualified by
+// the issuer so that two issuers returning the same subject never collide and the account is
+// still found if the provider is renamed.
+func uid(issuer, subject string) string {
+	return fmt.Sprintf("%s|%s", issuer, subject)
+}
+
+// canonicalIssuer normalizes the issuer claim. Some providers, for example Google, return the
+// issuer with or without the scheme.
+func canonicalIssuer(issuer string) string {
+	issuer = strings.TrimRight(strings.TrimSpace(issuer), "/")
+	if len(issuer) > 0 && !strings.Contains(issuer, "://") {
+		issuer = "https://" + issuer
+	}
+	return issuer
+}
+
+// canonicalSubject normalizes the subject claim if the provider is configured to do so. The
+// subject is used verbatim otherwise, because provider accounts are looked up by the subject
+// which was stored when they were linked.
+func canonicalSubject(c *Configuration, claims *Claims) string {
+	subject := claims.Subject
+	if c.NormalizeSubject {
+		subject = strings.TrimSpace(subject)
+		if issuer := strings.TrimRight(claims.Issuer, "/"); len(issuer) > 0 && strings.HasPrefix(subject, issuer) {
+			if rest := strings.TrimPrefix(subject, issuer); len(rest) > 1 && strings.ContainsAny(rest[:1], "|:/#") {
+				subject = rest[1:]
+			}
+		}
+	}
+
+	if c.LowercaseSubject {
+		subject = strings.ToLower(subject)
+	}
+
+	return subject
+}
+
+// findProviderAccount returns the identity which linked the provider account. Provider accounts
+// which were linked before credentials were identified by the issuer are found by the provider ID.
+func (s *Strategy) findProviderAccount(ctx context.Context, account ProviderCredentialsConfig) (*identity.Identity, *identity.Credentials, error) {
+	i, c, err := s.d.PrivilegedIdentityPool().FindByCredentialsIdentifier(ctx, identity.CredentialsTypeOIDC, account.identifier())
+	if errors.Is(err, sqlcon.ErrNoRows) && len(account.Issuer) > 0 {
+		return s.d.PrivilegedIdentityPool().FindByCredentialsIdentifier(ctx, identity.CredentialsTypeOIDC, uid(account.Provider, account.Subject))
+	}
+	return i, c, err
 }
 
 func (s *Strategy) populateMethod(r *http.Request, c *container.Container, message func(provider string) *text.Message) error {
@@ -362,6 +538,22 @@ func (s *Strategy) Config(ctx context.Context) (*ConfigurationCollection, error)
 	return &c, nil
 }
 
+// retryContext returns a context whose HTTP client, which is used to exchange the code and to fetch the
+// claims, retries failed requests as configured in `retry`.
+func (s *Strategy) retryContext(ctx context.Context) (context.Context, error) {
+	c, err := s.Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := c.Retry.HTTPClient(s.d.Logger())
+	if err != nil {
+		return nil, err
+	}
+
+	return context.WithValue(ctx, oauth2.HTTPClient, client), nil
+}
+
 func (s *Strategy) provider(ctx context.Context, r *http.Request, id string) (Provider, error) {
 	if c, err := s.Config(ctx); err != nil {
 		return nil, err
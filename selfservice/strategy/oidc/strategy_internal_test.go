@@ -0,0 +1,130 @@
+package oidc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-jsonnet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/herodot"
+)
+
+func TestCanonicalSubject(t *testing.T) {
+	for k, tc := range []struct {
+		c        Configuration
+		claims   Claims
+		expected string
+	}{
+		{claims: Claims{Subject: "AbC123"}, expected: "AbC123"},
+		{claims: Claims{Subject: " AbC123\n"}, expected: " AbC123\n"},
+		{claims: Claims{Issuer: "https://issuer.example.com", Subject: "https://issuer.example.com|AbC123"}, expected: "https://issuer.example.com|AbC123"},
+		{c: Configuration{NormalizeSubject: true}, claims: Claims{Subject: " AbC123\n"}, expected: "AbC123"},
+		{c: Configuration{LowercaseSubject: true}, claims: Claims{Subject: "AbC123"}, expected: "abc123"},
+		{c: Configuration{NormalizeSubject: true}, claims: Claims{Issuer: "https://issuer.example.com/", Subject: "https://issuer.example.com/AbC123"}, expected: "AbC123"},
+		{c: Configuration{NormalizeSubject: true}, claims: Claims{Issuer: "https://issuer.example.com", Subject: "https://issuer.example.com|AbC123"}, expected: "AbC123"},
+		{c: Configuration{NormalizeSubject: true}, claims: Claims{Issuer: "https://issuer.example.com", Subject: "https://issuer.example.com.evil|AbC123"}, expected: "https://issuer.example.com.evil|AbC123"},
+		{c: Configuration{NormalizeSubject: true}, claims: Claims{Issuer: "https://issuer.example.com", Subject: "https://issuer.example.com/"}, expected: "https://issuer.example.com/"},
+		{c: Configuration{NormalizeSubject: true, LowercaseSubject: true}, claims: Claims{Issuer: "https://issuer.example.com", Subject: "https://issuer.example.com#AbC123"}, expected: "abc123"},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			assert.Equal(t, tc.expected, canonicalSubject(&tc.c, &tc.claims))
+		})
+	}
+
+	t.Run("case=subjects are qualified by the issuer", func(t *testing.T) {
+		assert.Equal(t, "https://issuer.example.com|1234", ProviderCredentialsConfig{Provider: "provider-a", Issuer: "https://issuer.example.com", Subject: "1234"}.identifier())
+		assert.Equal(t, "provider-a|1234", ProviderCredentialsConfig{Provider: "provider-a", Subject: "1234"}.identifier())
+	})
+}
+
+func TestCanonicalIssuer(t *testing.T) {
+	for in, expected := range map[string]string{
+		"":                             "",
+		"https://accounts.google.com":  "https://accounts.google.com",
+		"https://accounts.google.com/": "https://accounts.google.com",
+		"accounts.google.com":          "https://accounts.google.com",
+		"http://127.0.0.1:4444/":       "http://127.0.0.1:4444",
+	} {
+		assert.Equal(t, expected, canonicalIssuer(in), "%s", in)
+	}
+}
+
+func TestProviderCredentialsConfigMatches(t *testing.T) {
+	account := ProviderCredentialsConfig{Provider: "provider-a", Issuer: "https://issuer.example.com", Subject: "1234"}
+	for k, tc := range []struct {
+		linked   ProviderCredentialsConfig
+		expected bool
+	}{
+		{linked: account, expected: true},
+		{linked: ProviderCredentialsConfig{Provider: "renamed", Issuer: "https://issuer.example.com", Subject: "1234"}, expected: true},
+		{linked: ProviderCredentialsConfig{Provider: "provider-a", Subject: "1234"}, expected: true},
+		{linked: ProviderCredentialsConfig{Provider: "provider-b", Subject: "1234"}},
+		{linked: ProviderCredentialsConfig{Provider: "provider-a", Issuer: "https://other.example.com", Subject: "1234"}},
+		{linked: ProviderCredentialsConfig{Provider: "provider-a", Issuer: "https://issuer.example.com", Subject: "5678"}},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.linked.matches(account))
+		})
+	}
+}
+
+func TestReturnTo(t *testing.T) {
+	requestURL := "https://www.ory.sh/self-service/registration/browser?return_to=https%3A%2F%2Fwww.ory.sh%2Fafter%3Fa%3Db"
+	assert.Equal(t, "https://www.ory.sh/after?a=b", requestReturnTo(requestURL))
+	assert.Empty(t, requestReturnTo("https://www.ory.sh/self-service/registration/browser"))
+
+	callbackURL := "https://www.ory.sh/self-service/methods/oidc/callback/google?code=foo&state=bar"
+	actual := withReturnTo(callbackURL, "https://www.ory.sh/after?a=b")
+	assert.Equal(t, "https://www.ory.sh/after?a=b", requestReturnTo(actual))
+	assert.Contains(t, actual, "code=foo")
+	assert.Equal(t, callbackURL, withReturnTo(callbackURL, ""))
+}
+
+func TestClaimHandling(t *testing.T) {
+	c := &Configuration{
+		ID:             "provider-a",
+		RequiredClaims: []string{"email"},
+		ClaimDefaults:  map[string]json.RawMessage{"email_verified": json.RawMessage(`false`), "locale": json.RawMessage(`"en"`)},
+	}
+
+	reason := func(t *testing.T, err error) string {
+		var e *herodot.DefaultError
+		require.True(t, errors.As(err, &e), "%+v", err)
+		return e.Reason()
+	}
+
+	t.Run("case=passes if all required claims are present", func(t *testing.T) {
+		require.NoError(t, requireClaims(c, &Claims{Subject: "1234", Email: "foo@ory.sh"}))
+		require.NoError(t, requireClaims(&Configuration{}, &Claims{Subject: "1234"}))
+	})
+
+	t.Run("case=names the missing required claim", func(t *testing.T) {
+		err := requireClaims(c, &Claims{Subject: "1234"})
+		require.Error(t, err)
+		assert.Contains(t, reason(t, err), `did not return the required claim "email"`)
+	})
+
+	t.Run("case=sets defaults for missing claims only", func(t *testing.T) {
+		raw, err := claimsWithDefaults(c, &Claims{Subject: "1234", Locale: "de"})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"sub":"1234","locale":"de","email_verified":false}`, string(raw))
+	})
+
+	t.Run("case=names the claim the mapper could not find", func(t *testing.T) {
+		raw, err := claimsWithDefaults(&Configuration{ID: "provider-a"}, &Claims{Subject: "1234"})
+		require.NoError(t, err)
+
+		vm := jsonnet.MakeVM()
+		vm.ExtCode("claims", string(raw))
+		_, err = vm.EvaluateSnippet("mapper", `local claims = std.extVar('claims'); {verified: claims.email_verified}`)
+		require.Error(t, err)
+		assert.Contains(t, reason(t, mapperError(c, err)), `did not return the claim "email_verified"`)
+
+		other := errors.New("some other error")
+		assert.Equal(t, other, mapperError(c, other))
+	})
+}
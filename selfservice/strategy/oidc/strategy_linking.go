@@ -0,0 +1,153 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/sqlcon"
+	"github.com/ory/x/stringslice"
+	"github.com/ory/x/stringsx"
+
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/schema"
+	"github.com/ory/kratos/selfservice/flow"
+	"github.com/ory/kratos/selfservice/flow/login"
+	"github.com/ory/kratos/selfservice/flow/registration"
+	"github.com/ory/kratos/text"
+	"github.com/ory/kratos/x"
+)
+
+var _ login.LinkableStrategy = new(Strategy)
+
+// linkToExistingIdentity starts linking the provider account to an existing identity with the same email address
+// if `linking` is enabled and the provider verified the email address. Instead of signing up a new identity, the
+// browser is redirected to a login flow which links the provider account once the existing identity signed in.
+// It returns false if the provider account can not be linked and a new identity should be signed up instead.
+func (s *Strategy) linkToExistingIdentity(w http.ResponseWriter, r *http.Request, a *registration.Flow, claims *Claims, provider Provider, container *authCodeContainer) (*login.Flow, bool, error) {
+	conf, err := s.Config(r.Context())
+	if err != nil {
+		return nil, false, s.handleError(w, r, a, provider.Config().ID, nil, err)
+	}
+
+	// Unverified email addresses are never linked, otherwise anyone could take over an account by signing up
+	// at a provider with someone else's email address.
+	if !conf.Linking.Enabled || len(claims.Email) == 0 || !claims.EmailVerified {
+		return nil, false, nil
+	}
+
+	identityID, err := s.findIdentityByEmail(r.Context(), claims.Email)
+	if errors.Is(err, sqlcon.ErrNoRows) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, s.handleError(w, r, a, provider.Config().ID, nil, err)
+	}
+
+	s.d.Logger().WithRequest(r).WithField("provider", provider.Config().ID).
+		WithField("subject", claims.Subject).
+		WithField("identity_id", identityID).
+		Debug("Received successful OpenID Connect callback for an email address which belongs to an existing identity. Re-initializing login flow to link the provider account.")
+
+	creds, err := NewCredentials(provider.Config().ID, canonicalIssuer(claims.Issuer), claims.Subject)
+	if err != nil {
+		return nil, false, s.handleError(w, r, a, provider.Config().ID, nil, err)
+	}
+
+	// This endpoint only handles browser flow at the moment.
+	ar, err := s.d.LoginHandler().NewLoginFlow(w, r, flow.TypeBrowser)
+	if err != nil {
+		return nil, false, s.handleError(w, r, a, provider.Config().ID, nil, err)
+	}
+
+	ar.RequestURL = withReturnTo(ar.RequestURL, container.ReturnTo)
+	ar.UI.Messages.Add(text.NewInfoLoginLinkCredentials(stringsx.Coalesce(provider.Config().Label, provider.Config().ID)))
+	if err := ar.SetCredentialsToLink(&login.CredentialsToLink{
+		IdentityID: identityID,
+		Type:       s.ID(),
+		Config:     json.RawMessage(creds.Config),
+	}); err != nil {
+		return ar, false, err
+	}
+
+	if err := s.d.LoginFlowPersister().UpdateLoginFlow(r.Context(), ar); err != nil {
+		return ar, false, err
+	}
+
+	http.Redirect(w, r, ar.AppendTo(s.d.Config(r.Context()).SelfServiceFlowLoginUI()).String(), http.StatusFound)
+	return nil, true, nil
+}
+
+// findIdentityByEmail returns the identity with the email address as a verifiable address or as password identifier.
+func (s *Strategy) findIdentityByEmail(ctx context.Context, email string) (uuid.UUID, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	address, err := s.d.PrivilegedIdentityPool().FindVerifiableAddressByValue(ctx, identity.VerifiableAddressTypeEmail, email)
+	if err == nil {
+		return address.IdentityID, nil
+	} else if !errors.Is(err, sqlcon.ErrNoRows) {
+		return uuid.Nil, err
+	}
+
+	i, _, err := s.d.PrivilegedIdentityPool().FindByCredentialsIdentifier(ctx, identity.CredentialsTypePassword, email)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return i.ID, nil
+}
+
+// LinkCredentials adds the provider accounts of the credentials configuration to the identity. It is called once
+// the identity confirmed the link by signing in with its existing credentials.
+func (s *Strategy) LinkCredentials(r *http.Request, i *identity.Identity, config json.RawMessage) error {
+	var link CredentialsConfig
+	if err := json.Unmarshal(config, &link); err != nil {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReason("The OpenID Connect credentials to link could not be decoded properly").WithDebug(err.Error()))
+	}
+
+	original, err := s.d.PrivilegedIdentityPool().GetIdentityConfidential(r.Context(), i.ID)
+	if err != nil {
+		return err
+	}
+
+	updated, err := s.d.PrivilegedIdentityPool().GetIdentityConfidential(r.Context(), i.ID)
+	if err != nil {
+		return err
+	}
+
+	var conf CredentialsConfig
+	creds, err := updated.ParseCredentials(s.ID(), &conf)
+	if errors.Is(err, herodot.ErrNotFound) {
+		creds = &identity.Credentials{Type: s.ID()}
+	} else if err != nil {
+		return err
+	}
+
+	for _, p := range link.Providers {
+		if stringslice.Has(creds.Identifiers, p.identifier()) {
+			continue
+		}
+		creds.Identifiers = append(creds.Identifiers, p.identifier())
+		conf.Providers = append(conf.Providers, p)
+	}
+
+	creds.Config, err = json.Marshal(conf)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	updated.SetCredentials(s.ID(), *creds)
+	if err := s.d.IdentityManager().Update(r.Context(), updated, identity.ManagerAllowWriteProtectedTraits); err != nil {
+		if errors.Is(err, sqlcon.ErrUniqueViolation) {
+			// The provider account was linked to another identity in the meantime.
+			return schema.NewDuplicateCredentialsError()
+		}
+		return err
+	}
+
+	ip := x.ClientIPString(r, s.d.Config(r.Context()).TrustedProxies())
+	return s.d.IdentityManager().RecordCredentialChanges(r.Context(), original, updated, ip)
+}
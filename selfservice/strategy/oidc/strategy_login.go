@@ -39,7 +39,8 @@ func (s *Strategy) PopulateLoginMethod(r *http.Request, l *login.Flow) error {
 }
 
 func (s *Strategy) processLogin(w http.ResponseWriter, r *http.Request, a *login.Flow, claims *Claims, provider Provider, container *authCodeContainer) (*registration.Flow, error) {
-	i, c, err := s.d.PrivilegedIdentityPool().FindByCredentialsIdentifier(r.Context(), identity.CredentialsTypeOIDC, uid(provider.Config().ID, claims.Subject))
+	account := newProviderCredentialsConfig(provider, claims)
+	i, c, err := s.findProviderAccount(r.Context(), account)
 	if err != nil {
 		if errors.Is(err, sqlcon.ErrNoRows) {
 			// If no account was found we're "manually" creating a new registration flow and redirecting the browser
@@ -61,6 +62,13 @@ func (s *Strategy) processLogin(w http.ResponseWriter, r *http.Request, a *login
 				return nil, s.handleError(w, r, a, provider.Config().ID, nil, err)
 			}
 
+			// The registration flow was initialized by the callback and would otherwise lose the return_to
+			// of the login flow.
+			aa.RequestURL = withReturnTo(aa.RequestURL, container.ReturnTo)
+			if err := s.d.RegistrationFlowPersister().UpdateRegistrationFlow(r.Context(), aa); err != nil {
+				return nil, s.handleError(w, r, a, provider.Config().ID, nil, err)
+			}
+
 			if _, err := s.processRegistration(w, r, aa, claims, provider, container); err != nil {
 				return aa, err
 			}
@@ -76,8 +84,12 @@ func (s *Strategy) processLogin(w http.ResponseWriter, r *http.Request, a *login
 		return nil, s.handleError(w, r, a, provider.Config().ID, nil, errors.WithStack(herodot.ErrInternalServerError.WithReason("The password credentials could not be decoded properly").WithDebug(err.Error())))
 	}
 
-	for _, c := range o.Providers {
-		if c.Subject == claims.Subject && c.Provider == provider.Config().ID {
+	for k, c := range o.Providers {
+		if c.matches(account) {
+			if len(c.Issuer) == 0 && len(account.Issuer) > 0 {
+				s.identifyByIssuer(r, i, o, k, account.Issuer)
+			}
+
 			if err = s.d.LoginHookExecutor().PostLoginHook(w, r, identity.CredentialsTypeOIDC, a, i); err != nil {
 				return nil, s.handleError(w, r, a, provider.Config().ID, nil, err)
 			}
@@ -122,16 +134,47 @@ func (s *Strategy) Login(w http.ResponseWriter, r *http.Request, f *login.Flow)
 	}
 
 	state := x.NewUUID().String()
+	verifier := newPKCEVerifier(r.Context(), provider)
 	if err := s.d.ContinuityManager().Pause(r.Context(), w, r, sessionName,
 		continuity.WithPayload(&authCodeContainer{
-			State:  state,
-			FlowID: f.ID.String(),
-			Form:   r.PostForm,
+			State:        state,
+			FlowID:       f.ID.String(),
+			Form:         r.PostForm,
+			ReturnTo:     requestReturnTo(f.RequestURL),
+			PKCEVerifier: verifier,
 		}),
 		continuity.WithLifespan(time.Minute*30)); err != nil {
 		return nil, s.handleError(w, r, f, pid, nil, err)
 	}
 
-	http.Redirect(w, r, c.AuthCodeURL(state, provider.AuthCodeURLOptions(req)...), http.StatusFound)
+	http.Redirect(w, r, c.AuthCodeURL(state, append(provider.AuthCodeURLOptions(req), pkceAuthCodeURLOptions(verifier)...)...), http.StatusFound)
 	return nil, errors.WithStack(flow.ErrCompletedByStrategy)
 }
+
+// identifyByIssuer stores the issuer of a provider account which was linked before credentials were identified
+// by the issuer. Failures are only logged because the provider account is still found by the provider ID.
+func (s *Strategy) identifyByIssuer(r *http.Request, i *identity.Identity, conf CredentialsConfig, k int, issuer string) {
+	creds, ok := i.GetCredentials(s.ID())
+	if !ok {
+		return
+	}
+
+	conf.Providers[k].Issuer = issuer
+	creds.Identifiers = make([]string, len(conf.Providers))
+	for k, p := range conf.Providers {
+		creds.Identifiers[k] = p.identifier()
+	}
+
+	config, err := json.Marshal(conf)
+	if err != nil {
+		s.d.Logger().WithRequest(r).WithError(err).Warn("Unable to encode the OpenID Connect credentials.")
+		return
+	}
+	creds.Config = config
+
+	i.SetCredentials(s.ID(), *creds)
+	if err := s.d.PrivilegedIdentityPool().UpdateIdentity(r.Context(), i); err != nil {
+		s.d.Logger().WithRequest(r).WithError(err).WithField("identity_id", i.ID).
+			Warn("Unable to identify the OpenID Connect provider account by its issuer.")
+	}
+}
@@ -1,7 +1,6 @@
 package oidc
 
 import (
-	"bytes"
 	"encoding/json"
 	"net/http"
 	"time"
@@ -15,10 +14,8 @@ import (
 	"github.com/ory/herodot"
 	"github.com/ory/kratos/continuity"
 
-	"github.com/google/go-jsonnet"
 	"github.com/tidwall/gjson"
 
-	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/selfservice/flow"
 	"github.com/ory/kratos/selfservice/flow/registration"
@@ -84,23 +81,26 @@ func (s *Strategy) Register(w http.ResponseWriter, r *http.Request, f *registrat
 	}
 
 	state := x.NewUUID().String()
+	verifier := newPKCEVerifier(r.Context(), provider)
 	if err := s.d.ContinuityManager().Pause(r.Context(), w, r, sessionName,
 		continuity.WithPayload(&authCodeContainer{
-			State:  state,
-			FlowID: f.ID.String(),
-			Form:   r.PostForm,
+			State:        state,
+			FlowID:       f.ID.String(),
+			Form:         r.PostForm,
+			ReturnTo:     requestReturnTo(f.RequestURL),
+			PKCEVerifier: verifier,
 		}),
 		continuity.WithLifespan(time.Minute*30)); err != nil {
 		return s.handleError(w, r, f, pid, nil, err)
 	}
 
-	http.Redirect(w, r, c.AuthCodeURL(state, provider.AuthCodeURLOptions(req)...), http.StatusFound)
+	http.Redirect(w, r, c.AuthCodeURL(state, append(provider.AuthCodeURLOptions(req), pkceAuthCodeURLOptions(verifier)...)...), http.StatusFound)
 
 	return errors.WithStack(flow.ErrCompletedByStrategy)
 }
 
 func (s *Strategy) processRegistration(w http.ResponseWriter, r *http.Request, a *registration.Flow, claims *Claims, provider Provider, container *authCodeContainer) (*login.Flow, error) {
-	if _, _, err := s.d.PrivilegedIdentityPool().FindByCredentialsIdentifier(r.Context(), identity.CredentialsTypeOIDC, uid(provider.Config().ID, claims.Subject)); err == nil {
+	if _, _, err := s.findProviderAccount(r.Context(), newProviderCredentialsConfig(provider, claims)); err == nil {
 		// If the identity already exists, we should perform the login flow instead.
 
 		// That will execute the "pre registration" hook which allows to e.g. disallow this flow. The registration
@@ -120,29 +120,45 @@ func (s *Strategy) processRegistration(w http.ResponseWriter, r *http.Request, a
 			return nil, s.handleError(w, r, a, provider.Config().ID, nil, err)
 		}
 
+		// The login flow was initialized by the callback and would otherwise lose the return_to of the
+		// registration flow.
+		ar.RequestURL = withReturnTo(ar.RequestURL, container.ReturnTo)
+		if err := s.d.LoginFlowPersister().UpdateLoginFlow(r.Context(), ar); err != nil {
+			return nil, s.handleError(w, r, a, provider.Config().ID, nil, err)
+		}
+
 		if _, err := s.processLogin(w, r, ar, claims, provider, container); err != nil {
 			return ar, err
 		}
 		return nil, nil
 	}
 
+	if ar, linking, err := s.linkToExistingIdentity(w, r, a, claims, provider, container); err != nil {
+		return ar, err
+	} else if linking {
+		return nil, nil
+	}
+
 	jn, err := s.f.Fetch(provider.Config().Mapper)
 	if err != nil {
 		return nil, s.handleError(w, r, a, provider.Config().ID, nil, err)
 	}
 
-	var jsonClaims bytes.Buffer
-	if err := json.NewEncoder(&jsonClaims).Encode(claims); err != nil {
+	jsonClaims, err := claimsWithDefaults(provider.Config(), claims)
+	if err != nil {
 		return nil, s.handleError(w, r, a, provider.Config().ID, nil, err)
 	}
 
-	i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
-
-	vm := jsonnet.MakeVM()
-	vm.ExtCode("claims", jsonClaims.String())
-	evaluated, err := vm.EvaluateSnippet(provider.Config().Mapper, jn.String())
+	ts, err := s.d.IdentitySchemaResolver().TraitsSchemaForRequest(r)
 	if err != nil {
 		return nil, s.handleError(w, r, a, provider.Config().ID, nil, err)
+	}
+
+	i := identity.NewIdentity(ts.ID)
+
+	evaluated, err := evaluateMapper(provider.Config(), jn.String(), jsonClaims)
+	if err != nil {
+		return nil, s.handleError(w, r, a, provider.Config().ID, nil, mapperError(provider.Config(), err))
 	} else if traits := gjson.Get(evaluated, "identity.traits"); !traits.IsObject() {
 		i.Traits = []byte{'{', '}'}
 		s.d.Logger().
@@ -164,7 +180,7 @@ func (s *Strategy) processRegistration(w http.ResponseWriter, r *http.Request, a
 		WithField("mapper_jsonnet_url", provider.Config().Mapper).
 		Debug("OpenID Connect Jsonnet mapper completed.")
 
-	option, err := decoderRegistration(s.d.Config(r.Context()).DefaultIdentityTraitsSchemaURL().String())
+	option, err := decoderRegistration(ts.URL)
 	if err != nil {
 		return nil, s.handleError(w, r, a, provider.Config().ID, nil, err)
 	}
@@ -179,7 +195,7 @@ func (s *Strategy) processRegistration(w http.ResponseWriter, r *http.Request, a
 		return nil, s.handleError(w, r, a, provider.Config().ID, i.Traits, err)
 	}
 
-	creds, err := NewCredentials(provider.Config().ID, claims.Subject)
+	creds, err := NewCredentials(provider.Config().ID, canonicalIssuer(claims.Issuer), claims.Subject)
 	if err != nil {
 		return nil, s.handleError(w, r, a, provider.Config().ID, i.Traits, err)
 	}
@@ -10,6 +10,7 @@ import (
 	"github.com/ory/kratos/continuity"
 	"github.com/ory/kratos/selfservice/strategy"
 	"github.com/ory/x/decoderx"
+	"github.com/ory/x/sqlcon"
 
 	"github.com/ory/kratos/session"
 
@@ -21,6 +22,7 @@ import (
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/selfservice/flow"
 	"github.com/ory/kratos/selfservice/flow/settings"
+	"github.com/ory/kratos/ui/node"
 
 	"github.com/ory/kratos/x"
 )
@@ -148,6 +150,10 @@ func (s *Strategy) PopulateSettingsMethod(r *http.Request, id *identity.Identity
 		sr.UI.GetNodes().Append(NewUnlinkNode(l.Config().ID))
 	}
 
+	if len(linkable)+len(linked) > 0 && s.d.Config(r.Context()).SelfServiceFlowSettingsRequiresCurrentPassword(s.SettingsStrategyID()) {
+		sr.UI.Nodes.Upsert(settings.NewCurrentPasswordNode(node.OpenIDConnectGroup))
+	}
+
 	return nil
 }
 
@@ -169,6 +175,14 @@ type submitSelfServiceBrowserSettingsOIDCFlowPayload struct {
 	// in: body
 	Unlink string `json:"unlink"`
 
+	// CurrentPassword is the identity's current password
+	//
+	// Only required if the oidc method is listed in `selfservice.flows.settings.require_current_password`.
+	//
+	// type: string
+	// in: body
+	CurrentPassword string `json:"current_password"`
+
 	// Flow ID is the flow's ID.
 	//
 	// in: query
@@ -185,8 +199,9 @@ func (p *submitSelfServiceBrowserSettingsOIDCFlowPayload) SetFlowID(rid uuid.UUI
 
 func (s *Strategy) Settings(w http.ResponseWriter, r *http.Request, f *settings.Flow, ss *session.Session) (*settings.UpdateContext, error) {
 	var method struct {
-		Link   string `json:"link" form:"link"`
-		Unlink string `json:"unlink" form:"unlink"`
+		Link            string `json:"link" form:"link"`
+		Unlink          string `json:"unlink" form:"unlink"`
+		CurrentPassword string `json:"current_password" form:"current_password"`
 	}
 
 	compiler, err := decoderx.HTTPRawJSONSchemaCompiler(settingsSchema)
@@ -233,6 +248,7 @@ func (s *Strategy) Settings(w http.ResponseWriter, r *http.Request, f *settings.
 	}
 	p.Link = method.Link
 	p.Unlink = method.Unlink
+	p.CurrentPassword = method.CurrentPassword
 
 	if !s.d.Config(r.Context()).SelfServiceStrategy(s.SettingsStrategyID()).Enabled {
 		return nil, errors.WithStack(herodot.ErrNotFound.WithReason(strategy.EndpointDisabledMessage))
@@ -300,6 +316,14 @@ func (s *Strategy) initLinkProvider(w http.ResponseWriter, r *http.Request, ctxU
 		return s.handleSettingsError(w, r, ctxUpdate, p, errors.WithStack(settings.NewFlowNeedsReAuth()))
 	}
 
+	if err := settings.RequireVerifiedAddress(r.Context(), s.d, s.SettingsStrategyID(), ctxUpdate.Session.Identity.ID); err != nil {
+		return s.handleSettingsError(w, r, ctxUpdate, p, err)
+	}
+
+	if err := settings.VerifyCurrentPassword(r.Context(), s.d, s.SettingsStrategyID(), ctxUpdate.Session.Identity.ID, p.CurrentPassword); err != nil {
+		return s.handleSettingsError(w, r, ctxUpdate, p, err)
+	}
+
 	provider, err := s.provider(r.Context(), r, p.Link)
 	if err != nil {
 		return s.handleSettingsError(w, r, ctxUpdate, p, err)
@@ -316,17 +340,19 @@ func (s *Strategy) initLinkProvider(w http.ResponseWriter, r *http.Request, ctxU
 	}
 
 	state := x.NewUUID().String()
+	verifier := newPKCEVerifier(r.Context(), provider)
 	if err := s.d.ContinuityManager().Pause(r.Context(), w, r, sessionName,
 		continuity.WithPayload(&authCodeContainer{
-			State:  state,
-			FlowID: ctxUpdate.Flow.ID.String(),
-			Form:   r.PostForm,
+			State:        state,
+			FlowID:       ctxUpdate.Flow.ID.String(),
+			Form:         r.PostForm,
+			PKCEVerifier: verifier,
 		}),
 		continuity.WithLifespan(time.Minute*30)); err != nil {
 		return s.handleSettingsError(w, r, ctxUpdate, p, err)
 	}
 
-	http.Redirect(w, r, c.AuthCodeURL(state, provider.AuthCodeURLOptions(req)...), http.StatusFound)
+	http.Redirect(w, r, c.AuthCodeURL(state, append(provider.AuthCodeURLOptions(req), pkceAuthCodeURLOptions(verifier)...)...), http.StatusFound)
 	return errors.WithStack(flow.ErrCompletedByStrategy)
 }
 
@@ -342,19 +368,27 @@ func (s *Strategy) linkProvider(w http.ResponseWriter, r *http.Request, ctxUpdat
 		return s.handleSettingsError(w, r, ctxUpdate, p, err)
 	}
 
+	// Provider accounts which were linked before credentials were identified by the issuer are not caught
+	// by the unique identifiers.
+	account := newProviderCredentialsConfig(provider, claims)
+	if _, _, err := s.findProviderAccount(r.Context(), account); err == nil {
+		return s.handleSettingsError(w, r, ctxUpdate, p, errors.WithStack(ConnectionExistValidationError))
+	} else if !errors.Is(err, sqlcon.ErrNoRows) {
+		return s.handleSettingsError(w, r, ctxUpdate, p, err)
+	}
+
 	var conf CredentialsConfig
 	creds, err := i.ParseCredentials(s.ID(), &conf)
 	if errors.Is(err, herodot.ErrNotFound) {
 		var err error
-		if creds, err = NewCredentials(provider.Config().ID, claims.Subject); err != nil {
+		if creds, err = NewCredentials(account.Provider, account.Issuer, account.Subject); err != nil {
 			return s.handleSettingsError(w, r, ctxUpdate, p, err)
 		}
 	} else if err != nil {
 		return s.handleSettingsError(w, r, ctxUpdate, p, err)
 	} else {
-		creds.Identifiers = append(creds.Identifiers, uid(provider.Config().ID, claims.Subject))
-		conf.Providers = append(conf.Providers, ProviderCredentialsConfig{
-			Subject: claims.Subject, Provider: provider.Config().ID})
+		creds.Identifiers = append(creds.Identifiers, account.identifier())
+		conf.Providers = append(conf.Providers, account)
 
 		creds.Config, err = json.Marshal(conf)
 		if err != nil {
@@ -377,6 +411,10 @@ func (s *Strategy) unlinkProvider(w http.ResponseWriter, r *http.Request, ctxUpd
 		return s.handleSettingsError(w, r, ctxUpdate, p, errors.WithStack(settings.NewFlowNeedsReAuth()))
 	}
 
+	if err := settings.VerifyCurrentPassword(r.Context(), s.d, s.SettingsStrategyID(), ctxUpdate.Session.Identity.ID, p.CurrentPassword); err != nil {
+		return s.handleSettingsError(w, r, ctxUpdate, p, err)
+	}
+
 	providers, err := s.Config(r.Context())
 	if err != nil {
 		return s.handleSettingsError(w, r, ctxUpdate, p, err)
@@ -405,7 +443,7 @@ func (s *Strategy) unlinkProvider(w http.ResponseWriter, r *http.Request, ctxUpd
 		if p.Unlink == available.Config().ID {
 			for _, link := range cc.Providers {
 				if link.Provider != p.Unlink {
-					updatedIdentifiers = append(updatedIdentifiers, uid(link.Provider, link.Subject))
+					updatedIdentifiers = append(updatedIdentifiers, link.identifier())
 					updatedProviders = append(updatedProviders, link)
 				} else {
 					found = true
@@ -82,10 +82,11 @@ func TestSettingsStrategy(t *testing.T) {
 		conf, reg = internal.NewFastRegistryWithMocks(t)
 		subject   string
 		website   string
+		verified  bool
 		scope     []string
 	)
 
-	remoteAdmin, remotePublic, _ := newHydra(t, &subject, &website, &scope)
+	remoteAdmin, remotePublic, _ := newHydra(t, &subject, &website, &verified, &scope)
 	uiTS := newUI(t, reg)
 	errTS := testhelpers.NewErrorTestServer(t, reg)
 	publicTS, adminTS := testhelpers.NewKratosServers(t)
@@ -115,13 +116,13 @@ func TestSettingsStrategy(t *testing.T) {
 			SchemaID: config.DefaultIdentityTraitsSchemaID,
 			Credentials: map[identity.CredentialsType]identity.Credentials{
 				identity.CredentialsTypeOIDC: {Type: identity.CredentialsTypeOIDC,
-					Identifiers: []string{"ory:hackerman+" + testID},
+					Identifiers: []string{"ory|hackerman+" + testID},
 					Config:      sqlxx.JSONRawMessage(`{"providers":[{"provider":"ory","subject":"hackerman+` + testID + `"}]}`)}},
 		},
 		"githuber": {ID: x.NewUUID(), Traits: identity.Traits(`{"email":"hackerman+github+` + testID + `@ory.sh"}`),
 			Credentials: map[identity.CredentialsType]identity.Credentials{
 				identity.CredentialsTypeOIDC: {Type: identity.CredentialsTypeOIDC,
-					Identifiers: []string{"ory:hackerman+github+" + testID, "github:hackerman+github+" + testID},
+					Identifiers: []string{"ory|hackerman+github+" + testID, "github|hackerman+github+" + testID},
 					Config:      sqlxx.JSONRawMessage(`{"providers":[{"provider":"ory","subject":"hackerman+github+` + testID + `"},{"provider":"github","subject":"hackerman+github+` + testID + `"}]}`)}},
 			SchemaID: config.DefaultIdentityTraitsSchemaID,
 		},
@@ -131,7 +132,7 @@ func TestSettingsStrategy(t *testing.T) {
 					Identifiers: []string{"hackerman+multiuser+" + testID + "@ory.sh"},
 					Config:      sqlxx.JSONRawMessage(`{"hashed_password":"$argon2id$iammocked...."}`)},
 				identity.CredentialsTypeOIDC: {Type: identity.CredentialsTypeOIDC,
-					Identifiers: []string{"ory:hackerman+multiuser+" + testID, "google:hackerman+multiuser+" + testID},
+					Identifiers: []string{"ory|hackerman+multiuser+" + testID, "google|hackerman+multiuser+" + testID},
 					Config:      sqlxx.JSONRawMessage(`{"providers":[{"provider":"ory","subject":"hackerman+multiuser+` + testID + `"},{"provider":"google","subject":"hackerman+multiuser+` + testID + `"}]}`)}},
 			SchemaID: config.DefaultIdentityTraitsSchemaID,
 		},
@@ -1584,6 +1585,33 @@ func TestSettingsStrategy(t *testing.T) {
 			})
 		})
 	})
+
+	t.Run("suite=current password", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceSettingsRequireCurrentPassword, []string{"oidc"})
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceSettingsRequireCurrentPassword, []string{})
+		})
+
+		agent := "multiuser"
+		for _, tc := range []struct {
+			d       string
+			current string
+			message string
+		}{
+			{d: "missing", current: "", message: "Property current_password is missing."},
+			{d: "wrong", current: "not-the-password", message: "The current password is incorrect."},
+		} {
+			for op, provider := range map[string]string{"link": "github", "unlink": "google"} {
+				t.Run("case=should not "+op+" a connection because the current password is "+tc.d, func(t *testing.T) {
+					req := nprSDK(t, agents[agent], "", time.Hour)
+					body, res := testhelpers.HTTPPostForm(t, agents[agent], action(req),
+						&url.Values{"csrf_token": {x.FakeCSRFToken}, op: {provider}, "current_password": {tc.current}})
+					assert.Contains(t, res.Request.URL.String(), uiTS.URL+"/settings?flow="+req.Id)
+					assert.Equal(t, tc.message, gjson.GetBytes(body, "ui.nodes.#(attributes.name==current_password).messages.0.text").String(), "%s", body)
+				})
+			}
+		}
+	})
 }
 
 func TestPopulateSettingsMethod(t *testing.T) {
@@ -37,6 +37,7 @@ import (
 	"github.com/ory/kratos/selfservice/flow/registration"
 
 	"github.com/ory/kratos/selfservice/strategy/oidc"
+	"github.com/ory/kratos/text"
 	"github.com/ory/kratos/x"
 )
 
@@ -50,10 +51,11 @@ func TestStrategy(t *testing.T) {
 	var (
 		conf, reg        = internal.NewFastRegistryWithMocks(t)
 		subject, website string
+		emailVerified    bool
 		scope            []string
 	)
 
-	remoteAdmin, remotePublic, hydraIntegrationTSURL := newHydra(t, &subject, &website, &scope)
+	remoteAdmin, remotePublic, hydraIntegrationTSURL := newHydra(t, &subject, &website, &emailVerified, &scope)
 	returnTS := newReturnTs(t, reg)
 	uiTS := newUI(t, reg)
 	errTS := testhelpers.NewErrorTestServer(t, reg)
@@ -306,6 +308,29 @@ func TestStrategy(t *testing.T) {
 		})
 	})
 
+	t.Run("case=login with an account linked before credentials were identified by the issuer", func(t *testing.T) {
+		subject = "login-legacy@ory.sh"
+		scope = []string{"openid"}
+
+		creds, err := oidc.NewCredentials("valid", "", subject)
+		require.NoError(t, err)
+		i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+		i.Traits = identity.Traits(`{"subject":"` + subject + `"}`)
+		i.SetCredentials(identity.CredentialsTypeOIDC, *creds)
+		require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(context.Background(), i))
+
+		r := newLoginFlow(t, returnTS.URL, time.Minute)
+		res, body := makeRequest(t, "valid", afv(t, r.ID, "valid"), url.Values{})
+		ai(t, res, body)
+		assert.Equal(t, i.ID.String(), gjson.GetBytes(body, "identity.id").String(), "%s", body)
+
+		actual, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(context.Background(), i.ID)
+		require.NoError(t, err)
+		actualCreds, ok := actual.GetCredentials(identity.CredentialsTypeOIDC)
+		require.True(t, ok)
+		assert.Equal(t, []string{strings.TrimRight(remotePublic, "/") + "|" + subject}, actualCreds.Identifiers)
+	})
+
 	t.Run("case=login without registered account", func(t *testing.T) {
 		subject = "login-without-register@ory.sh"
 		scope = []string{"openid"}
@@ -337,6 +362,36 @@ func TestStrategy(t *testing.T) {
 		})
 	})
 
+	t.Run("case=preserve return_to when switching flows", func(t *testing.T) {
+		subject = "switch-flows@ory.sh"
+		scope = []string{"openid"}
+
+		conf.MustSet(config.ViperKeyURLsWhitelistedReturnToDomains, []string{returnTS.URL})
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyURLsWhitelistedReturnToDomains, []string{})
+		})
+
+		var withReturnTo = func(route, path string) string {
+			return ts.URL + route + "?" + url.Values{"return_to": {returnTS.URL + path}}.Encode()
+		}
+
+		t.Run("case=login without registered account continues with registration", func(t *testing.T) {
+			r := newLoginFlow(t, withReturnTo(login.RouteInitBrowserFlow, "/after-login"), time.Minute)
+			action := afv(t, r.ID, "valid")
+			res, body := makeRequest(t, "valid", action, url.Values{})
+			ai(t, res, body)
+			assert.Equal(t, "/after-login", res.Request.URL.Path)
+		})
+
+		t.Run("case=registration with registered account continues with login", func(t *testing.T) {
+			r := newRegistrationFlow(t, withReturnTo(registration.RouteInitBrowserFlow, "/after-registration"), time.Minute)
+			action := afv(t, r.ID, "valid")
+			res, body := makeRequest(t, "valid", action, url.Values{})
+			ai(t, res, body)
+			assert.Equal(t, "/after-registration", res.Request.URL.Path)
+		})
+	})
+
 	t.Run("case=register, merge, and complete data", func(t *testing.T) {
 		subject = "incomplete-data@ory.sh"
 		scope = []string{"openid"}
@@ -393,6 +448,87 @@ func TestStrategy(t *testing.T) {
 		})
 	})
 
+	t.Run("case=should link to an existing identity", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceStrategyConfig+".oidc.config.linking.enabled", true)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceStrategyConfig+".oidc.config.linking.enabled", false)
+			emailVerified = false
+		})
+
+		var createPasswordIdentity = func(t *testing.T, identifier string) *identity.Identity {
+			i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+			i.SetCredentials(identity.CredentialsTypePassword, identity.Credentials{
+				Identifiers: []string{identifier},
+			})
+			i.Traits = identity.Traits(`{"subject":"` + identifier + `"}`)
+			require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(context.Background(), i))
+			return i
+		}
+
+		t.Run("case=should ask to sign in if the provider verified the email address", func(t *testing.T) {
+			subject = "link-verified@ory.sh"
+			scope = []string{"openid"}
+			emailVerified = true
+			i := createPasswordIdentity(t, subject)
+
+			r := newRegistrationFlow(t, returnTS.URL, time.Minute)
+			res, body := makeRequest(t, "valid", afv(t, r.ID, "valid"), url.Values{})
+			require.Contains(t, res.Request.URL.String(), uiTS.URL+"/login", "%s", body)
+			assert.Equal(t, int64(text.InfoSelfServiceLoginLinkCredentials), gjson.GetBytes(body, "ui.messages.0.id").Int(), "%s", body)
+
+			lf, err := reg.LoginFlowPersister().GetLoginFlow(context.Background(), x.ParseUUID(gjson.GetBytes(body, "id").String()))
+			require.NoError(t, err)
+			link, err := lf.CredentialsToLink()
+			require.NoError(t, err)
+			require.NotNil(t, link)
+			assert.Equal(t, i.ID, link.IdentityID)
+			assert.Equal(t, identity.CredentialsTypeOIDC, link.Type)
+
+			actual, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(context.Background(), i.ID)
+			require.NoError(t, err)
+			_, ok := actual.GetCredentials(identity.CredentialsTypeOIDC)
+			assert.False(t, ok, "the credentials must not be linked before the identity signed in")
+
+			t.Run("case=should sign in with the provider once linked", func(t *testing.T) {
+				s, err := reg.AllLoginStrategies().Strategy(identity.CredentialsTypeOIDC)
+				require.NoError(t, err)
+				require.NoError(t, s.(login.LinkableStrategy).LinkCredentials(httptest.NewRequest("POST", "/", nil), i, link.Config))
+
+				actual, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(context.Background(), i.ID)
+				require.NoError(t, err)
+				creds, ok := actual.GetCredentials(identity.CredentialsTypeOIDC)
+				require.True(t, ok)
+				assert.Contains(t, creds.Identifiers, strings.TrimRight(remotePublic, "/")+"|"+subject)
+
+				r := newLoginFlow(t, returnTS.URL, time.Minute)
+				res, body := makeRequest(t, "valid", afv(t, r.ID, "valid"), url.Values{})
+				ai(t, res, body)
+				assert.Equal(t, i.ID.String(), gjson.GetBytes(body, "identity.id").String(), "%s", body)
+			})
+		})
+
+		t.Run("case=should not link if the provider did not verify the email address", func(t *testing.T) {
+			subject = "link-unverified@ory.sh"
+			scope = []string{"openid"}
+			emailVerified = false
+			createPasswordIdentity(t, subject)
+
+			r := newRegistrationFlow(t, returnTS.URL, time.Minute)
+			res, body := makeRequest(t, "valid", afv(t, r.ID, "valid"), url.Values{})
+			aue(t, res, body, "An account with the same identifier (email, phone, username, ...) exists already.")
+		})
+
+		t.Run("case=should register if no identity uses the email address", func(t *testing.T) {
+			subject = "link-no-match@ory.sh"
+			scope = []string{"openid"}
+			emailVerified = true
+
+			r := newRegistrationFlow(t, returnTS.URL, time.Minute)
+			res, body := makeRequest(t, "valid", afv(t, r.ID, "valid"), url.Values{})
+			ai(t, res, body)
+		})
+	})
+
 	t.Run("case=should redirect to default return ts when sending authenticated login flow without forced flag", func(t *testing.T) {
 		subject = "no-reauth-login@ory.sh"
 		scope = []string{"openid"}
@@ -421,7 +557,7 @@ func TestStrategy(t *testing.T) {
 		require.NoError(t, reg.LoginFlowPersister().ForceLoginFlow(context.Background(), r2.ID))
 		res2, body2 := makeRequestWithCookieJar(t, "valid", afv(t, r2.ID, "valid"), fv, jar)
 		ai(t, res2, body2)
-		assert.NotEqual(t, gjson.GetBytes(body1, "id"), gjson.GetBytes(body2, "id"))
+		assert.Equal(t, gjson.GetBytes(body1, "id").String(), gjson.GetBytes(body2, "id").String(), "the session must be refreshed instead of issuing a new one")
 		authAt1, err := time.Parse(time.RFC3339, gjson.GetBytes(body1, "authenticated_at").String())
 		require.NoError(t, err)
 		authAt2, err := time.Parse(time.RFC3339, gjson.GetBytes(body2, "authenticated_at").String())
@@ -609,7 +745,7 @@ func TestCountActiveCredentials(t *testing.T) {
 		{
 			in: identity.CredentialsCollection{{
 				Type:        strategy.ID(),
-				Identifiers: []string{"bar:"},
+				Identifiers: []string{"bar|"},
 				Config: toJson(oidc.CredentialsConfig{Providers: []oidc.ProviderCredentialsConfig{
 					{Subject: "foo", Provider: "bar"},
 				}}),
@@ -618,7 +754,7 @@ func TestCountActiveCredentials(t *testing.T) {
 		{
 			in: identity.CredentialsCollection{{
 				Type:        strategy.ID(),
-				Identifiers: []string{":foo"},
+				Identifiers: []string{"|foo"},
 				Config: toJson(oidc.CredentialsConfig{Providers: []oidc.ProviderCredentialsConfig{
 					{Subject: "foo", Provider: "bar"},
 				}}),
@@ -627,7 +763,7 @@ func TestCountActiveCredentials(t *testing.T) {
 		{
 			in: identity.CredentialsCollection{{
 				Type:        strategy.ID(),
-				Identifiers: []string{"not-bar:foo"},
+				Identifiers: []string{"not-bar|foo"},
 				Config: toJson(oidc.CredentialsConfig{Providers: []oidc.ProviderCredentialsConfig{
 					{Subject: "foo", Provider: "bar"},
 				}}),
@@ -636,7 +772,7 @@ func TestCountActiveCredentials(t *testing.T) {
 		{
 			in: identity.CredentialsCollection{{
 				Type:        strategy.ID(),
-				Identifiers: []string{"bar:not-foo"},
+				Identifiers: []string{"bar|not-foo"},
 				Config: toJson(oidc.CredentialsConfig{Providers: []oidc.ProviderCredentialsConfig{
 					{Subject: "foo", Provider: "bar"},
 				}}),
@@ -650,6 +786,34 @@ func TestCountActiveCredentials(t *testing.T) {
 					{Subject: "foo", Provider: "bar"},
 				}}),
 			}},
+		},
+		{
+			in: identity.CredentialsCollection{{
+				Type:        strategy.ID(),
+				Identifiers: []string{"bar|foo"},
+				Config: toJson(oidc.CredentialsConfig{Providers: []oidc.ProviderCredentialsConfig{
+					{Subject: "foo", Provider: "bar", Issuer: "https://issuer.example.com"},
+				}}),
+			}},
+		},
+		{
+			in: identity.CredentialsCollection{{
+				Type:        strategy.ID(),
+				Identifiers: []string{"https://issuer.example.com|foo"},
+				Config: toJson(oidc.CredentialsConfig{Providers: []oidc.ProviderCredentialsConfig{
+					{Subject: "foo", Provider: "bar", Issuer: "https://issuer.example.com"},
+				}}),
+			}},
+			expected: 1,
+		},
+		{
+			in: identity.CredentialsCollection{{
+				Type:        strategy.ID(),
+				Identifiers: []string{"bar|foo"},
+				Config: toJson(oidc.CredentialsConfig{Providers: []oidc.ProviderCredentialsConfig{
+					{Subject: "foo", Provider: "bar"},
+				}}),
+			}},
 			expected: 1,
 		},
 	} {
@@ -665,6 +829,43 @@ func TestCountActiveCredentials(t *testing.T) {
 	}
 }
 
+func TestIssuerQualifiedSubject(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://stub/stub.schema.json")
+	ctx := context.Background()
+
+	subject := "shared-subject-" + x.NewUUID().String()
+	ids := map[string]*identity.Identity{}
+	for _, issuer := range []string{"https://issuer-a.example.com", "https://issuer-b.example.com"} {
+		creds, err := oidc.NewCredentials("provider", issuer, subject)
+		require.NoError(t, err)
+
+		i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+		i.SetCredentials(identity.CredentialsTypeOIDC, *creds)
+		require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(ctx, i), "two issuers sharing a subject must not collide")
+		ids[issuer] = i
+	}
+
+	for issuer, expected := range ids {
+		actual, creds, err := reg.PrivilegedIdentityPool().FindByCredentialsIdentifier(ctx, identity.CredentialsTypeOIDC, issuer+"|"+subject)
+		require.NoError(t, err)
+		assert.Equal(t, expected.ID, actual.ID)
+		assert.Equal(t, []string{issuer + "|" + subject}, creds.Identifiers)
+	}
+
+	t.Run("case=accounts without an issuer are qualified by the provider", func(t *testing.T) {
+		creds, err := oidc.NewCredentials("provider", "", subject)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"provider|" + subject}, creds.Identifiers)
+	})
+
+	creds, err := oidc.NewCredentials("renamed-provider", "https://issuer-a.example.com", subject)
+	require.NoError(t, err)
+	i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+	i.SetCredentials(identity.CredentialsTypeOIDC, *creds)
+	require.Error(t, reg.PrivilegedIdentityPool().CreateIdentity(ctx, i), "the same provider account must not be linked twice")
+}
+
 func TestDisabledEndpoint(t *testing.T) {
 	conf, reg := internal.NewFastRegistryWithMocks(t)
 	testhelpers.StrategyEnable(t, conf, identity.CredentialsTypeOIDC.String(), false)
@@ -23,10 +23,12 @@ type CredentialsConfig struct {
 	Providers []ProviderCredentialsConfig `json:"providers"`
 }
 
-func NewCredentials(provider, subject string) (*identity.Credentials, error) {
+func NewCredentials(provider, issuer, subject string) (*identity.Credentials, error) {
+	account := ProviderCredentialsConfig{Subject: subject, Provider: provider, Issuer: issuer}
+
 	var b bytes.Buffer
 	if err := json.NewEncoder(&b).Encode(CredentialsConfig{
-		Providers: []ProviderCredentialsConfig{{Subject: subject, Provider: provider}},
+		Providers: []ProviderCredentialsConfig{account},
 	}); err != nil {
 		return nil, errors.WithStack(x.PseudoPanic.
 			WithDebugf("Unable to encode password options to JSON: %s", err))
@@ -34,7 +36,7 @@ func NewCredentials(provider, subject string) (*identity.Credentials, error) {
 
 	return &identity.Credentials{
 		Type:        identity.CredentialsTypeOIDC,
-		Identifiers: []string{uid(provider, subject)},
+		Identifiers: []string{account.identifier()},
 		Config:      b.Bytes(),
 	}, nil
 }
@@ -42,6 +44,35 @@ func NewCredentials(provider, subject string) (*identity.Credentials, error) {
 type ProviderCredentialsConfig struct {
 	Subject  string `json:"subject"`
 	Provider string `json:"provider"`
+
+	// Issuer is empty if the provider account was linked before credentials were identified by the issuer.
+	Issuer string `json:"issuer,omitempty"`
+}
+
+// newProviderCredentialsConfig returns the provider account which the claims belong to.
+func newProviderCredentialsConfig(provider Provider, claims *Claims) ProviderCredentialsConfig {
+	return ProviderCredentialsConfig{
+		Subject:  claims.Subject,
+		Provider: provider.Config().ID,
+		Issuer:   canonicalIssuer(claims.Issuer),
+	}
+}
+
+// identifier returns the credentials identifier of the provider account. Provider accounts which were linked
+// before the issuer was stored are identified by the provider ID instead.
+func (p ProviderCredentialsConfig) identifier() string {
+	return uid(stringsx.Coalesce(p.Issuer, p.Provider), p.Subject)
+}
+
+// matches returns true if both are the same provider account.
+func (p ProviderCredentialsConfig) matches(account ProviderCredentialsConfig) bool {
+	if p.Subject != account.Subject {
+		return false
+	}
+	if len(p.Issuer) == 0 || len(account.Issuer) == 0 {
+		return p.Provider == account.Provider
+	}
+	return p.Issuer == account.Issuer
 }
 
 type FlowMethod struct {
@@ -0,0 +1,155 @@
+package password
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/sqlcon"
+	"github.com/ory/x/sqlxx"
+
+	"github.com/ory/kratos/corp"
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/schema"
+	"github.com/ory/kratos/selfservice/flow/settings"
+	"github.com/ory/kratos/x"
+)
+
+type (
+	// LoginAttempts counts the failed password logins of an identifier. Identifiers which do not belong to
+	// an identity are counted as well, so that a lockout does not reveal whether an account exists.
+	LoginAttempts struct {
+		ID  uuid.UUID `json:"id" db:"id" faker:"-"`
+		NID uuid.UUID `json:"-" db:"nid" faker:"-"`
+
+		// Identifier is the identifier used to sign in, see LockoutIdentifier.
+		Identifier string `json:"identifier" db:"identifier"`
+
+		// FailedAttempts counts the failed logins since WindowStartedAt.
+		FailedAttempts int `json:"failed_attempts" db:"failed_attempts"`
+
+		// WindowStartedAt is the time (UTC) of the first failed login which is counted.
+		WindowStartedAt time.Time `json:"window_started_at" db:"window_started_at" faker:"-"`
+
+		// LockedUntil is the time (UTC) until which logins with the identifier fail.
+		LockedUntil sqlxx.NullTime `json:"locked_until" db:"locked_until" faker:"-"`
+
+		// CreatedAt is a helper struct field for gobuffalo.pop.
+		CreatedAt time.Time `json:"-" db:"created_at" faker:"-"`
+
+		// UpdatedAt is a helper struct field for gobuffalo.pop.
+		UpdatedAt time.Time `json:"-" db:"updated_at" faker:"-"`
+	}
+
+	LoginAttemptsPersister interface {
+		// FindLoginAttempts returns the failed logins of the identifier or sqlcon.ErrNoRows if there are none.
+		FindLoginAttempts(ctx context.Context, identifier string) (*LoginAttempts, error)
+
+		// RecordFailedLoginAttempt counts a failed login of the identifier. Counting starts over once the window
+		// or a lockout elapsed. The identifier is locked for lockoutDuration once maxAttempts is reached.
+		RecordFailedLoginAttempt(ctx context.Context, identifier string, maxAttempts int, window, lockoutDuration time.Duration) (*LoginAttempts, error)
+
+		// ResetLoginAttempts forgets the failed logins of the identifier.
+		ResetLoginAttempts(ctx context.Context, identifier string) error
+	}
+
+	LoginAttemptsPersistenceProvider interface {
+		LoginAttemptsPersister() LoginAttemptsPersister
+	}
+)
+
+func (LoginAttempts) TableName(ctx context.Context) string {
+	return corp.ContextualizeTableName(ctx, "identity_login_attempts")
+}
+
+// IsLocked returns true if logins with the identifier fail at the given time.
+func (a *LoginAttempts) IsLocked(now time.Time) bool {
+	return !time.Time(a.LockedUntil).IsZero() && time.Time(a.LockedUntil).After(now)
+}
+
+// LockoutIdentifier returns the key under which failed logins with the identifier are counted. Identifiers are
+// case-insensitive and phone numbers are counted in E.164 format, so that changing the notation does not
+// circumvent the lockout.
+func LockoutIdentifier(identifier string) string {
+	if normalized, err := identity.NormalizePhoneNumber(identifier); err == nil {
+		return normalized
+	}
+	return strings.ToLower(strings.TrimSpace(identifier))
+}
+
+type (
+	currentPasswordLockoutDependencies interface {
+		config.Provider
+		x.LoggingProvider
+		LoginAttemptsPersistenceProvider
+	}
+
+	// CurrentPasswordLockout counts wrong current passwords entered to confirm settings changes like failed
+	// logins, using `selfservice.methods.password.config.lockout`. The attempts are counted per identity.
+	CurrentPasswordLockout struct {
+		d currentPasswordLockoutDependencies
+	}
+)
+
+var _ settings.CurrentPasswordLockout = new(CurrentPasswordLockout)
+
+func NewCurrentPasswordLockout(d currentPasswordLockoutDependencies) *CurrentPasswordLockout {
+	return &CurrentPasswordLockout{d: d}
+}
+
+// currentPasswordLockoutIdentifier returns the key under which the wrong current passwords of the identity are
+// counted. It is kept apart from the identity's login identifiers, so that guessing the current password does
+// not lock the identity out of signing in.
+func currentPasswordLockoutIdentifier(id uuid.UUID) string {
+	return "settings:current_password:" + id.String()
+}
+
+func (l *CurrentPasswordLockout) EnsureNotLockedOut(ctx context.Context, id uuid.UUID) error {
+	if !l.d.Config(ctx).PasswordLockoutConfig().Enabled {
+		return nil
+	}
+
+	a, err := l.d.LoginAttemptsPersister().FindLoginAttempts(ctx, currentPasswordLockoutIdentifier(id))
+	if errors.Is(err, sqlcon.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if a.IsLocked(time.Now()) {
+		return schema.NewCurrentPasswordLockedOutError(time.Time(a.LockedUntil))
+	}
+	return nil
+}
+
+func (l *CurrentPasswordLockout) RecordFailedAttempt(ctx context.Context, id uuid.UUID) error {
+	c := l.d.Config(ctx).PasswordLockoutConfig()
+	if !c.Enabled {
+		return schema.NewCurrentPasswordInvalidError()
+	}
+
+	a, err := l.d.LoginAttemptsPersister().RecordFailedLoginAttempt(ctx, currentPasswordLockoutIdentifier(id), c.MaxAttempts, c.Window, c.Duration)
+	if err != nil {
+		l.d.Logger().WithError(err).Warn("Unable to record the wrong current password.")
+		return schema.NewCurrentPasswordInvalidError()
+	}
+
+	if a.IsLocked(time.Now()) {
+		return schema.NewCurrentPasswordLockedOutError(time.Time(a.LockedUntil))
+	}
+	return schema.NewCurrentPasswordInvalidError()
+}
+
+func (l *CurrentPasswordLockout) ResetAttempts(ctx context.Context, id uuid.UUID) {
+	if !l.d.Config(ctx).PasswordLockoutConfig().Enabled {
+		return
+	}
+
+	if err := l.d.LoginAttemptsPersister().ResetLoginAttempts(ctx, currentPasswordLockoutIdentifier(id)); err != nil {
+		l.d.Logger().WithError(err).Warn("Unable to reset the wrong current passwords.")
+	}
+}
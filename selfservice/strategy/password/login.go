@@ -2,6 +2,7 @@ package password
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"time"
@@ -18,6 +19,7 @@ import (
 	"github.com/ory/kratos/ui/node"
 	"github.com/ory/kratos/x"
 	"github.com/ory/x/decoderx"
+	"github.com/ory/x/sqlcon"
 )
 
 func (s *Strategy) RegisterLoginRoutes(r *x.RouterPublic) {
@@ -65,25 +67,126 @@ func (s *Strategy) Login(w http.ResponseWriter, r *http.Request, f *login.Flow)
 		return nil, s.handleLoginError(w, r, f, &p, err)
 	}
 
-	i, c, err := s.d.PrivilegedIdentityPool().FindByCredentialsIdentifier(r.Context(), s.ID(), p.Identifier)
+	if err := login.BindFlowToIdentifier(r.Context(), s.d, f, p.Identifier); err != nil {
+		return nil, s.handleLoginError(w, r, f, &p, err)
+	}
+
+	if err := s.ensureNotLockedOut(r.Context(), p.Identifier); err != nil {
+		return nil, s.handleLoginError(w, r, f, &p, err)
+	}
+
+	i, creds, err := s.d.PrivilegedIdentityPool().FindByCredentialsIdentifier(r.Context(), s.ID(), p.Identifier)
+	if normalized, nerr := identity.NormalizePhoneNumber(p.Identifier); err != nil && nerr == nil && normalized != p.Identifier {
+		// Phone numbers are stored in E.164 format but may be entered in any notation.
+		i, creds, err = s.d.PrivilegedIdentityPool().FindByCredentialsIdentifier(r.Context(), s.ID(), normalized)
+	}
 	if err != nil {
 		time.Sleep(x.RandomDelay(s.d.Config(r.Context()).HasherArgon2().ExpectedDuration, s.d.Config(r.Context()).HasherArgon2().ExpectedDeviation))
-		return nil, s.handleLoginError(w, r, f, &p, errors.WithStack(schema.NewInvalidCredentialsError()))
+		return nil, s.handleLoginError(w, r, f, &p, s.recordFailedLogin(r.Context(), p.Identifier))
 	}
 
 	var o CredentialsConfig
-	d := json.NewDecoder(bytes.NewBuffer(c.Config))
+	d := json.NewDecoder(bytes.NewBuffer(creds.Config))
 	if err := d.Decode(&o); err != nil {
 		return nil, herodot.ErrInternalServerError.WithReason("The password credentials could not be decoded properly").WithDebug(err.Error()).WithWrap(err)
 	}
 
-	if err := hash.Compare(r.Context(), []byte(p.Password), []byte(o.HashedPassword)); err != nil {
-		return nil, s.handleLoginError(w, r, f, &p, errors.WithStack(schema.NewInvalidCredentialsError()))
+	if err := s.d.Hasher().Compare(r.Context(), []byte(p.Password), []byte(o.HashedPassword)); err != nil {
+		return nil, s.handleLoginError(w, r, f, &p, s.recordFailedLogin(r.Context(), p.Identifier))
+	}
+
+	s.resetFailedLogins(r.Context(), p.Identifier)
+
+	if err := login.ReleaseFlowFromIdentifier(r.Context(), s.d, f); err != nil {
+		return nil, err
+	}
+
+	if c := s.d.Config(r.Context()); c.PasswordRehashOnLogin() && hash.NeedsRehash(c, []byte(o.HashedPassword)) {
+		s.rehashPassword(r.Context(), i, creds, o, p.Password)
 	}
 
 	return i, nil
 }
 
+// ensureNotLockedOut returns an error if the identifier is locked because of too many failed logins.
+func (s *Strategy) ensureNotLockedOut(ctx context.Context, identifier string) error {
+	if !s.d.Config(ctx).PasswordLockoutConfig().Enabled {
+		return nil
+	}
+
+	a, err := s.d.LoginAttemptsPersister().FindLoginAttempts(ctx, LockoutIdentifier(identifier))
+	if errors.Is(err, sqlcon.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if a.IsLocked(time.Now()) {
+		return schema.NewLoginLockedOutError(time.Time(a.LockedUntil))
+	}
+	return nil
+}
+
+// recordFailedLogin counts the failed login and returns the error to show. It is the same for identifiers with
+// and without an account, so that neither the error nor the lockout reveal whether an account exists.
+func (s *Strategy) recordFailedLogin(ctx context.Context, identifier string) error {
+	c := s.d.Config(ctx).PasswordLockoutConfig()
+	if !c.Enabled {
+		return errors.WithStack(schema.NewInvalidCredentialsError())
+	}
+
+	a, err := s.d.LoginAttemptsPersister().RecordFailedLoginAttempt(ctx, LockoutIdentifier(identifier), c.MaxAttempts, c.Window, c.Duration)
+	if err != nil {
+		// Counting may fail if the identifier is used concurrently for the first time. The attempt is then
+		// not counted but the login still fails.
+		s.d.Logger().WithError(err).Warn("Unable to record the failed login attempt.")
+		return errors.WithStack(schema.NewInvalidCredentialsError())
+	}
+
+	if a.IsLocked(time.Now()) {
+		return schema.NewLoginLockedOutError(time.Time(a.LockedUntil))
+	}
+	return errors.WithStack(schema.NewInvalidCredentialsError())
+}
+
+// resetFailedLogins forgets the failed logins of the identifier after a successful login.
+func (s *Strategy) resetFailedLogins(ctx context.Context, identifier string) {
+	if !s.d.Config(ctx).PasswordLockoutConfig().Enabled {
+		return
+	}
+
+	if err := s.d.LoginAttemptsPersister().ResetLoginAttempts(ctx, LockoutIdentifier(identifier)); err != nil {
+		s.d.Logger().WithError(err).Warn("Unable to reset the failed login attempts.")
+	}
+}
+
+// rehashPassword replaces the hash of the password with one generated by the configured hasher. Failing to
+// do so does not fail the login, the hash is replaced on one of the next logins instead.
+func (s *Strategy) rehashPassword(ctx context.Context, i *identity.Identity, c *identity.Credentials, o CredentialsConfig, password string) {
+	l := s.d.Logger().WithField("identity_id", i.ID)
+
+	hpw, err := s.d.Hasher().Generate(ctx, []byte(password))
+	if err != nil {
+		l.WithError(err).Warn("Unable to rehash the password.")
+		return
+	}
+
+	o.HashedPassword = string(hpw)
+	co, err := json.Marshal(o)
+	if err != nil {
+		l.WithError(err).Warn("Unable to encode the rehashed password credentials.")
+		return
+	}
+
+	// The credentials are only updated if they were not changed since they were read. Concurrent logins thus
+	// do not overwrite each other's hash and a concurrent password change is not reverted.
+	if err := s.d.PrivilegedIdentityPool().UpdateCredentialsConfig(ctx, i.ID, c.ID, c.Config, co); errors.Is(err, sqlcon.ErrNoRows) {
+		l.Debug("Not rehashing the password because the credentials were changed concurrently.")
+	} else if err != nil {
+		l.WithError(err).Warn("Unable to store the rehashed password.")
+	}
+}
+
 func (s *Strategy) PopulateLoginMethod(r *http.Request, sr *login.Flow) error {
 	// This block adds the identifier to the method when the request is forced - as a hint for the user.
 	var identifier string
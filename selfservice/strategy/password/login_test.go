@@ -18,6 +18,7 @@ import (
 
 	"github.com/ory/x/assertx"
 	"github.com/ory/x/errorsx"
+	"github.com/ory/x/sqlcon"
 	"github.com/ory/x/sqlxx"
 
 	"github.com/stretchr/testify/assert"
@@ -25,11 +26,13 @@ import (
 	"github.com/tidwall/gjson"
 
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/hash"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/internal"
 	"github.com/ory/kratos/internal/testhelpers"
 	"github.com/ory/kratos/schema"
 	"github.com/ory/kratos/selfservice/flow/login"
+	"github.com/ory/kratos/selfservice/strategy/password"
 	"github.com/ory/kratos/text"
 	"github.com/ory/kratos/x"
 )
@@ -501,7 +504,7 @@ func TestCompleteLogin(t *testing.T) {
 		})
 	})
 
-	t.Run("should be a new session with forced flag", func(t *testing.T) {
+	t.Run("should refresh the session with forced flag", func(t *testing.T) {
 		identifier, pwd := x.NewUUID().String(), "password"
 		createIdentity(identifier, pwd)
 
@@ -519,7 +522,8 @@ func TestCompleteLogin(t *testing.T) {
 
 		require.Contains(t, res.Request.URL.Path, "return-ts", "%s", res.Request.URL.String())
 		assert.Equal(t, identifier, gjson.Get(body2, "identity.traits.subject").String(), "%s", body2)
-		assert.NotEqual(t, gjson.Get(body1, "id").String(), gjson.Get(body2, "id").String(), "%s\n\n%s\n", body1, body2)
+		assert.Equal(t, gjson.Get(body1, "id").String(), gjson.Get(body2, "id").String(), "%s\n\n%s\n", body1, body2)
+		assert.True(t, gjson.Get(body2, "authenticated_at").Time().After(gjson.Get(body1, "authenticated_at").Time()), "%s\n\n%s\n", body1, body2)
 	})
 
 	t.Run("should login same identity regardless of identifier capitalization", func(t *testing.T) {
@@ -539,4 +543,244 @@ func TestCompleteLogin(t *testing.T) {
 
 		assert.Equal(t, identifier, gjson.Get(body2, "identity.traits.subject").String(), "%s", body2)
 	})
+
+	t.Run("case=should limit the concurrent flows per identifier", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceLoginMaxConcurrentFlowsPerIdentifier, 2)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceLoginMaxConcurrentFlowsPerIdentifier, 0)
+		})
+
+		identifier := x.NewUUID().String()
+		submit := func(t *testing.T, f *kratos.LoginFlow, identifier string) string {
+			values := url.Values{"method": {"password"}, "password_identifier": {identifier}, "password": {"not-the-password"}}
+			body, res := testhelpers.LoginMakeRequest(t, true, f, apiClient, testhelpers.EncodeFormAsJSON(t, true, values))
+			assert.EqualValues(t, http.StatusBadRequest, res.StatusCode, "%s", body)
+			return gjson.Get(body, "ui.messages.0.text").String()
+		}
+
+		first := testhelpers.InitializeLoginFlowViaAPI(t, apiClient, publicTS, false)
+		second := testhelpers.InitializeLoginFlowViaAPI(t, apiClient, publicTS, false)
+		assert.Equal(t, text.NewErrorValidationInvalidCredentials().Text, submit(t, first, identifier))
+		assert.Equal(t, text.NewErrorValidationInvalidCredentials().Text, submit(t, second, strings.ToUpper(identifier)))
+
+		third := testhelpers.InitializeLoginFlowViaAPI(t, apiClient, publicTS, false)
+		assert.Equal(t, text.NewErrorValidationLoginTooManyFlows().Text, submit(t, third, identifier))
+		assert.Equal(t, text.NewErrorValidationInvalidCredentials().Text, submit(t, third, x.NewUUID().String()),
+			"other identifiers are not limited")
+		assert.Equal(t, text.NewErrorValidationInvalidCredentials().Text, submit(t, first, identifier),
+			"flows which are already bound to the identifier can be submitted again")
+	})
+
+	t.Run("case=should not limit repeated successful logins", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceLoginMaxConcurrentFlowsPerIdentifier, 2)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceLoginMaxConcurrentFlowsPerIdentifier, 0)
+		})
+
+		identifier, pwd := x.NewUUID().String(), "password"
+		createIdentity(identifier, pwd)
+
+		for k := 0; k < 4; k++ {
+			f := testhelpers.InitializeLoginFlowViaAPI(t, apiClient, publicTS, false)
+			values := url.Values{"method": {"password"}, "password_identifier": {identifier}, "password": {pwd}}
+			body, res := testhelpers.LoginMakeRequest(t, true, f, apiClient, testhelpers.EncodeFormAsJSON(t, true, values))
+			require.EqualValues(t, http.StatusOK, res.StatusCode, "login %d: %s", k, body)
+			assert.NotEmpty(t, gjson.Get(body, "session_token").String(), "%s", body)
+		}
+
+		f := testhelpers.InitializeLoginFlowViaAPI(t, apiClient, publicTS, false)
+		values := url.Values{"method": {"password"}, "password_identifier": {identifier}, "password": {"not-the-password"}}
+		body, res := testhelpers.LoginMakeRequest(t, true, f, apiClient, testhelpers.EncodeFormAsJSON(t, true, values))
+		assert.EqualValues(t, http.StatusBadRequest, res.StatusCode, "%s", body)
+		assert.Equal(t, text.NewErrorValidationInvalidCredentials().Text, gjson.Get(body, "ui.messages.0.text").String(), "%s", body)
+	})
+
+	t.Run("case=should lock the identifier after repeated failed logins", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyPasswordLockoutEnabled, true)
+		conf.MustSet(config.ViperKeyPasswordLockoutMaxAttempts, 3)
+		conf.MustSet(config.ViperKeyPasswordLockoutWindow, "1h")
+		conf.MustSet(config.ViperKeyPasswordLockoutDuration, "1h")
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyPasswordLockoutEnabled, false)
+			conf.MustSet(config.ViperKeyPasswordLockoutDuration, "15m")
+		})
+
+		submit := func(t *testing.T, identifier, pwd string) (int, string) {
+			f := testhelpers.InitializeLoginFlowViaAPI(t, apiClient, publicTS, false)
+			values := url.Values{"method": {"password"}, "password_identifier": {identifier}, "password": {pwd}}
+			body, res := testhelpers.LoginMakeRequest(t, true, f, apiClient, testhelpers.EncodeFormAsJSON(t, true, values))
+			return res.StatusCode, body
+		}
+
+		messageID := func(body string) int {
+			return int(gjson.Get(body, "ui.messages.0.id").Int())
+		}
+
+		t.Run("case=locks the identifier", func(t *testing.T) {
+			identifier, pwd := x.NewUUID().String(), "password"
+			createIdentity(identifier, pwd)
+
+			for k := 0; k < 2; k++ {
+				_, body := submit(t, identifier, "not-password")
+				assert.EqualValues(t, text.ErrorValidationInvalidCredentials, messageID(body), "%s", body)
+			}
+
+			_, body := submit(t, identifier, "not-password")
+			assert.EqualValues(t, text.ErrorValidationLoginLockedOut, messageID(body), "%s", body)
+			assert.NotEmpty(t, gjson.Get(body, "ui.messages.0.context.locked_until").String(), "%s", body)
+
+			status, body := submit(t, strings.ToUpper(identifier), pwd)
+			assert.EqualValues(t, http.StatusBadRequest, status, "the correct password must not be accepted during the lockout: %s", body)
+			assert.EqualValues(t, text.ErrorValidationLoginLockedOut, messageID(body), "%s", body)
+		})
+
+		t.Run("case=does not reveal whether the account exists", func(t *testing.T) {
+			identifier := x.NewUUID().String()
+			for k := 0; k < 3; k++ {
+				submit(t, identifier, "not-password")
+			}
+
+			_, body := submit(t, identifier, "not-password")
+			assert.EqualValues(t, text.ErrorValidationLoginLockedOut, messageID(body), "%s", body)
+		})
+
+		t.Run("case=unlocks the identifier once the lockout elapsed", func(t *testing.T) {
+			conf.MustSet(config.ViperKeyPasswordLockoutDuration, "100ms")
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeyPasswordLockoutDuration, "1h")
+			})
+
+			identifier, pwd := x.NewUUID().String(), "password"
+			createIdentity(identifier, pwd)
+
+			for k := 0; k < 3; k++ {
+				submit(t, identifier, "not-password")
+			}
+			_, body := submit(t, identifier, pwd)
+			assert.EqualValues(t, text.ErrorValidationLoginLockedOut, messageID(body), "%s", body)
+
+			time.Sleep(time.Millisecond * 200)
+
+			status, body := submit(t, identifier, pwd)
+			assert.EqualValues(t, http.StatusOK, status, "%s", body)
+		})
+
+		t.Run("case=resets the counter on success", func(t *testing.T) {
+			identifier, pwd := x.NewUUID().String(), "password"
+			createIdentity(identifier, pwd)
+
+			for k := 0; k < 2; k++ {
+				submit(t, identifier, "not-password")
+			}
+
+			status, body := submit(t, identifier, pwd)
+			require.EqualValues(t, http.StatusOK, status, "%s", body)
+
+			_, err := reg.LoginAttemptsPersister().FindLoginAttempts(context.Background(), password.LockoutIdentifier(identifier))
+			require.ErrorIs(t, err, sqlcon.ErrNoRows)
+
+			for k := 0; k < 2; k++ {
+				_, body := submit(t, identifier, "not-password")
+				assert.EqualValues(t, text.ErrorValidationInvalidCredentials, messageID(body), "%s", body)
+			}
+		})
+	})
+
+	t.Run("case=should rehash the password on login", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyPasswordRehashOnLogin, true)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyPasswordRehashOnLogin, false)
+		})
+
+		login := func(t *testing.T, identifier, pwd string, h []byte) string {
+			i := &identity.Identity{
+				ID:     x.NewUUID(),
+				Traits: identity.Traits(fmt.Sprintf(`{"subject":"%s"}`, identifier)),
+				Credentials: map[identity.CredentialsType]identity.Credentials{
+					identity.CredentialsTypePassword: {
+						Type:        identity.CredentialsTypePassword,
+						Identifiers: []string{identifier},
+						Config:      sqlxx.JSONRawMessage(`{"hashed_password":"` + string(h) + `"}`),
+					},
+				},
+			}
+			require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(context.Background(), i))
+
+			f := testhelpers.InitializeLoginFlowViaAPI(t, apiClient, publicTS, false)
+			values := url.Values{"method": {"password"}, "password_identifier": {identifier}, "password": {pwd}}
+			body, res := testhelpers.LoginMakeRequest(t, true, f, apiClient, testhelpers.EncodeFormAsJSON(t, true, values))
+			require.EqualValues(t, http.StatusOK, res.StatusCode, "%s", body)
+
+			actual, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(context.Background(), i.ID)
+			require.NoError(t, err)
+			c, ok := actual.GetCredentials(identity.CredentialsTypePassword)
+			require.True(t, ok)
+
+			rehashed := gjson.GetBytes(c.Config, "hashed_password").String()
+			require.NoError(t, reg.Hasher().Compare(context.Background(), []byte(pwd), []byte(rehashed)))
+			return rehashed
+		}
+
+		t.Run("case=bcrypt to argon2id", func(t *testing.T) {
+			identifier, pwd := x.NewUUID().String(), x.NewUUID().String()
+			h, err := hash.NewHasherBcrypt(reg).Generate(context.Background(), []byte(pwd))
+			require.NoError(t, err)
+
+			rehashed := login(t, identifier, pwd, h)
+			assert.True(t, hash.IsArgon2idHash([]byte(rehashed)), "%s", rehashed)
+			assert.False(t, hash.NeedsRehash(conf, []byte(rehashed)))
+		})
+
+		t.Run("case=argon2id with new parameters", func(t *testing.T) {
+			identifier, pwd := x.NewUUID().String(), x.NewUUID().String()
+			h, err := reg.Hasher().Generate(context.Background(), []byte(pwd))
+			require.NoError(t, err)
+
+			iterations := conf.HasherArgon2().Iterations
+			conf.MustSet(config.ViperKeyHasherArgon2ConfigIterations, iterations+1)
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeyHasherArgon2ConfigIterations, iterations)
+			})
+
+			rehashed := login(t, identifier, pwd, h)
+			assert.Contains(t, rehashed, fmt.Sprintf(",t=%d,", iterations+1))
+			assert.False(t, hash.NeedsRehash(conf, []byte(rehashed)))
+		})
+
+		t.Run("case=up-to-date hashes are kept", func(t *testing.T) {
+			identifier, pwd := x.NewUUID().String(), x.NewUUID().String()
+			h, err := reg.Hasher().Generate(context.Background(), []byte(pwd))
+			require.NoError(t, err)
+
+			assert.Equal(t, string(h), login(t, identifier, pwd, h))
+		})
+
+		t.Run("case=rotated pepper", func(t *testing.T) {
+			conf.MustSet(config.ViperKeySecretsPepper, []string{"old-pepper-0123456789"})
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeySecretsPepper, []string{})
+			})
+
+			identifier, pwd := x.NewUUID().String(), x.NewUUID().String()
+			h, err := reg.Hasher().Generate(context.Background(), []byte(pwd))
+			require.NoError(t, err)
+
+			conf.MustSet(config.ViperKeySecretsPepper, []string{"new-pepper-0123456789", "old-pepper-0123456789"})
+			rehashed := login(t, identifier, pwd, h)
+			assert.NotEqual(t, string(h), rehashed)
+			assert.False(t, hash.NeedsRehash(conf, []byte(rehashed)))
+
+			conf.MustSet(config.ViperKeySecretsPepper, []string{"new-pepper-0123456789"})
+			require.NoError(t, reg.Hasher().Compare(context.Background(), []byte(pwd), []byte(rehashed)))
+		})
+
+		t.Run("case=does nothing if disabled", func(t *testing.T) {
+			conf.MustSet(config.ViperKeyPasswordRehashOnLogin, false)
+			identifier, pwd := x.NewUUID().String(), x.NewUUID().String()
+			h, err := hash.NewHasherBcrypt(reg).Generate(context.Background(), []byte(pwd))
+			require.NoError(t, err)
+
+			assert.Equal(t, string(h), login(t, identifier, pwd, h))
+		})
+	})
 }
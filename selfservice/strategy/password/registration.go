@@ -36,6 +36,9 @@ func (s *Strategy) RegisterRegistrationRoutes(_ *x.RouterPublic) {
 
 func (s *Strategy) handleRegistrationError(_ http.ResponseWriter, r *http.Request, f *registration.Flow, p *RegistrationFormPayload, err error) error {
 	if f != nil {
+		// The decoded payload lacks values which could not be decoded, so we start with the values as they
+		// were submitted.
+		f.UI.UpdateNodeValuesFromRequest(r, "traits")
 		if p != nil {
 			for _, n := range container.NewFromJSON("", node.PasswordGroup, p.Traits, "traits").Nodes {
 				// we only set the value and not the whole field because we want to keep types from the initial form generation
@@ -52,8 +55,13 @@ func (s *Strategy) handleRegistrationError(_ http.ResponseWriter, r *http.Reques
 }
 
 func (s *Strategy) decode(p *RegistrationFormPayload, r *http.Request) error {
+	ts, err := s.d.IdentitySchemaResolver().TraitsSchemaForRequest(r)
+	if err != nil {
+		return err
+	}
+
 	raw, err := sjson.SetBytes(registrationSchema,
-		"properties.traits.$ref", s.d.Config(r.Context()).DefaultIdentityTraitsSchemaURL().String()+"#/properties/traits")
+		"properties.traits.$ref", ts.URL+"#/properties/traits")
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -63,7 +71,11 @@ func (s *Strategy) decode(p *RegistrationFormPayload, r *http.Request) error {
 		return errors.WithStack(err)
 	}
 
-	return s.hd.Decode(r, p, compiler, decoderx.HTTPDecoderSetValidatePayloads(true), decoderx.HTTPDecoderJSONFollowsFormFormat())
+	return s.hd.Decode(r, p, compiler,
+		decoderx.HTTPKeepRequestBody(true),
+		decoderx.HTTPDecoderSetValidatePayloads(true),
+		decoderx.HTTPDecoderJSONFollowsFormFormat(),
+	)
 }
 
 func (s *Strategy) Register(w http.ResponseWriter, r *http.Request, f *registration.Flow, i *identity.Identity) (err error) {
@@ -109,7 +121,8 @@ func (s *Strategy) Register(w http.ResponseWriter, r *http.Request, f *registrat
 		return s.handleRegistrationError(w, r, f, &p, err)
 	}
 
-	return nil
+	// The post-registration hooks ran already, so the registration handler must not run them again.
+	return errors.WithStack(flow.ErrCompletedByStrategy)
 }
 
 func (s *Strategy) validateCredentials(ctx context.Context, i *identity.Identity, pw string) error {
@@ -125,7 +138,7 @@ func (s *Strategy) validateCredentials(ctx context.Context, i *identity.Identity
 		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("No login identifiers (e.g. email, phone number, username) were set. Contact an administrator, the identity schema is misconfigured."))
 	}
 
-	for _, id := range c.Identifiers {
+	for _, id := range ComparedIdentifiers(s.d.Config(ctx), i, c.Identifiers) {
 		if err := s.d.PasswordValidator().Validate(ctx, id, pw); err != nil {
 			if _, ok := errorsx.Cause(err).(*herodot.DefaultError); ok {
 				return err
@@ -138,7 +151,12 @@ func (s *Strategy) validateCredentials(ctx context.Context, i *identity.Identity
 }
 
 func (s *Strategy) PopulateRegistrationMethod(r *http.Request, f *registration.Flow) error {
-	nodes, err := container.NodesFromJSONSchema(node.PasswordGroup, s.d.Config(r.Context()).DefaultIdentityTraitsSchemaURL().String(), "", nil)
+	ts, err := s.d.IdentitySchemaResolver().TraitsSchemaForRequest(r)
+	if err != nil {
+		return err
+	}
+
+	nodes, err := container.NodesFromJSONSchema(node.PasswordGroup, ts.URL, "", nil)
 	if err != nil {
 		return err
 	}
@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -543,6 +545,44 @@ func TestRegistration(t *testing.T) {
 			})
 		})
 
+		t.Run("case=should keep the submitted values", func(t *testing.T) {
+			conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/registration.typed.schema.json")
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/registration.schema.json")
+			})
+
+			var check = func(t *testing.T, actual string) {
+				assert.NotEmpty(t, gjson.Get(actual, "ui.nodes.#(attributes.name==traits.age).messages.0.text").String(), "%s", actual)
+				assert.EqualValues(t, "registration-identifier-typed", gjson.Get(actual, "ui.nodes.#(attributes.name==traits.username).attributes.value").String(), "%s", actual)
+				assert.EqualValues(t, "not-a-number", gjson.Get(actual, "ui.nodes.#(attributes.name==traits.age).attributes.value").String(), "%s", actual)
+				assert.False(t, gjson.Get(actual, "ui.nodes.#(attributes.name==password).attributes.value").Exists(), "%s", actual)
+			}
+
+			var values = func(v url.Values) {
+				v.Set("traits.username", "registration-identifier-typed")
+				v.Set("traits.age", "not-a-number")
+				v.Set("password", x.NewUUID().String())
+			}
+
+			t.Run("type=api", func(t *testing.T) {
+				check(t, expectValidationError(t, true, values))
+			})
+
+			t.Run("type=browser", func(t *testing.T) {
+				check(t, expectValidationError(t, false, values))
+			})
+
+			t.Run("type=api/payload=nested", func(t *testing.T) {
+				f := testhelpers.InitializeRegistrationFlowViaAPI(t, apiClient, publicTS)
+				actual, res := testhelpers.RegistrationMakeRequest(t, true, f, apiClient,
+					`{"method":"password","password":"`+x.NewUUID().String()+`","traits":{"username":"registration-identifier-typed","age":"not-a-number"}}`)
+				assert.Equal(t, http.StatusBadRequest, res.StatusCode, "%s", actual)
+				assert.EqualValues(t, "registration-identifier-typed", gjson.Get(actual, "ui.nodes.#(attributes.name==traits.username).attributes.value").String(), "%s", actual)
+				assert.EqualValues(t, "not-a-number", gjson.Get(actual, "ui.nodes.#(attributes.name==traits.age).attributes.value").String(), "%s", actual)
+				assert.False(t, gjson.Get(actual, "ui.nodes.#(attributes.name==password).attributes.value").Exists(), "%s", actual)
+			})
+		})
+
 		t.Run("case=should work even if password is just numbers", func(t *testing.T) {
 			conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://stub/registration.schema.json")
 			conf.MustSet(config.HookStrategyKey(config.ViperKeySelfServiceRegistrationAfter, identity.CredentialsTypePassword.String()), []config.SelfServiceHook{{Name: "session"}})
@@ -600,3 +640,85 @@ func TestRegistration(t *testing.T) {
 		}, f.Ui)
 	})
 }
+
+func TestRegistrationHooksRunOnce(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeySelfServiceStrategyConfig+"."+string(identity.CredentialsTypePassword), map[string]interface{}{"enabled": true})
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/registration.schema.json")
+
+	var calls int32
+	reg.WithHooks(map[string]func(config.SelfServiceHook) interface{}{
+		"count": func(config.SelfServiceHook) interface{} {
+			return registration.PostHookPrePersistExecutorFunc(func(http.ResponseWriter, *http.Request, *registration.Flow, *identity.Identity) error {
+				atomic.AddInt32(&calls, 1)
+				return nil
+			})
+		},
+	})
+	conf.MustSet(config.HookStrategyKey(config.ViperKeySelfServiceRegistrationAfter, identity.CredentialsTypePassword.String()),
+		[]config.SelfServiceHook{{Name: "count"}, {Name: "session"}})
+
+	publicTS, _ := testhelpers.NewKratosServer(t, reg)
+	_ = testhelpers.NewRegistrationUIFlowEchoServer(t, reg)
+	redirTS := testhelpers.NewRedirSessionEchoTS(t, reg)
+	conf.MustSet(config.ViperKeySelfServiceBrowserDefaultReturnTo, redirTS.URL+"/default-return-to")
+
+	for _, isAPI := range []bool{true, false} {
+		t.Run(fmt.Sprintf("api=%v", isAPI), func(t *testing.T) {
+			atomic.StoreInt32(&calls, 0)
+
+			var hc *http.Client
+			expectURL := publicTS.URL + registration.RouteSubmitFlow
+			if !isAPI {
+				hc = testhelpers.NewClientWithCookies(t)
+				expectURL = redirTS.URL + "/default-return-to"
+			}
+
+			body := testhelpers.SubmitRegistrationForm(t, isAPI, hc, publicTS, func(v url.Values) {
+				v.Set("traits.username", "registration-hooks-run-once-"+x.NewUUID().String())
+				v.Set("password", x.NewUUID().String())
+				v.Set("traits.foobar", "bar")
+			}, identity.CredentialsTypePassword, http.StatusOK, expectURL)
+
+			assert.NotEmpty(t, gjson.Get(body, "identity.id").String(), "%s", body)
+			assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+		})
+	}
+}
+
+func TestRegistrationWebHookEnrichesSession(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeySelfServiceStrategyConfig+"."+string(identity.CredentialsTypePassword), map[string]interface{}{"enabled": true})
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/registration.schema.json")
+
+	webHookTS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"foo":"bar"}`))
+	}))
+	t.Cleanup(webHookTS.Close)
+	webHook := config.SelfServiceHook{Name: "web_hook", Config: json.RawMessage(`{"url":"` + webHookTS.URL + `","enrich_session":true}`)}
+
+	publicTS, _ := testhelpers.NewKratosServer(t, reg)
+	_ = testhelpers.NewRegistrationUIFlowEchoServer(t, reg)
+	redirTS := testhelpers.NewRedirSessionEchoTS(t, reg)
+	conf.MustSet(config.ViperKeySelfServiceBrowserDefaultReturnTo, redirTS.URL+"/default-return-to")
+
+	for _, tc := range []struct {
+		d     string
+		hooks []config.SelfServiceHook
+	}{
+		{d: "web hook before session hook", hooks: []config.SelfServiceHook{webHook, {Name: "session"}}},
+		{d: "web hook after session hook", hooks: []config.SelfServiceHook{{Name: "session"}, webHook}},
+	} {
+		t.Run("case="+tc.d, func(t *testing.T) {
+			conf.MustSet(config.HookStrategyKey(config.ViperKeySelfServiceRegistrationAfter, identity.CredentialsTypePassword.String()), tc.hooks)
+
+			body := testhelpers.SubmitRegistrationForm(t, false, testhelpers.NewClientWithCookies(t), publicTS, func(v url.Values) {
+				v.Set("traits.username", "registration-web-hook-"+x.NewUUID().String())
+				v.Set("password", x.NewUUID().String())
+				v.Set("traits.foobar", "bar")
+			}, identity.CredentialsTypePassword, http.StatusOK, redirTS.URL+"/default-return-to")
+
+			assert.Equal(t, "bar", gjson.Get(body, "extra.foo").String(), "%s", body)
+		})
+	}
+}
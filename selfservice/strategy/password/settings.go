@@ -50,6 +50,13 @@ type submitSelfServiceSettingsFlowWithPasswordMethodBody struct {
 	// required: true
 	Password string `json:"password"`
 
+	// CurrentPassword is the identity's current password
+	//
+	// Only required if the password method is listed in `selfservice.flows.settings.require_current_password`.
+	//
+	// type: string
+	CurrentPassword string `json:"current_password"`
+
 	// CSRFToken is the anti-CSRF token
 	//
 	// type: string
@@ -134,6 +141,14 @@ func (s *Strategy) continueSettingsFlow(
 		return schema.NewRequiredError("#/password", "password")
 	}
 
+	if err := settings.VerifyCurrentPassword(r.Context(), s.d, s.SettingsStrategyID(), ctxUpdate.Session.Identity.ID, p.CurrentPassword); err != nil {
+		return err
+	}
+
+	if err := settings.RequireVerifiedAddress(r.Context(), s.d, s.SettingsStrategyID(), ctxUpdate.Session.Identity.ID); err != nil {
+		return err
+	}
+
 	hpw, err := s.d.Hasher().Generate(r.Context(), []byte(p.Password))
 	if err != nil {
 		return err
@@ -170,6 +185,9 @@ func (s *Strategy) continueSettingsFlow(
 func (s *Strategy) PopulateSettingsMethod(r *http.Request, _ *identity.Identity, f *settings.Flow) error {
 	f.UI.SetCSRF(s.d.GenerateCSRFToken(r))
 	f.UI.Nodes.Upsert(NewPasswordNode("password").WithMetaLabel(text.NewInfoNodeInputPassword()))
+	if s.d.Config(r.Context()).SelfServiceFlowSettingsRequiresCurrentPassword(s.SettingsStrategyID()) {
+		f.UI.Nodes.Upsert(settings.NewCurrentPasswordNode(node.PasswordGroup))
+	}
 	f.UI.Nodes.Append(node.NewInputField("method", "password", node.PasswordGroup, node.InputAttributeTypeSubmit).WithMetaLabel(text.NewInfoNodeLabelSave()))
 
 	return nil
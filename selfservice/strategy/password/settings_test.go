@@ -23,6 +23,7 @@ import (
 	"github.com/ory/kratos/internal"
 	"github.com/ory/kratos/internal/testhelpers"
 	"github.com/ory/kratos/selfservice/flow/settings"
+	"github.com/ory/kratos/text"
 	"github.com/ory/kratos/x"
 	"github.com/ory/x/assertx"
 	"github.com/ory/x/httpx"
@@ -183,7 +184,8 @@ func TestSettings(t *testing.T) {
 		var check = func(t *testing.T, actual string) {
 			assert.Equal(t, "success", gjson.Get(actual, "state").String(), "%s", actual)
 			assert.Empty(t, gjson.Get(actual, "ui.nodes.#(attributes.name==password).value").String(), "%s", actual)
-			assert.Empty(t, gjson.Get(actual, "ui.nodes.#(attributes.name==password).messages.0.text").String(), actual)
+			assert.Empty(t, gjson.Get(actual, "ui.nodes.#(attributes.name==password).messages.#(type==error)").Raw, actual)
+			assert.EqualValues(t, text.InfoSelfServiceSettingsUpdateSuccessCredentials, gjson.Get(actual, "ui.nodes.#(attributes.name==password).messages.0.id").Int(), actual)
 		}
 
 		var payload = func(v url.Values) {
@@ -274,6 +276,8 @@ func TestSettings(t *testing.T) {
 		var check = func(t *testing.T, actual string, id *identity.Identity) {
 			assert.Equal(t, "success", gjson.Get(actual, "state").String(), "%s", actual)
 			assert.Empty(t, gjson.Get(actual, "ui.nodes.#(name==password).attributes.value").String(), "%s", actual)
+			assert.EqualValues(t, text.InfoSelfServiceSettingsUpdateSuccessCredentials, gjson.Get(actual, "ui.nodes.#(attributes.name==password).messages.0.id").Int(), "%s", actual)
+			assert.EqualValues(t, "password", gjson.Get(actual, "ui.nodes.#(attributes.name==password).messages.0.context.credentials_type").String(), "%s", actual)
 
 			actualIdentity, err := reg.PrivilegedIdentityPool().GetIdentityConfidential(context.Background(), id.ID)
 			require.NoError(t, err)
@@ -330,4 +334,120 @@ func TestSettings(t *testing.T) {
 			run(t, rs, false, browserUser1, browserIdentity1)
 		})
 	})
+
+	t.Run("description=should require the current password if configured", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceSettingsRequireCurrentPassword, []string{"password"})
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceSettingsRequireCurrentPassword, []string{})
+		})
+
+		current := randx.MustString(16, randx.AlphaNum)
+		hpw, err := reg.Hasher().Generate(context.Background(), []byte(current))
+		require.NoError(t, err)
+
+		var newIdentity = func(email string) *identity.Identity {
+			i := newIdentityWithPassword(email)
+			i.Credentials[identity.CredentialsTypePassword] = identity.Credentials{
+				Type:        identity.CredentialsTypePassword,
+				Identifiers: []string{email},
+				Config:      []byte(`{"hashed_password":"` + string(hpw) + `"}`),
+			}
+			return i
+		}
+
+		apiUser := testhelpers.NewHTTPClientWithIdentitySessionToken(t, reg, newIdentity("current-api@doe.com"))
+		browserUser := testhelpers.NewHTTPClientWithIdentitySessionCookie(t, reg, newIdentity("current-browser@doe.com"))
+
+		t.Run("case=should show the current password node", func(t *testing.T) {
+			f := testhelpers.InitializeSettingsFlowViaAPI(t, apiUser, publicTS)
+			actual, err := json.Marshal(f)
+			require.NoError(t, err)
+			assert.True(t, gjson.GetBytes(actual, "ui.nodes.#(attributes.name==current_password)").Exists(), "%s", actual)
+		})
+
+		for _, tc := range []struct {
+			d       string
+			current string
+			message string
+		}{
+			{d: "missing", current: "", message: "Property current_password is missing."},
+			{d: "wrong", current: "not-" + current, message: "The current password is incorrect."},
+		} {
+			t.Run("case=should fail because the current password is "+tc.d, func(t *testing.T) {
+				var payload = func(v url.Values) {
+					v.Set("method", "password")
+					v.Set("password", randx.MustString(16, randx.AlphaNum))
+					v.Set("current_password", tc.current)
+				}
+
+				t.Run("type=api", func(t *testing.T) {
+					actual := expectValidationError(t, true, apiUser, payload)
+					assert.Equal(t, tc.message, gjson.Get(actual, "ui.nodes.#(attributes.name==current_password).messages.0.text").String(), "%s", actual)
+				})
+
+				t.Run("type=browser", func(t *testing.T) {
+					actual := expectValidationError(t, false, browserUser, payload)
+					assert.Equal(t, tc.message, gjson.Get(actual, "ui.nodes.#(attributes.name==current_password).messages.0.text").String(), "%s", actual)
+				})
+			})
+		}
+
+		t.Run("case=should update the password if the current password is correct", func(t *testing.T) {
+			t.Run("type=api", func(t *testing.T) {
+				next := randx.MustString(16, randx.AlphaNum)
+				actual := expectSuccess(t, true, apiUser, func(v url.Values) {
+					v.Set("method", "password")
+					v.Set("password", next)
+					v.Set("current_password", current)
+				})
+				assert.Equal(t, "success", gjson.Get(actual, "flow.state").String(), "%s", actual)
+			})
+		})
+
+		t.Run("case=should lock out the identity after too many wrong current passwords", func(t *testing.T) {
+			conf.MustSet(config.ViperKeyPasswordLockoutEnabled, true)
+			conf.MustSet(config.ViperKeyPasswordLockoutMaxAttempts, 2)
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeyPasswordLockoutEnabled, false)
+				conf.MustSet(config.ViperKeyPasswordLockoutMaxAttempts, 5)
+			})
+
+			user := testhelpers.NewHTTPClientWithIdentitySessionToken(t, reg, newIdentity("current-lockout@doe.com"))
+			var submit = func(current string) string {
+				return gjson.Get(expectValidationError(t, true, user, func(v url.Values) {
+					v.Set("method", "password")
+					v.Set("password", randx.MustString(16, randx.AlphaNum))
+					v.Set("current_password", current)
+				}), "ui.nodes.#(attributes.name==current_password).messages.0").Raw
+			}
+
+			assert.EqualValues(t, text.ErrorValidationSettingsCurrentPasswordInvalid, gjson.Get(submit("not-"+current), "id").Int())
+			assert.EqualValues(t, text.ErrorValidationSettingsCurrentPasswordLockedOut, gjson.Get(submit("not-"+current), "id").Int())
+
+			// The correct password is rejected as well until the lockout elapsed.
+			assert.EqualValues(t, text.ErrorValidationSettingsCurrentPasswordLockedOut, gjson.Get(submit(current), "id").Int())
+		})
+	})
+
+	t.Run("description=should require a verified address if configured", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceSettingsRequireVerifiedAddress, []string{"password"})
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceSettingsRequireVerifiedAddress, []string{})
+		})
+
+		var payload = func(v url.Values) {
+			v.Set("method", "password")
+			v.Set("password", randx.MustString(16, randx.AlphaNum))
+		}
+
+		t.Run("type=api", func(t *testing.T) {
+			actual := expectValidationError(t, true, testhelpers.NewHTTPClientWithIdentitySessionToken(t, reg, newIdentityWithPassword("unverified-api@doe.com")), payload)
+			assert.EqualValues(t, text.ErrorValidationSettingsVerifiedAddressRequired, gjson.Get(actual, "ui.messages.0.id").Int(), "%s", actual)
+		})
+
+		t.Run("type=browser", func(t *testing.T) {
+			actual := expectValidationError(t, false, testhelpers.NewHTTPClientWithIdentitySessionCookie(t, reg, newIdentityWithPassword("unverified-browser@doe.com")), payload)
+			assert.EqualValues(t, text.ErrorValidationSettingsVerifiedAddressRequired, gjson.Get(actual, "ui.messages.0.id").Int(), "%s", actual)
+		})
+	})
 }
@@ -55,13 +55,17 @@ type registrationStrategyDependencies interface {
 	settings.FlowPersistenceProvider
 	settings.HookExecutorProvider
 	settings.HooksProvider
+	settings.CurrentPasswordLockoutProvider
 	settings.ErrorHandlerProvider
 
 	identity.PrivilegedPoolProvider
 	identity.ValidationProvider
+	identity.SchemaResolverProvider
 
 	session.HandlerProvider
 	session.ManagementProvider
+
+	LoginAttemptsPersistenceProvider
 }
 
 type Strategy struct {
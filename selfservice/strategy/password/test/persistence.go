@@ -0,0 +1,89 @@
+package password
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/internal/testhelpers"
+	"github.com/ory/kratos/persistence"
+)
+
+func TestPersister(ctx context.Context, conf *config.Config, p interface {
+	persistence.Persister
+}) func(t *testing.T) {
+	return func(t *testing.T) {
+		nid, p := testhelpers.NewNetworkUnlessExisting(t, ctx, p)
+
+		t.Run("login_attempts", func(t *testing.T) {
+			t.Run("case=should error when there are no failed attempts", func(t *testing.T) {
+				_, err := p.FindLoginAttempts(ctx, "no-attempts@ory.sh")
+				require.ErrorIs(t, err, sqlcon.ErrNoRows)
+			})
+
+			t.Run("case=should count failed attempts and lock the identifier", func(t *testing.T) {
+				id := "lockout@ory.sh"
+				for k := 1; k <= 3; k++ {
+					actual, err := p.RecordFailedLoginAttempt(ctx, id, 3, time.Hour, time.Hour)
+					require.NoError(t, err)
+					assert.Equal(t, k, actual.FailedAttempts)
+					assert.Equal(t, k == 3, actual.IsLocked(time.Now()))
+				}
+
+				actual, err := p.FindLoginAttempts(ctx, id)
+				require.NoError(t, err)
+				assert.Equal(t, nid, actual.NID)
+				assert.True(t, actual.IsLocked(time.Now()))
+
+				t.Run("not work on another network", func(t *testing.T) {
+					_, p := testhelpers.NewNetwork(t, ctx, p)
+					_, err := p.FindLoginAttempts(ctx, id)
+					require.ErrorIs(t, err, sqlcon.ErrNoRows)
+				})
+			})
+
+			t.Run("case=should start over once the window elapsed", func(t *testing.T) {
+				id := "window@ory.sh"
+				_, err := p.RecordFailedLoginAttempt(ctx, id, 2, time.Millisecond, time.Hour)
+				require.NoError(t, err)
+
+				time.Sleep(time.Millisecond * 10)
+
+				actual, err := p.RecordFailedLoginAttempt(ctx, id, 2, time.Millisecond, time.Hour)
+				require.NoError(t, err)
+				assert.Equal(t, 1, actual.FailedAttempts)
+				assert.False(t, actual.IsLocked(time.Now()))
+			})
+
+			t.Run("case=should start over once the lockout elapsed", func(t *testing.T) {
+				id := "unlock@ory.sh"
+				actual, err := p.RecordFailedLoginAttempt(ctx, id, 1, time.Hour, time.Millisecond*50)
+				require.NoError(t, err)
+				assert.True(t, actual.IsLocked(time.Now()))
+
+				time.Sleep(time.Millisecond * 100)
+
+				actual, err = p.RecordFailedLoginAttempt(ctx, id, 2, time.Hour, time.Hour)
+				require.NoError(t, err)
+				assert.Equal(t, 1, actual.FailedAttempts)
+				assert.False(t, actual.IsLocked(time.Now()))
+			})
+
+			t.Run("case=should reset failed attempts", func(t *testing.T) {
+				id := "reset@ory.sh"
+				_, err := p.RecordFailedLoginAttempt(ctx, id, 5, time.Hour, time.Hour)
+				require.NoError(t, err)
+
+				require.NoError(t, p.ResetLoginAttempts(ctx, id))
+				_, err = p.FindLoginAttempts(ctx, id)
+				require.ErrorIs(t, err, sqlcon.ErrNoRows)
+			})
+		})
+	}
+}
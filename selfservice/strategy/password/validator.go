@@ -8,6 +8,7 @@ import (
 	"github.com/hashicorp/go-retryablehttp"
 
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
 
 	/* #nosec G505 sha1 is used for k-anonymity */
 	"crypto/sha1"
@@ -18,6 +19,7 @@ import (
 	"sync"
 
 	"github.com/arbovm/levenshtein"
+	"github.com/tidwall/gjson"
 
 	"github.com/ory/x/httpx"
 
@@ -58,9 +60,6 @@ type DefaultPasswordValidator struct {
 	reg    validatorDependencies
 	Client *retryablehttp.Client
 	hashes map[string]int64
-
-	minIdentifierPasswordDist            int
-	maxIdentifierPasswordSubstrThreshold float32
 }
 
 type validatorDependencies interface {
@@ -69,10 +68,31 @@ type validatorDependencies interface {
 
 func NewDefaultPasswordValidatorStrategy(reg validatorDependencies) *DefaultPasswordValidator {
 	return &DefaultPasswordValidator{
-		Client:                    httpx.NewResilientClient(httpx.ResilientClientWithConnectionTimeout(time.Second)),
-		reg:                       reg,
-		hashes:                    map[string]int64{},
-		minIdentifierPasswordDist: 5, maxIdentifierPasswordSubstrThreshold: 0.5}
+		Client: httpx.NewResilientClient(httpx.ResilientClientWithConnectionTimeout(time.Second)),
+		reg:    reg,
+		hashes: map[string]int64{},
+	}
+}
+
+// ComparedIdentifiers returns the identifiers which are passed to Validator.Validate for the identity: its
+// credentials identifiers and the values of the traits listed in
+// `selfservice.methods.password.config.identifier_similarity.traits`.
+func ComparedIdentifiers(c *config.Config, i *identity.Identity, identifiers []string) []string {
+	compared := append([]string{}, identifiers...)
+	for _, path := range c.PasswordPolicyConfig().IdentifierSimilarity.Traits {
+		res := gjson.GetBytes(i.Traits, path)
+		if !res.IsArray() {
+			res = gjson.Parse("[" + res.Raw + "]")
+		}
+
+		res.ForEach(func(_, v gjson.Result) bool {
+			if v.Type == gjson.String && len(v.String()) > 0 {
+				compared = append(compared, v.String())
+			}
+			return true
+		})
+	}
+	return compared
 }
 
 func b20(src []byte) string {
@@ -149,11 +169,17 @@ func (s *DefaultPasswordValidator) Validate(ctx context.Context, identifier, pas
 		return errors.Errorf("password length must be at least 6 characters but only got %d", len(password))
 	}
 
-	compIdentifier, compPassword := strings.ToLower(identifier), strings.ToLower(password)
-	dist := levenshtein.Distance(compIdentifier, compPassword)
-	lcs := float32(lcsLength(compIdentifier, compPassword)) / float32(len(compPassword))
-	if dist < s.minIdentifierPasswordDist || lcs > s.maxIdentifierPasswordSubstrThreshold {
-		return errors.Errorf("the password is too similar to the user identifier")
+	if similarity := s.reg.Config(ctx).PasswordPolicyConfig().IdentifierSimilarity; similarity.Enabled {
+		compIdentifier, compPassword := strings.ToLower(identifier), strings.ToLower(password)
+		if compIdentifier == compPassword {
+			return errors.Errorf("the password must not be equal to the user identifier")
+		}
+
+		dist := levenshtein.Distance(compIdentifier, compPassword)
+		lcs := float64(lcsLength(compIdentifier, compPassword)) / float64(len(compPassword))
+		if dist < similarity.MinDistance || lcs > similarity.MaxSubstringRatio {
+			return errors.Errorf("the password is too similar to the user identifier")
+		}
 	}
 
 	/* #nosec G401 sha1 is used for k-anonymity */
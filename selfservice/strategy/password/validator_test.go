@@ -12,9 +12,11 @@ import (
 
 	"github.com/ory/x/httpx"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/internal"
 	"github.com/ory/kratos/selfservice/strategy/password"
 )
@@ -110,6 +112,51 @@ func TestDefaultPasswordValidationStrategy(t *testing.T) {
 		})
 	})
 
+	t.Run("identifier similarity", func(t *testing.T) {
+		conf, reg := internal.NewFastRegistryWithMocks(t)
+		s := password.NewDefaultPasswordValidatorStrategy(reg)
+		fakeClient := NewFakeHTTPClient()
+		s.Client = httpx.NewResilientClient(httpx.ResilientClientWithClient(&fakeClient.Client), httpx.ResilientClientWithMaxRetry(1), httpx.ResilientClientWithConnectionTimeout(time.Millisecond))
+		fakeClient.RespondWith(http.StatusOK, "")
+
+		t.Run("case=rejects equal password", func(t *testing.T) {
+			err := s.Validate(context.Background(), "Hello@Example.com", "hello@example.com")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "must not be equal")
+		})
+
+		t.Run("case=strictness is configurable", func(t *testing.T) {
+			require.NoError(t, s.Validate(context.Background(), "hello@example.com", "hello-xq81zt93"))
+
+			conf.MustSet(config.ViperKeyPasswordIdentifierSimilarityMaxSubstringRatio, 0.3)
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeyPasswordIdentifierSimilarityMaxSubstringRatio, 0.5)
+			})
+			require.Error(t, s.Validate(context.Background(), "hello@example.com", "hello-xq81zt93"))
+		})
+
+		t.Run("case=can be disabled", func(t *testing.T) {
+			conf.MustSet(config.ViperKeyPasswordIdentifierSimilarityEnabled, false)
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeyPasswordIdentifierSimilarityEnabled, true)
+			})
+			require.NoError(t, s.Validate(context.Background(), "hello@example.com", "hello@example.com"))
+		})
+
+		t.Run("case=compares designated traits", func(t *testing.T) {
+			i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+			i.Traits = identity.Traits(`{"email":"hello@example.com","name":{"first":"Alexandria"},"nicknames":["alex","lexi"]}`)
+
+			assert.Equal(t, []string{"hello@example.com"}, password.ComparedIdentifiers(conf, i, []string{"hello@example.com"}))
+
+			conf.MustSet(config.ViperKeyPasswordIdentifierSimilarityTraits, []string{"name.first", "nicknames", "missing"})
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeyPasswordIdentifierSimilarityTraits, []string{})
+			})
+			assert.Equal(t, []string{"hello@example.com", "Alexandria", "alex", "lexi"}, password.ComparedIdentifiers(conf, i, []string{"hello@example.com"}))
+		})
+	})
+
 	t.Run("max breaches", func(t *testing.T) {
 		conf, reg := internal.NewFastRegistryWithMocks(t)
 		s := password.NewDefaultPasswordValidatorStrategy(reg)
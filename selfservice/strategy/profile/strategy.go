@@ -13,9 +13,9 @@ import (
 	"github.com/tidwall/sjson"
 
 	"github.com/ory/herodot"
-	"github.com/ory/jsonschema/v3"
 	"github.com/ory/kratos/continuity"
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/hash"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/schema"
 	"github.com/ory/kratos/selfservice/errorx"
@@ -48,6 +48,8 @@ type (
 		identity.ManagementProvider
 		identity.PrivilegedPoolProvider
 
+		hash.HashProvider
+
 		errorx.ManagementProvider
 
 		settings.HookExecutorProvider
@@ -55,6 +57,7 @@ type (
 		settings.FlowPersistenceProvider
 		settings.StrategyProvider
 		settings.HooksProvider
+		settings.CurrentPasswordLockoutProvider
 
 		schema.IdentityTraitsProvider
 	}
@@ -86,7 +89,7 @@ func (s *Strategy) PopulateSettingsMethod(r *http.Request, id *identity.Identity
 	}
 
 	// use a schema compiler that disables identifiers
-	schemaCompiler := jsonschema.NewCompiler()
+	schemaCompiler := schema.NewCompiler()
 	nodes, err := container.NodesFromJSONSchema(node.ProfileGroup, traitsSchema.URL, "", schemaCompiler)
 	if err != nil {
 		return err
@@ -98,6 +101,9 @@ func (s *Strategy) PopulateSettingsMethod(r *http.Request, id *identity.Identity
 
 	f.UI.SetCSRF(s.d.GenerateCSRFToken(r))
 	f.UI.UpdateNodesFromJSON(json.RawMessage(id.Traits), "traits", node.ProfileGroup)
+	if s.d.Config(r.Context()).SelfServiceFlowSettingsRequiresCurrentPassword(s.SettingsStrategyID()) {
+		f.UI.Nodes.Upsert(settings.NewCurrentPasswordNode(node.ProfileGroup))
+	}
 	f.UI.Nodes.Append(node.NewInputField("method", "profile", node.ProfileGroup, node.InputAttributeTypeSubmit).WithMetaLabel(text.NewInfoNodeLabelSave()))
 
 	return nil
@@ -151,6 +157,10 @@ func (s *Strategy) continueFlow(w http.ResponseWriter, r *http.Request, ctxUpdat
 		return errors.WithStack(herodot.ErrBadRequest.WithReasonf("Did not receive any value changes."))
 	}
 
+	if err := settings.VerifyCurrentPassword(r.Context(), s.d, s.SettingsStrategyID(), ctxUpdate.GetSessionIdentity().ID, p.CurrentPassword); err != nil {
+		return err
+	}
+
 	if err := s.hydrateForm(r, ctxUpdate.Flow, ctxUpdate.Session, p.Traits); err != nil {
 		return err
 	}
@@ -205,6 +215,13 @@ type submitSelfServiceBrowserSettingsProfileStrategyFlow struct {
 	// type: string
 	Method string `json:"method"`
 
+	// CurrentPassword is the identity's current password
+	//
+	// Only required if the profile method is listed in `selfservice.flows.settings.require_current_password`.
+	//
+	// type: string
+	CurrentPassword string `json:"current_password"`
+
 	// FlowIDRequestID is the flow ID.
 	//
 	// swagger:ignore
@@ -29,6 +29,7 @@ import (
 	"github.com/ory/kratos/internal"
 	"github.com/ory/kratos/internal/testhelpers"
 	"github.com/ory/kratos/selfservice/flow/settings"
+	"github.com/ory/kratos/text"
 	"github.com/ory/kratos/x"
 	"github.com/ory/x/assertx"
 	"github.com/ory/x/httpx"
@@ -527,6 +528,10 @@ func TestStrategyTraits(t *testing.T) {
 			assert.Equal(t, 15.0, gjson.Get(actual, "ui.nodes.#(attributes.name==traits.numby).attributes.value").Value(), "%s", actual)
 			assert.Equal(t, 9001.0, gjson.Get(actual, "ui.nodes.#(attributes.name==traits.should_big_number).attributes.value").Value(), "%s", actual)
 			assert.Equal(t, "this is such a long string, amazing stuff!", gjson.Get(actual, "ui.nodes.#(attributes.name==traits.should_long_string).attributes.value").Value(), "%s", actual)
+
+			assert.EqualValues(t, text.InfoSelfServiceSettingsUpdateSuccessTrait, gjson.Get(actual, "ui.nodes.#(attributes.name==traits.email).messages.0.id").Int(), "%s", actual)
+			assert.EqualValues(t, "email", gjson.Get(actual, "ui.nodes.#(attributes.name==traits.email).messages.0.context.trait").String(), "%s", actual)
+			assert.EqualValues(t, text.InfoSelfServiceSettingsUpdateSuccess, gjson.Get(actual, "ui.messages.0.id").Int(), "%s", actual)
 		}
 
 		var payload = func(newEmail string) func(v url.Values) {
@@ -588,7 +593,7 @@ func TestStrategyTraits(t *testing.T) {
 
 		values := testhelpers.SDKFormFieldsToURLValues(f.Ui.Nodes)
 		values.Set("method", settings.StrategyProfile)
-		values.Set("traits.should_big_number", "9001")
+		values.Set("traits.should_big_number", "9002")
 		res, err := browserUser1.PostForm(f.Ui.Action, values)
 
 		require.NoError(t, err)
@@ -599,6 +604,38 @@ func TestStrategyTraits(t *testing.T) {
 		assert.True(t, returned, "%d - %s", res.StatusCode, body)
 	})
 
+	t.Run("description=should not save unchanged traits", func(t *testing.T) {
+		setPrivileged(t)
+
+		var payload = func(v url.Values) {
+			v.Set("method", settings.StrategyProfile)
+		}
+
+		var check = func(t *testing.T, actual string) {
+			assert.NotEqual(t, settings.StateSuccess, gjson.Get(actual, "state").String(), "%s", actual)
+			assert.Equal(t, text.NewInfoSelfServiceSettingsNoChanges().Text, gjson.Get(actual, "ui.messages.0.text").String(), "%s", actual)
+		}
+
+		t.Run("type=api", func(t *testing.T) {
+			actual := expectSuccess(t, true, apiUser1, payload)
+			check(t, gjson.Get(actual, "flow").Raw)
+		})
+
+		t.Run("type=browser", func(t *testing.T) {
+			check(t, expectSuccess(t, false, browserUser1, payload))
+		})
+
+		t.Run("case=saves unchanged traits if configured", func(t *testing.T) {
+			conf.MustSet(config.ViperKeySelfServiceSettingsUnchanged, "save")
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeySelfServiceSettingsUnchanged, "skip")
+			})
+
+			actual := expectSuccess(t, false, browserUser1, payload)
+			assert.EqualValues(t, settings.StateSuccess, gjson.Get(actual, "state").String(), "%s", actual)
+		})
+	})
+
 	// Update the login endpoint to auto-accept any incoming login request!
 	_ = testhelpers.NewSettingsLoginAcceptAPIServer(t, adminClient, conf)
 
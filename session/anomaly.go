@@ -0,0 +1,183 @@
+package session
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/event"
+	"github.com/ory/kratos/x"
+)
+
+const (
+	// AnomalyHookEmitEvent emits an event.TypeSessionAnomaly event.
+	AnomalyHookEmitEvent = "emit_event"
+
+	// AnomalyHookRequireReauthentication revokes the session so that the user has to sign in again.
+	AnomalyHookRequireReauthentication = "require_reauthentication"
+
+	// AnomalyReasonIPAddress is reported if a session is used from a different network.
+	AnomalyReasonIPAddress = "ip_address"
+
+	// AnomalyReasonFingerprint is reported if a session is used by a client with a different fingerprint.
+	AnomalyReasonFingerprint = "fingerprint"
+)
+
+// ErrSessionReauthenticationRequired is returned if a session was revoked because it was used by a client which
+// differs markedly from the client it was issued to.
+var ErrSessionReauthenticationRequired = herodot.ErrUnauthorized.WithError("the session was used by an unusual client").WithReason("This session was used from an unusual network or device. Please sign in again.")
+
+type (
+	// Anomaly describes how the client which uses a session differs from the client the session was issued to.
+	Anomaly struct {
+		// Reasons lists what differs, for example AnomalyReasonIPAddress.
+		Reasons []string
+
+		// IPAddress is the IP address of the client which uses the session.
+		IPAddress string
+	}
+
+	// AnomalyDetector compares the client which sent the request with the client the session was issued to.
+	// It returns nil if they do not differ markedly.
+	AnomalyDetector interface {
+		DetectSessionAnomaly(r *http.Request, s *Session) (*Anomaly, error)
+	}
+
+	// AnomalyHook is executed if an anomaly was detected. The session is rejected if it returns an error.
+	AnomalyHook interface {
+		ExecuteSessionAnomalyHook(r *http.Request, s *Session, a *Anomaly) error
+	}
+
+	AnomalyProvider interface {
+		SessionAnomalyDetector() AnomalyDetector
+		SessionAnomalyHooks(ctx context.Context) []AnomalyHook
+	}
+
+	anomalyDetectorDependencies interface {
+		config.Provider
+	}
+	// DefaultAnomalyDetector reports sessions which are used from a different /24 (IPv4) or /64 (IPv6)
+	// network, or by a client whose fingerprint differs (see `session.fingerprint`).
+	DefaultAnomalyDetector struct {
+		d anomalyDetectorDependencies
+	}
+
+	anomalyHookDependencies interface {
+		event.EmitterProvider
+		PersistenceProvider
+	}
+	// AnomalyEventHook emits an event.TypeSessionAnomaly event.
+	AnomalyEventHook struct {
+		d anomalyHookDependencies
+	}
+	// AnomalyReauthenticationHook revokes the session and rejects it with ErrSessionReauthenticationRequired.
+	AnomalyReauthenticationHook struct {
+		d anomalyHookDependencies
+	}
+)
+
+var (
+	_ AnomalyDetector = new(DefaultAnomalyDetector)
+	_ AnomalyHook     = new(AnomalyEventHook)
+	_ AnomalyHook     = new(AnomalyReauthenticationHook)
+)
+
+func NewDefaultAnomalyDetector(d anomalyDetectorDependencies) *DefaultAnomalyDetector {
+	return &DefaultAnomalyDetector{d: d}
+}
+
+func (a *DefaultAnomalyDetector) DetectSessionAnomaly(r *http.Request, s *Session) (*Anomaly, error) {
+	c := a.d.Config(r.Context())
+
+	var reasons []string
+	current := x.ClientIP(r, c.TrustedProxies())
+	if issued := net.ParseIP(s.IPAddress); issued != nil && current != nil && !sameNetwork(issued, current) {
+		reasons = append(reasons, AnomalyReasonIPAddress)
+	}
+
+	mismatching, err := s.MismatchingFingerprintAttributes(r, c.SessionFingerprintAttributes())
+	if err != nil {
+		return nil, err
+	} else if len(mismatching) > 0 {
+		reasons = append(reasons, AnomalyReasonFingerprint)
+	}
+
+	if len(reasons) == 0 {
+		return nil, nil
+	}
+
+	anomaly := &Anomaly{Reasons: reasons}
+	if current != nil {
+		anomaly.IPAddress = current.String()
+	}
+	return anomaly, nil
+}
+
+// sameNetwork returns true if both addresses are in the same /24 (IPv4) or /64 (IPv6) network. Clients often
+// change their address within these networks, for example when their lease is renewed.
+func sameNetwork(a, b net.IP) bool {
+	if a4, b4 := a.To4(), b.To4(); a4 != nil || b4 != nil {
+		mask := net.CIDRMask(24, 32)
+		return a4 != nil && b4 != nil && a4.Mask(mask).Equal(b4.Mask(mask))
+	}
+
+	mask := net.CIDRMask(64, 128)
+	return a.Mask(mask).Equal(b.Mask(mask))
+}
+
+func NewAnomalyEventHook(d anomalyHookDependencies) *AnomalyEventHook {
+	return &AnomalyEventHook{d: d}
+}
+
+func (h *AnomalyEventHook) ExecuteSessionAnomalyHook(r *http.Request, s *Session, a *Anomaly) error {
+	h.d.EventEmitter().Emit(r.Context(), event.TypeSessionAnomaly, &event.Data{
+		IdentityID: s.IdentityID, SessionID: &s.ID, AnomalyReasons: a.Reasons})
+	return nil
+}
+
+func NewAnomalyReauthenticationHook(d anomalyHookDependencies) *AnomalyReauthenticationHook {
+	return &AnomalyReauthenticationHook{d: d}
+}
+
+func (h *AnomalyReauthenticationHook) ExecuteSessionAnomalyHook(r *http.Request, s *Session, _ *Anomaly) error {
+	if err := h.d.SessionPersister().RevokeSessionByToken(r.Context(), s.Token); err != nil {
+		return err
+	}
+	return errors.WithStack(ErrSessionReauthenticationRequired)
+}
+
+func checkAnomaly(d interface {
+	AnomalyProvider
+	x.LoggingProvider
+}, r *http.Request, s *Session) error {
+	hooks := d.SessionAnomalyHooks(r.Context())
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	anomaly, err := d.SessionAnomalyDetector().DetectSessionAnomaly(r, s)
+	if err != nil {
+		return err
+	} else if anomaly == nil {
+		return nil
+	}
+
+	d.Audit().
+		WithRequest(r).
+		WithField("session_id", s.ID).
+		WithField("identity_id", s.IdentityID).
+		WithField("anomaly_reasons", anomaly.Reasons).
+		Warn("A session was used by a client which differs markedly from the client the session was issued to.")
+
+	for _, hook := range hooks {
+		if err := hook.ExecuteSessionAnomalyHook(r, s, anomaly); err != nil {
+			return err
+		}
+	}
+	return nil
+}
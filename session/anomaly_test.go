@@ -0,0 +1,174 @@
+package session_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/event"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/session"
+)
+
+type anomalyRecorder struct {
+	anomalies []*session.Anomaly
+}
+
+func (r *anomalyRecorder) ExecuteSessionAnomalyHook(_ *http.Request, _ *session.Session, a *session.Anomaly) error {
+	r.anomalies = append(r.anomalies, a)
+	return nil
+}
+
+type anomalyDetectorFunc func(r *http.Request, s *session.Session) (*session.Anomaly, error)
+
+func (f anomalyDetectorFunc) DetectSessionAnomaly(r *http.Request, s *session.Session) (*session.Anomaly, error) {
+	return f(r, s)
+}
+
+func TestAnomaly(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/fake-session.schema.json")
+
+	newRequest := func(ip, userAgent string, s *session.Session) *http.Request {
+		r := httptest.NewRequest("GET", "/sessions/whoami", nil)
+		r.RemoteAddr = net.JoinHostPort(ip, "54321")
+		r.Header.Set("User-Agent", userAgent)
+		if s != nil {
+			r.Header.Set("X-Session-Token", s.Token)
+		}
+		return r
+	}
+
+	issue := func(t *testing.T, ip string) *session.Session {
+		i := identity.Identity{Traits: []byte("{}")}
+		require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(ctx, &i))
+
+		s := session.NewActiveSession(&i, conf, time.Now())
+		require.NoError(t, s.BindFingerprint(conf, newRequest(ip, "Go-http-client/1.1", nil)))
+		require.NoError(t, reg.SessionPersister().CreateSession(ctx, s))
+		assert.Equal(t, ip, s.IPAddress)
+		return s
+	}
+
+	setHooks := func(t *testing.T, hooks ...string) {
+		conf.MustSet(config.ViperKeySessionAnomalyHooks, hooks)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySessionAnomalyHooks, []string{})
+		})
+	}
+
+	fetch := func(r *http.Request) error {
+		_, err := reg.SessionManager().FetchFromRequest(ctx, r)
+		return err
+	}
+
+	t.Run("case=does not detect anomalies without hooks", func(t *testing.T) {
+		s := issue(t, "203.0.113.5")
+		require.NoError(t, fetch(newRequest("198.51.100.7", "curl/7.64.1", s)))
+	})
+
+	t.Run("case=requires re-authentication if a token is replayed from a different network", func(t *testing.T) {
+		setHooks(t, session.AnomalyHookRequireReauthentication)
+		s := issue(t, "203.0.113.5")
+
+		require.NoError(t, fetch(newRequest("203.0.113.5", "Go-http-client/1.1", s)))
+		require.NoError(t, fetch(newRequest("203.0.113.77", "Go-http-client/1.1", s)), "address changes within the network must be ignored")
+
+		err := fetch(newRequest("198.51.100.7", "Go-http-client/1.1", s))
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, session.ErrSessionReauthenticationRequired), "%+v", err)
+
+		err = fetch(newRequest("203.0.113.5", "Go-http-client/1.1", s))
+		assert.True(t, errors.Is(err, session.ErrNoActiveSessionFound), "the session must have been revoked: %+v", err)
+	})
+
+	t.Run("case=compares IPv6 networks", func(t *testing.T) {
+		setHooks(t, session.AnomalyHookRequireReauthentication)
+		s := issue(t, "2001:db8:1:2::1")
+
+		require.NoError(t, fetch(newRequest("2001:db8:1:2::ffff", "Go-http-client/1.1", s)))
+		assert.Error(t, fetch(newRequest("2001:db8:1:3::1", "Go-http-client/1.1", s)))
+	})
+
+	t.Run("case=detects a different device", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySessionFingerprintMode, "log")
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySessionFingerprintMode, "off")
+		})
+
+		recorder := new(anomalyRecorder)
+		reg.WithSessionAnomalyHooks(recorder)
+		t.Cleanup(func() {
+			reg.WithSessionAnomalyHooks()
+		})
+
+		s := issue(t, "203.0.113.5")
+		require.NoError(t, fetch(newRequest("203.0.113.5", "Go-http-client/1.1", s)))
+		require.Empty(t, recorder.anomalies)
+
+		require.NoError(t, fetch(newRequest("198.51.100.7", "curl/7.64.1", s)))
+		require.Len(t, recorder.anomalies, 1)
+		assert.Equal(t, []string{session.AnomalyReasonIPAddress, session.AnomalyReasonFingerprint}, recorder.anomalies[0].Reasons)
+		assert.Equal(t, "198.51.100.7", recorder.anomalies[0].IPAddress)
+	})
+
+	t.Run("case=emits an event", func(t *testing.T) {
+		setHooks(t, session.AnomalyHookEmitEvent)
+
+		received := make(chan []byte, 1)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			received <- body
+		}))
+		t.Cleanup(ts.Close)
+		conf.MustSet(config.ViperKeyEventsSinkURL, ts.URL)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyEventsSinkURL, "")
+		})
+
+		s := issue(t, "203.0.113.5")
+		require.NoError(t, fetch(newRequest("198.51.100.7", "Go-http-client/1.1", s)), "emitting an event must not reject the session")
+
+		select {
+		case body := <-received:
+			assert.Equal(t, event.TypeSessionAnomaly, gjson.GetBytes(body, "type").String())
+			assert.Equal(t, s.ID.String(), gjson.GetBytes(body, "data.session_id").String())
+			assert.Equal(t, session.AnomalyReasonIPAddress, gjson.GetBytes(body, "data.anomaly_reasons.0").String())
+		case <-time.After(5 * time.Second):
+			t.Fatal("the event was not emitted")
+		}
+	})
+
+	t.Run("case=uses a custom detector", func(t *testing.T) {
+		setHooks(t, session.AnomalyHookRequireReauthentication)
+		reg.WithSessionAnomalyDetector(anomalyDetectorFunc(func(r *http.Request, s *session.Session) (*session.Anomaly, error) {
+			if r.Header.Get("X-ASN") == "64496" {
+				return nil, nil
+			}
+			return &session.Anomaly{Reasons: []string{"asn"}}, nil
+		}))
+		t.Cleanup(func() {
+			reg.WithSessionAnomalyDetector(nil)
+		})
+
+		s := issue(t, "203.0.113.5")
+		r := newRequest("198.51.100.7", "Go-http-client/1.1", s)
+		r.Header.Set("X-ASN", "64496")
+		require.NoError(t, fetch(r))
+
+		r.Header.Set("X-ASN", "64511")
+		assert.True(t, errors.Is(fetch(r), session.ErrSessionReauthenticationRequired))
+	})
+}
@@ -0,0 +1,116 @@
+package session
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/kratos/x"
+)
+
+// ErrCacheMiss is returned by a SessionCache if no session is cached for a token.
+var ErrCacheMiss = errors.New("session is not cached")
+
+type (
+	// SessionCache caches the sessions which the session manager resolves from session tokens, so that
+	// requests with a known token do not hit the database. The SQL persister invalidates cached sessions
+	// whenever it deletes, revokes or updates them or changes their identity, see InvalidateCachedIdentity.
+	//
+	// Implementations must store and return copies of sessions because callers modify them.
+	SessionCache interface {
+		// Get returns the session cached for the token or ErrCacheMiss if there is none.
+		Get(ctx context.Context, token string) (*Session, error)
+
+		// Set caches the session for its token until the ttl expires. Callers never pass a ttl which
+		// exceeds the session's expiry.
+		Set(ctx context.Context, s *Session, ttl time.Duration) error
+
+		// InvalidateToken removes the session with the given token from the cache.
+		InvalidateToken(ctx context.Context, token string) error
+
+		// InvalidateSession removes the session with the given ID from the cache.
+		InvalidateSession(ctx context.Context, id uuid.UUID) error
+
+		// InvalidateIdentity removes all sessions of the given identity from the cache.
+		InvalidateIdentity(ctx context.Context, id uuid.UUID) error
+	}
+
+	SessionCacheProvider interface {
+		SessionCache() SessionCache
+	}
+
+	cacheDependencies interface {
+		SessionCacheProvider
+		WhoamiCacheProvider
+	}
+
+	// NoopSessionCache caches nothing. It is the default SessionCache.
+	NoopSessionCache struct{}
+)
+
+var _ SessionCache = new(NoopSessionCache)
+
+func (NoopSessionCache) Get(context.Context, string) (*Session, error) {
+	return nil, errors.WithStack(ErrCacheMiss)
+}
+
+func (NoopSessionCache) Set(context.Context, *Session, time.Duration) error {
+	return nil
+}
+
+func (NoopSessionCache) InvalidateToken(context.Context, string) error {
+	return nil
+}
+
+func (NoopSessionCache) InvalidateSession(context.Context, uuid.UUID) error {
+	return nil
+}
+
+func (NoopSessionCache) InvalidateIdentity(context.Context, uuid.UUID) error {
+	return nil
+}
+
+// cacheFor returns the session cache unless the context uses a tenant's database. Tenants share the
+// cache's key space, so their sessions are neither cached nor invalidated.
+func cacheFor(ctx context.Context, d SessionCacheProvider) SessionCache {
+	if _, ok := x.TenantConnectionFromContext(ctx); ok {
+		return NoopSessionCache{}
+	}
+	return d.SessionCache()
+}
+
+// InvalidateCachedToken removes the session with the given token from the session cache and the whoami cache.
+func InvalidateCachedToken(ctx context.Context, d cacheDependencies, token string) error {
+	if _, ok := x.TenantConnectionFromContext(ctx); !ok {
+		d.SessionWhoamiCache().InvalidateToken(token)
+	}
+	return cacheFor(ctx, d).InvalidateToken(ctx, token)
+}
+
+// InvalidateCachedSession removes the session with the given ID from the session cache and the whoami cache.
+func InvalidateCachedSession(ctx context.Context, d cacheDependencies, id uuid.UUID) error {
+	if _, ok := x.TenantConnectionFromContext(ctx); !ok {
+		d.SessionWhoamiCache().InvalidateSession(id)
+	}
+	return cacheFor(ctx, d).InvalidateSession(ctx, id)
+}
+
+// InvalidateCachedIdentity removes all sessions of the given identity from the session cache and the whoami
+// cache. It must be called whenever the identity changes, because cached sessions embed it.
+func InvalidateCachedIdentity(ctx context.Context, d cacheDependencies, id uuid.UUID) error {
+	if _, ok := x.TenantConnectionFromContext(ctx); !ok {
+		d.SessionWhoamiCache().InvalidateIdentity(id)
+	}
+	return cacheFor(ctx, d).InvalidateIdentity(ctx, id)
+}
+
+// cacheTTL returns the time the session may be cached for, which is the configured ttl but never longer
+// than the session is valid. It returns zero or less if the session must not be cached.
+func cacheTTL(s *Session, ttl time.Duration) time.Duration {
+	if remaining := time.Until(s.ExpiresAt); remaining < ttl {
+		return remaining
+	}
+	return ttl
+}
@@ -0,0 +1,250 @@
+package session_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/x"
+)
+
+type memorySessionCache struct {
+	sync.Mutex
+	items map[string]memorySessionCacheItem
+	hits  int
+}
+
+type memorySessionCacheItem struct {
+	session   session.Session
+	ttl       time.Duration
+	expiresAt time.Time
+}
+
+var _ session.SessionCache = new(memorySessionCache)
+
+func newMemorySessionCache() *memorySessionCache {
+	return &memorySessionCache{items: make(map[string]memorySessionCacheItem)}
+}
+
+func (c *memorySessionCache) Get(_ context.Context, token string) (*session.Session, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	item, ok := c.items[token]
+	if !ok || time.Now().After(item.expiresAt) {
+		return nil, errors.WithStack(session.ErrCacheMiss)
+	}
+	c.hits++
+	s := item.session
+	return &s, nil
+}
+
+func (c *memorySessionCache) Set(_ context.Context, s *session.Session, ttl time.Duration) error {
+	c.Lock()
+	defer c.Unlock()
+
+	c.items[s.Token] = memorySessionCacheItem{session: *s, ttl: ttl, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (c *memorySessionCache) InvalidateToken(_ context.Context, token string) error {
+	c.Lock()
+	defer c.Unlock()
+
+	delete(c.items, token)
+	return nil
+}
+
+func (c *memorySessionCache) InvalidateSession(_ context.Context, id uuid.UUID) error {
+	return c.invalidate(func(s *session.Session) bool { return s.ID == id })
+}
+
+func (c *memorySessionCache) InvalidateIdentity(_ context.Context, id uuid.UUID) error {
+	return c.invalidate(func(s *session.Session) bool { return s.IdentityID == id })
+}
+
+func (c *memorySessionCache) invalidate(match func(s *session.Session) bool) error {
+	c.Lock()
+	defer c.Unlock()
+
+	for k, item := range c.items {
+		if match(&item.session) {
+			delete(c.items, k)
+		}
+	}
+	return nil
+}
+
+func (c *memorySessionCache) item(token string) (memorySessionCacheItem, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	item, ok := c.items[token]
+	return item, ok
+}
+
+func TestSessionCache(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/fake-session.schema.json")
+	conf.MustSet(config.ViperKeySessionLifespan, "1h")
+	conf.MustSet(config.ViperKeySessionCacheTTL, "1m")
+
+	c := newMemorySessionCache()
+	reg.WithSessionCache(c)
+
+	newSession := func(t *testing.T) *session.Session {
+		i := identity.Identity{Traits: []byte("{}")}
+		require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(ctx, &i))
+		s := session.NewActiveSession(&i, conf, time.Now())
+		require.NoError(t, reg.SessionPersister().CreateSession(ctx, s))
+		return s
+	}
+
+	newRequest := func(s *session.Session) *http.Request {
+		r := httptest.NewRequest("GET", "/sessions/whoami", nil)
+		r.Header.Set("Authorization", "Bearer "+s.Token)
+		return r
+	}
+
+	fetch := func(t *testing.T, s *session.Session) error {
+		_, err := reg.SessionManager().FetchFromRequest(ctx, newRequest(s))
+		return err
+	}
+
+	t.Run("case=should serve cached sessions", func(t *testing.T) {
+		s := newSession(t)
+
+		require.NoError(t, fetch(t, s))
+		_, ok := c.item(s.Token)
+		require.True(t, ok)
+
+		hits := c.hits
+		require.NoError(t, fetch(t, s))
+		assert.Equal(t, hits+1, c.hits)
+	})
+
+	t.Run("case=should not use the default cache", func(t *testing.T) {
+		_, reg := internal.NewFastRegistryWithMocks(t)
+		_, err := reg.SessionCache().Get(ctx, "token")
+		assert.ErrorIs(t, err, session.ErrCacheMiss)
+	})
+
+	t.Run("case=should invalidate the session on logout", func(t *testing.T) {
+		s := newSession(t)
+		require.NoError(t, fetch(t, s))
+
+		require.NoError(t, reg.SessionManager().PurgeFromRequest(ctx, httptest.NewRecorder(), newRequest(s)))
+		_, ok := c.item(s.Token)
+		assert.False(t, ok)
+		assert.ErrorIs(t, fetch(t, s), session.ErrNoActiveSessionFound)
+	})
+
+	t.Run("case=should invalidate the session on revocation", func(t *testing.T) {
+		for name, revoke := range map[string]func(s *session.Session) error{
+			"token": func(s *session.Session) error {
+				return reg.SessionPersister().RevokeSessionByToken(ctx, s.Token)
+			},
+			"session": func(s *session.Session) error {
+				return reg.SessionPersister().DeleteSession(ctx, s.ID)
+			},
+			"identity": func(s *session.Session) error {
+				_, err := reg.SessionPersister().DeleteSessionsByIdentity(ctx, s.IdentityID)
+				return err
+			},
+		} {
+			t.Run("by="+name, func(t *testing.T) {
+				s := newSession(t)
+				require.NoError(t, fetch(t, s))
+
+				require.NoError(t, revoke(s))
+				_, ok := c.item(s.Token)
+				assert.False(t, ok)
+				assert.ErrorIs(t, fetch(t, s), session.ErrNoActiveSessionFound)
+			})
+		}
+	})
+
+	t.Run("case=should invalidate the session on reauthentication", func(t *testing.T) {
+		s := newSession(t)
+		require.NoError(t, fetch(t, s))
+
+		require.NoError(t, reg.SessionPersister().UpdateSessionAuthenticatedAt(ctx, s.ID, time.Now()))
+		_, ok := c.item(s.Token)
+		assert.False(t, ok)
+	})
+
+	t.Run("case=should invalidate the session when an address is verified", func(t *testing.T) {
+		i := identity.Identity{Traits: []byte("{}")}
+		i.VerifiableAddresses = []identity.VerifiableAddress{{
+			Value:  x.NewUUID().String() + "@ory.sh",
+			Via:    identity.VerifiableAddressTypeEmail,
+			Status: identity.VerifiableAddressStatusPending,
+		}}
+		require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(ctx, &i))
+		s := session.NewActiveSession(&i, conf, time.Now())
+		require.NoError(t, reg.SessionPersister().CreateSession(ctx, s))
+		require.NoError(t, fetch(t, s))
+		reg.SessionWhoamiCache().Set(s.Token, s, time.Minute)
+
+		a, err := reg.PrivilegedIdentityPool().FindVerifiableAddressByValue(ctx, identity.VerifiableAddressTypeEmail, i.VerifiableAddresses[0].Value)
+		require.NoError(t, err)
+		a.Verified = true
+		require.NoError(t, reg.PrivilegedIdentityPool().UpdateVerifiableAddress(ctx, a))
+
+		_, ok := c.item(s.Token)
+		assert.False(t, ok)
+		_, ok = reg.SessionWhoamiCache().Get(s.Token)
+		assert.False(t, ok)
+	})
+
+	t.Run("case=should not cache the sessions of tenants", func(t *testing.T) {
+		s := newSession(t)
+		_, err := reg.SessionManager().FetchFromRequest(x.WithTenantConnection(ctx, reg.Persister().GetConnection(ctx)), newRequest(s))
+		require.NoError(t, err)
+
+		_, ok := c.item(s.Token)
+		assert.False(t, ok)
+	})
+
+	t.Run("case=should not cache sessions past their expiry", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySessionLifespan, "10s")
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySessionLifespan, "1h")
+		})
+
+		s := newSession(t)
+		require.NoError(t, fetch(t, s))
+
+		item, ok := c.item(s.Token)
+		require.True(t, ok)
+		assert.True(t, item.ttl <= 10*time.Second, "%s", item.ttl)
+	})
+
+	t.Run("case=should not serve expired sessions from the cache", func(t *testing.T) {
+		s := newSession(t)
+		expired := *s
+		expired.ExpiresAt = time.Now().Add(-time.Minute)
+		require.NoError(t, c.Set(ctx, &expired, time.Minute))
+
+		hits := c.hits
+		require.NoError(t, fetch(t, s))
+		assert.Equal(t, hits+1, c.hits)
+
+		item, ok := c.item(s.Token)
+		require.True(t, ok)
+		assert.Equal(t, s.ExpiresAt.Unix(), item.session.ExpiresAt.Unix())
+	})
+}
@@ -0,0 +1,87 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/google/go-jsonnet"
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/fetcher"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/x"
+)
+
+type (
+	claimsMapperDependencies interface {
+		config.Provider
+		x.LoggingProvider
+	}
+	ClaimsMapperProvider interface {
+		SessionClaimsMapper() *ClaimsMapper
+	}
+	// ClaimsMapper projects a session and its identity into a claims object using
+	// the Jsonnet mapper configured at `session.claims_mapper_url`.
+	ClaimsMapper struct {
+		d claimsMapperDependencies
+		f *fetcher.Fetcher
+	}
+)
+
+func NewClaimsMapper(d claimsMapperDependencies) *ClaimsMapper {
+	return &ClaimsMapper{d: d, f: fetcher.NewFetcher()}
+}
+
+// MapClaims evaluates the claims mapper for the given session. It returns nil if no
+// claims mapper is configured.
+//
+// The mapper receives the session (including the identity but without credentials)
+// as `std.extVar('session')` and must return an object with key `claims`.
+func (m *ClaimsMapper) MapClaims(ctx context.Context, s *Session) (json.RawMessage, error) {
+	mapper := m.d.Config(ctx).SessionClaimsMapperURL()
+	if mapper == nil {
+		return nil, nil
+	}
+
+	jn, err := m.f.Fetch(mapper.String())
+	if err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.
+			WithReasonf("Unable to fetch the session claims mapper.").WithDebug(err.Error()))
+	}
+
+	declassified := *s
+	declassified.Claims = nil
+	if s.Identity != nil {
+		declassified.Identity = s.Identity.CopyWithoutCredentials()
+	}
+
+	var input bytes.Buffer
+	if err := json.NewEncoder(&input).Encode(&declassified); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	vm := jsonnet.MakeVM()
+	vm.ExtCode("session", input.String())
+	evaluated, err := vm.EvaluateSnippet(mapper.String(), jn.String())
+	if err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.
+			WithReasonf("Unable to evaluate the session claims mapper.").WithDebug(err.Error()))
+	}
+
+	claims := gjson.Get(evaluated, "claims")
+	if !claims.IsObject() {
+		m.d.Logger().
+			WithField("session_id", s.ID).
+			WithField("mapper_jsonnet_output", evaluated).
+			WithField("mapper_jsonnet_url", mapper.String()).
+			Error("Session claims Jsonnet mapper did not return an object for key claims. Please check your Jsonnet code!")
+		return nil, errors.WithStack(herodot.ErrInternalServerError.
+			WithReasonf(`The session claims mapper must return an object for key "claims".`))
+	}
+
+	return json.RawMessage(claims.Raw), nil
+}
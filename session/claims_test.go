@@ -0,0 +1,45 @@
+package session_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/session"
+)
+
+func TestClaimsMapper(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+
+	i := identity.NewIdentity(config.DefaultIdentityTraitsSchemaID)
+	i.Traits = identity.Traits(`{"email":"foo@ory.sh"}`)
+	s := session.NewActiveSession(i, conf, time.Now())
+
+	t.Run("case=returns nothing if no mapper is configured", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySessionClaimsMapperURL, "")
+		claims, err := reg.SessionClaimsMapper().MapClaims(context.Background(), s)
+		require.NoError(t, err)
+		assert.Nil(t, claims)
+	})
+
+	t.Run("case=maps traits to claims", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySessionClaimsMapperURL, "file://./stub/claims.jsonnet")
+		claims, err := reg.SessionClaimsMapper().MapClaims(context.Background(), s)
+		require.NoError(t, err)
+		assert.Equal(t, i.ID.String(), gjson.GetBytes(claims, "sub").String(), "%s", claims)
+		assert.Equal(t, "foo@ory.sh", gjson.GetBytes(claims, "email").String(), "%s", claims)
+	})
+
+	t.Run("case=fails if the mapper does not return claims", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySessionClaimsMapperURL, "file://./stub/claims.invalid.jsonnet")
+		_, err := reg.SessionClaimsMapper().MapClaims(context.Background(), s)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,125 @@
+package session
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/x"
+)
+
+// ErrSessionFingerprintMismatch is returned if a session is used by a client whose fingerprint differs from the
+// fingerprint of the client the session was issued to.
+var ErrSessionFingerprintMismatch = herodot.ErrUnauthorized.WithError("the session was issued to a different client").WithReason("This session was issued to a different client. Please sign in again.")
+
+var userAgentVersions = regexp.MustCompile(`[0-9]+([._][0-9]+)*`)
+
+func fingerprintAttribute(r *http.Request, attribute string) string {
+	switch attribute {
+	case "user_agent":
+		return userAgentVersions.ReplaceAllString(r.UserAgent(), "")
+	case "accept_language":
+		return r.Header.Get("Accept-Language")
+	case "platform":
+		return r.Header.Get("Sec-CH-UA-Platform")
+	case "tls_version":
+		if r.TLS != nil {
+			return strconv.Itoa(int(r.TLS.Version))
+		}
+	case "tls_cipher_suite":
+		if r.TLS != nil {
+			return tls.CipherSuiteName(r.TLS.CipherSuite)
+		}
+	}
+	return ""
+}
+
+func fingerprint(r *http.Request, attributes []string) map[string]string {
+	fp := make(map[string]string, len(attributes))
+	for _, a := range attributes {
+		h := sha256.Sum256([]byte(fingerprintAttribute(r, a)))
+		fp[a] = hex.EncodeToString(h[:])
+	}
+	return fp
+}
+
+// BindFingerprint stores a fingerprint of the client which sent the request on the session if
+// `session.fingerprint.mode` is not `off`. Only hashes of the configured attributes are stored. The
+// client's IP address is always recorded.
+func (s *Session) BindFingerprint(c *config.Config, r *http.Request) error {
+	if ip := x.ClientIP(r, c.TrustedProxies()); ip != nil {
+		s.IPAddress = ip.String()
+	}
+
+	if c.SessionFingerprintMode() == "off" {
+		return nil
+	}
+
+	fp, err := json.Marshal(fingerprint(r, c.SessionFingerprintAttributes()))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	s.Fingerprint = fp
+	return nil
+}
+
+// MismatchingFingerprintAttributes returns the attributes in which the client which sent the request differs
+// from the client the session was issued to. Attributes which were not recorded when the session was issued
+// are skipped, so that sessions survive adding attributes to the configuration.
+func (s *Session) MismatchingFingerprintAttributes(r *http.Request, attributes []string) ([]string, error) {
+	if len(s.Fingerprint) == 0 {
+		return nil, nil
+	}
+
+	var recorded map[string]string
+	if err := json.Unmarshal(s.Fingerprint, &recorded); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var mismatching []string
+	current := fingerprint(r, attributes)
+	for _, a := range attributes {
+		if expected, ok := recorded[a]; ok && expected != current[a] {
+			mismatching = append(mismatching, a)
+		}
+	}
+	return mismatching, nil
+}
+
+func checkFingerprint(d interface {
+	config.Provider
+	x.LoggingProvider
+}, r *http.Request, s *Session) error {
+	c := d.Config(r.Context())
+	mode := c.SessionFingerprintMode()
+	if mode == "off" {
+		return nil
+	}
+
+	mismatching, err := s.MismatchingFingerprintAttributes(r, c.SessionFingerprintAttributes())
+	if err != nil {
+		return err
+	} else if len(mismatching) == 0 {
+		return nil
+	}
+
+	d.Audit().
+		WithRequest(r).
+		WithField("session_id", s.ID).
+		WithField("identity_id", s.IdentityID).
+		WithField("mismatching_attributes", mismatching).
+		Warn("A session was used by a client whose fingerprint differs from the client the session was issued to.")
+	if mode != "enforce" {
+		return nil
+	}
+	return errors.WithStack(ErrSessionFingerprintMismatch)
+}
@@ -2,17 +2,24 @@ package session
 
 import (
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
+	"github.com/gofrs/uuid"
 	"github.com/julienschmidt/httprouter"
 	"github.com/pkg/errors"
 
 	"github.com/ory/x/decoderx"
+	"github.com/ory/x/urlx"
 
 	"github.com/ory/x/errorsx"
 
 	"github.com/ory/herodot"
 
 	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/event"
+	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/x"
 )
 
@@ -20,6 +27,12 @@ type (
 	handlerDependencies interface {
 		ManagementProvider
 		PersistenceProvider
+		ClaimsMapperProvider
+		WhoamiCacheProvider
+		AnomalyProvider
+		config.Provider
+		event.EmitterProvider
+		identity.PoolProvider
 		x.WriterProvider
 		x.LoggingProvider
 		x.CSRFProvider
@@ -28,8 +41,9 @@ type (
 		SessionHandler() *Handler
 	}
 	Handler struct {
-		r  handlerDependencies
-		dx *decoderx.HTTP
+		r             handlerDependencies
+		dx            *decoderx.HTTP
+		whoamiLimiter *x.RateLimiter
 	}
 )
 
@@ -37,14 +51,18 @@ func NewHandler(
 	r handlerDependencies,
 ) *Handler {
 	return &Handler{
-		r:  r,
-		dx: decoderx.NewHTTP(),
+		r:             r,
+		dx:            decoderx.NewHTTP(),
+		whoamiLimiter: x.NewRateLimiter(),
 	}
 }
 
 const (
 	RouteWhoami = "/sessions/whoami"
 	RouteRevoke = "/sessions"
+
+	RouteImpersonate = "/sessions/impersonate"
+	RouteIdentity    = "/identities/:id/sessions"
 	// SessionsWhoisPath  = "/sessions/whois"
 )
 
@@ -62,6 +80,9 @@ func (h *Handler) RegisterPublicRoutes(public *x.RouterPublic) {
 
 func (h *Handler) RegisterAdminRoutes(admin *x.RouterAdmin) {
 	// admin.GET(SessionsWhoisPath, h.fromPath)
+	admin.POST(RouteImpersonate, h.impersonate)
+	admin.GET(RouteIdentity, h.listIdentitySessions)
+	admin.DELETE(RouteIdentity, h.revokeIdentitySessions)
 }
 
 // swagger:parameters revokeSession
@@ -111,11 +132,27 @@ func (h *Handler) revoke(w http.ResponseWriter, r *http.Request, _ httprouter.Pa
 		return
 	}
 
-	if err := h.r.SessionPersister().RevokeSessionByToken(r.Context(), p.SessionToken); err != nil {
+	token := storedToken(h.r.Config(r.Context()), p.SessionToken)
+	if isJWT(token) {
+		var err error
+		if token, err = tokenFromJWT(r.Context(), h.r, token); err != nil {
+			h.r.Writer().WriteError(w, r, err)
+			return
+		}
+	}
+
+	s, err := h.r.SessionPersister().GetSessionByToken(r.Context(), token)
+	if err != nil {
 		h.r.Writer().WriteError(w, r, err)
 		return
 	}
 
+	if err := h.r.SessionPersister().RevokeSessionByToken(r.Context(), token); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.r.EventEmitter().Emit(r.Context(), event.TypeSessionRevoked, &event.Data{IdentityID: s.IdentityID, SessionID: &s.ID})
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -150,11 +187,44 @@ type whoamiParameters struct {
 //     Responses:
 //       200: session
 //       401: genericError
+//       429: genericError
 //       500: genericError
 func (h *Handler) whoami(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	c := h.r.Config(r.Context())
+	if limit, period := c.SessionWhoamiRateLimit(); limit > 0 {
+		if rl, ok := h.whoamiLimiter.Allow(x.ClientIPString(r, c.TrustedProxies()), limit, period); !ok {
+			whoamiRateLimited.Inc()
+			x.WriteRateLimitExceeded(w, r, h.r, rl, "Too many sessions were checked from this IP address.")
+			return
+		}
+	}
+
+	cache := h.whoamiCache(r)
+	token := h.r.SessionManager().ExtractToken(r)
+	// JWTs are not cached because revoking their session does not invalidate them by token.
+	if cache != nil && len(token) > 0 && !isJWT(token) {
+		if s, ok := cache.Get(token); ok {
+			// Cached sessions are checked like those fetched by the session manager.
+			if err := checkFingerprint(h.r, r, s); err != nil {
+				h.r.Writer().WriteError(w, r, err)
+				return
+			}
+			if err := checkAnomaly(h.r, r, s); err != nil {
+				h.r.Writer().WriteError(w, r, err)
+				return
+			}
+			h.writeWhoami(w, r, s)
+			return
+		}
+	}
+
 	s, err := h.r.SessionManager().FetchFromRequest(r.Context(), r)
 	if err != nil {
 		h.r.Audit().WithRequest(r).WithError(err).Info("No valid session cookie found.")
+		if errorsx.Cause(err).Error() == ErrSessionFingerprintMismatch.Error() {
+			h.r.Writer().WriteError(w, r, err)
+			return
+		}
 		h.r.Writer().WriteError(w, r, herodot.ErrUnauthorized.WithWrap(err).WithReasonf("No valid session cookie found."))
 		return
 	}
@@ -162,6 +232,31 @@ func (h *Handler) whoami(w http.ResponseWriter, r *http.Request, ps httprouter.P
 	// s.Devices = nil
 	s.Identity = s.Identity.CopyWithoutCredentials()
 
+	if s.Claims, err = h.r.SessionClaimsMapper().MapClaims(r.Context(), s); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if cache != nil && !isJWT(token) {
+		cache.Set(token, s, c.SessionWhoamiCacheTTL())
+	}
+
+	h.writeWhoami(w, r, s)
+}
+
+// whoamiCache returns nil if whoami responses are not cached. Tenants share the cache's key space,
+// so requests which use a tenant's database are not cached either.
+func (h *Handler) whoamiCache(r *http.Request) *WhoamiCache {
+	if h.r.Config(r.Context()).SessionWhoamiCacheTTL() <= 0 {
+		return nil
+	}
+	if _, ok := x.TenantConnectionFromContext(r.Context()); ok {
+		return nil
+	}
+	return h.r.SessionWhoamiCache()
+}
+
+func (h *Handler) writeWhoami(w http.ResponseWriter, r *http.Request, s *Session) {
 	// Set userId as the X-Kratos-Authenticated-Identity-Id header.
 	w.Header().Set("X-Kratos-Authenticated-Identity-Id", s.Identity.ID.String())
 
@@ -187,7 +282,8 @@ func (h *Handler) IsAuthenticated(wrap httprouter.Handle, onUnauthenticated http
 func (h *Handler) IsNotAuthenticated(wrap httprouter.Handle, onAuthenticated httprouter.Handle) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 		if _, err := h.r.SessionManager().FetchFromRequest(r.Context(), r); err != nil {
-			if errorsx.Cause(err).Error() == ErrNoActiveSessionFound.Error() {
+			if cause := errorsx.Cause(err).Error(); cause == ErrNoActiveSessionFound.Error() ||
+				cause == ErrSessionFingerprintMismatch.Error() {
 				wrap(w, r, ps)
 				return
 			}
@@ -206,7 +302,9 @@ func (h *Handler) IsNotAuthenticated(wrap httprouter.Handle, onAuthenticated htt
 
 func RedirectOnAuthenticated(d interface{ config.Provider }) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-		returnTo, err := x.SecureRedirectTo(r, d.Config(r.Context()).SelfServiceBrowserDefaultReturnTo(), x.SecureRedirectAllowSelfServiceURLs(d.Config(r.Context()).SelfPublicURL(r)))
+		returnTo, err := x.SecureRedirectTo(r, d.Config(r.Context()).SelfServiceBrowserDefaultReturnTo(),
+			x.SecureRedirectAllowSelfServiceURLs(d.Config(r.Context()).SelfPublicURL(r)),
+			x.SecureRedirectRequireHTTPS(d.Config(r.Context()).SelfServiceBrowserRequireHTTPSReturnTo()))
 		if err != nil {
 			http.Redirect(w, r, d.Config(r.Context()).SelfServiceBrowserDefaultReturnTo().String(), http.StatusFound)
 			return
@@ -227,3 +325,300 @@ func RespondWithJSONErrorOnAuthenticated(h herodot.Writer, err error) httprouter
 		h.WriteError(w, r, err)
 	}
 }
+
+// swagger:parameters impersonateIdentity
+// nolint:deadcode,unused
+type impersonateIdentityParameters struct {
+	// in: body
+	// required: true
+	Body impersonateIdentity
+}
+
+type impersonateIdentity struct {
+	// IdentityID is the ID of the identity to impersonate.
+	//
+	// required: true
+	IdentityID uuid.UUID `json:"identity_id"`
+
+	// ImpersonatedBy identifies the administrator acting as the identity, for example their ID in your
+	// support tool.
+	//
+	// required: true
+	ImpersonatedBy string `json:"impersonated_by"`
+
+	// Reason explains why the identity is impersonated, for example a ticket number.
+	//
+	// required: true
+	Reason string `json:"reason"`
+}
+
+// The response of an impersonation request.
+//
+// swagger:model impersonationResponse
+type impersonationResponse struct {
+	// The Session Token
+	//
+	// Use it in the `X-Session-Token` header to act as the identity.
+	//
+	// required: true
+	Token string `json:"session_token"`
+
+	// required: true
+	Session *Session `json:"session"`
+}
+
+// swagger:route POST /sessions/impersonate admin impersonateIdentity
+//
+// Impersonate an Identity
+//
+// Issues a short-lived session for the identity to an administrator, for example to reproduce what a user
+// sees. The session carries the administrator's ID and reason, which are returned by whoami and recorded in
+// audit logs and events. Credentials can only be changed using the session if allowed in
+// `session.impersonation.allowed_credential_changes`.
+//
+// This endpoint is only available if `session.impersonation.enabled` is set.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       201: impersonationResponse
+//       400: genericError
+//       403: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) impersonate(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	c := h.r.Config(r.Context())
+	if !c.SessionImpersonationEnabled() {
+		h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrForbidden.
+			WithReasonf("Impersonating identities is disabled. Enable it using %s.", config.ViperKeySessionImpersonationEnabled)))
+		return
+	}
+
+	var p impersonateIdentity
+	if err := h.dx.Decode(r, &p,
+		decoderx.HTTPJSONDecoder(),
+		decoderx.HTTPDecoderAllowedMethods("POST")); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if len(p.ImpersonatedBy) == 0 || len(p.Reason) == 0 {
+		h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.
+			WithReason("Both impersonated_by and reason must be set.")))
+		return
+	} else if len(p.ImpersonatedBy) > 255 || len(p.Reason) > 255 {
+		h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.
+			WithReason("Neither impersonated_by nor reason may be longer than 255 characters.")))
+		return
+	}
+
+	i, err := h.r.IdentityPool().GetIdentity(r.Context(), p.IdentityID)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	now := time.Now().UTC()
+	s := NewActiveSession(i, c, now)
+	s.ExpiresAt = now.Add(c.SessionImpersonationLifespan())
+	s.ImpersonatedBy = p.ImpersonatedBy
+	s.ImpersonationReason = p.Reason
+	if err := h.r.SessionPersister().CreateSession(r.Context(), s); err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.r.Audit().
+		WithRequest(r).
+		WithField("identity_id", i.ID).
+		WithField("session_id", s.ID).
+		WithField("impersonated_by", s.ImpersonatedBy).
+		WithField("impersonation_reason", s.ImpersonationReason).
+		Info("An administrator is impersonating an identity.")
+	h.r.EventEmitter().Emit(r.Context(), event.TypeSessionImpersonated, &event.Data{
+		IdentityID: i.ID, SessionID: &s.ID, ImpersonatedBy: s.ImpersonatedBy})
+
+	token, err := IssuedToken(c, s)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	s.Identity = s.Identity.CopyWithoutCredentials()
+	h.r.Writer().WriteCode(w, r, http.StatusCreated, &impersonationResponse{Token: token, Session: s})
+}
+
+// swagger:parameters listIdentitySessions
+// nolint:deadcode,unused
+type listIdentitySessionsParameters struct {
+	// ID is the identity's ID.
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+
+	// Items per Page
+	//
+	// This is the number of items per page.
+	//
+	// required: false
+	// in: query
+	// default: 100
+	// min: 1
+	// max: 500
+	PerPage int `json:"per_page"`
+
+	// Pagination Page
+	//
+	// required: false
+	// in: query
+	// default: 0
+	// min: 0
+	Page int `json:"page"`
+
+	// Active
+	//
+	// Only lists sessions which are active if true, or sessions which were revoked or have expired if false.
+	//
+	// required: false
+	// in: query
+	Active *bool `json:"active"`
+}
+
+// A list of sessions.
+// swagger:response sessionList
+// nolint:deadcode,unused
+type sessionListResponse struct {
+	// in: body
+	// required: true
+	// type: array
+	Body []Session
+}
+
+// swagger:route GET /identities/{id}/sessions admin listIdentitySessions
+//
+// List the Sessions of an Identity
+//
+// Lists the sessions of the identity, most recently issued first, for example to diagnose sign in issues.
+// The `active` field of a session is false if the session was revoked or has expired. The `devices` field
+// describes the client each session was issued to.
+//
+// The sessions are paginated by `page` and `per_page`.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: sessionList
+//       400: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) listIdentitySessions(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var active *bool
+	if raw := r.URL.Query().Get("active"); raw != "" {
+		a, err := strconv.ParseBool(raw)
+		if err != nil {
+			h.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf("Query parameter active must be true or false but got: %s", raw)))
+			return
+		}
+		active = &a
+	}
+
+	i, err := h.r.IdentityPool().GetIdentity(r.Context(), x.ParseUUID(ps.ByName("id")))
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	page, itemsPerPage := x.ParsePagination(r)
+	ss, total, err := h.r.SessionPersister().ListSessionsByIdentity(r.Context(), i.ID, active, page, itemsPerPage)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	for _, s := range ss {
+		s.Active = s.IsActive()
+		s.Declassify()
+		if err := s.ExposeDevices(); err != nil {
+			h.r.Writer().WriteError(w, r, err)
+			return
+		}
+	}
+
+	u := urlx.AppendPaths(h.r.Config(r.Context()).SelfAdminURL(), "identities", i.ID.String(), "sessions")
+	if active != nil {
+		u.RawQuery = url.Values{"active": {strconv.FormatBool(*active)}}.Encode()
+	}
+	x.PaginationHeader(w, u, total, page, itemsPerPage)
+	h.r.Writer().Write(w, r, ss)
+}
+
+// swagger:parameters revokeIdentitySessions
+// nolint:deadcode,unused
+type revokeIdentitySessionsParameters struct {
+	// ID is the identity's ID.
+	//
+	// required: true
+	// in: path
+	ID string `json:"id"`
+}
+
+// The response of a request which revokes the sessions of an identity.
+//
+// swagger:model revokedSessions
+type revokedSessions struct {
+	// The number of sessions which were revoked.
+	//
+	// required: true
+	Count int `json:"count"`
+}
+
+// swagger:route DELETE /identities/{id}/sessions admin revokeIdentitySessions
+//
+// Revoke all Sessions of an Identity
+//
+// Revokes all sessions of the identity which have not expired yet, for example when an employee leaves
+// the company. The identity is not changed and can sign in again.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: revokedSessions
+//       404: genericError
+//       500: genericError
+func (h *Handler) revokeIdentitySessions(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	i, err := h.r.IdentityPool().GetIdentity(r.Context(), x.ParseUUID(ps.ByName("id")))
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	count, err := h.r.SessionPersister().DeleteSessionsByIdentity(r.Context(), i.ID)
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.r.Audit().
+		WithRequest(r).
+		WithField("identity_id", i.ID).
+		WithField("revoked_sessions", count).
+		Info("An administrator revoked all sessions of an identity.")
+	if count > 0 {
+		h.r.EventEmitter().Emit(r.Context(), event.TypeSessionRevoked, &event.Data{IdentityID: i.ID})
+	}
+
+	h.r.Writer().Write(w, r, &revokedSessions{Count: count})
+}
@@ -3,8 +3,10 @@ package session_test
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,6 +15,7 @@ import (
 	"github.com/julienschmidt/httprouter"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
 
 	"github.com/ory/kratos-client-go"
 	"github.com/ory/kratos/driver/config"
@@ -79,6 +82,145 @@ func TestSessionWhoAmI(t *testing.T) {
 	})
 }
 
+func TestSessionWhoAmIThrottling(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	publicTS, _ := testhelpers.NewKratosServer(t, reg)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://stub/identity.schema.json")
+
+	var newSession = func(t *testing.T) *Session {
+		i := &identity.Identity{Traits: identity.Traits(`{"baz":"bar"}`)}
+		require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(ctx, i))
+		sess := NewActiveSession(i, conf, time.Now())
+		require.NoError(t, reg.SessionPersister().CreateSession(ctx, sess))
+		return sess
+	}
+
+	var whoami = func(t *testing.T, token string) int {
+		req, err := http.NewRequest("GET", publicTS.URL+RouteWhoami, nil)
+		require.NoError(t, err)
+		req.Header.Set("X-Session-Token", token)
+		res, err := publicTS.Client().Do(req)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		return res.StatusCode
+	}
+
+	t.Run("case=caches responses", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySessionWhoamiCacheTTL, "1h")
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySessionWhoamiCacheTTL, "0s")
+		})
+
+		sess := newSession(t)
+		require.Equal(t, http.StatusOK, whoami(t, sess.Token))
+
+		_, ok := reg.SessionWhoamiCache().Get(sess.Token)
+		require.True(t, ok)
+
+		t.Run("case=revoking the session bypasses the cache", func(t *testing.T) {
+			require.NoError(t, reg.SessionPersister().RevokeSessionByToken(ctx, sess.Token))
+			assert.Equal(t, http.StatusUnauthorized, whoami(t, sess.Token))
+		})
+
+		t.Run("case=deleting the sessions of the identity bypasses the cache", func(t *testing.T) {
+			sess := newSession(t)
+			require.Equal(t, http.StatusOK, whoami(t, sess.Token))
+
+			_, err := reg.SessionPersister().DeleteSessionsByIdentity(ctx, sess.IdentityID)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusUnauthorized, whoami(t, sess.Token))
+		})
+	})
+
+	t.Run("case=checks the fingerprint of cached sessions", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySessionWhoamiCacheTTL, "1h")
+		conf.MustSet(config.ViperKeySessionFingerprintMode, "enforce")
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySessionWhoamiCacheTTL, "0s")
+			conf.MustSet(config.ViperKeySessionFingerprintMode, "off")
+		})
+
+		i := &identity.Identity{Traits: identity.Traits(`{"baz":"bar"}`)}
+		require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(ctx, i))
+		sess := NewActiveSession(i, conf, time.Now())
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("User-Agent", "Go-http-client/1.1")
+		require.NoError(t, sess.BindFingerprint(conf, r))
+		require.NoError(t, reg.SessionPersister().CreateSession(ctx, sess))
+		require.Equal(t, http.StatusOK, whoami(t, sess.Token))
+
+		req, err := http.NewRequest("GET", publicTS.URL+RouteWhoami, nil)
+		require.NoError(t, err)
+		req.Header.Set("X-Session-Token", sess.Token)
+		req.Header.Set("User-Agent", "curl/7.64.1")
+		res, err := publicTS.Client().Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body := x.MustReadAll(res.Body)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		assert.Equal(t, ErrSessionFingerprintMismatch.ErrorField, gjson.GetBytes(body, "error.message").String(), "%s", body)
+	})
+
+	t.Run("case=detects anomalies of cached sessions", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySessionWhoamiCacheTTL, "1h")
+		conf.MustSet(config.ViperKeySessionAnomalyHooks, []string{AnomalyHookRequireReauthentication})
+		conf.MustSet(config.ViperKeyTrustedProxies, []string{"127.0.0.0/8"})
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySessionWhoamiCacheTTL, "0s")
+			conf.MustSet(config.ViperKeySessionAnomalyHooks, []string{})
+			conf.MustSet(config.ViperKeyTrustedProxies, []string{})
+		})
+
+		i := &identity.Identity{Traits: identity.Traits(`{"baz":"bar"}`)}
+		require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(ctx, i))
+		sess := NewActiveSession(i, conf, time.Now())
+		sess.IPAddress = "203.0.113.5"
+		require.NoError(t, reg.SessionPersister().CreateSession(ctx, sess))
+
+		whoamiFrom := func(t *testing.T, ip string) int {
+			req, err := http.NewRequest("GET", publicTS.URL+RouteWhoami, nil)
+			require.NoError(t, err)
+			req.Header.Set("X-Session-Token", sess.Token)
+			req.Header.Set("X-Forwarded-For", ip)
+			res, err := publicTS.Client().Do(req)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+			return res.StatusCode
+		}
+
+		require.Equal(t, http.StatusOK, whoamiFrom(t, "203.0.113.5"))
+		_, ok := reg.SessionWhoamiCache().Get(sess.Token)
+		require.True(t, ok)
+
+		assert.Equal(t, http.StatusUnauthorized, whoamiFrom(t, "198.51.100.7"), "a token replayed from a different network must be rejected")
+		assert.Equal(t, http.StatusUnauthorized, whoamiFrom(t, "203.0.113.5"), "the session must have been revoked")
+	})
+
+	t.Run("case=does not cache responses by default", func(t *testing.T) {
+		sess := newSession(t)
+		require.Equal(t, http.StatusOK, whoami(t, sess.Token))
+
+		_, ok := reg.SessionWhoamiCache().Get(sess.Token)
+		assert.False(t, ok)
+	})
+
+	t.Run("case=rate limits requests", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySessionWhoamiRateLimitRequests, 2)
+		conf.MustSet(config.ViperKeySessionWhoamiRateLimitPeriod, "1h")
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySessionWhoamiRateLimitRequests, 0)
+		})
+
+		sess := newSession(t)
+		var codes []int
+		for k := 0; k < 3; k++ {
+			codes = append(codes, whoami(t, sess.Token))
+		}
+		assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, codes)
+	})
+}
+
 func TestSessionRevoke(t *testing.T) {
 	conf, reg := internal.NewFastRegistryWithMocks(t)
 	publicTS, _ := testhelpers.NewKratosServer(t, reg)
@@ -232,3 +374,218 @@ func TestIsAuthenticated(t *testing.T) {
 		})
 	}
 }
+
+func TestSessionImpersonate(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	publicTS, adminTS := testhelpers.NewKratosServer(t, reg)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://stub/identity.schema.json")
+	i := &identity.Identity{Traits: identity.Traits(`{"baz":"bar"}`)}
+	require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(context.Background(), i))
+
+	var impersonate = func(t *testing.T, body string) (int, string) {
+		res, err := adminTS.Client().Post(adminTS.URL+RouteImpersonate, "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		b, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res.StatusCode, string(b)
+	}
+	valid := `{"identity_id":"` + i.ID.String() + `","impersonated_by":"admin-1","reason":"TICKET-123"}`
+
+	t.Run("case=is disabled by default", func(t *testing.T) {
+		code, _ := impersonate(t, valid)
+		assert.Equal(t, http.StatusForbidden, code)
+	})
+
+	conf.MustSet(config.ViperKeySessionImpersonationEnabled, true)
+
+	t.Run("case=requires the administrator and a reason", func(t *testing.T) {
+		code, _ := impersonate(t, `{"identity_id":"`+i.ID.String()+`","impersonated_by":"admin-1"}`)
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("case=requires an existing identity", func(t *testing.T) {
+		code, _ := impersonate(t, `{"identity_id":"`+x.NewUUID().String()+`","impersonated_by":"admin-1","reason":"TICKET-123"}`)
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+
+	t.Run("case=issues a short-lived impersonation session", func(t *testing.T) {
+		code, body := impersonate(t, valid)
+		require.Equal(t, http.StatusCreated, code, body)
+		assert.Equal(t, "admin-1", gjson.Get(body, "session.impersonated_by").String(), body)
+		assert.Equal(t, i.ID.String(), gjson.Get(body, "session.identity.id").String(), body)
+		assert.WithinDuration(t, time.Now().Add(15*time.Minute), gjson.Get(body, "session.expires_at").Time(), time.Minute)
+
+		req, err := http.NewRequest("GET", publicTS.URL+RouteWhoami, nil)
+		require.NoError(t, err)
+		req.Header.Set("X-Session-Token", gjson.Get(body, "session_token").String())
+		res, err := publicTS.Client().Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		whoami, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "admin-1", gjson.GetBytes(whoami, "impersonated_by").String(), "%s", whoami)
+		assert.Equal(t, "TICKET-123", gjson.GetBytes(whoami, "impersonation_reason").String(), "%s", whoami)
+
+		s, err := reg.SessionPersister().GetSession(context.Background(), x.ParseUUID(gjson.Get(body, "session.id").String()))
+		require.NoError(t, err)
+		assert.True(t, s.IsImpersonation())
+	})
+}
+
+func TestRevokeIdentitySessions(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	publicTS, adminTS := testhelpers.NewKratosServer(t, reg)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://stub/identity.schema.json")
+	i := &identity.Identity{Traits: identity.Traits(`{"baz":"bar"}`)}
+	require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(ctx, i))
+
+	var revoke = func(t *testing.T, id string) (int, string) {
+		req, err := http.NewRequest("DELETE", adminTS.URL+strings.Replace(RouteIdentity, ":id", id, 1), nil)
+		require.NoError(t, err)
+		res, err := adminTS.Client().Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		b, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res.StatusCode, string(b)
+	}
+
+	var whoami = func(t *testing.T, token string) int {
+		req, err := http.NewRequest("GET", publicTS.URL+RouteWhoami, nil)
+		require.NoError(t, err)
+		req.Header.Set("X-Session-Token", token)
+		res, err := publicTS.Client().Do(req)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		return res.StatusCode
+	}
+
+	t.Run("case=requires an existing identity", func(t *testing.T) {
+		code, _ := revoke(t, x.NewUUID().String())
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+
+	t.Run("case=revokes all sessions of the identity", func(t *testing.T) {
+		var sessions []*Session
+		for k := 0; k < 2; k++ {
+			s := NewActiveSession(i, conf, time.Now())
+			require.NoError(t, reg.SessionPersister().CreateSession(ctx, s))
+			require.Equal(t, http.StatusOK, whoami(t, s.Token))
+			sessions = append(sessions, s)
+		}
+
+		other := &identity.Identity{Traits: identity.Traits(`{"baz":"bar"}`)}
+		require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(ctx, other))
+		kept := NewActiveSession(other, conf, time.Now())
+		require.NoError(t, reg.SessionPersister().CreateSession(ctx, kept))
+
+		code, body := revoke(t, i.ID.String())
+		require.Equal(t, http.StatusOK, code, body)
+		assert.EqualValues(t, 2, gjson.Get(body, "count").Int(), body)
+
+		for _, s := range sessions {
+			assert.Equal(t, http.StatusUnauthorized, whoami(t, s.Token))
+		}
+		assert.Equal(t, http.StatusOK, whoami(t, kept.Token))
+
+		code, body = revoke(t, i.ID.String())
+		require.Equal(t, http.StatusOK, code, body)
+		assert.EqualValues(t, 0, gjson.Get(body, "count").Int(), body)
+	})
+}
+
+func TestListIdentitySessions(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	_, adminTS := testhelpers.NewKratosServer(t, reg)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://stub/identity.schema.json")
+	i := &identity.Identity{Traits: identity.Traits(`{"baz":"bar"}`)}
+	require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(ctx, i))
+
+	var list = func(t *testing.T, u string) (int, string, *http.Response) {
+		res, err := adminTS.Client().Get(u)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		b, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res.StatusCode, string(b), res
+	}
+	route := adminTS.URL + strings.Replace(RouteIdentity, ":id", i.ID.String(), 1)
+
+	now := time.Now().UTC()
+	active := NewActiveSession(i, conf, now)
+	active.IPAddress = "192.0.2.1"
+	active.Fingerprint = []byte(`{"user_agent":"abcd"}`)
+	expired := NewActiveSession(i, conf, now)
+	expired.ExpiresAt = now.Add(-time.Minute)
+	expired.IssuedAt = now.Add(time.Second)
+	revoked := NewActiveSession(i, conf, now)
+	revoked.IssuedAt = now.Add(2 * time.Second)
+	for _, s := range []*Session{active, expired, revoked} {
+		require.NoError(t, reg.SessionPersister().CreateSession(ctx, s))
+	}
+	require.NoError(t, reg.SessionPersister().RevokeSessionByToken(ctx, revoked.Token))
+
+	t.Run("case=requires an existing identity", func(t *testing.T) {
+		code, _, _ := list(t, adminTS.URL+strings.Replace(RouteIdentity, ":id", x.NewUUID().String(), 1))
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+
+	t.Run("case=rejects an invalid filter", func(t *testing.T) {
+		code, _, _ := list(t, route+"?active=maybe")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("case=flags expired and revoked sessions", func(t *testing.T) {
+		code, body, _ := list(t, route)
+		require.Equal(t, http.StatusOK, code, body)
+		assert.EqualValues(t, 3, gjson.Get(body, "#").Int(), body)
+		assert.Equal(t, "192.0.2.1", gjson.Get(body, "2.devices.0.ip_address").String(), body)
+		assert.Equal(t, "abcd", gjson.Get(body, "2.devices.0.fingerprint.user_agent").String(), body)
+		assert.False(t, gjson.Get(body, "0.devices").Exists(), body)
+
+		for k, s := range []*Session{revoked, expired, active} {
+			assert.Equal(t, s.ID.String(), gjson.Get(body, fmt.Sprintf("%d.id", k)).String(), body)
+			assert.Equal(t, s == active, gjson.Get(body, fmt.Sprintf("%d.active", k)).Bool(), body)
+			assert.WithinDuration(t, s.IssuedAt, gjson.Get(body, fmt.Sprintf("%d.issued_at", k)).Time(), time.Second, body)
+			assert.WithinDuration(t, s.ExpiresAt, gjson.Get(body, fmt.Sprintf("%d.expires_at", k)).Time(), time.Second, body)
+			assert.False(t, gjson.Get(body, fmt.Sprintf("%d.identity.credentials", k)).Exists(), body)
+		}
+	})
+
+	t.Run("case=filters by active", func(t *testing.T) {
+		_, body, _ := list(t, route+"?active=true")
+		assert.EqualValues(t, 1, gjson.Get(body, "#").Int(), body)
+		assert.Equal(t, active.ID.String(), gjson.Get(body, "0.id").String(), body)
+
+		_, body, _ = list(t, route+"?active=false")
+		assert.EqualValues(t, 2, gjson.Get(body, "#").Int(), body)
+		assert.False(t, gjson.Get(body, "0.active").Bool(), body)
+		assert.False(t, gjson.Get(body, "1.active").Bool(), body)
+	})
+
+	t.Run("case=paginates", func(t *testing.T) {
+		var seen []string
+		u := route + "?active=false&per_page=1"
+		for k := 0; k < 3 && u != ""; k++ {
+			code, body, res := list(t, u)
+			require.Equal(t, http.StatusOK, code, body)
+			require.EqualValues(t, 1, gjson.Get(body, "#").Int(), body)
+			seen = append(seen, gjson.Get(body, "0.id").String())
+
+			u = ""
+			for _, link := range strings.Split(res.Header.Get("Link"), ",") {
+				if strings.Contains(link, `rel="next"`) {
+					next := link[strings.Index(link, "<")+1 : strings.Index(link, ">")]
+					assert.Contains(t, next, "active=false")
+					u = adminTS.URL + next[strings.Index(next, "/identities"):]
+				}
+			}
+		}
+		assert.Equal(t, []string{revoked.ID.String(), expired.ID.String()}, seen)
+	})
+}
@@ -3,6 +3,8 @@ package session
 import (
 	"net/http"
 	"strings"
+
+	"github.com/ory/kratos/driver/config"
 )
 
 func bearerTokenFromRequest(r *http.Request) (string, bool) {
@@ -14,3 +16,23 @@ func bearerTokenFromRequest(r *http.Request) (string, bool) {
 
 	return "", false
 }
+
+// IssuedToken returns the session's token as handed out to API clients. Depending on `session.token_format`
+// this is either the opaque token prefixed with `session.token_prefix` or a signed JWT.
+func IssuedToken(c *config.Config, s *Session) (string, error) {
+	if c.SessionTokenFormat() == TokenFormatJWT {
+		return newJWT(c, s)
+	}
+	return opaqueToken(c, s), nil
+}
+
+// opaqueToken returns the session's token prefixed with `session.token_prefix`.
+func opaqueToken(c *config.Config, s *Session) string {
+	return c.SessionTokenPrefix() + s.Token
+}
+
+// storedToken removes `session.token_prefix` from a token sent by a client. Tokens issued before the
+// prefix was configured are returned unchanged.
+func storedToken(c *config.Config, token string) string {
+	return strings.TrimPrefix(token, c.SessionTokenPrefix())
+}
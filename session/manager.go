@@ -24,6 +24,10 @@ type Manager interface {
 	// Also regenerates CSRF tokens due to assumed principal change.
 	IssueCookie(context.Context, http.ResponseWriter, *http.Request, *Session) error
 
+	// ExtractToken returns the session token sent with the request, without `session.token_prefix`, or an
+	// empty string if there is none.
+	ExtractToken(*http.Request) string
+
 	// FetchFromRequest creates an HTTP session using cookies.
 	FetchFromRequest(context.Context, *http.Request) (*Session, error)
 
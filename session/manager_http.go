@@ -3,6 +3,7 @@ package session
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -22,7 +23,10 @@ type (
 		identity.PoolProvider
 		x.CookieProvider
 		x.CSRFProvider
+		x.LoggingProvider
+		AnomalyProvider
 		PersistenceProvider
+		SessionCacheProvider
 	}
 	ManagerHTTP struct {
 		cookieName func(ctx context.Context) string
@@ -40,6 +44,10 @@ func NewManagerHTTP(r managerHTTPDependencies) *ManagerHTTP {
 }
 
 func (s *ManagerHTTP) CreateAndIssueCookie(ctx context.Context, w http.ResponseWriter, r *http.Request, ss *Session) error {
+	if err := ss.BindFingerprint(s.r.Config(ctx), r); err != nil {
+		return err
+	}
+
 	if err := s.r.SessionPersister().CreateSession(ctx, ss); err != nil {
 		return err
 	}
@@ -84,20 +92,21 @@ func (s *ManagerHTTP) IssueCookie(ctx context.Context, w http.ResponseWriter, r
 		cookie.Options.MaxAge = int(s.r.Config(ctx).SessionLifespan().Seconds())
 	}
 
-	cookie.Values["session_token"] = session.Token
+	cookie.Values["session_token"] = opaqueToken(s.r.Config(ctx), session)
 	if err := cookie.Save(r, w); err != nil {
 		return errors.WithStack(err)
 	}
 	return nil
 }
 
-func (s *ManagerHTTP) extractToken(r *http.Request) string {
+func (s *ManagerHTTP) ExtractToken(r *http.Request) string {
+	c := s.r.Config(r.Context())
 	if token, ok := bearerTokenFromRequest(r); ok {
-		return token
+		return storedToken(c, token)
 	}
 
 	if token := r.Header.Get("X-Session-Token"); len(token) > 0 {
-		return token
+		return storedToken(c, token)
 	}
 
 	cookie, err := s.r.CookieManager(r.Context()).Get(r, s.cookieName(r.Context()))
@@ -107,19 +116,25 @@ func (s *ManagerHTTP) extractToken(r *http.Request) string {
 
 	token, ok := cookie.Values["session_token"].(string)
 	if ok {
-		return token
+		return storedToken(c, token)
 	}
 
 	return ""
 }
 
 func (s *ManagerHTTP) FetchFromRequest(ctx context.Context, r *http.Request) (*Session, error) {
-	token := s.extractToken(r)
+	token := s.ExtractToken(r)
 	if token == "" {
 		return nil, errors.WithStack(ErrNoActiveSessionFound)
 	}
 
-	se, err := s.r.SessionPersister().GetSessionByToken(ctx, token)
+	var se *Session
+	var err error
+	if isJWT(token) {
+		se, err = s.sessionByJWT(ctx, token)
+	} else {
+		se, err = s.sessionByToken(ctx, token)
+	}
 	if err != nil {
 		if errors.Is(err, herodot.ErrNotFound) || errors.Is(err, sqlcon.ErrNoRows) {
 			return nil, errors.WithStack(ErrNoActiveSessionFound)
@@ -127,16 +142,55 @@ func (s *ManagerHTTP) FetchFromRequest(ctx context.Context, r *http.Request) (*S
 		return nil, err
 	}
 
-	if !se.IsActive() {
+	if !se.IsActive() || (se.Identity != nil && se.Identity.IsDeleted()) {
 		return nil, errors.WithStack(ErrNoActiveSessionFound)
 	}
 
+	if err := checkFingerprint(s.r, r, se); err != nil {
+		return nil, err
+	}
+
+	if err := checkAnomaly(s.r, r, se); err != nil {
+		return nil, err
+	}
+
 	se.Identity = se.Identity.CopyWithoutCredentials()
 	return se, nil
 }
 
+// sessionByToken looks the session up in the session cache and falls back to the persister. Sessions
+// loaded from the persister are cached, but never past their expiry.
+func (s *ManagerHTTP) sessionByToken(ctx context.Context, token string) (*Session, error) {
+	c := cacheFor(ctx, s.r)
+	se, err := c.Get(ctx, token)
+	if err == nil && se.ExpiresAt.After(time.Now()) {
+		return se, nil
+	} else if err != nil && !errors.Is(err, ErrCacheMiss) {
+		s.r.Logger().WithError(err).Warn("Unable to look up session in cache.")
+	}
+
+	se, err = s.r.SessionPersister().GetSessionByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if ttl := cacheTTL(se, s.r.Config(ctx).SessionCacheTTL()); se.IsActive() && ttl > 0 {
+		if err := c.Set(ctx, se, ttl); err != nil {
+			s.r.Logger().WithError(err).Warn("Unable to cache session.")
+		}
+	}
+	return se, nil
+}
+
 func (s *ManagerHTTP) PurgeFromRequest(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 	if token, ok := bearerTokenFromRequest(r); ok {
+		token = storedToken(s.r.Config(ctx), token)
+		if isJWT(token) {
+			var err error
+			if token, err = tokenFromJWT(ctx, s.r, token); err != nil {
+				return err
+			}
+		}
 		return errors.WithStack(s.r.SessionPersister().RevokeSessionByToken(ctx, token))
 	}
 
@@ -146,7 +200,7 @@ func (s *ManagerHTTP) PurgeFromRequest(ctx context.Context, w http.ResponseWrite
 		return nil
 	}
 
-	if err := s.r.SessionPersister().RevokeSessionByToken(ctx, token); err != nil {
+	if err := s.r.SessionPersister().RevokeSessionByToken(ctx, storedToken(s.r.Config(ctx), token)); err != nil {
 		return errors.WithStack(err)
 	}
 
@@ -32,6 +32,9 @@ func (f *mockCSRFHandler) ExemptPath(s string) {
 func (f *mockCSRFHandler) IgnorePath(s string) {
 }
 
+func (f *mockCSRFHandler) ExemptGlob(s string) {
+}
+
 func (f *mockCSRFHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
@@ -135,5 +138,102 @@ func TestManagerHTTP(t *testing.T) {
 			require.NoError(t, err)
 			assert.EqualValues(t, http.StatusUnauthorized, res.StatusCode)
 		})
+
+		t.Run("case=token prefix", func(t *testing.T) {
+			conf.MustSet(config.ViperKeySessionTokenPrefix, "prod_")
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeySessionTokenPrefix, "")
+			})
+
+			i := identity.Identity{Traits: []byte("{}")}
+			require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(context.Background(), &i))
+			s = session.NewActiveSession(&i, conf, time.Now())
+
+			c := testhelpers.NewClientWithCookies(t)
+			testhelpers.MockHydrateCookieClient(t, c, pts.URL+"/session/set")
+			token, err := session.IssuedToken(conf, s)
+			require.NoError(t, err)
+			assert.Equal(t, "prod_"+s.Token, token)
+
+			res, err := c.Get(pts.URL + "/session/get")
+			require.NoError(t, err)
+			assert.EqualValues(t, http.StatusOK, res.StatusCode)
+
+			for token, code := range map[string]int{
+				"prod_" + s.Token: http.StatusOK,
+				s.Token:           http.StatusOK,
+				"dev_" + s.Token:  http.StatusUnauthorized,
+			} {
+				req, err := http.NewRequest("GET", pts.URL+"/session/get", nil)
+				require.NoError(t, err)
+				req.Header.Set("X-Session-Token", token)
+
+				res, err := http.DefaultClient.Do(req)
+				require.NoError(t, err)
+				assert.EqualValues(t, code, res.StatusCode, token)
+			}
+
+			res, err = c.Get(pts.URL + "/session/revoke")
+			require.NoError(t, err)
+			assert.EqualValues(t, http.StatusOK, res.StatusCode)
+
+			res, err = c.Get(pts.URL + "/session/get")
+			require.NoError(t, err)
+			assert.EqualValues(t, http.StatusUnauthorized, res.StatusCode)
+		})
+
+		t.Run("case=fingerprint", func(t *testing.T) {
+			get := func(t *testing.T, c *http.Client, userAgent string) int {
+				req, err := http.NewRequest("GET", pts.URL+"/session/get", nil)
+				require.NoError(t, err)
+				req.Header.Set("User-Agent", userAgent)
+				res, err := c.Do(req)
+				require.NoError(t, err)
+				defer res.Body.Close()
+				return res.StatusCode
+			}
+
+			issue := func(t *testing.T, mode string) *http.Client {
+				conf.MustSet(config.ViperKeySessionFingerprintMode, mode)
+				i := identity.Identity{Traits: []byte("{}")}
+				require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(context.Background(), &i))
+				s = session.NewActiveSession(&i, conf, time.Now())
+
+				c := testhelpers.NewClientWithCookies(t)
+				testhelpers.MockHydrateCookieClient(t, c, pts.URL+"/session/set")
+				return c
+			}
+
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeySessionFingerprintMode, "off")
+			})
+
+			t.Run("case=rejects a different client", func(t *testing.T) {
+				c := issue(t, "enforce")
+				assert.EqualValues(t, http.StatusOK, get(t, c, "Go-http-client/1.1"))
+				assert.EqualValues(t, http.StatusOK, get(t, c, "Go-http-client/2.0"), "version changes must be ignored")
+				assert.EqualValues(t, http.StatusUnauthorized, get(t, c, "curl/7.64.1"))
+			})
+
+			t.Run("case=only logs a different client", func(t *testing.T) {
+				c := issue(t, "log")
+				assert.EqualValues(t, http.StatusOK, get(t, c, "curl/7.64.1"))
+			})
+
+			t.Run("case=does not check sessions issued without fingerprint", func(t *testing.T) {
+				c := issue(t, "off")
+				conf.MustSet(config.ViperKeySessionFingerprintMode, "enforce")
+				assert.EqualValues(t, http.StatusOK, get(t, c, "curl/7.64.1"))
+			})
+
+			t.Run("case=skips attributes which were not recorded", func(t *testing.T) {
+				c := issue(t, "enforce")
+				conf.MustSet(config.ViperKeySessionFingerprintAttributes, []string{"user_agent", "accept_language"})
+				t.Cleanup(func() {
+					conf.MustSet(config.ViperKeySessionFingerprintAttributes, []string{"user_agent"})
+				})
+				assert.EqualValues(t, http.StatusOK, get(t, c, "Go-http-client/1.1"))
+			})
+		})
 	})
 }
@@ -3,12 +3,15 @@ package session
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/bxcodec/faker/v3"
 	"github.com/gofrs/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/ory/x/sqlxx"
+
 	"github.com/ory/kratos/driver/config"
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/x"
@@ -28,8 +31,14 @@ type Persister interface {
 	// DeleteSession removes a session from the store.
 	DeleteSession(ctx context.Context, id uuid.UUID) error
 
-	// DeleteSessionsByIdentity removes all active session from the store for the given identity.
-	DeleteSessionsByIdentity(ctx context.Context, identity uuid.UUID) error
+	// DeleteSessionsByIdentity removes all sessions of the given identity which have not expired yet from the
+	// store and returns how many were removed.
+	DeleteSessionsByIdentity(ctx context.Context, identity uuid.UUID) (int, error)
+
+	// ListSessionsByIdentity returns a page of the identity's sessions, most recently issued first, and the
+	// number of sessions on all pages. The page is zero-based. If active is set, only sessions which are or are
+	// not active are listed. Sessions are not active if they were revoked or have expired.
+	ListSessionsByIdentity(ctx context.Context, identity uuid.UUID, active *bool, page, perPage int) ([]*Session, int64, error)
 
 	// GetSessionByToken gets the session associated with the given token.
 	//
@@ -45,6 +54,13 @@ type Persister interface {
 
 	// RevokeSessionByToken marks a session inactive with the given token.
 	RevokeSessionByToken(ctx context.Context, token string) error
+
+	// UpdateSessionAuthenticatedAt sets the time at which the session's identity last authenticated,
+	// for example when the identity re-authenticated using a refresh login flow.
+	UpdateSessionAuthenticatedAt(ctx context.Context, sid uuid.UUID, authenticatedAt time.Time) error
+
+	// UpdateSessionExtra replaces the additional context which a web hook attached to the session.
+	UpdateSessionExtra(ctx context.Context, sid uuid.UUID, extra sqlxx.NullJSONRawMessage) error
 }
 
 func TestPersister(ctx context.Context, conf *config.Config, p interface {
@@ -135,17 +151,21 @@ func TestPersister(ctx context.Context, conf *config.Config, p interface {
 			var expected1 Session
 			var expected2 Session
 			require.NoError(t, faker.FakeData(&expected1))
+			expected1.ExpiresAt = time.Now().Add(time.Hour)
 			require.NoError(t, p.CreateIdentity(ctx, expected1.Identity))
 
 			require.NoError(t, p.CreateSession(ctx, &expected1))
 
 			require.NoError(t, faker.FakeData(&expected2))
+			expected2.ExpiresAt = time.Now().Add(time.Hour)
 			expected2.Identity = expected1.Identity
 			expected2.IdentityID = expected1.IdentityID
 			require.NoError(t, p.CreateSession(ctx, &expected2))
 
-			require.NoError(t, p.DeleteSessionsByIdentity(ctx, expected2.IdentityID))
-			_, err := p.GetSession(ctx, expected1.ID)
+			count, err := p.DeleteSessionsByIdentity(ctx, expected2.IdentityID)
+			require.NoError(t, err)
+			assert.Equal(t, 2, count)
+			_, err = p.GetSession(ctx, expected1.ID)
 			require.Error(t, err)
 			_, err = p.GetSession(ctx, expected2.ID)
 			require.Error(t, err)
@@ -2,13 +2,16 @@ package session
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 
 	"github.com/ory/kratos/corp"
 
 	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
 
 	"github.com/ory/x/randx"
+	"github.com/ory/x/sqlxx"
 
 	"github.com/ory/kratos/identity"
 	"github.com/ory/kratos/x"
@@ -33,6 +36,36 @@ type Session struct {
 	// required: true
 	Identity *identity.Identity `json:"identity" faker:"identity" db:"-" belongs_to:"identities" fk_id:"IdentityID"`
 
+	// Claims contains the output of the session claims mapper (if configured). It is
+	// computed when the session is returned and not persisted.
+	Claims json.RawMessage `json:"claims,omitempty" faker:"-" db:"-"`
+
+	// Extra contains additional context which was attached to the session by a web hook
+	// when the session was created.
+	Extra sqlxx.NullJSONRawMessage `json:"extra,omitempty" faker:"-" db:"extra"`
+
+	// Recovered is true if the session was issued by the recovery flow. Such sessions may only change the
+	// credentials allowed by `selfservice.flows.recovery.allowed_credential_changes`.
+	Recovered bool `json:"-" faker:"-" db:"recovered"`
+
+	// Fingerprint contains hashes of the attributes of the client the session was issued to. It is
+	// only set if `session.fingerprint.mode` is not `off`.
+	Fingerprint sqlxx.NullJSONRawMessage `json:"-" faker:"-" db:"fingerprint"`
+
+	// IPAddress is the IP address of the client the session was issued to. It is used to detect sessions
+	// which are used from a different network (see AnomalyDetector).
+	IPAddress string `json:"-" faker:"-" db:"ip_address"`
+
+	// Devices lists the clients the session was issued to. It is only returned by the admin API.
+	Devices []Device `json:"devices,omitempty" faker:"-" db:"-"`
+
+	// ImpersonatedBy is set if the session was issued to an administrator acting as the identity. It
+	// contains the administrator's ID as given to the impersonation endpoint.
+	ImpersonatedBy string `json:"impersonated_by,omitempty" faker:"-" db:"impersonated_by"`
+
+	// ImpersonationReason is the reason the administrator gave for impersonating the identity.
+	ImpersonationReason string `json:"impersonation_reason,omitempty" faker:"-" db:"impersonation_reason"`
+
 	// IdentityID is a helper struct field for gobuffalo.pop.
 	IdentityID uuid.UUID `json:"-" faker:"-" db:"identity_id"`
 	// CreatedAt is a helper struct field for gobuffalo.pop.
@@ -62,9 +95,18 @@ func NewActiveSession(i *identity.Identity, c interface {
 	}
 }
 
+// A Session Device
+//
+// Describes the client a session was issued to.
+//
+// swagger:model sessionDevice
 type Device struct {
-	UserAgent string      `json:"user_agent"`
-	SeenAt    []time.Time `json:"seen_at" faker:"time_types"`
+	// IPAddress is the IP address of the client.
+	IPAddress string `json:"ip_address,omitempty"`
+
+	// Fingerprint contains the hashes of the client's attributes, keyed by attribute. It is only set if
+	// `session.fingerprint.mode` was not `off` when the session was issued.
+	Fingerprint map[string]string `json:"fingerprint,omitempty"`
 }
 
 func (s *Session) Declassify() *Session {
@@ -72,6 +114,28 @@ func (s *Session) Declassify() *Session {
 	return s
 }
 
+// ExposeDevices sets Devices from the IP address and fingerprint recorded when the session was issued.
+func (s *Session) ExposeDevices() error {
+	s.Devices = nil
+
+	d := Device{IPAddress: s.IPAddress}
+	if len(s.Fingerprint) > 0 {
+		if err := json.Unmarshal(s.Fingerprint, &d.Fingerprint); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if len(d.IPAddress) > 0 || len(d.Fingerprint) > 0 {
+		s.Devices = []Device{d}
+	}
+	return nil
+}
+
+// IsImpersonation returns true if the session was issued to an administrator acting as the identity.
+func (s *Session) IsImpersonation() bool {
+	return len(s.ImpersonatedBy) > 0
+}
+
 func (s *Session) IsActive() bool {
 	return s.Active && s.ExpiresAt.After(time.Now())
 }
@@ -17,6 +17,7 @@ import (
 	"github.com/ory/kratos/x"
 	"github.com/ory/x/randx"
 	"github.com/ory/x/sqlcon"
+	"github.com/ory/x/sqlxx"
 )
 
 func TestPersister(ctx context.Context, conf *config.Config, p interface {
@@ -116,6 +117,45 @@ func TestPersister(ctx context.Context, conf *config.Config, p interface {
 			require.Error(t, err)
 		})
 
+		t.Run("case=update authenticated at", func(t *testing.T) {
+			var expected session.Session
+			require.NoError(t, faker.FakeData(&expected))
+			require.NoError(t, p.CreateIdentity(ctx, expected.Identity))
+			require.NoError(t, p.CreateSession(ctx, &expected))
+
+			authenticatedAt := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+			t.Run("on another network", func(t *testing.T) {
+				_, other := testhelpers.NewNetwork(t, ctx, p)
+				err := other.UpdateSessionAuthenticatedAt(ctx, expected.ID, authenticatedAt)
+				assert.ErrorIs(t, err, sqlcon.ErrNoRows)
+			})
+
+			require.NoError(t, p.UpdateSessionAuthenticatedAt(ctx, expected.ID, authenticatedAt))
+			actual, err := p.GetSession(ctx, expected.ID)
+			require.NoError(t, err)
+			assert.Equal(t, authenticatedAt.Unix(), actual.AuthenticatedAt.Unix())
+			assert.Equal(t, expected.IssuedAt.Unix(), actual.IssuedAt.Unix())
+		})
+
+		t.Run("case=update extra", func(t *testing.T) {
+			var expected session.Session
+			require.NoError(t, faker.FakeData(&expected))
+			require.NoError(t, p.CreateIdentity(ctx, expected.Identity))
+			require.NoError(t, p.CreateSession(ctx, &expected))
+
+			extra := sqlxx.NullJSONRawMessage(`{"foo":"bar"}`)
+			t.Run("on another network", func(t *testing.T) {
+				_, other := testhelpers.NewNetwork(t, ctx, p)
+				err := other.UpdateSessionExtra(ctx, expected.ID, extra)
+				assert.ErrorIs(t, err, sqlcon.ErrNoRows)
+			})
+
+			require.NoError(t, p.UpdateSessionExtra(ctx, expected.ID, extra))
+			actual, err := p.GetSession(ctx, expected.ID)
+			require.NoError(t, err)
+			assert.JSONEq(t, string(extra), string(actual.Extra))
+		})
+
 		t.Run("case=revoke session by token", func(t *testing.T) {
 			var expected session.Session
 			require.NoError(t, faker.FakeData(&expected))
@@ -148,29 +188,123 @@ func TestPersister(ctx context.Context, conf *config.Config, p interface {
 			var expected1 session.Session
 			var expected2 session.Session
 			require.NoError(t, faker.FakeData(&expected1))
+			expected1.ExpiresAt = time.Now().Add(time.Hour)
 			require.NoError(t, p.CreateIdentity(ctx, expected1.Identity))
 
 			require.NoError(t, p.CreateSession(ctx, &expected1))
 
 			require.NoError(t, faker.FakeData(&expected2))
+			expected2.ExpiresAt = time.Now().Add(time.Hour)
 			expected2.Identity = expected1.Identity
 			expected2.IdentityID = expected1.IdentityID
 			require.NoError(t, p.CreateSession(ctx, &expected2))
 
+			var expired session.Session
+			require.NoError(t, faker.FakeData(&expired))
+			expired.ExpiresAt = time.Now().Add(-time.Hour)
+			expired.Identity = expected1.Identity
+			expired.IdentityID = expected1.IdentityID
+			require.NoError(t, p.CreateSession(ctx, &expired))
+
 			t.Run("on another network", func(t *testing.T) {
 				_, other := testhelpers.NewNetwork(t, ctx, p)
-				err := other.DeleteSessionsByIdentity(ctx, expected2.IdentityID)
-				assert.ErrorIs(t, err, sqlcon.ErrNoRows)
+				count, err := other.DeleteSessionsByIdentity(ctx, expected2.IdentityID)
+				require.NoError(t, err)
+				assert.Equal(t, 0, count)
 
 				_, err = p.GetSession(ctx, expected1.ID)
 				require.NoError(t, err)
 			})
 
-			require.NoError(t, p.DeleteSessionsByIdentity(ctx, expected2.IdentityID))
-			_, err := p.GetSession(ctx, expected1.ID)
-			require.Error(t, err)
-			_, err = p.GetSession(ctx, expected2.ID)
-			require.Error(t, err)
+			count, err := p.DeleteSessionsByIdentity(ctx, expected2.IdentityID)
+			require.NoError(t, err)
+			assert.Equal(t, 2, count)
+
+			for _, s := range []session.Session{expected1, expected2} {
+				_, err = p.GetSession(ctx, s.ID)
+				require.Error(t, err)
+				_, err = p.GetSessionByToken(ctx, s.Token)
+				assert.ErrorIs(t, err, sqlcon.ErrNoRows)
+			}
+
+			t.Run("case=keeps expired sessions", func(t *testing.T) {
+				_, err := p.GetSession(ctx, expired.ID)
+				require.NoError(t, err)
+			})
+
+			t.Run("case=returns zero if there are no sessions", func(t *testing.T) {
+				count, err := p.DeleteSessionsByIdentity(ctx, expected2.IdentityID)
+				require.NoError(t, err)
+				assert.Equal(t, 0, count)
+			})
+		})
+
+		t.Run("case=list sessions by identity", func(t *testing.T) {
+			var active, expired, revoked session.Session
+			now := time.Now().UTC()
+			for k, s := range []*session.Session{&active, &expired, &revoked} {
+				require.NoError(t, faker.FakeData(s))
+				s.Active = true
+				s.ExpiresAt = now.Add(time.Hour)
+				s.IssuedAt = now.Add(time.Duration(k) * time.Minute)
+				if k > 0 {
+					s.Identity = active.Identity
+					s.IdentityID = active.IdentityID
+				} else {
+					require.NoError(t, p.CreateIdentity(ctx, s.Identity))
+				}
+			}
+			expired.ExpiresAt = now.Add(-time.Hour)
+			revoked.Active = false
+			for _, s := range []*session.Session{&active, &expired, &revoked} {
+				require.NoError(t, p.CreateSession(ctx, s))
+			}
+
+			ids := func(t *testing.T, ss []*session.Session) (ids []uuid.UUID) {
+				for _, s := range ss {
+					assert.Equal(t, active.IdentityID, s.Identity.ID)
+					ids = append(ids, s.ID)
+				}
+				return ids
+			}
+
+			ss, total, err := p.ListSessionsByIdentity(ctx, active.IdentityID, nil, 0, 10)
+			require.NoError(t, err)
+			assert.EqualValues(t, 3, total)
+			assert.Equal(t, []uuid.UUID{revoked.ID, expired.ID, active.ID}, ids(t, ss))
+
+			t.Run("case=paginates", func(t *testing.T) {
+				ss, total, err := p.ListSessionsByIdentity(ctx, active.IdentityID, nil, 0, 2)
+				require.NoError(t, err)
+				assert.EqualValues(t, 3, total)
+				assert.Equal(t, []uuid.UUID{revoked.ID, expired.ID}, ids(t, ss))
+
+				ss, _, err = p.ListSessionsByIdentity(ctx, active.IdentityID, nil, 1, 2)
+				require.NoError(t, err)
+				assert.Equal(t, []uuid.UUID{active.ID}, ids(t, ss))
+			})
+
+			t.Run("case=filters by active", func(t *testing.T) {
+				yes, no := true, false
+
+				ss, total, err := p.ListSessionsByIdentity(ctx, active.IdentityID, &yes, 0, 10)
+				require.NoError(t, err)
+				assert.EqualValues(t, 1, total)
+				assert.Equal(t, []uuid.UUID{active.ID}, ids(t, ss))
+
+				ss, total, err = p.ListSessionsByIdentity(ctx, active.IdentityID, &no, 0, 10)
+				require.NoError(t, err)
+				assert.EqualValues(t, 2, total)
+				assert.Equal(t, []uuid.UUID{revoked.ID, expired.ID}, ids(t, ss))
+			})
+
+			t.Run("on another network", func(t *testing.T) {
+				_, other := testhelpers.NewNetwork(t, ctx, p)
+				ss, total, err := other.ListSessionsByIdentity(ctx, active.IdentityID, nil, 0, 10)
+				require.NoError(t, err)
+				assert.EqualValues(t, 0, total)
+				assert.Empty(t, ss)
+			})
 		})
 
 		t.Run("network isolation", func(t *testing.T) {
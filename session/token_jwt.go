@@ -0,0 +1,182 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+const (
+	// TokenFormatOpaque hands out the session token as stored in the database.
+	TokenFormatOpaque = "opaque"
+
+	// TokenFormatJWT hands out a JWT signed with `secrets.session_jwt` which API gateways can verify
+	// without asking Ory Kratos.
+	TokenFormatJWT = "jwt"
+
+	// jwtAAL is the authenticator assurance level of every session. This version of Ory Kratos does
+	// not support a second factor.
+	jwtAAL = "aal1"
+)
+
+type tokenClaims struct {
+	jwt.StandardClaims
+
+	// AuthTime is the time the identity authenticated at.
+	AuthTime int64 `json:"auth_time"`
+
+	// AAL is the authenticator assurance level of the session.
+	AAL string `json:"aal"`
+
+	// Traits contains the traits listed in `session.jwt.traits`.
+	Traits json.RawMessage `json:"traits,omitempty"`
+}
+
+// isJWT returns true if the token is a JWT rather than an opaque session token. Opaque tokens are
+// alphanumeric and never contain dots.
+func isJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// newJWT returns a JWT for the session which is signed with the first secret of `secrets.session_jwt`.
+func newJWT(c *config.Config, s *Session) (string, error) {
+	claims := &tokenClaims{
+		StandardClaims: jwt.StandardClaims{
+			Id:        s.ID.String(),
+			Subject:   s.IdentityID.String(),
+			Issuer:    c.SelfPublicURL(nil).String(),
+			IssuedAt:  s.IssuedAt.Unix(),
+			ExpiresAt: s.ExpiresAt.Unix(),
+		},
+		AuthTime: s.AuthenticatedAt.Unix(),
+		AAL:      jwtAAL,
+	}
+
+	if paths := c.SessionJWTTraits(); len(paths) > 0 && s.Identity != nil {
+		traits := []byte("{}")
+		for _, path := range paths {
+			value := gjson.GetBytes(s.Identity.Traits, path)
+			if !value.Exists() {
+				continue
+			}
+
+			var err error
+			if traits, err = sjson.SetRawBytes(traits, path, []byte(value.Raw)); err != nil {
+				return "", errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to embed trait %q in the session token: %s", path, err))
+			}
+		}
+		claims.Traits = traits
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(c.SecretsSessionJWT()[0])
+	if err != nil {
+		return "", errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to sign the session token: %s", err))
+	}
+	return token, nil
+}
+
+// parseJWT verifies the token's signature against all secrets of `secrets.session_jwt` and returns its
+// claims. Expired tokens and tokens of other issuers are rejected with ErrNoActiveSessionFound.
+func parseJWT(c *config.Config, token string) (*tokenClaims, error) {
+	parser := &jwt.Parser{ValidMethods: []string{jwt.SigningMethodHS256.Alg()}}
+
+	for _, secret := range c.SecretsSessionJWT() {
+		claims := new(tokenClaims)
+		if _, err := parser.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+			return secret, nil
+		}); err != nil {
+			var ve *jwt.ValidationError
+			if errors.As(err, &ve) && ve.Errors&jwt.ValidationErrorSignatureInvalid != 0 {
+				// The token might have been signed with another secret.
+				continue
+			}
+			return nil, errors.WithStack(ErrNoActiveSessionFound.WithWrap(err))
+		}
+
+		if !claims.VerifyIssuer(c.SelfPublicURL(nil).String(), true) {
+			return nil, errors.WithStack(ErrNoActiveSessionFound.WithReasonf("The session token was issued by %q.", claims.Issuer))
+		}
+		return claims, nil
+	}
+
+	return nil, errors.WithStack(ErrNoActiveSessionFound.WithReason("The session token's signature is invalid."))
+}
+
+// sessionByJWT returns the session of a token in the JWT format. If `session.jwt.check_revocation` is
+// enabled, the session is loaded by the token's `jti` claim so that revoked sessions are rejected.
+// Otherwise the session is built from the token's claims.
+func (s *ManagerHTTP) sessionByJWT(ctx context.Context, token string) (*Session, error) {
+	c := s.r.Config(ctx)
+	claims, err := parseJWT(c, token)
+	if err != nil {
+		return nil, err
+	}
+
+	sid, err := uuid.FromString(claims.Id)
+	if err != nil {
+		return nil, errors.WithStack(ErrNoActiveSessionFound.WithReason("The session token's jti claim is invalid."))
+	}
+	iid, err := uuid.FromString(claims.Subject)
+	if err != nil {
+		return nil, errors.WithStack(ErrNoActiveSessionFound.WithReason("The session token's sub claim is invalid."))
+	}
+
+	if c.SessionJWTCheckRevocation() {
+		se, err := s.r.SessionPersister().GetSession(ctx, sid)
+		if err != nil {
+			return nil, err
+		}
+		if se.IdentityID != iid {
+			return nil, errors.WithStack(ErrNoActiveSessionFound.WithReason("The session token's sub claim does not match the session."))
+		}
+		return se, nil
+	}
+
+	i, err := s.r.IdentityPool().GetIdentity(ctx, iid)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Session{
+		ID:              sid,
+		Active:          true,
+		IssuedAt:        time.Unix(claims.IssuedAt, 0).UTC(),
+		ExpiresAt:       time.Unix(claims.ExpiresAt, 0).UTC(),
+		AuthenticatedAt: time.Unix(claims.AuthTime, 0).UTC(),
+		Identity:        i,
+		IdentityID:      i.ID,
+	}, nil
+}
+
+// tokenFromJWT returns the opaque token of the session a JWT was issued for, which is needed to revoke it.
+func tokenFromJWT(ctx context.Context, d interface {
+	config.Provider
+	PersistenceProvider
+}, token string) (string, error) {
+	claims, err := parseJWT(d.Config(ctx), token)
+	if err != nil {
+		return "", err
+	}
+
+	sid, err := uuid.FromString(claims.Id)
+	if err != nil {
+		return "", errors.WithStack(ErrNoActiveSessionFound.WithReason("The session token's jti claim is invalid."))
+	}
+
+	se, err := d.SessionPersister().GetSession(ctx, sid)
+	if err != nil {
+		return "", err
+	}
+	return se.Token, nil
+}
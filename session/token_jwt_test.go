@@ -0,0 +1,211 @@
+package session_test
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/identity"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/session"
+)
+
+func TestSessionTokenJWT(t *testing.T) {
+	ctx := context.Background()
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyDefaultIdentitySchemaURL, "file://./stub/fake-session.schema.json")
+	conf.MustSet(config.ViperKeyPublicBaseURL, "https://www.ory.sh/")
+	conf.MustSet(config.ViperKeySessionLifespan, "1h")
+	conf.MustSet(config.ViperKeySecretsSessionJWT, []string{"a-very-secret-jwt-secret"})
+	conf.MustSet(config.ViperKeySessionTokenFormat, session.TokenFormatJWT)
+	conf.MustSet(config.ViperKeySessionJWTTraits, []string{"email", "name.first"})
+
+	newSession := func(t *testing.T) *session.Session {
+		i := identity.Identity{Traits: []byte(`{"email":"foo@ory.sh","name":{"first":"Foo","last":"Bar"},"phone":"123"}`)}
+		require.NoError(t, reg.PrivilegedIdentityPool().CreateIdentity(ctx, &i))
+		s := session.NewActiveSession(&i, conf, time.Now())
+		require.NoError(t, reg.SessionPersister().CreateSession(ctx, s))
+		return s
+	}
+
+	issue := func(t *testing.T, s *session.Session) string {
+		token, err := session.IssuedToken(conf, s)
+		require.NoError(t, err)
+		return token
+	}
+
+	newRequest := func(token string) *http.Request {
+		r := httptest.NewRequest("GET", "/sessions/whoami", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		return r
+	}
+
+	fetch := func(token string) (*session.Session, error) {
+		return reg.SessionManager().FetchFromRequest(ctx, newRequest(token))
+	}
+
+	claimsOf := func(t *testing.T, token string) gjson.Result {
+		parts := strings.Split(token, ".")
+		require.Len(t, parts, 3)
+		payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+		require.NoError(t, err)
+		return gjson.ParseBytes(payload)
+	}
+
+	t.Run("case=should issue a signed JWT", func(t *testing.T) {
+		s := newSession(t)
+		token := issue(t, s)
+
+		parsed, err := jwt.Parse(token, func(*jwt.Token) (interface{}, error) {
+			return []byte("a-very-secret-jwt-secret"), nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "HS256", parsed.Method.Alg())
+
+		claims := claimsOf(t, token)
+		assert.Equal(t, s.ID.String(), claims.Get("jti").String())
+		assert.Equal(t, s.IdentityID.String(), claims.Get("sub").String())
+		assert.Equal(t, "https://www.ory.sh/", claims.Get("iss").String())
+		assert.Equal(t, s.ExpiresAt.Unix(), claims.Get("exp").Int())
+		assert.Equal(t, s.AuthenticatedAt.Unix(), claims.Get("auth_time").Int())
+		assert.Equal(t, "aal1", claims.Get("aal").String())
+		assert.JSONEq(t, `{"email":"foo@ory.sh","name":{"first":"Foo"}}`, claims.Get("traits").Raw)
+	})
+
+	t.Run("case=should verify a JWT", func(t *testing.T) {
+		s := newSession(t)
+
+		actual, err := fetch(issue(t, s))
+		require.NoError(t, err)
+		assert.Equal(t, s.ID, actual.ID)
+		assert.Equal(t, s.IdentityID, actual.Identity.ID)
+	})
+
+	t.Run("case=should verify JWTs signed with an older secret", func(t *testing.T) {
+		s := newSession(t)
+		token := issue(t, s)
+
+		conf.MustSet(config.ViperKeySecretsSessionJWT, []string{"a-new-secret-for-signing-jwts", "a-very-secret-jwt-secret"})
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySecretsSessionJWT, []string{"a-very-secret-jwt-secret"})
+		})
+
+		_, err := fetch(token)
+		require.NoError(t, err)
+	})
+
+	t.Run("case=should reject expired JWTs", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySessionJWTCheckRevocation, false)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySessionJWTCheckRevocation, true)
+		})
+
+		s := newSession(t)
+		s.ExpiresAt = time.Now().Add(-time.Minute)
+
+		_, err := fetch(issue(t, s))
+		assert.ErrorIs(t, err, session.ErrNoActiveSessionFound)
+	})
+
+	t.Run("case=should reject tampered JWTs", func(t *testing.T) {
+		s := newSession(t)
+		other := newSession(t)
+		token := issue(t, s)
+
+		parts := strings.Split(token, ".")
+		payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+		require.NoError(t, err)
+		parts[1] = base64.RawURLEncoding.EncodeToString([]byte(strings.Replace(string(payload), s.IdentityID.String(), other.IdentityID.String(), 1)))
+
+		for name, token := range map[string]string{
+			"payload":   strings.Join(parts, "."),
+			"signature": token[:len(token)-4] + "AAAA",
+		} {
+			t.Run("part="+name, func(t *testing.T) {
+				_, err := fetch(token)
+				assert.ErrorIs(t, err, session.ErrNoActiveSessionFound)
+			})
+		}
+	})
+
+	t.Run("case=should reject JWTs signed with an unknown secret or algorithm", func(t *testing.T) {
+		s := newSession(t)
+		claims := jwt.MapClaims{
+			"jti": s.ID.String(),
+			"sub": s.IdentityID.String(),
+			"iss": "https://www.ory.sh/",
+			"exp": s.ExpiresAt.Unix(),
+		}
+
+		for name, sign := range map[string]func() (string, error){
+			"secret": func() (string, error) {
+				return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("not-the-right-secret"))
+			},
+			"algorithm": func() (string, error) {
+				return jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+			},
+		} {
+			t.Run("unknown="+name, func(t *testing.T) {
+				token, err := sign()
+				require.NoError(t, err)
+
+				_, err = fetch(token)
+				assert.ErrorIs(t, err, session.ErrNoActiveSessionFound)
+			})
+		}
+	})
+
+	t.Run("case=should reject JWTs of revoked sessions", func(t *testing.T) {
+		s := newSession(t)
+		token := issue(t, s)
+
+		require.NoError(t, reg.SessionManager().PurgeFromRequest(ctx, httptest.NewRecorder(), newRequest(token)))
+		_, err := fetch(token)
+		assert.ErrorIs(t, err, session.ErrNoActiveSessionFound)
+
+		actual, err := reg.SessionPersister().GetSession(ctx, s.ID)
+		require.NoError(t, err)
+		assert.False(t, actual.Active)
+	})
+
+	t.Run("case=should trust JWTs if revocation is not checked", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySessionJWTCheckRevocation, false)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySessionJWTCheckRevocation, true)
+		})
+
+		s := newSession(t)
+		token := issue(t, s)
+		require.NoError(t, reg.SessionPersister().RevokeSessionByToken(ctx, s.Token))
+
+		actual, err := fetch(token)
+		require.NoError(t, err)
+		assert.Equal(t, s.ID, actual.ID)
+		assert.Equal(t, s.IdentityID, actual.Identity.ID)
+		assert.Equal(t, s.ExpiresAt.Unix(), actual.ExpiresAt.Unix())
+	})
+
+	t.Run("case=should still accept opaque tokens", func(t *testing.T) {
+		s := newSession(t)
+
+		actual, err := fetch(s.Token)
+		require.NoError(t, err)
+		assert.Equal(t, s.ID, actual.ID)
+
+		conf.MustSet(config.ViperKeySessionTokenFormat, session.TokenFormatOpaque)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySessionTokenFormat, session.TokenFormatJWT)
+		})
+		assert.Equal(t, s.Token, issue(t, s))
+	})
+}
@@ -0,0 +1,112 @@
+package session
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	whoamiCacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kratos_session_whoami_cache_lookups_total",
+		Help: "The number of whoami cache lookups partitioned by result (hit or miss).",
+	}, []string{"result"})
+	whoamiRateLimited = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "kratos_session_whoami_rate_limited_total",
+		Help: "The number of whoami requests which were rejected by the rate limit.",
+	})
+)
+
+type (
+	// WhoamiCache keeps the responses of the whoami endpoint in memory for a short time, keyed by the
+	// session token. Revoking or deleting a session through this instance removes it from the cache,
+	// other instances serve it until the TTL expires.
+	WhoamiCache struct {
+		sync.RWMutex
+		items     map[string]whoamiCacheItem
+		lastPurge time.Time
+	}
+	whoamiCacheItem struct {
+		session   *Session
+		expiresAt time.Time
+	}
+
+	WhoamiCacheProvider interface {
+		SessionWhoamiCache() *WhoamiCache
+	}
+)
+
+func NewWhoamiCache() *WhoamiCache {
+	return &WhoamiCache{items: make(map[string]whoamiCacheItem)}
+}
+
+// Get returns the session cached for the token or false if there is none.
+func (c *WhoamiCache) Get(token string) (*Session, bool) {
+	c.RLock()
+	item, ok := c.items[token]
+	c.RUnlock()
+
+	if !ok || time.Now().After(item.expiresAt) {
+		whoamiCacheLookups.WithLabelValues("miss").Inc()
+		return nil, false
+	}
+
+	whoamiCacheLookups.WithLabelValues("hit").Inc()
+	return item.session, true
+}
+
+// Set caches the session for the token until the ttl expires, but not longer than the session
+// itself is valid. Expired entries are removed while doing so.
+func (c *WhoamiCache) Set(token string, s *Session, ttl time.Duration) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	if s.ExpiresAt.Before(expiresAt) {
+		expiresAt = s.ExpiresAt
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	if now.Sub(c.lastPurge) > ttl {
+		for k, item := range c.items {
+			if now.After(item.expiresAt) {
+				delete(c.items, k)
+			}
+		}
+		c.lastPurge = now
+	}
+
+	c.items[token] = whoamiCacheItem{session: s, expiresAt: expiresAt}
+}
+
+// InvalidateToken removes the session with the given token from the cache.
+func (c *WhoamiCache) InvalidateToken(token string) {
+	c.Lock()
+	defer c.Unlock()
+
+	delete(c.items, token)
+}
+
+// InvalidateSession removes the session with the given ID from the cache.
+func (c *WhoamiCache) InvalidateSession(id uuid.UUID) {
+	c.invalidate(func(s *Session) bool { return s.ID == id })
+}
+
+// InvalidateIdentity removes all sessions of the given identity from the cache.
+func (c *WhoamiCache) InvalidateIdentity(id uuid.UUID) {
+	c.invalidate(func(s *Session) bool { return s.IdentityID == id })
+}
+
+func (c *WhoamiCache) invalidate(match func(s *Session) bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	for k, item := range c.items {
+		if match(item.session) {
+			delete(c.items, k)
+		}
+	}
+}
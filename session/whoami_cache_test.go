@@ -0,0 +1,55 @@
+package session_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/session"
+	"github.com/ory/kratos/x"
+)
+
+func TestWhoamiCache(t *testing.T) {
+	c := session.NewWhoamiCache()
+	newSession := func() *session.Session {
+		return &session.Session{ID: x.NewUUID(), IdentityID: x.NewUUID(), ExpiresAt: time.Now().Add(time.Hour)}
+	}
+
+	t.Run("case=returns cached sessions until the ttl expires", func(t *testing.T) {
+		s := newSession()
+		c.Set("a", s, time.Hour)
+		actual, ok := c.Get("a")
+		require.True(t, ok)
+		assert.Same(t, s, actual)
+
+		c.Set("b", s, -time.Second)
+		_, ok = c.Get("b")
+		assert.False(t, ok)
+	})
+
+	t.Run("case=does not outlive the session", func(t *testing.T) {
+		s := newSession()
+		s.ExpiresAt = time.Now().Add(-time.Second)
+		c.Set("c", s, time.Hour)
+		_, ok := c.Get("c")
+		assert.False(t, ok)
+	})
+
+	t.Run("case=invalidates", func(t *testing.T) {
+		byToken, byID, byIdentity := newSession(), newSession(), newSession()
+		c.Set("token", byToken, time.Hour)
+		c.Set("id", byID, time.Hour)
+		c.Set("identity", byIdentity, time.Hour)
+
+		c.InvalidateToken("token")
+		c.InvalidateSession(byID.ID)
+		c.InvalidateIdentity(byIdentity.IdentityID)
+
+		for _, token := range []string{"token", "id", "identity"} {
+			_, ok := c.Get(token)
+			assert.False(t, ok, token)
+		}
+	})
+}
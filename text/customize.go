@@ -0,0 +1,33 @@
+package text
+
+import (
+	"bytes"
+	"encoding/json"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// Customize replaces the message's text with the result of executing the Go template tmpl with
+// the message's context. The text is left unchanged if the template is invalid.
+func (m *Message) Customize(tmpl string) error {
+	t, err := template.New("message").Option("missingkey=zero").Parse(tmpl)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	data := map[string]interface{}{}
+	if len(m.Context) > 0 {
+		if err := json.Unmarshal(m.Context, &data); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return errors.WithStack(err)
+	}
+
+	m.Text = b.String()
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 
 func TestIDs(t *testing.T) {
 	assert.Equal(t, 1010000, int(InfoSelfServiceLoginRoot))
+	assert.Equal(t, 1010003, int(InfoSelfServiceLoginLinkCredentials))
 
 	assert.Equal(t, 1020000, int(InfoSelfServiceLogout))
 
@@ -15,13 +16,19 @@ func TestIDs(t *testing.T) {
 
 	assert.Equal(t, 1040000, int(InfoSelfServiceRegistrationRoot))
 	assert.Equal(t, 1040001, int(InfoSelfServiceRegistration))
+	assert.Equal(t, 1040003, int(InfoSelfServiceRegistrationEmailSent))
 
 	assert.Equal(t, 1050000, int(InfoSelfServiceSettings))
 	assert.Equal(t, 1050001, int(InfoSelfServiceSettingsUpdateSuccess))
+	assert.Equal(t, 1050004, int(InfoSelfServiceSettingsUpdateSuccessTrait))
+	assert.Equal(t, 1050005, int(InfoSelfServiceSettingsUpdateSuccessCredentials))
+	assert.Equal(t, 1050006, int(InfoSelfServiceSettingsNoChanges))
 
 	assert.Equal(t, 1060000, int(InfoSelfServiceRecovery))
 	assert.Equal(t, 1060001, int(InfoSelfServiceRecoverySuccessful))
 	assert.Equal(t, 1060002, int(InfoSelfServiceRecoveryEmailSent))
+	assert.Equal(t, 1060003, int(InfoSelfServiceRecoverySetPassword))
+	assert.Equal(t, 1060004, int(InfoSelfServiceRecoveryCodeSent))
 
 	assert.Equal(t, 1070000, int(InfoSelfServiceVerification))
 
@@ -31,9 +38,11 @@ func TestIDs(t *testing.T) {
 
 	assert.Equal(t, 4010000, int(ErrorValidationLogin))
 	assert.Equal(t, 4010001, int(ErrorValidationLoginFlowExpired))
+	assert.Equal(t, 4010010, int(ErrorValidationLoginLockedOut))
 
 	assert.Equal(t, 4040000, int(ErrorValidationRegistration))
 	assert.Equal(t, 4040001, int(ErrorValidationRegistrationFlowExpired))
+	assert.Equal(t, 4040002, int(ErrorValidationRegistrationIdentifierBlocked))
 
 	assert.Equal(t, 4050000, int(ErrorValidationSettings))
 	assert.Equal(t, 4050001, int(ErrorValidationSettingsFlowExpired))
@@ -41,9 +50,15 @@ func TestIDs(t *testing.T) {
 	assert.Equal(t, 4060000, int(ErrorValidationRecovery))
 	assert.Equal(t, 4060001, int(ErrorValidationRecoveryRetrySuccess))
 	assert.Equal(t, 4060002, int(ErrorValidationRecoveryStateFailure))
+	assert.Equal(t, 4060006, int(ErrorValidationRecoveryCodeInvalid))
+	assert.Equal(t, 4060007, int(ErrorValidationRecoveryCodeInvalidOrAlreadyUsed))
+	assert.Equal(t, 4060008, int(ErrorValidationRecoveryResendCooldown))
+	assert.Equal(t, 4060009, int(ErrorValidationRecoveryResendLimit))
 
 	assert.Equal(t, 4070000, int(ErrorValidationVerification))
 	assert.Equal(t, 4070001, int(ErrorValidationVerificationTokenInvalidOrAlreadyUsed))
+	assert.Equal(t, 4070007, int(ErrorValidationVerificationResendCooldown))
+	assert.Equal(t, 4070008, int(ErrorValidationVerificationResendLimit))
 
 	assert.Equal(t, 5000000, int(ErrorSystem))
 }
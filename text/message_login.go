@@ -2,13 +2,15 @@ package text
 
 import (
 	"fmt"
+	"math"
 	"time"
 )
 
 const (
-	InfoSelfServiceLoginRoot ID = 1010000 + iota // 1010000
-	InfoSelfServiceLogin                         // 1010001
-	InfoSelfServiceLoginWith                     // 1010002
+	InfoSelfServiceLoginRoot            ID = 1010000 + iota // 1010000
+	InfoSelfServiceLogin                                    // 1010001
+	InfoSelfServiceLoginWith                                // 1010002
+	InfoSelfServiceLoginLinkCredentials                     // 1010003
 )
 
 const (
@@ -19,6 +21,10 @@ const (
 	ErrorValidationSettingsNoStrategyFound                         // 4010004
 	ErrorValidationRecoveryNoStrategyFound                         // 4010005
 	ErrorValidationVerificationNoStrategyFound                     // 4010006
+	ErrorValidationLoginNotAllowed                                 // 4010007
+	ErrorValidationLoginTooManyFlows                               // 4010008
+	ErrorValidationLoginIdentityMismatch                           // 4010009
+	ErrorValidationLoginLockedOut                                  // 4010010
 )
 
 func NewInfoLogin() *Message {
@@ -41,6 +47,17 @@ func NewInfoLoginWith(provider string) *Message {
 	}
 }
 
+func NewInfoLoginLinkCredentials(provider string) *Message {
+	return &Message{
+		ID:   InfoSelfServiceLoginLinkCredentials,
+		Text: fmt.Sprintf("An account with the same email address exists already. Sign in to link your %s account to it.", provider),
+		Type: Info,
+		Context: context(map[string]interface{}{
+			"provider": provider,
+		}),
+	}
+}
+
 func NewErrorValidationLoginFlowExpired(ago time.Duration) *Message {
 	return &Message{
 		ID:   ErrorValidationLoginFlowExpired,
@@ -60,6 +77,42 @@ func NewErrorValidationLoginNoStrategyFound() *Message {
 	}
 }
 
+func NewErrorValidationLoginNotAllowed(reason string) *Message {
+	return &Message{
+		ID:      ErrorValidationLoginNotAllowed,
+		Text:    reason,
+		Type:    Error,
+		Context: context(nil),
+	}
+}
+
+func NewErrorValidationLoginTooManyFlows() *Message {
+	return &Message{
+		ID:   ErrorValidationLoginTooManyFlows,
+		Text: "Too many sign in attempts, please try again later.",
+		Type: Error,
+	}
+}
+
+func NewErrorValidationLoginIdentityMismatch() *Message {
+	return &Message{
+		ID:   ErrorValidationLoginIdentityMismatch,
+		Text: "Please confirm this action by signing in with the account you are currently signed in with.",
+		Type: Error,
+	}
+}
+
+func NewErrorValidationLoginLockedOut(lockedUntil time.Time) *Message {
+	return &Message{
+		ID:   ErrorValidationLoginLockedOut,
+		Text: fmt.Sprintf("Too many failed sign in attempts, please try again in %.0f minutes.", math.Ceil(time.Until(lockedUntil).Minutes())),
+		Type: Error,
+		Context: context(map[string]interface{}{
+			"locked_until": lockedUntil,
+		}),
+	}
+}
+
 func NewErrorValidationRegistrationNoStrategyFound() *Message {
 	return &Message{
 		ID:   ErrorValidationRegistrationNoStrategyFound,
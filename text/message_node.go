@@ -1,12 +1,19 @@
 package text
 
+import "fmt"
+
 const (
-	InfoNodeLabel              ID = 1070000 + iota // 1070000
-	InfoNodeLabelInputPassword                     // 1070001
-	InfoNodeLabelGenerated                         // 1070002
-	InfoNodeLabelSave                              // 1070003
-	InfoNodeLabelID                                // 1070004
-	InfoNodeLabelSubmit                            // 1070005
+	InfoNodeLabel                ID = 1070000 + iota // 1070000
+	InfoNodeLabelInputPassword                       // 1070001
+	InfoNodeLabelGenerated                           // 1070002
+	InfoNodeLabelSave                                // 1070003
+	InfoNodeLabelID                                  // 1070004
+	InfoNodeLabelSubmit                              // 1070005
+	InfoNodeLabelCurrentPassword                     // 1070006
+	InfoNodeLabelVerifyAddress                       // 1070007
+	InfoNodeLabelDeleteAccount                       // 1070008
+	InfoNodeLabelRecoveryCode                        // 1070009
+	InfoNodeLabelResend                              // 1070010
 )
 
 func NewInfoNodeInputPassword() *Message {
@@ -17,6 +24,14 @@ func NewInfoNodeInputPassword() *Message {
 	}
 }
 
+func NewInfoNodeLabelCurrentPassword() *Message {
+	return &Message{
+		ID:   InfoNodeLabelCurrentPassword,
+		Text: "Current Password",
+		Type: Info,
+	}
+}
+
 func NewInfoNodeLabelGenerated(title string) *Message {
 	return &Message{
 		ID:   InfoNodeLabelGenerated,
@@ -48,3 +63,38 @@ func NewInfoNodeLabelID() *Message {
 		Type: Info,
 	}
 }
+
+func NewInfoNodeLabelVerifyAddress(address string) *Message {
+	return &Message{
+		ID:   InfoNodeLabelVerifyAddress,
+		Text: fmt.Sprintf("Verify %s", address),
+		Type: Info,
+		Context: context(map[string]interface{}{
+			"address": address,
+		}),
+	}
+}
+
+func NewInfoNodeLabelDeleteAccount() *Message {
+	return &Message{
+		ID:   InfoNodeLabelDeleteAccount,
+		Text: "Delete account",
+		Type: Info,
+	}
+}
+
+func NewInfoNodeLabelRecoveryCode() *Message {
+	return &Message{
+		ID:   InfoNodeLabelRecoveryCode,
+		Text: "Recovery code",
+		Type: Info,
+	}
+}
+
+func NewInfoNodeLabelResend() *Message {
+	return &Message{
+		ID:   InfoNodeLabelResend,
+		Text: "Resend",
+		Type: Info,
+	}
+}
@@ -2,6 +2,7 @@ package text
 
 import (
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/pkg/errors"
@@ -10,9 +11,11 @@ import (
 )
 
 const (
-	InfoSelfServiceRecovery           ID = 1060000 + iota // 1060000
-	InfoSelfServiceRecoverySuccessful                     // 1060001
-	InfoSelfServiceRecoveryEmailSent                      // 1060002
+	InfoSelfServiceRecovery            ID = 1060000 + iota // 1060000
+	InfoSelfServiceRecoverySuccessful                      // 1060001
+	InfoSelfServiceRecoveryEmailSent                       // 1060002
+	InfoSelfServiceRecoverySetPassword                     // 1060003
+	InfoSelfServiceRecoveryCodeSent                        // 1060004
 )
 
 const (
@@ -22,6 +25,10 @@ const (
 	ErrorValidationRecoveryMissingRecoveryToken                          // 4060003
 	ErrorValidationRecoveryTokenInvalidOrAlreadyUsed                     // 4060004
 	ErrorValidationRecoveryFlowExpired                                   // 4060005
+	ErrorValidationRecoveryCodeInvalid                                   // 4060006
+	ErrorValidationRecoveryCodeInvalidOrAlreadyUsed                      // 4060007
+	ErrorValidationRecoveryResendCooldown                                // 4060008
+	ErrorValidationRecoveryResendLimit                                   // 4060009
 )
 
 func NewErrorValidationRecoveryFlowExpired(ago time.Duration) *Message {
@@ -56,6 +63,24 @@ func NewRecoveryEmailSent() *Message {
 	}
 }
 
+func NewRecoveryCodeSent() *Message {
+	return &Message{
+		ID:      InfoSelfServiceRecoveryCodeSent,
+		Type:    Info,
+		Text:    "An email containing a recovery code has been sent to the email address you provided.",
+		Context: context(nil),
+	}
+}
+
+func NewRecoverySetPassword() *Message {
+	return &Message{
+		ID:      InfoSelfServiceRecoverySetPassword,
+		Type:    Info,
+		Text:    "You successfully recovered your account. Please set a new password.",
+		Context: context(nil),
+	}
+}
+
 func NewErrorValidationRecoveryMissingRecoveryToken() error {
 	return errors.WithStack(herodot.
 		ErrBadRequest.
@@ -89,3 +114,43 @@ func NewErrorValidationRecoveryStateFailure() *Message {
 		Context: context(nil),
 	}
 }
+
+func NewErrorValidationRecoveryCodeInvalid() *Message {
+	return &Message{
+		ID:      ErrorValidationRecoveryCodeInvalid,
+		Text:    "The recovery code is invalid, please check for spelling mistakes.",
+		Type:    Error,
+		Context: context(nil),
+	}
+}
+
+func NewErrorValidationRecoveryCodeInvalidOrAlreadyUsed() *Message {
+	return &Message{
+		ID:      ErrorValidationRecoveryCodeInvalidOrAlreadyUsed,
+		Text:    "The recovery code is invalid, expired or has already been used. Please request a new code.",
+		Type:    Error,
+		Context: context(nil),
+	}
+}
+
+func NewErrorValidationRecoveryResendCooldown(resendAt time.Time) *Message {
+	return &Message{
+		ID:   ErrorValidationRecoveryResendCooldown,
+		Text: fmt.Sprintf("Please wait %.0f seconds before requesting another recovery email.", math.Ceil(time.Until(resendAt).Seconds())),
+		Type: Error,
+		Context: context(map[string]interface{}{
+			"resend_at": resendAt,
+		}),
+	}
+}
+
+func NewErrorValidationRecoveryResendLimit(max int) *Message {
+	return &Message{
+		ID:   ErrorValidationRecoveryResendLimit,
+		Text: fmt.Sprintf("The recovery email was already sent again %d times. Please start a new recovery flow.", max),
+		Type: Error,
+		Context: context(map[string]interface{}{
+			"max_resends": max,
+		}),
+	}
+}
@@ -6,14 +6,16 @@ import (
 )
 
 const (
-	InfoSelfServiceRegistrationRoot ID = 1040000 + iota // 1040000
-	InfoSelfServiceRegistration                         // 1040001
-	InfoSelfServiceRegistrationWith                     // 1040002
+	InfoSelfServiceRegistrationRoot      ID = 1040000 + iota // 1040000
+	InfoSelfServiceRegistration                              // 1040001
+	InfoSelfServiceRegistrationWith                          // 1040002
+	InfoSelfServiceRegistrationEmailSent                     // 1040003
 )
 
 const (
 	ErrorValidationRegistration ID = 4040000 + iota
 	ErrorValidationRegistrationFlowExpired
+	ErrorValidationRegistrationIdentifierBlocked
 )
 
 func NewInfoRegistration() *Message {
@@ -36,6 +38,15 @@ func NewInfoRegistrationWith(provider string) *Message {
 	}
 }
 
+func NewRegistrationEmailSent() *Message {
+	return &Message{
+		ID:      InfoSelfServiceRegistrationEmailSent,
+		Type:    Info,
+		Text:    "An email containing further instructions has been sent to the email address you provided.",
+		Context: context(nil),
+	}
+}
+
 func NewErrorValidationRegistrationFlowExpired(ago time.Duration) *Message {
 	return &Message{
 		ID:   ErrorValidationRegistrationFlowExpired,
@@ -46,3 +57,14 @@ func NewErrorValidationRegistrationFlowExpired(ago time.Duration) *Message {
 		}),
 	}
 }
+
+func NewErrorValidationRegistrationIdentifierBlocked(identifier string) *Message {
+	return &Message{
+		ID:   ErrorValidationRegistrationIdentifierBlocked,
+		Text: fmt.Sprintf("Signing up with %s is not allowed, please use a different identifier.", identifier),
+		Type: Error,
+		Context: context(map[string]interface{}{
+			"identifier": identifier,
+		}),
+	}
+}
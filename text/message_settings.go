@@ -2,6 +2,7 @@ package text
 
 import (
 	"fmt"
+	"math"
 	"time"
 )
 
@@ -10,11 +11,17 @@ const (
 	InfoSelfServiceSettingsUpdateSuccess
 	InfoSelfServiceSettingsUpdateLinkOidc
 	InfoSelfServiceSettingsUpdateUnlinkOidc
+	InfoSelfServiceSettingsUpdateSuccessTrait
+	InfoSelfServiceSettingsUpdateSuccessCredentials
+	InfoSelfServiceSettingsNoChanges
 )
 
 const (
 	ErrorValidationSettings ID = 4050000 + iota
 	ErrorValidationSettingsFlowExpired
+	ErrorValidationSettingsCurrentPasswordInvalid
+	ErrorValidationSettingsVerifiedAddressRequired
+	ErrorValidationSettingsCurrentPasswordLockedOut
 )
 
 func NewErrorValidationSettingsFlowExpired(ago time.Duration) *Message {
@@ -36,6 +43,36 @@ func NewInfoSelfServiceSettingsUpdateSuccess() *Message {
 	}
 }
 
+func NewInfoSelfServiceSettingsNoChanges() *Message {
+	return &Message{
+		ID:   InfoSelfServiceSettingsNoChanges,
+		Text: "There were no changes to save.",
+		Type: Info,
+	}
+}
+
+func NewInfoSelfServiceSettingsUpdateSuccessTrait(trait string) *Message {
+	return &Message{
+		ID:   InfoSelfServiceSettingsUpdateSuccessTrait,
+		Text: fmt.Sprintf("Your %s has been updated.", trait),
+		Type: Info,
+		Context: context(map[string]interface{}{
+			"trait": trait,
+		}),
+	}
+}
+
+func NewInfoSelfServiceSettingsUpdateSuccessCredentials(credentialsType string) *Message {
+	return &Message{
+		ID:   InfoSelfServiceSettingsUpdateSuccessCredentials,
+		Text: fmt.Sprintf("Your %s credentials have been updated.", credentialsType),
+		Type: Info,
+		Context: context(map[string]interface{}{
+			"credentials_type": credentialsType,
+		}),
+	}
+}
+
 func NewInfoSelfServiceSettingsUpdateLinkOIDC(provider string) *Message {
 	return &Message{
 		ID:   InfoSelfServiceSettingsUpdateLinkOidc,
@@ -57,3 +94,30 @@ func NewInfoSelfServiceSettingsUpdateUnlinkOIDC(provider string) *Message {
 		}),
 	}
 }
+
+func NewErrorValidationSettingsCurrentPasswordInvalid() *Message {
+	return &Message{
+		ID:   ErrorValidationSettingsCurrentPasswordInvalid,
+		Text: "The current password is incorrect.",
+		Type: Error,
+	}
+}
+
+func NewErrorValidationSettingsCurrentPasswordLockedOut(lockedUntil time.Time) *Message {
+	return &Message{
+		ID:   ErrorValidationSettingsCurrentPasswordLockedOut,
+		Text: fmt.Sprintf("The current password was entered incorrectly too many times, please try again in %.0f minutes.", math.Ceil(time.Until(lockedUntil).Minutes())),
+		Type: Error,
+		Context: context(map[string]interface{}{
+			"locked_until": lockedUntil,
+		}),
+	}
+}
+
+func NewErrorValidationSettingsVerifiedAddressRequired() *Message {
+	return &Message{
+		ID:   ErrorValidationSettingsVerifiedAddressRequired,
+		Text: "Please verify your email address before changing how you sign in. Check your inbox for the verification link or request a new one.",
+		Type: Error,
+	}
+}
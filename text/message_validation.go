@@ -14,6 +14,9 @@ const (
 	ErrorValidationInvalidCredentials
 	ErrorValidationDuplicateCredentials
 	ErrorValidationTOTPVerifierWrong
+	ErrorValidationTooManyAddresses
+	ErrorValidationTraitsTooDeep
+	ErrorValidationTraitsTooManyProperties
 )
 
 func NewValidationErrorGeneric(reason string) *Message {
@@ -97,3 +100,36 @@ func NewErrorValidationTOTPVerifierWrong() *Message {
 		Context: context(nil),
 	}
 }
+
+func NewErrorValidationTooManyAddresses(max int) *Message {
+	return &Message{
+		ID:   ErrorValidationTooManyAddresses,
+		Text: fmt.Sprintf("An account can not have more than %d addresses of the same kind.", max),
+		Type: Error,
+		Context: context(map[string]interface{}{
+			"max": max,
+		}),
+	}
+}
+
+func NewErrorValidationTraitsTooDeep(max int) *Message {
+	return &Message{
+		ID:   ErrorValidationTraitsTooDeep,
+		Text: fmt.Sprintf("The traits must not be nested more than %d levels deep.", max),
+		Type: Error,
+		Context: context(map[string]interface{}{
+			"max": max,
+		}),
+	}
+}
+
+func NewErrorValidationTraitsTooManyProperties(max int) *Message {
+	return &Message{
+		ID:   ErrorValidationTraitsTooManyProperties,
+		Text: fmt.Sprintf("The traits must not have more than %d properties.", max),
+		Type: Error,
+		Context: context(map[string]interface{}{
+			"max": max,
+		}),
+	}
+}
@@ -2,6 +2,7 @@ package text
 
 import (
 	"fmt"
+	"math"
 	"time"
 )
 
@@ -17,6 +18,9 @@ const (
 	ErrorValidationVerificationStateFailure                                  // 4070003
 	ErrorValidationVerificationMissingVerificationToken                      // 4070004
 	ErrorValidationVerificationFlowExpired                                   // 4070005
+	ErrorValidationVerificationAddressNotOwned                               // 4070006
+	ErrorValidationVerificationResendCooldown                                // 4070007
+	ErrorValidationVerificationResendLimit                                   // 4070008
 )
 
 func NewErrorValidationVerificationFlowExpired(ago time.Duration) *Message {
@@ -65,3 +69,36 @@ func NewErrorValidationVerificationStateFailure() *Message {
 		Context: context(nil),
 	}
 }
+
+func NewErrorValidationVerificationAddressNotOwned(address string) *Message {
+	return &Message{
+		ID:   ErrorValidationVerificationAddressNotOwned,
+		Text: fmt.Sprintf("The address %s does not belong to your account.", address),
+		Type: Error,
+		Context: context(map[string]interface{}{
+			"address": address,
+		}),
+	}
+}
+
+func NewErrorValidationVerificationResendCooldown(resendAt time.Time) *Message {
+	return &Message{
+		ID:   ErrorValidationVerificationResendCooldown,
+		Text: fmt.Sprintf("Please wait %.0f seconds before requesting another verification email.", math.Ceil(time.Until(resendAt).Seconds())),
+		Type: Error,
+		Context: context(map[string]interface{}{
+			"resend_at": resendAt,
+		}),
+	}
+}
+
+func NewErrorValidationVerificationResendLimit(max int) *Message {
+	return &Message{
+		ID:   ErrorValidationVerificationResendLimit,
+		Text: fmt.Sprintf("The verification email was already sent again %d times. Please start a new verification flow.", max),
+		Type: Error,
+		Context: context(map[string]interface{}{
+			"max_resends": max,
+		}),
+	}
+}
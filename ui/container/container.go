@@ -1,10 +1,12 @@
 package container
 
 import (
+	"bytes"
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"strings"
 
@@ -12,8 +14,10 @@ import (
 	"github.com/ory/x/sqlxx"
 
 	"github.com/ory/jsonschema/v3"
+	"github.com/tidwall/gjson"
 
 	"github.com/ory/x/decoderx"
+	"github.com/ory/x/httpx"
 	"github.com/ory/x/jsonschemax"
 	"github.com/ory/x/jsonx"
 	"github.com/ory/x/stringslice"
@@ -206,6 +210,54 @@ func (c *Container) UpdateNodesFromJSON(raw json.RawMessage, prefix string, grou
 	}
 }
 
+// UpdateNodeValuesFromRequest sets the values of the existing nodes below prefix (e.g. `traits`) to the
+// raw values submitted with the request. Unlike UpdateNodesFromJSON it does not add nodes and never sets
+// the value of password inputs. It is used to re-render a form whose submission could not be decoded,
+// which requires the request body to be kept when decoding it.
+func (c *Container) UpdateNodeValuesFromRequest(r *http.Request, prefix string) {
+	values := map[string]interface{}{}
+	if httpx.HasContentType(r, "application/json") {
+		raw, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(raw))
+
+		// The payload may be nested (`{"traits":{"email":"..."}}`) or flat (`{"traits.email":"..."}`).
+		gjson.ParseBytes(raw).ForEach(func(k, v gjson.Result) bool {
+			if !v.IsObject() {
+				values[k.String()] = v.Value()
+				return true
+			}
+			for kk, vv := range jsonx.Flatten(json.RawMessage(v.Raw)) {
+				values[k.String()+"."+kk] = vv
+			}
+			return true
+		})
+	} else if err := r.ParseForm(); err == nil {
+		for k := range r.PostForm {
+			values[k] = r.PostForm.Get(k)
+		}
+	}
+
+	for k, v := range values {
+		if !strings.HasPrefix(k, prefix+".") {
+			continue
+		}
+
+		n := c.Nodes.Find(k)
+		if n == nil {
+			continue
+		}
+
+		if a, ok := n.Attributes.(*node.InputAttributes); ok && a.Type == node.InputAttributeTypePassword {
+			continue
+		}
+
+		n.Attributes.SetValue(v)
+	}
+}
+
 // Unset removes a field from the container.
 func (c *Container) UnsetNode(id string) {
 	c.Nodes.Remove(id)
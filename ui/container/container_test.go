@@ -251,6 +251,36 @@ func TestContainer(t *testing.T) {
 		}
 	})
 
+	t.Run("method=UpdateNodeValuesFromRequest", func(t *testing.T) {
+		for k, tc := range []struct {
+			d string
+			r *http.Request
+		}{
+			{d: "form", r: newFormRequest(t, url.Values{"traits.email": {"foo@ory.sh"}, "traits.age": {"not a number"}, "traits.secret": {"password"}, "traits.unknown": {"bar"}, "password": {"password"}})},
+			{d: "nested json", r: newJSONRequest(t, `{"traits":{"email":"foo@ory.sh","age":"not a number","secret":"password","unknown":"bar"},"password":"password"}`)},
+			{d: "flat json", r: newJSONRequest(t, `{"traits.email":"foo@ory.sh","traits.age":"not a number","traits.secret":"password","traits.unknown":"bar","password":"password"}`)},
+		} {
+			t.Run("case="+tc.d, func(t *testing.T) {
+				c := Container{
+					Nodes: node.Nodes{
+						node.NewInputField("traits.email", "", node.PasswordGroup, node.InputAttributeTypeEmail),
+						node.NewInputField("traits.age", nil, node.PasswordGroup, node.InputAttributeTypeNumber),
+						node.NewInputField("traits.secret", nil, node.PasswordGroup, node.InputAttributeTypePassword),
+						node.NewInputField("password", nil, node.PasswordGroup, node.InputAttributeTypeText),
+					},
+				}
+
+				c.UpdateNodeValuesFromRequest(tc.r, "traits")
+
+				assert.Len(t, c.Nodes, 4, "%d", k)
+				assert.EqualValues(t, "foo@ory.sh", c.Nodes.Find("traits.email").Attributes.GetValue(), "%d", k)
+				assert.EqualValues(t, "not a number", c.Nodes.Find("traits.age").Attributes.GetValue(), "%d", k)
+				assert.Nil(t, c.Nodes.Find("traits.secret").Attributes.GetValue(), "%d", k)
+				assert.Nil(t, c.Nodes.Find("password").Attributes.GetValue(), "%d", k)
+			})
+		}
+	})
+
 	t.Run("method=SetCSRF", func(t *testing.T) {
 		f := &Container{Nodes: node.Nodes{}}
 		f.SetCSRF("csrf-token")
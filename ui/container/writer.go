@@ -0,0 +1,127 @@
+package container
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/text"
+	"github.com/ory/kratos/ui/node"
+	"github.com/ory/kratos/x"
+)
+
+// UIGetter is implemented by flows which contain a UI container.
+type UIGetter interface {
+	GetUI() *Container
+}
+
+// NodeAttributesWriter adds the custom attributes configured by `selfservice.ui_node_attributes`
+// to the nodes of every flow before it is written.
+type NodeAttributesWriter struct {
+	herodot.Writer
+	d config.Provider
+}
+
+var _ herodot.Writer = new(NodeAttributesWriter)
+
+func NewNodeAttributesWriter(w herodot.Writer, d config.Provider) *NodeAttributesWriter {
+	return &NodeAttributesWriter{Writer: w, d: d}
+}
+
+func (w *NodeAttributesWriter) Write(rw http.ResponseWriter, r *http.Request, e interface{}, opts ...herodot.EncoderOptions) {
+	w.addAttributes(r, e)
+	w.Writer.Write(rw, r, e, opts...)
+}
+
+func (w *NodeAttributesWriter) WriteCode(rw http.ResponseWriter, r *http.Request, code int, e interface{}, opts ...herodot.EncoderOptions) {
+	w.addAttributes(r, e)
+	w.Writer.WriteCode(rw, r, code, e, opts...)
+}
+
+func (w *NodeAttributesWriter) WriteCreated(rw http.ResponseWriter, r *http.Request, location string, e interface{}) {
+	w.addAttributes(r, e)
+	w.Writer.WriteCreated(rw, r, location, e)
+}
+
+func (w *NodeAttributesWriter) addAttributes(r *http.Request, e interface{}) {
+	f, ok := e.(UIGetter)
+	if !ok || f.GetUI() == nil {
+		return
+	}
+
+	for _, a := range w.d.Config(r.Context()).SelfServiceUINodeAttributes() {
+		f.GetUI().Nodes.AddCustomAttributes(a.Name, node.Group(a.Group), a.Attributes)
+	}
+}
+
+// MessagesWriter replaces the texts of the UI messages configured by `selfservice.ui_messages` in
+// every flow before it is written.
+type MessagesWriter struct {
+	herodot.Writer
+	d interface {
+		config.Provider
+		x.LoggingProvider
+	}
+}
+
+var _ herodot.Writer = new(MessagesWriter)
+
+func NewMessagesWriter(w herodot.Writer, d interface {
+	config.Provider
+	x.LoggingProvider
+}) *MessagesWriter {
+	return &MessagesWriter{Writer: w, d: d}
+}
+
+func (w *MessagesWriter) Write(rw http.ResponseWriter, r *http.Request, e interface{}, opts ...herodot.EncoderOptions) {
+	w.customize(r, e)
+	w.Writer.Write(rw, r, e, opts...)
+}
+
+func (w *MessagesWriter) WriteCode(rw http.ResponseWriter, r *http.Request, code int, e interface{}, opts ...herodot.EncoderOptions) {
+	w.customize(r, e)
+	w.Writer.WriteCode(rw, r, code, e, opts...)
+}
+
+func (w *MessagesWriter) WriteCreated(rw http.ResponseWriter, r *http.Request, location string, e interface{}) {
+	w.customize(r, e)
+	w.Writer.WriteCreated(rw, r, location, e)
+}
+
+func (w *MessagesWriter) customize(r *http.Request, e interface{}) {
+	f, ok := e.(UIGetter)
+	if !ok || f.GetUI() == nil {
+		return
+	}
+
+	overrides := w.d.Config(r.Context()).SelfServiceUIMessages()
+	if len(overrides) == 0 {
+		return
+	}
+
+	apply := func(m *text.Message) {
+		tmpl, ok := overrides[strconv.Itoa(int(m.ID))]
+		if !ok {
+			return
+		}
+		if err := m.Customize(tmpl); err != nil {
+			w.d.Logger().WithError(err).WithField("message_id", m.ID).
+				Warnf("Unable to apply the custom text from %s, using the default text.", config.ViperKeySelfServiceUIMessages)
+		}
+	}
+
+	ui := f.GetUI()
+	for k := range ui.Messages {
+		apply(&ui.Messages[k])
+	}
+	for _, n := range ui.Nodes {
+		for k := range n.Messages {
+			apply(&n.Messages[k])
+		}
+		if n.Meta != nil && n.Meta.Label != nil {
+			apply(n.Meta.Label)
+		}
+	}
+}
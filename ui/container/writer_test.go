@@ -0,0 +1,103 @@
+package container_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ory/herodot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/text"
+	"github.com/ory/kratos/ui/container"
+	"github.com/ory/kratos/ui/node"
+	"github.com/ory/kratos/x"
+)
+
+type uiFlow struct {
+	UI *container.Container `json:"ui"`
+}
+
+func (f *uiFlow) GetUI() *container.Container {
+	return f.UI
+}
+
+func TestNodeAttributesWriter(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeySelfServiceUINodeAttributes, []map[string]interface{}{
+		{"name": "traits.email", "attributes": map[string]interface{}{"data-analytics-id": "email"}},
+		{"group": "password", "attributes": map[string]interface{}{"data-method": "password"}},
+	})
+	w := container.NewNodeAttributesWriter(herodot.NewJSONWriter(reg.Logger()), reg)
+
+	f := &uiFlow{UI: container.New("/action")}
+	f.UI.Nodes.Append(node.NewInputField("traits.email", "", node.PasswordGroup, node.InputAttributeTypeEmail))
+	f.UI.Nodes.Append(node.NewCSRFNode(x.FakeCSRFToken))
+
+	rec := httptest.NewRecorder()
+	w.Write(rec, httptest.NewRequest("GET", "/", nil), f)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body := rec.Body.Bytes()
+	assert.Equal(t, "email", gjson.GetBytes(body, "ui.nodes.0.attributes.data-analytics-id").String(), "%s", body)
+	assert.Equal(t, "password", gjson.GetBytes(body, "ui.nodes.0.attributes.data-method").String(), "%s", body)
+	assert.False(t, gjson.GetBytes(body, "ui.nodes.1.attributes.data-method").Exists(), "%s", body)
+}
+
+func TestMessagesWriter(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	w := container.NewMessagesWriter(herodot.NewJSONWriter(reg.Logger()), reg)
+
+	write := func(t *testing.T) []byte {
+		f := &uiFlow{UI: container.New("/action")}
+		f.UI.Messages.Add(text.NewErrorValidationInvalidCredentials())
+		f.UI.Nodes.Append(node.NewInputField("provider", "github", node.OpenIDConnectGroup, node.InputAttributeTypeSubmit).
+			WithMetaLabel(text.NewInfoLoginWith("GitHub")))
+		f.UI.Nodes.Append(node.NewInputField("password", nil, node.PasswordGroup, node.InputAttributeTypePassword))
+		f.UI.Nodes[1].Messages.Add(text.NewErrorValidationInvalidCredentials())
+
+		rec := httptest.NewRecorder()
+		w.Write(rec, httptest.NewRequest("GET", "/", nil), f)
+		require.Equal(t, http.StatusOK, rec.Code)
+		return rec.Body.Bytes()
+	}
+
+	t.Run("case=uses the default texts", func(t *testing.T) {
+		body := write(t)
+		assert.Equal(t, text.NewErrorValidationInvalidCredentials().Text, gjson.GetBytes(body, "ui.messages.0.text").String(), "%s", body)
+		assert.Equal(t, "Sign in with GitHub", gjson.GetBytes(body, "ui.nodes.0.meta.label.text").String(), "%s", body)
+	})
+
+	t.Run("case=replaces the configured texts", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceUIMessages, map[string]interface{}{
+			fmt.Sprintf("%d", text.ErrorValidationInvalidCredentials): "Please check your credentials.",
+			fmt.Sprintf("%d", text.InfoSelfServiceLoginWith):          "Continue with {{ .provider }}",
+		})
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceUIMessages, nil)
+		})
+
+		body := write(t)
+		assert.Equal(t, "Please check your credentials.", gjson.GetBytes(body, "ui.messages.0.text").String(), "%s", body)
+		assert.Equal(t, "Please check your credentials.", gjson.GetBytes(body, "ui.nodes.1.messages.0.text").String(), "%s", body)
+		assert.EqualValues(t, text.ErrorValidationInvalidCredentials, gjson.GetBytes(body, "ui.nodes.1.messages.0.id").Int(), "%s", body)
+		assert.Equal(t, "Continue with GitHub", gjson.GetBytes(body, "ui.nodes.0.meta.label.text").String(), "%s", body)
+	})
+
+	t.Run("case=keeps the default text if the template is invalid", func(t *testing.T) {
+		conf.MustSet(config.ViperKeySelfServiceUIMessages, map[string]interface{}{
+			fmt.Sprintf("%d", text.InfoSelfServiceLoginWith): "Continue with {{ .provider",
+		})
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeySelfServiceUIMessages, nil)
+		})
+
+		body := write(t)
+		assert.Equal(t, "Sign in with GitHub", gjson.GetBytes(body, "ui.nodes.0.meta.label.text").String(), "%s", body)
+	})
+}
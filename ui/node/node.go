@@ -4,11 +4,14 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"path"
+	"reflect"
 	"sort"
 	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
 
 	"github.com/ory/kratos/schema"
 
@@ -32,7 +35,9 @@ const (
 	OpenIDConnectGroup    Group = "oidc"
 	ProfileGroup          Group = "profile"
 	RecoveryLinkGroup     Group = "link"
+	RecoveryCodeGroup     Group = "code"
 	VerificationLinkGroup Group = "link"
+	AccountDeletionGroup  Group = "account_deletion"
 
 	Text   Type = "text"
 	Input  Type = "input"
@@ -82,6 +87,10 @@ type Node struct {
 	//
 	// required: true
 	Meta *Meta `json:"meta"`
+
+	// CustomAttributes are added to the node's attributes when it is encoded unless they would
+	// replace one of the node's own attributes.
+	CustomAttributes map[string]string `json:"-" faker:"-"`
 }
 
 // A Node's Meta Information
@@ -104,6 +113,8 @@ type jsonRawNode struct {
 	Attributes Attributes    `json:"attributes"`
 	Messages   text.Messages `json:"messages"`
 	Meta       *Meta         `json:"meta"`
+
+	CustomAttributes map[string]string `json:"-"`
 }
 
 func (n *Node) ID() string {
@@ -325,6 +336,28 @@ func (n *Nodes) SetValueAttribute(id string, value interface{}) bool {
 	return false
 }
 
+// AddCustomAttributes adds the attributes to all nodes whose name matches the glob pattern and
+// which belong to the group. An empty name or group matches all nodes.
+func (n Nodes) AddCustomAttributes(name string, group Group, attributes map[string]string) {
+	for _, nn := range n {
+		if group != "" && nn.Group != group {
+			continue
+		}
+		if name != "" {
+			if matched, _ := path.Match(name, nn.ID()); !matched || nn.ID() == "" {
+				continue
+			}
+		}
+
+		if nn.CustomAttributes == nil {
+			nn.CustomAttributes = make(map[string]string, len(attributes))
+		}
+		for key, value := range attributes {
+			nn.CustomAttributes[key] = value
+		}
+	}
+}
+
 // Append appends a node.
 func (n *Nodes) Append(node *Node) {
 	*n = append(*n, node)
@@ -387,5 +420,41 @@ func (n *Node) MarshalJSON() ([]byte, error) {
 		n.Meta = new(Meta)
 	}
 
-	return json.Marshal((*jsonRawNode)(n))
+	out, err := json.Marshal((*jsonRawNode)(n))
+	if err != nil || len(n.CustomAttributes) == 0 {
+		return out, err
+	}
+
+	core := attributeKeys(n.Attributes)
+	for key, value := range n.CustomAttributes {
+		if stringslice.Has(core, key) {
+			continue
+		}
+
+		out, err = sjson.SetBytes(out, "attributes."+sjsonEscaper.Replace(key), value)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	return out, nil
+}
+
+var sjsonEscaper = strings.NewReplacer(".", `\.`, "*", `\*`, "?", `\?`)
+
+// attributeKeys returns the JSON keys of the attributes including those which are omitted
+// because they are empty.
+func attributeKeys(a Attributes) []string {
+	t := reflect.TypeOf(a)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	keys := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		if key := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]; key != "" && key != "-" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
 }
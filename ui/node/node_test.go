@@ -158,3 +158,48 @@ func TestNodeJSON(t *testing.T) {
 		require.EqualError(t, json.NewDecoder(bytes.NewReader(json.RawMessage(`{"type": "foo"}`))).Decode(&n), "unexpected node type: foo")
 	})
 }
+
+func TestNodesAddCustomAttributes(t *testing.T) {
+	newNodes := func() node.Nodes {
+		return node.Nodes{
+			node.NewInputField("traits.email", "", node.PasswordGroup, node.InputAttributeTypeEmail),
+			node.NewInputField("traits.name", "", node.ProfileGroup, node.InputAttributeTypeText),
+			node.NewInputField("provider", "google", node.OpenIDConnectGroup, node.InputAttributeTypeSubmit),
+		}
+	}
+
+	t.Run("case=matches nodes by name and group", func(t *testing.T) {
+		nodes := newNodes()
+		nodes.AddCustomAttributes("traits.*", node.PasswordGroup, map[string]string{"data-analytics-id": "email"})
+
+		assert.Equal(t, map[string]string{"data-analytics-id": "email"}, nodes[0].CustomAttributes)
+		assert.Empty(t, nodes[1].CustomAttributes)
+		assert.Empty(t, nodes[2].CustomAttributes)
+	})
+
+	t.Run("case=empty selectors match all nodes", func(t *testing.T) {
+		nodes := newNodes()
+		nodes.AddCustomAttributes("", "", map[string]string{"data-theme": "dark"})
+
+		for _, n := range nodes {
+			assert.Equal(t, "dark", n.CustomAttributes["data-theme"])
+		}
+	})
+
+	t.Run("case=adds attributes to the JSON without replacing core attributes", func(t *testing.T) {
+		nodes := newNodes()
+		nodes.AddCustomAttributes("provider", "", map[string]string{
+			"aria-label": "Sign in with Google",
+			"data.id":    "google",
+			"value":      "github",
+			"pattern":    ".*",
+		})
+
+		out, err := json.Marshal(nodes[2])
+		require.NoError(t, err)
+		assert.Equal(t, "Sign in with Google", gjson.GetBytes(out, "attributes.aria-label").String())
+		assert.Equal(t, "google", gjson.GetBytes(out, `attributes.data\.id`).String())
+		assert.Equal(t, "google", gjson.GetBytes(out, "attributes.value").String())
+		assert.False(t, gjson.GetBytes(out, "attributes.pattern").Exists())
+	})
+}
@@ -0,0 +1,58 @@
+package x
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ClientIP returns the IP of the client which sent the request.
+//
+// The `X-Forwarded-For` header is only taken into account if the request was sent by one of the
+// trusted proxies. In that case the header is read from right to left and the first address which
+// is not a trusted proxy is returned.
+func ClientIP(r *http.Request, trustedProxies []*net.IPNet) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !containsIP(trustedProxies, ip) {
+		return ip
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if hop == nil {
+			break
+		}
+
+		ip = hop
+		if !containsIP(trustedProxies, hop) {
+			break
+		}
+	}
+
+	return ip
+}
+
+// ClientIPString returns the IP of the client which sent the request as a string. Unlike ClientIP, it never
+// returns "<nil>" but falls back to the remote address if that is not a valid IP, so that it can be used as
+// the key of rate limits.
+func ClientIPString(r *http.Request, trustedProxies []*net.IPNet) string {
+	if ip := ClientIP(r, trustedProxies); ip != nil {
+		return ip.String()
+	}
+	return r.RemoteAddr
+}
+
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -14,6 +14,12 @@ var PseudoPanic = herodot.DefaultError{
 	CodeField:   http.StatusConflict,
 }
 
+var ErrTooManyRequests = herodot.DefaultError{
+	StatusField: http.StatusText(http.StatusTooManyRequests),
+	ErrorField:  "Too many requests were made, please try again later",
+	CodeField:   http.StatusTooManyRequests,
+}
+
 type StatusCodeCarrier interface {
 	StatusCode() int
 }
@@ -0,0 +1,27 @@
+package x
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+// HSTS returns a middleware which sets the Strict-Transport-Security header as configured by
+// `serve.<iface>.hsts` where iface is either "public" or "admin".
+func HSTS(d config.Provider, iface string) func(http.ResponseWriter, *http.Request, http.HandlerFunc) {
+	return func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		if c := d.Config(r.Context()).HSTS(iface); c.Enabled {
+			value := fmt.Sprintf("max-age=%d", int64(c.MaxAge.Seconds()))
+			if c.IncludeSubdomains {
+				value += "; includeSubDomains"
+			}
+			if c.Preload {
+				value += "; preload"
+			}
+			w.Header().Set("Strict-Transport-Security", value)
+		}
+
+		next(w, r)
+	}
+}
@@ -0,0 +1,44 @@
+package x_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/x"
+)
+
+func TestHSTS(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+
+	var header = func(iface string) string {
+		w := httptest.NewRecorder()
+		x.HSTS(reg, iface)(w, httptest.NewRequest("GET", "/", nil), func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		return w.Header().Get("Strict-Transport-Security")
+	}
+
+	t.Run("case=disabled by default", func(t *testing.T) {
+		assert.Empty(t, header("public"))
+		assert.Empty(t, header("admin"))
+	})
+
+	t.Run("case=enabled", func(t *testing.T) {
+		conf.MustSet("serve.public.hsts.enabled", true)
+		assert.Equal(t, "max-age=31536000", header("public"))
+		assert.Empty(t, header("admin"))
+	})
+
+	t.Run("case=all directives", func(t *testing.T) {
+		conf.MustSet("serve.admin.hsts.enabled", true)
+		conf.MustSet("serve.admin.hsts.max_age", "1h")
+		conf.MustSet("serve.admin.hsts.include_subdomains", true)
+		conf.MustSet("serve.admin.hsts.preload", true)
+		assert.Equal(t, "max-age=3600; includeSubDomains; preload", header("admin"))
+	})
+}
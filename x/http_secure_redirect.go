@@ -19,6 +19,7 @@ type secureRedirectOptions struct {
 	whitelist       []url.URL
 	defaultReturnTo *url.URL
 	sourceURL       string
+	requireHTTPS    bool
 }
 
 type SecureRedirectOption func(*secureRedirectOptions)
@@ -55,6 +56,14 @@ func SecureRedirectOverrideDefaultReturnTo(defaultReturnTo *url.URL) SecureRedir
 	}
 }
 
+// SecureRedirectRequireHTTPS rejects `?return_to=` values which do not use the `https` scheme,
+// even if they are whitelisted.
+func SecureRedirectRequireHTTPS(require bool) SecureRedirectOption {
+	return func(o *secureRedirectOptions) {
+		o.requireHTTPS = require
+	}
+}
+
 // SecureRedirectTo implements a HTTP redirector who mitigates open redirect vulnerabilities by
 // working with whitelisting.
 func SecureRedirectTo(r *http.Request, defaultReturnTo *url.URL, opts ...SecureRedirectOption) (returnTo *url.URL, err error) {
@@ -84,6 +93,12 @@ func SecureRedirectTo(r *http.Request, defaultReturnTo *url.URL, opts ...SecureR
 	returnTo.Host = stringsx.Coalesce(returnTo.Host, o.defaultReturnTo.Host)
 	returnTo.Scheme = stringsx.Coalesce(returnTo.Scheme, o.defaultReturnTo.Scheme)
 
+	if o.requireHTTPS && !strings.EqualFold(returnTo.Scheme, "https") {
+		return nil, errors.WithStack(herodot.ErrBadRequest.
+			WithReasonf("Requested return_to URL \"%s\" is not allowed because it does not use HTTPS.", returnTo).
+			WithDebug("Non-HTTPS return_to URLs can be allowed by setting selfservice.require_https_return_urls to false."))
+	}
+
 	var found bool
 	for _, allowed := range o.whitelist {
 		if strings.EqualFold(allowed.Scheme, returnTo.Scheme) &&
@@ -123,6 +138,7 @@ func SecureContentNegotiationRedirection(
 				SecureRedirectUseSourceURL(requestURL),
 				SecureRedirectAllowURLs(c.SelfServiceBrowserWhitelistedReturnToDomains()),
 				SecureRedirectAllowSelfServiceURLs(c.SelfPublicURL(r)),
+				SecureRedirectRequireHTTPS(c.SelfServiceBrowserRequireHTTPSReturnTo()),
 			}, opts...)...,
 		)
 		if err != nil {
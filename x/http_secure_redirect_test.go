@@ -196,6 +196,39 @@ func TestSecureRedirectTo(t *testing.T) {
 		assert.Equal(t, body, s.URL+"/another-default")
 	})
 
+	t.Run("case=return to a whitelisted http URL fails if https is required", func(t *testing.T) {
+		s := newServer(t, false, false, true, func(ts *httptest.Server) []x.SecureRedirectOption {
+			return []x.SecureRedirectOption{
+				x.SecureRedirectAllowURLs([]url.URL{*urlx.ParseOrPanic("http://www.ory.sh/")}),
+				x.SecureRedirectRequireHTTPS(true),
+			}
+		})
+		_, body := makeRequest(t, s, "?return_to=http://www.ory.sh/kratos")
+		assert.Equal(t, body, "error")
+	})
+
+	t.Run("case=return to a whitelisted http URL works if https is not required", func(t *testing.T) {
+		s := newServer(t, false, false, false, func(ts *httptest.Server) []x.SecureRedirectOption {
+			return []x.SecureRedirectOption{
+				x.SecureRedirectAllowURLs([]url.URL{*urlx.ParseOrPanic("http://www.ory.sh/")}),
+				x.SecureRedirectRequireHTTPS(false),
+			}
+		})
+		_, body := makeRequest(t, s, "?return_to=http://www.ory.sh/kratos")
+		assert.Equal(t, body, "http://www.ory.sh/kratos")
+	})
+
+	t.Run("case=return to a whitelisted https URL works if https is required", func(t *testing.T) {
+		s := newServer(t, false, false, false, func(ts *httptest.Server) []x.SecureRedirectOption {
+			return []x.SecureRedirectOption{
+				x.SecureRedirectAllowURLs([]url.URL{*urlx.ParseOrPanic("https://www.ory.sh/")}),
+				x.SecureRedirectRequireHTTPS(true),
+			}
+		})
+		_, body := makeRequest(t, s, "?return_to=https://www.ory.sh/kratos")
+		assert.Equal(t, body, "https://www.ory.sh/kratos")
+	})
+
 	t.Run("case=should override return_to", func(t *testing.T) {
 		s := newServer(t, false, false, false, func(ts *httptest.Server) []x.SecureRedirectOption {
 			return []x.SecureRedirectOption{
@@ -0,0 +1,36 @@
+package x
+
+import (
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+// AdminIPFilter returns a middleware which rejects requests from client IPs which are not allowed
+// by `serve.admin.ip_filter` with 403 Forbidden.
+func AdminIPFilter(d interface {
+	config.Provider
+	WriterProvider
+}) func(http.ResponseWriter, *http.Request, http.HandlerFunc) {
+	return func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		c := d.Config(r.Context())
+		allow, deny := c.AdminIPFilterAllow(), c.AdminIPFilterDeny()
+		if len(allow) == 0 && len(deny) == 0 {
+			next(w, r)
+			return
+		}
+
+		ip := ClientIP(r, c.TrustedProxies())
+		if ip == nil || containsIP(deny, ip) || (len(allow) > 0 && !containsIP(allow, ip)) {
+			d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrForbidden.
+				WithReasonf("Access to the admin API is not allowed from this IP address.")))
+			return
+		}
+
+		next(w, r)
+	}
+}
@@ -0,0 +1,84 @@
+package x_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/x"
+)
+
+func TestClientIP(t *testing.T) {
+	conf, _ := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyTrustedProxies, []string{"10.0.0.0/8"})
+	trusted := conf.TrustedProxies()
+
+	for k, tc := range []struct {
+		d         string
+		remote    string
+		forwarded string
+		expected  string
+	}{
+		{d: "no proxy", remote: "1.2.3.4:1234", expected: "1.2.3.4"},
+		{d: "ignores header of untrusted remote", remote: "1.2.3.4:1234", forwarded: "5.6.7.8", expected: "1.2.3.4"},
+		{d: "uses header of trusted proxy", remote: "10.0.0.1:1234", forwarded: "5.6.7.8", expected: "5.6.7.8"},
+		{d: "skips trusted hops", remote: "10.0.0.1:1234", forwarded: "5.6.7.8, 1.2.3.4, 10.0.0.2", expected: "1.2.3.4"},
+		{d: "trusted proxy without header", remote: "10.0.0.1:1234", expected: "10.0.0.1"},
+		{d: "falls back to the remote address if it is not an IP", remote: "@", expected: "@"},
+	} {
+		t.Run("case="+tc.d, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tc.remote
+			if tc.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tc.forwarded)
+			}
+			assert.Equal(t, tc.expected, x.ClientIPString(r, trusted), "%d", k)
+		})
+	}
+}
+
+func TestAdminIPFilter(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	filter := x.AdminIPFilter(reg)
+
+	var status = func(remote string) int {
+		r := httptest.NewRequest("GET", "/identities", nil)
+		r.RemoteAddr = remote
+		w := httptest.NewRecorder()
+		filter(w, r, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+		return w.Code
+	}
+
+	t.Run("case=allows all sources if not configured", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, status("1.2.3.4:1234"))
+	})
+
+	t.Run("case=only allows listed sources", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyAdminIPFilterAllow, []string{"10.0.0.0/8", "127.0.0.1"})
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyAdminIPFilterAllow, []string{})
+		})
+
+		assert.Equal(t, http.StatusNoContent, status("10.1.2.3:1234"))
+		assert.Equal(t, http.StatusNoContent, status("127.0.0.1:1234"))
+		assert.Equal(t, http.StatusForbidden, status("1.2.3.4:1234"))
+	})
+
+	t.Run("case=denies listed sources", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyAdminIPFilterAllow, []string{"10.0.0.0/8"})
+		conf.MustSet(config.ViperKeyAdminIPFilterDeny, []string{"10.1.0.0/16"})
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyAdminIPFilterAllow, []string{})
+			conf.MustSet(config.ViperKeyAdminIPFilterDeny, []string{})
+		})
+
+		assert.Equal(t, http.StatusNoContent, status("10.2.0.1:1234"))
+		assert.Equal(t, http.StatusForbidden, status("10.1.0.1:1234"))
+	})
+}
@@ -0,0 +1,350 @@
+package x
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ContentTypeJSONPatch is the content type of JSON Patch documents (RFC 6902).
+const ContentTypeJSONPatch = "application/json-patch+json"
+
+// JSONPatch is a single operation of a JSON Patch document (RFC 6902).
+//
+// swagger:model jsonPatch
+type JSONPatch struct {
+	// The operation to be performed. One of "add", "remove", "replace", "move", "copy", or "test".
+	//
+	// required: true
+	// example: replace
+	Op string `json:"op"`
+
+	// The path to the target location as a JSON Pointer.
+	//
+	// required: true
+	// example: /traits/name/first
+	Path string `json:"path"`
+
+	// The value to be used by "add", "replace", and "test" operations.
+	//
+	// example: foobar
+	Value json.RawMessage `json:"value,omitempty"`
+
+	// The source location of "move" and "copy" operations as a JSON Pointer.
+	//
+	// example: /traits/name/last
+	From string `json:"from,omitempty"`
+}
+
+var jsonPointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// ApplyJSONPatch applies the JSON Patch (RFC 6902) operations to the target document and returns the
+// result. The operations are applied in order and the target is left untouched if any of them fails.
+func ApplyJSONPatch(target []byte, patch []JSONPatch) (json.RawMessage, error) {
+	var doc interface{}
+	if err := json.Unmarshal(target, &doc); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	for k, op := range patch {
+		var err error
+		if doc, err = applyJSONPatchOperation(doc, op); err != nil {
+			return nil, errors.Wrapf(err, "operation %d (%s %s)", k, op.Op, op.Path)
+		}
+	}
+
+	result, err := json.Marshal(doc)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return result, nil
+}
+
+func applyJSONPatchOperation(doc interface{}, op JSONPatch) (interface{}, error) {
+	path, err := parseJSONPointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if len(op.Value) == 0 {
+			return nil, errors.New("the value is missing")
+		}
+
+		var value interface{}
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		switch op.Op {
+		case "add":
+			return jsonPatchAdd(doc, path, value)
+		case "replace":
+			if _, err := jsonPatchGet(doc, path); err != nil {
+				return nil, err
+			} else if len(path) == 0 {
+				return value, nil
+			}
+			if doc, err = jsonPatchRemove(doc, path); err != nil {
+				return nil, err
+			}
+			return jsonPatchAdd(doc, path, value)
+		default:
+			actual, err := jsonPatchGet(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(actual, value) {
+				return nil, errors.New("the value does not match")
+			}
+			return doc, nil
+		}
+	case "remove":
+		return jsonPatchRemove(doc, path)
+	case "move", "copy":
+		from, err := parseJSONPointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := jsonPatchGet(doc, from)
+		if err != nil {
+			return nil, err
+		}
+
+		if op.Op == "move" {
+			if len(path) > len(from) && reflect.DeepEqual(path[:len(from)], from) {
+				return nil, errors.New("a location can not be moved into one of its children")
+			}
+			if doc, err = jsonPatchRemove(doc, from); err != nil {
+				return nil, err
+			}
+		} else if value, err = jsonPatchClone(value); err != nil {
+			return nil, err
+		}
+
+		return jsonPatchAdd(doc, path, value)
+	default:
+		return nil, errors.Errorf("the operation %q is unknown", op.Op)
+	}
+}
+
+// parseJSONPointer returns the unescaped reference tokens of the JSON Pointer (RFC 6901).
+func parseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, errors.Errorf("the JSON Pointer %q must start with a slash", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for k, t := range tokens {
+		tokens[k] = jsonPointerUnescaper.Replace(t)
+	}
+	return tokens, nil
+}
+
+// jsonPatchArrayIndex returns the index the token refers to. The token "-" and the array's length
+// are only valid if end is set, as they refer to the end of the array.
+func jsonPatchArrayIndex(a []interface{}, token string, end bool) (int, error) {
+	if token == "-" && end {
+		return len(a), nil
+	}
+
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, errors.Errorf("the array index %q is invalid", token)
+	}
+
+	if i > len(a) || (i == len(a) && !end) {
+		return 0, errors.Errorf("the array index %d is out of bounds", i)
+	}
+	return i, nil
+}
+
+func jsonPatchGet(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch d := doc.(type) {
+		case map[string]interface{}:
+			v, ok := d[token]
+			if !ok {
+				return nil, errors.Errorf("the member %q does not exist", token)
+			}
+			doc = v
+		case []interface{}:
+			i, err := jsonPatchArrayIndex(d, token, false)
+			if err != nil {
+				return nil, err
+			}
+			doc = d[i]
+		default:
+			return nil, errors.Errorf("the member %q does not exist", token)
+		}
+	}
+	return doc, nil
+}
+
+// jsonPatchUpdate replaces the parent container of the path with the result of f and returns the
+// updated document.
+func jsonPatchUpdate(doc interface{}, path []string, f func(container interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return f(doc, path[0])
+	}
+
+	child, err := jsonPatchGet(doc, path[:1])
+	if err != nil {
+		return nil, err
+	}
+
+	if child, err = jsonPatchUpdate(child, path[1:], f); err != nil {
+		return nil, err
+	}
+
+	switch d := doc.(type) {
+	case map[string]interface{}:
+		d[path[0]] = child
+	case []interface{}:
+		i, _ := jsonPatchArrayIndex(d, path[0], false)
+		d[i] = child
+	}
+	return doc, nil
+}
+
+func jsonPatchAdd(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return jsonPatchUpdate(doc, path, func(container interface{}, token string) (interface{}, error) {
+		switch c := container.(type) {
+		case map[string]interface{}:
+			c[token] = value
+			return c, nil
+		case []interface{}:
+			i, err := jsonPatchArrayIndex(c, token, true)
+			if err != nil {
+				return nil, err
+			}
+			c = append(c, nil)
+			copy(c[i+1:], c[i:])
+			c[i] = value
+			return c, nil
+		default:
+			return nil, errors.Errorf("the parent of member %q is not an object or array", token)
+		}
+	})
+}
+
+func jsonPatchRemove(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, errors.New("the whole document can not be removed")
+	}
+
+	return jsonPatchUpdate(doc, path, func(container interface{}, token string) (interface{}, error) {
+		switch c := container.(type) {
+		case map[string]interface{}:
+			if _, ok := c[token]; !ok {
+				return nil, errors.Errorf("the member %q does not exist", token)
+			}
+			delete(c, token)
+			return c, nil
+		case []interface{}:
+			i, err := jsonPatchArrayIndex(c, token, false)
+			if err != nil {
+				return nil, err
+			}
+			return append(c[:i], c[i+1:]...), nil
+		default:
+			return nil, errors.Errorf("the member %q does not exist", token)
+		}
+	})
+}
+
+func jsonPatchClone(value interface{}) (interface{}, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var clone interface{}
+	if err := json.Unmarshal(raw, &clone); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return clone, nil
+}
+
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// DiffJSONPatch returns the JSON Patch (RFC 6902) which turns the from document into the to document.
+// Objects are compared member by member, other values including arrays are replaced as a whole. Empty
+// documents are treated as null.
+func DiffJSONPatch(from, to []byte) ([]JSONPatch, error) {
+	var f, t interface{}
+	if len(from) > 0 {
+		if err := json.Unmarshal(from, &f); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	if len(to) > 0 {
+		if err := json.Unmarshal(to, &t); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	return diffJSONPatch(nil, "", f, t)
+}
+
+func diffJSONPatch(patch []JSONPatch, path string, from, to interface{}) ([]JSONPatch, error) {
+	if reflect.DeepEqual(from, to) {
+		return patch, nil
+	}
+
+	f, fok := from.(map[string]interface{})
+	t, tok := to.(map[string]interface{})
+	if !fok || !tok {
+		value, err := json.Marshal(to)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return append(patch, JSONPatch{Op: "replace", Path: path, Value: value}), nil
+	}
+
+	keys := make([]string, 0, len(f)+len(t))
+	for k := range f {
+		keys = append(keys, k)
+	}
+	for k := range t {
+		if _, ok := f[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		child := path + "/" + jsonPointerEscaper.Replace(k)
+		fv, inFrom := f[k]
+		tv, inTo := t[k]
+		switch {
+		case !inTo:
+			patch = append(patch, JSONPatch{Op: "remove", Path: child})
+		case !inFrom:
+			value, err := json.Marshal(tv)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			patch = append(patch, JSONPatch{Op: "add", Path: child, Value: value})
+		default:
+			var err error
+			if patch, err = diffJSONPatch(patch, child, fv, tv); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return patch, nil
+}
@@ -0,0 +1,88 @@
+package x
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyJSONPatch(t *testing.T) {
+	// Test cases from RFC 6902, Appendix A.
+	for k, tc := range []struct{ target, patch, expected string }{
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{`{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{`{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{`{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{`{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`, `{"baz":"qux","foo":["a",2,"c"]}`},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"foo":"bar","child":{"grandchild":{}}}`},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`},
+		{`{"foo":null}`, `[{"op":"test","path":"/foo","value":null}]`, `{"foo":null}`},
+		{`{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10}]`, `{"/":9,"~1":10}`},
+		{`{"foo":{"bar":"baz"}}`, `[{"op":"copy","from":"/foo","path":"/qux"},{"op":"add","path":"/qux/bar","value":"x"}]`, `{"foo":{"bar":"baz"},"qux":{"bar":"x"}}`},
+		{`{"foo":"bar"}`, `[{"op":"replace","path":"","value":{"baz":"qux"}}]`, `{"baz":"qux"}`},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			var patch []JSONPatch
+			require.NoError(t, json.Unmarshal([]byte(tc.patch), &patch))
+
+			actual, err := ApplyJSONPatch([]byte(tc.target), patch)
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.expected, string(actual))
+		})
+	}
+
+	for k, tc := range []struct{ target, patch string }{
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`},
+		{`{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":"10"}]`},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/2","value":"qux"}]`},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/01","value":"qux"}]`},
+		{`{"foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`},
+		{`{"foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"qux"}]`},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz"}]`},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"baz","value":"qux"}]`},
+		{`{"foo":"bar"}`, `[{"op":"invalid","path":"/foo"}]`},
+		{`{"foo":{"bar":"baz"}}`, `[{"op":"move","from":"/foo","path":"/foo/qux"}]`},
+	} {
+		t.Run(fmt.Sprintf("case=error/%d", k), func(t *testing.T) {
+			var patch []JSONPatch
+			require.NoError(t, json.Unmarshal([]byte(tc.patch), &patch))
+
+			_, err := ApplyJSONPatch([]byte(tc.target), patch)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestDiffJSONPatch(t *testing.T) {
+	for k, tc := range []struct{ from, to, expected string }{
+		{`{"a":"b"}`, `{"a":"b"}`, `null`},
+		{`{"a":"b"}`, `{"a":"c"}`, `[{"op":"replace","path":"/a","value":"c"}]`},
+		{`{"a":"b","c":"d"}`, `{"a":"b"}`, `[{"op":"remove","path":"/c"}]`},
+		{`{}`, `{"a":{"b":1}}`, `[{"op":"add","path":"/a","value":{"b":1}}]`},
+		{`{"a":{"b":1,"c":2}}`, `{"a":{"b":3,"c":2}}`, `[{"op":"replace","path":"/a/b","value":3}]`},
+		{`{"a":[1,2]}`, `{"a":[1,3]}`, `[{"op":"replace","path":"/a","value":[1,3]}]`},
+		{`{"a/b":1,"c~d":2}`, `{"c~d":3}`, `[{"op":"remove","path":"/a~1b"},{"op":"replace","path":"/c~0d","value":3}]`},
+		{``, `{"a":1}`, `[{"op":"replace","path":"","value":{"a":1}}]`},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			patch, err := DiffJSONPatch([]byte(tc.from), []byte(tc.to))
+			require.NoError(t, err)
+
+			actual, err := json.Marshal(patch)
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.expected, string(actual))
+
+			if len(tc.from) > 0 {
+				patched, err := ApplyJSONPatch([]byte(tc.from), patch)
+				require.NoError(t, err)
+				assert.JSONEq(t, tc.to, string(patched))
+			}
+		})
+	}
+}
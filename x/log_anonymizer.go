@@ -0,0 +1,194 @@
+package x
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/ory/x/logrusx"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+const (
+	LogAnonymizationOff      = "off"
+	LogAnonymizationHash     = "hash"
+	LogAnonymizationTruncate = "truncate"
+)
+
+var (
+	logAnonymizedIDFields = []string{"identity_id", "session_id"}
+
+	// logAnonymizedIdentifierFields identify a user like IDs do, but they can not be truncated without
+	// revealing them and are therefore redacted in truncate mode.
+	logAnonymizedIdentifierFields = []string{"subject", "email", "identifier"}
+)
+
+// LogConfigFunc returns the configuration of the log hooks or nil if it was not loaded yet, which is
+// the case for entries logged while the service starts.
+type LogConfigFunc func(ctx context.Context) *config.Config
+
+// LogAnonymizer is a logrus hook which anonymizes identity and session IDs, other fields which identify
+// a user such as OpenID Connect subjects and login identifiers, and IP addresses in log entries as
+// configured by `log.anonymization`. Audit logs are derived from the same logger and are anonymized
+// as well.
+type LogAnonymizer struct {
+	c LogConfigFunc
+}
+
+var _ logrus.Hook = new(LogAnonymizer)
+
+func NewLogAnonymizer(c LogConfigFunc) *LogAnonymizer {
+	return &LogAnonymizer{c: c}
+}
+
+// AddLogAnonymizer adds the anonymizer to the logger unless it has one already.
+func AddLogAnonymizer(l *logrusx.Logger, c LogConfigFunc) {
+	for _, hooks := range l.Logrus().Hooks {
+		for _, h := range hooks {
+			if _, ok := h.(*LogAnonymizer); ok {
+				return
+			}
+		}
+	}
+	l.Logrus().AddHook(NewLogAnonymizer(c))
+}
+
+func (a *LogAnonymizer) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (a *LogAnonymizer) Fire(e *logrus.Entry) error {
+	ctx := e.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	c := a.c(ctx)
+	if c == nil {
+		return nil
+	}
+
+	mode := c.LogAnonymization()
+	if mode != LogAnonymizationHash && mode != LogAnonymizationTruncate {
+		return nil
+	}
+
+	var secret []byte
+	if mode == LogAnonymizationHash {
+		secret = c.SecretsDefault()[0]
+	}
+
+	for _, key := range logAnonymizedIDFields {
+		if v, ok := e.Data[key]; ok {
+			e.Data[key] = AnonymizeID(mode, secret, fmt.Sprintf("%s", v))
+		}
+	}
+
+	for _, key := range logAnonymizedIdentifierFields {
+		if v, ok := e.Data[key]; ok {
+			if mode == LogAnonymizationHash {
+				e.Data[key] = anonymizationHash(secret, fmt.Sprintf("%s", v))
+			} else {
+				e.Data[key] = Redacted
+			}
+		}
+	}
+
+	// The request map is shared by all entries derived from the same request logger
+	// and must therefore be copied before it is modified.
+	if req, ok := e.Data["http_request"].(map[string]interface{}); ok {
+		anonymized := make(map[string]interface{}, len(req))
+		for k, v := range req {
+			anonymized[k] = v
+		}
+
+		if remote, ok := req["remote"].(string); ok {
+			anonymized["remote"] = AnonymizeIP(mode, secret, remote)
+		}
+
+		if headers, ok := req["headers"].(map[string]interface{}); ok {
+			if forwarded, ok := headers["x-forwarded-for"].(string); ok {
+				h := make(map[string]interface{}, len(headers))
+				for k, v := range headers {
+					h[k] = v
+				}
+
+				ips := strings.Split(forwarded, ",")
+				for k, ip := range ips {
+					ips[k] = AnonymizeIP(mode, secret, strings.TrimSpace(ip))
+				}
+				h["x-forwarded-for"] = strings.Join(ips, ", ")
+				anonymized["headers"] = h
+			}
+		}
+
+		e.Data["http_request"] = anonymized
+	}
+
+	return nil
+}
+
+// AnonymizeID hashes the ID with the secret if mode is `hash` and returns its first eight
+// characters if mode is `truncate`.
+func AnonymizeID(mode string, secret []byte, id string) string {
+	switch mode {
+	case LogAnonymizationHash:
+		return anonymizationHash(secret, id)
+	case LogAnonymizationTruncate:
+		if len(id) > 8 {
+			return id[:8]
+		}
+	}
+	return id
+}
+
+// AnonymizeConfiguredID anonymizes the ID as configured by `log.anonymization`, so that IDs which
+// leave the service in other ways than logs can be correlated with the logs.
+func AnonymizeConfiguredID(c *config.Config, id string) string {
+	mode := c.LogAnonymization()
+	if mode != LogAnonymizationHash {
+		return AnonymizeID(mode, nil, id)
+	}
+	return AnonymizeID(mode, c.SecretsDefault()[0], id)
+}
+
+// AnonymizeIP hashes the IP address with the secret if mode is `hash`. If mode is `truncate`,
+// IPv4 addresses are reduced to their /24 and IPv6 addresses to their /48 network. A port is
+// removed in both cases.
+func AnonymizeIP(mode string, secret []byte, addr string) string {
+	if mode != LogAnonymizationHash && mode != LogAnonymizationTruncate {
+		return addr
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+
+	if mode == LogAnonymizationHash {
+		return anonymizationHash(secret, host)
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return ""
+	}
+
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return ip.Mask(net.CIDRMask(48, 128)).String()
+}
+
+func anonymizationHash(secret []byte, value string) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))[:16]
+}
@@ -0,0 +1,90 @@
+package x_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/logrusx"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/x"
+)
+
+func TestAnonymizeIP(t *testing.T) {
+	for k, tc := range []struct {
+		mode, addr, expected string
+	}{
+		{mode: "off", addr: "192.168.1.123:4433", expected: "192.168.1.123:4433"},
+		{mode: "truncate", addr: "192.168.1.123:4433", expected: "192.168.1.0"},
+		{mode: "truncate", addr: "192.168.1.123", expected: "192.168.1.0"},
+		{mode: "truncate", addr: "[2001:db8:85a3:8d3:1319:8a2e:370:7348]:4433", expected: "2001:db8:85a3::"},
+		{mode: "truncate", addr: "not-an-ip", expected: ""},
+	} {
+		assert.Equal(t, tc.expected, x.AnonymizeIP(tc.mode, nil, tc.addr), "%d", k)
+	}
+
+	hashed := x.AnonymizeIP("hash", []byte("secret"), "192.168.1.123:4433")
+	assert.Len(t, hashed, 16)
+	assert.Equal(t, hashed, x.AnonymizeIP("hash", []byte("secret"), "192.168.1.123:1234"), "the port must not change the hash")
+	assert.NotEqual(t, hashed, x.AnonymizeIP("hash", []byte("other-secret"), "192.168.1.123"))
+}
+
+func TestLogAnonymizer(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeySecretsDefault, []string{"a-very-secret-log-secret"})
+	hook := test.NewLocal(reg.Logger().Logrus())
+
+	id := x.NewUUID().String()
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "192.168.1.123:4433"
+	r.Header.Set("X-Forwarded-For", "10.1.2.3, 10.4.5.6")
+	l := reg.Audit().WithRequest(r).WithField("identity_id", id).WithField("identifier", "foo@ory.sh")
+
+	for _, tc := range []struct {
+		mode, identityID, identifier, remote, forwarded string
+	}{
+		{mode: "off", identityID: id, identifier: "foo@ory.sh", remote: "192.168.1.123:4433", forwarded: "10.1.2.3, 10.4.5.6"},
+		{mode: "truncate", identityID: id[:8], identifier: x.Redacted, remote: "192.168.1.0", forwarded: "10.1.2.0, 10.4.5.0"},
+		{mode: "hash",
+			identityID: x.AnonymizeID("hash", []byte("a-very-secret-log-secret"), id),
+			identifier: x.AnonymizeID("hash", []byte("a-very-secret-log-secret"), "foo@ory.sh"),
+			remote:     x.AnonymizeIP("hash", []byte("a-very-secret-log-secret"), "192.168.1.123"),
+			forwarded:  x.AnonymizeIP("hash", []byte("a-very-secret-log-secret"), "10.1.2.3") + ", " + x.AnonymizeIP("hash", []byte("a-very-secret-log-secret"), "10.4.5.6")},
+	} {
+		t.Run("mode="+tc.mode, func(t *testing.T) {
+			conf.MustSet(config.ViperKeyLogAnonymization, tc.mode)
+			hook.Reset()
+
+			// Logging twice ensures that values shared between entries are not anonymized twice.
+			l.Info("first")
+			l.Info("second")
+			require.Len(t, hook.AllEntries(), 2)
+
+			for _, e := range hook.AllEntries() {
+				assert.Equal(t, tc.identityID, e.Data["identity_id"])
+				assert.Equal(t, tc.identifier, e.Data["identifier"])
+				req := e.Data["http_request"].(map[string]interface{})
+				assert.Equal(t, tc.remote, req["remote"])
+				assert.Equal(t, tc.forwarded, req["headers"].(map[string]interface{})["x-forwarded-for"])
+			}
+		})
+	}
+}
+
+func TestLogAnonymizerWithoutConfig(t *testing.T) {
+	l := logrusx.New("", "")
+	hook := test.NewLocal(l.Logrus())
+	x.AddLogAnonymizer(l, func(context.Context) *config.Config { return nil })
+	x.AddLogRedactor(l, func(context.Context) *config.Config { return nil })
+
+	id := x.NewUUID().String()
+	l.WithField("identity_id", id).Info("before the configuration was loaded")
+	require.Len(t, hook.AllEntries(), 1)
+	assert.Equal(t, id, hook.LastEntry().Data["identity_id"])
+}
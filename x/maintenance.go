@@ -0,0 +1,88 @@
+package x
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+// ErrMaintenanceMode is returned for requests which write data while `maintenance.enabled` is set.
+var ErrMaintenanceMode = herodot.DefaultError{
+	StatusField: http.StatusText(http.StatusServiceUnavailable),
+	ErrorField:  "The service is undergoing maintenance",
+	CodeField:   http.StatusServiceUnavailable,
+}
+
+// MaintenanceMode returns a middleware which answers requests that write data with 503 Service Unavailable
+// and a `Retry-After` header while `maintenance.enabled` is set.
+//
+// GET and HEAD requests only read data, except those to self-service endpoints which initialize flows,
+// complete links, or log out. Fetching flows and errors as well as CORS preflight requests continue to work.
+//
+// Whether the maintenance mode is enabled is logged when the middleware is created for the given server and
+// whenever the configuration value changes, for example because the configuration was reloaded.
+func MaintenanceMode(ctx context.Context, d interface {
+	config.Provider
+	LoggingProvider
+	WriterProvider
+}, server string) func(http.ResponseWriter, *http.Request, http.HandlerFunc) {
+	var state int32
+	logMaintenanceMode(d, server, d.Config(ctx).MaintenanceModeEnabled(), &state)
+
+	return func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		c := d.Config(r.Context())
+		logMaintenanceMode(d, server, c.MaintenanceModeEnabled(), &state)
+		if !c.MaintenanceModeEnabled() || !isWriteRequest(r) {
+			next(w, r)
+			return
+		}
+
+		retryAfter := int64(c.MaintenanceRetryAfter().Seconds())
+		w.Header().Set("Retry-After", strconv.FormatInt(retryAfter, 10))
+		d.Writer().WriteError(w, r, errors.WithStack(ErrMaintenanceMode.
+			WithReasonf("This request can not be processed right now because the service is undergoing maintenance. Please try again in %d seconds.", retryAfter)))
+	}
+}
+
+// logMaintenanceMode logs the maintenance mode if it differs from the state the middleware saw last. The state
+// is swapped atomically so that concurrent requests log each change only once.
+func logMaintenanceMode(d LoggingProvider, server string, enabled bool, state *int32) {
+	var old, new int32 = 1, 0
+	if enabled {
+		old, new = 0, 1
+	}
+	if !atomic.CompareAndSwapInt32(state, old, new) {
+		return
+	}
+
+	l := d.Logger().WithField("server", server)
+	if enabled {
+		l.Warnf("Maintenance mode is enabled, requests which write data are answered with 503 Service Unavailable until %s is disabled.", config.ViperKeyMaintenanceEnabled)
+	} else {
+		l.Info("Maintenance mode is disabled, requests which write data are processed again.")
+	}
+}
+
+func isWriteRequest(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodOptions:
+		return false
+	case http.MethodGet, http.MethodHead:
+	default:
+		return true
+	}
+
+	if !strings.HasPrefix(r.URL.Path, "/self-service/") {
+		return false
+	}
+
+	return !strings.HasSuffix(r.URL.Path, "/flows") && r.URL.Path != "/self-service/errors"
+}
@@ -0,0 +1,103 @@
+package x_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/x"
+)
+
+func TestMaintenanceMode(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyMaintenanceRetryAfter, "2m")
+	mw := x.MaintenanceMode(context.Background(), reg, "public")
+
+	var send = func(t *testing.T, method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mw(w, httptest.NewRequest(method, path, nil), func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+		return w
+	}
+
+	for _, tc := range []struct {
+		method, path string
+		write        bool
+	}{
+		{method: "GET", path: "/sessions/whoami"},
+		{method: "GET", path: "/self-service/login/flows"},
+		{method: "GET", path: "/self-service/errors"},
+		{method: "GET", path: "/identities"},
+		{method: "OPTIONS", path: "/self-service/login"},
+		{method: "GET", path: "/self-service/registration/browser", write: true},
+		{method: "GET", path: "/self-service/recovery/link", write: true},
+		{method: "GET", path: "/self-service/browser/flows/logout", write: true},
+		{method: "POST", path: "/self-service/login", write: true},
+		{method: "POST", path: "/self-service/settings", write: true},
+		{method: "POST", path: "/identities", write: true},
+		{method: "DELETE", path: "/identities/1234", write: true},
+	} {
+		t.Run("case="+tc.method+" "+tc.path, func(t *testing.T) {
+			conf.MustSet(config.ViperKeyMaintenanceEnabled, false)
+			assert.Equal(t, http.StatusOK, send(t, tc.method, tc.path).Code)
+
+			conf.MustSet(config.ViperKeyMaintenanceEnabled, true)
+			t.Cleanup(func() {
+				conf.MustSet(config.ViperKeyMaintenanceEnabled, false)
+			})
+
+			res := send(t, tc.method, tc.path)
+			if !tc.write {
+				assert.Equal(t, http.StatusOK, res.Code)
+				assert.Empty(t, res.Header().Get("Retry-After"))
+				return
+			}
+
+			assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+			assert.Equal(t, "120", res.Header().Get("Retry-After"))
+			assert.Contains(t, res.Body.String(), "undergoing maintenance")
+		})
+	}
+
+	t.Run("case=logs changes of the maintenance mode", func(t *testing.T) {
+		hook := test.NewLocal(reg.Logger().Logrus())
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyMaintenanceEnabled, false)
+		})
+
+		conf.MustSet(config.ViperKeyMaintenanceEnabled, true)
+		mw := x.MaintenanceMode(context.Background(), reg, "admin")
+		require.Len(t, hook.AllEntries(), 1)
+		assert.Equal(t, logrus.WarnLevel, hook.LastEntry().Level)
+		assert.Equal(t, "admin", hook.LastEntry().Data["server"])
+		assert.Contains(t, hook.LastEntry().Message, "Maintenance mode is enabled")
+
+		send := func() {
+			mw(httptest.NewRecorder(), httptest.NewRequest("GET", "/identities", nil), func(w http.ResponseWriter, r *http.Request) {})
+		}
+
+		send()
+		require.Len(t, hook.AllEntries(), 1, "the maintenance mode did not change")
+
+		conf.MustSet(config.ViperKeyMaintenanceEnabled, false)
+		send()
+		send()
+		require.Len(t, hook.AllEntries(), 2)
+		assert.Equal(t, logrus.InfoLevel, hook.LastEntry().Level)
+		assert.Contains(t, hook.LastEntry().Message, "Maintenance mode is disabled")
+
+		conf.MustSet(config.ViperKeyMaintenanceEnabled, true)
+		send()
+		require.Len(t, hook.AllEntries(), 3)
+		assert.Contains(t, hook.LastEntry().Message, "Maintenance mode is enabled")
+	})
+}
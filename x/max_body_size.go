@@ -0,0 +1,71 @@
+package x
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+// ErrRequestEntityTooLarge is returned if the request body exceeds `serve.public.max_request_body_size`.
+var ErrRequestEntityTooLarge = herodot.DefaultError{
+	StatusField: http.StatusText(http.StatusRequestEntityTooLarge),
+	ErrorField:  "The request body is too large",
+	CodeField:   http.StatusRequestEntityTooLarge,
+}
+
+// MaxRequestBodySize returns a middleware which answers requests whose body is larger than
+// `serve.public.max_request_body_size` with 413 Request Entity Too Large before the handler reads it.
+// Bodies of unknown length are read up to the limit to find out their size.
+func MaxRequestBodySize(d interface {
+	config.Provider
+	WriterProvider
+}) func(http.ResponseWriter, *http.Request, http.HandlerFunc) {
+	return func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		limit := d.Config(r.Context()).PublicMaxRequestBodySize()
+		if limit == 0 || r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0 {
+			next(w, r)
+			return
+		}
+
+		tooLarge := func() {
+			d.Writer().WriteError(w, r, errors.WithStack(ErrRequestEntityTooLarge.
+				WithReasonf("The request body must not be larger than %d bytes.", limit)))
+		}
+
+		if r.ContentLength > limit {
+			tooLarge()
+			return
+		}
+
+		if r.ContentLength < 0 {
+			body, err := ioutil.ReadAll(io.LimitReader(r.Body, limit+1))
+			if err != nil {
+				d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.
+					WithReasonf("Unable to read the request body: %s", err)))
+				return
+			}
+			_ = r.Body.Close()
+
+			if int64(len(body)) > limit {
+				tooLarge()
+				return
+			}
+
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			r.ContentLength = int64(len(body))
+			next(w, r)
+			return
+		}
+
+		// Guard against clients sending more than they announced.
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		next(w, r)
+	}
+}
@@ -0,0 +1,69 @@
+package x_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/x"
+)
+
+func TestMaxRequestBodySize(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	conf.MustSet(config.ViperKeyPublicMaxRequestBodySize, 10)
+	limit := x.MaxRequestBodySize(reg)
+
+	var send = func(t *testing.T, body string, contentLength int64) (int, string) {
+		r := httptest.NewRequest("POST", "/self-service/login", strings.NewReader(body))
+		r.ContentLength = contentLength
+		w := httptest.NewRecorder()
+		limit(w, r, func(w http.ResponseWriter, r *http.Request) {
+			received, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write(received)
+		})
+		return w.Code, w.Body.String()
+	}
+
+	t.Run("case=passes bodies within the limit", func(t *testing.T) {
+		code, body := send(t, "0123456789", 10)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "0123456789", body)
+	})
+
+	t.Run("case=rejects bodies exceeding the announced limit", func(t *testing.T) {
+		code, body := send(t, "0123456789a", 11)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, code)
+		assert.Contains(t, body, "must not be larger than 10 bytes")
+	})
+
+	t.Run("case=passes bodies of unknown length within the limit", func(t *testing.T) {
+		code, body := send(t, "0123456789", -1)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "0123456789", body)
+	})
+
+	t.Run("case=rejects bodies of unknown length exceeding the limit", func(t *testing.T) {
+		code, _ := send(t, "0123456789a", -1)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, code)
+	})
+
+	t.Run("case=does not limit the body if disabled", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyPublicMaxRequestBodySize, 0)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyPublicMaxRequestBodySize, 10)
+		})
+
+		code, body := send(t, "0123456789a", -1)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "0123456789a", body)
+	})
+}
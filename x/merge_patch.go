@@ -0,0 +1,50 @@
+package x
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// ContentTypeMergePatch is the content type of JSON Merge Patch documents (RFC 7386).
+const ContentTypeMergePatch = "application/merge-patch+json"
+
+// MergePatch applies the JSON Merge Patch (RFC 7386) patch to the target document and returns the result.
+func MergePatch(target, patch []byte) (json.RawMessage, error) {
+	var t, p interface{}
+	if len(target) > 0 {
+		if err := json.Unmarshal(target, &t); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	merged, err := json.Marshal(mergePatch(t, p))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return merged, nil
+}
+
+func mergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = mergePatch(t[k], v)
+	}
+	return t
+}
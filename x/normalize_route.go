@@ -0,0 +1,64 @@
+package x
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/urfave/negroni"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+// NormalizeRoutes returns a middleware which rewrites requests to one of the given routes which have a trailing
+// slash or a different case to the route itself, depending on `serve.public.route_normalization`. Requests to
+// other paths are passed through unchanged.
+func NormalizeRoutes(d config.Provider, routes []string) negroni.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		c := d.Config(r.Context())
+		trailingSlash, caseInsensitive := c.PublicRouteTrailingSlash(), c.PublicRouteCaseInsensitive()
+		if !trailingSlash && !caseInsensitive {
+			next(rw, r)
+			return
+		}
+
+		path := r.URL.Path
+		if trailingSlash && len(path) > 1 {
+			path = strings.TrimSuffix(path, "/")
+		}
+
+		for _, route := range routes {
+			if normalized, ok := matchRoute(route, path, caseInsensitive); ok {
+				r.URL.Path = normalized
+				r.URL.RawPath = ""
+				break
+			}
+		}
+
+		next(rw, r)
+	}
+}
+
+// matchRoute matches path against an httprouter route. Static segments are replaced by the route's
+// segments while parameters (e.g. `:provider`) are taken from the path as they are.
+func matchRoute(route, path string, caseInsensitive bool) (string, bool) {
+	routeSegments, pathSegments := strings.Split(route, "/"), strings.Split(path, "/")
+	if len(routeSegments) != len(pathSegments) {
+		return "", false
+	}
+
+	for k, segment := range routeSegments {
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			if len(pathSegments[k]) == 0 {
+				return "", false
+			}
+		case segment == pathSegments[k]:
+		case caseInsensitive && strings.EqualFold(segment, pathSegments[k]):
+			pathSegments[k] = segment
+		default:
+			return "", false
+		}
+	}
+
+	return strings.Join(pathSegments, "/"), true
+}
@@ -0,0 +1,51 @@
+package x_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/x"
+)
+
+func TestNormalizeRoutes(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	normalize := x.NormalizeRoutes(reg, []string{
+		"/self-service/login/browser",
+		"/self-service/methods/oidc/callback/:provider",
+	})
+
+	for k, tc := range []struct {
+		trailingSlash, caseInsensitive bool
+		path, expected                 string
+	}{
+		{path: "/self-service/login/browser/", expected: "/self-service/login/browser/"},
+		{path: "/Self-Service/Login/Browser", expected: "/Self-Service/Login/Browser"},
+		{trailingSlash: true, path: "/self-service/login/browser/", expected: "/self-service/login/browser"},
+		{trailingSlash: true, path: "/Self-Service/Login/Browser/", expected: "/Self-Service/Login/Browser/"},
+		{trailingSlash: true, path: "/other/", expected: "/other/"},
+		{trailingSlash: true, path: "/", expected: "/"},
+		{caseInsensitive: true, path: "/Self-Service/Login/Browser", expected: "/self-service/login/browser"},
+		{caseInsensitive: true, path: "/Self-Service/Login/Browser/", expected: "/Self-Service/Login/Browser/"},
+		{caseInsensitive: true, path: "/Self-Service/Methods/OIDC/Callback/GitHub", expected: "/self-service/methods/oidc/callback/GitHub"},
+		{caseInsensitive: true, path: "/Self-Service/Methods/OIDC/Callback/", expected: "/Self-Service/Methods/OIDC/Callback/"},
+		{trailingSlash: true, caseInsensitive: true, path: "/SELF-SERVICE/login/browser/", expected: "/self-service/login/browser"},
+		{trailingSlash: true, caseInsensitive: true, path: "/self-service/login/api", expected: "/self-service/login/api"},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			conf.MustSet(config.ViperKeyPublicRouteTrailingSlash, tc.trailingSlash)
+			conf.MustSet(config.ViperKeyPublicRouteCaseInsensitive, tc.caseInsensitive)
+
+			var actual string
+			normalize(httptest.NewRecorder(), httptest.NewRequest("GET", tc.path, nil), func(_ http.ResponseWriter, r *http.Request) {
+				actual = r.URL.Path
+			})
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
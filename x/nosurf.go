@@ -66,6 +66,9 @@ func (f *FakeCSRFHandler) ExemptPath(s string) {
 func (f *FakeCSRFHandler) IgnorePath(s string) {
 }
 
+func (f *FakeCSRFHandler) ExemptGlob(s string) {
+}
+
 func (f *FakeCSRFHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
@@ -82,6 +85,7 @@ type CSRFHandler interface {
 	RegenerateToken(w http.ResponseWriter, r *http.Request) string
 	ExemptPath(string)
 	IgnorePath(string)
+	ExemptGlob(string)
 }
 
 func NosurfBaseCookieHandler(reg interface {
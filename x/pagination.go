@@ -56,6 +56,9 @@ func header(u *url.URL, rel string, limit, page int64) string {
 	return fmt.Sprintf("<%s>; rel=\"%s\"", u.String(), rel)
 }
 
+// PaginationHeader sets the RFC 5988 Link header for a paginated list. The `first` link is always
+// included, `prev` is included unless on the first page, `next` is omitted on the last page, and
+// `last` is included if the list is not empty.
 func PaginationHeader(w http.ResponseWriter, u *url.URL, total int64, page, itemsPerPage int) {
 	if itemsPerPage <= 0 {
 		itemsPerPage = 1
@@ -72,42 +75,76 @@ func PaginationHeader(w http.ResponseWriter, u *url.URL, total int64, page, item
 	} else {
 		lastOffset = (total / itemsPerPage64) * itemsPerPage64
 	}
+	if lastOffset < 0 {
+		lastOffset = 0
+	}
 
-	// Check for last page
-	if offset >= lastOffset {
-		if total == 0 {
-			w.Header().Set("Link", strings.Join([]string{
-				header(u, "first", itemsPerPage64, 0),
-				header(u, "next", itemsPerPage64, ((offset/itemsPerPage64)+1)*itemsPerPage64),
-				header(u, "prev", itemsPerPage64, ((offset/itemsPerPage64)-1)*itemsPerPage64),
-			}, ","))
-			return
-		}
+	links := []string{header(u, "first", itemsPerPage64, 0)}
+	if offset < lastOffset {
+		links = append(links, header(u, "next", itemsPerPage64, offset+itemsPerPage64))
+	}
+	if offset > 0 {
+		links = append(links, header(u, "prev", itemsPerPage64, offset-itemsPerPage64))
+	}
+	if total > 0 {
+		links = append(links, header(u, "last", itemsPerPage64, lastOffset))
+	}
+
+	w.Header().Set("Link", strings.Join(links, ","))
+}
+
+// IsTokenPagination returns true if the request asks for token based pagination.
+func IsTokenPagination(r *http.Request) bool {
+	q := r.URL.Query()
+	_, hasToken := q["page_token"]
+	_, hasSize := q["page_size"]
+	return hasToken || hasSize
+}
+
+// ParseTokenPagination parses page_token and page_size from *http.Request with given limits and defaults.
+func ParseTokenPagination(r *http.Request) (pageToken string, pageSize int) {
+	pageToken = r.URL.Query().Get("page_token")
 
-		if total < itemsPerPage64 {
-			w.Header().Set("link", header(u, "first", total, 0))
-			return
+	pageSize = paginationDefaultItems
+	if sizeParam := r.URL.Query().Get("page_size"); sizeParam != "" {
+		if size, err := strconv.ParseInt(sizeParam, 10, 0); err == nil {
+			pageSize = int(size)
 		}
+	}
+
+	if pageSize > paginationMaxItems {
+		pageSize = paginationMaxItems
+	}
+
+	if pageSize < 1 {
+		pageSize = 1
+	}
 
-		w.Header().Set("Link", strings.Join([]string{
-			header(u, "first", itemsPerPage64, 0),
-			header(u, "prev", itemsPerPage64, lastOffset-itemsPerPage64),
-		}, ","))
-		return
+	return
+}
+
+func tokenHeader(u *url.URL, rel string, pageSize int, pageToken string) string {
+	l := *u
+	q := l.Query()
+	q.Set("page_size", fmt.Sprintf("%d", pageSize))
+	q.Del("page_token")
+	if pageToken != "" {
+		q.Set("page_token", pageToken)
 	}
+	l.RawQuery = q.Encode()
+	return fmt.Sprintf("<%s>; rel=\"%s\"", l.String(), rel)
+}
 
-	if offset < itemsPerPage64 {
-		w.Header().Set("Link", strings.Join([]string{
-			header(u, "next", itemsPerPage64, itemsPerPage64),
-			header(u, "last", itemsPerPage64, lastOffset),
-		}, ","))
-		return
+// TokenPaginationHeader sets the RFC 5988 Link header for a token paginated list. The `first` link is
+// always included, `next` and `prev` are included if their page token is not empty.
+func TokenPaginationHeader(w http.ResponseWriter, u *url.URL, pageSize int, next, prev string) {
+	links := []string{tokenHeader(u, "first", pageSize, "")}
+	if next != "" {
+		links = append(links, tokenHeader(u, "next", pageSize, next))
+	}
+	if prev != "" {
+		links = append(links, tokenHeader(u, "prev", pageSize, prev))
 	}
 
-	w.Header().Set("Link", strings.Join([]string{
-		header(u, "first", itemsPerPage64, 0),
-		header(u, "next", itemsPerPage64, ((offset/itemsPerPage64)+1)*itemsPerPage64),
-		header(u, "prev", itemsPerPage64, ((offset/itemsPerPage64)-1)*itemsPerPage64),
-		header(u, "last", itemsPerPage64, lastOffset),
-	}, ","))
+	w.Header().Set("Link", strings.Join(links, ","))
 }
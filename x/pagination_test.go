@@ -35,23 +35,25 @@ import (
 func TestPaginationHeader(t *testing.T) {
 	u := urlx.ParseOrPanic("http://example.com")
 
-	t.Run("Create previous and first but not next or last if at the end", func(t *testing.T) {
+	t.Run("Create first, previous, and last but not next if at the end", func(t *testing.T) {
 		r := httptest.NewRecorder()
 		PaginationHeader(r, u, 120, 2, 50)
 
 		expect := strings.Join([]string{
 			"<http://example.com?page=0&per_page=50>; rel=\"first\"",
 			"<http://example.com?page=1&per_page=50>; rel=\"prev\"",
+			"<http://example.com?page=2&per_page=50>; rel=\"last\"",
 		}, ",")
 
 		assert.EqualValues(t, expect, r.Result().Header.Get("Link"))
 	})
 
-	t.Run("Create next and last, but not previous or first if at the beginning", func(t *testing.T) {
+	t.Run("Create first, next, and last, but not previous if at the beginning", func(t *testing.T) {
 		r := httptest.NewRecorder()
 		PaginationHeader(r, u, 120, 0, 50)
 
 		expect := strings.Join([]string{
+			"<http://example.com?page=0&per_page=50>; rel=\"first\"",
 			"<http://example.com?page=1&per_page=50>; rel=\"next\"",
 			"<http://example.com?page=2&per_page=50>; rel=\"last\"",
 		}, ",")
@@ -87,24 +89,26 @@ func TestPaginationHeader(t *testing.T) {
 		assert.EqualValues(t, expect, r.Result().Header.Get("Link"))
 	})
 
-	t.Run("Create previous, next, first, but not last if in the middle and no total was provided", func(t *testing.T) {
+	t.Run("Create first and previous but not next or last if the list is empty", func(t *testing.T) {
 		r := httptest.NewRecorder()
 		PaginationHeader(r, u, 0, 3, 50)
 
 		expect := strings.Join([]string{
 			"<http://example.com?page=0&per_page=50>; rel=\"first\"",
-			"<http://example.com?page=4&per_page=50>; rel=\"next\"",
 			"<http://example.com?page=2&per_page=50>; rel=\"prev\"",
 		}, ",")
 
 		assert.EqualValues(t, expect, r.Result().Header.Get("Link"))
 	})
 
-	t.Run("Create only first if the limits provided exceeds the number of clients found", func(t *testing.T) {
+	t.Run("Create only first and last if the limit exceeds the number of items found", func(t *testing.T) {
 		r := httptest.NewRecorder()
 		PaginationHeader(r, u, 5, 0, 50)
 
-		expect := "<http://example.com?page=0&per_page=5>; rel=\"first\""
+		expect := strings.Join([]string{
+			"<http://example.com?page=0&per_page=50>; rel=\"first\"",
+			"<http://example.com?page=0&per_page=50>; rel=\"last\"",
+		}, ",")
 
 		assert.EqualValues(t, expect, r.Result().Header.Get("Link"))
 	})
@@ -131,3 +135,53 @@ func TestParsePagination(t *testing.T) {
 		})
 	}
 }
+
+func TestTokenPaginationHeader(t *testing.T) {
+	u := urlx.ParseOrPanic("http://example.com")
+
+	t.Run("Create first, next, and previous", func(t *testing.T) {
+		r := httptest.NewRecorder()
+		TokenPaginationHeader(r, u, 50, "next-token", "prev-token")
+
+		expect := strings.Join([]string{
+			"<http://example.com?page_size=50>; rel=\"first\"",
+			"<http://example.com?page_size=50&page_token=next-token>; rel=\"next\"",
+			"<http://example.com?page_size=50&page_token=prev-token>; rel=\"prev\"",
+		}, ",")
+
+		assert.EqualValues(t, expect, r.Result().Header.Get("Link"))
+	})
+
+	t.Run("Create only first if there are no other pages", func(t *testing.T) {
+		r := httptest.NewRecorder()
+		TokenPaginationHeader(r, u, 50, "", "")
+
+		assert.EqualValues(t, "<http://example.com?page_size=50>; rel=\"first\"", r.Result().Header.Get("Link"))
+	})
+}
+
+func TestParseTokenPagination(t *testing.T) {
+	for _, tc := range []struct {
+		d                 string
+		url               string
+		expectedPageSize  int
+		expectedPageToken string
+		expectedToken     bool
+	}{
+		{"normal", "http://localhost/foo?page_size=10&page_token=abc", 10, "abc", true},
+		{"only size", "http://localhost/foo?page_size=10", 10, "", true},
+		{"offset", "http://localhost/foo?per_page=10&page=1", paginationDefaultItems, "", false},
+		{"limits", "http://localhost/foo?page_size=2000", paginationMaxItems, "", true},
+		{"negatives", "http://localhost/foo?page_size=-1", 1, "", true},
+		{"invalid_params", "http://localhost/foo?page_size=a", paginationDefaultItems, "", true},
+	} {
+		t.Run(fmt.Sprintf("case=%s", tc.d), func(t *testing.T) {
+			u, _ := url.Parse(tc.url)
+			r := &http.Request{URL: u}
+			token, size := ParseTokenPagination(r)
+			assert.EqualValues(t, tc.expectedPageSize, size, "page_size")
+			assert.EqualValues(t, tc.expectedPageToken, token, "page_token")
+			assert.EqualValues(t, tc.expectedToken, IsTokenPagination(r))
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package x
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+type (
+	// RateLimiter counts the requests made for a key, for example a client IP, in fixed windows.
+	RateLimiter struct {
+		sync.Mutex
+		windows   map[string]*rateLimitWindow
+		lastPurge time.Time
+	}
+	rateLimitWindow struct {
+		count     int
+		expiresAt time.Time
+	}
+
+	// RateLimit is the state of a key's current window.
+	RateLimit struct {
+		// Limit is the number of requests allowed per window.
+		Limit int
+
+		// Remaining is the number of requests which are still allowed in the current window.
+		Remaining int
+
+		// Reset is the time at which the current window ends.
+		Reset time.Time
+	}
+)
+
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{windows: make(map[string]*rateLimitWindow)}
+}
+
+// Allow records a request for key and returns the state of the key's current window. It returns false
+// if more than limit requests were made for key in the current period.
+func (l *RateLimiter) Allow(key string, limit int, period time.Duration) (*RateLimit, bool) {
+	l.Lock()
+	defer l.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastPurge) > period {
+		for k, w := range l.windows {
+			if now.After(w.expiresAt) {
+				delete(l.windows, k)
+			}
+		}
+		l.lastPurge = now
+	}
+
+	w, ok := l.windows[key]
+	if !ok || now.After(w.expiresAt) {
+		w = &rateLimitWindow{expiresAt: now.Add(period)}
+		l.windows[key] = w
+	}
+
+	w.count++
+	remaining := limit - w.count
+	if remaining < 0 {
+		remaining = 0
+	}
+	return &RateLimit{Limit: limit, Remaining: remaining, Reset: w.expiresAt}, w.count <= limit
+}
+
+// resetAfter returns the number of seconds until the window ends, but at least one.
+func (rl *RateLimit) resetAfter() int64 {
+	seconds := int64(math.Ceil(time.Until(rl.Reset).Seconds()))
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+// WriteRateLimitExceeded answers a request which exceeded a rate limit with 429 Too Many Requests. All rate
+// limiters use it so that clients can rely on the same response: a `Retry-After` header, the
+// `RateLimit-Limit`, `RateLimit-Remaining`, and `RateLimit-Reset` headers of the draft IETF RateLimit header
+// fields unless `rate_limit.headers` is disabled, and an error whose details contain the same values.
+func WriteRateLimitExceeded(w http.ResponseWriter, r *http.Request, d interface {
+	config.Provider
+	WriterProvider
+}, rl *RateLimit, reason string) {
+	resetAfter := rl.resetAfter()
+	w.Header().Set("Retry-After", strconv.FormatInt(resetAfter, 10))
+	if d.Config(r.Context()).RateLimitHeadersEnabled() {
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(rl.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(rl.Remaining))
+		w.Header().Set("RateLimit-Reset", strconv.FormatInt(resetAfter, 10))
+	}
+
+	d.Writer().WriteError(w, r, errors.WithStack(ErrTooManyRequests.
+		WithReason(reason).
+		WithDetail("limit", rl.Limit).
+		WithDetail("remaining", rl.Remaining).
+		WithDetail("reset", resetAfter)))
+}
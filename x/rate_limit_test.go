@@ -0,0 +1,76 @@
+package x_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/x"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := x.NewRateLimiter()
+
+	rl, ok := l.Allow("foo", 2, time.Minute)
+	require.True(t, ok)
+	assert.Equal(t, 2, rl.Limit)
+	assert.Equal(t, 1, rl.Remaining)
+
+	rl, ok = l.Allow("foo", 2, time.Minute)
+	require.True(t, ok)
+	assert.Equal(t, 0, rl.Remaining)
+
+	rl, ok = l.Allow("foo", 2, time.Minute)
+	require.False(t, ok)
+	assert.Equal(t, 0, rl.Remaining)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), rl.Reset, time.Second)
+
+	_, ok = l.Allow("bar", 2, time.Minute)
+	assert.True(t, ok, "keys are limited independently")
+}
+
+func TestWriteRateLimitExceeded(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	rl := &x.RateLimit{Limit: 10, Remaining: 0, Reset: time.Now().Add(30 * time.Second)}
+
+	var send = func(t *testing.T) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/sessions/whoami", nil)
+		r.Header.Set("Accept", "application/json")
+		x.WriteRateLimitExceeded(w, r, reg, rl, "Too many requests.")
+		return w
+	}
+
+	res := send(t)
+	assert.Equal(t, http.StatusTooManyRequests, res.Code)
+	assert.Equal(t, "30", res.Header().Get("Retry-After"))
+	assert.Equal(t, "10", res.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", res.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", res.Header().Get("RateLimit-Reset"))
+
+	body := res.Body.Bytes()
+	assert.EqualValues(t, http.StatusTooManyRequests, gjson.GetBytes(body, "error.code").Int(), "%s", body)
+	assert.Equal(t, "Too many requests.", gjson.GetBytes(body, "error.reason").String(), "%s", body)
+	assert.EqualValues(t, 10, gjson.GetBytes(body, "error.details.limit").Int(), "%s", body)
+	assert.EqualValues(t, 0, gjson.GetBytes(body, "error.details.remaining").Int(), "%s", body)
+	assert.EqualValues(t, 30, gjson.GetBytes(body, "error.details.reset").Int(), "%s", body)
+
+	t.Run("case=without rate limit headers", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyRateLimitHeaders, false)
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyRateLimitHeaders, true)
+		})
+
+		res := send(t)
+		assert.Equal(t, http.StatusTooManyRequests, res.Code)
+		assert.Equal(t, "30", res.Header().Get("Retry-After"))
+		assert.Empty(t, res.Header().Get("RateLimit-Limit"))
+	})
+}
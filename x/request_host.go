@@ -0,0 +1,34 @@
+package x
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// RequestHost returns the host name, without a port, the request was sent to. The `X-Forwarded-Host`
+// header is only used if the request was sent by one of the trusted proxies. If the header contains several
+// hosts, the last one is used because it was added by the proxy closest to this server.
+func RequestHost(r *http.Request, trustedProxies []*net.IPNet) string {
+	host := r.Host
+
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+
+	if ip := net.ParseIP(remote); ip != nil && containsIP(trustedProxies, ip) {
+		if forwarded := r.Header.Values("X-Forwarded-Host"); len(forwarded) > 0 {
+			hosts := strings.Split(forwarded[len(forwarded)-1], ",")
+			if h := strings.TrimSpace(hosts[len(hosts)-1]); len(h) > 0 {
+				host = h
+			}
+		}
+	}
+
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	return strings.ToLower(host)
+}
@@ -0,0 +1,40 @@
+package x
+
+import (
+	"net"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestHost(t *testing.T) {
+	_, proxies, err := net.ParseCIDR("10.0.0.0/8")
+	require.NoError(t, err)
+
+	for k, tc := range []struct {
+		d         string
+		remote    string
+		host      string
+		forwarded []string
+		expected  string
+	}{
+		{d: "host without port", remote: "192.0.2.1:1234", host: "Brand-A.example.org", expected: "brand-a.example.org"},
+		{d: "host with port", remote: "192.0.2.1:1234", host: "brand-a.example.org:4433", expected: "brand-a.example.org"},
+		{d: "untrusted proxy", remote: "192.0.2.1:1234", host: "kratos", forwarded: []string{"brand-a.example.org"}, expected: "kratos"},
+		{d: "trusted proxy", remote: "10.0.0.1:1234", host: "kratos", forwarded: []string{"brand-a.example.org:443"}, expected: "brand-a.example.org"},
+		{d: "trusted proxy with several hosts", remote: "10.0.0.1:1234", host: "kratos", forwarded: []string{"evil.example.org, brand-a.example.org"}, expected: "brand-a.example.org"},
+		{d: "trusted proxy without header", remote: "10.0.0.1:1234", host: "kratos", expected: "kratos"},
+	} {
+		t.Run("case="+tc.d, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tc.remote
+			r.Host = tc.host
+			for _, f := range tc.forwarded {
+				r.Header.Add("X-Forwarded-Host", f)
+			}
+			assert.Equal(t, tc.expected, RequestHost(r, []*net.IPNet{proxies}), "%d", k)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package x
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gobuffalo/pop/v5"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/kratos/driver/config"
+)
+
+type tenantContextKey int
+
+const tenantConnectionKey tenantContextKey = 0
+
+type TenantConnectionProvider interface {
+	// TenantConnection returns the connection to the database of the tenant. release must be
+	// called once the connection is no longer used.
+	TenantConnection(ctx context.Context, tenant string) (c *pop.Connection, release func(), err error)
+}
+
+// WithTenantConnection returns a context whose database operations use the tenant's connection.
+func WithTenantConnection(ctx context.Context, c *pop.Connection) context.Context {
+	return context.WithValue(ctx, tenantConnectionKey, c)
+}
+
+// TenantConnectionFromContext returns the tenant's connection set by WithTenantConnection.
+func TenantConnectionFromContext(ctx context.Context) (*pop.Connection, bool) {
+	c, ok := ctx.Value(tenantConnectionKey).(*pop.Connection)
+	return c, ok
+}
+
+// TenantResolver returns a middleware which determines the tenant of a request as configured
+// by `tenancy` and attaches the connection to the tenant's database to the request context.
+// Requests without a tenant are passed through unchanged while unknown tenants are rejected
+// with 404 Not Found.
+func TenantResolver(d interface {
+	config.Provider
+	WriterProvider
+	TenantConnectionProvider
+}) func(http.ResponseWriter, *http.Request, http.HandlerFunc) {
+	return func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		c := d.Config(r.Context())
+		if !c.TenancyEnabled() {
+			next(w, r)
+			return
+		}
+
+		tenant := RequestTenant(r, c.TenancySource(), c.TenancyHeader())
+		if tenant == "" {
+			next(w, r)
+			return
+		}
+
+		if _, ok := c.TenantDSN(tenant); !ok {
+			d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrNotFound.
+				WithReasonf("Tenant %s does not exist.", tenant)))
+			return
+		}
+
+		conn, release, err := d.TenantConnection(r.Context(), tenant)
+		if err != nil {
+			d.Writer().WriteError(w, r, err)
+			return
+		}
+		defer release()
+
+		next(w, r.WithContext(WithTenantConnection(r.Context(), conn)))
+	}
+}
+
+// RequestTenant returns the tenant of the request or an empty string. If source is `subdomain`,
+// the tenant is the first label of hosts such as `tenant.example.org`. Otherwise, it is taken
+// from the header.
+func RequestTenant(r *http.Request, source, header string) string {
+	if source != "subdomain" {
+		return strings.TrimSpace(r.Header.Get(header))
+	}
+
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	if net.ParseIP(host) != nil {
+		return ""
+	}
+
+	labels := strings.Split(host, ".")
+	if len(labels) < 3 {
+		return ""
+	}
+
+	return labels[0]
+}
@@ -0,0 +1,74 @@
+package x_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ory/kratos/driver/config"
+	"github.com/ory/kratos/internal"
+	"github.com/ory/kratos/x"
+)
+
+func TestRequestTenant(t *testing.T) {
+	for k, tc := range []struct {
+		d        string
+		source   string
+		host     string
+		header   string
+		expected string
+	}{
+		{d: "header", source: "header", header: "acme", expected: "acme"},
+		{d: "missing header", source: "header"},
+		{d: "subdomain", source: "subdomain", host: "acme.example.org", expected: "acme"},
+		{d: "subdomain with port", source: "subdomain", host: "acme.example.org:4433", expected: "acme"},
+		{d: "host without subdomain", source: "subdomain", host: "example.org"},
+		{d: "ip address", source: "subdomain", host: "127.0.0.1:4433"},
+	} {
+		t.Run("case="+tc.d, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			if tc.host != "" {
+				r.Host = tc.host
+			}
+			if tc.header != "" {
+				r.Header.Set("X-Tenant-ID", tc.header)
+			}
+			assert.Equal(t, tc.expected, x.RequestTenant(r, tc.source, "X-Tenant-ID"), "%d", k)
+		})
+	}
+}
+
+func TestTenantResolver(t *testing.T) {
+	conf, reg := internal.NewFastRegistryWithMocks(t)
+	resolver := x.TenantResolver(reg)
+
+	var status = func(tenant string) int {
+		r := httptest.NewRequest("GET", "/identities", nil)
+		r.Header.Set("X-Tenant-ID", tenant)
+		w := httptest.NewRecorder()
+		resolver(w, r, func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := x.TenantConnectionFromContext(r.Context()); ok {
+				w.WriteHeader(http.StatusAccepted)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+		return w.Code
+	}
+
+	t.Run("case=ignores tenants if not configured", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, status("acme"))
+	})
+
+	t.Run("case=rejects unknown tenants", func(t *testing.T) {
+		conf.MustSet(config.ViperKeyTenancyTenants, []map[string]interface{}{{"id": "acme", "dsn": config.DefaultSQLiteMemoryDSN}})
+		t.Cleanup(func() {
+			conf.MustSet(config.ViperKeyTenancyTenants, []map[string]interface{}{})
+		})
+
+		assert.Equal(t, http.StatusNotFound, status("unknown"))
+		assert.Equal(t, http.StatusNoContent, status(""), "requests without a tenant use the default database")
+	})
+}